@@ -6,7 +6,6 @@ import (
 	"fmt"
 
 	"github.com/TimurManjosov/goflagship/internal/cli"
-	"github.com/TimurManjosov/goflagship/internal/client"
 	"github.com/TimurManjosov/goflagship/internal/store"
 	"github.com/spf13/cobra"
 )
@@ -70,7 +69,7 @@ func runCreateCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create API client
-	apiClient := client.NewClient(envConfig.BaseURL, envConfig.APIKey)
+	apiClient := newAPIClient(envConfig.BaseURL, envConfig.APIKey)
 
 	// Prepare flag creation parameters
 	params := store.UpsertParams{
@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/TimurManjosov/goflagship/internal/cli"
-	"github.com/TimurManjosov/goflagship/internal/client"
 	"github.com/spf13/cobra"
 )
 
@@ -50,7 +49,7 @@ Examples:
 		}
 
 		// Create API client
-		c := client.NewClient(envCfg.BaseURL, envCfg.APIKey)
+		c := newAPIClient(envCfg.BaseURL, envCfg.APIKey)
 
 		// Delete flag
 		ctx := context.Background()
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/cli"
+	"github.com/TimurManjosov/goflagship/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	evalPreset string
+	evalUser   string
+	evalAttrs  []string
+)
+
+var evalCmd = &cobra.Command{
+	Use:   "eval [key]",
+	Short: "Evaluate flags for a user context",
+	Long: `Evaluate one flag, or all flags, for a user context as the server would
+serve it, with the reason for each result.
+
+The context is built from a context preset stored on the server (--preset),
+then --user and --attr, which replace the preset's values. Evaluation uses
+the server's default environment, or the environment of an SDK key given
+with --api-key.
+
+Examples:
+  flagship eval new_checkout --preset qa-premium-us
+  flagship eval --preset android-beta-tester --attr appVersion=5.2.0
+  flagship eval new_checkout --user user-123 --attr plan=premium --attr country=US`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var key string
+		if len(args) == 1 {
+			key = args[0]
+		}
+
+		evalCtx := client.EvaluationContext{ID: evalUser}
+		for _, attr := range evalAttrs {
+			name, value, ok := strings.Cut(attr, "=")
+			if !ok || name == "" {
+				return fmt.Errorf("invalid --attr %q: expected name=value", attr)
+			}
+			switch name {
+			case "id":
+				evalCtx.ID = value
+			case "email":
+				evalCtx.Email = value
+			case "country":
+				evalCtx.Country = value
+			default:
+				if evalCtx.Properties == nil {
+					evalCtx.Properties = make(map[string]any)
+				}
+				evalCtx.Properties[name] = value
+			}
+		}
+		if evalPreset == "" && evalCtx.ID == "" {
+			return fmt.Errorf("either --preset or --user is required")
+		}
+
+		envCfg, _, err := cli.GetEnvConfig(env, baseURL, apiKey)
+		if err != nil {
+			return fmt.Errorf("configuration error: %w", err)
+		}
+
+		c := newAPIClient(envCfg.BaseURL, envCfg.APIKey)
+		results, err := c.Evaluate(context.Background(), key, evalPreset, evalCtx)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate: %w", err)
+		}
+
+		if !quiet {
+			return cli.PrintEvaluationResults(results, cli.OutputFormat(format))
+		}
+		return nil
+	},
+}
+
+func init() {
+	evalCmd.Flags().StringVar(&evalPreset, "preset", "", "Context preset to evaluate for")
+	evalCmd.Flags().StringVar(&evalUser, "user", "", "User ID")
+	evalCmd.Flags().StringArrayVar(&evalAttrs, "attr", nil, "Context attribute as name=value (repeatable)")
+	rootCmd.AddCommand(evalCmd)
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 
 	"github.com/TimurManjosov/goflagship/internal/cli"
-	"github.com/TimurManjosov/goflagship/internal/client"
 	"github.com/TimurManjosov/goflagship/internal/store"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -39,7 +38,7 @@ Examples:
 		}
 
 		// Create API client
-		c := client.NewClient(envCfg.BaseURL, envCfg.APIKey)
+		c := newAPIClient(envCfg.BaseURL, envCfg.APIKey)
 
 		// List flags
 		ctx := context.Background()
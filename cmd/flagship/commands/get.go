@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/TimurManjosov/goflagship/internal/cli"
-	"github.com/TimurManjosov/goflagship/internal/client"
 	"github.com/spf13/cobra"
 )
 
@@ -28,7 +27,7 @@ Examples:
 		}
 
 		// Create API client
-		c := client.NewClient(envCfg.BaseURL, envCfg.APIKey)
+		c := newAPIClient(envCfg.BaseURL, envCfg.APIKey)
 
 		// Get flag
 		ctx := context.Background()
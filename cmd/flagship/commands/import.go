@@ -6,118 +6,198 @@ import (
 	"os"
 
 	"github.com/TimurManjosov/goflagship/internal/cli"
-	"github.com/TimurManjosov/goflagship/internal/client"
+	"github.com/TimurManjosov/goflagship/internal/importer"
 	"github.com/TimurManjosov/goflagship/internal/store"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
 var (
-	importDryRun bool
-	importForce  bool
+	importDryRun    bool
+	importForce     bool
+	importFrom      string
+	importAPIToken  string
+	importProject   string
+	importSourceURL string
+	importSourceEnv string
 )
 
 var importCmd = &cobra.Command{
-	Use:   "import <file>",
-	Short: "Import flags from a file",
-	Long: `Import flags from a YAML or JSON file.
+	Use:   "import [file]",
+	Short: "Import flags from a file or another flag service",
+	Long: `Import flags from a YAML or JSON file, or directly from LaunchDarkly or Unleash.
+
+When importing from another service, flags, targeting rules, segments and
+percentage rollouts are converted to goflagship equivalents. Anything that
+cannot be represented is listed in a mapping report; review it with --dry-run
+before importing.
 
 Examples:
   flagship import flags.yaml --env prod
   flagship import flags.yaml --env staging --dry-run
-  flagship import flags.yaml --env prod --force`,
-	Args: cobra.ExactArgs(1),
+  flagship import flags.yaml --env prod --force
+  flagship import --from launchdarkly --api-token $LD_TOKEN --project web --source-env production --env prod --dry-run
+  flagship import --from unleash --source-url https://unleash.example.com --api-token $UNLEASH_TOKEN --env prod`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		filename := args[0]
-
-		// Read file
-		data, err := os.ReadFile(filename)
-		if err != nil {
-			return fmt.Errorf("failed to read file: %w", err)
-		}
-
-		// Parse file
-		var importData ExportFormat
-		if err := yaml.Unmarshal(data, &importData); err != nil {
-			return fmt.Errorf("failed to parse file: %w", err)
+		var flags []store.Flag
+		switch importFrom {
+		case "", "file":
+			if len(args) != 1 {
+				return fmt.Errorf("a file is required unless --from is set")
+			}
+			fileFlags, err := readImportFile(args[0])
+			if err != nil {
+				return err
+			}
+			flags = fileFlags
+		case "launchdarkly", "unleash":
+			if len(args) != 0 {
+				return fmt.Errorf("a file cannot be combined with --from %s", importFrom)
+			}
+			result, err := fetchFromSource(cmd.Context())
+			if err != nil {
+				return err
+			}
+			printMappingReport(result)
+			flags = result.Flags
+		default:
+			return fmt.Errorf("unsupported --from %q (expected launchdarkly or unleash)", importFrom)
 		}
 
 		// Validate flags
-		if len(importData.Flags) == 0 {
-			return fmt.Errorf("no flags found in file")
+		if len(flags) == 0 {
+			return fmt.Errorf("no flags found to import")
 		}
 
 		if verbose {
-			fmt.Printf("Found %d flag(s) to import\n", len(importData.Flags))
+			fmt.Printf("Found %d flag(s) to import\n", len(flags))
 		}
 
 		// Dry run mode - just validate and show what would be imported
 		if importDryRun {
 			fmt.Println("Dry run mode - the following flags would be imported:")
-			for _, flag := range importData.Flags {
-				fmt.Printf("  - %s (enabled: %v, rollout: %d%%, env: %s)\n",
-					flag.Key, flag.Enabled, flag.Rollout, flag.Env)
+			for _, flag := range flags {
+				fmt.Printf("  - %s (enabled: %v, rollout: %d%%, rules: %d, env: %s)\n",
+					flag.Key, flag.Enabled, flag.Rollout, len(flag.TargetingRules), flag.Env)
 			}
 			return nil
 		}
 
-		// Get environment configuration
-		envCfg, effectiveEnv, err := cli.GetEnvConfig(env, baseURL, apiKey)
-		if err != nil {
-			return fmt.Errorf("configuration error: %w", err)
-		}
-
-		// Create API client
-		c := client.NewClient(envCfg.BaseURL, envCfg.APIKey)
-		ctx := context.Background()
+		return importFlags(flags)
+	},
+}
 
-		// Import flags
-		successCount := 0
-		errorCount := 0
+// readImportFile parses a file in the `flagship export` format.
+func readImportFile(filename string) ([]store.Flag, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var importData ExportFormat
+	if err := yaml.Unmarshal(data, &importData); err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	return importData.Flags, nil
+}
 
-		for _, flag := range importData.Flags {
-			// Use the environment from the flag or override with --env flag
-			targetEnv := flag.Env
-			if effectiveEnv != "" {
-				targetEnv = effectiveEnv
-			}
+// fetchFromSource converts flags from the service selected with --from.
+func fetchFromSource(ctx context.Context) (*importer.Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if importFrom == "launchdarkly" {
+		return importer.FromLaunchDarkly(ctx, importer.LaunchDarklyOptions{
+			BaseURL:     importSourceURL,
+			APIToken:    importAPIToken,
+			Project:     importProject,
+			Environment: importSourceEnv,
+			TargetEnv:   env,
+		})
+	}
+	return importer.FromUnleash(ctx, importer.UnleashOptions{
+		BaseURL:   importSourceURL,
+		APIToken:  importAPIToken,
+		Project:   importProject,
+		TargetEnv: env,
+	})
+}
 
-			params := store.UpsertParams{
-				Key:         flag.Key,
-				Description: flag.Description,
-				Enabled:     flag.Enabled,
-				Rollout:     flag.Rollout,
-				Expression:  flag.Expression,
-				Config:      flag.Config,
-				Variants:    flag.Variants,
-				Env:         targetEnv,
-			}
+// printMappingReport lists source features that were changed or dropped.
+func printMappingReport(result *importer.Result) {
+	if quiet {
+		return
+	}
+	if len(result.Notes) == 0 {
+		fmt.Printf("Mapping report: all %d flag(s) converted without changes\n", len(result.Flags))
+		return
+	}
+	fmt.Printf("Mapping report (%d note(s)):\n", len(result.Notes))
+	for _, n := range result.Notes {
+		fmt.Printf("  - %s: %s\n", n.Flag, n.Message)
+	}
+}
 
-			if verbose {
-				fmt.Printf("Importing flag: %s\n", flag.Key)
-			}
+// importFlags creates or updates each flag through the API.
+func importFlags(flags []store.Flag) error {
+	// Get environment configuration
+	envCfg, effectiveEnv, err := cli.GetEnvConfig(env, baseURL, apiKey)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	// Create API client
+	c := newAPIClient(envCfg.BaseURL, envCfg.APIKey)
+	ctx := context.Background()
+
+	// Import flags
+	successCount := 0
+	errorCount := 0
+
+	for _, flag := range flags {
+		// Use the environment from the flag or override with --env flag
+		targetEnv := flag.Env
+		if effectiveEnv != "" {
+			targetEnv = effectiveEnv
+		}
 
-			if err := c.CreateFlag(ctx, params); err != nil {
-				errorCount++
-				fmt.Fprintf(os.Stderr, "Failed to import flag '%s': %v\n", flag.Key, err)
-				if !importForce {
-					return fmt.Errorf("import failed, use --force to continue on errors")
-				}
-			} else {
-				successCount++
-			}
+		params := store.UpsertParams{
+			Key:            flag.Key,
+			Description:    flag.Description,
+			Enabled:        flag.Enabled,
+			Rollout:        flag.Rollout,
+			Expression:     flag.Expression,
+			Config:         flag.Config,
+			TargetingRules: flag.TargetingRules,
+			Variants:       flag.Variants,
+			Env:            targetEnv,
 		}
 
-		if !quiet {
-			fmt.Printf("Import complete: %d succeeded, %d failed\n", successCount, errorCount)
+		if verbose {
+			fmt.Printf("Importing flag: %s\n", flag.Key)
 		}
 
-		if errorCount > 0 && !importForce {
-			return fmt.Errorf("import completed with errors")
+		if err := c.CreateFlag(ctx, params); err != nil {
+			errorCount++
+			fmt.Fprintf(os.Stderr, "Failed to import flag '%s': %v\n", flag.Key, err)
+			if !importForce {
+				return fmt.Errorf("import failed, use --force to continue on errors")
+			}
+		} else {
+			successCount++
 		}
+	}
 
-		return nil
-	},
+	if !quiet {
+		fmt.Printf("Import complete: %d succeeded, %d failed\n", successCount, errorCount)
+	}
+
+	if errorCount > 0 && !importForce {
+		return fmt.Errorf("import completed with errors")
+	}
+
+	return nil
 }
 
 func init() {
@@ -125,4 +205,9 @@ func init() {
 
 	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Validate without importing")
 	importCmd.Flags().BoolVar(&importForce, "force", false, "Continue on errors")
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Import from another service (launchdarkly, unleash)")
+	importCmd.Flags().StringVar(&importAPIToken, "api-token", "", "API token for the source service")
+	importCmd.Flags().StringVar(&importProject, "project", "", "Source project key (required for launchdarkly)")
+	importCmd.Flags().StringVar(&importSourceURL, "source-url", "", "Source API URL (required for unleash)")
+	importCmd.Flags().StringVar(&importSourceEnv, "source-env", "production", "LaunchDarkly environment to read targeting from")
 }
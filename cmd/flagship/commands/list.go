@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/TimurManjosov/goflagship/internal/cli"
-	"github.com/TimurManjosov/goflagship/internal/client"
 	"github.com/TimurManjosov/goflagship/internal/store"
 	"github.com/spf13/cobra"
 )
@@ -31,7 +30,7 @@ Examples:
 		}
 
 		// Create API client
-		c := client.NewClient(envCfg.BaseURL, envCfg.APIKey)
+		c := newAPIClient(envCfg.BaseURL, envCfg.APIKey)
 
 		// List flags
 		ctx := context.Background()
@@ -1,7 +1,11 @@
 package commands
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
+
+	"github.com/TimurManjosov/goflagship/internal/client"
 )
 
 var (
@@ -12,6 +16,8 @@ var (
 	format  string
 	quiet   bool
 	verbose bool
+	timeout time.Duration
+	retries int
 )
 
 // rootCmd represents the base command
@@ -44,4 +50,15 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&format, "format", "table", "Output format (table, json, yaml)")
 	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "Suppress output")
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Verbose output")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for each API request attempt")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 2, "Retries for failed idempotent API requests")
+}
+
+// newAPIClient creates an API client honouring the global --timeout and
+// --retries flags.
+func newAPIClient(baseURL, apiKey string) *client.Client {
+	c := client.NewClient(baseURL, apiKey)
+	c.Timeout = timeout
+	c.Retry.MaxAttempts = retries + 1
+	return c
 }
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TimurManjosov/goflagship/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+var rotateEncryptionKeyCmd = &cobra.Command{
+	Use:   "rotate-encryption-key",
+	Short: "Re-encrypt stored secrets with the primary encryption key",
+	Long: `Re-encrypt webhook secrets, webhook headers and flag configs stored on the
+server with the primary (first) key in the server's ENCRYPTION_KEYS. Values
+written in plaintext before encryption was enabled are encrypted as well.
+
+To rotate the key: add the new key first in ENCRYPTION_KEYS, keeping the old
+one, restart the server, run this command, then remove the old key.
+Requires a superadmin API key.
+
+Examples:
+  flagship rotate-encryption-key --env prod`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envCfg, _, err := cli.GetEnvConfig(env, baseURL, apiKey)
+		if err != nil {
+			return fmt.Errorf("configuration error: %w", err)
+		}
+
+		c := newAPIClient(envCfg.BaseURL, envCfg.APIKey)
+		result, err := c.RotateEncryption(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to rotate encryption key: %w", err)
+		}
+
+		if !quiet {
+			fmt.Printf("Re-encrypted %d webhook secret(s), the headers of %d webhook(s) and %d flag config(s) with key '%s'\n",
+				result.WebhookSecrets, result.WebhookHeaders, result.FlagConfigs, result.KeyID)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rotateEncryptionKeyCmd)
+}
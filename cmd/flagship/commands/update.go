@@ -6,7 +6,6 @@ import (
 	"fmt"
 
 	"github.com/TimurManjosov/goflagship/internal/cli"
-	"github.com/TimurManjosov/goflagship/internal/client"
 	"github.com/TimurManjosov/goflagship/internal/store"
 	"github.com/spf13/cobra"
 )
@@ -38,7 +37,7 @@ Examples:
 		}
 
 		// Create API client
-		c := client.NewClient(envCfg.BaseURL, envCfg.APIKey)
+		c := newAPIClient(envCfg.BaseURL, envCfg.APIKey)
 
 		// First, get the existing flag to preserve values
 		ctx := context.Background()
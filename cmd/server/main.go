@@ -2,16 +2,33 @@
 //
 // Application Startup Flow:
 //
-//  1. Load configuration from environment variables (config.Load)
+//  1. Load configuration from environment variables and, if SECRETS_BACKEND is
+//     set, Vault or AWS Secrets Manager (config.Load)
 //  2. Initialize Prometheus metrics registry (telemetry.Init)
 //  3. Set rollout salt for deterministic user bucketing (snapshot.SetRolloutSalt)
-//  4. Create database store - Postgres or in-memory (store.NewStore)
+//  4. Create database store - Postgres, in-memory (store.NewStore) or read-only
+//     flag files (store.NewFileStore)
 //  5. Load initial flag snapshot from database (store.GetAllFlags)
 //  6. Build and store snapshot in memory (snapshot.BuildFromFlags, snapshot.Update)
-//  7. Start API server on :8080 (handles client requests - evaluations, admin ops)
-//  8. Start metrics/pprof server on :9090 (for observability - /metrics, /debug/pprof)
-//  9. Wait for SIGINT/SIGTERM for graceful shutdown
-//  10. Shutdown: close connections, drain audit queue, stop webhook dispatcher
+//  7. Create missing SDK keys and the first superadmin key if
+//     BOOTSTRAP_ENVIRONMENTS or BOOTSTRAP_SUPERADMIN is set (api.Server.Bootstrap),
+//     then print a setup token if no admin credentials exist (api.Server.EnableSetup)
+//  8. Start git sync loop if GITSYNC_REPO_URL is set (gitsync.Syncer.Run), the
+//     flag file watcher for STORE_TYPE=file (store.FileStore.Watch),
+//     the ephemeral environment reaper (api.Server.RunEphemeralEnvironmentReaper),
+//     the stale flag cleanup report (api.Server.RunCleanupReports), the
+//     evaluation time flush (api.Server.RunEvaluationFlush), the flag
+//     expiry job (api.Server.RunFlagExpiry), the trash purge and webhook
+//     delivery pruning (api.Server.RunTrashPurge, RunWebhookDeliveryPruning),
+//     the summary report (api.Server.RunSummaryReports), the API key expiry
+//     and idle checks (api.Server.RunKeyExpiryChecks, RunKeyIdleChecks) and
+//     the secrets refresh loop (secrets.Source.Run), each supervised and
+//     restarted after a panic (api.Server.RunWorker, reported by /readyz and
+//     the worker_* metrics)
+//  9. Start API server on :8080 (handles client requests - evaluations, admin ops)
+//  10. Start metrics/pprof server on :9090 (for observability - /metrics, /debug/pprof)
+//  11. Wait for SIGINT/SIGTERM for graceful shutdown
+//  12. Shutdown: stop git sync and the background jobs, flush evaluation times, close connections, drain audit queue, stop webhook dispatcher
 //
 // The server runs two HTTP servers concurrently:
 //   - API Server (:8080): Client-facing REST API and SSE streaming
@@ -29,16 +46,25 @@ import (
 	"log"
 	"net/http"
 	_ "net/http/pprof" // <-- registers /debug/pprof/* on DefaultServeMux
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/api"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/cluster"
 	"github.com/TimurManjosov/goflagship/internal/config"
+	"github.com/TimurManjosov/goflagship/internal/db"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+	"github.com/TimurManjosov/goflagship/internal/gitsync"
+	"github.com/TimurManjosov/goflagship/internal/report"
+	"github.com/TimurManjosov/goflagship/internal/secrets"
 	"github.com/TimurManjosov/goflagship/internal/snapshot"
 	"github.com/TimurManjosov/goflagship/internal/store"
 	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -67,11 +93,19 @@ func main() {
 	ctx := context.Background()
 
 	// Create store based on configuration
-	st, err := store.NewStore(ctx, cfg.StoreType, cfg.DatabaseDSN)
+	var st store.Store
+	var fileStore *store.FileStore
+	if cfg.StoreType == "file" {
+		fileStore, err = store.NewFileStore(cfg.FileStoreDir, cfg.Env)
+		st = fileStore
+	} else {
+		st, err = store.NewStoreWithPool(ctx, cfg.StoreType, cfg.DatabaseDSN, cfg.DBPool())
+	}
 	if err != nil {
 		log.Fatalf("failed to initialize store (type=%s): %v", cfg.StoreType, err)
 	}
 	defer st.Close()
+	pgStore, _ := st.(*store.PostgresStore)
 
 	// For postgres stores, verify database connectivity before proceeding
 	if cfg.StoreType == "postgres" {
@@ -83,6 +117,19 @@ func main() {
 			log.Fatalf("database connectivity check failed: %v\n\nPlease verify:\n  - PostgreSQL is running\n  - DB_DSN is correct: %s\n  - Database exists and migrations are applied", err, cfg.DatabaseDSN)
 		}
 		log.Printf("[server] database connectivity verified")
+		if pgStore != nil {
+			telemetry.RegisterPoolStats(pgStore.PoolStat)
+		}
+	}
+
+	// ---- Flag read cache (optional) ----
+	if cfg.StoreCacheTTL > 0 {
+		if pgStore != nil {
+			st = store.NewCachedStore(pgStore, cfg.StoreCacheTTL)
+			log.Printf("[server] flag read cache enabled: ttl=%s", cfg.StoreCacheTTL)
+		} else {
+			log.Printf("[server] WARNING: STORE_CACHE_TTL is ignored with STORE_TYPE=%s", cfg.StoreType)
+		}
 	}
 
 	// Load initial flag snapshot into memory
@@ -93,13 +140,268 @@ func main() {
 	currentSnapshot := snapshot.BuildFromFlags(flags)
 	snapshot.Update(currentSnapshot)
 	telemetry.SnapshotFlags.Set(float64(len(currentSnapshot.Flags)))
+	telemetry.SnapshotLastRebuild.WithLabelValues(cfg.Env).SetToCurrentTime()
 	log.Printf("[server] snapshot loaded: flags=%d etag=%s store=%s", 
 		len(currentSnapshot.Flags), currentSnapshot.ETag, cfg.StoreType)
 
+	server := api.NewServer(st, cfg.Env, cfg.AdminAPIKey)
+	telemetry.RegisterWorkerStats(server.WorkerStatuses)
+	if err := server.LoadShadowFlags(ctx, cfg.Env); err != nil {
+		log.Printf("[server] WARNING: failed to load shadow flag versions: %v", err)
+	}
+	if err := server.LoadUserOverrides(ctx, cfg.Env); err != nil {
+		log.Printf("[server] WARNING: failed to load user overrides: %v", err)
+	}
+	server.SetRequireSDKKey(cfg.RequireSDKKey)
+	server.SetEvaluationAuditSampling(cfg.AuditEvalSampleRate)
+	if cfg.AuditQueuePath != "" {
+		if err := server.SetAuditQueue(cfg.AuditQueuePath); err != nil {
+			log.Fatalf("audit queue: %v", err)
+		}
+		log.Printf("[server] durable audit queue enabled: path=%s", cfg.AuditQueuePath)
+	}
+	server.SetLimits(api.Limits{
+		MaxFlags:        cfg.LimitMaxFlags,
+		MaxRulesPerFlag: cfg.LimitMaxRulesPerFlag,
+		MaxVariants:     cfg.LimitMaxVariants,
+		MaxWebhooks:     cfg.LimitMaxWebhooks,
+	})
+	server.SetContextLimits(api.ContextLimits{
+		MaxAttributes: cfg.ContextMaxAttributes,
+		MaxValueBytes: cfg.ContextMaxValueBytes,
+		MaxDepth:      cfg.ContextMaxDepth,
+	})
+	server.SetTenantRateLimit(cfg.TenantRateLimit)
+	server.SetEvaluationRateLimits(api.EvaluationRateLimits{
+		Anonymous: cfg.EvalRateLimitIP,
+		SDKKey:    cfg.EvalRateLimitSDKKey,
+	})
+	server.SetTimeouts(api.Timeouts{
+		Default:  cfg.HTTPTimeout,
+		Evaluate: cfg.HTTPTimeoutEvaluate,
+		Bulk:     cfg.HTTPTimeoutBulk,
+	})
+	server.SetAuthLockout(auth.LockoutConfig{
+		Threshold: cfg.AuthLockoutThreshold,
+		BaseDelay: cfg.AuthLockoutBase,
+		MaxDelay:  cfg.AuthLockoutMax,
+	})
+
+	// Encryption at rest; ENCRYPTION_KEYS was checked by cfg.Validate
+	keyring, err := encryption.ParseKeys(cfg.EncryptionKeys)
+	if err != nil {
+		log.Fatalf("encryption keys: %v", err)
+	}
+	server.SetEncryption(keyring, cfg.EncryptFlagConfig)
+	if keyring != nil {
+		log.Printf("[server] encryption at rest enabled: primary key=%s flag configs=%v", keyring.PrimaryKeyID(), cfg.EncryptFlagConfig)
+	}
+
+	// Step-up authentication; STEP_UP_TOTP_SECRET was checked by cfg.Validate
+	if cfg.StepUpTOTPSecret != "" {
+		totp, err := auth.NewTOTP(cfg.StepUpTOTPSecret)
+		if err != nil {
+			log.Fatalf("step-up totp secret: %v", err)
+		}
+		server.SetStepUpTOTP(totp)
+		log.Printf("[server] step-up authentication enabled for destructive superadmin actions")
+	}
+
+	// Webhook targets and transport; WEBHOOK_* was checked by cfg.Validate
+	webhookPolicy, err := webhook.NewURLPolicy(cfg.WebhookURLPolicy())
+	if err != nil {
+		log.Fatalf("webhook url policy: %v", err)
+	}
+	server.SetWebhookURLPolicy(webhookPolicy)
+	if err := server.SetWebhookTransport(cfg.WebhookTransport()); err != nil {
+		log.Fatalf("webhook transport: %v", err)
+	}
+	if proxyURL, _ := url.Parse(cfg.WebhookProxyURL); cfg.WebhookProxyURL != "" && proxyURL != nil {
+		log.Printf("[server] webhook deliveries go through proxy %s", proxyURL.Redacted())
+	}
+	server.SetWebhookCircuitBreaker(webhook.BreakerConfig{
+		Threshold: cfg.WebhookBreakerFails,
+		Cooldown:  cfg.WebhookBreakerCool,
+	})
+	server.SetWebhookWorkers(cfg.WebhookWorkers)
+	server.SetWebhookDeliveryRetention(cfg.WebhookRetention)
+	server.SetAttributeEnforcement(api.AttributeEnforcement(cfg.AttributeEnforcement))
+
+	// ---- First-start bootstrap (optional) ----
+	if envs := cfg.BootstrapEnvironments(); len(envs) > 0 || cfg.BootstrapSuperadmin {
+		created, err := server.Bootstrap(ctx, api.BootstrapConfig{
+			Environments:   envs,
+			SeedSuperadmin: cfg.BootstrapSuperadmin,
+		})
+		// Keys are only stored hashed: print whatever was created, even on error.
+		for _, env := range envs {
+			if key, ok := created.SDKKeys[env]; ok {
+				log.Printf("[bootstrap] created SDK key for env=%s (shown once): %s", env, key)
+			}
+		}
+		if created.SuperadminKey != "" {
+			log.Printf("[bootstrap] created superadmin API key (shown once): %s", created.SuperadminKey)
+		}
+		if err != nil {
+			log.Printf("[bootstrap] WARNING: bootstrap incomplete: %v", err)
+		}
+	}
+
+	// ---- First-run setup ----
+	// Without ADMIN_API_KEY and API keys nobody can administer the server;
+	// the first superadmin key is claimed with the token printed here.
+	if token, err := server.EnableSetup(ctx); err != nil {
+		log.Printf("[setup] WARNING: could not check for existing API keys: %v", err)
+	} else if token != "" {
+		log.Printf("[setup] no admin credentials exist; create the first superadmin key with POST /v1/setup {\"token\": %q}", token)
+	}
+
+	// Background jobs decide writes from what they read, so they never read
+	// from the replica.
+	syncCtx, stopSync := context.WithCancel(store.ReadPrimary(ctx))
+	defer stopSync()
+
+	// ---- Read replica (optional) ----
+	if cfg.DBReplicaDSN != "" {
+		if pgStore != nil {
+			replicaPool, err := db.NewPool(ctx, cfg.DBReplicaDSN, cfg.DBPool())
+			if err != nil {
+				log.Fatalf("read replica: %v", err)
+			}
+			pgStore.SetReplica(replicaPool, cfg.DBReplicaMaxLag)
+			go server.RunWorker(syncCtx, "replica_monitor", func(ctx context.Context) {
+				pgStore.MonitorReplica(ctx, store.ReplicaCheckInterval)
+			})
+			log.Printf("[server] read replica enabled: max_lag=%s", cfg.DBReplicaMaxLag)
+		} else {
+			log.Printf("[server] WARNING: DB_REPLICA_DSN is ignored with STORE_TYPE=%s", cfg.StoreType)
+		}
+	}
+
+	// ---- Git sync (optional) ----
+	if cfg.GitSyncRepoURL != "" {
+		gs := gitsync.New(gitsync.Config{
+			RepoURL:  cfg.GitSyncRepoURL,
+			Branch:   cfg.GitSyncBranch,
+			Path:     cfg.GitSyncPath,
+			Env:      cfg.Env,
+			Interval: cfg.GitSyncInterval,
+		}, st, server.RebuildSnapshot)
+		server.SetGitSync(gs, cfg.GitSyncWebhookSecret)
+		go server.RunWorker(syncCtx, "gitsync", gs.Run)
+		log.Printf("[server] git sync enabled: branch=%s path=%s interval=%s",
+			cfg.GitSyncBranch, cfg.GitSyncPath, cfg.GitSyncInterval)
+	}
+
+	// ---- Flag file watcher (STORE_TYPE=file) ----
+	if fileStore != nil {
+		go server.RunWorker(syncCtx, "file_watcher", func(ctx context.Context) {
+			if err := fileStore.Watch(ctx, server.RebuildSnapshot); err != nil {
+				log.Printf("[filestore] WARNING: flag file changes are not picked up until restart: %v", err)
+			}
+		})
+		log.Printf("[server] serving read-only flags from %s, reloaded on change", fileStore.Dir())
+	}
+
+	// ---- Cluster-wide snapshot updates (optional) ----
+	if cfg.ClusterRedisURL != "" {
+		transport, err := cluster.NewRedisTransport(cfg.ClusterRedisURL, cfg.ClusterRedisChannel)
+		if err != nil {
+			log.Fatalf("cluster: %v", err)
+		}
+		bridge := cluster.New(transport, server.ApplySnapshotUpdate)
+		defer bridge.Close()
+		server.SetSnapshotPublisher(bridge)
+		go server.RunWorker(syncCtx, "cluster", func(ctx context.Context) {
+			if err := bridge.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("[cluster] WARNING: snapshot updates from other replicas are not received: %v", err)
+			}
+		})
+		log.Printf("[server] cluster snapshot updates enabled: channel=%s", cfg.ClusterRedisChannel)
+	}
+
+	// ---- Secrets backend refresh (optional) ----
+	// DB_DSN and ROLLOUT_SALT are only read at startup: changing them live
+	// would need a new connection pool or re-bucket every user.
+	if src := cfg.Secrets; src != nil {
+		server.SetWebhookSecretLookup(src.WebhookSecret)
+		go server.RunWorker(syncCtx, "secrets_refresh", func(ctx context.Context) {
+			src.Run(ctx, cfg.SecretsRefresh, func() {
+				if key, ok := src.Get(secrets.AdminAPIKey); ok {
+					server.SetAdminAPIKey(key)
+				}
+			})
+		})
+		log.Printf("[server] secrets backend enabled: backend=%s refresh=%s", src.Name(), cfg.SecretsRefresh)
+	}
+
+	// ---- Ephemeral environment reaper ----
+	go server.RunWorker(syncCtx, "ephemeral_reaper", func(ctx context.Context) {
+		server.RunEphemeralEnvironmentReaper(ctx, cfg.EphemeralEnvInterval)
+	})
+
+	// ---- Stale flag cleanup report ----
+	server.SetCleanupPolicy(api.CleanupPolicy{
+		StaleAfter:  time.Duration(cfg.CleanupStaleDays) * 24 * time.Hour,
+		AutoArchive: cfg.CleanupAutoArchive,
+		Grace:       time.Duration(cfg.CleanupGraceDays) * 24 * time.Hour,
+	})
+	go server.RunWorker(syncCtx, "cleanup_reports", func(ctx context.Context) {
+		server.RunCleanupReports(ctx, cfg.CleanupInterval)
+	})
+
+	// ---- Last evaluation times ----
+	go server.RunWorker(syncCtx, "evaluation_flush", func(ctx context.Context) {
+		server.RunEvaluationFlush(ctx, cfg.EvalFlushInterval)
+	})
+
+	// ---- Flag expiry ----
+	server.SetFlagExpiryAction(api.FlagExpiryAction(cfg.FlagExpiryAction))
+	go server.RunWorker(syncCtx, "flag_expiry", func(ctx context.Context) {
+		server.RunFlagExpiry(ctx, cfg.FlagExpiryInterval)
+	})
+
+	// ---- Trash purge ----
+	server.SetTrashRetention(cfg.TrashRetention)
+	go server.RunWorker(syncCtx, "trash_purge", server.RunTrashPurge)
+
+	// ---- Webhook delivery pruning ----
+	go server.RunWorker(syncCtx, "webhook_delivery_pruning", server.RunWebhookDeliveryPruning)
+
+	// ---- Summary report ----
+	reportTemplate, err := report.LoadTemplate(cfg.ReportTemplate)
+	if err != nil {
+		log.Fatalf("report template: %v", err)
+	}
+	summaryReport := api.SummaryReportConfig{Template: reportTemplate}
+	if cfg.ReportEmailTo != "" || cfg.ReportSMTPAddr != "" {
+		if summaryReport.Mailer, err = report.NewMailer(cfg.ReportMail()); err != nil {
+			log.Fatalf("report email: %v", err)
+		}
+	}
+	server.SetSummaryReport(summaryReport)
+	go server.RunWorker(syncCtx, "summary_reports", func(ctx context.Context) {
+		server.RunSummaryReports(ctx, cfg.ReportInterval)
+	})
+
+	// ---- API key expiry warnings ----
+	server.SetKeyExpiry(api.KeyExpiryConfig{Mailer: summaryReport.Mailer})
+	go server.RunWorker(syncCtx, "key_expiry_checks", func(ctx context.Context) {
+		server.RunKeyExpiryChecks(ctx, cfg.KeyExpiryInterval)
+	})
+
+	// ---- Idle API key policy ----
+	server.SetKeyIdlePolicy(api.KeyIdlePolicy{
+		IdleAfter:     time.Duration(cfg.KeyIdleDays) * 24 * time.Hour,
+		Disable:       cfg.KeyIdleDisable,
+		ReenableGrace: time.Duration(cfg.KeyReenableGraceDays) * 24 * time.Hour,
+	})
+	go server.RunWorker(syncCtx, "key_idle_checks", server.RunKeyIdleChecks)
+
 	// ---- API server (:8080) ----
 	apiSrv := &http.Server{
 		Addr:         cfg.HTTPAddr,
-		Handler:      api.NewServer(st, cfg.Env, cfg.AdminAPIKey).Router(),
+		Handler:      server.Router(),
 		ReadTimeout:  3 * time.Second,
 		WriteTimeout: 0, // keep SSE connections alive
 		IdleTimeout:  60 * time.Second,
@@ -137,12 +439,18 @@ func main() {
 	<-shutdownSignal
 
 	log.Println("[server] shutdown signal received, stopping servers...")
+	stopSync()
 	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelShutdown()
 
 	if err := apiSrv.Shutdown(shutdownCtx); err != nil {
 		log.Printf("[server] error during API server shutdown: %v", err)
 	}
+	if cfg.EvalFlushInterval > 0 {
+		if err := server.FlushEvaluations(shutdownCtx); err != nil {
+			log.Printf("[server] error flushing evaluation times: %v", err)
+		}
+	}
 	if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
 		log.Printf("[server] error during metrics server shutdown: %v", err)
 	}
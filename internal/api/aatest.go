@@ -0,0 +1,228 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/rollout"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+)
+
+// An A/A test checks that the hashing and assignment pipeline is not biased
+// before an experiment's results are trusted:
+//
+//	GET /v1/flags/{key}/aa-test?env=&users=   run the checks (admin+)
+//
+// Each check compares counts with the shares the flag is configured for
+// using a chi-square test and fails below aaTestAlpha:
+//
+//   - sample_ratio: variants served to real traffic, from the sampled
+//     evaluations of insights.go (sample ratio mismatch)
+//   - assignment: rollout and variants of simulated users run through
+//     evaluation with the live salt, ignoring targeting
+//   - bucketing: raw hash buckets of the simulated users in 10 groups
+//
+// A check without enough data or nothing to split is skipped.
+
+const (
+	// aaTestAlpha is the p-value below which a check fails. It is strict
+	// because a false alarm stops an experiment.
+	aaTestAlpha = 0.001
+
+	defaultAATestUsers = 10000
+	maxAATestUsers     = 100000
+
+	// minSampleRatioSamples is the fewest sampled evaluations the sample
+	// ratio check runs on.
+	minSampleRatioSamples = 100
+)
+
+const (
+	aaCheckPass    = "pass"
+	aaCheckFail    = "fail"
+	aaCheckSkipped = "skipped"
+)
+
+type aaCheck struct {
+	Status    string             `json:"status"`
+	Reason    string             `json:"reason,omitempty"` // why the check was skipped or failed
+	Samples   int                `json:"samples"`
+	Observed  map[string]int     `json:"observed,omitempty"`
+	Expected  map[string]float64 `json:"expected,omitempty"` // share, 0-1
+	ChiSquare float64            `json:"chi_square"`
+	PValue    float64            `json:"p_value"`
+}
+
+type aaTestResponse struct {
+	Key         string  `json:"key"`
+	Env         string  `json:"env"`
+	Passed      bool    `json:"passed"` // no check failed
+	Alpha       float64 `json:"alpha"`
+	SampleRatio aaCheck `json:"sample_ratio"`
+	Assignment  aaCheck `json:"assignment"`
+	Bucketing   aaCheck `json:"bucketing"`
+}
+
+// handleAATest handles GET /v1/flags/{id}/aa-test.
+func (s *Server) handleAATest(w http.ResponseWriter, r *http.Request) {
+	users := defaultAATestUsers
+	if raw := r.URL.Query().Get("users"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxAATestUsers {
+			ValidationError(w, r, "Invalid users", map[string]string{
+				"users": fmt.Sprintf("must be between 1 and %d", maxAATestUsers),
+			})
+			return
+		}
+		users = n
+	}
+	env, flag, ok := s.routeFlag(w, r)
+	if !ok {
+		return
+	}
+	snap := s.snapshotForEnv(env)
+	view, ok := snap.Flags[flag.Key]
+	if !ok {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+
+	resp := aaTestResponse{
+		Key:         flag.Key,
+		Env:         env,
+		Alpha:       aaTestAlpha,
+		SampleRatio: sampleRatioCheck(view, s.insights.summary(env, flag.Key)),
+		Assignment:  assignmentCheck(view, snap.RolloutSalt, users),
+		Bucketing:   bucketingCheck(view.Key, snap.RolloutSalt, users),
+	}
+	resp.Passed = resp.SampleRatio.Status != aaCheckFail &&
+		resp.Assignment.Status != aaCheckFail &&
+		resp.Bucketing.Status != aaCheckFail
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// sampleRatioCheck compares the variants of sampled evaluations with the
+// variant weights. The on/off share is not checked as it depends on
+// targeting.
+func sampleRatioCheck(flag snapshot.FlagView, insights insightsResponse) aaCheck {
+	if len(flag.Variants) < 2 {
+		return aaCheck{Status: aaCheckSkipped, Reason: "flag has fewer than two variants"}
+	}
+	expected := make(map[string]float64, len(flag.Variants))
+	for _, v := range flag.Variants {
+		expected[v.Name] = float64(v.Weight) / 100
+	}
+	check := runAACheck(insights.Variants, expected)
+	if check.Samples < minSampleRatioSamples {
+		return aaCheck{
+			Status:   aaCheckSkipped,
+			Reason:   fmt.Sprintf("%d sampled evaluations, need %d", check.Samples, minSampleRatioSamples),
+			Samples:  check.Samples,
+			Observed: check.Observed,
+			Expected: check.Expected,
+		}
+	}
+	return check
+}
+
+// assignmentCheck evaluates flag, enabled and without targeting, for the
+// given number of simulated users and compares the off share and variants
+// with its rollout and variant weights.
+func assignmentCheck(flag snapshot.FlagView, salt string, users int) aaCheck {
+	flag.Enabled, flag.Expression = true, nil
+	rolledOut := float64(flag.Rollout) / 100
+	expected := map[string]float64{}
+	if flag.Rollout < 100 {
+		expected["off"] = 1 - rolledOut
+	}
+	if len(flag.Variants) == 0 {
+		expected["on"] = rolledOut
+	}
+	for _, v := range flag.Variants {
+		expected[v.Name] = rolledOut * float64(v.Weight) / 100
+	}
+
+	observed := map[string]int{}
+	for i := 0; i < users; i++ {
+		res := evaluation.EvaluateFlag(flag, evaluation.Context{UserID: aaTestUserID(i)}, salt)
+		switch {
+		case !res.Enabled:
+			observed["off"]++
+		case res.Variant == "":
+			observed["on"]++
+		default:
+			observed[res.Variant]++
+		}
+	}
+	check := runAACheck(observed, expected)
+	if countShares(expected) < 2 {
+		check.Status, check.Reason = aaCheckSkipped, "rollout and variants assign every user the same result"
+	}
+	return check
+}
+
+// bucketingCheck hashes the given number of simulated users for key into 10
+// groups of buckets, which should be equally likely.
+func bucketingCheck(key, salt string, users int) aaCheck {
+	observed := map[string]int{}
+	expected := map[string]float64{}
+	for g := 0; g < 10; g++ {
+		expected[fmt.Sprintf("%02d-%02d", g*10, g*10+9)] = 0.1
+	}
+	for i := 0; i < users; i++ {
+		g := rollout.BucketUser(aaTestUserID(i), key, salt) / 10
+		observed[fmt.Sprintf("%02d-%02d", g*10, g*10+9)]++
+	}
+	return runAACheck(observed, expected)
+}
+
+// runAACheck tests observed counts against expected shares. Observed
+// categories missing from expected have a share of 0.
+func runAACheck(observed map[string]int, expected map[string]float64) aaCheck {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	for name := range observed {
+		if _, ok := expected[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	counts := make([]int, len(names))
+	shares := make([]float64, len(names))
+	check := aaCheck{Observed: map[string]int{}, Expected: expected}
+	for i, name := range names {
+		counts[i], shares[i] = observed[name], expected[name]
+		check.Observed[name] = observed[name]
+		check.Samples += observed[name]
+	}
+	check.ChiSquare, check.PValue = rollout.ChiSquareTest(counts, shares)
+	if math.IsInf(check.ChiSquare, 1) {
+		check.ChiSquare, check.Reason = 0, "observed results the flag is not configured to serve"
+	}
+	check.Status = aaCheckPass
+	if check.PValue < aaTestAlpha {
+		check.Status = aaCheckFail
+	}
+	return check
+}
+
+func countShares(expected map[string]float64) int {
+	n := 0
+	for _, share := range expected {
+		if share > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func aaTestUserID(i int) string {
+	return "aa-test-user-" + strconv.Itoa(i)
+}
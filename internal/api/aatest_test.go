@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestAATest(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{
+		Key: "checkout", Enabled: true, Rollout: 100, Env: "prod",
+		Variants: []store.Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}},
+	})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	run := func() aaTestResponse {
+		t.Helper()
+		rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/aa-test?users=5000", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp aaTestResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := run()
+	if !resp.Passed || resp.SampleRatio.Status != aaCheckSkipped {
+		t.Fatalf("expected a pass with the sample ratio check skipped, got %+v", resp)
+	}
+	if resp.Assignment.Status != aaCheckPass || resp.Assignment.Samples != 5000 || resp.Bucketing.Status != aaCheckPass {
+		t.Errorf("expected simulated users to pass, got %+v / %+v", resp.Assignment, resp.Bucketing)
+	}
+
+	// Sampled traffic served a 60/40 split of a 50/50 experiment.
+	var sampled []insightResult
+	for i := 0; i < 1000; i++ {
+		variant := "a"
+		if i%10 >= 6 {
+			variant = "b"
+		}
+		sampled = append(sampled, insightResult{key: "checkout", enabled: true, variant: variant})
+	}
+	srv.insights.record("prod", sampled)
+	resp = run()
+	if resp.Passed || resp.SampleRatio.Status != aaCheckFail || resp.SampleRatio.Observed["a"] != 600 {
+		t.Errorf("expected a sample ratio mismatch, got %+v", resp.SampleRatio)
+	}
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/aa-test?users=0", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid user count, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The activity feed backs the admin UI's "recent activity" panel:
+//
+//	GET /v1/admin/activity?since=<RFC3339>&limit=<n>   (admin+)
+//
+// It merges, newest first:
+//   - audit: audit log entries made by API keys, SDK keys or users
+//   - system: audit log entries made by background jobs (git sync, the
+//     ephemeral environment reaper, stale flag cleanup)
+//   - webhook_failure: failed webhook delivery attempts
+//
+// since defaults to 24 hours ago and limit to 50 (max 200). Each source is
+// read with the same limit, so the merged feed is complete up to limit items.
+// Scheduled jobs record their effects as system audit entries; there is no
+// separate execution log to merge.
+
+const (
+	activityKindAudit          = "audit"
+	activityKindSystem         = "system"
+	activityKindWebhookFailure = "webhook_failure"
+
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+	defaultActivityRange = 24 * time.Hour
+)
+
+type activityItem struct {
+	Kind         string    `json:"kind"`
+	Timestamp    time.Time `json:"timestamp"`
+	Summary      string    `json:"summary"`
+	Actor        string    `json:"actor,omitempty"`
+	Action       string    `json:"action,omitempty"`
+	ResourceType string    `json:"resource_type,omitempty"`
+	ResourceID   string    `json:"resource_id,omitempty"`
+	Environment  string    `json:"environment,omitempty"`
+	WebhookID    string    `json:"webhook_id,omitempty"`
+	EventType    string    `json:"event_type,omitempty"`
+	StatusCode   *int32    `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+type activityResponse struct {
+	Since time.Time      `json:"since"`
+	Items []activityItem `json:"items"`
+}
+
+func (s *Server) handleActivityFeed(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().UTC().Add(-defaultActivityRange)
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			ValidationError(w, r, "Invalid query parameters", map[string]string{
+				"since": "must be an RFC3339 timestamp",
+			})
+			return
+		}
+		since = t.UTC()
+	}
+	limit := defaultActivityLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxActivityLimit {
+			ValidationError(w, r, "Invalid query parameters", map[string]string{
+				"limit": fmt.Sprintf("must be between 1 and %d", maxActivityLimit),
+			})
+			return
+		}
+		limit = n
+	}
+
+	pgStore := s.requirePostgresStore(w, r)
+	if pgStore == nil {
+		return // Error already written to response
+	}
+	queries := getQueriesFromStore(pgStore)
+	if queries == nil {
+		InternalError(w, r, "Database queries not available")
+		return
+	}
+
+	logs, err := pgStore.ListAuditLogs(r.Context(), dbgen.ListAuditLogsParams{
+		Limit:     int32(limit),
+		StartDate: pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		InternalError(w, r, "Failed to list audit logs")
+		return
+	}
+	deliveries, err := queries.ListFailedWebhookDeliveriesSince(r.Context(), dbgen.ListFailedWebhookDeliveriesSinceParams{
+		Timestamp: pgtype.Timestamptz{Time: since, Valid: true},
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		InternalError(w, r, "Failed to list webhook deliveries")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, activityResponse{
+		Since: since,
+		Items: mergeActivity(logs, deliveries, limit),
+	})
+}
+
+// mergeActivity converts audit logs and failed deliveries into feed items,
+// newest first, and keeps at most limit of them.
+func mergeActivity(logs []dbgen.AuditLog, deliveries []dbgen.WebhookDelivery, limit int) []activityItem {
+	items := make([]activityItem, 0, len(logs)+len(deliveries))
+	for _, log := range logs {
+		items = append(items, auditActivityItem(log))
+	}
+	for _, d := range deliveries {
+		items = append(items, webhookFailureActivityItem(d))
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Timestamp.After(items[j].Timestamp)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+func auditActivityItem(log dbgen.AuditLog) activityItem {
+	item := activityItem{
+		Kind:      activityKindAudit,
+		Timestamp: log.Timestamp.Time,
+		Action:    log.Action,
+	}
+	if log.ResourceType.Valid {
+		item.ResourceType = log.ResourceType.String
+	}
+	if log.ResourceID.Valid {
+		item.ResourceID = log.ResourceID.String
+	}
+	if log.Environment.Valid {
+		item.Environment = log.Environment.String
+	}
+
+	var details struct {
+		Actor audit.Actor `json:"actor"`
+	}
+	_ = json.Unmarshal(log.Details, &details) // rows written before actors were recorded have none
+	item.Actor = details.Actor.Display
+	if details.Actor.Kind == audit.ActorKindSystem {
+		item.Kind = activityKindSystem
+	}
+	if item.Actor == "" {
+		item.Actor = "unknown"
+	}
+
+	summary := []string{item.Actor, log.Action}
+	if item.ResourceType != "" {
+		summary = append(summary, item.ResourceType+"/"+item.ResourceID)
+	}
+	if item.Environment != "" {
+		summary = append(summary, "("+item.Environment+")")
+	}
+	item.Summary = strings.Join(summary, " ")
+	return item
+}
+
+func webhookFailureActivityItem(d dbgen.WebhookDelivery) activityItem {
+	item := activityItem{
+		Kind:      activityKindWebhookFailure,
+		Timestamp: d.Timestamp.Time,
+		WebhookID: formatUUID(d.WebhookID),
+		EventType: d.EventType,
+	}
+	reason := "no response"
+	if d.StatusCode.Valid {
+		item.StatusCode = &d.StatusCode.Int32
+		reason = fmt.Sprintf("HTTP %d", d.StatusCode.Int32)
+	}
+	if d.ErrorMessage.Valid {
+		item.Error = d.ErrorMessage.String
+		reason = d.ErrorMessage.String
+	}
+	item.Summary = fmt.Sprintf("Webhook delivery of %s failed (attempt %d): %s", d.EventType, d.RetryCount+1, reason)
+	return item
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMergeActivity(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: base.Add(time.Duration(minutes) * time.Minute), Valid: true}
+	}
+	text := func(s string) pgtype.Text { return pgtype.Text{String: s, Valid: true} }
+
+	logs := []dbgen.AuditLog{
+		{Timestamp: at(30), Action: "updated", ResourceType: text("flag"), ResourceID: text("checkout"), Environment: text("prod"),
+			Details: []byte(`{"actor":{"kind":"api_key","display":"api_key:1234abcd"}}`)},
+		{Timestamp: at(10), Action: "deleted", ResourceType: text("environment"), ResourceID: text("pr-42"),
+			Details: []byte(`{"actor":{"kind":"system","display":"system"}}`)},
+	}
+	deliveries := []dbgen.WebhookDelivery{
+		{Timestamp: at(20), EventType: "flag.updated", StatusCode: pgtype.Int4{Int32: 502, Valid: true}, RetryCount: 1},
+	}
+
+	items := mergeActivity(logs, deliveries, 10)
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
+	}
+	wantKinds := []string{activityKindAudit, activityKindWebhookFailure, activityKindSystem}
+	for i, want := range wantKinds {
+		if items[i].Kind != want {
+			t.Errorf("items[%d].Kind = %q, want %q", i, items[i].Kind, want)
+		}
+	}
+	if got := items[0].Summary; got != "api_key:1234abcd updated flag/checkout (prod)" {
+		t.Errorf("audit summary = %q", got)
+	}
+	if got := items[1].Summary; got != "Webhook delivery of flag.updated failed (attempt 2): HTTP 502" {
+		t.Errorf("webhook summary = %q", got)
+	}
+
+	if limited := mergeActivity(logs, deliveries, 2); len(limited) != 2 || limited[1].Kind != activityKindWebhookFailure {
+		t.Errorf("limit should keep the newest items, got %+v", limited)
+	}
+}
+
+func TestActivityFeed_Validation(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	for _, target := range []string{"/v1/admin/activity?since=yesterday", "/v1/admin/activity?limit=0", "/v1/admin/activity?limit=500"} {
+		rr := doV2Request(t, handler, http.MethodGet, target, "")
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", target, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,491 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// POST /v1/apply accepts the full desired state of an environment and returns
+// the plan needed to reach it. The plan is only executed with ?confirm=true,
+// so a pipeline can run the same request twice: once to review, once to apply.
+//
+// The document describes one project ("project", default store.DefaultProject)
+// of the environment; flags of other projects are left alone.
+//
+// Resources handled:
+//   - flags: always managed; flags of the project in env that are absent from
+//     the document are deleted
+//   - webhooks: managed only when the "webhooks" key is present; matched by URL
+//     among webhooks scoped to env, and require the postgres store
+//
+// Segments are not part of the data model and are not accepted.
+
+const (
+	applyActionCreate = "create"
+	applyActionUpdate = "update"
+	applyActionDelete = "delete"
+
+	applyResourceFlag    = "flag"
+	applyResourceWebhook = "webhook"
+)
+
+type applyRequest struct {
+	Project  string          `json:"project,omitempty"`
+	Env      string          `json:"env"`
+	Flags    []upsertRequest `json:"flags"`
+	Webhooks *[]applyWebhook `json:"webhooks,omitempty"`
+}
+
+// applyWebhook is the desired state of a webhook, identified by URL.
+type applyWebhook struct {
+	URL            string   `json:"url"`
+	Description    string   `json:"description,omitempty"`
+	Enabled        *bool    `json:"enabled,omitempty"`
+	Events         []string `json:"events"`
+	Environments   []string `json:"environments,omitempty"`
+	MaxRetries     int32    `json:"max_retries,omitempty"`
+	TimeoutSeconds int32    `json:"timeout_seconds,omitempty"`
+
+	DeliveryRetentionDays int32 `json:"delivery_retention_days,omitempty"`
+	DeliveryMaxRows       int32 `json:"delivery_max_rows,omitempty"`
+}
+
+type applyChange struct {
+	Action       string         `json:"action"`
+	ResourceType string         `json:"resource_type"`
+	Key          string         `json:"key"`
+	Diff         map[string]any `json:"diff,omitempty"`
+
+	// unexported execution details
+	flagReq    *upsertRequest
+	webhook    *applyWebhook
+	webhookID  pgtype.UUID
+	webhookOld *dbgen.Webhook
+}
+
+type applySummary struct {
+	Create int `json:"create"`
+	Update int `json:"update"`
+	Delete int `json:"delete"`
+}
+
+type applyResponse struct {
+	Env     string        `json:"env"`
+	Applied bool          `json:"applied"`
+	Summary applySummary  `json:"summary"`
+	Changes []applyChange `json:"changes"`
+	ETag    string        `json:"etag,omitempty"`
+}
+
+func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
+	var req applyRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+
+	req.Project = strings.TrimSpace(req.Project)
+	if req.Project == "" {
+		req.Project = store.DefaultProject
+	}
+	if known, err := s.knownProject(r, req.Project); err != nil {
+		InternalError(w, r, "Failed to load project")
+		return
+	} else if !known {
+		NotFoundError(w, r, "Project not found")
+		return
+	}
+	req.Env = strings.TrimSpace(req.Env)
+	if req.Env == "" {
+		ValidationError(w, r, "Missing required fields", map[string]string{"env": "env is required"})
+		return
+	}
+	if !requireTenantEnv(w, r, req.Env) {
+		return
+	}
+	if fields := s.validateApplyRequest(r.Context(), &req); len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more resources", fields)
+		return
+	}
+
+	var queries *dbgen.Queries
+	if req.Webhooks != nil {
+		queries = s.requireQueries(w, r)
+		if queries == nil {
+			return // Error already written to response
+		}
+	}
+
+	changes, werr := s.planApply(r.Context(), req, queries)
+	if werr != nil {
+		werr.write(w, r)
+		return
+	}
+
+	resp := applyResponse{Env: req.Env, Changes: append([]applyChange{}, changes...)}
+	for _, c := range changes {
+		switch c.Action {
+		case applyActionCreate:
+			resp.Summary.Create++
+		case applyActionUpdate:
+			resp.Summary.Update++
+		case applyActionDelete:
+			resp.Summary.Delete++
+		}
+	}
+
+	if r.URL.Query().Get("confirm") == "true" {
+		for _, c := range changes {
+			if werr := s.executeApplyChange(r, req.Env, c, queries); werr != nil {
+				werr.write(w, r)
+				return
+			}
+		}
+		resp.Applied = true
+		resp.ETag = s.snapshotForEnv(req.Env).ETag
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validateApplyRequest validates every desired flag and webhook up front so a
+// confirmed apply never stops halfway because of bad input. Field keys are
+// prefixed with the resource position, e.g. "flags[2].rollout".
+func (s *Server) validateApplyRequest(ctx context.Context, req *applyRequest) map[string]string {
+	fields := map[string]string{}
+	seen := map[string]bool{}
+	for i := range req.Flags {
+		f := &req.Flags[i]
+		prefix := fmt.Sprintf("flags[%d]", i)
+		if f.Env != nil && strings.TrimSpace(*f.Env) != "" && strings.TrimSpace(*f.Env) != req.Env {
+			fields[prefix+".env"] = "env must match the document env"
+			continue
+		}
+		f.Env = &req.Env
+		if f.Project != nil && strings.TrimSpace(*f.Project) != "" && strings.TrimSpace(*f.Project) != req.Project {
+			fields[prefix+".project"] = "project must match the document project"
+			continue
+		}
+		f.Project = &req.Project
+		if f.Protected == nil {
+			f.Protected = new(bool) // the document is the full desired state
+		}
+		if f.Owner == nil {
+			f.Owner = new(string)
+		}
+		if f.TicketURL == nil {
+			f.TicketURL = new(string)
+		}
+		if f.Metadata == nil {
+			f.Metadata = map[string]string{}
+		}
+		if seen[f.Key] {
+			fields[prefix+".key"] = "duplicate flag key " + f.Key
+			continue
+		}
+		seen[f.Key] = true
+		if field, message, ok := validateTargetingRules(f.TargetingRules); !ok {
+			fields[prefix+"."+field] = message
+			continue
+		}
+		if s.attrEnforcement == AttributeEnforcementReject {
+			for field, message := range s.ruleAttributeViolations(ctx, f.TargetingRules) {
+				fields[prefix+"."+field] = message
+			}
+		}
+		if werr := validateUpsertRequest(f, req.Env); werr != nil {
+			for field, message := range werr.fields {
+				fields[prefix+"."+field] = message
+			}
+		}
+	}
+
+	if req.Webhooks != nil {
+		seenURLs := map[string]bool{}
+		for i, wh := range *req.Webhooks {
+			prefix := fmt.Sprintf("webhooks[%d]", i)
+			switch {
+			case wh.URL == "":
+				fields[prefix+".url"] = "URL is required"
+			case seenURLs[wh.URL]:
+				fields[prefix+".url"] = "duplicate webhook URL " + wh.URL
+			case len(wh.Events) == 0:
+				fields[prefix+".events"] = "At least one event type is required"
+			default:
+				if msg := s.validateWebhookURL(ctx, wh.URL); msg != "" {
+					fields[prefix+".url"] = msg
+				}
+			}
+			retentionErrors := map[string]string{}
+			validateDeliveryRetention(retentionErrors, wh.DeliveryRetentionDays, wh.DeliveryMaxRows)
+			for field, message := range retentionErrors {
+				fields[prefix+"."+field] = message
+			}
+			seenURLs[wh.URL] = true
+		}
+	}
+	return fields
+}
+
+// planApply diffs the desired state against the current state.
+// Changes are ordered creates/updates first (by key), then deletes.
+func (s *Server) planApply(ctx context.Context, req applyRequest, queries *dbgen.Queries) ([]applyChange, *flagWriteError) {
+	all, err := s.store.GetAllFlags(ctx, req.Env)
+	if err != nil {
+		return nil, internalWriteError("Failed to load flags")
+	}
+	var current []store.Flag
+	currentByKey := make(map[string]*store.Flag, len(all))
+	for i := range all {
+		currentByKey[all[i].Key] = &all[i]
+		if all[i].Project == req.Project {
+			current = append(current, all[i])
+		}
+	}
+
+	var upserts, deletes []applyChange
+	desiredKeys := map[string]bool{}
+	for i := range req.Flags {
+		desired := req.Flags[i]
+		desiredKeys[desired.Key] = true
+		after := applyFlagState(flagFromUpsertRequest(desired, req.Env))
+
+		if existing, ok := currentByKey[desired.Key]; ok {
+			if existing.Project != req.Project {
+				return nil, &flagWriteError{
+					status:  http.StatusConflict,
+					code:    ErrCodeConflict,
+					message: "Flag '" + desired.Key + "' belongs to project '" + existing.Project + "' in this environment",
+				}
+			}
+			diff := audit.ComputeChanges(applyFlagState(existing), after)
+			if len(diff) == 0 {
+				continue
+			}
+			upserts = append(upserts, applyChange{Action: applyActionUpdate, ResourceType: applyResourceFlag, Key: desired.Key, Diff: diff, flagReq: &desired})
+			continue
+		}
+		upserts = append(upserts, applyChange{Action: applyActionCreate, ResourceType: applyResourceFlag, Key: desired.Key, Diff: audit.ComputeChanges(nil, after), flagReq: &desired})
+	}
+	for _, f := range current {
+		if !desiredKeys[f.Key] {
+			deletes = append(deletes, applyChange{Action: applyActionDelete, ResourceType: applyResourceFlag, Key: f.Key, Diff: audit.ComputeChanges(applyFlagState(&f), nil)})
+		}
+	}
+
+	if req.Webhooks != nil {
+		whUpserts, whDeletes, err := planWebhooks(ctx, queries, req.Env, *req.Webhooks)
+		if err != nil {
+			return nil, internalWriteError("Failed to load webhooks")
+		}
+		upserts = append(upserts, whUpserts...)
+		deletes = append(deletes, whDeletes...)
+	}
+
+	sort.SliceStable(upserts, func(i, j int) bool { return upserts[i].Key < upserts[j].Key })
+	sort.SliceStable(deletes, func(i, j int) bool { return deletes[i].Key < deletes[j].Key })
+	return append(upserts, deletes...), nil
+}
+
+func planWebhooks(ctx context.Context, queries *dbgen.Queries, env string, desired []applyWebhook) ([]applyChange, []applyChange, error) {
+	all, err := queries.ListWebhooks(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	tenant := auth.GetTenantFromContext(ctx)
+	currentByURL := map[string]dbgen.Webhook{}
+	for _, wh := range all {
+		if wh.Tenant == tenant && slices.Contains(wh.Environments, env) {
+			currentByURL[wh.Url] = wh
+		}
+	}
+
+	var upserts, deletes []applyChange
+	desiredURLs := map[string]bool{}
+	for i := range desired {
+		d := desired[i]
+		if len(d.Environments) == 0 {
+			d.Environments = []string{env}
+		}
+		if d.MaxRetries == 0 {
+			d.MaxRetries = 3
+		}
+		if d.TimeoutSeconds == 0 {
+			d.TimeoutSeconds = 10
+		}
+		desiredURLs[d.URL] = true
+		after := applyWebhookState(d)
+
+		if existing, ok := currentByURL[d.URL]; ok {
+			diff := audit.ComputeChanges(applyWebhookStateFromDB(existing), after)
+			if len(diff) == 0 {
+				continue
+			}
+			old := existing
+			upserts = append(upserts, applyChange{Action: applyActionUpdate, ResourceType: applyResourceWebhook, Key: d.URL, Diff: diff, webhook: &d, webhookID: existing.ID, webhookOld: &old})
+			continue
+		}
+		upserts = append(upserts, applyChange{Action: applyActionCreate, ResourceType: applyResourceWebhook, Key: d.URL, Diff: audit.ComputeChanges(nil, after), webhook: &d})
+	}
+	for url, wh := range currentByURL {
+		if !desiredURLs[url] {
+			deletes = append(deletes, applyChange{Action: applyActionDelete, ResourceType: applyResourceWebhook, Key: url, Diff: audit.ComputeChanges(applyWebhookStateFromDB(wh), nil), webhookID: wh.ID})
+		}
+	}
+	return upserts, deletes, nil
+}
+
+// executeApplyChange performs one planned change. Flag changes reuse the
+// regular write path so audit events, snapshot rebuilds and webhooks fire.
+func (s *Server) executeApplyChange(r *http.Request, env string, c applyChange, queries *dbgen.Queries) *flagWriteError {
+	switch c.ResourceType {
+	case applyResourceFlag:
+		if c.Action == applyActionDelete {
+			return s.removeFlag(r, c.Key, env)
+		}
+		_, werr := s.saveFlag(r, *c.flagReq)
+		return werr
+	case applyResourceWebhook:
+		return s.executeWebhookChange(r, c, queries)
+	}
+	return nil
+}
+
+func (s *Server) executeWebhookChange(r *http.Request, c applyChange, queries *dbgen.Queries) *flagWriteError {
+	ctx := r.Context()
+	switch c.Action {
+	case applyActionCreate:
+		if werr := s.checkWebhookLimit(ctx, queries); werr != nil {
+			return werr
+		}
+		_, secret, err := s.newWebhookSecret()
+		if err != nil {
+			return internalWriteError("Failed to generate webhook secret")
+		}
+		params := dbgen.CreateWebhookParams{
+			Url:            c.webhook.URL,
+			Enabled:        c.webhook.Enabled == nil || *c.webhook.Enabled,
+			Events:         c.webhook.Events,
+			Environments:   c.webhook.Environments,
+			Secret:         secret,
+			MaxRetries:     c.webhook.MaxRetries,
+			TimeoutSeconds: c.webhook.TimeoutSeconds,
+			Tenant:         auth.GetTenantFromContext(ctx),
+
+			DeliveryRetentionDays: c.webhook.DeliveryRetentionDays,
+			DeliveryMaxRows:       c.webhook.DeliveryMaxRows,
+		}
+		if c.webhook.Description != "" {
+			params.Description = pgtype.Text{String: c.webhook.Description, Valid: true}
+		}
+		if _, err := queries.CreateWebhook(ctx, params); err != nil {
+			return internalWriteError("Failed to create webhook")
+		}
+	case applyActionUpdate:
+		params := dbgen.UpdateWebhookParams{
+			ID:             c.webhookID,
+			Url:            c.webhook.URL,
+			Enabled:        c.webhook.Enabled == nil || *c.webhook.Enabled,
+			Events:         c.webhook.Events,
+			ProjectID:      c.webhookOld.ProjectID,
+			Environments:   c.webhook.Environments,
+			MaxRetries:     c.webhook.MaxRetries,
+			TimeoutSeconds: c.webhook.TimeoutSeconds,
+
+			DeliveryRetentionDays: c.webhook.DeliveryRetentionDays,
+			DeliveryMaxRows:       c.webhook.DeliveryMaxRows,
+		}
+		if c.webhook.Description != "" {
+			params.Description = pgtype.Text{String: c.webhook.Description, Valid: true}
+		}
+		if err := queries.UpdateWebhook(ctx, params); err != nil {
+			return internalWriteError("Failed to update webhook")
+		}
+	case applyActionDelete:
+		if err := queries.DeleteWebhook(ctx, c.webhookID); err != nil {
+			return internalWriteError("Failed to delete webhook")
+		}
+	}
+	return nil
+}
+
+// flagFromUpsertRequest builds the flag that req would produce in env.
+func flagFromUpsertRequest(req upsertRequest, env string) *store.Flag {
+	flag := &store.Flag{
+		Key:            req.Key,
+		Description:    req.Description,
+		Enabled:        req.Enabled,
+		Rollout:        req.Rollout,
+		Expression:     req.Expression,
+		Config:         req.Config,
+		TargetingRules: req.TargetingRules,
+		Protected:      req.Protected != nil && *req.Protected,
+		Tags:           req.Tags,
+		Owner:          derefString(req.Owner),
+		TicketURL:      derefString(req.TicketURL),
+		Metadata:       req.Metadata,
+		ExpiresAt:      req.ExpiresAt,
+		Env:            env,
+		Project:        store.DefaultProject,
+	}
+	if req.Project != nil && *req.Project != "" {
+		flag.Project = *req.Project
+	}
+	for _, v := range req.Variants {
+		flag.Variants = append(flag.Variants, store.Variant{Name: v.Name, Weight: v.Weight, Config: v.Config})
+	}
+	return flag
+}
+
+// applyFlagState is the comparable state of a flag for plan diffs.
+// It extends flagToMap with targeting rules and drops the timestamp and the
+// archived state, which documents do not manage.
+func applyFlagState(flag *store.Flag) map[string]any {
+	m := flagToMap(flag)
+	delete(m, "updated_at")
+	delete(m, "archived")
+	if len(flag.TargetingRules) > 0 {
+		m["targeting_rules"] = flag.TargetingRules
+	}
+	return m
+}
+
+func applyWebhookState(d applyWebhook) map[string]any {
+	enabled := d.Enabled == nil || *d.Enabled
+	return map[string]any{
+		"url":             d.URL,
+		"description":     d.Description,
+		"enabled":         enabled,
+		"events":          d.Events,
+		"environments":    d.Environments,
+		"max_retries":     d.MaxRetries,
+		"timeout_seconds": d.TimeoutSeconds,
+
+		"delivery_retention_days": d.DeliveryRetentionDays,
+		"delivery_max_rows":       d.DeliveryMaxRows,
+	}
+}
+
+func applyWebhookStateFromDB(wh dbgen.Webhook) map[string]any {
+	return map[string]any{
+		"url":             wh.Url,
+		"description":     wh.Description.String,
+		"enabled":         wh.Enabled,
+		"events":          wh.Events,
+		"environments":    wh.Environments,
+		"max_retries":     wh.MaxRetries,
+		"timeout_seconds": wh.TimeoutSeconds,
+
+		"delivery_retention_days": wh.DeliveryRetentionDays,
+		"delivery_max_rows":       wh.DeliveryMaxRows,
+	}
+}
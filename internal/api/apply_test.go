@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestApply_PlanWithoutConfirm(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "keep", Enabled: true, Rollout: 100, Env: "prod"})
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "change", Rollout: 10, Env: "prod"})
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "remove", Env: "prod"})
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	body := `{"env":"prod","flags":[
+		{"key":"keep","enabled":true,"rollout":100},
+		{"key":"change","rollout":50},
+		{"key":"added","enabled":true}
+	]}`
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/apply", body)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp applyResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Applied {
+		t.Error("plan should not be applied without confirm")
+	}
+	if resp.Summary != (applySummary{Create: 1, Update: 1, Delete: 1}) {
+		t.Errorf("unexpected summary: %+v", resp.Summary)
+	}
+	if resp.Changes[len(resp.Changes)-1].Action != applyActionDelete {
+		t.Error("deletes should be ordered last")
+	}
+	if _, err := st.GetFlagByKey(ctx, "added", "prod"); err == nil {
+		t.Error("plan-only request must not create flags")
+	}
+	if _, err := st.GetFlagByKey(ctx, "remove", "prod"); err != nil {
+		t.Error("plan-only request must not delete flags")
+	}
+}
+
+func TestApply_Confirm(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "remove", Env: "prod"})
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/apply?confirm=true", `{"env":"prod","flags":[{"key":"added","rollout":30}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp applyResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	if !resp.Applied || resp.ETag == "" {
+		t.Errorf("expected applied response with etag, got %+v", resp)
+	}
+
+	flag, err := st.GetFlagByKey(ctx, "added", "prod")
+	if err != nil || flag.Rollout != 30 {
+		t.Errorf("flag should be created with rollout 30: %v %+v", err, flag)
+	}
+	if _, err := st.GetFlagByKey(ctx, "remove", "prod"); err == nil {
+		t.Error("flag missing from document should be deleted")
+	}
+
+	// Re-applying the same document is a no-op
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/apply", `{"env":"prod","flags":[{"key":"added","rollout":30}]}`)
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Changes) != 0 {
+		t.Errorf("expected empty plan, got %+v", resp.Changes)
+	}
+}
+
+func TestApply_ValidationIsUpFront(t *testing.T) {
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	body := `{"env":"prod","flags":[{"key":"ok_flag"},{"key":"bad","rollout":150},{"key":"ok_flag"}]}`
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/apply?confirm=true", body)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+	var errResp ErrorResponse
+	_ = json.NewDecoder(rr.Body).Decode(&errResp)
+	if errResp.Fields["flags[1].rollout"] == "" || errResp.Fields["flags[2].key"] == "" {
+		t.Errorf("expected per-resource field errors, got %v", errResp.Fields)
+	}
+	if _, err := st.GetFlagByKey(context.Background(), "ok_flag", "prod"); err == nil {
+		t.Error("nothing should be applied when validation fails")
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+)
+
+// A flag is either active or archived. Archived flags stay in the store, so
+// they can still be read, listed and changed, but they are left out of the
+// snapshot: SDKs no longer receive them and evaluating one answers
+// FLAG_NOT_FOUND. Unlike a deleted flag (see trash.go), an archived flag
+// keeps its key in use, its stars and its version history.
+//
+//	POST /v1/flags/{key}/archive?env=      archive a flag (admin+)
+//	POST /v1/flags/{key}/unarchive?env=    make it active again (admin+)
+//	GET  /v1/flags?env=&state=archived     list archived (or active) flags
+//
+// Both answer with the flag and are idempotent. Archiving a protected flag
+// counts as destructive, see protection.go. Flags are also archived by bulk
+// actions (bulk.go), stale flag cleanup (cleanup.go) and expiry (expiry.go);
+// other writes keep a flag's archived state.
+
+func (s *Server) handleArchiveFlag(w http.ResponseWriter, r *http.Request) {
+	s.setFlagArchived(w, r, true)
+}
+
+func (s *Server) handleUnarchiveFlag(w http.ResponseWriter, r *http.Request) {
+	s.setFlagArchived(w, r, false)
+}
+
+// setFlagArchived moves the flag of r into the archived or active state,
+// rebuilding the snapshot and recording the change like any other update.
+func (s *Server) setFlagArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	env, flag, ok := s.routeFlag(w, r)
+	if !ok {
+		return
+	}
+	if flag.Archived == archived {
+		writeJSON(w, http.StatusOK, toFlagResponse(flag))
+		return
+	}
+	overrideReason, werr := s.checkFlagProtection(r, flag, archived)
+	if werr != nil {
+		werr.write(w, r)
+		return
+	}
+
+	params := upsertParamsFromFlag(flag)
+	params.Archived = archived
+	params.ExpectedVersion = &flag.Version
+	if err := s.store.UpsertFlag(r.Context(), params); err != nil {
+		flagStoreWriteError(err, "Failed to update flag").write(w, r)
+		return
+	}
+	if err := s.RebuildSnapshot(r.Context(), env); err != nil {
+		InternalError(w, r, "Failed to rebuild snapshot")
+		return
+	}
+
+	after, err := s.store.GetFlagByKey(r.Context(), flag.Key, env)
+	if err != nil {
+		InternalError(w, r, "Failed to load flag")
+		return
+	}
+	beforeState, afterState := flagToMap(flag), flagToMap(after)
+	changes := audit.ComputeChanges(beforeState, afterState)
+	auditID := s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeFlag, flag.Key, env, beforeState, afterState, changes, audit.StatusSuccess, "")
+	if overrideReason != "" {
+		s.auditProtectionOverride(r, flag.Key, env, overrideReason)
+	}
+	s.dispatchWebhookEvent(r, auditID, flag.Key, env, beforeState, afterState, changes)
+
+	w.Header().Set("ETag", flagVersionETag(after.Version))
+	writeJSON(w, http.StatusOK, toFlagResponse(after))
+}
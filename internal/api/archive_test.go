@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestArchiveFlag_LeavesSnapshotAndUnarchiveRestores(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	for _, key := range []string{"banner", "checkout"} {
+		if err := st.UpsertFlag(ctx, store.UpsertParams{Key: key, Enabled: true, Rollout: 100, Env: "prod"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/banner/archive?env=prod", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("archive: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !getFlagResponse(t, handler, "banner").Archived {
+		t.Fatal("banner should be archived")
+	}
+	if _, ok := snapshot.Load().Flags["banner"]; ok {
+		t.Error("archived flag must not be served")
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/banner/archive?env=prod", ""); rr.Code != http.StatusOK {
+		t.Errorf("archiving again should be a no-op, got %d", rr.Code)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags?env=prod&state=archived", "")
+	var list listFlagsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 1 || list.Flags[0].Key != "banner" {
+		t.Errorf("state=archived: expected only banner, got %+v", list.Flags)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags/banner/unarchive?env=prod", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unarchive: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if served, ok := snapshot.Load().Flags["banner"]; !ok || !served.Enabled {
+		t.Error("unarchived flag should be served as it was")
+	}
+}
+
+func TestArchiveFlag_NotFoundAndProtected(t *testing.T) {
+	srv, _ := newProtectedFlagServer(t)
+	handler := srv.Router()
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/missing/archive?env=prod", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("missing flag: expected 404, got %d", rr.Code)
+	}
+
+	req := adminRequest(http.MethodPost, "/v1/flags/payments/archive?env=prod")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "payments")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+	srv.handleArchiveFlag(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("protected flag: expected 403 for an admin, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
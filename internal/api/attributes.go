@@ -0,0 +1,455 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/engine"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/go-chi/chi/v5"
+)
+
+// The context attribute registry declares which attributes evaluation
+// contexts carry and targeting rules may refer to, per project:
+//
+//	GET    /v2/projects/{project}/attributes          list declared attributes (admin+)
+//	PUT    /v2/projects/{project}/attributes/{name}   declare or replace an attribute (admin+)
+//	DELETE /v2/projects/{project}/attributes/{name}   remove a declaration (admin+)
+//
+// With enforcement enabled (SetAttributeEnforcement), targeting rules and
+// evaluation contexts are checked against the registry once it declares at
+// least one attribute: an undeclared attribute (say "planName" when "plan" is
+// declared) or a value of the wrong type is a violation. "warn" counts
+// violations in the context_attribute_violations_total metric and logs rule
+// violations; "reject" also fails the request with 400 SCHEMA_VIOLATION. The
+// user ID ("id") is always declared.
+//
+// The registry is cached per server instance and re-read every
+// attributeRegistryTTL, so changes made through another instance take effect
+// within that time.
+
+// AttributeEnforcement selects how context attributes are checked against the
+// registry.
+type AttributeEnforcement string
+
+const (
+	AttributeEnforcementOff    AttributeEnforcement = "off"
+	AttributeEnforcementWarn   AttributeEnforcement = "warn"
+	AttributeEnforcementReject AttributeEnforcement = "reject"
+)
+
+const (
+	// attributeRegistryTTL is how long a loaded registry is used before it
+	// is read from the store again.
+	attributeRegistryTTL = 30 * time.Second
+
+	// maxAttributeDescriptionLength is the maximum description length in characters.
+	maxAttributeDescriptionLength = 1000
+)
+
+// attributeNamePattern matches attribute names: a letter followed by letters,
+// digits, '_', '-' or '.', at most 100 characters.
+var attributeNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,99}$`)
+
+// builtinAttributes are declared implicitly. Rules may refer to the user ID
+// under any of these names, see engine.getContextValue.
+var builtinAttributes = map[string]bool{"id": true, "user_id": true, "userid": true}
+
+type putAttributeRequest struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Example     any    `json:"example,omitempty"`
+}
+
+// SetAttributeEnforcement sets how evaluation contexts and targeting rules
+// are checked against the attribute registry. The default is off.
+func (s *Server) SetAttributeEnforcement(mode AttributeEnforcement) {
+	s.attrEnforcement = mode
+}
+
+// requireContextAttributeStore returns the store as a ContextAttributeStore,
+// writing an error response and returning nil if it has no registry.
+func (s *Server) requireContextAttributeStore(w http.ResponseWriter, r *http.Request) store.ContextAttributeStore {
+	if as, ok := s.store.(store.ContextAttributeStore); ok {
+		return as
+	}
+	InternalError(w, r, "The attribute registry is not supported by this store")
+	return nil
+}
+
+// handleListContextAttributes handles GET /v2/projects/{project}/attributes.
+func (s *Server) handleListContextAttributes(w http.ResponseWriter, r *http.Request) {
+	as := s.requireContextAttributeStore(w, r)
+	if as == nil {
+		return
+	}
+	attrs, err := as.ListContextAttributes(r.Context(), chi.URLParam(r, "project"))
+	if err != nil {
+		InternalError(w, r, "Failed to list attributes")
+		return
+	}
+	writeJSON(w, http.StatusOK, v2Envelope{Data: attrs})
+}
+
+// handlePutContextAttribute handles PUT /v2/projects/{project}/attributes/{name}.
+func (s *Server) handlePutContextAttribute(w http.ResponseWriter, r *http.Request) {
+	var req putAttributeRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	name := chi.URLParam(r, "name")
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	req.Description = strings.TrimSpace(req.Description)
+
+	fields := map[string]string{}
+	if !attributeNamePattern.MatchString(name) {
+		fields["name"] = "Must start with a letter and contain only letters, digits, '_', '-' or '.' (max 100 characters)"
+	}
+	switch req.Type {
+	case store.AttributeTypeString, store.AttributeTypeNumber, store.AttributeTypeBoolean, store.AttributeTypeList, store.AttributeTypeObject:
+		if req.Example != nil && attributeType(req.Example) != req.Type {
+			fields["example"] = "Example must be a " + req.Type
+		}
+	case "":
+		fields["type"] = "Type is required"
+	default:
+		fields["type"] = "Must be one of string, number, boolean, list, object"
+	}
+	if utf8.RuneCountInString(req.Description) > maxAttributeDescriptionLength {
+		fields["description"] = "Description must not exceed 1000 characters"
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+
+	as := s.requireContextAttributeStore(w, r)
+	if as == nil {
+		return
+	}
+	project := chi.URLParam(r, "project")
+	existing, err := as.ListContextAttributes(r.Context(), project)
+	if err != nil {
+		InternalError(w, r, "Failed to load attributes")
+		return
+	}
+	var before map[string]any
+	for _, attr := range existing {
+		if attr.Name == name {
+			before = contextAttributeToMap(attr)
+		}
+	}
+
+	attr, err := as.PutContextAttribute(r.Context(), store.ContextAttribute{
+		Project:     project,
+		Name:        name,
+		Type:        req.Type,
+		Description: req.Description,
+		Example:     req.Example,
+		UpdatedBy:   createdByFromRequest(r),
+	})
+	if err != nil {
+		InternalError(w, r, "Failed to save attribute")
+		return
+	}
+	s.registry.invalidate()
+
+	action, after := audit.ActionUpdated, contextAttributeToMap(attr)
+	if before == nil {
+		action = audit.ActionCreated
+	}
+	s.auditLog(r, action, audit.ResourceTypeContextAttribute, name, "", before, after, audit.ComputeChanges(before, after), audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, v2Envelope{Data: attr})
+}
+
+// handleDeleteContextAttribute handles DELETE /v2/projects/{project}/attributes/{name}.
+func (s *Server) handleDeleteContextAttribute(w http.ResponseWriter, r *http.Request) {
+	as := s.requireContextAttributeStore(w, r)
+	if as == nil {
+		return
+	}
+	name := chi.URLParam(r, "name")
+	if err := as.DeleteContextAttribute(r.Context(), chi.URLParam(r, "project"), name); err != nil {
+		if errors.Is(err, store.ErrContextAttributeNotFound) {
+			NotFoundError(w, r, "Attribute not found")
+			return
+		}
+		InternalError(w, r, "Failed to delete attribute")
+		return
+	}
+	s.registry.invalidate()
+
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeContextAttribute, name, "", nil, nil, nil, audit.StatusSuccess, "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func contextAttributeToMap(attr store.ContextAttribute) map[string]any {
+	return map[string]any{
+		"type":        attr.Type,
+		"description": attr.Description,
+		"example":     attr.Example,
+	}
+}
+
+// attributeRegistry caches the declared attributes of the default project.
+type attributeRegistry struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	declared map[string]string // name -> type
+	loadedAt time.Time
+}
+
+func newAttributeRegistry() *attributeRegistry {
+	return &attributeRegistry{now: time.Now}
+}
+
+// invalidate makes the next lookup read the registry from the store.
+func (reg *attributeRegistry) invalidate() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.loadedAt = time.Time{}
+}
+
+// declaredAttributes returns the declared attribute types by name, or nil if
+// enforcement is off or nothing is declared.
+func (s *Server) declaredAttributes(ctx context.Context) map[string]string {
+	if s.attrEnforcement != AttributeEnforcementWarn && s.attrEnforcement != AttributeEnforcementReject {
+		return nil
+	}
+	as, ok := s.store.(store.ContextAttributeStore)
+	if !ok {
+		return nil
+	}
+
+	reg := s.registry
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if !reg.loadedAt.IsZero() && reg.now().Sub(reg.loadedAt) < attributeRegistryTTL {
+		return reg.declared
+	}
+	attrs, err := as.ListContextAttributes(ctx, store.DefaultProject)
+	if err != nil {
+		// Keep enforcing the last registry we saw; retry on the next lookup.
+		log.Printf("[attributes] failed to load attribute registry: %v", err)
+		return reg.declared
+	}
+	declared := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		declared[attr.Name] = attr.Type
+	}
+	if len(declared) == 0 {
+		declared = nil
+	}
+	reg.declared, reg.loadedAt = declared, reg.now()
+	return declared
+}
+
+// checkRuleAttributes checks the condition properties of ruleset against the
+// registry. In reject mode a violation fails the write; in warn mode it is
+// logged and counted.
+func (s *Server) checkRuleAttributes(r *http.Request, key string, ruleset []rules.Rule) *flagWriteError {
+	fields := s.ruleAttributeViolations(r.Context(), ruleset)
+	if len(fields) == 0 {
+		return nil
+	}
+	if s.attrEnforcement == AttributeEnforcementReject {
+		return &flagWriteError{
+			status:  http.StatusBadRequest,
+			code:    ErrCodeSchemaViolation,
+			message: "Targeting rules use undeclared or mistyped context attributes",
+			fields:  fields,
+		}
+	}
+	for field, message := range fields {
+		log.Printf("[attributes] flag %q: %s: %s", key, field, message)
+	}
+	return nil
+}
+
+// ruleAttributeViolations returns a message per offending condition, keyed by
+// its field path, and counts them.
+func (s *Server) ruleAttributeViolations(ctx context.Context, ruleset []rules.Rule) map[string]string {
+	declared := s.declaredAttributes(ctx)
+	if declared == nil {
+		return nil
+	}
+	fields := map[string]string{}
+	for i, rule := range ruleset {
+		for j, cond := range rule.Conditions {
+			if builtinAttributes[strings.ToLower(cond.Property)] {
+				continue
+			}
+			field := fmt.Sprintf("targeting_rules[%d].conditions[%d].property", i, j)
+			typ, ok := declared[cond.Property]
+			if !ok {
+				fields[field] = undeclaredAttributeMessage(cond.Property, declared)
+				telemetry.ContextAttributeViolations.WithLabelValues("rule", "undeclared").Inc()
+				continue
+			}
+			if info, ok := operatorInfo(cond.Operator); ok && !slices.Contains(info.AttributeTypes, typ) {
+				fields[field] = fmt.Sprintf("attribute %q is declared as %s, which operator %s does not apply to", cond.Property, typ, cond.Operator)
+				telemetry.ContextAttributeViolations.WithLabelValues("rule", "mistyped").Inc()
+			}
+		}
+	}
+	return fields
+}
+
+// checkUserContext checks a /v1/evaluate or OFREP context against the
+// registry. It returns the violations, keyed by attribute name, if the
+// request must be rejected.
+func (s *Server) checkUserContext(r *http.Request, ctx *engine.UserContext) map[string]string {
+	declared := s.declaredAttributes(r.Context())
+	if declared == nil || ctx == nil {
+		return nil
+	}
+	return s.contextViolations(declared, userContextAttributes(ctx), false)
+}
+
+// checkEvaluationContext is checkUserContext for /v1/flags/evaluate. GET
+// requests pass attributes as query parameters, so string values are
+// accepted for number and boolean attributes if they parse as such.
+func (s *Server) checkEvaluationContext(r *http.Request, ctx evaluation.Context) map[string]string {
+	declared := s.declaredAttributes(r.Context())
+	if declared == nil {
+		return nil
+	}
+	return s.contextViolations(declared, evaluationContextAttributes(ctx), r.Method == http.MethodGet)
+}
+
+func (s *Server) contextViolations(declared map[string]string, attrs map[string]any, fromQuery bool) map[string]string {
+	violations := map[string]string{}
+	for name, value := range attrs {
+		if builtinAttributes[strings.ToLower(name)] || value == nil {
+			continue
+		}
+		typ, ok := declared[name]
+		if !ok {
+			violations[name] = undeclaredAttributeMessage(name, declared)
+			telemetry.ContextAttributeViolations.WithLabelValues("evaluation", "undeclared").Inc()
+			continue
+		}
+		if got := attributeType(value); got != typ && !(fromQuery && parsesAs(value, typ)) {
+			violations[name] = fmt.Sprintf("attribute %q is declared as %s, got %s", name, typ, got)
+			telemetry.ContextAttributeViolations.WithLabelValues("evaluation", "mistyped").Inc()
+		}
+	}
+	if len(violations) == 0 || s.attrEnforcement != AttributeEnforcementReject {
+		return nil
+	}
+	return violations
+}
+
+// contextViolationFields prefixes violations with "context." for an error response.
+func contextViolationFields(violations map[string]string) map[string]string {
+	fields := make(map[string]string, len(violations))
+	for name, message := range violations {
+		fields["context."+name] = message
+	}
+	return fields
+}
+
+// contextViolationDetails joins violations into one sorted message.
+func contextViolationDetails(violations map[string]string) string {
+	messages := make([]string, 0, len(violations))
+	for _, message := range violations {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	return strings.Join(messages, "; ")
+}
+
+// parsesAs reports whether value is a string that parses as typ.
+func parsesAs(value any, typ string) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	switch typ {
+	case store.AttributeTypeNumber:
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	case store.AttributeTypeBoolean:
+		_, err := strconv.ParseBool(s)
+		return err == nil
+	}
+	return false
+}
+
+// undeclaredAttributeMessage reports an undeclared attribute, suggesting the
+// closest declared name to catch typos like "planName" for "plan".
+func undeclaredAttributeMessage(name string, declared map[string]string) string {
+	message := fmt.Sprintf("attribute %q is not declared", name)
+	if suggestion := closestAttribute(name, declared); suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+	}
+	return message
+}
+
+// closestAttribute returns the declared name closest to name: one that
+// differs only in case, is a prefix or extension of it (of at least three
+// characters), or is within an edit distance of 2. It returns "" if none is
+// close.
+func closestAttribute(name string, declared map[string]string) string {
+	lower := strings.ToLower(name)
+	best, bestDist := "", 3
+	for candidate := range declared {
+		c := strings.ToLower(candidate)
+		dist := editDistance(lower, c)
+		if dist > 0 && min(len(lower), len(c)) >= 3 && (strings.HasPrefix(lower, c) || strings.HasPrefix(c, lower)) {
+			dist = 1
+		}
+		if dist < bestDist || (dist == bestDist && candidate < best) {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
+
+// operatorInfo returns the catalog entry for op.
+func operatorInfo(op rules.Operator) (rules.OperatorInfo, bool) {
+	for _, info := range rules.Operators() {
+		if info.Operator == op {
+			return info, true
+		}
+	}
+	return rules.OperatorInfo{}, false
+}
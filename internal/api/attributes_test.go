@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func declareAttributes(t *testing.T, handler http.Handler) {
+	t.Helper()
+	for name, body := range map[string]string{
+		"plan": `{"type":"string","description":"Billing plan","example":"pro"}`,
+		"age":  `{"type":"number"}`,
+	} {
+		rr := doV2Request(t, handler, http.MethodPut, "/v2/projects/default/attributes/"+name, body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("declare %s: expected 200, got %d: %s", name, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestContextAttributes_CRUD(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+	declareAttributes(t, handler)
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v2/projects/default/attributes", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []store.ContextAttribute `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 || resp.Data[0].Name != "age" || resp.Data[1].Example != "pro" || resp.Data[1].Description != "Billing plan" {
+		t.Fatalf("unexpected attributes: %+v", resp.Data)
+	}
+
+	rr = doV2Request(t, handler, http.MethodDelete, "/v2/projects/default/attributes/age", "")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rr.Code)
+	}
+	rr = doV2Request(t, handler, http.MethodDelete, "/v2/projects/default/attributes/age", "")
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a second delete, got %d", rr.Code)
+	}
+	rr = doV2Request(t, handler, http.MethodGet, "/v2/projects/other/attributes", "")
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown project, got %d", rr.Code)
+	}
+}
+
+func TestContextAttributes_Validation(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	for _, tc := range []struct{ name, body, field string }{
+		{"plan", `{}`, "type"},
+		{"plan", `{"type":"date"}`, "type"},
+		{"plan", `{"type":"number","example":"pro"}`, "example"},
+		{"9lives", `{"type":"string"}`, "name"},
+	} {
+		rr := doV2Request(t, handler, http.MethodPut, "/v2/projects/default/attributes/"+tc.name, tc.body)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", tc.body, rr.Code)
+		}
+		if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields[tc.field] == "" {
+			t.Errorf("%s: expected an error on %q, got %+v", tc.body, tc.field, resp.Fields)
+		}
+	}
+}
+
+func TestContextAttributes_RejectRules(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetAttributeEnforcement(AttributeEnforcementReject)
+	handler := srv.Router()
+
+	flag := func(property, op, value string) string {
+		return `{"key":"checkout","enabled":true,"rollout":100,"targeting_rules":[{"id":"r1","conditions":[{"property":"` + property +
+			`","operator":"` + op + `","value":` + value + `}],"distribution":{"on":100}}]}`
+	}
+
+	// Nothing declared yet: no enforcement.
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", flag("planName", "eq", `"pro"`)); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 with an empty registry, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	declareAttributes(t, handler)
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", flag("planName", "eq", `"pro"`))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an undeclared attribute, got %d", rr.Code)
+	}
+	resp := decodeErrorResponse(t, rr.Body.Bytes())
+	if resp.Code != ErrCodeSchemaViolation || !strings.Contains(resp.Fields["targeting_rules[0].conditions[0].property"], `did you mean "plan"?`) {
+		t.Fatalf("unexpected error: %+v", resp)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPut, "/v2/projects/default/environments/prod/flags/checkout", `{"enabled":true,"targeting_rules":[{"id":"r1","conditions":[{"property":"plan","operator":"gt","value":3}],"distribution":{"on":100}}]}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an operator that does not fit the type, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	for _, body := range []string{flag("plan", "eq", `"pro"`), flag("age", "gte", "18"), flag("user_id", "eq", `"u1"`)} {
+		if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", body); rr.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestContextAttributes_RejectEvaluations(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetAttributeEnforcement(AttributeEnforcementReject)
+	handler := srv.Router()
+	declareAttributes(t, handler)
+
+	for _, tc := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPost, "/v1/evaluate", `{"context":{"id":"u1","plan":"pro","properties":{"age":31}}}`, http.StatusOK},
+		{http.MethodPost, "/v1/evaluate", `{"context":{"id":"u1","properties":{"planName":"pro"}}}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/evaluate", `{"context":{"id":"u1","properties":{"age":"31"}}}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/flags/evaluate", `{"user":{"id":"u1","attributes":{"age":true}}}`, http.StatusBadRequest},
+		{http.MethodGet, "/v1/flags/evaluate?userId=u1&age=31", "", http.StatusOK},
+		{http.MethodGet, "/v1/flags/evaluate?userId=u1&age=old", "", http.StatusBadRequest},
+		{http.MethodPost, "/ofrep/v1/evaluate/flags", `{"context":{"targetingKey":"u1","planName":"pro"}}`, http.StatusBadRequest},
+	} {
+		var body *bytes.Buffer
+		if tc.body != "" {
+			body = bytes.NewBufferString(tc.body)
+		} else {
+			body = &bytes.Buffer{}
+		}
+		req := httptest.NewRequest(tc.method, tc.path, body)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s %s %s: expected %d, got %d: %s", tc.method, tc.path, tc.body, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestContextAttributes_Warn(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetAttributeEnforcement(AttributeEnforcementWarn)
+	handler := srv.Router()
+	declareAttributes(t, handler)
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":100,"targeting_rules":[{"id":"r1","conditions":[{"property":"planName","operator":"eq","value":"pro"}],"distribution":{"on":100}}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("warn mode should accept the write, got %d: %s", rr.Code, rr.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/evaluate", bytes.NewBufferString(`{"context":{"id":"u1","properties":{"planName":"pro"}}}`))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("warn mode should evaluate, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestClosestAttribute(t *testing.T) {
+	declared := map[string]string{"plan": "string", "country": "string", "age": "number"}
+	for name, want := range map[string]string{
+		"planName": "plan",
+		"Plan":     "plan",
+		"contry":   "country",
+		"ag":       "age",
+		"browser":  "",
+	} {
+		if got := closestAttribute(name, declared); got != want {
+			t.Errorf("closestAttribute(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
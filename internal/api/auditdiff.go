@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+// The diff of an audit log entry is computed from its before and after
+// states, so clients can render a change without diffing the blobs
+// themselves:
+//
+//	GET /v1/admin/audit-logs/{id}/diff   field-by-field changes (admin+)
+//
+// Nested objects are compared field by field with dotted paths
+// (config.color); any other value, including arrays, is compared as a whole.
+// Entries written without states fall back to their stored changes.
+
+const (
+	auditDiffAdded   = "added"
+	auditDiffRemoved = "removed"
+	auditDiffChanged = "changed"
+)
+
+type auditFieldChange struct {
+	Field  string `json:"field"` // dotted path of the field
+	Change string `json:"change"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+type auditDiffResponse struct {
+	ID           string             `json:"id"`
+	Timestamp    string             `json:"timestamp"`
+	Action       string             `json:"action"`
+	ResourceType string             `json:"resource_type,omitempty"`
+	ResourceID   string             `json:"resource_id,omitempty"`
+	Environment  string             `json:"environment,omitempty"`
+	Changes      []auditFieldChange `json:"changes"` // sorted by field
+}
+
+// handleAuditLogDiff handles GET /v1/admin/audit-logs/{id}/diff.
+func (s *Server) handleAuditLogDiff(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		BadRequestErrorWithFields(w, r, ErrCodeValidation, "Invalid audit log ID format", map[string]string{
+			"id": "Audit log ID must be a valid UUID format",
+		})
+		return
+	}
+
+	pgStore := s.requirePostgresStore(w, r)
+	if pgStore == nil {
+		return // Error already written to response
+	}
+	log, err := pgStore.GetAuditLog(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !tenantOwnsRow(r, log.Tenant)) {
+		NotFoundError(w, r, "Audit log not found")
+		return
+	}
+	if err != nil {
+		InternalError(w, r, "Failed to get audit log")
+		return
+	}
+
+	info := auditLogToInfo(log)
+	writeJSON(w, http.StatusOK, auditDiffResponse{
+		ID:           info.ID,
+		Timestamp:    info.Timestamp,
+		Action:       info.Action,
+		ResourceType: info.ResourceType,
+		ResourceID:   info.ResourceID,
+		Environment:  info.Environment,
+		Changes:      auditLogDiff(log),
+	})
+}
+
+// auditLogDiff returns the field changes of an audit log entry.
+func auditLogDiff(log dbgen.AuditLog) []auditFieldChange {
+	if len(log.BeforeState) == 0 && len(log.AfterState) == 0 {
+		var stored map[string]any
+		if err := json.Unmarshal(log.Changes, &stored); err != nil {
+			return []auditFieldChange{}
+		}
+		return diffFromChanges(stored)
+	}
+	var before, after map[string]any
+	_ = json.Unmarshal(log.BeforeState, &before)
+	_ = json.Unmarshal(log.AfterState, &after)
+	return diffStates(before, after)
+}
+
+// diffStates compares two states field by field, descending into objects
+// present on both sides.
+func diffStates(before, after map[string]any) []auditFieldChange {
+	changes := make([]auditFieldChange, 0)
+	appendDiff(&changes, "", before, after)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func appendDiff(changes *[]auditFieldChange, prefix string, before, after map[string]any) {
+	for field := range audit.ComputeChanges(before, after) {
+		path := prefix + field
+		beforeVal, hadBefore := before[field]
+		afterVal, hasAfter := after[field]
+		beforeObj, beforeIsObj := beforeVal.(map[string]any)
+		afterObj, afterIsObj := afterVal.(map[string]any)
+		switch {
+		case beforeIsObj && afterIsObj:
+			appendDiff(changes, path+".", beforeObj, afterObj)
+		case !hadBefore:
+			*changes = append(*changes, auditFieldChange{Field: path, Change: auditDiffAdded, After: afterVal})
+		case !hasAfter:
+			*changes = append(*changes, auditFieldChange{Field: path, Change: auditDiffRemoved, Before: beforeVal})
+		default:
+			*changes = append(*changes, auditFieldChange{Field: path, Change: auditDiffChanged, Before: beforeVal, After: afterVal})
+		}
+	}
+}
+
+// diffFromChanges converts the stored {"field": {"before", "after"}} changes
+// of an entry without states. A missing or null side counts as absent.
+func diffFromChanges(stored map[string]any) []auditFieldChange {
+	before, after := map[string]any{}, map[string]any{}
+	for field, change := range stored {
+		values, ok := change.(map[string]any)
+		if !ok {
+			continue
+		}
+		if v := values["before"]; v != nil {
+			before[field] = v
+		}
+		if v := values["after"]; v != nil {
+			after[field] = v
+		}
+	}
+	return diffStates(before, after)
+}
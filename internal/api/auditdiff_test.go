@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+func TestAuditLogDiff(t *testing.T) {
+	log := dbgen.AuditLog{
+		BeforeState: []byte(`{"enabled":false,"rollout":10,"config":{"color":"red","size":1},"description":"old"}`),
+		AfterState:  []byte(`{"enabled":true,"rollout":10,"config":{"color":"blue","size":1},"tags":["a"]}`),
+	}
+	got := fmt.Sprint(auditLogDiff(log))
+	want := fmt.Sprint([]auditFieldChange{
+		{Field: "config.color", Change: auditDiffChanged, Before: "red", After: "blue"},
+		{Field: "description", Change: auditDiffRemoved, Before: "old"},
+		{Field: "enabled", Change: auditDiffChanged, Before: false, After: true},
+		{Field: "tags", Change: auditDiffAdded, After: []any{"a"}},
+	})
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestAuditLogDiff_StoredChanges(t *testing.T) {
+	log := dbgen.AuditLog{Changes: []byte(`{"rollout":{"before":10,"after":50},"key":{"before":null,"after":"checkout"}}`)}
+	got := fmt.Sprint(auditLogDiff(log))
+	want := fmt.Sprint([]auditFieldChange{
+		{Field: "key", Change: auditDiffAdded, After: "checkout"},
+		{Field: "rollout", Change: auditDiffChanged, Before: float64(10), After: float64(50)},
+	})
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if changes := auditLogDiff(dbgen.AuditLog{}); changes == nil || len(changes) != 0 {
+		t.Errorf("expected no changes for an empty entry, got %v", changes)
+	}
+}
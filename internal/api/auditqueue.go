@@ -0,0 +1,21 @@
+package api
+
+import "github.com/TimurManjosov/goflagship/internal/audit"
+
+// SetAuditQueue queues audit events in a local file at path before they are
+// written to the database (see audit.FileQueue), so they are not lost when
+// the in-memory queue is full, the database is unreachable or the process
+// dies. Events left from a previous run are written first. It is a no-op
+// without a Postgres store, which has no audit log.
+func (s *Server) SetAuditQueue(path string) error {
+	if s.auditService == nil {
+		return nil
+	}
+	q, err := audit.OpenFileQueue(path)
+	if err != nil {
+		return err
+	}
+	s.auditService.SetDurableQueue(q)
+	s.workers.Add(s.auditService.Workers()...)
+	return nil
+}
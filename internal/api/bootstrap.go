@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// bootstrapPollInterval is the polling interval recommended to SDKs that
+// cannot stream. It matches the Go SDK's default PollInterval.
+const bootstrapPollInterval = 30 * time.Second
+
+// clientBootstrapResponse is everything an SDK needs to initialize, returned
+// by GET /v1/client/bootstrap in a single round trip.
+type clientBootstrapResponse struct {
+	Env        string             `json:"env"`
+	ServerTime time.Time          `json:"serverTime"` // Lets clients detect clock skew
+	Snapshot   any                `json:"snapshot"`   // In the negotiated schema version, see snapshotschema.go
+	Streaming  bootstrapStreaming `json:"streaming"`
+	Polling    bootstrapPolling   `json:"polling"`
+	Rollout    bootstrapRollout   `json:"rollout"`
+}
+
+type bootstrapStreaming struct {
+	Enabled          bool   `json:"enabled"`
+	URL              string `json:"url"`
+	HeartbeatSeconds int    `json:"heartbeatSeconds"`
+}
+
+type bootstrapPolling struct {
+	URL             string `json:"url"`
+	IntervalSeconds int    `json:"intervalSeconds"`
+}
+
+// bootstrapRollout describes how users are bucketed, so client-side
+// evaluation matches the server.
+type bootstrapRollout struct {
+	Algorithm string `json:"algorithm"` // Hash of "userID:flagKey:salt"
+	Buckets   int    `json:"buckets"`
+	Salt      string `json:"salt,omitempty"`
+}
+
+// handleClientBootstrap handles GET /v1/client/bootstrap. Like the snapshot
+// endpoint it is served from the SDK key's environment (see sdk_keys.go).
+func (s *Server) handleClientBootstrap(w http.ResponseWriter, r *http.Request) {
+	schema, ok := requireSnapshotSchema(w, r)
+	if !ok {
+		return
+	}
+	env := s.requestEnvironment(r)
+	snap := s.snapshotForEnv(env)
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("ETag", snap.ETag)
+	setSnapshotHeaders(w, snap)
+	writeJSON(w, http.StatusOK, clientBootstrapResponse{
+		Env:        env,
+		ServerTime: time.Now().UTC(),
+		Snapshot:   schema.render(snap),
+		Streaming: bootstrapStreaming{
+			Enabled:          true,
+			URL:              "/v1/flags/stream",
+			HeartbeatSeconds: int(streamHeartbeatInterval / time.Second),
+		},
+		Polling: bootstrapPolling{
+			URL:             "/v1/flags/snapshot",
+			IntervalSeconds: int(bootstrapPollInterval / time.Second),
+		},
+		Rollout: bootstrapRollout{
+			Algorithm: "xxhash64",
+			Buckets:   100,
+			Salt:      snap.RolloutSalt,
+		},
+	})
+}
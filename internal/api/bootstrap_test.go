@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestClientBootstrap(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Env: "prod"})
+	srv := NewServer(st, "prod", "test-key")
+	if err := srv.RebuildSnapshot(ctx, "prod"); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/client/bootstrap", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		clientBootstrapResponse
+		Snapshot *snapshot.Snapshot `json:"snapshot"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Env != "prod" {
+		t.Errorf("expected env prod, got %q", resp.Env)
+	}
+	if resp.Snapshot == nil || resp.Snapshot.ETag != rr.Header().Get("ETag") {
+		t.Fatalf("snapshot ETag should match the response ETag header")
+	}
+	if _, ok := resp.Snapshot.Flags["checkout"]; !ok {
+		t.Errorf("expected checkout flag in snapshot, got %v", resp.Snapshot.Flags)
+	}
+	if time.Since(resp.ServerTime) > time.Minute {
+		t.Errorf("unexpected server time %v", resp.ServerTime)
+	}
+	if !resp.Streaming.Enabled || resp.Streaming.URL != "/v1/flags/stream" || resp.Polling.IntervalSeconds <= 0 {
+		t.Errorf("unexpected update config: %+v %+v", resp.Streaming, resp.Polling)
+	}
+	if resp.Rollout.Buckets != 100 {
+		t.Errorf("expected 100 buckets, got %d", resp.Rollout.Buckets)
+	}
+}
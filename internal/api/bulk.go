@@ -0,0 +1,247 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+)
+
+// POST /v1/flags/bulk-action applies one action to every flag in an
+// environment that carries at least one of the given tags, e.g. disabling
+// everything tagged "black-friday" once the event is over:
+//
+//	{"env": "prod", "tags": ["black-friday"], "action": "disable"}
+//
+// Actions are enable, disable, archive and delete. Archived flags stay in the
+// store but are left out of snapshots and evaluation.
+//
+// All writes are applied in one store transaction followed by a single
+// snapshot rebuild, so clients never see a half-applied action. Protected
+// flags follow the rules in protection.go: one protected match without an
+// override rejects the whole request. Audit events and webhooks fire per flag.
+
+const (
+	bulkActionEnable  = "enable"
+	bulkActionDisable = "disable"
+	bulkActionArchive = "archive"
+	bulkActionDelete  = "delete"
+)
+
+var bulkActions = []string{bulkActionEnable, bulkActionDisable, bulkActionArchive, bulkActionDelete}
+
+type bulkActionRequest struct {
+	Env    string   `json:"env,omitempty"` // defaults to s.env
+	Tags   []string `json:"tags"`
+	Action string   `json:"action"`
+}
+
+type bulkActionResponse struct {
+	Env       string   `json:"env"`
+	Action    string   `json:"action"`
+	Changed   []string `json:"changed"`             // keys written by this request
+	Unchanged []string `json:"unchanged,omitempty"` // matching keys already in the target state
+	ETag      string   `json:"etag"`
+
+	// Set by ?dry_run=true, see dryrun.go; Changed then lists the keys that
+	// would be written.
+	DryRun   bool            `json:"dry_run,omitempty"`
+	Snapshot *snapshotDiff   `json:"snapshot,omitempty"`
+	Webhooks []dryRunWebhook `json:"webhooks,omitempty"`
+}
+
+// requireBatchFlagStore returns the store as a BatchFlagStore, writing an
+// error response and returning nil if it cannot apply batches.
+func (s *Server) requireBatchFlagStore(w http.ResponseWriter, r *http.Request) store.BatchFlagStore {
+	if bs, ok := s.store.(store.BatchFlagStore); ok {
+		return bs
+	}
+	InternalError(w, r, "Bulk actions are not supported by this store")
+	return nil
+}
+
+func (s *Server) handleBulkAction(w http.ResponseWriter, r *http.Request) {
+	var req bulkActionRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	req.Env = strings.TrimSpace(req.Env)
+	if req.Env == "" {
+		req.Env = s.env
+	}
+
+	fields := map[string]string{}
+	if !slices.Contains(bulkActions, req.Action) {
+		fields["action"] = "Action must be one of: " + strings.Join(bulkActions, ", ")
+	}
+	if len(req.Tags) == 0 {
+		fields["tags"] = "At least one tag is required"
+	} else if message, ok := validation.ValidateTags(req.Tags).Errors["tags"]; ok {
+		fields["tags"] = message
+	}
+	if message, ok := validation.ValidateEnv(req.Env).Errors["env"]; ok {
+		fields["env"] = message
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+	if !requireTenantEnv(w, r, req.Env) {
+		return
+	}
+
+	bs := s.requireBatchFlagStore(w, r)
+	if bs == nil {
+		return
+	}
+
+	flags, err := s.store.GetAllFlags(r.Context(), req.Env)
+	if err != nil {
+		InternalError(w, r, "Failed to load flags")
+		return
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+
+	resp := bulkActionResponse{Env: req.Env, Action: req.Action, Changed: []string{}}
+	var batch store.FlagBatch
+	var matched, results []*store.Flag // results[i] is matched[i] after the action, nil if deleted
+	overrideReasons := map[string]string{}
+	for i := range flags {
+		flag := &flags[i]
+		if !slices.ContainsFunc(req.Tags, func(tag string) bool { return slices.Contains(flag.Tags, tag) }) {
+			continue
+		}
+		params, changed := bulkActionParams(flag, req.Action)
+		if !changed {
+			resp.Unchanged = append(resp.Unchanged, flag.Key)
+			continue
+		}
+		reason, werr := s.checkFlagProtection(r, flag, req.Action != bulkActionEnable)
+		if werr != nil {
+			werr.message = "Flag " + flag.Key + ": " + werr.message
+			werr.write(w, r)
+			return
+		}
+		if reason != "" {
+			overrideReasons[flag.Key] = reason
+		}
+		if werr := s.checkGuardrail(r.Context(), flag, params); werr != nil {
+			werr.message = "Flag " + flag.Key + ": " + werr.message
+			werr.write(w, r)
+			return
+		}
+		if werr := s.checkReadiness(r.Context(), flag, params); werr != nil {
+			werr.message = "Flag " + flag.Key + ": " + werr.message
+			werr.write(w, r)
+			return
+		}
+		if req.Action == bulkActionDelete {
+			batch.Deletes = append(batch.Deletes, store.FlagRef{Key: flag.Key, Env: flag.Env})
+			results = append(results, nil)
+		} else {
+			batch.Upserts = append(batch.Upserts, params)
+			results = append(results, flagFromParams(params))
+		}
+		matched = append(matched, flag)
+		resp.Changed = append(resp.Changed, flag.Key)
+	}
+
+	if isDryRun(r) {
+		preview, werr := s.dryRunFlagChanges(r, req.Env, matched, results)
+		if werr != nil {
+			werr.write(w, r)
+			return
+		}
+		resp.DryRun, resp.Snapshot, resp.Webhooks = true, preview.Snapshot, preview.Webhooks
+		resp.ETag = s.snapshotForEnv(req.Env).ETag
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if len(matched) > 0 {
+		if err := bs.ApplyFlagBatch(r.Context(), batch); err != nil {
+			InternalError(w, r, "Failed to apply bulk action")
+			return
+		}
+		if err := s.RebuildSnapshot(r.Context(), req.Env); err != nil {
+			InternalError(w, r, "Failed to rebuild snapshot")
+			return
+		}
+		s.recordBulkAction(r, req, matched, overrideReasons)
+	}
+
+	resp.ETag = s.snapshotForEnv(req.Env).ETag
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// bulkActionParams returns the upsert that applies action to flag and whether
+// it changes anything. For delete the params are unused.
+func bulkActionParams(flag *store.Flag, action string) (store.UpsertParams, bool) {
+	params := upsertParamsFromFlag(flag)
+	switch action {
+	case bulkActionEnable:
+		params.Enabled = true
+		return params, !flag.Enabled
+	case bulkActionDisable:
+		params.Enabled = false
+		return params, flag.Enabled
+	case bulkActionArchive:
+		params.Archived = true
+		return params, !flag.Archived
+	}
+	return params, true // delete
+}
+
+// recordBulkAction writes the audit events and webhooks for flags changed by
+// a bulk action, comparing each flag with its state after the batch.
+func (s *Server) recordBulkAction(r *http.Request, req bulkActionRequest, changed []*store.Flag, overrideReasons map[string]string) {
+	for _, before := range changed {
+		beforeState := flagToMap(before)
+		var afterState, changes map[string]any
+		action := audit.ActionDeleted
+		if req.Action != bulkActionDelete {
+			action = audit.ActionUpdated
+			if after, err := s.store.GetFlagByKey(r.Context(), before.Key, req.Env); err == nil {
+				afterState = flagToMap(after)
+			}
+			changes = audit.ComputeChanges(beforeState, afterState)
+		}
+		auditID := s.auditLog(r, action, audit.ResourceTypeFlag, before.Key, req.Env, beforeState, afterState, changes, audit.StatusSuccess, "")
+		if reason, ok := overrideReasons[before.Key]; ok {
+			s.auditProtectionOverride(r, before.Key, req.Env, reason)
+		}
+		s.dispatchWebhookEvent(r, auditID, before.Key, req.Env, beforeState, afterState, changes)
+	}
+}
+
+// upsertParamsFromFlag returns the upsert that stores flag unchanged.
+func upsertParamsFromFlag(flag *store.Flag) store.UpsertParams {
+	return store.UpsertParams{
+		Key:            flag.Key,
+		Description:    flag.Description,
+		Enabled:        flag.Enabled,
+		Rollout:        flag.Rollout,
+		Expression:     flag.Expression,
+		Config:         flag.Config,
+		ConfigJSON:     flag.ConfigJSON,
+		TargetingRules: flag.TargetingRules,
+		Variants:       flag.Variants,
+		Protected:      flag.Protected,
+		Tags:           flag.Tags,
+		Archived:       flag.Archived,
+		Owner:          flag.Owner,
+		TicketURL:      flag.TicketURL,
+		Metadata:       flag.Metadata,
+		ExpiresAt:      flag.ExpiresAt,
+		Env:            flag.Env,
+		Project:        flag.Project,
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func newBulkTestStore(t *testing.T) *store.MemoryStore {
+	t.Helper()
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	seed := []store.UpsertParams{
+		{Key: "bf_banner", Enabled: true, Rollout: 100, Tags: []string{"black-friday"}, Env: "prod"},
+		{Key: "bf_discount", Enabled: true, Rollout: 50, Tags: []string{"black-friday", "checkout"}, Env: "prod"},
+		{Key: "bf_off", Enabled: false, Tags: []string{"black-friday"}, Env: "prod"},
+		{Key: "search", Enabled: true, Rollout: 100, Tags: []string{"search"}, Env: "prod"},
+		{Key: "bf_banner", Enabled: true, Rollout: 100, Tags: []string{"black-friday"}, Env: "staging"},
+	}
+	for _, p := range seed {
+		if err := st.UpsertFlag(ctx, p); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return st
+}
+
+func TestBulkAction_DisableByTag(t *testing.T) {
+	st := newBulkTestStore(t)
+	handler := NewServer(st, "prod", "test-key").Router()
+	ctx := context.Background()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/bulk-action", `{"env":"prod","tags":["black-friday"],"action":"disable"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp bulkActionResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Changed) != 2 || resp.Changed[0] != "bf_banner" || resp.Changed[1] != "bf_discount" {
+		t.Errorf("changed = %v, want [bf_banner bf_discount]", resp.Changed)
+	}
+	if len(resp.Unchanged) != 1 || resp.Unchanged[0] != "bf_off" {
+		t.Errorf("unchanged = %v, want [bf_off]", resp.Unchanged)
+	}
+
+	for _, key := range []string{"bf_banner", "bf_discount"} {
+		flag, err := st.GetFlagByKey(ctx, key, "prod")
+		if err != nil || flag.Enabled {
+			t.Errorf("%s should be disabled, got %+v, %v", key, flag, err)
+		}
+		if snapshot.Load().Flags[key].Enabled {
+			t.Errorf("%s should be disabled in the snapshot", key)
+		}
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "search", "prod"); !flag.Enabled {
+		t.Error("untagged flag should be untouched")
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "bf_banner", "staging"); !flag.Enabled {
+		t.Error("flags in other environments should be untouched")
+	}
+}
+
+func TestBulkAction_ArchiveAndDelete(t *testing.T) {
+	st := newBulkTestStore(t)
+	handler := NewServer(st, "prod", "test-key").Router()
+	ctx := context.Background()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/bulk-action", `{"tags":["checkout"],"action":"archive"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("archive: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	flag, err := st.GetFlagByKey(ctx, "bf_discount", "prod")
+	if err != nil || !flag.Archived {
+		t.Fatalf("bf_discount should be archived, got %+v, %v", flag, err)
+	}
+	if _, ok := snapshot.Load().Flags["bf_discount"]; ok {
+		t.Error("archived flag should not be in the snapshot")
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags/bulk-action", `{"env":"staging","tags":["black-friday"],"action":"delete"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := st.GetFlagByKey(ctx, "bf_banner", "staging"); err == nil {
+		t.Error("bf_banner should be deleted from staging")
+	}
+	if _, err := st.GetFlagByKey(ctx, "bf_banner", "prod"); err != nil {
+		t.Error("bf_banner in prod should be untouched")
+	}
+}
+
+func TestBulkAction_Validation(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	for name, body := range map[string]string{
+		"unknown action": `{"tags":["x"],"action":"explode"}`,
+		"missing tags":   `{"action":"disable"}`,
+		"invalid tag":    `{"tags":["black friday"],"action":"disable"}`,
+	} {
+		rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/bulk-action", body)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestBulkAction_ProtectedFlagRejectsWholeRequest(t *testing.T) {
+	st := newBulkTestStore(t)
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "bf_payments", Enabled: true, Protected: true, Tags: []string{"black-friday"}, Env: "prod"})
+	srv := NewServer(st, "prod", "test-key")
+
+	bulkRequest := func(target string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(`{"tags":["black-friday"],"action":"disable"}`))
+		return r.WithContext(context.WithValue(r.Context(), auth.ContextKeyRole, auth.RoleAdmin))
+	}
+
+	rr := httptest.NewRecorder()
+	srv.handleBulkAction(rr, bulkRequest("/v1/flags/bulk-action"))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "bf_banner", "prod"); !flag.Enabled {
+		t.Error("no flag should change when one match is protected")
+	}
+
+	rr = httptest.NewRecorder()
+	srv.handleBulkAction(rr, bulkRequest("/v1/flags/bulk-action?force=true&reason=event+over"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 with override, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "bf_payments", "prod"); flag.Enabled || !flag.Protected {
+		t.Errorf("protected flag should be disabled and stay protected, got %+v", flag)
+	}
+}
@@ -0,0 +1,364 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// Change sets stage flag changes in one environment for review before they
+// reach it, then apply or discard them as a unit:
+//
+//	GET    /v1/changesets?env=&status=            list change sets, newest first (admin+)
+//	POST   /v1/changesets                         create an empty change set (admin+)
+//	GET    /v1/changesets/{id}                    get a change set (admin+)
+//	PUT    /v1/changesets/{id}/operations/{key}   stage the change to a flag (admin+)
+//	DELETE /v1/changesets/{id}/operations/{key}   unstage the change to a flag (admin+)
+//	GET    /v1/changesets/{id}/diff               combined diff against the current flags (admin+)
+//	POST   /v1/changesets/{id}/apply              apply all changes as one transaction (admin+)
+//	POST   /v1/changesets/{id}/discard            discard the change set (admin+)
+//
+// Operations have the shape of POST /v1/transactions operations, one per
+// flag; staging a flag again replaces its operation. They are only checked
+// for shape when staged: the diff and apply run every check of a transaction
+// against the flags as they are at that time, so a patch staged last week
+// applies to today's flag. Applying supports ?dry_run=true. Applied and
+// discarded change sets are kept, read-only, as a record.
+
+const (
+	// maxChangeSetNameLength is the maximum name length in characters.
+	maxChangeSetNameLength = 200
+
+	// maxChangeSetDescriptionLength is the maximum description length in characters.
+	maxChangeSetDescriptionLength = 1000
+)
+
+type createChangeSetRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Env         string `json:"env,omitempty"` // defaults to s.env
+}
+
+type listChangeSetsResponse struct {
+	ChangeSets []store.ChangeSet `json:"change_sets"`
+}
+
+// changeSetResultResponse is returned by the diff and apply endpoints.
+type changeSetResultResponse struct {
+	ChangeSet store.ChangeSet `json:"change_set"`
+	Result    any             `json:"result"` // *dryRunResponse for diffs and dry runs, else *transactionResponse
+}
+
+// requireChangeSetStore returns the store as a ChangeSetStore, writing an
+// error response and returning nil if it does not support change sets.
+func (s *Server) requireChangeSetStore(w http.ResponseWriter, r *http.Request) store.ChangeSetStore {
+	if cs, ok := s.store.(store.ChangeSetStore); ok {
+		return cs
+	}
+	InternalError(w, r, "Change sets are not supported by this store")
+	return nil
+}
+
+// loadChangeSet returns the {id} change set, writing an error response and
+// returning nil if it does not exist or belongs to another tenant.
+func (s *Server) loadChangeSet(w http.ResponseWriter, r *http.Request, cs store.ChangeSetStore) *store.ChangeSet {
+	set, err := cs.GetChangeSet(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, store.ErrChangeSetNotFound) {
+			NotFoundError(w, r, "Change set not found")
+			return nil
+		}
+		InternalError(w, r, "Failed to load change set")
+		return nil
+	}
+	if !tenantOwnsEnv(r, set.Env) {
+		NotFoundError(w, r, "Change set not found")
+		return nil
+	}
+	return set
+}
+
+func (s *Server) handleListChangeSets(w http.ResponseWriter, r *http.Request) {
+	cs := s.requireChangeSetStore(w, r)
+	if cs == nil {
+		return
+	}
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env != "" && !requireTenantEnv(w, r, env) {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", store.ChangeSetOpen, store.ChangeSetApplied, store.ChangeSetDiscarded:
+	default:
+		ValidationError(w, r, "Invalid query parameters", map[string]string{"status": "must be open, applied or discarded"})
+		return
+	}
+	sets, err := cs.ListChangeSets(r.Context(), env, status)
+	if err != nil {
+		InternalError(w, r, "Failed to list change sets")
+		return
+	}
+	sets = slices.DeleteFunc(sets, func(set store.ChangeSet) bool { return !tenantOwnsEnv(r, set.Env) })
+	writeJSON(w, http.StatusOK, listChangeSetsResponse{ChangeSets: sets})
+}
+
+func (s *Server) handleCreateChangeSet(w http.ResponseWriter, r *http.Request) {
+	var req createChangeSetRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	req.Env = strings.TrimSpace(req.Env)
+	if req.Env == "" {
+		req.Env = s.env
+	}
+
+	fields := map[string]string{}
+	switch {
+	case req.Name == "":
+		fields["name"] = "Name is required"
+	case utf8.RuneCountInString(req.Name) > maxChangeSetNameLength:
+		fields["name"] = "Name must not exceed 200 characters"
+	}
+	if utf8.RuneCountInString(req.Description) > maxChangeSetDescriptionLength {
+		fields["description"] = "Description must not exceed 1000 characters"
+	}
+	if message, ok := validation.ValidateEnv(req.Env).Errors["env"]; ok {
+		fields["env"] = message
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+	if !requireTenantEnv(w, r, req.Env) {
+		return
+	}
+
+	cs := s.requireChangeSetStore(w, r)
+	if cs == nil {
+		return
+	}
+	set, err := cs.CreateChangeSet(r.Context(), store.CreateChangeSetParams{
+		Name:        req.Name,
+		Description: req.Description,
+		Env:         req.Env,
+		Operations:  json.RawMessage("[]"),
+		CreatedBy:   createdByFromRequest(r),
+	})
+	if err != nil {
+		InternalError(w, r, "Failed to create change set")
+		return
+	}
+
+	s.auditLog(r, audit.ActionCreated, audit.ResourceTypeChangeSet, set.ID, set.Env, nil, changeSetToMap(set), nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusCreated, set)
+}
+
+func (s *Server) handleGetChangeSet(w http.ResponseWriter, r *http.Request) {
+	cs := s.requireChangeSetStore(w, r)
+	if cs == nil {
+		return
+	}
+	set := s.loadChangeSet(w, r, cs)
+	if set == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, set)
+}
+
+// handleStageChangeSetOperation handles PUT /v1/changesets/{id}/operations/{key}.
+func (s *Server) handleStageChangeSetOperation(w http.ResponseWriter, r *http.Request) {
+	var op transactionOperation
+	if !decodeJSON(w, r, &op, maxJSONBodySize) {
+		return
+	}
+	key := strings.TrimSpace(chi.URLParam(r, "key"))
+	op.Key = key
+	if op.Action == txActionUpsert && op.Flag != nil && strings.TrimSpace(op.Flag.Key) == "" {
+		op.Flag.Key = key
+	}
+	s.updateChangeSetOperations(w, r, func(ops []transactionOperation) ([]transactionOperation, bool) {
+		ops = slices.DeleteFunc(ops, func(o transactionOperation) bool { return o.key() == key })
+		return append(ops, op), true
+	})
+}
+
+// handleUnstageChangeSetOperation handles DELETE /v1/changesets/{id}/operations/{key}.
+func (s *Server) handleUnstageChangeSetOperation(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimSpace(chi.URLParam(r, "key"))
+	s.updateChangeSetOperations(w, r, func(ops []transactionOperation) ([]transactionOperation, bool) {
+		n := len(ops)
+		ops = slices.DeleteFunc(ops, func(o transactionOperation) bool { return o.key() == key })
+		return ops, len(ops) < n
+	})
+}
+
+// updateChangeSetOperations replaces the operations of the {id} change set
+// with update(operations), which reports false if there is no operation to
+// remove.
+func (s *Server) updateChangeSetOperations(w http.ResponseWriter, r *http.Request, update func([]transactionOperation) ([]transactionOperation, bool)) {
+	cs := s.requireChangeSetStore(w, r)
+	if cs == nil {
+		return
+	}
+	set := s.loadChangeSet(w, r, cs)
+	if set == nil {
+		return
+	}
+	if set.Status != store.ChangeSetOpen {
+		ConflictError(w, r, "Change set is "+set.Status)
+		return
+	}
+	ops, err := changeSetOperations(*set)
+	if err != nil {
+		InternalError(w, r, "Failed to read change set")
+		return
+	}
+	ops, ok := update(ops)
+	if !ok {
+		NotFoundError(w, r, "No change staged for this flag")
+		return
+	}
+	if len(ops) > 0 {
+		if fields := validateTransaction(transactionRequest{Env: set.Env, Operations: ops}); len(fields) > 0 {
+			ValidationError(w, r, "Validation failed for one or more operations", fields)
+			return
+		}
+	}
+	raw, err := json.Marshal(ops)
+	if err != nil {
+		InternalError(w, r, "Failed to update change set")
+		return
+	}
+
+	updated, err := cs.UpdateChangeSetOperations(r.Context(), set.ID, raw)
+	if err != nil {
+		writeChangeSetUpdateError(w, r, err)
+		return
+	}
+	s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeChangeSet, updated.ID, updated.Env,
+		changeSetToMap(*set), changeSetToMap(updated), nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// handleChangeSetDiff handles GET /v1/changesets/{id}/diff: a dry run of
+// applying the change set now.
+func (s *Server) handleChangeSetDiff(w http.ResponseWriter, r *http.Request) {
+	s.runChangeSet(w, asDryRun(r))
+}
+
+// handleApplyChangeSet handles POST /v1/changesets/{id}/apply.
+func (s *Server) handleApplyChangeSet(w http.ResponseWriter, r *http.Request) {
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	s.runChangeSet(w, r)
+}
+
+// runChangeSet runs the operations of the {id} change set as a transaction
+// and, unless r is a dry run, marks the change set applied.
+func (s *Server) runChangeSet(w http.ResponseWriter, r *http.Request) {
+	cs := s.requireChangeSetStore(w, r)
+	if cs == nil {
+		return
+	}
+	set := s.loadChangeSet(w, r, cs)
+	if set == nil {
+		return
+	}
+	if set.Status != store.ChangeSetOpen {
+		ConflictError(w, r, "Change set is "+set.Status)
+		return
+	}
+	ops, err := changeSetOperations(*set)
+	if err != nil {
+		InternalError(w, r, "Failed to read change set")
+		return
+	}
+
+	result, werr := s.runTransaction(r, transactionRequest{Env: set.Env, Operations: ops})
+	if werr != nil {
+		werr.write(w, r)
+		return
+	}
+	if isDryRun(r) {
+		writeJSON(w, http.StatusOK, changeSetResultResponse{ChangeSet: *set, Result: result})
+		return
+	}
+
+	applied, err := cs.CloseChangeSet(r.Context(), set.ID, store.ChangeSetApplied, createdByFromRequest(r))
+	if err != nil {
+		// The flags are written; only the change set status is stale.
+		log.Printf("[changesets] mark %s applied: %v", set.ID, err)
+		applied = *set
+	}
+	s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeChangeSet, set.ID, set.Env,
+		changeSetToMap(*set), changeSetToMap(applied), nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, changeSetResultResponse{ChangeSet: applied, Result: result})
+}
+
+// handleDiscardChangeSet handles POST /v1/changesets/{id}/discard.
+func (s *Server) handleDiscardChangeSet(w http.ResponseWriter, r *http.Request) {
+	cs := s.requireChangeSetStore(w, r)
+	if cs == nil {
+		return
+	}
+	set := s.loadChangeSet(w, r, cs)
+	if set == nil {
+		return
+	}
+	discarded, err := cs.CloseChangeSet(r.Context(), set.ID, store.ChangeSetDiscarded, createdByFromRequest(r))
+	if err != nil {
+		writeChangeSetUpdateError(w, r, err)
+		return
+	}
+	s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeChangeSet, set.ID, set.Env,
+		changeSetToMap(*set), changeSetToMap(discarded), nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, discarded)
+}
+
+// writeChangeSetUpdateError writes the response for a failed change set update.
+func writeChangeSetUpdateError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrChangeSetNotFound):
+		NotFoundError(w, r, "Change set not found")
+	case errors.Is(err, store.ErrChangeSetClosed):
+		ConflictError(w, r, "Change set is already applied or discarded")
+	default:
+		InternalError(w, r, "Failed to update change set")
+	}
+}
+
+// changeSetOperations decodes the staged operations of set.
+func changeSetOperations(set store.ChangeSet) ([]transactionOperation, error) {
+	var ops []transactionOperation
+	if len(set.Operations) == 0 {
+		return ops, nil
+	}
+	if err := json.Unmarshal(set.Operations, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// changeSetToMap converts a change set for audit logging.
+func changeSetToMap(set store.ChangeSet) map[string]any {
+	return map[string]any{
+		"name":       set.Name,
+		"env":        set.Env,
+		"status":     set.Status,
+		"operations": set.Operations,
+	}
+}
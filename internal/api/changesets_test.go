@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func createTestChangeSet(t *testing.T, handler http.Handler) store.ChangeSet {
+	t.Helper()
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/changesets", `{"name":"checkout launch","env":"prod"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var set store.ChangeSet
+	if err := json.Unmarshal(rr.Body.Bytes(), &set); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return set
+}
+
+func TestChangeSet_StageDiffApply(t *testing.T) {
+	srv, st := newTransactionTestServer(t)
+	handler := srv.Router()
+	ctx := context.Background()
+	set := createTestChangeSet(t, handler)
+	base := "/v1/changesets/" + set.ID
+
+	for path, body := range map[string]string{
+		base + "/operations/new_checkout":  `{"action":"patch","patch":{"rollout":50}}`,
+		base + "/operations/old_checkout":  `{"action":"patch","patch":{"enabled":false}}`,
+		base + "/operations/checkout_beta": `{"action":"delete"}`,
+	} {
+		if rr := doV2Request(t, handler, http.MethodPut, path, body); rr.Code != http.StatusOK {
+			t.Fatalf("stage %s: expected 200, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+	}
+	// Staging a flag again replaces its operation.
+	if rr := doV2Request(t, handler, http.MethodPut, base+"/operations/new_checkout", `{"action":"patch","patch":{"enabled":true}}`); rr.Code != http.StatusOK {
+		t.Fatalf("restage: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodDelete, base+"/operations/checkout_beta", ""); rr.Code != http.StatusOK {
+		t.Fatalf("unstage: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr := doV2Request(t, handler, http.MethodGet, base+"/diff", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("diff: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var diff struct {
+		ChangeSet store.ChangeSet `json:"change_set"`
+		Result    dryRunResponse  `json:"result"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &diff); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !diff.Result.DryRun || len(diff.Result.Changes) != 2 || diff.Result.Snapshot == nil || len(diff.Result.Snapshot.Changed) != 2 {
+		t.Errorf("unexpected diff %+v", diff.Result)
+	}
+	if f, _ := st.GetFlagByKey(ctx, "new_checkout", "prod"); f.Enabled {
+		t.Error("diff must not write")
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, base+"/apply", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("apply: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var applied struct {
+		ChangeSet store.ChangeSet     `json:"change_set"`
+		Result    transactionResponse `json:"result"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &applied); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if applied.ChangeSet.Status != store.ChangeSetApplied || !applied.Result.Applied {
+		t.Errorf("unexpected apply response %+v", applied)
+	}
+	if f, _ := st.GetFlagByKey(ctx, "new_checkout", "prod"); !f.Enabled || f.Rollout != 100 {
+		t.Errorf("new_checkout should be enabled by the restaged patch, got %+v", f)
+	}
+	if f, _ := st.GetFlagByKey(ctx, "old_checkout", "prod"); f.Enabled {
+		t.Error("old_checkout should be disabled")
+	}
+	if _, err := st.GetFlagByKey(ctx, "checkout_beta", "prod"); err != nil {
+		t.Error("checkout_beta was unstaged and should be kept")
+	}
+
+	// Applied change sets are read-only.
+	if rr := doV2Request(t, handler, http.MethodPost, base+"/apply", ""); rr.Code != http.StatusConflict {
+		t.Errorf("second apply: expected 409, got %d", rr.Code)
+	}
+	if rr := doV2Request(t, handler, http.MethodPut, base+"/operations/old_checkout", `{"action":"delete"}`); rr.Code != http.StatusConflict {
+		t.Errorf("stage after apply: expected 409, got %d", rr.Code)
+	}
+}
+
+func TestChangeSet_ApplyFailureKeepsChangeSetOpen(t *testing.T) {
+	srv, st := newTransactionTestServer(t)
+	handler := srv.Router()
+	set := createTestChangeSet(t, handler)
+	base := "/v1/changesets/" + set.ID
+
+	if rr := doV2Request(t, handler, http.MethodPut, base+"/operations/old_checkout", `{"action":"patch","patch":{"enabled":false}}`); rr.Code != http.StatusOK {
+		t.Fatalf("stage: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	// The staged flag disappears before the change set is applied.
+	if err := st.DeleteFlag(context.Background(), "old_checkout", "prod"); err != nil {
+		t.Fatalf("DeleteFlag: %v", err)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, base+"/apply", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("apply: expected 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got, err := st.GetChangeSet(context.Background(), set.ID)
+	if err != nil || got.Status != store.ChangeSetOpen {
+		t.Fatalf("change set should stay open, got %+v, %v", got, err)
+	}
+
+	rr := doV2Request(t, handler, http.MethodPost, base+"/discard", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("discard: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/changesets?status=discarded", "")
+	var list listChangeSetsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || len(list.ChangeSets) != 1 {
+		t.Errorf("expected one discarded change set, got %s", rr.Body.String())
+	}
+}
+
+func TestChangeSet_StageValidatesOperation(t *testing.T) {
+	srv, _ := newTransactionTestServer(t)
+	handler := srv.Router()
+	set := createTestChangeSet(t, handler)
+
+	rr := doV2Request(t, handler, http.MethodPut, "/v1/changesets/"+set.ID+"/operations/new_checkout", `{"action":"rename"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if fields := decodeErrorResponse(t, rr.Body.Bytes()).Fields; fields["operations[0].action"] == "" {
+		t.Errorf("expected an operations[0].action error, got %v", fields)
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/changesets/unknown", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown change set: expected 404, got %d", rr.Code)
+	}
+}
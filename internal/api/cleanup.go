@@ -0,0 +1,281 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+// A periodic job (weekly by default) reports flags of the default environment
+// that are probably dead, and can optionally archive them:
+//
+//	GET /v1/flags/cleanup-report   preview the report without archiving (admin+)
+//
+// A reported flag carries one or more reasons:
+//   - rolled_out: enabled at 100% and unchanged for StaleAfter; remove it from code
+//   - fully_off: disabled or at 0% and unchanged for StaleAfter
+//   - not_evaluated: not evaluated (server-side or via /v1/exposures) for StaleAfter
+//
+// Evaluation times are tracked in memory (see evaltracker.go), so not_evaluated
+// is only reported once the server has been up for StaleAfter. Flags carry no
+// ticket metadata, so ticket close dates are not considered.
+//
+// The report is delivered as a "report.cleanup" webhook event. With
+// AutoArchive, flags that have been fully_off or not_evaluated for a further
+// Grace are archived in one batch. rolled_out flags are never archived, since
+// archiving would turn them off for users, and neither are protected flags.
+
+const (
+	cleanupReasonRolledOut    = "rolled_out"
+	cleanupReasonFullyOff     = "fully_off"
+	cleanupReasonNotEvaluated = "not_evaluated"
+)
+
+// CleanupPolicy configures the stale flag cleanup job.
+type CleanupPolicy struct {
+	StaleAfter  time.Duration // how long a flag must be unchanged or unused to be reported
+	AutoArchive bool          // archive reported flags once Grace has passed (opt-in)
+	Grace       time.Duration // extra time a flag must stay stale before it is archived
+}
+
+// DefaultCleanupPolicy reports flags stale for 30 days and does not archive.
+func DefaultCleanupPolicy() CleanupPolicy {
+	return CleanupPolicy{
+		StaleAfter: 30 * 24 * time.Hour,
+		Grace:      14 * 24 * time.Hour,
+	}
+}
+
+// SetCleanupPolicy replaces the policy used by the cleanup job and report.
+func (s *Server) SetCleanupPolicy(policy CleanupPolicy) {
+	s.cleanupPolicy = policy
+}
+
+type cleanupReport struct {
+	Env         string             `json:"env"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	StaleAfter  string             `json:"stale_after"`
+	AutoArchive bool               `json:"auto_archive"`
+	Flags       []cleanupCandidate `json:"flags"`
+	Archived    []string           `json:"archived,omitempty"`
+}
+
+type cleanupCandidate struct {
+	Key             string     `json:"key"`
+	Reasons         []string   `json:"reasons"`
+	Enabled         bool       `json:"enabled"`
+	Rollout         int32      `json:"rollout"`
+	Protected       bool       `json:"protected,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at,omitempty"`
+	ArchiveAfter    *time.Time `json:"archive_after,omitempty"` // set when the flag may be auto-archived
+
+	flag *store.Flag
+}
+
+func (s *Server) handleCleanupReport(w http.ResponseWriter, r *http.Request) {
+	report, err := s.buildCleanupReport(r.Context(), time.Now().UTC())
+	if err != nil {
+		InternalError(w, r, "Failed to build cleanup report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// RunCleanupReports runs RunCleanup every interval until ctx is cancelled.
+// It returns immediately if interval is not positive.
+func (s *Server) RunCleanupReports(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunCleanup(ctx, time.Now().UTC()); err != nil {
+				log.Printf("[cleanup] run failed: %v", err)
+			}
+			worker.Processed(ctx)
+		}
+	}
+}
+
+// RunCleanup builds the cleanup report as of now, archives eligible flags if
+// the policy allows it, and dispatches the report as a webhook event.
+func (s *Server) RunCleanup(ctx context.Context, now time.Time) (*cleanupReport, error) {
+	report, err := s.buildCleanupReport(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	if s.cleanupPolicy.AutoArchive {
+		archived, err := s.archiveStaleFlags(ctx, report, now)
+		if err != nil {
+			return nil, err
+		}
+		report.Archived = archived
+	}
+	log.Printf("[cleanup] env=%s stale=%d archived=%d", report.Env, len(report.Flags), len(report.Archived))
+
+	if s.webhookDispatcher != nil {
+		s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+			ForReport(webhook.EventCleanupReport, "cleanup", report.Env, cleanupReportData(report)).
+			Build())
+	}
+	return report, nil
+}
+
+// buildCleanupReport lists the stale flags of the default environment.
+func (s *Server) buildCleanupReport(ctx context.Context, now time.Time) (*cleanupReport, error) {
+	flags, err := s.store.GetAllFlags(ctx, s.env)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+
+	policy := s.cleanupPolicy
+	report := &cleanupReport{
+		Env:         s.env,
+		GeneratedAt: now,
+		StaleAfter:  policy.StaleAfter.String(),
+		AutoArchive: policy.AutoArchive,
+		Flags:       []cleanupCandidate{},
+	}
+	cutoff := now.Add(-policy.StaleAfter)
+	trackedLongEnough := !s.evalTracker.startedAt.After(cutoff)
+
+	for i := range flags {
+		flag := &flags[i]
+		if flag.Archived {
+			continue
+		}
+		c := cleanupCandidate{
+			Key:       flag.Key,
+			Enabled:   flag.Enabled,
+			Rollout:   flag.Rollout,
+			Protected: flag.Protected,
+			UpdatedAt: flag.UpdatedAt,
+			flag:      flag,
+		}
+		var archivableSince time.Time
+		unchanged := flag.UpdatedAt.Before(cutoff)
+		switch {
+		case unchanged && (!flag.Enabled || flag.Rollout == 0):
+			c.Reasons = append(c.Reasons, cleanupReasonFullyOff)
+			archivableSince = flag.UpdatedAt
+		case unchanged && flag.Rollout == 100:
+			c.Reasons = append(c.Reasons, cleanupReasonRolledOut)
+		}
+
+		lastEval, evaluated := s.evalTracker.lastEvaluated(s.env, flag.Key)
+		if evaluated {
+			c.LastEvaluatedAt = &lastEval
+		}
+		if trackedLongEnough && unchanged && (!evaluated || lastEval.Before(cutoff)) {
+			c.Reasons = append(c.Reasons, cleanupReasonNotEvaluated)
+			unusedSince := latest(flag.UpdatedAt, s.evalTracker.startedAt, lastEval)
+			if archivableSince.IsZero() || unusedSince.Before(archivableSince) {
+				archivableSince = unusedSince
+			}
+		}
+		if len(c.Reasons) == 0 {
+			continue
+		}
+		if !archivableSince.IsZero() && !flag.Protected && !slices.Contains(c.Reasons, cleanupReasonRolledOut) {
+			archiveAfter := archivableSince.Add(policy.StaleAfter + policy.Grace)
+			c.ArchiveAfter = &archiveAfter
+		}
+		report.Flags = append(report.Flags, c)
+	}
+	return report, nil
+}
+
+// archiveStaleFlags archives every reported flag whose ArchiveAfter has
+// passed, in one batch with a single snapshot rebuild, and returns their keys.
+func (s *Server) archiveStaleFlags(ctx context.Context, report *cleanupReport, now time.Time) ([]string, error) {
+	bs, ok := s.store.(store.BatchFlagStore)
+	if !ok {
+		return nil, nil
+	}
+
+	var batch store.FlagBatch
+	var archived []*store.Flag
+	for _, c := range report.Flags {
+		if c.ArchiveAfter == nil || c.ArchiveAfter.After(now) {
+			continue
+		}
+		params, _ := bulkActionParams(c.flag, bulkActionArchive)
+		batch.Upserts = append(batch.Upserts, params)
+		archived = append(archived, c.flag)
+	}
+	if len(archived) == 0 {
+		return nil, nil
+	}
+	if err := bs.ApplyFlagBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+	if err := s.RebuildSnapshot(ctx, report.Env); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(archived))
+	for _, before := range archived {
+		beforeState := flagToMap(before)
+		var afterState map[string]any
+		if after, err := s.store.GetFlagByKey(ctx, before.Key, report.Env); err == nil {
+			afterState = flagToMap(after)
+		}
+		changes := audit.ComputeChanges(beforeState, afterState)
+		auditID := s.logSystemAudit(audit.NewSystemEventBuilder().
+			ForResource(audit.ResourceTypeFlag, before.Key).
+			WithAction(audit.ActionUpdated).
+			WithEnvironment(report.Env).
+			WithBeforeState(beforeState).
+			WithAfterState(afterState).
+			WithChanges(changes).
+			WithNote("archived by stale flag cleanup"))
+		if s.webhookDispatcher != nil {
+			s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+				ForFlag(before.Key, report.Env).
+				WithStates(beforeState, afterState).
+				WithChanges(changes).
+				WithAuditEventID(auditID).
+				Build())
+		}
+		keys = append(keys, before.Key)
+	}
+	return keys, nil
+}
+
+// cleanupReportData converts report into the generic webhook payload.
+func cleanupReportData(report *cleanupReport) map[string]any {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return nil
+	}
+	var data map[string]any
+	_ = json.Unmarshal(b, &data)
+	return data
+}
+
+// latest returns the latest of times.
+func latest(times ...time.Time) time.Time {
+	var result time.Time
+	for _, t := range times {
+		if t.After(result) {
+			result = t
+		}
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func newCleanupTestServer(t *testing.T) (*Server, *store.MemoryStore) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	seed := []store.UpsertParams{
+		{Key: "old_off", Enabled: false, Env: "prod"},
+		{Key: "old_full", Enabled: true, Rollout: 100, Env: "prod"},
+		{Key: "active", Enabled: true, Rollout: 50, Env: "prod"},
+		{Key: "guarded", Enabled: false, Protected: true, Env: "prod"},
+	}
+	for _, p := range seed {
+		if err := st.UpsertFlag(ctx, p); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	srv := NewServer(st, "prod", "test-key")
+	srv.evalTracker.startedAt = time.Now().UTC().Add(-90 * 24 * time.Hour)
+	return srv, st
+}
+
+func TestCleanupReport_Reasons(t *testing.T) {
+	srv, _ := newCleanupTestServer(t)
+	now := time.Now().UTC().Add(31 * 24 * time.Hour)
+	srv.evalTracker.now = func() time.Time { return now.Add(-24 * time.Hour) }
+	srv.evalTracker.touch("prod", "active")
+
+	report, err := srv.buildCleanupReport(context.Background(), now)
+	if err != nil {
+		t.Fatalf("buildCleanupReport: %v", err)
+	}
+	byKey := map[string]cleanupCandidate{}
+	for _, c := range report.Flags {
+		byKey[c.Key] = c
+	}
+
+	if _, ok := byKey["active"]; ok {
+		t.Error("recently evaluated partial rollout should not be reported")
+	}
+	if c := byKey["old_off"]; !slices.Equal(c.Reasons, []string{cleanupReasonFullyOff, cleanupReasonNotEvaluated}) || c.ArchiveAfter == nil {
+		t.Errorf("old_off: unexpected candidate %+v", c)
+	}
+	if c := byKey["old_full"]; !slices.Contains(c.Reasons, cleanupReasonRolledOut) || c.ArchiveAfter != nil {
+		t.Errorf("old_full should be reported as rolled_out and never archived, got %+v", c)
+	}
+	if c, ok := byKey["guarded"]; !ok || c.ArchiveAfter != nil {
+		t.Errorf("protected flag should be reported but never archived, got %+v", c)
+	}
+}
+
+func TestCleanupReport_NotEvaluatedNeedsTrackingHistory(t *testing.T) {
+	srv, _ := newCleanupTestServer(t)
+	now := time.Now().UTC().Add(31 * 24 * time.Hour)
+	srv.evalTracker.startedAt = now.Add(-time.Hour) // restarted recently
+
+	report, err := srv.buildCleanupReport(context.Background(), now)
+	if err != nil {
+		t.Fatalf("buildCleanupReport: %v", err)
+	}
+	for _, c := range report.Flags {
+		if slices.Contains(c.Reasons, cleanupReasonNotEvaluated) {
+			t.Errorf("%s: not_evaluated must not be reported without tracking history", c.Key)
+		}
+	}
+}
+
+func TestRunCleanup_AutoArchiveAfterGrace(t *testing.T) {
+	srv, st := newCleanupTestServer(t)
+	ctx := context.Background()
+	policy := DefaultCleanupPolicy()
+	policy.AutoArchive = true
+	srv.SetCleanupPolicy(policy)
+
+	report, err := srv.RunCleanup(ctx, time.Now().UTC().Add(31*24*time.Hour))
+	if err != nil {
+		t.Fatalf("RunCleanup: %v", err)
+	}
+	if len(report.Archived) != 0 {
+		t.Fatalf("nothing should be archived within the grace period, got %v", report.Archived)
+	}
+
+	later := time.Now().UTC().Add(45 * 24 * time.Hour)
+	srv.evalTracker.now = func() time.Time { return later.Add(-time.Hour) }
+	srv.evalTracker.touch("prod", "active")
+	report, err = srv.RunCleanup(ctx, later)
+	if err != nil {
+		t.Fatalf("RunCleanup: %v", err)
+	}
+	if !slices.Equal(report.Archived, []string{"old_off"}) {
+		t.Fatalf("archived = %v, want [old_off]", report.Archived)
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "old_off", "prod"); !flag.Archived {
+		t.Error("old_off should be archived in the store")
+	}
+	if _, ok := snapshot.Load().Flags["old_off"]; ok {
+		t.Error("archived flag should be removed from the snapshot")
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "old_full", "prod"); flag.Archived {
+		t.Error("rolled out flag must not be archived")
+	}
+}
+
+func TestCleanupReportEndpoint(t *testing.T) {
+	srv, _ := newCleanupTestServer(t)
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/cleanup-report", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var report cleanupReport
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Env != "prod" || report.StaleAfter != "720h0m0s" || report.AutoArchive {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.Flags) != 0 {
+		t.Errorf("freshly written flags should not be stale, got %+v", report.Flags)
+	}
+}
+
+func TestEvaluationTracker_RecordsEvaluations(t *testing.T) {
+	srv, _ := newCleanupTestServer(t)
+	if err := srv.RebuildSnapshot(context.Background(), "prod"); err != nil {
+		t.Fatalf("RebuildSnapshot: %v", err)
+	}
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/evaluate", `{"flagKey":"active","context":{"id":"user-1"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, ok := srv.evalTracker.lastEvaluated("prod", "active"); !ok {
+		t.Error("evaluation of active should be tracked")
+	}
+	if _, ok := srv.evalTracker.lastEvaluated("prod", "old_off"); ok {
+		t.Error("old_off was not evaluated")
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+)
+
+// SnapshotPublisher tells other API replicas about snapshot rebuilds, so
+// their SSE and long-poll clients see flag changes made through this one
+// (see cluster.Bridge).
+type SnapshotPublisher interface {
+	Publish(ctx context.Context, env, etag string)
+}
+
+// SetSnapshotPublisher makes RebuildSnapshot publish every rebuild with p.
+func (s *Server) SetSnapshotPublisher(p SnapshotPublisher) {
+	s.snapshotPublisher = p
+}
+
+// ApplySnapshotUpdate rebuilds the snapshot of env after another replica
+// rebuilt it with etag, notifying this replica's clients. Nothing happens if
+// the snapshot already has etag, or if env is not the default environment and
+// this replica has not built its snapshot yet (it does on first use). The
+// rebuild is not published again.
+func (s *Server) ApplySnapshotUpdate(ctx context.Context, env, etag string) error {
+	if env != s.env {
+		if _, ok := snapshot.LoadEnv(env); !ok {
+			return nil
+		}
+	}
+	if s.snapshotForEnv(env).ETag == etag {
+		return nil
+	}
+	_, err := s.rebuildSnapshot(ctx, env)
+	return err
+}
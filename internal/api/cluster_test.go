@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+type recordingPublisher struct{ etags []string }
+
+func (p *recordingPublisher) Publish(_ context.Context, env, etag string) {
+	p.etags = append(p.etags, env+" "+etag)
+}
+
+func TestSnapshotPublisher(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	pub := &recordingPublisher{}
+	srv.SetSnapshotPublisher(pub)
+
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "replicated", Enabled: true, Rollout: 100, Env: "prod"})
+	if err := srv.RebuildSnapshot(ctx, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	etag := snapshot.Load().ETag
+	if len(pub.etags) != 1 || pub.etags[0] != "prod "+etag {
+		t.Fatalf("published %v, want [prod %s]", pub.etags, etag)
+	}
+
+	// Another replica changed the flag: the update rebuilds the snapshot
+	// without publishing it again.
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "replicated", Enabled: false, Rollout: 100, Env: "prod"})
+	updates, unsubscribe := snapshot.Subscribe()
+	defer unsubscribe()
+	if err := srv.ApplySnapshotUpdate(ctx, "prod", "W/\"other\""); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Load().ETag == etag {
+		t.Error("snapshot was not rebuilt")
+	}
+	select {
+	case <-updates:
+	default:
+		t.Error("local subscribers were not notified")
+	}
+	if len(pub.etags) != 1 {
+		t.Errorf("applied update was published again: %v", pub.etags)
+	}
+
+	// Up to date or never built: nothing to do.
+	if err := srv.ApplySnapshotUpdate(ctx, "prod", snapshot.Load().ETag); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.ApplySnapshotUpdate(ctx, "never-built", "W/\"x\""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := snapshot.LoadEnv("never-built"); ok {
+		t.Error("snapshot of an unused environment was built")
+	}
+}
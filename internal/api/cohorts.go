@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Exposures that identify a user (user_hash, plus user_id from SDKs with
+// ExposureOptions.IncludeUserIDs) are kept per flag and variant, so the users
+// of a variant can be followed up on after an experiment:
+//
+//	GET /v1/flags/{key}/cohort?env=&variant=&format=json|csv   users exposed to a variant (admin+)
+//
+// Flags without variants have the cohorts "on" and "off". Like insights,
+// cohorts are kept in memory per server instance: users are dropped
+// cohortRetention after their last exposure, and at most maxCohortUsers are
+// kept per flag.
+
+const (
+	cohortRetention = 30 * 24 * time.Hour
+	maxCohortUsers  = 100000
+)
+
+type cohortMember struct {
+	UserID    string    `json:"user_id,omitempty"` // empty unless the SDK sends raw IDs
+	UserHash  string    `json:"user_hash,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+
+	variant string
+}
+
+type cohortResponse struct {
+	Key     string         `json:"key"`
+	Env     string         `json:"env"`
+	Variant string         `json:"variant"`
+	Count   int            `json:"count"`
+	Users   []cohortMember `json:"users"` // first exposed first
+}
+
+// cohortTracker records the users exposed to each flag.
+type cohortTracker struct {
+	mu    sync.Mutex
+	flags map[string]map[string]*cohortMember // "env/key" -> "variant\x00user" -> member
+	now   func() time.Time
+}
+
+func newCohortTracker() *cohortTracker {
+	return &cohortTracker{flags: make(map[string]map[string]*cohortMember), now: time.Now}
+}
+
+// cohortLabel is the cohort of an exposure: its variant, or on/off.
+func cohortLabel(variant string, enabled bool) string {
+	switch {
+	case variant != "":
+		return variant
+	case enabled:
+		return "on"
+	}
+	return "off"
+}
+
+// record adds the users of accepted exposures in env.
+func (t *cohortTracker) record(env string, events []exposureEvent) {
+	now := t.now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, e := range events {
+		user := e.UserHash
+		if user == "" {
+			user = e.UserID
+		}
+		if user == "" {
+			continue
+		}
+		members := t.flags[env+"/"+e.Flag]
+		if members == nil {
+			members = make(map[string]*cohortMember)
+			t.flags[env+"/"+e.Flag] = members
+		}
+		label := cohortLabel(e.Variant, e.Enabled)
+		id := label + "\x00" + user
+		m := members[id]
+		if m == nil {
+			if len(members) >= maxCohortUsers {
+				expireCohort(members, now)
+				if len(members) >= maxCohortUsers {
+					continue
+				}
+			}
+			m = &cohortMember{UserHash: e.UserHash, FirstSeen: now, variant: label}
+			members[id] = m
+		}
+		if e.UserID != "" {
+			m.UserID = e.UserID
+		}
+		m.LastSeen = now
+	}
+}
+
+// expireCohort drops members last seen before the retention.
+func expireCohort(members map[string]*cohortMember, now time.Time) {
+	for id, m := range members {
+		if now.Sub(m.LastSeen) > cohortRetention {
+			delete(members, id)
+		}
+	}
+}
+
+// cohort returns the users of key in env exposed to variant.
+func (t *cohortTracker) cohort(env, key, variant string) []cohortMember {
+	now := t.now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]cohortMember, 0)
+	members := t.flags[env+"/"+key]
+	expireCohort(members, now)
+	for _, m := range members {
+		if m.variant == variant {
+			result = append(result, *m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].FirstSeen.Equal(result[j].FirstSeen) {
+			return result[i].FirstSeen.Before(result[j].FirstSeen)
+		}
+		return result[i].UserHash+result[i].UserID < result[j].UserHash+result[j].UserID
+	})
+	return result
+}
+
+// handleFlagCohort handles GET /v1/flags/{id}/cohort.
+func (s *Server) handleFlagCohort(w http.ResponseWriter, r *http.Request) {
+	variant, format := r.URL.Query().Get("variant"), r.URL.Query().Get("format")
+	fields := map[string]string{}
+	if variant == "" {
+		fields["variant"] = "is required"
+	}
+	if format != "" && format != "json" && format != "csv" {
+		fields["format"] = "must be json or csv"
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Invalid cohort request", fields)
+		return
+	}
+	env, flag, ok := s.routeFlag(w, r)
+	if !ok {
+		return
+	}
+
+	users := s.cohorts.cohort(env, flag.Key, variant)
+	if format != "csv" {
+		writeJSON(w, http.StatusOK, cohortResponse{Key: flag.Key, Env: env, Variant: variant, Count: len(users), Users: users})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename="+flag.Key+"-cohort.csv")
+	csvWriter := csv.NewWriter(w)
+	defer csvWriter.Flush()
+	if err := csvWriter.Write([]string{"user_id", "user_hash", "first_seen", "last_seen"}); err != nil {
+		return
+	}
+	for _, u := range users {
+		if err := csvWriter.Write([]string{u.UserID, u.UserHash, u.FirstSeen.Format(time.RFC3339), u.LastSeen.Format(time.RFC3339)}); err != nil {
+			return
+		}
+	}
+}
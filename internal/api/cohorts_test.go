@@ -0,0 +1,70 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestFlagCohort(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{
+		Key: "checkout", Enabled: true, Rollout: 100, Env: "prod",
+		Variants: []store.Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}},
+	})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	body := `{"exposures":[
+		{"flag":"checkout","variant":"treatment","enabled":true,"user_hash":"h1","user_id":"alice"},
+		{"flag":"checkout","variant":"treatment","enabled":true,"user_hash":"h2"},
+		{"flag":"checkout","variant":"control","enabled":true,"user_hash":"h3","user_id":"carol"},
+		{"flag":"checkout","variant":"treatment","enabled":true}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/exposures", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("exposures: expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/cohort", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a variant, got %d", rr.Code)
+	}
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/cohort?variant=treatment", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp cohortResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 2 || len(resp.Users) != 2 {
+		t.Fatalf("expected the 2 identified treatment users, got %+v", resp)
+	}
+	if resp.Users[0].UserHash != "h1" || resp.Users[0].UserID != "alice" || resp.Users[1].UserID != "" {
+		t.Errorf("unexpected users: %+v", resp.Users)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/cohort?variant=control&format=csv", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("expected a CSV response, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0][0] != "user_id" || records[1][0] != "carol" || records[1][1] != "h3" {
+		t.Errorf("unexpected CSV: %v", records)
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// Comments record why a flag changed ("rolled back due to INC-1234") next to
+// the flag itself:
+//
+//	GET  /v1/flags/{key}/comments?env=   list comments, oldest first (admin+)
+//	POST /v1/flags/{key}/comments?env=   add a comment (admin+)
+//
+// A comment may reference the audit event it explains via audit_log_id, so
+// the UI can show it on the flag's audit timeline. Comments can only be added
+// to existing flags but outlive them, so a deleted flag's history stays
+// readable.
+
+// maxCommentLength is the maximum comment body length in characters.
+const maxCommentLength = 4000
+
+type createCommentRequest struct {
+	Body       string `json:"body"`
+	AuditLogID string `json:"audit_log_id,omitempty"`
+}
+
+type listCommentsResponse struct {
+	Comments []store.FlagComment `json:"comments"`
+}
+
+// requireFlagCommentStore returns the store as a FlagCommentStore, writing an
+// error response and returning nil if it does not support comments.
+func (s *Server) requireFlagCommentStore(w http.ResponseWriter, r *http.Request) store.FlagCommentStore {
+	if cs, ok := s.store.(store.FlagCommentStore); ok {
+		return cs
+	}
+	InternalError(w, r, "Flag comments are not supported by this store")
+	return nil
+}
+
+func (s *Server) handleListFlagComments(w http.ResponseWriter, r *http.Request) {
+	cs := s.requireFlagCommentStore(w, r)
+	if cs == nil {
+		return
+	}
+	key, env := strings.TrimSpace(chi.URLParam(r, "id")), s.queryEnv(r)
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+	comments, err := cs.ListFlagComments(r.Context(), key, env)
+	if err != nil {
+		InternalError(w, r, "Failed to list comments")
+		return
+	}
+	writeJSON(w, http.StatusOK, listCommentsResponse{Comments: comments})
+}
+
+func (s *Server) handleCreateFlagComment(w http.ResponseWriter, r *http.Request) {
+	var req createCommentRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	req.AuditLogID = strings.TrimSpace(req.AuditLogID)
+
+	fields := map[string]string{}
+	switch {
+	case req.Body == "":
+		fields["body"] = "Comment body is required"
+	case utf8.RuneCountInString(req.Body) > maxCommentLength:
+		fields["body"] = "Comment body must not exceed 4000 characters"
+	}
+	if req.AuditLogID != "" {
+		if _, err := parseUUID(req.AuditLogID); err != nil {
+			fields["audit_log_id"] = "Must be an audit log ID (UUID)"
+		}
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+
+	cs := s.requireFlagCommentStore(w, r)
+	if cs == nil {
+		return
+	}
+	key, env := strings.TrimSpace(chi.URLParam(r, "id")), s.queryEnv(r)
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+	if _, ok := s.loadEnvFlag(r, key, env); !ok {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+
+	comment, err := cs.CreateFlagComment(r.Context(), store.CreateFlagCommentParams{
+		FlagKey:    key,
+		Env:        env,
+		Body:       req.Body,
+		AuditLogID: req.AuditLogID,
+		CreatedBy:  createdByFromRequest(r),
+	})
+	if err != nil {
+		InternalError(w, r, "Failed to create comment")
+		return
+	}
+
+	s.auditLog(r, audit.ActionCreated, audit.ResourceTypeFlagComment, comment.ID, env, nil, map[string]any{
+		"flag_key": key,
+		"body":     comment.Body,
+	}, nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+// queryEnv returns the ?env= query parameter, defaulting to s.env.
+func (s *Server) queryEnv(r *http.Request) string {
+	if env := strings.TrimSpace(r.URL.Query().Get("env")); env != "" {
+		return env
+	}
+	return s.env
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestFlagComments_CreateAndList(t *testing.T) {
+	st := store.NewMemoryStore()
+	if err := st.UpsertFlag(context.Background(), store.UpsertParams{Key: "checkout", Enabled: true, Env: "prod"}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	for _, body := range []string{
+		`{"body":"rolled back due to INC-1234","audit_log_id":"0b7e8f52-3c2e-4f0a-9d51-8f6d2c1a7b90"}`,
+		`{"body":"re-enabled after fix"}`,
+	} {
+		rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout/comments", body)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/comments", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp listCommentsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Comments) != 2 {
+		t.Fatalf("expected 2 comments, got %d", len(resp.Comments))
+	}
+	first := resp.Comments[0]
+	if first.Body != "rolled back due to INC-1234" || first.AuditLogID == "" || first.Env != "prod" || first.CreatedBy == "" {
+		t.Errorf("unexpected first comment: %+v", first)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/comments?env=staging", "")
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || len(resp.Comments) != 0 {
+		t.Errorf("comments must be scoped to the environment, got %+v, %v", resp.Comments, err)
+	}
+}
+
+func TestFlagComments_Validation(t *testing.T) {
+	st := store.NewMemoryStore()
+	_ = st.UpsertFlag(context.Background(), store.UpsertParams{Key: "checkout", Env: "prod"})
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	cases := map[string]struct {
+		path, body string
+		want       int
+	}{
+		"empty body":   {"/v1/flags/checkout/comments", `{"body":"  "}`, http.StatusBadRequest},
+		"bad audit id": {"/v1/flags/checkout/comments", `{"body":"x","audit_log_id":"INC-1234"}`, http.StatusBadRequest},
+		"unknown flag": {"/v1/flags/missing/comments", `{"body":"x"}`, http.StatusNotFound},
+		"wrong env":    {"/v1/flags/checkout/comments?env=staging", `{"body":"x"}`, http.StatusNotFound},
+	}
+	for name, tc := range cases {
+		rr := doV2Request(t, handler, http.MethodPost, tc.path, tc.body)
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+}
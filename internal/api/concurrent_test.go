@@ -258,7 +258,7 @@ func TestConcurrent_SameFlag_MultipleUpdates(t *testing.T) {
 	wg.Wait()
 
 	// Verify flag exists and has valid state
-	flag, err := st.GetFlagByKey(ctx, "shared_flag")
+	flag, err := st.GetFlagByKey(ctx, "shared_flag", "prod")
 	if err != nil {
 		t.Fatalf("Failed to get shared_flag: %v", err)
 	}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/TimurManjosov/goflagship/internal/engine"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+)
+
+// ContextLimits bound the evaluation contexts clients send to /v1/evaluate,
+// /v1/flags/evaluate and OFREP, so a multi-megabyte property map is rejected
+// with 400 CONTEXT_TOO_LARGE (OFREP: INVALID_CONTEXT) before it reaches the
+// engine, instead of only hitting the body size cap. Built-in attributes
+// (id, email, country, plan) count towards MaxAttributes. A zero limit is
+// unlimited.
+type ContextLimits struct {
+	MaxAttributes int // attributes per context
+	MaxValueBytes int // JSON-encoded size of one attribute value
+	MaxDepth      int // nested objects and arrays in one attribute value
+}
+
+// DefaultContextLimits are the context limits of a new server.
+var DefaultContextLimits = ContextLimits{
+	MaxAttributes: 100,
+	MaxValueBytes: 4096,
+	MaxDepth:      5,
+}
+
+// SetContextLimits replaces the evaluation context limits (see ContextLimits).
+func (s *Server) SetContextLimits(limits ContextLimits) {
+	s.contextLimits = limits
+}
+
+// checkUserContextLimits checks a /v1/evaluate or OFREP context against the
+// context limits. It returns the violations, keyed by attribute name ("" for
+// the context as a whole), if there are any.
+func (s *Server) checkUserContextLimits(ctx *engine.UserContext) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	return s.contextLimitViolations(userContextAttributes(ctx))
+}
+
+// checkEvaluationContextLimits is checkUserContextLimits for
+// /v1/flags/evaluate.
+func (s *Server) checkEvaluationContextLimits(ctx evaluation.Context) map[string]string {
+	return s.contextLimitViolations(evaluationContextAttributes(ctx))
+}
+
+func (s *Server) contextLimitViolations(attrs map[string]any) map[string]string {
+	l := s.contextLimits
+	if l.MaxAttributes > 0 && len(attrs) > l.MaxAttributes {
+		return map[string]string{"": fmt.Sprintf("context has %d attributes, the limit is %d", len(attrs), l.MaxAttributes)}
+	}
+	violations := map[string]string{}
+	for name, value := range attrs {
+		if depth := contextValueDepth(value); l.MaxDepth > 0 && depth > l.MaxDepth {
+			violations[name] = fmt.Sprintf("attribute %q is nested %d levels deep, the limit is %d", name, depth, l.MaxDepth)
+			continue
+		}
+		if l.MaxValueBytes <= 0 {
+			continue
+		}
+		if size := contextValueSize(value); size > l.MaxValueBytes {
+			violations[name] = fmt.Sprintf("attribute %q is %d bytes, the limit is %d", name, size, l.MaxValueBytes)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// contextValueDepth counts the nested objects and arrays in v.
+func contextValueDepth(v any) int {
+	depth := 0
+	switch v := v.(type) {
+	case map[string]any:
+		for _, child := range v {
+			depth = max(depth, contextValueDepth(child))
+		}
+	case []any:
+		for _, child := range v {
+			depth = max(depth, contextValueDepth(child))
+		}
+	default:
+		return 0
+	}
+	return depth + 1
+}
+
+// contextValueSize is the JSON-encoded size of v in bytes.
+func contextValueSize(v any) int {
+	if s, ok := v.(string); ok {
+		return len(s) + 2 // quotes; escapes are not counted
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// writeContextTooLarge writes a 400 CONTEXT_TOO_LARGE response for violations.
+func writeContextTooLarge(w http.ResponseWriter, r *http.Request, violations map[string]string) {
+	fields := make(map[string]string, len(violations))
+	for name, message := range violations {
+		if name == "" {
+			fields["context"] = message
+			continue
+		}
+		fields["context."+name] = message
+	}
+	BadRequestErrorWithFields(w, r, ErrCodeContextTooLarge, "Evaluation context exceeds the configured limits", fields)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestContextLimits_RejectEvaluations(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetContextLimits(ContextLimits{MaxAttributes: 3, MaxValueBytes: 16, MaxDepth: 2})
+	handler := srv.Router()
+
+	long := strings.Repeat("x", 20)
+	for _, tc := range []struct {
+		name, method, path, body string
+		want                     int
+		field                    string
+	}{
+		{"within limits", http.MethodPost, "/v1/evaluate", `{"context":{"id":"u1","properties":{"tags":["a",["b"]]}}}`, http.StatusOK, ""},
+		{"too many attributes", http.MethodPost, "/v1/evaluate", `{"context":{"id":"u1","email":"a@b.c","properties":{"a":1,"b":2}}}`, http.StatusBadRequest, "context"},
+		{"value too large", http.MethodPost, "/v1/evaluate", `{"context":{"id":"u1","properties":{"bio":"` + long + `"}}}`, http.StatusBadRequest, "context.bio"},
+		{"nested too deep", http.MethodPost, "/v1/flags/evaluate", `{"user":{"id":"u1","attributes":{"org":{"a":{"b":{}}}}}}`, http.StatusBadRequest, "context.org"},
+		{"query value too large", http.MethodGet, "/v1/flags/evaluate?userId=u1&bio=" + long, "", http.StatusBadRequest, "context.bio"},
+		{"ofrep", http.MethodPost, "/ofrep/v1/evaluate/flags", `{"context":{"targetingKey":"u1","bio":"` + long + `"}}`, http.StatusBadRequest, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, rr.Code, rr.Body.String())
+			}
+			if tc.field == "" {
+				return
+			}
+			resp := decodeErrorResponse(t, rr.Body.Bytes())
+			if resp.Code != ErrCodeContextTooLarge || resp.Fields[tc.field] == "" {
+				t.Errorf("expected CONTEXT_TOO_LARGE naming %s, got %+v", tc.field, resp)
+			}
+		})
+	}
+}
+
+func TestContextLimits_ZeroIsUnlimited(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetContextLimits(ContextLimits{})
+	if violations := srv.contextLimitViolations(map[string]any{"bio": strings.Repeat("x", 1<<16), "deep": []any{[]any{[]any{[]any{}}}}}); violations != nil {
+		t.Errorf("expected no violations without limits, got %v", violations)
+	}
+}
+
+func TestContextValueDepth(t *testing.T) {
+	for _, tc := range []struct {
+		value any
+		want  int
+	}{
+		{"x", 0},
+		{[]any{}, 1},
+		{map[string]any{"a": []any{1.0}}, 2},
+		{[]any{1.0, map[string]any{"a": map[string]any{}}}, 3},
+	} {
+		if got := contextValueDepth(tc.value); got != tc.want {
+			t.Errorf("contextValueDepth(%v) = %d, want %d", tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Request bodies are decoded with decodeJSON (admin endpoints) or
+// decodeClientJSON (public client endpoints), which bound the body, reject
+// trailing data after the JSON value and answer failures uniformly: 413
+// REQUEST_TOO_LARGE over the limit, 400 INVALID_JSON otherwise.
+
+// maxJSONBodySize is the default limit for JSON request bodies.
+const maxJSONBodySize = 1 << 20
+
+// errTrailingJSON is returned by readJSONBody when the body holds more than
+// one JSON value.
+var errTrailingJSON = errors.New("request body must contain a single JSON value")
+
+// decodeJSON decodes a JSON request body of at most maxBytes into dst,
+// rejecting fields dst doesn't declare. It returns false after writing the
+// error response if the body can't be decoded.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) bool {
+	if err := readJSONBody(w, r, dst, maxBytes, false); err != nil {
+		writeJSONDecodeError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// decodeClientJSON is decodeJSON for client (SDK) endpoints: unknown fields
+// are ignored, so SDKs may send fields this server doesn't know yet.
+func decodeClientJSON(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) bool {
+	if err := readJSONBody(w, r, dst, maxBytes, true); err != nil {
+		writeJSONDecodeError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// readJSONBody decodes the body into dst without writing a response, for
+// endpoints with their own error format (OFREP). An empty body yields io.EOF.
+func readJSONBody(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64, allowUnknownFields bool) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	dec := json.NewDecoder(r.Body)
+	if !allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return errTrailingJSON
+	}
+	return nil
+}
+
+// writeJSONDecodeError writes the error response for a failed readJSONBody:
+// 413 when the body exceeds its limit, otherwise 400 INVALID_JSON with a
+// message pointing at the problem (and, for unknown or mistyped fields, the
+// field in "fields").
+func writeJSONDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		RequestTooLargeError(w, r, fmt.Sprintf("Request body exceeds %s limit", formatBytes(maxBytesErr.Limit)))
+		return
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: request body ends unexpectedly")
+	case errors.Is(err, errTrailingJSON):
+		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: "+err.Error())
+	case errors.As(err, &syntaxErr):
+		BadRequestError(w, r, ErrCodeInvalidJSON, fmt.Sprintf("Invalid JSON: syntax error at byte %d: %s", syntaxErr.Offset, syntaxErr.Error()))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		BadRequestErrorWithFields(w, r, ErrCodeInvalidJSON, "Invalid JSON: field has the wrong type", map[string]string{
+			typeErr.Field: fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		BadRequestErrorWithFields(w, r, ErrCodeInvalidJSON, fmt.Sprintf("Invalid JSON: unknown field %q (check its spelling)", field), map[string]string{
+			field: "unknown field",
+		})
+	default:
+		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: "+err.Error())
+	}
+}
+
+// jsonTypeName names a Go type the way a JSON client thinks of it.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return t.String()
+	}
+}
+
+// formatBytes formats a body size limit, e.g. 1MB.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestDecodeJSON_RejectsTrailingData(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"banner","enabled":true} {"key":"other"}`)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "single JSON value") {
+		t.Fatalf("expected 400 for trailing data, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Trailing whitespace is fine.
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", "{\"key\":\"banner\",\"enabled\":true}\n\n")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestDecodeJSON_UniformAcrossAdminEndpoints(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	for _, path := range []string{"/v1/admin/keys", "/v1/admin/sdk-keys", "/v1/admin/webhooks", "/graphql", "/v1/apply"} {
+		rr := doV2Request(t, handler, http.MethodPost, path, `{"unexpected":true}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 for an unknown field, got %d: %s", path, rr.Code, rr.Body.String())
+			continue
+		}
+		if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Code != ErrCodeInvalidJSON || resp.Fields["unexpected"] == "" {
+			t.Errorf("%s: unexpected error %+v", path, resp)
+		}
+
+		big := `{"name":"` + strings.Repeat("x", maxJSONBodySize) + `"}`
+		if rr := doV2Request(t, handler, http.MethodPost, path, big); rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: expected 413 for an oversized body, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestDecodeClientJSON_IgnoresUnknownFieldsButIsBounded(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post("/v1/flags/evaluate", `{"user":{"id":"u1"},"sdkVersion":"9.9"}`); rr.Code != http.StatusOK {
+		t.Errorf("unknown fields on a client endpoint: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	big := `{"user":{"id":"` + strings.Repeat("x", maxJSONBodySize) + `"}}`
+	if rr := post("/v1/flags/evaluate", big); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized evaluate body: expected 413, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+)
+
+// Mutations accept ?dry_run=true: the request goes through every check of a
+// real write (validation, tenant scope, limits, flag protection, attribute
+// registry) and the response describes what would happen instead. Nothing is
+// stored, audited or sent to webhooks, which makes dry runs safe for CI.
+//
+// Supported on flag writes (v1 and v2 create, update, patch and delete, and
+// bulk actions) and on webhook create, update and delete. Flag dry runs also
+// report the snapshot diff and the webhooks that would be notified. Step-up
+// codes are not checked, since checking one spends it. POST /v1/apply has its
+// own plan mode (without ?confirm=true). Segments are not part of the data
+// model.
+
+const dryRunParam = "dry_run"
+
+type dryRunResponse struct {
+	DryRun   bool            `json:"dry_run"`
+	Changes  []applyChange   `json:"changes"`            // same shape as POST /v1/apply
+	Snapshot *snapshotDiff   `json:"snapshot,omitempty"` // flag writes only
+	Webhooks []dryRunWebhook `json:"webhooks,omitempty"` // webhooks the change would notify
+}
+
+// snapshotDiff lists the flags whose served state would change.
+type snapshotDiff struct {
+	ETag    string   `json:"etag"` // current snapshot ETag
+	Added   []string `json:"added"`
+	Changed []string `json:"changed"`
+	Removed []string `json:"removed"`
+}
+
+type dryRunWebhook struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	EventType string `json:"event_type"`
+	FlagKey   string `json:"flag_key,omitempty"`
+}
+
+type dryRunContextKey struct{}
+
+// withDryRun parses ?dry_run and returns r marked as a dry run when it is
+// true. It writes a validation error and returns false if the value is not a
+// boolean, so a typo never turns a dry run into a real write.
+func withDryRun(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	raw := r.URL.Query().Get(dryRunParam)
+	if raw == "" {
+		return r, true
+	}
+	dryRun, err := strconv.ParseBool(raw)
+	if err != nil {
+		ValidationError(w, r, "Invalid query parameters", map[string]string{dryRunParam: "must be true or false"})
+		return r, false
+	}
+	if !dryRun {
+		return r, true
+	}
+	return asDryRun(r), true
+}
+
+// asDryRun returns r marked as a dry run.
+func asDryRun(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), dryRunContextKey{}, true))
+}
+
+// isDryRun reports whether r was marked as a dry run by withDryRun.
+func isDryRun(r *http.Request) bool {
+	dryRun, _ := r.Context().Value(dryRunContextKey{}).(bool)
+	return dryRun
+}
+
+// dryRunSaveFlag checks req like saveFlag and describes the write.
+func (s *Server) dryRunSaveFlag(r *http.Request, req upsertRequest) (*dryRunResponse, *flagWriteError) {
+	fw, werr := s.prepareFlagWrite(r, req)
+	if werr != nil {
+		return nil, werr
+	}
+	after := flagFromParams(fw.params)
+	return s.dryRunFlagChanges(r, fw.params.Env, []*store.Flag{fw.oldFlag}, []*store.Flag{after})
+}
+
+// dryRunRemoveFlag checks a delete like removeFlag and describes it.
+func (s *Server) dryRunRemoveFlag(r *http.Request, key, env string) (*dryRunResponse, *flagWriteError) {
+	oldFlag, _, werr := s.prepareFlagDelete(r, key, env)
+	if werr != nil {
+		return nil, werr
+	}
+	return s.dryRunFlagChanges(r, env, []*store.Flag{oldFlag}, []*store.Flag{nil})
+}
+
+// dryRunFlagChanges describes replacing each befores[i] with afters[i] in
+// env; a nil before is a create and a nil after a delete.
+func (s *Server) dryRunFlagChanges(r *http.Request, env string, befores, afters []*store.Flag) (*dryRunResponse, *flagWriteError) {
+	resp := &dryRunResponse{DryRun: true, Changes: []applyChange{}}
+	for i, before := range befores {
+		after := afters[i]
+		if before == nil && after == nil {
+			continue // deleting a flag that does not exist
+		}
+		change := applyChange{Action: applyActionUpdate, ResourceType: applyResourceFlag}
+		var beforeState, afterState map[string]any
+		switch {
+		case before == nil:
+			change.Action, change.Key = applyActionCreate, after.Key
+			afterState = dryRunFlagState(after)
+		case after == nil:
+			change.Action, change.Key = applyActionDelete, before.Key
+			beforeState = dryRunFlagState(before)
+		default:
+			change.Key = before.Key
+			beforeState, afterState = dryRunFlagState(before), dryRunFlagState(after)
+		}
+		change.Diff = audit.ComputeChanges(beforeState, afterState)
+		resp.Changes = append(resp.Changes, change)
+
+		matched, err := s.matchingWebhooks(r.Context(), flagEvent(r, "", change.Key, env, beforeState, afterState, change.Diff))
+		if err != nil {
+			return nil, internalWriteError("Failed to load webhooks")
+		}
+		resp.Webhooks = append(resp.Webhooks, matched...)
+	}
+
+	diff, err := s.snapshotImpact(r.Context(), env, afters, befores)
+	if err != nil {
+		return nil, internalWriteError("Failed to load flags")
+	}
+	resp.Snapshot = diff
+	return resp, nil
+}
+
+// snapshotImpact compares the snapshot of env with the one that results from
+// storing the non-nil flags of upserts and deleting the flags of deletes that
+// have no upsert.
+func (s *Server) snapshotImpact(ctx context.Context, env string, upserts, deletes []*store.Flag) (*snapshotDiff, error) {
+	flags, err := s.store.GetAllFlags(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]store.Flag, len(flags))
+	for _, f := range flags {
+		byKey[f.Key] = f
+	}
+	for i, f := range upserts {
+		switch {
+		case f != nil:
+			byKey[f.Key] = *f
+		case deletes[i] != nil:
+			delete(byKey, deletes[i].Key)
+		}
+	}
+	next := make([]store.Flag, 0, len(byKey))
+	for _, f := range byKey {
+		next = append(next, f)
+	}
+
+	before, after := snapshot.BuildFromFlags(flags), snapshot.BuildFromFlags(next)
+	diff := &snapshotDiff{ETag: before.ETag, Added: []string{}, Changed: []string{}, Removed: []string{}}
+	for key, view := range after.Flags {
+		old, ok := before.Flags[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case !sameFlagView(old, view):
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range before.Flags {
+		if _, ok := after.Flags[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Removed)
+	return diff, nil
+}
+
+// sameFlagView compares two served flags, ignoring the update timestamp
+// that every write bumps.
+func sameFlagView(a, b snapshot.FlagView) bool {
+	a.UpdatedAt, b.UpdatedAt = time.Time{}, time.Time{}
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	return string(aj) == string(bj)
+}
+
+// matchingWebhooks lists the webhooks event would be delivered to.
+func (s *Server) matchingWebhooks(ctx context.Context, event webhook.Event) ([]dryRunWebhook, error) {
+	if s.webhookDispatcher == nil || event.Type == "" {
+		return nil, nil
+	}
+	webhooks, err := s.webhookDispatcher.MatchingWebhooks(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]dryRunWebhook, len(webhooks))
+	for i, wh := range webhooks {
+		matched[i] = dryRunWebhook{ID: formatUUID(wh.ID), URL: wh.Url, EventType: event.Type, FlagKey: event.Resource.Key}
+	}
+	return matched, nil
+}
+
+// dryRunFlagState extends the plan state of apply with the archived state,
+// which bulk actions change.
+func dryRunFlagState(flag *store.Flag) map[string]any {
+	m := applyFlagState(flag)
+	if flag.Archived {
+		m["archived"] = true
+	}
+	return m
+}
+
+// flagFromParams is the flag that storing params would produce.
+func flagFromParams(p store.UpsertParams) *store.Flag {
+	return &store.Flag{
+		Key:            p.Key,
+		Description:    p.Description,
+		Enabled:        p.Enabled,
+		Rollout:        p.Rollout,
+		Expression:     p.Expression,
+		Config:         p.Config,
+		TargetingRules: p.TargetingRules,
+		Variants:       p.Variants,
+		Protected:      p.Protected,
+		Tags:           p.Tags,
+		Archived:       p.Archived,
+		Owner:          p.Owner,
+		TicketURL:      p.TicketURL,
+		Metadata:       p.Metadata,
+		ExpiresAt:      p.ExpiresAt,
+		Env:            p.Env,
+		Project:        p.Project,
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func decodeDryRun(t *testing.T, body []byte) dryRunResponse {
+	t.Helper()
+	var resp dryRunResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.DryRun {
+		t.Fatalf("expected a dry run response, got %s", body)
+	}
+	return resp
+}
+
+func TestDryRun_FlagWritesPersistNothing(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	if err := st.UpsertFlag(ctx, store.UpsertParams{Key: "banner", Enabled: true, Rollout: 50, Env: "prod"}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags?dry_run=true", `{"key":"new_checkout","enabled":true,"rollout":25,"env":"prod"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	resp := decodeDryRun(t, rr.Body.Bytes())
+	if len(resp.Changes) != 1 || resp.Changes[0].Action != applyActionCreate || resp.Changes[0].Key != "new_checkout" {
+		t.Errorf("unexpected changes %+v", resp.Changes)
+	}
+	if resp.Snapshot == nil || !slices.Equal(resp.Snapshot.Added, []string{"new_checkout"}) || len(resp.Snapshot.Changed) != 0 {
+		t.Errorf("unexpected snapshot diff %+v", resp.Snapshot)
+	}
+	if _, err := st.GetFlagByKey(ctx, "new_checkout", "prod"); err == nil {
+		t.Fatal("dry run must not create the flag")
+	}
+
+	rr = doV2Request(t, handler, http.MethodPatch, "/v2/projects/default/environments/prod/flags/banner?dry_run=true", `{"rollout":100}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var env struct {
+		Data dryRunResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(env.Data.Changes) != 1 || env.Data.Changes[0].Diff["rollout"] == nil || !slices.Equal(env.Data.Snapshot.Changed, []string{"banner"}) {
+		t.Errorf("unexpected patch dry run %+v", env.Data)
+	}
+
+	rr = doV2Request(t, handler, http.MethodDelete, "/v1/flags?key=banner&env=prod&dry_run=true", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp := decodeDryRun(t, rr.Body.Bytes()); !slices.Equal(resp.Snapshot.Removed, []string{"banner"}) {
+		t.Errorf("unexpected delete dry run %+v", resp.Snapshot)
+	}
+	flag, err := st.GetFlagByKey(ctx, "banner", "prod")
+	if err != nil || flag.Rollout != 50 {
+		t.Fatalf("dry runs must leave banner alone, got %+v, %v", flag, err)
+	}
+}
+
+func TestDryRun_RunsFullValidation(t *testing.T) {
+	srv, _ := newProtectedFlagServer(t)
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags?dry_run=true", `{"key":"bad key","rollout":150,"env":"prod"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid flag, got %d", rr.Code)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags?dry_run=yes", `{"key":"ok","env":"prod"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a non-boolean dry_run, got %d", rr.Code)
+	}
+	if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields[dryRunParam] == "" {
+		t.Errorf("expected a dry_run field error, got %+v", resp)
+	}
+
+	r, _ := withDryRun(nil, adminRequest(http.MethodDelete, "/v1/flags?key=payments&env=prod&dry_run=true"))
+	if _, werr := srv.dryRunRemoveFlag(r, "payments", "prod"); werr == nil || werr.code != ErrCodeFlagProtected {
+		t.Fatalf("expected FLAG_PROTECTED for an admin dry run, got %v", werr)
+	}
+}
+
+func TestDryRun_BulkAction(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	for _, key := range []string{"promo_a", "promo_b"} {
+		if err := st.UpsertFlag(ctx, store.UpsertParams{Key: key, Enabled: true, Rollout: 100, Tags: []string{"promo"}, Env: "prod"}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/bulk-action?dry_run=true", `{"env":"prod","tags":["promo"],"action":"archive"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp bulkActionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.DryRun || !slices.Equal(resp.Changed, []string{"promo_a", "promo_b"}) || !slices.Equal(resp.Snapshot.Removed, []string{"promo_a", "promo_b"}) {
+		t.Errorf("unexpected bulk dry run %+v", resp)
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "promo_a", "prod"); flag == nil || flag.Archived {
+		t.Fatalf("dry run must not archive, got %+v", flag)
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Encryption at rest (see package encryption):
+//
+//	POST /v1/admin/webhooks/{id}/secret   generate a new webhook secret, returned once (admin+)
+//	POST /v1/admin/encryption/rotate      re-encrypt stored values with the primary key (superadmin, operator)
+//
+// With a keyring configured, webhook secrets and custom header values are
+// always stored encrypted and flag configs are encrypted if encryptFlagConfig
+// is set. Webhook secrets are only returned when they are generated (on
+// create and by the secret endpoint), never by list or get; header values
+// are never returned.
+//
+// To rotate the key encryption key, put a new key first in ENCRYPTION_KEYS
+// while keeping the old one, restart, call the rotate endpoint (or
+// `flagship rotate-encryption-key`), then remove the old key. Rotation also
+// encrypts values written in plaintext before encryption was enabled.
+
+// SetEncryption configures encryption at rest. A nil keyring disables it.
+func (s *Server) SetEncryption(keyring *encryption.Keyring, encryptFlagConfig bool) {
+	s.keyring = keyring
+	if s.webhookDispatcher != nil {
+		s.webhookDispatcher.SetKeyring(keyring)
+	}
+	if es, ok := s.store.(interface {
+		SetEncryption(*encryption.Keyring, bool)
+	}); ok {
+		es.SetEncryption(keyring, encryptFlagConfig)
+	}
+}
+
+// newWebhookSecret generates a webhook secret and returns it together with
+// the form it is stored in.
+func (s *Server) newWebhookSecret() (secret, stored string, err error) {
+	secret, err = webhook.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	stored, err = s.keyring.Encrypt(secret)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, stored, nil
+}
+
+type rotateEncryptionResponse struct {
+	KeyID          string `json:"key_id"`
+	WebhookSecrets int    `json:"webhook_secrets"`
+	WebhookHeaders int    `json:"webhook_headers"`
+	FlagConfigs    int    `json:"flag_configs"`
+}
+
+// handleRotateWebhookSecret replaces a webhook's signing secret.
+func (s *Server) handleRotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	var webhookID pgtype.UUID
+	if err := webhookID.Scan(chi.URLParam(r, "id")); err != nil {
+		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid webhook ID format")
+		return
+	}
+	queries := s.requireQueries(w, r)
+	if queries == nil {
+		return // Error already written to response
+	}
+	wh, err := queries.GetWebhook(r.Context(), webhookID)
+	if err != nil || !tenantOwnsRow(r, wh.Tenant) {
+		NotFoundError(w, r, "Webhook not found")
+		return
+	}
+
+	secret, stored, err := s.newWebhookSecret()
+	if err != nil {
+		InternalError(w, r, "Failed to generate webhook secret")
+		return
+	}
+	if err := queries.UpdateWebhookSecret(r.Context(), dbgen.UpdateWebhookSecretParams{ID: webhookID, Secret: stored}); err != nil {
+		InternalError(w, r, "Failed to update webhook secret")
+		return
+	}
+
+	s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeWebhook, formatUUID(webhookID), "", nil, nil,
+		map[string]any{"secret": "regenerated"}, audit.StatusSuccess, "")
+	resp := webhookToResponse(wh)
+	resp.Secret = secret
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleRotateEncryption re-encrypts webhook secrets, webhook headers and
+// flag configs that are not yet encrypted with the primary key.
+func (s *Server) handleRotateEncryption(w http.ResponseWriter, r *http.Request) {
+	if s.keyring == nil {
+		BadRequestError(w, r, ErrCodeBadRequest, "Encryption is not configured (set ENCRYPTION_KEYS)")
+		return
+	}
+	queries := s.requireQueries(w, r)
+	if queries == nil {
+		return // Error already written to response
+	}
+
+	resp := rotateEncryptionResponse{KeyID: s.keyring.PrimaryKeyID()}
+	webhooks, err := queries.ListWebhooks(r.Context())
+	if err != nil {
+		InternalError(w, r, "Failed to list webhooks")
+		return
+	}
+	for _, wh := range webhooks {
+		rotated, changed, err := s.keyring.Rotate(wh.Secret)
+		if err != nil {
+			InternalError(w, r, "Failed to decrypt the secret of webhook "+formatUUID(wh.ID)+": "+err.Error())
+			return
+		}
+		if changed {
+			if err := queries.UpdateWebhookSecret(r.Context(), dbgen.UpdateWebhookSecretParams{ID: wh.ID, Secret: rotated}); err != nil {
+				InternalError(w, r, "Failed to update webhook secret")
+				return
+			}
+			resp.WebhookSecrets++
+		}
+
+		headers, changed, err := webhook.RotateHeaders(s.keyring, wh.Headers)
+		if err != nil {
+			InternalError(w, r, "Failed to decrypt the headers of webhook "+formatUUID(wh.ID)+": "+err.Error())
+			return
+		}
+		if changed {
+			if err := queries.UpdateWebhookHeaders(r.Context(), dbgen.UpdateWebhookHeadersParams{ID: wh.ID, Headers: headers}); err != nil {
+				InternalError(w, r, "Failed to update webhook headers")
+				return
+			}
+			resp.WebhookHeaders++
+		}
+	}
+
+	if es, ok := s.store.(store.ConfigEncryptionStore); ok {
+		n, err := es.RotateFlagConfigs(r.Context())
+		resp.FlagConfigs = n
+		if err != nil {
+			InternalError(w, r, "Failed to re-encrypt flag configs: "+err.Error())
+			return
+		}
+	}
+
+	s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeSystem, "encryption", "", nil, nil, map[string]any{
+		"key_id":          resp.KeyID,
+		"webhook_secrets": resp.WebhookSecrets,
+		"webhook_headers": resp.WebhookHeaders,
+		"flag_configs":    resp.FlagConfigs,
+	}, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestRotateEncryption_RequiresKeyring(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	rr := doV2Request(t, srv.Router(), http.MethodPost, "/v1/admin/encryption/rotate", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without ENCRYPTION_KEYS, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestNewWebhookSecret_StoredEncrypted(t *testing.T) {
+	keyring, err := encryption.ParseKeys("k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetEncryption(keyring, false)
+
+	secret, stored, err := srv.newWebhookSecret()
+	if err != nil {
+		t.Fatalf("newWebhookSecret: %v", err)
+	}
+	if !encryption.IsEncrypted(stored) || stored == secret {
+		t.Fatalf("secret stored in plaintext: %q", stored)
+	}
+	if got, err := keyring.Decrypt(stored); err != nil || got != secret {
+		t.Errorf("stored secret decrypts to %q, %v", got, err)
+	}
+
+	// Without a keyring the secret is stored as is.
+	plain := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	if secret, stored, _ := plain.newWebhookSecret(); stored != secret {
+		t.Errorf("expected plaintext storage without a keyring")
+	}
+}
+
+func TestWebhookToResponse_OmitsSecret(t *testing.T) {
+	resp := webhookToResponse(dbgen.Webhook{Url: "https://example.com", Secret: "whsec"})
+	if resp.Secret != "" {
+		t.Errorf("webhook responses must not include the stored secret, got %q", resp.Secret)
+	}
+}
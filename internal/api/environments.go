@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// POST /v1/environments/{env}/clone copies every flag of env into a new
+// environment, e.g. to spin up a preview environment that mirrors prod.
+//
+// The target must not contain flags yet; with ?overwrite=true its flags are
+// replaced so that it mirrors the source exactly. Each copied flag goes
+// through the regular write path, so audit events and webhooks fire per flag.
+//
+// With a "ttl" (a Go duration such as "72h") the target becomes an ephemeral
+// environment that is deleted once the TTL has passed, see ephemeral.go.
+//
+// Segments are not part of the data model and are not copied.
+
+type cloneEnvironmentRequest struct {
+	Target string `json:"target"`
+	TTL    string `json:"ttl,omitempty"`
+}
+
+type cloneEnvironmentResponse struct {
+	Source    string     `json:"source"`
+	Target    string     `json:"target"`
+	Flags     int        `json:"flags"`
+	Removed   []string   `json:"removed,omitempty"` // target-only flags deleted by ?overwrite=true
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ETag      string     `json:"etag"`
+}
+
+func (s *Server) handleCloneEnvironment(w http.ResponseWriter, r *http.Request) {
+	source := strings.TrimSpace(chi.URLParam(r, "env"))
+
+	var req cloneEnvironmentRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	req.Target = strings.TrimSpace(req.Target)
+
+	fields := map[string]string{}
+	if message, ok := validation.ValidateEnv(req.Target).Errors["env"]; ok {
+		fields["target"] = message
+	} else if req.Target == source {
+		fields["target"] = "Target environment must differ from the source"
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.TTL)
+		switch {
+		case err != nil:
+			fields["ttl"] = "TTL must be a duration such as \"72h\""
+		case ttl < minEphemeralEnvTTL || ttl > maxEphemeralEnvTTL:
+			fields["ttl"] = fmt.Sprintf("TTL must be between %s and %s", minEphemeralEnvTTL, maxEphemeralEnvTTL)
+		case req.Target == s.env:
+			fields["ttl"] = "The default environment cannot be ephemeral"
+		}
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+	if !requireTenantEnvs(w, r, []string{source, req.Target}) {
+		return
+	}
+
+	var es store.EphemeralEnvironmentStore
+	if ttl > 0 {
+		if es = s.requireEphemeralEnvStore(w, r); es == nil {
+			return
+		}
+	}
+
+	flags, err := s.store.GetAllFlags(r.Context(), source)
+	if err != nil {
+		InternalError(w, r, "Failed to load flags")
+		return
+	}
+	if len(flags) == 0 {
+		NotFoundError(w, r, "Environment not found")
+		return
+	}
+
+	existing, err := s.store.GetAllFlags(r.Context(), req.Target)
+	if err != nil {
+		InternalError(w, r, "Failed to load flags")
+		return
+	}
+	if len(existing) > 0 && r.URL.Query().Get("overwrite") != "true" {
+		ConflictError(w, r, "Target environment already has flags; use ?overwrite=true to replace them")
+		return
+	}
+
+	copied := make(map[string]bool, len(flags))
+	for i := range flags {
+		flagReq := upsertRequestFromFlag(&flags[i])
+		flagReq.Env = &req.Target
+		if _, werr := s.saveFlag(r, flagReq); werr != nil {
+			werr.write(w, r)
+			return
+		}
+		copied[flags[i].Key] = true
+	}
+
+	resp := cloneEnvironmentResponse{Source: source, Target: req.Target, Flags: len(flags)}
+	for _, f := range existing {
+		if copied[f.Key] {
+			continue
+		}
+		if werr := s.removeFlag(r, f.Key, req.Target); werr != nil {
+			werr.write(w, r)
+			return
+		}
+		resp.Removed = append(resp.Removed, f.Key)
+	}
+	if es != nil {
+		env, err := es.UpsertEphemeralEnvironment(r.Context(), store.EphemeralEnvironment{
+			Name:      req.Target,
+			ExpiresAt: time.Now().UTC().Add(ttl),
+			CreatedBy: createdByFromRequest(r),
+		})
+		if err != nil {
+			InternalError(w, r, "Failed to register ephemeral environment")
+			return
+		}
+		resp.ExpiresAt = &env.ExpiresAt
+		s.auditLog(r, audit.ActionCreated, audit.ResourceTypeEnvironment, env.Name, env.Name, nil, map[string]any{
+			"name":       env.Name,
+			"source":     source,
+			"expires_at": env.ExpiresAt.Format(time.RFC3339),
+		}, nil, audit.StatusSuccess, "")
+	}
+	resp.ETag = s.snapshotForEnv(req.Target).ETag
+
+	writeJSON(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestCloneEnvironment(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 40, Env: "prod"})
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "banner", Description: "promo", Env: "prod"})
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/environments/prod/clone", `{"target":"pr-42"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp cloneEnvironmentResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Flags != 2 || resp.Target != "pr-42" || resp.ETag == "" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	clone, err := st.GetFlagByKey(ctx, "checkout", "pr-42")
+	if err != nil {
+		t.Fatalf("cloned flag missing: %v", err)
+	}
+	if !clone.Enabled || clone.Rollout != 40 {
+		t.Errorf("clone does not mirror source: %+v", clone)
+	}
+	if src, err := st.GetFlagByKey(ctx, "checkout", "prod"); err != nil || src.Env != "prod" {
+		t.Errorf("source flag should be untouched, got %+v, %v", src, err)
+	}
+
+	// Cloning into a populated environment needs ?overwrite=true.
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/environments/prod/clone", `{"target":"pr-42"}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCloneEnvironment_OverwriteRemovesTargetOnlyFlags(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Rollout: 100, Env: "prod"})
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Rollout: 5, Env: "preview"})
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "experiment", Env: "preview"})
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/environments/prod/clone?overwrite=true", `{"target":"preview"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	flags, _ := st.GetAllFlags(ctx, "preview")
+	if len(flags) != 1 || flags[0].Key != "checkout" || flags[0].Rollout != 100 {
+		t.Errorf("preview should mirror prod, got %+v", flags)
+	}
+}
+
+func TestCloneEnvironment_Validation(t *testing.T) {
+	st := store.NewMemoryStore()
+	_ = st.UpsertFlag(context.Background(), store.UpsertParams{Key: "checkout", Env: "prod"})
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	tests := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"missing target", "/v1/environments/prod/clone", `{}`, http.StatusBadRequest},
+		{"same env", "/v1/environments/prod/clone", `{"target":"prod"}`, http.StatusBadRequest},
+		{"unknown source", "/v1/environments/nope/clone", `{"target":"pr-1"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := doV2Request(t, handler, http.MethodPost, tt.path, tt.body)
+			if rr.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCloneEnvironment_EphemeralTTL(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Env: "prod"})
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/environments/prod/clone", `{"target":"pr-7","ttl":"1h"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp cloneEnvironmentResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.ExpiresAt == nil || time.Until(*resp.ExpiresAt) > time.Hour {
+		t.Fatalf("unexpected expiry: %v", resp.ExpiresAt)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/environments/ephemeral", "")
+	var list listEphemeralEnvironmentsResponse
+	_ = json.NewDecoder(rr.Body).Decode(&list)
+	if len(list.Environments) != 1 || list.Environments[0].Name != "pr-7" {
+		t.Fatalf("unexpected ephemeral environments: %+v", list.Environments)
+	}
+
+	// Not expired yet: nothing is reaped.
+	if deleted, _ := srv.ReapEphemeralEnvironments(ctx, time.Now()); len(deleted) != 0 {
+		t.Fatalf("expected nothing reaped, got %v", deleted)
+	}
+
+	deleted, err := srv.ReapEphemeralEnvironments(ctx, time.Now().Add(2*time.Hour))
+	if err != nil || len(deleted) != 1 || deleted[0] != "pr-7" {
+		t.Fatalf("expected pr-7 reaped, got %v, %v", deleted, err)
+	}
+	if flags, _ := st.GetAllFlags(ctx, "pr-7"); len(flags) != 0 {
+		t.Errorf("flags of expired env should be deleted, got %+v", flags)
+	}
+	if _, err := st.GetFlagByKey(ctx, "checkout", "prod"); err != nil {
+		t.Errorf("source env must be untouched: %v", err)
+	}
+	if envs, _ := st.ListEphemeralEnvironments(ctx); len(envs) != 0 {
+		t.Errorf("expired env should be unregistered, got %+v", envs)
+	}
+}
+
+func TestCloneEnvironment_InvalidTTL(t *testing.T) {
+	st := store.NewMemoryStore()
+	_ = st.UpsertFlag(context.Background(), store.UpsertParams{Key: "checkout", Env: "staging"})
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	for _, body := range []string{
+		`{"target":"pr-1","ttl":"soon"}`,
+		`{"target":"pr-1","ttl":"1s"}`,
+		`{"target":"prod","ttl":"1h"}`,
+	} {
+		rr := doV2Request(t, handler, http.MethodPost, "/v1/environments/staging/clone", body)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+// Ephemeral environments are created by cloning with a "ttl" (see
+// environments.go) and deleted by the reaper once the TTL has passed:
+//
+//	GET /v1/environments/ephemeral   list ephemeral environments and their expiry (admin+)
+//
+// Deleting an environment removes its flags, strips it from webhook
+// environment filters (webhooks scoped only to it are deleted), revokes its
+// SDK keys and records audit events for each step with the system as actor.
+
+const (
+	// minEphemeralEnvTTL and maxEphemeralEnvTTL bound the "ttl" of a clone.
+	minEphemeralEnvTTL = time.Minute
+	maxEphemeralEnvTTL = 30 * 24 * time.Hour
+)
+
+type listEphemeralEnvironmentsResponse struct {
+	Environments []store.EphemeralEnvironment `json:"environments"`
+}
+
+// requireEphemeralEnvStore returns the store as an EphemeralEnvironmentStore,
+// writing an error response and returning nil if it does not support them.
+func (s *Server) requireEphemeralEnvStore(w http.ResponseWriter, r *http.Request) store.EphemeralEnvironmentStore {
+	if es, ok := s.store.(store.EphemeralEnvironmentStore); ok {
+		return es
+	}
+	InternalError(w, r, "Ephemeral environments are not supported by this store")
+	return nil
+}
+
+func (s *Server) handleListEphemeralEnvironments(w http.ResponseWriter, r *http.Request) {
+	es := s.requireEphemeralEnvStore(w, r)
+	if es == nil {
+		return
+	}
+	envs, err := es.ListEphemeralEnvironments(r.Context())
+	if err != nil {
+		InternalError(w, r, "Failed to list ephemeral environments")
+		return
+	}
+	writeJSON(w, http.StatusOK, listEphemeralEnvironmentsResponse{Environments: envs})
+}
+
+// RunEphemeralEnvironmentReaper deletes expired ephemeral environments every
+// interval until ctx is cancelled. It returns immediately if the store does
+// not support ephemeral environments or interval is not positive.
+func (s *Server) RunEphemeralEnvironmentReaper(ctx context.Context, interval time.Duration) {
+	if _, ok := s.store.(store.EphemeralEnvironmentStore); !ok || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ReapEphemeralEnvironments(ctx, time.Now()); err != nil {
+				log.Printf("[ephemeral] reap failed: %v", err)
+			}
+			worker.Processed(ctx)
+		}
+	}
+}
+
+// ReapEphemeralEnvironments deletes every ephemeral environment that expired
+// at or before now and returns their names. An environment whose cleanup
+// fails stays registered and is retried on the next run.
+func (s *Server) ReapEphemeralEnvironments(ctx context.Context, now time.Time) ([]string, error) {
+	es, ok := s.store.(store.EphemeralEnvironmentStore)
+	if !ok {
+		return nil, nil
+	}
+	expired, err := es.ListExpiredEphemeralEnvironments(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for _, env := range expired {
+		if env.Name == s.env {
+			continue // never delete the default environment
+		}
+		if err := s.deleteEphemeralEnvironment(ctx, es, env); err != nil {
+			log.Printf("[ephemeral] failed to delete env=%s: %v", env.Name, err)
+			continue
+		}
+		log.Printf("[ephemeral] deleted expired env=%s", env.Name)
+		deleted = append(deleted, env.Name)
+	}
+	return deleted, nil
+}
+
+// deleteEphemeralEnvironment removes env's flags, webhook filters and SDK
+// keys, then unregisters it.
+func (s *Server) deleteEphemeralEnvironment(ctx context.Context, es store.EphemeralEnvironmentStore, env store.EphemeralEnvironment) error {
+	flags, err := s.store.GetAllFlags(ctx, env.Name)
+	if err != nil {
+		return err
+	}
+	flagKeys := make([]string, 0, len(flags))
+	for i := range flags {
+		before := flagToMap(&flags[i])
+		if err := s.store.DeleteFlag(ctx, flags[i].Key, env.Name); err != nil {
+			return err
+		}
+		flagKeys = append(flagKeys, flags[i].Key)
+		auditID := s.logSystemAudit(audit.NewSystemEventBuilder().
+			ForResource(audit.ResourceTypeFlag, flags[i].Key).
+			WithAction(audit.ActionDeleted).
+			WithEnvironment(env.Name).
+			WithBeforeState(before))
+		if s.webhookDispatcher != nil {
+			s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+				ForFlag(flags[i].Key, env.Name).
+				WithStates(before, nil).
+				WithAuditEventID(auditID).
+				Build())
+		}
+	}
+	if err := s.RebuildSnapshot(ctx, env.Name); err != nil {
+		return err
+	}
+
+	webhooksRemoved, err := s.removeEnvFromWebhooks(ctx, env.Name)
+	if err != nil {
+		return err
+	}
+
+	var sdkKeysRevoked int
+	if ks, ok := s.store.(store.SDKKeyStore); ok {
+		keys, err := ks.ListSDKKeys(ctx, env.Name)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !key.Enabled {
+				continue
+			}
+			if err := ks.RevokeSDKKey(ctx, key.ID); err != nil {
+				return err
+			}
+			sdkKeysRevoked++
+		}
+	}
+
+	if err := es.DeleteEphemeralEnvironment(ctx, env.Name); err != nil {
+		return err
+	}
+	s.logSystemAudit(audit.NewSystemEventBuilder().
+		ForResource(audit.ResourceTypeEnvironment, env.Name).
+		WithAction(audit.ActionDeleted).
+		WithEnvironment(env.Name).
+		WithBeforeState(map[string]any{
+			"name":             env.Name,
+			"expires_at":       env.ExpiresAt.Format(time.RFC3339),
+			"created_by":       env.CreatedBy,
+			"flags":            flagKeys,
+			"webhooks_removed": webhooksRemoved,
+			"sdk_keys_revoked": sdkKeysRevoked,
+		}))
+	return nil
+}
+
+// removeEnvFromWebhooks strips env from webhook environment filters. A webhook
+// filtered to env alone is deleted, since an empty filter would match every
+// environment. Returns the number of webhooks changed or deleted.
+func (s *Server) removeEnvFromWebhooks(ctx context.Context, env string) (int, error) {
+	pgStore, ok := s.store.(PostgresStoreInterface)
+	if !ok {
+		return 0, nil
+	}
+	queries := getQueriesFromStore(pgStore)
+	if queries == nil {
+		return 0, nil
+	}
+	webhooks, err := queries.ListWebhooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, wh := range webhooks {
+		if !slices.Contains(wh.Environments, env) {
+			continue
+		}
+		remaining := slices.DeleteFunc(slices.Clone(wh.Environments), func(e string) bool { return e == env })
+		if len(remaining) == 0 {
+			err = queries.DeleteWebhook(ctx, wh.ID)
+		} else {
+			err = queries.UpdateWebhook(ctx, dbgen.UpdateWebhookParams{
+				ID:             wh.ID,
+				Url:            wh.Url,
+				Description:    wh.Description,
+				Enabled:        wh.Enabled,
+				Events:         wh.Events,
+				ProjectID:      wh.ProjectID,
+				Environments:   remaining,
+				MaxRetries:     wh.MaxRetries,
+				TimeoutSeconds: wh.TimeoutSeconds,
+
+				DeliveryRetentionDays: wh.DeliveryRetentionDays,
+				DeliveryMaxRows:       wh.DeliveryMaxRows,
+			})
+		}
+		if err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// logSystemAudit records an event built by a background job. Like auditLog,
+// it returns the event's ID, or "" if no audit service is configured.
+func (s *Server) logSystemAudit(builder *audit.EventBuilder) string {
+	event := builder.Build()
+	s.logFlagMutation(event)
+	if s.auditService == nil {
+		return "" // No audit service available
+	}
+	s.auditService.Log(event)
+	return event.ID
+}
@@ -22,6 +22,16 @@ const (
 	ErrCodeNotFound       ErrorCode = "NOT_FOUND"            // Resource doesn't exist
 	ErrCodeRateLimited    ErrorCode = "RATE_LIMITED"         // Too many requests
 	ErrCodeRequestTooLarge ErrorCode = "REQUEST_TOO_LARGE"   // Request body too large
+	ErrCodeConflict       ErrorCode = "CONFLICT"             // Request conflicts with current state
+	ErrCodeFlagProtected  ErrorCode = "FLAG_PROTECTED"       // Destructive change to a protected flag
+	ErrCodeLimitExceeded  ErrorCode = "LIMIT_EXCEEDED"       // Write would exceed a configured limit
+	ErrCodeStepUpRequired ErrorCode = "STEP_UP_REQUIRED"     // Missing or invalid step-up code
+	ErrCodeGuardrailTripped ErrorCode = "GUARDRAIL_TRIPPED" // Flag exposure is held by a tripped guardrail
+	ErrCodeUnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE" // Request body is not JSON
+	ErrCodeVersionConflict ErrorCode = "VERSION_CONFLICT" // Flag changed since the expected version
+	ErrCodeNotAcceptable  ErrorCode = "NOT_ACCEPTABLE"       // No acceptable snapshot schema version
+	ErrCodeContextTooLarge ErrorCode = "CONTEXT_TOO_LARGE"  // Evaluation context exceeds the context limits
+	ErrCodeFlagNotFound   ErrorCode = "FLAG_NOT_FOUND"       // Evaluated flag is not served in the environment
 
 	// Validation error codes
 	ErrCodeValidation        ErrorCode = "VALIDATION_ERROR"      // Generic validation failure
@@ -34,6 +44,7 @@ const (
 	ErrCodeSchemaViolation   ErrorCode = "SCHEMA_VIOLATION"      // Data doesn't match schema
 	ErrCodeInvalidExpression ErrorCode = "INVALID_EXPRESSION"    // Targeting expression invalid
 	ErrCodeInvalidVariants   ErrorCode = "INVALID_VARIANTS"      // A/B test variants invalid
+	ErrCodeFlagNotReady      ErrorCode = "FLAG_NOT_READY"        // Enabling a flag that misses readiness checklist items
 )
 
 // ErrorResponse represents a structured API error response.
@@ -186,3 +197,48 @@ func RequestTooLargeError(w http.ResponseWriter, r *http.Request, message string
 	errResp := NewErrorResponse(http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge, message)
 	writeErrorResponse(w, r, http.StatusRequestEntityTooLarge, errResp)
 }
+
+// ConflictError creates a conflict (409) error response.
+//
+// Usage:
+//
+//	ConflictError(w, r, "Git sync already in progress")
+func ConflictError(w http.ResponseWriter, r *http.Request, message string) {
+	errResp := NewErrorResponse(http.StatusConflict, ErrCodeConflict, message)
+	writeErrorResponse(w, r, http.StatusConflict, errResp)
+}
+
+// flagWriteError describes why a flag write was rejected. Shared write paths
+// return it instead of writing to the response so that REST and GraphQL
+// handlers can each render the failure in their own format.
+type flagWriteError struct {
+	status  int
+	code    ErrorCode
+	message string
+	fields  map[string]string
+}
+
+func (e *flagWriteError) Error() string {
+	return e.message
+}
+
+// write renders the error as a structured REST error response.
+func (e *flagWriteError) write(w http.ResponseWriter, r *http.Request) {
+	errResp := NewErrorResponse(e.status, e.code, e.message).WithFields(e.fields)
+	writeErrorResponse(w, r, e.status, errResp)
+}
+
+// validationWriteError builds a 400 VALIDATION_ERROR flagWriteError.
+func validationWriteError(message string, fields map[string]string) *flagWriteError {
+	return &flagWriteError{status: http.StatusBadRequest, code: ErrCodeValidation, message: message, fields: fields}
+}
+
+// notFoundWriteError builds a 404 NOT_FOUND flagWriteError.
+func notFoundWriteError(message string) *flagWriteError {
+	return &flagWriteError{status: http.StatusNotFound, code: ErrCodeNotFound, message: message}
+}
+
+// internalWriteError builds a 500 INTERNAL_ERROR flagWriteError.
+func internalWriteError(message string) *flagWriteError {
+	return &flagWriteError{status: http.StatusInternalServerError, code: ErrCodeInternal, message: message}
+}
@@ -4,6 +4,7 @@ package api
 type EvaluationRequest struct {
 	Context EvaluationContextDTO `json:"context"`
 	FlagKey string               `json:"flagKey,omitempty"`
+	Preset  string               `json:"preset,omitempty"` // context preset, see presets.go
 }
 
 // EvaluationContextDTO represents API-layer evaluation context.
@@ -26,4 +27,6 @@ type FlagResult struct {
 	Value   any    `json:"value,omitempty"`
 	Variant string `json:"variant,omitempty"`
 	Reason  string `json:"reason,omitempty"`
+
+	err error // evaluation failure behind engine.ReasonError, see evalerrors.go
 }
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+)
+
+// Evaluation requests are not audited by default: they are public, frequent
+// and read-only. For security reviews ("who reads which configuration?")
+// SetEvaluationAuditSampling(n) records every n-th evaluation request as an
+// "evaluated" audit event with resource type "evaluation" and the request
+// path as resource ID. The event records:
+//   - the actor: the SDK key (by prefix, with its ID in after_state) or
+//     "anonymous"; the caller's IP and user agent are kept as for any event
+//   - the environment served
+//   - in after_state, the flag keys requested, or all_flags with a count
+//     when the request evaluated every flag
+//
+// Sampling is a shared counter across evaluate and OFREP endpoints, so the
+// rate is exact rather than random. Like all audit events, sampled events
+// are dropped when no audit service is configured.
+
+// evaluationSampler selects 1 in every n evaluation requests.
+type evaluationSampler struct {
+	n     uint64 // 0 disables sampling
+	count atomic.Uint64
+}
+
+// SetEvaluationAuditSampling audits 1 in every n evaluation requests.
+// n <= 0 disables sampling (the default).
+func (s *Server) SetEvaluationAuditSampling(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.evalSampler = &evaluationSampler{n: uint64(n)}
+}
+
+// sample reports whether the current request should be audited.
+func (es *evaluationSampler) sample() bool {
+	if es == nil || es.n == 0 {
+		return false
+	}
+	return es.count.Add(1)%es.n == 0
+}
+
+// auditEvaluation records a sampled evaluation request. requested is the
+// list of flag keys the caller asked for, or empty if it asked for all flags,
+// in which case evaluated is the number of flags returned.
+func (s *Server) auditEvaluation(r *http.Request, requested []string, evaluated int) {
+	if s.auditService == nil || !s.evalSampler.sample() {
+		return
+	}
+	state := map[string]any{"flag_keys": requested}
+	if len(requested) == 0 {
+		state = map[string]any{"all_flags": true, "flag_count": evaluated}
+	}
+	if key, ok := requestSDKKey(r); ok {
+		state["sdk_key_id"] = key.ID
+	}
+	s.auditService.Log(audit.NewEventBuilder(r).
+		WithActor(evaluationActor(r)).
+		ForResource(audit.ResourceTypeEvaluation, r.URL.Path).
+		WithAction(audit.ActionEvaluated).
+		WithEnvironment(s.requestEnvironment(r)).
+		WithAfterState(state).
+		Build())
+}
+
+// evaluationActor identifies the caller of a client endpoint. SDK key IDs
+// are kept out of Actor.ID, which the audit sink stores as an API key ID.
+func evaluationActor(r *http.Request) audit.Actor {
+	if key, ok := requestSDKKey(r); ok {
+		return audit.Actor{
+			Kind:    audit.ActorKindSDKKey,
+			Display: "sdk_key:" + key.KeyPrefix,
+		}
+	}
+	return audit.Actor{
+		Kind:    audit.ActorKindAnonymous,
+		Display: "anonymous",
+	}
+}
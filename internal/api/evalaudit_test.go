@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// recordingSink collects audit events in memory.
+type recordingSink struct {
+	mu     sync.Mutex
+	events []audit.AuditEvent
+}
+
+func (s *recordingSink) Write(_ context.Context, event audit.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// waitForEvents waits until the sink holds n events and returns them.
+func (s *recordingSink) waitForEvents(t *testing.T, n int) []audit.AuditEvent {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		got := append([]audit.AuditEvent(nil), s.events...)
+		s.mu.Unlock()
+		if len(got) >= n || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newEvalAuditTestServer(t *testing.T, sampleRate int) (*Server, *recordingSink) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	for _, key := range []string{"checkout", "search"} {
+		if err := st.UpsertFlag(context.Background(), store.UpsertParams{Key: key, Enabled: true, Rollout: 100, Env: "prod"}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	srv := NewServer(st, "prod", "test-key")
+	if err := srv.RebuildSnapshot(context.Background(), "prod"); err != nil {
+		t.Fatalf("RebuildSnapshot: %v", err)
+	}
+	sink := &recordingSink{}
+	srv.auditService = audit.NewService(sink, nil, nil, nil, 16)
+	t.Cleanup(func() { _ = srv.auditService.Close() })
+	srv.SetEvaluationAuditSampling(sampleRate)
+	return srv, sink
+}
+
+func TestEvaluationAudit_SamplesOneInN(t *testing.T) {
+	srv, sink := newEvalAuditTestServer(t, 3)
+	handler := srv.Router()
+
+	for i := 0; i < 6; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v1/flags/evaluate", strings.NewReader(`{"user":{"id":"u1"},"keys":["checkout"]}`))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	events := sink.waitForEvents(t, 2)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 sampled events out of 6 requests, got %d", len(events))
+	}
+	event := events[0]
+	if event.Action != audit.ActionEvaluated || event.ResourceType != audit.ResourceTypeEvaluation || event.ResourceID != "/v1/flags/evaluate" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if event.Actor.Kind != audit.ActorKindAnonymous {
+		t.Errorf("actor kind = %q, want anonymous", event.Actor.Kind)
+	}
+	if event.Environment == nil || *event.Environment != "prod" {
+		t.Errorf("environment = %v, want prod", event.Environment)
+	}
+	if keys, ok := event.AfterState["flag_keys"].([]string); !ok || len(keys) != 1 || keys[0] != "checkout" {
+		t.Errorf("flag_keys = %v, want [checkout]", event.AfterState["flag_keys"])
+	}
+}
+
+func TestEvaluationAudit_AllFlagsAndDisabledByDefault(t *testing.T) {
+	srv, sink := newEvalAuditTestServer(t, 1)
+	handler := srv.Router()
+
+	req := httptest.NewRequest(http.MethodPost, "/ofrep/v1/evaluate/flags", strings.NewReader(`{"context":{"targetingKey":"u1"}}`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	events := sink.waitForEvents(t, 1)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].AfterState["all_flags"] != true || events[0].AfterState["flag_count"] != 2 {
+		t.Errorf("unexpected after_state: %v", events[0].AfterState)
+	}
+
+	srv.SetEvaluationAuditSampling(0)
+	req = httptest.NewRequest(http.MethodPost, "/ofrep/v1/evaluate/flags", strings.NewReader(`{}`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got := sink.waitForEvents(t, 2); len(got) != 1 {
+		t.Errorf("sampling disabled: expected no new events, got %d total", len(got))
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+)
+
+// A flag whose expression or targeting rules fail at runtime, or whose
+// evaluation panics, does not fail the request: it serves its on_error value ({"on_error": true} in the flag
+// config keeps it on, the default is off) with reason ERROR. Each failure is
+// counted in flag_evaluation_errors_total and tracked here so the condition
+// is visible to admins instead of silently serving the fallback forever:
+//
+//	GET /v1/admin/stats   flags failing evaluation in the last 24 hours (admin+)
+//
+// Like insights, the data is kept in memory per server instance.
+
+const (
+	// evalErrorWindow is how long a failing flag is reported after its last error.
+	evalErrorWindow = 24 * time.Hour
+
+	// maxTrackedEvalErrors bounds the tracker; further flags are only counted
+	// in the metric until old entries expire.
+	maxTrackedEvalErrors = 10000
+)
+
+type evalErrorStat struct {
+	Env          string    `json:"env"`
+	Key          string    `json:"key"`
+	Errors       int64     `json:"errors"` // failed evaluations since first_error_at
+	LastError    string    `json:"last_error"`
+	FirstErrorAt time.Time `json:"first_error_at"`
+	LastErrorAt  time.Time `json:"last_error_at"`
+	OnError      bool      `json:"on_error"` // enabled state currently served while failing
+}
+
+type adminStatsResponse struct {
+	EvaluationErrors []evalErrorStat `json:"evaluation_errors"` // most recent first
+}
+
+// evalErrorTracker records failing flag evaluations per environment and key.
+type evalErrorTracker struct {
+	mu    sync.Mutex
+	flags map[string]*evalErrorStat // "env/key" -> stat
+	now   func() time.Time
+}
+
+func newEvalErrorTracker() *evalErrorTracker {
+	return &evalErrorTracker{flags: make(map[string]*evalErrorStat), now: time.Now}
+}
+
+// record counts a failed evaluation of key in env.
+func (t *evalErrorTracker) record(env, key string, err error) {
+	now := t.now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := env + "/" + key
+	stat := t.flags[id]
+	if stat != nil && now.Sub(stat.LastErrorAt) > evalErrorWindow {
+		stat = nil // recovered since; start a new episode
+	}
+	if stat == nil {
+		if len(t.flags) >= maxTrackedEvalErrors {
+			t.expire(now)
+			if len(t.flags) >= maxTrackedEvalErrors {
+				return
+			}
+		}
+		stat = &evalErrorStat{Env: env, Key: key, FirstErrorAt: now}
+		t.flags[id] = stat
+	}
+	stat.Errors++
+	stat.LastError = err.Error()
+	stat.LastErrorAt = now
+}
+
+// expire drops flags without errors in the window. t.mu must be held.
+func (t *evalErrorTracker) expire(now time.Time) {
+	for id, stat := range t.flags {
+		if now.Sub(stat.LastErrorAt) > evalErrorWindow {
+			delete(t.flags, id)
+		}
+	}
+}
+
+// list returns the flags that failed within the window, most recent first.
+func (t *evalErrorTracker) list() []evalErrorStat {
+	now := t.now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(now)
+	stats := make([]evalErrorStat, 0, len(t.flags))
+	for _, stat := range t.flags {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if !stats[i].LastErrorAt.Equal(stats[j].LastErrorAt) {
+			return stats[i].LastErrorAt.After(stats[j].LastErrorAt)
+		}
+		return stats[i].Env+"/"+stats[i].Key < stats[j].Env+"/"+stats[j].Key
+	})
+	return stats
+}
+
+// recordEvaluationError records a failed evaluation of key in the request's
+// environment. A nil err is ignored.
+func (s *Server) recordEvaluationError(r *http.Request, key string, err error) {
+	if err != nil {
+		s.evalErrors.record(s.requestEnvironment(r), key, err)
+	}
+}
+
+// handleAdminStats handles GET /v1/admin/stats.
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	resp := adminStatsResponse{EvaluationErrors: []evalErrorStat{}}
+	for _, stat := range s.evalErrors.list() {
+		if !tenantOwnsEnv(r, stat.Env) {
+			continue
+		}
+		if flag, ok := s.snapshotForEnv(stat.Env).Flags[stat.Key]; ok {
+			stat.OnError = rules.OnErrorEnabled(flag.Config)
+		}
+		resp.EvaluationErrors = append(resp.EvaluationErrors, stat)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestAdminStats_EvaluationErrors(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	// Written directly: the API rejects expressions that can't be evaluated.
+	expr := `{"no_such_operator": [{"var": "plan"}, "premium"]}`
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Expression: &expr, Config: map[string]any{"on_error": true}, Env: "prod"})
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "healthy", Enabled: true, Rollout: 100, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/v1/flags/evaluate?userId=user-1&plan=premium", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("evaluate: expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp evaluateResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range resp.Flags {
+			if res.Key == "checkout" && (!res.Enabled || res.Reason != evaluation.ReasonError) {
+				t.Fatalf("expected the on_error value with reason ERROR, got %+v", res)
+			}
+		}
+	}
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/admin/stats", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp adminStatsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.EvaluationErrors) != 1 {
+		t.Fatalf("expected only the failing flag, got %+v", resp.EvaluationErrors)
+	}
+	got := resp.EvaluationErrors[0]
+	if got.Env != "prod" || got.Key != "checkout" || got.Errors != 2 || !got.OnError || got.LastError == "" {
+		t.Errorf("unexpected stats: %+v", got)
+	}
+}
+
+func TestEvalErrorTracker_Window(t *testing.T) {
+	tracker := newEvalErrorTracker()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	tracker.record("prod", "checkout", errors.New("boom"))
+	now = now.Add(time.Hour)
+	tracker.record("prod", "search", errors.New("bang"))
+
+	stats := tracker.list()
+	if len(stats) != 2 || stats[0].Key != "search" || stats[1].Key != "checkout" {
+		t.Fatalf("expected both flags, most recent first, got %+v", stats)
+	}
+
+	now = now.Add(evalErrorWindow)
+	stats = tracker.list()
+	if len(stats) != 1 || stats[0].Key != "search" {
+		t.Fatalf("expected checkout to fall out of the window, got %+v", stats)
+	}
+}
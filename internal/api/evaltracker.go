@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+// evaluationTracker remembers when each flag was last evaluated, either by
+// the server (evaluate and OFREP endpoints) or by an SDK reporting exposures.
+// Evaluations are recorded in memory, off the store's write path; with a
+// store.FlagEvaluationStore, RunEvaluationFlush writes them to the store in
+// batches, so the times outlive restarts and are shared by replicas. Without
+// one, every flag is unknown again after a restart; startedAt tells readers
+// how far back the data goes.
+type evaluationTracker struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	last      map[string]time.Time // flagID-style "env/key" -> last evaluation
+	pending   map[trackedFlag]bool // evaluated since the last flush; nil until flushing starts
+	now       func() time.Time
+}
+
+// trackedFlag identifies a flag awaiting a flush.
+type trackedFlag struct {
+	env, key string
+}
+
+func newEvaluationTracker() *evaluationTracker {
+	return &evaluationTracker{
+		startedAt: time.Now().UTC(),
+		last:      make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// touch records an evaluation of keys in env.
+func (t *evaluationTracker) touch(env string, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	now := t.now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range keys {
+		t.last[env+"/"+key] = now
+		if t.pending != nil {
+			t.pending[trackedFlag{env, key}] = true
+		}
+	}
+}
+
+// lastEvaluated returns when key in env was last evaluated.
+func (t *evaluationTracker) lastEvaluated(env, key string) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	at, ok := t.last[env+"/"+key]
+	return at, ok
+}
+
+// startPending makes touch remember evaluations for takePending.
+func (t *evaluationTracker) startPending() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending == nil {
+		t.pending = make(map[trackedFlag]bool)
+	}
+}
+
+// takePending returns the evaluations recorded since the last call, by env
+// and key, and forgets them.
+func (t *evaluationTracker) takePending() map[string]map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.pending) == 0 {
+		return nil
+	}
+	batch := make(map[string]map[string]time.Time)
+	for f := range t.pending {
+		if batch[f.env] == nil {
+			batch[f.env] = make(map[string]time.Time)
+		}
+		batch[f.env][f.key] = t.last[f.env+"/"+f.key]
+	}
+	clear(t.pending)
+	return batch
+}
+
+// requeue marks the flags of a batch that failed to flush as pending again.
+func (t *evaluationTracker) requeue(batch map[string]map[string]time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for env, keys := range batch {
+		for key := range keys {
+			t.pending[trackedFlag{env, key}] = true
+		}
+	}
+}
+
+// RunEvaluationFlush writes the evaluation times recorded since the last
+// flush to the store every interval until ctx is cancelled. It returns
+// immediately if interval is not positive or the store does not keep
+// evaluation times. Call FlushEvaluations once more on shutdown.
+func (s *Server) RunEvaluationFlush(ctx context.Context, interval time.Duration) {
+	if _, ok := s.store.(store.FlagEvaluationStore); !ok || interval <= 0 {
+		return
+	}
+	s.evalTracker.startPending()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.FlushEvaluations(ctx); err != nil {
+				log.Printf("[evaluations] flush failed: %v", err)
+			}
+			worker.Processed(ctx)
+		}
+	}
+}
+
+// FlushEvaluations writes the evaluation times recorded since the last flush
+// to the store. Flags that fail to be written are kept for the next flush.
+func (s *Server) FlushEvaluations(ctx context.Context) error {
+	es, ok := s.store.(store.FlagEvaluationStore)
+	if !ok {
+		return nil
+	}
+	batch := s.evalTracker.takePending()
+	for env, at := range batch {
+		if err := es.RecordFlagEvaluations(ctx, env, at); err != nil {
+			s.evalTracker.requeue(batch)
+			return err
+		}
+	}
+	return nil
+}
+
+// lastEvaluations returns the last evaluation time of the flags of env that
+// have been evaluated, by key: the later of the store's and this server's
+// own, not yet flushed, record.
+func (s *Server) lastEvaluations(ctx context.Context, env string) (map[string]time.Time, error) {
+	result := make(map[string]time.Time)
+	if es, ok := s.store.(store.FlagEvaluationStore); ok {
+		stored, err := es.ListFlagEvaluations(ctx, env)
+		if err != nil {
+			return nil, err
+		}
+		result = stored
+	}
+	prefix := env + "/"
+	s.evalTracker.mu.Lock()
+	defer s.evalTracker.mu.Unlock()
+	for id, at := range s.evalTracker.last {
+		if key, ok := strings.CutPrefix(id, prefix); ok && at.After(result[key]) {
+			result[key] = at
+		}
+	}
+	return result, nil
+}
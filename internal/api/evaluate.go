@@ -4,7 +4,8 @@
 //
 //  1. Parse and validate request (user ID required, optional flag keys filter)
 //  2. Load current snapshot from memory (thread-safe atomic read)
-//  3. For each flag in snapshot (or filtered subset):
+//  3. For each flag in snapshot (or filtered subset; requested keys the
+//     environment does not serve get reason FLAG_NOT_FOUND):
 //     a. Check if flag is enabled (if not, return enabled=false)
 //     b. Evaluate targeting expression against user context (using JSON Logic)
 //     c. Evaluate rollout percentage with deterministic bucketing (hash-based)
@@ -16,19 +17,20 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/evaluation"
 	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 )
 
 // evaluateRequest represents the request body for POST /v1/flags/evaluate
 type evaluateRequest struct {
-	User *evaluateUser `json:"user"`
-	Keys []string      `json:"keys,omitempty"`
+	User   *evaluateUser `json:"user"`
+	Keys   []string      `json:"keys,omitempty"`
+	Preset string        `json:"preset,omitempty"` // context preset, see presets.go
 }
 
 // evaluateUser represents the user context in evaluate request
@@ -47,17 +49,32 @@ type evaluateResponse struct {
 // handleEvaluate handles POST /v1/flags/evaluate
 func (s *Server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
 	var req evaluateRequest
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: "+err.Error())
+	if !decodeClientJSON(w, r, &req, maxJSONBodySize) {
 		return
 	}
 
+	// Build evaluation context, starting from the preset if one is named
+	var ctx evaluation.Context
+	if req.User != nil {
+		ctx = evaluation.Context{
+			UserID:     req.User.ID,
+			Attributes: req.User.Attributes,
+		}
+	}
+	preset := strings.TrimSpace(req.Preset)
+	if preset != "" {
+		p, ok := s.loadContextPreset(w, r, preset)
+		if !ok {
+			return
+		}
+		ctx = presetEvaluationContext(p, ctx)
+	}
+
 	// Validate with field-level errors
 	errors := make(map[string]string)
-	if req.User == nil {
+	if req.User == nil && preset == "" {
 		errors["user"] = "User is required"
-	} else if strings.TrimSpace(req.User.ID) == "" {
+	} else if strings.TrimSpace(ctx.UserID) == "" {
 		errors["user.id"] = "User ID is required"
 	}
 
@@ -66,27 +83,15 @@ func (s *Server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build evaluation context and evaluate
-	ctx := evaluation.Context{
-		UserID:     req.User.ID,
-		Attributes: req.User.Attributes,
-	}
-
-	s.evaluateAndRespond(w, ctx, req.Keys)
+	s.evaluateAndRespond(w, r, ctx, req.Keys)
 }
 
 // handleEvaluateGET handles GET /v1/flags/evaluate with query parameters
 func (s *Server) handleEvaluateGET(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	// Get userId (required)
+	// Get userId (required unless the preset has one)
 	userID := strings.TrimSpace(query.Get("userId"))
-	if userID == "" {
-		BadRequestErrorWithFields(w, r, ErrCodeMissingField, "Missing required parameter", map[string]string{
-			"userId": "userId query parameter is required",
-		})
-		return
-	}
 
 	// Get keys (optional, comma-separated)
 	var keys []string
@@ -100,8 +105,8 @@ func (s *Server) handleEvaluateGET(w http.ResponseWriter, r *http.Request) {
 	// Build attributes from other query params
 	attributes := make(map[string]any)
 	for key, values := range query {
-		// Skip userId and keys parameters
-		if key == "userId" || key == "keys" {
+		// Skip userId, keys and preset parameters
+		if key == "userId" || key == "keys" || key == "preset" {
 			continue
 		}
 		// Use the first value for each attribute
@@ -115,18 +120,58 @@ func (s *Server) handleEvaluateGET(w http.ResponseWriter, r *http.Request) {
 		UserID:     userID,
 		Attributes: attributes,
 	}
+	if preset := strings.TrimSpace(query.Get("preset")); preset != "" {
+		p, ok := s.loadContextPreset(w, r, preset)
+		if !ok {
+			return
+		}
+		ctx = presetEvaluationContext(p, ctx)
+	}
+	if ctx.UserID == "" {
+		BadRequestErrorWithFields(w, r, ErrCodeMissingField, "Missing required parameter", map[string]string{
+			"userId": "userId query parameter is required",
+		})
+		return
+	}
 
-	s.evaluateAndRespond(w, ctx, keys)
+	s.evaluateAndRespond(w, r, ctx, keys)
 }
 
 // evaluateAndRespond performs flag evaluation and writes the JSON response.
 // This is shared by both POST and GET evaluation handlers to avoid duplication.
-func (s *Server) evaluateAndRespond(w http.ResponseWriter, ctx evaluation.Context, keys []string) {
-	// Load current snapshot
-	snap := snapshot.Load()
+func (s *Server) evaluateAndRespond(w http.ResponseWriter, r *http.Request, ctx evaluation.Context, keys []string) {
+	if violations := s.checkEvaluationContextLimits(ctx); violations != nil {
+		writeContextTooLarge(w, r, violations)
+		return
+	}
+	if violations := s.checkEvaluationContext(r, ctx); violations != nil {
+		BadRequestErrorWithFields(w, r, ErrCodeSchemaViolation, "Context uses undeclared or mistyped attributes", contextViolationFields(violations))
+		return
+	}
+	s.observeEvaluationContext(r, ctx)
 
-	// Evaluate flags
-	results := evaluation.EvaluateAll(snap.Flags, ctx, snap.RolloutSalt, keys)
+	// Load current snapshot
+	snap := s.requestSnapshot(r)
+
+	// Evaluate flags, serving the user's overrides (see overrides.go)
+	overrides := s.userOverrides.forUser(s.requestEnvironment(r), ctx.UserID)
+	results := evaluateAll(snap.Flags, ctx, snap.RolloutSalt, keys, overrides)
+	found := make([]evaluation.Result, 0, len(results)) // without FLAG_NOT_FOUND results
+	evaluated := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.Reason != evaluation.ReasonFlagNotFound {
+			found = append(found, result)
+			evaluated = append(evaluated, result.Key)
+		}
+	}
+	s.evalTracker.touch(s.requestEnvironment(r), evaluated...)
+	s.recordEvaluationResults(r, found)
+	s.compareShadows(r, ctx.UserID, len(found), func(i int) shadowOutcome { return evaluationOutcome(found[i]) },
+		func(flag snapshot.FlagView) shadowOutcome {
+			return evaluationOutcome(evaluateFlag(flag, ctx, snap.RolloutSalt, overrides))
+		})
+	telemetry.ObserveEvaluatedFlags(telemetry.EvalHandlerFlags, len(found))
+	s.auditEvaluation(r, keys, len(found))
 
 	// Build and write response
 	resp := evaluateResponse{
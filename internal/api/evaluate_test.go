@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
 	"github.com/TimurManjosov/goflagship/internal/snapshot"
 	"github.com/TimurManjosov/goflagship/internal/store"
 )
@@ -441,12 +442,15 @@ func TestHandleEvaluate_NonExistentKey(t *testing.T) {
 	var resp evaluateResponse
 	json.NewDecoder(rr.Body).Decode(&resp)
 
-	// Should only return existing flag
-	if len(resp.Flags) != 1 {
-		t.Errorf("Expected 1 flag, got %d", len(resp.Flags))
+	// The missing flag is reported as not found, in request order
+	if len(resp.Flags) != 2 {
+		t.Fatalf("Expected 2 flags, got %d", len(resp.Flags))
+	}
+	if resp.Flags[0].Key != "flag1" || resp.Flags[0].Reason == evaluation.ReasonFlagNotFound {
+		t.Errorf("Expected flag1 to be evaluated, got %+v", resp.Flags[0])
 	}
-	if resp.Flags[0].Key != "flag1" {
-		t.Errorf("Expected flag1, got %s", resp.Flags[0].Key)
+	if missing := resp.Flags[1]; missing.Key != "nonexistent" || missing.Enabled || missing.Reason != evaluation.ReasonFlagNotFound {
+		t.Errorf("Expected nonexistent to be FLAG_NOT_FOUND, got %+v", missing)
 	}
 }
 
@@ -1,16 +1,17 @@
 package api
 
 import (
-	"encoding/json"
-	"errors"
 	"net/http"
 	"sort"
 	"strings"
 	"sync"
 
 	"github.com/TimurManjosov/goflagship/internal/engine"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/rules"
 	"github.com/TimurManjosov/goflagship/internal/snapshot"
 	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 )
 
 var snapshotAccessMu sync.RWMutex
@@ -18,18 +19,16 @@ var snapshotAccessMu sync.RWMutex
 // handleContextEvaluate handles POST /v1/evaluate.
 // POST is used to support complex JSON context payloads while keeping evaluation stateless.
 func (s *Server) handleContextEvaluate(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, maxFlagRequestBodySize)
-	defer r.Body.Close()
-
 	var req EvaluationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		var maxErr *http.MaxBytesError
-		if errors.As(err, &maxErr) {
-			RequestTooLargeError(w, r, "Request body exceeds 1MB limit")
+	if !decodeClientJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	if preset := strings.TrimSpace(req.Preset); preset != "" {
+		p, ok := s.loadContextPreset(w, r, preset)
+		if !ok {
 			return
 		}
-		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: "+err.Error())
-		return
+		req.Context = presetUserContext(p, req.Context)
 	}
 
 	if isEmptyEvaluationContext(req.Context) {
@@ -40,35 +39,49 @@ func (s *Server) handleContextEvaluate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := toUserContext(req.Context)
+	if violations := s.checkUserContextLimits(&ctx); violations != nil {
+		writeContextTooLarge(w, r, violations)
+		return
+	}
+	if violations := s.checkUserContext(r, &ctx); violations != nil {
+		BadRequestErrorWithFields(w, r, ErrCodeSchemaViolation, "Context uses undeclared or mistyped attributes", contextViolationFields(violations))
+		return
+	}
+	s.observeUserContext(r, &ctx)
 	flagKey := strings.TrimSpace(req.FlagKey)
 	if flagKey != "" {
 		s.evaluateSingleFlag(w, r, flagKey, &ctx)
 		return
 	}
 
-	s.evaluateAllFlags(w, &ctx)
+	s.evaluateAllFlags(w, r, &ctx)
 }
 
 func (s *Server) evaluateSingleFlag(w http.ResponseWriter, r *http.Request, flagKey string, ctx *engine.UserContext) {
-	snapshotAccessMu.RLock()
-	snap := snapshot.Load()
-	snapshotAccessMu.RUnlock()
+	snap := s.requestSnapshot(r)
 	flag, exists := snap.Flags[flagKey]
 	if !exists {
-		NotFoundError(w, r, "Flag '"+flagKey+"' not found")
+		writeFlagNotFound(w, r, flagKey, s.requestEnvironment(r))
 		return
 	}
 
-	result := evaluateSnapshotFlag(flag, ctx)
+	overrides := s.userOverrides.forUser(s.requestEnvironment(r), ctx.ID)
+	result := evaluateSnapshotFlag(flag, ctx, overrides)
+	s.evalTracker.touch(s.requestEnvironment(r), flagKey)
+	s.recordFlagResults(r, []FlagResult{result})
+	s.compareShadows(r, ctx.ID, 1, func(int) shadowOutcome { return result.outcome() },
+		func(flag snapshot.FlagView) shadowOutcome {
+			return evaluateSnapshotFlag(flag, ctx, overrides).outcome()
+		})
+	telemetry.ObserveEvaluatedFlags(telemetry.EvalHandlerContext, 1)
+	s.auditEvaluation(r, []string{flagKey}, 1)
 	writeJSON(w, http.StatusOK, EvaluationResponse{
 		Results: []FlagResult{result},
 	})
 }
 
-func (s *Server) evaluateAllFlags(w http.ResponseWriter, ctx *engine.UserContext) {
-	snapshotAccessMu.RLock()
-	snap := snapshot.Load()
-	snapshotAccessMu.RUnlock()
+func (s *Server) evaluateAllFlags(w http.ResponseWriter, r *http.Request, ctx *engine.UserContext) {
+	snap := s.requestSnapshot(r)
 
 	keys := make([]string, 0, len(snap.Flags))
 	for key := range snap.Flags {
@@ -76,24 +89,46 @@ func (s *Server) evaluateAllFlags(w http.ResponseWriter, ctx *engine.UserContext
 	}
 	sort.Strings(keys)
 
+	overrides := s.userOverrides.forUser(s.requestEnvironment(r), ctx.ID)
 	results := make([]FlagResult, 0, len(keys))
 	for _, key := range keys {
-		results = append(results, evaluateSnapshotFlag(snap.Flags[key], ctx))
+		results = append(results, evaluateSnapshotFlag(snap.Flags[key], ctx, overrides))
 	}
+	s.evalTracker.touch(s.requestEnvironment(r), keys...)
+	s.recordFlagResults(r, results)
+	s.compareShadows(r, ctx.ID, len(results), func(i int) shadowOutcome { return results[i].outcome() },
+		func(flag snapshot.FlagView) shadowOutcome {
+			return evaluateSnapshotFlag(flag, ctx, overrides).outcome()
+		})
+	telemetry.ObserveEvaluatedFlags(telemetry.EvalHandlerContext, len(results))
+	s.auditEvaluation(r, nil, len(results))
 
 	writeJSON(w, http.StatusOK, EvaluationResponse{
 		Results: results,
 	})
 }
 
-func evaluateSnapshotFlag(flag snapshot.FlagView, ctx *engine.UserContext) FlagResult {
-	evaluation := engine.Evaluate(toStoreFlag(flag), ctx)
+// evaluateSnapshotFlag evaluates flag with the engine, serving the user's
+// override of the flag if overrides has one.
+func evaluateSnapshotFlag(flag snapshot.FlagView, ctx *engine.UserContext, overrides map[string]evaluation.Override) FlagResult {
+	var result engine.EvaluationResult
+	var enabled bool
+	if o, ok := overrides[flag.Key]; ok && flag.Enabled {
+		result, enabled = overrideEngineResult(flag, ctx, o)
+	} else {
+		result = engine.Evaluate(toStoreFlag(flag), ctx)
+		enabled = result.Reason != string(engine.ReasonDisabled)
+	}
+	if result.Err != nil {
+		enabled = rules.OnErrorEnabled(flag.Config)
+	}
 	return FlagResult{
 		Key:     flag.Key,
-		Enabled: evaluation.Reason != string(engine.ReasonDisabled),
-		Value:   evaluation.Value,
-		Variant: evaluation.Variant,
-		Reason:  evaluation.Reason,
+		Enabled: enabled,
+		Value:   result.Value,
+		Variant: result.Variant,
+		Reason:  result.Reason,
+		err:     result.Err,
 	}
 }
 
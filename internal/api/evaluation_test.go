@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/engine"
 	"github.com/TimurManjosov/goflagship/internal/rules"
@@ -90,8 +91,8 @@ func TestHandleContextEvaluate(t *testing.T) {
 				if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
 					t.Fatalf("decode response: %v", err)
 				}
-				if resp.Code != ErrCodeNotFound {
-					t.Fatalf("expected NOT_FOUND code, got %s", resp.Code)
+				if resp.Code != ErrCodeFlagNotFound {
+					t.Fatalf("expected FLAG_NOT_FOUND code, got %s", resp.Code)
 				}
 			},
 		},
@@ -224,10 +225,18 @@ func (panicStore) GetAllFlags(context.Context, string) ([]store.Flag, error) {
 	panic("GetAllFlags should not be called")
 }
 
-func (panicStore) GetFlagByKey(context.Context, string) (*store.Flag, error) {
+func (panicStore) GetFlagByKey(context.Context, string, string) (*store.Flag, error) {
 	panic("GetFlagByKey should not be called")
 }
 
+func (panicStore) GetFlagsByKeys(context.Context, string, []string) ([]store.Flag, error) {
+	panic("GetFlagsByKeys should not be called")
+}
+
+func (panicStore) GetFlagsUpdatedSince(context.Context, string, time.Time) ([]store.Flag, error) {
+	panic("GetFlagsUpdatedSince should not be called")
+}
+
 func (panicStore) UpsertFlag(context.Context, store.UpsertParams) error {
 	panic("UpsertFlag should not be called")
 }
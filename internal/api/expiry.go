@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+// Temporary flags (a launch banner, a kill switch for an incident) can be
+// given an end:
+//
+//	{"key": "black_friday_banner", "enabled": true, "expires_at": "2026-11-30T00:00:00Z"}
+//
+// A job (every minute by default, FLAG_EXPIRY_INTERVAL) turns off each flag
+// whose expires_at has passed: it is disabled, or archived with
+// FLAG_EXPIRY_ACTION=archive, and its environment's snapshot is rebuilt. The
+// change is audited with the system as actor and sent as a "flag.updated"
+// webhook event, followed by a "flag.expired" event.
+//
+// expires_at is part of the flag's state like description: a write that
+// omits it removes the expiry. PATCH /v2/flags can set it but not remove it.
+// The flag keeps its expires_at after expiring, so a disabled flag that is
+// enabled again is disabled on the next run unless expires_at is moved or
+// removed in the same write. Each flag is written at the version that was
+// read, so a concurrent edit wins and replicas running the job do not change
+// a flag twice.
+
+// FlagExpiryAction selects what happens to a flag once its expires_at has passed.
+type FlagExpiryAction string
+
+const (
+	FlagExpiryDisable FlagExpiryAction = "disable"
+	FlagExpiryArchive FlagExpiryAction = "archive"
+)
+
+// SetFlagExpiryAction sets what the expiry job does to expired flags. The
+// default is FlagExpiryDisable.
+func (s *Server) SetFlagExpiryAction(action FlagExpiryAction) {
+	s.flagExpiryAction = action
+}
+
+// RunFlagExpiry runs ExpireFlags every interval until ctx is cancelled. It
+// returns immediately if the store cannot list expired flags or interval is
+// not positive.
+func (s *Server) RunFlagExpiry(ctx context.Context, interval time.Duration) {
+	if _, ok := s.store.(store.FlagExpiryStore); !ok || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ExpireFlags(ctx, time.Now()); err != nil {
+				log.Printf("[expiry] run failed: %v", err)
+			}
+			worker.Processed(ctx)
+		}
+	}
+}
+
+// ExpireFlags disables or archives every flag that expired at or before now
+// and returns them as they were before. A flag that fails to be written is
+// logged and retried on the next run.
+func (s *Server) ExpireFlags(ctx context.Context, now time.Time) ([]store.Flag, error) {
+	es, ok := s.store.(store.FlagExpiryStore)
+	if !ok {
+		return nil, nil
+	}
+	flags, err := es.ListExpiredFlags(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	action := bulkActionDisable
+	if s.flagExpiryAction == FlagExpiryArchive {
+		action = bulkActionArchive
+	}
+	var expired []store.Flag
+	var envs []string
+	for i := range flags {
+		flag := &flags[i]
+		params, changed := bulkActionParams(flag, action)
+		if !changed {
+			continue // already disabled
+		}
+		params.ExpectedVersion = &flag.Version
+		if err := s.store.UpsertFlag(ctx, params); err != nil {
+			if !errors.Is(err, store.ErrVersionConflict) {
+				log.Printf("[expiry] failed to %s flag=%s env=%s: %v", action, flag.Key, flag.Env, err)
+			}
+			continue // a conflicting write is re-read on the next run
+		}
+		if len(envs) == 0 || envs[len(envs)-1] != flag.Env {
+			envs = append(envs, flag.Env) // flags are ordered by env
+		}
+		expired = append(expired, *flag)
+	}
+	for _, env := range envs {
+		if err := s.RebuildSnapshot(ctx, env); err != nil {
+			log.Printf("[expiry] snapshot rebuild failed for env=%s: %v", env, err)
+		}
+	}
+
+	for i := range expired {
+		before := &expired[i]
+		log.Printf("[expiry] %s flag=%s env=%s expired at %s", action, before.Key, before.Env, before.ExpiresAt.Format(time.RFC3339))
+		beforeState := flagToMap(before)
+		var afterState map[string]any
+		if after, err := s.store.GetFlagByKey(ctx, before.Key, before.Env); err == nil {
+			afterState = flagToMap(after)
+		}
+		changes := audit.ComputeChanges(beforeState, afterState)
+		auditID := s.logSystemAudit(audit.NewSystemEventBuilder().
+			ForResource(audit.ResourceTypeFlag, before.Key).
+			WithAction(audit.ActionUpdated).
+			WithEnvironment(before.Env).
+			WithBeforeState(beforeState).
+			WithAfterState(afterState).
+			WithChanges(changes).
+			WithNote(action + "d on expiry at " + before.ExpiresAt.Format(time.RFC3339)))
+		if s.webhookDispatcher != nil {
+			s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+				ForFlag(before.Key, before.Env).
+				WithStates(beforeState, afterState).
+				WithChanges(changes).
+				WithAuditEventID(auditID).
+				WithProject(before.Project).
+				Build())
+			s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+				ForFlagAlert(webhook.EventFlagExpired, before.Key, before.Env, map[string]any{
+					"expires_at": before.ExpiresAt,
+					"action":     action,
+				}).
+				WithAuditEventID(auditID).
+				WithProject(before.Project).
+				Build())
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestExpireFlags(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	seed := []store.UpsertParams{
+		{Key: "banner", Enabled: true, Rollout: 100, ExpiresAt: &past, Env: "prod"},
+		{Key: "sale", Enabled: true, Rollout: 100, ExpiresAt: &future, Env: "prod"},
+		{Key: "off", Enabled: false, ExpiresAt: &past, Env: "prod"},
+		{Key: "kept", Enabled: true, Rollout: 100, Env: "prod"},
+	}
+	for _, p := range seed {
+		if err := st.UpsertFlag(ctx, p); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	srv := NewServer(st, "prod", "test-key")
+
+	expired, err := srv.ExpireFlags(ctx, now)
+	if err != nil {
+		t.Fatalf("ExpireFlags: %v", err)
+	}
+	if len(expired) != 1 || expired[0].Key != "banner" {
+		t.Fatalf("expired = %+v, want only banner", expired)
+	}
+	flag, _ := st.GetFlagByKey(ctx, "banner", "prod")
+	if flag.Enabled || flag.Archived || flag.ExpiresAt == nil {
+		t.Errorf("banner should be disabled and keep its expires_at, got %+v", flag)
+	}
+	if served, ok := snapshot.Load().Flags["banner"]; !ok || served.Enabled {
+		t.Error("snapshot should serve banner disabled")
+	}
+	for _, key := range []string{"sale", "kept"} {
+		if flag, _ := st.GetFlagByKey(ctx, key, "prod"); !flag.Enabled {
+			t.Errorf("%s must stay enabled", key)
+		}
+	}
+
+	if expired, _ := srv.ExpireFlags(ctx, now); len(expired) != 0 {
+		t.Errorf("a second run should change nothing, got %+v", expired)
+	}
+}
+
+func TestExpireFlags_Archive(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	past := time.Now().UTC().Add(-time.Minute)
+	if err := st.UpsertFlag(ctx, store.UpsertParams{Key: "banner", Enabled: false, ExpiresAt: &past, Env: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(st, "prod", "test-key")
+	srv.SetFlagExpiryAction(FlagExpiryArchive)
+
+	if expired, err := srv.ExpireFlags(ctx, time.Now()); err != nil || len(expired) != 1 {
+		t.Fatalf("expected banner to be archived, got %+v, %v", expired, err)
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "banner", "prod"); !flag.Archived {
+		t.Error("banner should be archived")
+	}
+}
+
+func TestExpiresAt_StoredAndCleared(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"banner","enabled":true,"rollout":100,"env":"prod","expires_at":"2030-01-02T03:04:05Z"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	want := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := getFlagResponse(t, handler, "banner").ExpiresAt; got == nil || !got.Equal(want) {
+		t.Fatalf("expires_at = %v, want %v", got, want)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"banner","enabled":true,"rollout":100,"env":"prod"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := getFlagResponse(t, handler, "banner").ExpiresAt; got != nil {
+		t.Errorf("a write without expires_at should remove it, got %v", got)
+	}
+}
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"gopkg.in/yaml.v3"
+)
+
+// Flag sets can be copied between clusters and kept in Git as files:
+//
+//	GET  /v1/flags/export?env=prod&format=json|yaml              (admin+)
+//	POST /v1/flags/import?env=staging&strategy=fail|skip|overwrite (admin+)
+//
+// An export is {"env", "exported_at", "flags"}, each flag in the shape of
+// POST /v1/flags without env, sorted by key. YAML exports have the same keys.
+// Import accepts an export as JSON or, with Content-Type application/yaml, as
+// YAML, and writes its flags into ?env= (default: the document's env, then
+// the server's). Flags that already exist in the target are conflicts,
+// handled by ?strategy=:
+//   - fail (default): reject the import with 409 if any flag exists
+//   - skip: keep existing flags and import the rest
+//   - overwrite: replace existing flags
+//
+// Flags identical to the stored ones are not conflicts and are never
+// rewritten, so importing the same file twice succeeds. The import is one
+// transaction (see transactions.go): every flag is checked like a single
+// write, one failure rejects the whole import, and audit events and webhooks
+// fire per flag. ?dry_run=true describes the import without writing it.
+// Archived state is not exported; flags in the target that are absent from
+// the document are left alone (use POST /v1/apply to sync deletes).
+
+const (
+	exportFormatJSON = "json"
+	exportFormatYAML = "yaml"
+
+	importStrategyFail      = "fail"
+	importStrategySkip      = "skip"
+	importStrategyOverwrite = "overwrite"
+
+	maxImportFlags    = 1000
+	maxImportBodySize = 10 << 20
+)
+
+var importStrategies = []string{importStrategyFail, importStrategySkip, importStrategyOverwrite}
+
+// yamlBodyRoutes also accept YAML request bodies (see requireJSONContentType).
+var yamlBodyRoutes = map[string]bool{"/v1/flags/import": true}
+
+type flagExport struct {
+	Env        string          `json:"env"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Flags      []upsertRequest `json:"flags"`
+}
+
+type importResponse struct {
+	Env       string          `json:"env"`
+	Strategy  string          `json:"strategy"`
+	DryRun    bool            `json:"dry_run"`
+	Applied   bool            `json:"applied"`
+	Changes   []applyChange   `json:"changes"`            // same shape as POST /v1/apply
+	Skipped   []string        `json:"skipped"`            // existing flags kept by strategy skip
+	Unchanged []string        `json:"unchanged"`          // identical to the stored flag
+	Snapshot  *snapshotDiff   `json:"snapshot,omitempty"` // dry runs only
+	Webhooks  []dryRunWebhook `json:"webhooks,omitempty"` // dry runs only
+	ETag      string          `json:"etag,omitempty"`
+}
+
+func (s *Server) handleExportFlags(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	switch format {
+	case "":
+		format = exportFormatJSON
+	case exportFormatJSON, exportFormatYAML:
+	default:
+		ValidationError(w, r, "Invalid query parameters", map[string]string{"format": "must be json or yaml"})
+		return
+	}
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = s.env
+	}
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+
+	flags, err := s.store.GetAllFlags(r.Context(), env)
+	if err != nil {
+		InternalError(w, r, "Failed to load flags")
+		return
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	doc := flagExport{Env: env, ExportedAt: time.Now().UTC(), Flags: make([]upsertRequest, len(flags))}
+	for i := range flags {
+		doc.Flags[i] = upsertRequestFromFlag(&flags[i])
+		doc.Flags[i].Env = nil // the document's env applies
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="flags-%s.%s"`, env, format))
+	if format == exportFormatJSON {
+		writeJSON(w, http.StatusOK, doc)
+		return
+	}
+	out, err := marshalYAMLViaJSON(doc)
+	if err != nil {
+		InternalError(w, r, "Failed to encode flags")
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+func (s *Server) handleImportFlags(w http.ResponseWriter, r *http.Request) {
+	var doc flagExport
+	if !decodeImportBody(w, r, &doc) {
+		return
+	}
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	strategy := strings.TrimSpace(r.URL.Query().Get("strategy"))
+	if strategy == "" {
+		strategy = importStrategyFail
+	}
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = strings.TrimSpace(doc.Env)
+	}
+	if env == "" {
+		env = s.env
+	}
+	if fields := validateImport(doc, strategy); len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more flags", fields)
+		return
+	}
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+	bs, ok := s.store.(store.BatchFlagStore)
+	if !ok {
+		InternalError(w, r, "Imports are not supported by this store")
+		return
+	}
+
+	resp, werr := s.runImport(r, bs, env, strategy, doc.Flags)
+	if werr != nil {
+		werr.write(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validateImport checks the shape of an import; the flags themselves are
+// checked by runImport.
+func validateImport(doc flagExport, strategy string) map[string]string {
+	fields := map[string]string{}
+	if !slices.Contains(importStrategies, strategy) {
+		fields["strategy"] = "Strategy must be one of: " + strings.Join(importStrategies, ", ")
+	}
+	switch n := len(doc.Flags); {
+	case n == 0:
+		fields["flags"] = "At least one flag is required"
+	case n > maxImportFlags:
+		fields["flags"] = fmt.Sprintf("At most %d flags can be imported at once", maxImportFlags)
+	}
+	seen := map[string]bool{}
+	for i, f := range doc.Flags {
+		prefix := fmt.Sprintf("flags[%d]", i)
+		key := strings.TrimSpace(f.Key)
+		switch {
+		case key == "":
+			fields[prefix+".key"] = "key is required"
+		case seen[key]:
+			fields[prefix+".key"] = "duplicate flag key " + key
+		case f.Env != nil && strings.TrimSpace(*f.Env) != "" && strings.TrimSpace(*f.Env) != strings.TrimSpace(doc.Env):
+			fields[prefix+".env"] = "env must be omitted or match the document env"
+		}
+		seen[key] = true
+	}
+	return fields
+}
+
+// runImport applies flags to env with strategy, or describes the import if
+// r is a dry run.
+func (s *Server) runImport(r *http.Request, bs store.BatchFlagStore, env, strategy string, flags []upsertRequest) (*importResponse, *flagWriteError) {
+	resp := &importResponse{Env: env, Strategy: strategy, DryRun: isDryRun(r), Changes: []applyChange{}, Skipped: []string{}, Unchanged: []string{}}
+	var conflicts []string
+	var changes []txChange
+	creates := 0
+	for i, f := range flags {
+		f.Key = strings.TrimSpace(f.Key)
+		f.Env = &env
+		existing, exists := s.loadEnvFlag(r, f.Key, env)
+		if exists {
+			if strategy == importStrategySkip {
+				resp.Skipped = append(resp.Skipped, f.Key)
+				continue
+			}
+			desired := flagFromUpsertRequest(f, env)
+			if f.Protected == nil {
+				desired.Protected = existing.Protected // kept by the write, see upsertRequest
+			}
+			if f.Project == nil {
+				desired.Project = existing.Project
+			}
+			keepOwnership(desired, f, existing)
+			if len(audit.ComputeChanges(applyFlagState(existing), applyFlagState(desired))) == 0 {
+				resp.Unchanged = append(resp.Unchanged, f.Key)
+				continue
+			}
+			if strategy == importStrategyFail {
+				conflicts = append(conflicts, f.Key)
+				continue
+			}
+		}
+
+		c, werr := s.prepareTransactionOperation(r, env, transactionOperation{Action: txActionUpsert, Flag: &f})
+		if werr != nil {
+			return nil, importFlagWriteError(werr, i, f.Key)
+		}
+		if c.before == nil {
+			creates++
+		}
+		changes = append(changes, c)
+	}
+	if len(conflicts) > 0 {
+		return nil, &flagWriteError{
+			status:  http.StatusConflict,
+			code:    ErrCodeConflict,
+			message: fmt.Sprintf("%d flag(s) already exist in environment %q; use strategy skip or overwrite", len(conflicts), env),
+			fields:  map[string]string{"flags": strings.Join(conflicts, ", ")},
+		}
+	}
+	if werr := s.checkBatchFlagLimit(r, env, "flags", "Import", creates, 0); werr != nil {
+		return nil, werr
+	}
+	if len(changes) == 0 {
+		resp.Applied = !resp.DryRun
+		resp.ETag = s.snapshotForEnv(env).ETag
+		return resp, nil
+	}
+
+	result, werr := s.commitTransaction(r, bs, env, changes)
+	if werr != nil {
+		return nil, werr
+	}
+	switch result := result.(type) {
+	case *dryRunResponse:
+		resp.Changes, resp.Snapshot, resp.Webhooks = result.Changes, result.Snapshot, result.Webhooks
+	case *transactionResponse:
+		resp.Applied, resp.Changes, resp.ETag = true, result.Changes, result.ETag
+	}
+	return resp, nil
+}
+
+// importFlagWriteError names the failing flag in werr.
+func importFlagWriteError(werr *flagWriteError, i int, key string) *flagWriteError {
+	out := *werr
+	out.message = fmt.Sprintf("Flag %d (%s): %s", i, key, werr.message)
+	if len(werr.fields) > 0 {
+		out.fields = make(map[string]string, len(werr.fields))
+		for field, message := range werr.fields {
+			out.fields[fmt.Sprintf("flags[%d].%s", i, field)] = message
+		}
+	}
+	return &out
+}
+
+// decodeImportBody decodes a JSON or YAML import document into dst, writing
+// an error response and returning false on failure. YAML is converted to
+// JSON first, so both are held to the same strict decoding.
+func decodeImportBody(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if !isYAMLContentType(r.Header.Get("Content-Type")) {
+		return decodeJSON(w, r, dst, maxImportBodySize)
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBodySize))
+	if err != nil {
+		writeJSONDecodeError(w, r, err)
+		return false
+	}
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		BadRequestError(w, r, ErrCodeInvalidJSON, "Request body is not valid YAML: "+err.Error())
+		return false
+	}
+	converted, err := json.Marshal(doc)
+	if err != nil {
+		BadRequestError(w, r, ErrCodeInvalidJSON, "Request body cannot be represented as JSON: "+err.Error())
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(converted))
+	return decodeJSON(w, r, dst, maxImportBodySize)
+}
+
+// marshalYAMLViaJSON encodes v as YAML with the keys of its JSON encoding.
+func marshalYAMLViaJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func isYAMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+	return false
+}
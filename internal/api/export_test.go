@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+	for _, params := range []store.UpsertParams{
+		{Key: "checkout", Enabled: true, Rollout: 50, Config: map[string]any{"color": "green"}, Tags: []string{"team-a"}, Env: "prod"},
+		{Key: "banner", Description: "Homepage banner", Rollout: 100, Env: "prod"},
+	} {
+		if err := st.UpsertFlag(ctx, params); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/export?env=prod&format=yaml", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/yaml" {
+		t.Fatalf("expected a YAML export, got %d %q: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+	exported := rr.Body.String()
+	if !strings.Contains(exported, "key: banner") || strings.Index(exported, "key: banner") > strings.Index(exported, "key: checkout") {
+		t.Errorf("expected flags sorted by key:\n%s", exported)
+	}
+
+	// Copy prod into staging.
+	rr = doImportRequest(t, handler, "/v1/flags/import?env=staging", "application/yaml", exported)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp importResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Applied || resp.Env != "staging" || len(resp.Changes) != 2 || resp.Changes[0].Action != applyActionCreate {
+		t.Errorf("unexpected import response: %+v", resp)
+	}
+	flag, err := st.GetFlagByKey(ctx, "checkout", "staging")
+	if err != nil || flag.Rollout != 50 || flag.Config["color"] != "green" || len(flag.Tags) != 1 {
+		t.Fatalf("expected checkout copied to staging, got %+v (%v)", flag, err)
+	}
+
+	// Importing the same file again changes nothing.
+	rr = doImportRequest(t, handler, "/v1/flags/import?env=staging", "application/yaml", exported)
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("re-import: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(resp.Changes) != 0 || len(resp.Unchanged) != 2 {
+		t.Errorf("expected every flag unchanged, got %+v", resp)
+	}
+
+	// JSON exports import too.
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/export?env=prod", "")
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment") {
+		t.Fatalf("expected a JSON export download, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/import?env=dev", rr.Body.String()); rr.Code != http.StatusOK {
+		t.Errorf("JSON import: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestImport_Strategies(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+	if err := st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Rollout: 10, Env: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	doc := `{"env":"prod","flags":[{"key":"checkout","rollout":90},{"key":"banner","rollout":100}]}`
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/import", doc)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "checkout") {
+		t.Fatalf("fail: expected 409 naming checkout, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := st.GetFlagByKey(ctx, "banner", "prod"); err == nil {
+		t.Fatal("fail: expected nothing imported on conflict")
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags/import?strategy=overwrite&dry_run=true", doc)
+	var resp importResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("dry run: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !resp.DryRun || resp.Applied || len(resp.Changes) != 2 || resp.Snapshot == nil {
+		t.Errorf("unexpected dry run response: %+v", resp)
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "checkout", "prod"); flag.Rollout != 10 {
+		t.Fatal("dry run: expected nothing written")
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags/import?strategy=skip", doc)
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("skip: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(resp.Skipped) != 1 || resp.Skipped[0] != "checkout" || len(resp.Changes) != 1 {
+		t.Errorf("skip: unexpected response %+v", resp)
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "checkout", "prod"); flag.Rollout != 10 {
+		t.Errorf("skip: expected checkout kept, got rollout %d", flag.Rollout)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/import?strategy=overwrite", doc); rr.Code != http.StatusOK {
+		t.Fatalf("overwrite: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "checkout", "prod"); flag.Rollout != 90 {
+		t.Errorf("overwrite: expected rollout 90, got %d", flag.Rollout)
+	}
+}
+
+func TestImport_Validation(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+	tests := map[string]struct{ path, body string }{
+		"unknown strategy": {"/v1/flags/import?strategy=merge", `{"flags":[{"key":"a"}]}`},
+		"no flags":         {"/v1/flags/import", `{"flags":[]}`},
+		"duplicate key":    {"/v1/flags/import", `{"flags":[{"key":"a"},{"key":"a"}]}`},
+		"invalid flag":     {"/v1/flags/import", `{"flags":[{"key":"a","rollout":500}]}`},
+		"unknown field":    {"/v1/flags/import", `{"flags":[{"key":"a","rolout":5}]}`},
+	}
+	for name, tt := range tests {
+		if rr := doV2Request(t, handler, http.MethodPost, tt.path, tt.body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := doImportRequest(t, handler, "/v1/flags/import", "application/yaml", "flags: [unclosed"); rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid YAML: expected 400, got %d", rr.Code)
+	}
+	if rr := doImportRequest(t, handler, "/v1/flags", "application/yaml", "key: a"); rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("YAML outside import: expected 415, got %d", rr.Code)
+	}
+}
+
+func doImportRequest(t *testing.T, handler http.Handler, path, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
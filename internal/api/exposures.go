@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+)
+
+// maxExposureBatch caps the number of events accepted per request.
+const maxExposureBatch = 1000
+
+// exposureEvent is one SDK evaluation, as batched by pkg/flagship.
+type exposureEvent struct {
+	Flag      string    `json:"flag"`
+	Variant   string    `json:"variant,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	UserHash  string    `json:"user_hash,omitempty"`
+	UserID    string    `json:"user_id,omitempty"` // sent by SDKs with ExposureOptions.IncludeUserIDs, see cohorts.go
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type exposureRequest struct {
+	Exposures []exposureEvent `json:"exposures"`
+}
+
+type exposureResponse struct {
+	Accepted int `json:"accepted"`
+	Ignored  int `json:"ignored"`
+}
+
+// handleIngestExposures handles POST /v1/exposures.
+//
+// Events are aggregated into the flag_exposures_total metric. Like the
+// evaluation endpoints this route is public, so only flags and variants that
+// exist in the current snapshot are counted; anything else is ignored to keep
+// metric cardinality bounded.
+func (s *Server) handleIngestExposures(w http.ResponseWriter, r *http.Request) {
+	var req exposureRequest
+	if !decodeClientJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	if len(req.Exposures) > maxExposureBatch {
+		ValidationError(w, r, "Too many exposures", map[string]string{
+			"exposures": fmt.Sprintf("at most %d events per request", maxExposureBatch),
+		})
+		return
+	}
+
+	snap := s.requestSnapshot(r)
+
+	var resp exposureResponse
+	var seen []string
+	var accepted []exposureEvent
+	for _, e := range req.Exposures {
+		flag, ok := snap.Flags[e.Flag]
+		if !ok || !knownVariant(flag, e.Variant) {
+			resp.Ignored++
+			continue
+		}
+		telemetry.FlagExposures.WithLabelValues(e.Flag, e.Variant, strconv.FormatBool(e.Enabled)).Inc()
+		seen = append(seen, e.Flag)
+		accepted = append(accepted, e)
+		resp.Accepted++
+	}
+	s.evalTracker.touch(s.requestEnvironment(r), seen...)
+	s.cohorts.record(s.requestEnvironment(r), accepted)
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+func knownVariant(flag snapshot.FlagView, variant string) bool {
+	if variant == "" {
+		return true
+	}
+	for _, v := range flag.Variants {
+		if v.Name == variant {
+			return true
+		}
+	}
+	return false
+}
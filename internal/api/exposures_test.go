@@ -0,0 +1,48 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestIngestExposures(t *testing.T) {
+	setupEvaluationSnapshot([]store.Flag{
+		{Key: "ab", Enabled: true, Variants: []store.Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}}},
+	})
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	body := `{"exposures":[
+		{"flag":"ab","variant":"a","enabled":true,"user_hash":"x"},
+		{"flag":"ab","variant":"zzz","enabled":true},
+		{"flag":"unknown","enabled":true}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/exposures", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp exposureResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Accepted != 1 || resp.Ignored != 2 {
+		t.Errorf("expected 1 accepted and 2 ignored, got %+v", resp)
+	}
+}
+
+func TestIngestExposures_BatchLimit(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+	events := strings.Repeat(`{"flag":"x"},`, maxExposureBatch) + `{"flag":"x"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/exposures", bytes.NewBufferString(`{"exposures":[`+events+`]}`))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// Flags are defined per environment, and evaluating a key in an environment
+// that does not serve it is reported explicitly rather than as a disabled
+// flag:
+//
+//   - POST/GET /v1/flags/evaluate with keys: each such key gets a result with
+//     reason FLAG_NOT_FOUND (see evaluation.NotFound)
+//   - POST /v1/evaluate with flagKey: 404 FLAG_NOT_FOUND
+//   - OFREP: 404 FLAG_NOT_FOUND, as the protocol requires
+//
+// A key is not served if it is not defined in the environment or is
+// archived. To see where a key is defined:
+//
+//	GET /v1/flags/{key}/environments   (admin+)
+//
+// lists the key's state in every environment the caller's tenant may access,
+// sorted by environment, or answers 404 if it is defined in none of them.
+
+type flagEnvironment struct {
+	Env       string    `json:"env"`
+	Enabled   bool      `json:"enabled"`
+	Rollout   int32     `json:"rollout"`
+	Archived  bool      `json:"archived"` // archived flags are not served
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type flagEnvironmentsResponse struct {
+	Key          string            `json:"key"`
+	Environments []flagEnvironment `json:"environments"`
+}
+
+func (s *Server) handleListFlagEnvironments(w http.ResponseWriter, r *http.Request) {
+	es, ok := s.store.(store.FlagEnvironmentStore)
+	if !ok {
+		InternalError(w, r, "Listing flag environments is not supported by this store")
+		return
+	}
+	key := chi.URLParam(r, "id")
+	flags, err := es.ListFlagEnvironments(r.Context(), key)
+	if err != nil {
+		InternalError(w, r, "Failed to load flag environments")
+		return
+	}
+	resp := flagEnvironmentsResponse{Key: key, Environments: make([]flagEnvironment, 0, len(flags))}
+	for _, flag := range flags {
+		if !tenantOwnsEnv(r, flag.Env) {
+			continue
+		}
+		resp.Environments = append(resp.Environments, flagEnvironment{
+			Env:       flag.Env,
+			Enabled:   flag.Enabled,
+			Rollout:   flag.Rollout,
+			Archived:  flag.Archived,
+			Version:   flag.Version,
+			UpdatedAt: flag.UpdatedAt,
+		})
+	}
+	if len(resp.Environments) == 0 {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeFlagNotFound writes the 404 FLAG_NOT_FOUND response of an evaluation
+// of key, which env does not serve.
+func writeFlagNotFound(w http.ResponseWriter, r *http.Request, key, env string) {
+	errResp := NewErrorResponse(http.StatusNotFound, ErrCodeFlagNotFound,
+		fmt.Sprintf("Flag '%s' is not served in environment '%s'", key, env))
+	writeErrorResponse(w, r, http.StatusNotFound, errResp)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestFlagEnvironments_ListsDefiningEnvironments(t *testing.T) {
+	st := store.NewMemoryStore()
+	for _, p := range []store.UpsertParams{
+		{Key: "checkout", Enabled: true, Rollout: 100, Env: "staging"},
+		{Key: "checkout", Enabled: false, Rollout: 10, Env: "prod", Archived: true},
+		{Key: "search", Enabled: true, Rollout: 100, Env: "dev"},
+	} {
+		if err := st.UpsertFlag(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/environments", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp flagEnvironmentsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Key != "checkout" || len(resp.Environments) != 2 {
+		t.Fatalf("expected checkout in 2 environments, got %+v", resp)
+	}
+	prod, staging := resp.Environments[0], resp.Environments[1]
+	if prod.Env != "prod" || !prod.Archived || prod.Rollout != 10 {
+		t.Errorf("unexpected prod entry: %+v", prod)
+	}
+	if staging.Env != "staging" || !staging.Enabled || staging.Archived {
+		t.Errorf("unexpected staging entry: %+v", staging)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/missing/environments", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("missing flag: expected 404, got %d", rr.Code)
+	}
+}
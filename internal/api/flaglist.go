@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// GET /v1/flags lists the flags of an environment. For large environments
+// the list can be filtered, sorted and paged; the store does the work where
+// it can (see store.FlagQueryStore):
+//
+//	GET /v1/flags?env=prod&q=checkout&tag=payments&enabled=true&sort=-updated_at&limit=50&offset=100
+//
+//   - q: case-insensitive substring of the key or description
+//   - tag: flags carrying the tag
+//   - enabled: true or false
+//   - state: active or archived (see archive.go)
+//   - project: flags of the project (see projects.go); project-scoped API
+//     keys only list their project
+//   - starred: the caller's starred flags (see stars.go)
+//   - sort: key (default), -key, updated_at or -updated_at
+//   - limit (max 1000) and offset: one page; without limit every match is
+//     returned
+//
+// The response carries total, the number of matches across all pages.
+// include_deleted appends deleted flags and cannot be combined with the
+// filters other than project, sorting or paging.
+
+const maxFlagListLimit = 1000
+
+// Flag lifecycle states accepted by ?state=.
+const (
+	flagStateActive   = "active"
+	flagStateArchived = "archived"
+)
+
+// flagListOptions are the parsed query parameters of GET /v1/flags.
+type flagListOptions struct {
+	query          store.FlagQuery
+	starred        bool
+	includeDeleted bool
+}
+
+// parseFlagListQuery parses the query parameters of GET /v1/flags, returning
+// the invalid ones as validation fields.
+func parseFlagListQuery(r *http.Request, env string) (flagListOptions, map[string]string) {
+	values := r.URL.Query()
+	opts := flagListOptions{query: store.FlagQuery{
+		Env:     env,
+		Query:   strings.TrimSpace(values.Get("q")),
+		Tag:     strings.TrimSpace(values.Get("tag")),
+		Sort:    strings.TrimSpace(values.Get("sort")),
+		Project: strings.TrimSpace(values.Get("project")),
+	}}
+	fields := map[string]string{}
+
+	parseBool := func(name string) bool {
+		raw := values.Get(name)
+		if raw == "" {
+			return false
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			fields[name] = "must be true or false"
+		}
+		return b
+	}
+	opts.includeDeleted = parseBool("include_deleted")
+	opts.starred = parseBool("starred")
+	if values.Get("enabled") != "" {
+		enabled := parseBool("enabled")
+		opts.query.Enabled = &enabled
+	}
+	switch state := values.Get("state"); state {
+	case "":
+	case flagStateActive, flagStateArchived:
+		archived := state == flagStateArchived
+		opts.query.Archived = &archived
+	default:
+		fields["state"] = "must be active or archived"
+	}
+
+	if opts.query.Sort == "" {
+		opts.query.Sort = store.FlagSortKey
+	} else if !slices.Contains(store.FlagSorts, opts.query.Sort) {
+		fields["sort"] = "must be one of: " + strings.Join(store.FlagSorts, ", ")
+	}
+	if raw := values.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxFlagListLimit {
+			fields["limit"] = fmt.Sprintf("must be between 1 and %d", maxFlagListLimit)
+		}
+		opts.query.Limit = n
+	}
+	if raw := values.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			fields["offset"] = "must be 0 or greater"
+		}
+		opts.query.Offset = n
+	}
+
+	if opts.includeDeleted {
+		for _, name := range []string{"q", "tag", "enabled", "state", "sort", "limit", "offset"} {
+			if values.Get(name) != "" {
+				fields["include_deleted"] = "cannot be combined with " + name
+				break
+			}
+		}
+	}
+	return opts, fields
+}
+
+// queryFlags runs q against the store, filtering in memory if the store
+// cannot.
+func (s *Server) queryFlags(ctx context.Context, q store.FlagQuery) ([]store.Flag, int, error) {
+	if qs, ok := s.store.(store.FlagQueryStore); ok {
+		return qs.QueryFlags(ctx, q)
+	}
+	flags, err := s.store.GetAllFlags(ctx, q.Env)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := store.FilterFlags(flags, q)
+	return page, total, nil
+}
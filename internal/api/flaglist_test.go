@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func newFlagListTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	st := store.NewMemoryStore()
+	for _, f := range []store.UpsertParams{
+		{Key: "checkout_v2", Description: "New checkout", Enabled: true, Tags: []string{"payments"}},
+		{Key: "search", Description: "Faster checkout search", Enabled: false},
+		{Key: "banner", Enabled: true, Tags: []string{"marketing"}},
+		{Key: "pay_later", Enabled: false, Tags: []string{"payments"}},
+	} {
+		f.Env, f.Rollout = "prod", 100
+		if err := st.UpsertFlag(context.Background(), f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewServer(st, "prod", "test-key").Router()
+}
+
+func TestListFlags_FilterSortAndPage(t *testing.T) {
+	handler := newFlagListTestHandler(t)
+
+	for _, tc := range []struct {
+		query string
+		want  []string
+		total int
+	}{
+		{"", []string{"banner", "checkout_v2", "pay_later", "search"}, 4},
+		{"?q=CHECKOUT", []string{"checkout_v2", "search"}, 2},
+		{"?tag=payments", []string{"checkout_v2", "pay_later"}, 2},
+		{"?enabled=false", []string{"pay_later", "search"}, 2},
+		{"?tag=payments&enabled=true", []string{"checkout_v2"}, 1},
+		{"?sort=-key", []string{"search", "pay_later", "checkout_v2", "banner"}, 4},
+		{"?limit=2", []string{"banner", "checkout_v2"}, 4},
+		{"?limit=2&offset=3", []string{"search"}, 4},
+		{"?offset=10", []string{}, 4},
+	} {
+		rr := doV2Request(t, handler, http.MethodGet, "/v1/flags"+tc.query, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tc.query, rr.Code, rr.Body.String())
+		}
+		var resp listFlagsResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		keys := make([]string, len(resp.Flags))
+		for i, f := range resp.Flags {
+			keys[i] = f.Key
+		}
+		if !slices.Equal(keys, tc.want) || resp.Total != tc.total {
+			t.Errorf("%s: expected %v of %d, got %v of %d", tc.query, tc.want, tc.total, keys, resp.Total)
+		}
+	}
+}
+
+func TestListFlags_InvalidParameters(t *testing.T) {
+	handler := newFlagListTestHandler(t)
+
+	for query, field := range map[string]string{
+		"?limit=0":                      "limit",
+		"?limit=1001":                   "limit",
+		"?offset=-1":                    "offset",
+		"?sort=name":                    "sort",
+		"?enabled=yes":                  "enabled",
+		"?state=deleted":                "state",
+		"?include_deleted=true&limit=5": "include_deleted",
+	} {
+		rr := doV2Request(t, handler, http.MethodGet, "/v1/flags"+query, "")
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+			continue
+		}
+		if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields[field] == "" {
+			t.Errorf("%s: expected a %s field error, got %+v", query, field, resp.Fields)
+		}
+	}
+}
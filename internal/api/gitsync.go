@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/TimurManjosov/goflagship/internal/gitsync"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+)
+
+// Git sync endpoints. All of them return 404 unless a syncer was attached
+// with SetGitSync (see GITSYNC_* configuration).
+//
+//	GET  /v1/admin/gitsync/status       current status and last result (admin+)
+//	POST /v1/admin/gitsync/sync         sync now; ?force=true overwrites conflicts (admin+)
+//	POST /v1/gitsync/webhook            push webhook; signed with X-Hub-Signature-256
+
+// gitSyncSignatureHeader carries the HMAC of the webhook body. The format
+// ("sha256=<hex>") matches GitHub and Gitea push webhooks.
+const gitSyncSignatureHeader = "X-Hub-Signature-256"
+
+// SetGitSync attaches a git syncer. webhookSecret enables the push webhook
+// endpoint; when empty the endpoint responds 404.
+func (s *Server) SetGitSync(gs *gitsync.Syncer, webhookSecret string) {
+	s.gitSync = gs
+	s.gitSyncWebhookSecret = webhookSecret
+}
+
+func (s *Server) handleGitSyncStatus(w http.ResponseWriter, r *http.Request) {
+	if s.gitSync == nil {
+		NotFoundError(w, r, "Git sync is not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.gitSync.Status())
+}
+
+func (s *Server) handleGitSyncRun(w http.ResponseWriter, r *http.Request) {
+	if s.gitSync == nil {
+		NotFoundError(w, r, "Git sync is not configured")
+		return
+	}
+	force := r.URL.Query().Get("force") == "true"
+	result, err := s.gitSync.Sync(r.Context(), force)
+	if errors.Is(err, gitsync.ErrSyncInProgress) {
+		ConflictError(w, r, "Git sync already in progress")
+		return
+	}
+	if err != nil {
+		InternalError(w, r, "Git sync failed: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleGitSyncWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.gitSync == nil || s.gitSyncWebhookSecret == "" {
+		NotFoundError(w, r, "Git sync webhook is not configured")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBodySize)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RequestTooLargeError(w, r, "Request body exceeds 1MB limit")
+		return
+	}
+	if !webhook.VerifySignature(body, r.Header.Get(gitSyncSignatureHeader), s.gitSyncWebhookSecret) {
+		UnauthorizedError(w, r, "Invalid webhook signature")
+		return
+	}
+
+	// Sync asynchronously; git providers expect a fast acknowledgement.
+	s.gitSync.Trigger()
+	writeJSON(w, http.StatusAccepted, map[string]bool{"accepted": true})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/gitsync"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+)
+
+func TestGitSync_NotConfigured(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/gitsync/status", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestGitSyncWebhook_Signature(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	srv.SetGitSync(gitsync.New(gitsync.Config{RepoURL: "file:///nonexistent", Env: "prod"}, st, nil), "s3cret")
+	handler := srv.Router()
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	tests := []struct {
+		name      string
+		signature string
+		want      int
+	}{
+		{"missing signature", "", http.StatusUnauthorized},
+		{"wrong signature", webhook.ComputeHMAC(body, "other"), http.StatusUnauthorized},
+		{"valid signature", webhook.ComputeHMAC(body, "s3cret"), http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/gitsync/webhook", bytes.NewReader(body))
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/graphql"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// POST /graphql exposes the admin data model in a single endpoint so the admin
+// UI can load a dashboard (flags per environment, recent audit logs, webhooks
+// and their deliveries) in one round trip.
+//
+// Query fields:
+//
+//	flags(env: String): [Flag]
+//	flag(key: String!, env: String): Flag
+//	environments: [Environment]            # { name, flags }
+//	environment(name: String!): Environment
+//	auditLogs(limit: Int, resourceType: String, resourceId: String): [AuditLog]
+//	webhooks: [Webhook]                    # { ..., deliveries(limit: Int) }
+//
+// Mutation fields:
+//
+//	upsertFlag(input: FlagInput!): Flag     # same body as POST /v1/flags
+//	deleteFlag(key: String!, env: String!): Boolean
+//
+// Field names match the JSON names used by the REST API. Audit logs and
+// webhooks require the postgres store. Mutations share saveFlag/removeFlag
+// with the REST handlers.
+
+const (
+	// graphqlDefaultListLimit is the page size for list fields without a limit argument.
+	graphqlDefaultListLimit = 20
+	// graphqlMaxListLimit caps limit arguments on list fields.
+	graphqlMaxListLimit = 100
+)
+
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"` // sent by some clients; unused
+}
+
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		BadRequestErrorWithFields(w, r, ErrCodeMissingField, "Missing required field", map[string]string{
+			"query": "query is required",
+		})
+		return
+	}
+
+	resp := graphql.Execute(r.Context(), s.graphqlSchema(r), req.Query, req.Variables)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// graphqlSchema builds the resolver tree for a single request. The request is
+// captured so mutations can attribute audit events and webhooks to the caller.
+func (s *Server) graphqlSchema(r *http.Request) *graphql.Schema {
+	return &graphql.Schema{
+		Query: map[string]graphql.Resolver{
+			"flags": func(ctx context.Context, args map[string]any) (any, error) {
+				env, err := graphql.StringArg(args, "env", s.env)
+				if err != nil {
+					return nil, err
+				}
+				return s.graphqlFlags(ctx, env)
+			},
+			"flag": func(ctx context.Context, args map[string]any) (any, error) {
+				key, err := graphql.StringArg(args, "key", "")
+				if err != nil {
+					return nil, err
+				}
+				env, err := graphql.StringArg(args, "env", s.env)
+				if err != nil {
+					return nil, err
+				}
+				flag, err := s.store.GetFlagByKey(ctx, key, env)
+				if err != nil {
+					return nil, nil
+				}
+				return toGraphQLObject(toFlagResponse(flag))
+			},
+			"environments": func(ctx context.Context, args map[string]any) (any, error) {
+				return []any{s.graphqlEnvironment(s.env)}, nil
+			},
+			"environment": func(ctx context.Context, args map[string]any) (any, error) {
+				name, err := graphql.StringArg(args, "name", "")
+				if err != nil {
+					return nil, err
+				}
+				if name == "" {
+					return nil, fmt.Errorf("argument %q is required", "name")
+				}
+				return s.graphqlEnvironment(name), nil
+			},
+			"auditLogs": s.graphqlAuditLogs,
+			"webhooks":  s.graphqlWebhooks,
+		},
+		Mutation: map[string]graphql.Resolver{
+			"upsertFlag": func(ctx context.Context, args map[string]any) (any, error) {
+				var req upsertRequest
+				if err := graphql.DecodeArg(args, "input", &req); err != nil {
+					return nil, err
+				}
+				if field, message, ok := validateTargetingRules(req.TargetingRules); !ok {
+					return nil, fmt.Errorf("invalid %s: %s", field, message)
+				}
+				flag, werr := s.saveFlag(r, req)
+				if werr != nil {
+					return nil, graphqlWriteError(werr)
+				}
+				if flag == nil {
+					return nil, nil
+				}
+				return toGraphQLObject(toFlagResponse(flag))
+			},
+			"deleteFlag": func(ctx context.Context, args map[string]any) (any, error) {
+				key, err := graphql.StringArg(args, "key", "")
+				if err != nil {
+					return nil, err
+				}
+				env, err := graphql.StringArg(args, "env", "")
+				if err != nil {
+					return nil, err
+				}
+				if key == "" || env == "" {
+					return nil, errors.New("arguments \"key\" and \"env\" are required")
+				}
+				if werr := s.removeFlag(r, key, env); werr != nil {
+					return nil, graphqlWriteError(werr)
+				}
+				return true, nil
+			},
+		},
+	}
+}
+
+// graphqlWriteError flattens a flagWriteError, including field details, into a single error.
+func graphqlWriteError(werr *flagWriteError) error {
+	if len(werr.fields) == 0 {
+		return werr
+	}
+	parts := make([]string, 0, len(werr.fields))
+	for field, msg := range werr.fields {
+		parts = append(parts, field+": "+msg)
+	}
+	return fmt.Errorf("%s (%s)", werr.message, strings.Join(parts, "; "))
+}
+
+func (s *Server) graphqlFlags(ctx context.Context, env string) ([]any, error) {
+	flags, err := s.store.GetAllFlags(ctx, env)
+	if err != nil {
+		return nil, errors.New("failed to load flags")
+	}
+	out := make([]any, 0, len(flags))
+	for i := range flags {
+		obj, err := toGraphQLObject(toFlagResponse(&flags[i]))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+func (s *Server) graphqlEnvironment(name string) map[string]any {
+	return map[string]any{
+		"name": name,
+		"flags": graphql.Resolver(func(ctx context.Context, _ map[string]any) (any, error) {
+			return s.graphqlFlags(ctx, name)
+		}),
+	}
+}
+
+func (s *Server) graphqlAuditLogs(ctx context.Context, args map[string]any) (any, error) {
+	pgStore, ok := s.store.(PostgresStoreInterface)
+	if !ok {
+		return nil, errors.New("audit logs require the postgres store")
+	}
+	limit, err := graphqlLimitArg(args)
+	if err != nil {
+		return nil, err
+	}
+	params := dbgen.ListAuditLogsParams{Limit: int32(limit)}
+	if rt, _ := graphql.StringArg(args, "resourceType", ""); rt != "" {
+		params.ResourceType = pgtype.Text{String: rt, Valid: true}
+	}
+	if rid, _ := graphql.StringArg(args, "resourceId", ""); rid != "" {
+		params.ResourceID = pgtype.Text{String: rid, Valid: true}
+	}
+
+	logs, err := pgStore.ListAuditLogs(ctx, params)
+	if err != nil {
+		return nil, errors.New("failed to list audit logs")
+	}
+	out := make([]any, 0, len(logs))
+	for _, log := range logs {
+		obj, err := toGraphQLObject(auditLogToInfo(log))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+func (s *Server) graphqlWebhooks(ctx context.Context, _ map[string]any) (any, error) {
+	pgStore, ok := s.store.(PostgresStoreInterface)
+	if !ok {
+		return nil, errors.New("webhooks require the postgres store")
+	}
+	queries := getQueriesFromStore(pgStore)
+	if queries == nil {
+		return nil, errors.New("database queries not available")
+	}
+
+	webhooks, err := queries.ListWebhooks(ctx)
+	if err != nil {
+		return nil, errors.New("failed to list webhooks")
+	}
+	out := make([]any, 0, len(webhooks))
+	for _, wh := range webhooks {
+		obj, err := toGraphQLObject(webhookToResponse(wh))
+		if err != nil {
+			return nil, err
+		}
+		webhookID := wh.ID
+		obj["deliveries"] = graphql.Resolver(func(ctx context.Context, args map[string]any) (any, error) {
+			limit, err := graphqlLimitArg(args)
+			if err != nil {
+				return nil, err
+			}
+			deliveries, err := queries.ListWebhookDeliveries(ctx, dbgen.ListWebhookDeliveriesParams{
+				WebhookID: webhookID,
+				Limit:     int32(limit),
+			})
+			if err != nil {
+				return nil, errors.New("failed to list deliveries")
+			}
+			items := make([]any, 0, len(deliveries))
+			for _, d := range deliveries {
+				item, err := toGraphQLObject(deliveryToResponse(d))
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			return items, nil
+		})
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+// graphqlLimitArg reads the optional limit argument for list fields.
+func graphqlLimitArg(args map[string]any) (int, error) {
+	limit, err := graphql.IntArg(args, "limit", graphqlDefaultListLimit)
+	if err != nil {
+		return 0, err
+	}
+	if limit < 1 {
+		return 0, errors.New("argument \"limit\" must be positive")
+	}
+	if limit > graphqlMaxListLimit {
+		limit = graphqlMaxListLimit
+	}
+	return limit, nil
+}
+
+// toGraphQLObject converts an API response struct into a resolver object using
+// its JSON field names, so GraphQL and REST expose identical shapes.
+func toGraphQLObject(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
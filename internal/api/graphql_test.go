@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func doGraphQL(t *testing.T, handler http.Handler, query string, variables map[string]any) map[string]any {
+	t.Helper()
+	body, _ := json.Marshal(graphqlRequest{Query: query, Variables: variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]any
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp
+}
+
+func TestGraphQL_RequiresAuth(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(`{"query":"{ flags { key } }"}`))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestGraphQL_QueryFlagsAndEnvironment(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "a", Enabled: true, Rollout: 100, Env: "prod"})
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "b", Env: "staging"})
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	resp := doGraphQL(t, handler, `{
+		flags { key enabled }
+		staging: environment(name: "staging") { name flags { key } }
+	}`, nil)
+	if resp["errors"] != nil {
+		t.Fatalf("unexpected errors: %v", resp["errors"])
+	}
+	data := resp["data"].(map[string]any)
+	flags := data["flags"].([]any)
+	if len(flags) != 1 || flags[0].(map[string]any)["key"] != "a" {
+		t.Errorf("unexpected flags: %v", flags)
+	}
+	staging := data["staging"].(map[string]any)
+	if staging["name"] != "staging" || len(staging["flags"].([]any)) != 1 {
+		t.Errorf("unexpected environment: %v", staging)
+	}
+}
+
+func TestGraphQL_UpsertAndDeleteMutations(t *testing.T) {
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	resp := doGraphQL(t, handler, `mutation($in: FlagInput!) { upsertFlag(input: $in) { key rollout } }`,
+		map[string]any{"in": map[string]any{"key": "gql_flag", "enabled": true, "rollout": 40}})
+	if resp["errors"] != nil {
+		t.Fatalf("unexpected errors: %v", resp["errors"])
+	}
+	if _, err := st.GetFlagByKey(context.Background(), "gql_flag", "prod"); err != nil {
+		t.Fatalf("flag should be stored: %v", err)
+	}
+
+	resp = doGraphQL(t, handler, `mutation { upsertFlag(input: {key: "bad key!"}) { key } }`, nil)
+	if resp["errors"] == nil {
+		t.Error("expected validation error for invalid key")
+	}
+
+	resp = doGraphQL(t, handler, `mutation { deleteFlag(key: "gql_flag", env: "prod") }`, nil)
+	if resp["errors"] != nil {
+		t.Fatalf("unexpected errors: %v", resp["errors"])
+	}
+	if _, err := st.GetFlagByKey(context.Background(), "gql_flag", "prod"); err == nil {
+		t.Error("flag should be deleted")
+	}
+}
+
+func TestGraphQL_AuditLogsRequirePostgres(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	resp := doGraphQL(t, handler, `{ auditLogs { id } }`, nil)
+	if resp["errors"] == nil {
+		t.Error("expected error for audit logs on memory store")
+	}
+}
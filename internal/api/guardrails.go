@@ -0,0 +1,579 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+)
+
+// A flag being ramped up can have a guardrail: an error budget checked
+// against request and error counts reported by the application (or a
+// monitoring webhook) while the flag is exposed:
+//
+//	GET    /v1/flags/{key}/guardrail?env=           guardrail and current window (admin+)
+//	PUT    /v1/flags/{key}/guardrail?env=           create or replace the guardrail (admin+)
+//	DELETE /v1/flags/{key}/guardrail?env=           remove the guardrail (admin+)
+//	POST   /v1/flags/{key}/guardrail/metrics?env=   report {"requests": n, "errors": n} (admin+)
+//	POST   /v1/flags/{key}/guardrail/reset?env=     clear the tripped state (admin+)
+//
+// A guardrail with request_metric and error_metric is also fed by the metric
+// events of users the flag currently enables, one request or error per
+// event, see metricevents.go.
+//
+// When the error rate of the reports in the last window_seconds exceeds
+// max_error_rate over at least min_requests requests while the flag is
+// enabled with a rollout above 0, the guardrail trips. The "rollback" action
+// then sets the rollout to rollback_rollout; both actions pause the ramp:
+// until the guardrail is reset or replaced, writes that enable the flag or
+// raise its rollout fail with 409 GUARDRAIL_TRIPPED. Trips are logged,
+// audited and sent as guardrail.tripped webhook events. Reports are kept in
+// memory per server instance; the tripped state is stored.
+
+const (
+	guardrailActionPause    = "pause"
+	guardrailActionRollback = "rollback"
+
+	defaultGuardrailWindow = 5 * time.Minute
+	maxGuardrailWindow     = 24 * time.Hour
+
+	// maxGuardrailReports bounds the reports kept per flag; older reports
+	// are dropped first.
+	maxGuardrailReports = 1000
+
+	// guardrailRollbackAttempts bounds how often a rollback is retried
+	// after a concurrent write to the flag.
+	guardrailRollbackAttempts = 3
+)
+
+var guardrailActions = []string{guardrailActionPause, guardrailActionRollback}
+
+type guardrailRequest struct {
+	MaxErrorRate    float64 `json:"max_error_rate"` // 0-1
+	MinRequests     int64   `json:"min_requests"`
+	WindowSeconds   int32   `json:"window_seconds,omitempty"` // defaults to 300
+	Action          string  `json:"action"`
+	RollbackRollout int32   `json:"rollback_rollout,omitempty"`
+	RequestMetric   string  `json:"request_metric,omitempty"` // set with error_metric or not at all
+	ErrorMetric     string  `json:"error_metric,omitempty"`
+}
+
+type guardrailMetricsRequest struct {
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+}
+
+type guardrailWindow struct {
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"` // 0-1, 0 without requests
+}
+
+type guardrailResponse struct {
+	Guardrail store.Guardrail `json:"guardrail"`
+	Window    guardrailWindow `json:"window"`
+}
+
+type guardrailReport struct {
+	at       time.Time
+	requests int64
+	errors   int64
+}
+
+// guardrailMetrics keeps the reports received per environment and key, and
+// the guardrails of each environment fed by metric events.
+type guardrailMetrics struct {
+	mu         sync.Mutex
+	reports    map[string][]guardrailReport // "env/key" -> reports, oldest first
+	guardrails map[string][]store.Guardrail // env -> guardrails, loaded on demand
+	now        func() time.Time
+}
+
+func newGuardrailMetrics() *guardrailMetrics {
+	return &guardrailMetrics{
+		reports:    make(map[string][]guardrailReport),
+		guardrails: make(map[string][]store.Guardrail),
+		now:        time.Now,
+	}
+}
+
+// add records a report for key in env and returns the totals of the last
+// window, including it.
+func (m *guardrailMetrics) add(env, key string, window time.Duration, requests, errs int64) guardrailWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := env + "/" + key
+	reports := append(m.reports[id], guardrailReport{at: m.now(), requests: requests, errors: errs})
+	if len(reports) > maxGuardrailReports {
+		reports = reports[len(reports)-maxGuardrailReports:]
+	}
+	m.reports[id] = reports
+	return m.totalsLocked(id, window)
+}
+
+// window returns the totals of the reports for key in env in the last window.
+func (m *guardrailMetrics) window(env, key string, window time.Duration) guardrailWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.totalsLocked(env+"/"+key, window)
+}
+
+// totalsLocked drops the reports of id older than window and sums the rest.
+// m.mu must be held.
+func (m *guardrailMetrics) totalsLocked(id string, window time.Duration) guardrailWindow {
+	reports := m.reports[id]
+	cutoff := m.now().Add(-window)
+	i := 0
+	for i < len(reports) && reports[i].at.Before(cutoff) {
+		i++
+	}
+	reports = reports[i:]
+	if len(reports) == 0 {
+		delete(m.reports, id)
+	} else {
+		m.reports[id] = reports
+	}
+
+	var totals guardrailWindow
+	for _, report := range reports {
+		totals.Requests += report.requests
+		totals.Errors += report.errors
+	}
+	if totals.Requests > 0 {
+		totals.ErrorRate = float64(totals.Errors) / float64(totals.Requests)
+	}
+	return totals
+}
+
+// reset drops the reports for key in env.
+func (m *guardrailMetrics) reset(env, key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.reports, env+"/"+key)
+}
+
+// invalidate drops the cached guardrails of env, see envGuardrails.
+func (m *guardrailMetrics) invalidate(env string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.guardrails, env)
+}
+
+// envGuardrails returns the guardrails of env, cached until a guardrail of
+// env changes or its snapshot is rebuilt.
+func (s *Server) envGuardrails(ctx context.Context, gs store.GuardrailStore, env string) ([]store.Guardrail, error) {
+	m := s.guardrailMetrics
+	m.mu.Lock()
+	guardrails, ok := m.guardrails[env]
+	m.mu.Unlock()
+	if ok {
+		return guardrails, nil
+	}
+	guardrails, err := gs.ListGuardrails(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.guardrails[env] = guardrails
+	m.mu.Unlock()
+	return guardrails, nil
+}
+
+func guardrailWindowDuration(g *store.Guardrail) time.Duration {
+	return time.Duration(g.WindowSeconds) * time.Second
+}
+
+// requireGuardrailStore returns the store as a GuardrailStore, writing an
+// error response and returning nil if it cannot keep guardrails.
+func (s *Server) requireGuardrailStore(w http.ResponseWriter, r *http.Request) store.GuardrailStore {
+	if gs, ok := s.store.(store.GuardrailStore); ok {
+		return gs
+	}
+	InternalError(w, r, "Store does not support guardrails")
+	return nil
+}
+
+// guardrailTarget resolves the store, environment and flag of a guardrail
+// request, writing an error response and returning ok=false on failure.
+func (s *Server) guardrailTarget(w http.ResponseWriter, r *http.Request) (gs store.GuardrailStore, env string, flag *store.Flag, ok bool) {
+	if gs = s.requireGuardrailStore(w, r); gs == nil {
+		return nil, "", nil, false
+	}
+	if env, flag, ok = s.routeFlag(w, r); !ok {
+		return nil, "", nil, false
+	}
+	return gs, env, flag, true
+}
+
+// loadGuardrail returns the guardrail of flag, writing an error response and
+// returning nil if there is none or it cannot be loaded.
+func loadGuardrail(w http.ResponseWriter, r *http.Request, gs store.GuardrailStore, flag *store.Flag, env string) *store.Guardrail {
+	g, err := gs.GetGuardrail(r.Context(), flag.Key, env)
+	if err != nil {
+		if errors.Is(err, store.ErrGuardrailNotFound) {
+			NotFoundError(w, r, "Flag has no guardrail")
+			return nil
+		}
+		InternalError(w, r, "Failed to load guardrail")
+		return nil
+	}
+	return g
+}
+
+func (s *Server) handleGetGuardrail(w http.ResponseWriter, r *http.Request) {
+	gs, env, flag, ok := s.guardrailTarget(w, r)
+	if !ok {
+		return
+	}
+	g := loadGuardrail(w, r, gs, flag, env)
+	if g == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, guardrailResponse{Guardrail: *g, Window: s.guardrailMetrics.window(env, flag.Key, guardrailWindowDuration(g))})
+}
+
+func (s *Server) handleSetGuardrail(w http.ResponseWriter, r *http.Request) {
+	var req guardrailRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	if req.WindowSeconds == 0 {
+		req.WindowSeconds = int32(defaultGuardrailWindow / time.Second)
+	}
+	if werr := validateGuardrailRequest(req); werr != nil {
+		werr.write(w, r)
+		return
+	}
+	gs, env, flag, ok := s.guardrailTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var beforeState map[string]any
+	if before, err := gs.GetGuardrail(r.Context(), flag.Key, env); err == nil {
+		beforeState = guardrailToMap(before)
+	}
+	g, err := gs.SetGuardrail(r.Context(), store.Guardrail{
+		Key:             flag.Key,
+		Env:             env,
+		MaxErrorRate:    req.MaxErrorRate,
+		MinRequests:     req.MinRequests,
+		WindowSeconds:   req.WindowSeconds,
+		Action:          req.Action,
+		RollbackRollout: req.RollbackRollout,
+		RequestMetric:   req.RequestMetric,
+		ErrorMetric:     req.ErrorMetric,
+	})
+	if err != nil {
+		s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeFlagGuardrail, flag.Key, env, nil, nil, nil, audit.StatusFailure, "Failed to save guardrail")
+		InternalError(w, r, "Failed to save guardrail")
+		return
+	}
+	s.guardrailMetrics.reset(env, flag.Key)
+	s.guardrailMetrics.invalidate(env)
+
+	action := audit.ActionUpdated
+	if beforeState == nil {
+		action = audit.ActionCreated
+	}
+	afterState := guardrailToMap(g)
+	s.auditLog(r, action, audit.ResourceTypeFlagGuardrail, flag.Key, env, beforeState, afterState, audit.ComputeChanges(beforeState, afterState), audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, guardrailResponse{Guardrail: *g})
+}
+
+func validateGuardrailRequest(req guardrailRequest) *flagWriteError {
+	fields := map[string]string{}
+	if req.MaxErrorRate <= 0 || req.MaxErrorRate > 1 {
+		fields["max_error_rate"] = "must be greater than 0 and at most 1"
+	}
+	if req.MinRequests < 0 {
+		fields["min_requests"] = "must not be negative"
+	}
+	if req.WindowSeconds < 1 || time.Duration(req.WindowSeconds)*time.Second > maxGuardrailWindow {
+		fields["window_seconds"] = fmt.Sprintf("must be between 1 and %d", int(maxGuardrailWindow/time.Second))
+	}
+	switch req.Action {
+	case guardrailActionPause, guardrailActionRollback:
+	default:
+		fields["action"] = fmt.Sprintf("must be one of %v", guardrailActions)
+	}
+	if req.RollbackRollout < 0 || req.RollbackRollout > 100 {
+		fields["rollback_rollout"] = "must be between 0 and 100"
+	}
+	if (req.RequestMetric == "") != (req.ErrorMetric == "") {
+		fields["error_metric"] = "request_metric and error_metric must be set together"
+	}
+	for field, name := range map[string]string{"request_metric": req.RequestMetric, "error_metric": req.ErrorMetric} {
+		if name != "" && !metricNamePattern.MatchString(name) {
+			fields[field] = metricNameMessage
+		}
+	}
+	if len(fields) > 0 {
+		return validationWriteError("Validation failed for one or more fields", fields)
+	}
+	return nil
+}
+
+func (s *Server) handleDeleteGuardrail(w http.ResponseWriter, r *http.Request) {
+	gs, env, flag, ok := s.guardrailTarget(w, r)
+	if !ok {
+		return
+	}
+	before, err := gs.GetGuardrail(r.Context(), flag.Key, env)
+	if err == nil {
+		err = gs.DeleteGuardrail(r.Context(), flag.Key, env)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrGuardrailNotFound) {
+			NotFoundError(w, r, "Flag has no guardrail")
+			return
+		}
+		InternalError(w, r, "Failed to remove guardrail")
+		return
+	}
+	s.guardrailMetrics.reset(env, flag.Key)
+	s.guardrailMetrics.invalidate(env)
+
+	beforeState := guardrailToMap(before)
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeFlagGuardrail, flag.Key, env, beforeState, nil, audit.ComputeChanges(beforeState, nil), audit.StatusSuccess, "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleResetGuardrail(w http.ResponseWriter, r *http.Request) {
+	gs, env, flag, ok := s.guardrailTarget(w, r)
+	if !ok {
+		return
+	}
+	before := loadGuardrail(w, r, gs, flag, env)
+	if before == nil {
+		return
+	}
+	g, err := gs.SetGuardrailTripped(r.Context(), flag.Key, env, nil, "")
+	if err != nil {
+		InternalError(w, r, "Failed to reset guardrail")
+		return
+	}
+	s.guardrailMetrics.reset(env, flag.Key)
+	s.guardrailMetrics.invalidate(env)
+
+	beforeState, afterState := guardrailToMap(before), guardrailToMap(g)
+	s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeFlagGuardrail, flag.Key, env, beforeState, afterState, audit.ComputeChanges(beforeState, afterState), audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, guardrailResponse{Guardrail: *g})
+}
+
+func (s *Server) handleGuardrailMetrics(w http.ResponseWriter, r *http.Request) {
+	var req guardrailMetricsRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	if req.Requests < 0 || req.Errors < 0 || req.Errors > req.Requests {
+		ValidationError(w, r, "Invalid metrics", map[string]string{"errors": "requests and errors must not be negative and errors must not exceed requests"})
+		return
+	}
+	gs, env, flag, ok := s.guardrailTarget(w, r)
+	if !ok {
+		return
+	}
+	g := loadGuardrail(w, r, gs, flag, env)
+	if g == nil {
+		return
+	}
+
+	g, window, err := s.observeGuardrail(r.Context(), gs, g, flag.Enabled, flag.Rollout, req.Requests, req.Errors)
+	if err != nil {
+		InternalError(w, r, "Failed to trip guardrail")
+		return
+	}
+	writeJSON(w, http.StatusOK, guardrailResponse{Guardrail: *g, Window: window})
+}
+
+// observeGuardrail adds a report to the window of g and trips it if the
+// flag, currently enabled with rollout, is over its error budget. It returns
+// the guardrail as stored afterwards.
+func (s *Server) observeGuardrail(ctx context.Context, gs store.GuardrailStore, g *store.Guardrail, enabled bool, rollout int32, requests, errs int64) (*store.Guardrail, guardrailWindow, error) {
+	window := s.guardrailMetrics.add(g.Env, g.Key, guardrailWindowDuration(g), requests, errs)
+	if g.TrippedAt != nil || !enabled || rollout <= 0 ||
+		window.Requests == 0 || window.Requests < g.MinRequests || window.ErrorRate <= g.MaxErrorRate {
+		return g, window, nil
+	}
+	flag, err := s.store.GetFlagByKey(ctx, g.Key, g.Env)
+	if err != nil {
+		return nil, window, err
+	}
+	tripped, err := s.tripGuardrail(ctx, gs, g, flag, window)
+	if err != nil {
+		return nil, window, err
+	}
+	return tripped, window, nil
+}
+
+// tripGuardrail records that g tripped, applies its action to flag and sends
+// the alert.
+func (s *Server) tripGuardrail(ctx context.Context, gs store.GuardrailStore, g *store.Guardrail, flag *store.Flag, window guardrailWindow) (*store.Guardrail, error) {
+	now := s.guardrailMetrics.now().UTC()
+	reason := fmt.Sprintf("error rate %.2f%% over %d requests exceeded %.2f%%",
+		window.ErrorRate*100, window.Requests, g.MaxErrorRate*100)
+	tripped, err := gs.SetGuardrailTripped(ctx, g.Key, g.Env, &now, reason)
+	if err != nil {
+		return nil, err
+	}
+	s.guardrailMetrics.invalidate(g.Env)
+
+	rollout := flag.Rollout
+	if g.Action == guardrailActionRollback && flag.Rollout > g.RollbackRollout {
+		before, rolledBack, err := s.rollBackFlag(ctx, g, flag)
+		if err != nil {
+			return nil, err
+		}
+		flag = before
+		rollout = flag.Rollout
+		if rolledBack {
+			if err := s.RebuildSnapshot(ctx, g.Env); err != nil {
+				return nil, err
+			}
+			rollout = g.RollbackRollout
+			s.logGuardrailRollback(ctx, g, flag, reason)
+		}
+	}
+
+	log.Printf("[guardrail] tripped flag=%s env=%s action=%s rollout=%d->%d: %s",
+		g.Key, g.Env, g.Action, flag.Rollout, rollout, reason)
+	afterState := guardrailToMap(tripped)
+	auditID := s.logSystemAudit(audit.NewSystemEventBuilder().
+		ForResource(audit.ResourceTypeFlagGuardrail, g.Key).
+		WithAction(audit.ActionGuardrailTripped).
+		WithEnvironment(g.Env).
+		WithAfterState(afterState).
+		WithNote(reason))
+	if s.webhookDispatcher != nil {
+		s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+			ForFlagAlert(webhook.EventGuardrailTripped, g.Key, g.Env, map[string]any{
+				"reason":     reason,
+				"action":     g.Action,
+				"requests":   window.Requests,
+				"errors":     window.Errors,
+				"error_rate": window.ErrorRate,
+				"rollout":    rollout,
+			}).
+			WithAuditEventID(auditID).
+			WithProject(flag.Project).
+			Build())
+	}
+	return tripped, nil
+}
+
+// rollBackFlag sets the rollout of flag to the rollback rollout of g unless
+// it is already at or below it. The write is conditional on the flag's
+// version; after a concurrent write the flag is reloaded and the rollback
+// retried, up to guardrailRollbackAttempts times. It returns the flag as it
+// was before the rollback and whether it was rolled back.
+func (s *Server) rollBackFlag(ctx context.Context, g *store.Guardrail, flag *store.Flag) (*store.Flag, bool, error) {
+	for attempt := 1; ; attempt++ {
+		if flag.Rollout <= g.RollbackRollout {
+			return flag, false, nil
+		}
+		params := upsertParamsFromFlag(flag)
+		params.Rollout = g.RollbackRollout
+		params.ExpectedVersion = &flag.Version
+		err := s.store.UpsertFlag(ctx, params)
+		if err == nil {
+			return flag, true, nil
+		}
+		if !errors.Is(err, store.ErrVersionConflict) {
+			return nil, false, fmt.Errorf("roll back flag: %w", err)
+		}
+		if attempt == guardrailRollbackAttempts {
+			// The guardrail stays tripped, so the rollout can't be raised
+			// meanwhile; the next trip after a reset retries.
+			log.Printf("[guardrail] WARNING: flag=%s env=%s kept changing, not rolled back", flag.Key, g.Env)
+			return flag, false, nil
+		}
+		if flag, err = s.store.GetFlagByKey(ctx, flag.Key, g.Env); err != nil {
+			return nil, false, fmt.Errorf("reload flag: %w", err)
+		}
+	}
+}
+
+// logGuardrailRollback audits the rollback of flag by g and sends it as a
+// flag update webhook event.
+func (s *Server) logGuardrailRollback(ctx context.Context, g *store.Guardrail, flag *store.Flag, reason string) {
+	beforeState := flagToMap(flag)
+	var afterState map[string]any
+	if after, err := s.store.GetFlagByKey(ctx, flag.Key, g.Env); err == nil {
+		afterState = flagToMap(after)
+	}
+	changes := audit.ComputeChanges(beforeState, afterState)
+	auditID := s.logSystemAudit(audit.NewSystemEventBuilder().
+		ForResource(audit.ResourceTypeFlag, flag.Key).
+		WithAction(audit.ActionUpdated).
+		WithEnvironment(g.Env).
+		WithBeforeState(beforeState).
+		WithAfterState(afterState).
+		WithChanges(changes).
+		WithNote("rolled back by guardrail: " + reason))
+	if s.webhookDispatcher != nil {
+		s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+			ForFlag(flag.Key, g.Env).
+			WithStates(beforeState, afterState).
+			WithChanges(changes).
+			WithAuditEventID(auditID).
+			WithProject(flag.Project).
+			Build())
+	}
+}
+
+// checkGuardrail rejects a write that enables existing or raises its rollout
+// while its guardrail is tripped.
+func (s *Server) checkGuardrail(ctx context.Context, existing *store.Flag, params store.UpsertParams) *flagWriteError {
+	if existing == nil || !params.Enabled || (existing.Enabled && params.Rollout <= existing.Rollout) {
+		return nil
+	}
+	gs, ok := s.store.(store.GuardrailStore)
+	if !ok {
+		return nil
+	}
+	g, err := gs.GetGuardrail(ctx, existing.Key, existing.Env)
+	if err != nil {
+		if errors.Is(err, store.ErrGuardrailNotFound) {
+			return nil
+		}
+		return internalWriteError("Failed to load guardrail")
+	}
+	if g.TrippedAt == nil {
+		return nil
+	}
+	return &flagWriteError{
+		status:  http.StatusConflict,
+		code:    ErrCodeGuardrailTripped,
+		message: "Flag exposure is paused by its guardrail; reset the guardrail first",
+		fields:  map[string]string{"guardrail": g.TripReason},
+	}
+}
+
+// guardrailToMap is the audit state of a guardrail.
+func guardrailToMap(g *store.Guardrail) map[string]any {
+	m := map[string]any{
+		"max_error_rate":   g.MaxErrorRate,
+		"min_requests":     g.MinRequests,
+		"window_seconds":   g.WindowSeconds,
+		"action":           g.Action,
+		"rollback_rollout": g.RollbackRollout,
+	}
+	if g.RequestMetric != "" {
+		m["request_metric"] = g.RequestMetric
+		m["error_metric"] = g.ErrorMetric
+	}
+	if g.TrippedAt != nil {
+		m["tripped_at"] = g.TrippedAt.Format(time.RFC3339)
+		m["trip_reason"] = g.TripReason
+	}
+	return m
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestGuardrail_TripRollsBackAndPauses(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 50, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/guardrail", `{"max_error_rate":1.5,"action":"explode"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid guardrail, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/guardrail",
+		`{"max_error_rate":0.05,"min_requests":100,"action":"rollback","rollback_rollout":10}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	report := func(body string) guardrailResponse {
+		t.Helper()
+		rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout/guardrail/metrics", body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("metrics: expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp guardrailResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if resp := report(`{"requests":50,"errors":20}`); resp.Guardrail.TrippedAt != nil {
+		t.Fatalf("expected no trip below min_requests, got %+v", resp)
+	}
+	resp := report(`{"requests":50,"errors":0}`)
+	if resp.Guardrail.TrippedAt == nil || resp.Window.Requests != 100 || resp.Window.ErrorRate != 0.2 {
+		t.Fatalf("expected the guardrail to trip, got %+v", resp)
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "checkout", "prod"); flag.Rollout != 10 {
+		t.Errorf("expected the rollout to be rolled back to 10, got %d", flag.Rollout)
+	}
+	if view := srv.snapshotForEnv("prod").Flags["checkout"]; view.Rollout != 10 {
+		t.Errorf("expected the snapshot to serve the rolled back rollout, got %d", view.Rollout)
+	}
+
+	raise := `{"key":"checkout","enabled":true,"rollout":50,"env":"prod"}`
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", raise)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while tripped, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var errResp ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&errResp); err != nil || errResp.Code != ErrCodeGuardrailTripped {
+		t.Errorf("expected %s, got %+v, %v", ErrCodeGuardrailTripped, errResp, err)
+	}
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":5,"env":"prod"}`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected lowering the rollout to be allowed while tripped, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout/guardrail/reset", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("reset: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", raise)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected raising the rollout to be allowed after reset, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGuardrailMetrics_Window(t *testing.T) {
+	m := newGuardrailMetrics()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	m.add("prod", "checkout", time.Minute, 100, 10)
+	now = now.Add(30 * time.Second)
+	if w := m.add("prod", "checkout", time.Minute, 100, 30); w.Requests != 200 || w.Errors != 40 || w.ErrorRate != 0.2 {
+		t.Errorf("expected both reports in the window, got %+v", w)
+	}
+	now = now.Add(45 * time.Second)
+	if w := m.window("prod", "checkout", time.Minute); w.Requests != 100 || w.Errors != 30 {
+		t.Errorf("expected the first report to leave the window, got %+v", w)
+	}
+	now = now.Add(time.Minute)
+	if w := m.window("prod", "checkout", time.Minute); w.Requests != 0 || w.ErrorRate != 0 {
+		t.Errorf("expected an empty window, got %+v", w)
+	}
+}
+
+func TestGuardrail_RollbackKeepsConcurrentEdits(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 50, Env: "prod"})
+	g, err := st.SetGuardrail(ctx, store.Guardrail{Key: "checkout", Env: "prod", MaxErrorRate: 0.05, Action: guardrailActionRollback, RollbackRollout: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale, _ := st.GetFlagByKey(ctx, "checkout", "prod")
+
+	// An admin edit lands between loading the flag and rolling it back.
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Description: "edited", Enabled: true, Rollout: 60, Env: "prod"})
+
+	if _, err := srv.tripGuardrail(ctx, st, g, stale, guardrailWindow{Requests: 100, Errors: 20, ErrorRate: 0.2}); err != nil {
+		t.Fatal(err)
+	}
+	flag, _ := st.GetFlagByKey(ctx, "checkout", "prod")
+	if flag.Rollout != 10 || flag.Description != "edited" {
+		t.Errorf("expected the edited flag to be rolled back to 10, got rollout=%d description=%q", flag.Rollout, flag.Description)
+	}
+}
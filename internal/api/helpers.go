@@ -134,6 +134,38 @@ func flagToMap(flag *store.Flag) map[string]any {
 		m["config"] = flag.Config
 	}
 
+	if flag.Protected {
+		m["protected"] = true
+	}
+
+	if len(flag.Tags) > 0 {
+		m["tags"] = flag.Tags
+	}
+
+	if flag.Archived {
+		m["archived"] = true
+	}
+
+	if flag.Owner != "" {
+		m["owner"] = flag.Owner
+	}
+
+	if flag.TicketURL != "" {
+		m["ticket_url"] = flag.TicketURL
+	}
+
+	if len(flag.Metadata) > 0 {
+		m["metadata"] = flag.Metadata
+	}
+
+	if flag.ExpiresAt != nil {
+		m["expires_at"] = flag.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	if flag.Project != "" && flag.Project != store.DefaultProject {
+		m["project"] = flag.Project
+	}
+
 	if len(flag.Variants) > 0 {
 		variants := make([]map[string]any, len(flag.Variants))
 		for i, v := range flag.Variants {
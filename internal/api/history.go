@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// Every version of a flag is kept as an immutable revision (see
+// store.FlagRevisionStore), so a bad change can be inspected and undone:
+//
+//	GET  /v1/flags/{key}/history?env=              revisions, newest first (admin+)
+//	POST /v1/flags/{key}/rollback/{version}?env=   write a revision back (admin+)
+//
+// A rollback does not rewrite history: it is a regular flag write of the old
+// definition, so it goes through every check of an update (protection,
+// limits, guardrails, readiness), is audited and sent to webhooks, supports
+// ?dry_run=true and If-Match (see versions.go), and records a new version.
+// The flag's archived state is kept, like for any update. Rolling back a
+// deleted flag creates it again.
+
+type flagHistoryResponse struct {
+	Key       string         `json:"key"`
+	Env       string         `json:"env"`
+	Revisions []flagResponse `json:"revisions"` // updated_at is when the version was written
+}
+
+// requireRevisionStore returns the store as a FlagRevisionStore, writing an
+// error response and returning nil if it does not keep revisions.
+func (s *Server) requireRevisionStore(w http.ResponseWriter, r *http.Request) store.FlagRevisionStore {
+	if rs, ok := s.store.(store.FlagRevisionStore); ok {
+		return rs
+	}
+	InternalError(w, r, "Flag history is not supported by this store")
+	return nil
+}
+
+func (s *Server) handleFlagHistory(w http.ResponseWriter, r *http.Request) {
+	rs := s.requireRevisionStore(w, r)
+	if rs == nil {
+		return
+	}
+	key := chi.URLParam(r, "id")
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = s.env
+	}
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+
+	revisions, err := rs.ListFlagRevisions(r.Context(), key, env)
+	if err != nil {
+		InternalError(w, r, "Failed to load flag history")
+		return
+	}
+	if len(revisions) == 0 {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+	resp := flagHistoryResponse{Key: key, Env: env, Revisions: make([]flagResponse, len(revisions))}
+	for i := range revisions {
+		resp.Revisions[i] = toFlagResponse(&revisions[i])
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleRollbackFlag(w http.ResponseWriter, r *http.Request) {
+	rs := s.requireRevisionStore(w, r)
+	if rs == nil {
+		return
+	}
+	key := chi.URLParam(r, "id")
+	version, err := strconv.ParseInt(chi.URLParam(r, "version"), 10, 64)
+	if err != nil || version < 1 {
+		ValidationError(w, r, "Invalid version", map[string]string{"version": "must be a positive integer"})
+		return
+	}
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = s.env
+	}
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+
+	revision, err := rs.GetFlagRevision(r.Context(), key, env, version)
+	if err != nil {
+		if errors.Is(err, store.ErrFlagRevisionNotFound) {
+			NotFoundError(w, r, "Flag version not found")
+			return
+		}
+		InternalError(w, r, "Failed to load flag version")
+		return
+	}
+	s.handleUpsertFlagRequest(w, r, upsertRequestFromFlag(revision))
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestFlagHistory_ListAndRollback(t *testing.T) {
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+	ctx := context.Background()
+
+	for _, body := range []string{
+		`{"key":"checkout","description":"v1","enabled":false,"rollout":0}`,
+		`{"key":"checkout","description":"v2","enabled":true,"rollout":25}`,
+		`{"key":"checkout","description":"v3","enabled":true,"rollout":100}`,
+	} {
+		if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", body); rr.Code != http.StatusOK {
+			t.Fatalf("write: expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	history := func() flagHistoryResponse {
+		t.Helper()
+		rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/history?env=prod", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("history: expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp flagHistoryResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	resp := history()
+	if len(resp.Revisions) != 3 || resp.Revisions[0].Version != 3 || resp.Revisions[2].Description != "v1" {
+		t.Fatalf("unexpected history: %+v", resp.Revisions)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout/rollback/1?env=prod", ""); rr.Code != http.StatusOK {
+		t.Fatalf("rollback: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	flag, err := st.GetFlagByKey(ctx, "checkout", "prod")
+	if err != nil || flag.Description != "v1" || flag.Enabled || flag.Version != 4 {
+		t.Fatalf("expected v1 written back as version 4, got %+v, %v", flag, err)
+	}
+	if resp := history(); len(resp.Revisions) != 4 || resp.Revisions[0].Description != "v1" {
+		t.Errorf("expected the rollback recorded as a new revision, got %+v", resp.Revisions)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout/rollback/9?env=prod", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown version: expected 404, got %d", rr.Code)
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout/rollback/latest?env=prod", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid version: expected 400, got %d", rr.Code)
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/missing/history", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown flag: expected 404, got %d", rr.Code)
+	}
+
+	// History outlives the flag, and a recreated flag counts on.
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v1/flags?key=checkout&env=prod", ""); rr.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d", rr.Code)
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout/rollback/2?env=prod", ""); rr.Code != http.StatusOK {
+		t.Fatalf("rollback of a deleted flag: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "checkout", "prod"); flag.Description != "v2" || flag.Version != 5 {
+		t.Errorf("expected v2 recreated as version 5, got %+v", flag)
+	}
+}
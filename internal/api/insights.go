@@ -0,0 +1,235 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/engine"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/go-chi/chi/v5"
+)
+
+// Evaluation insights show what a flag actually served, so an admin can check
+// that a 25% rollout really lands near 25%:
+//
+//	GET /v1/flags/{key}/insights?env=<env>   recent evaluation distribution (admin+)
+//
+// 1 in insightsSampleRate evaluation requests (evaluate and OFREP endpoints)
+// records the results of every flag it evaluated in hourly buckets; the
+// response sums the last insightsWindow. Like the attribute tracker, the data
+// is kept in memory per server instance.
+
+const (
+	// insightsSampleRate samples 1 in every n evaluation requests.
+	insightsSampleRate = 10
+
+	// insightsWindow is how far back insights go, in hourly buckets.
+	insightsWindow = 24 * time.Hour
+)
+
+type insightsResponse struct {
+	Key        string         `json:"key"`
+	Env        string         `json:"env"`
+	Since      time.Time      `json:"since"`       // start of the oldest bucket included
+	SampleRate int            `json:"sample_rate"` // 1 in sample_rate requests is recorded
+	Rollout    int32          `json:"rollout"`     // configured rollout, for comparison
+	Sampled    int            `json:"sampled"`     // sampled evaluations in the window
+	On         int            `json:"on"`
+	Off        int            `json:"off"`
+	OnPercent  float64        `json:"on_percent"`
+	Variants   map[string]int `json:"variants"`
+	Reasons    map[string]int `json:"reasons"`
+}
+
+// handleFlagInsights handles GET /v1/flags/{id}/insights.
+func (s *Server) handleFlagInsights(w http.ResponseWriter, r *http.Request) {
+	key, env := strings.TrimSpace(chi.URLParam(r, "id")), s.queryEnv(r)
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+	flag, ok := s.loadEnvFlag(r, key, env)
+	if !ok {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+
+	resp := s.insights.summary(env, key)
+	resp.Key, resp.Env, resp.Rollout = key, env, flag.Rollout
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// insightsTracker counts sampled evaluation results per flag and hour.
+type insightsTracker struct {
+	sampler evaluationSampler
+	now     func() time.Time
+
+	mu    sync.Mutex
+	flags map[string]*flagInsights // flagID-style "env/key" -> insights
+}
+
+// flagInsights holds the hourly buckets of one flag, oldest first.
+type flagInsights struct {
+	buckets []*insightsBucket
+}
+
+type insightsBucket struct {
+	hour     time.Time
+	on, off  int
+	variants map[string]int
+	reasons  map[string]int
+}
+
+// insightResult is one evaluated flag of a sampled request.
+type insightResult struct {
+	key     string
+	enabled bool
+	variant string
+	reason  string
+}
+
+func newInsightsTracker() *insightsTracker {
+	return &insightsTracker{
+		sampler: evaluationSampler{n: insightsSampleRate},
+		now:     time.Now,
+		flags:   make(map[string]*flagInsights),
+	}
+}
+
+// recordFlagResults records the evaluation errors (see evalerrors.go) and,
+// if sampled, the results of a /v1/evaluate request.
+func (s *Server) recordFlagResults(r *http.Request, results []FlagResult) {
+	for _, res := range results {
+		s.recordEvaluationError(r, res.Key, res.err)
+	}
+	if !s.insights.sampler.sample() {
+		return
+	}
+	sampled := make([]insightResult, len(results))
+	for i, res := range results {
+		sampled[i] = insightResult{key: res.Key, enabled: res.Enabled, variant: res.Variant, reason: res.Reason}
+	}
+	s.insights.record(s.requestEnvironment(r), sampled)
+}
+
+// recordEvaluationResults records the evaluation errors and, if sampled, the
+// results of a /v1/flags/evaluate request.
+func (s *Server) recordEvaluationResults(r *http.Request, results []evaluation.Result) {
+	for _, res := range results {
+		s.recordEvaluationError(r, res.Key, res.Err)
+	}
+	if !s.insights.sampler.sample() {
+		return
+	}
+	sampled := make([]insightResult, len(results))
+	for i, res := range results {
+		sampled[i] = insightResult{key: res.Key, enabled: res.Enabled, variant: res.Variant, reason: res.Reason}
+	}
+	s.insights.record(s.requestEnvironment(r), sampled)
+}
+
+// recordOFREPResults records the evaluation errors and, if sampled, the
+// results of an OFREP request, with reasons named as in the engine so they
+// add up with /v1/evaluate.
+func (s *Server) recordOFREPResults(r *http.Request, results []ofrepEvaluation) {
+	for _, res := range results {
+		s.recordEvaluationError(r, res.Key, res.err)
+	}
+	if !s.insights.sampler.sample() {
+		return
+	}
+	sampled := make([]insightResult, len(results))
+	for i, res := range results {
+		reason := string(engine.ReasonDefaultRollout)
+		switch res.Reason {
+		case ofrepReasonDisabled:
+			reason = string(engine.ReasonDisabled)
+		case ofrepReasonTargetingMatch:
+			reason = string(engine.ReasonTargetingMatch)
+		case ofrepReasonError:
+			reason = string(engine.ReasonError)
+		}
+		sampled[i] = insightResult{key: res.Key, enabled: res.enabled, variant: res.Variant, reason: reason}
+	}
+	s.insights.record(s.requestEnvironment(r), sampled)
+}
+
+func (t *insightsTracker) record(env string, results []insightResult) {
+	hour := t.now().UTC().Truncate(time.Hour)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, res := range results {
+		fi := t.flags[env+"/"+res.key]
+		if fi == nil {
+			fi = &flagInsights{}
+			t.flags[env+"/"+res.key] = fi
+		}
+		bucket := fi.bucket(hour)
+		if res.enabled {
+			bucket.on++
+		} else {
+			bucket.off++
+		}
+		if res.variant != "" {
+			bucket.variants[res.variant]++
+		}
+		if res.reason != "" {
+			bucket.reasons[res.reason]++
+		}
+	}
+}
+
+// bucket returns the bucket for hour, creating it and dropping buckets that
+// fell out of the window.
+func (fi *flagInsights) bucket(hour time.Time) *insightsBucket {
+	if n := len(fi.buckets); n > 0 && fi.buckets[n-1].hour.Equal(hour) {
+		return fi.buckets[n-1]
+	}
+	cutoff := hour.Add(-insightsWindow)
+	kept := fi.buckets[:0]
+	for _, b := range fi.buckets {
+		if b.hour.After(cutoff) {
+			kept = append(kept, b)
+		}
+	}
+	b := &insightsBucket{hour: hour, variants: map[string]int{}, reasons: map[string]int{}}
+	fi.buckets = append(kept, b)
+	return b
+}
+
+// summary sums the buckets of key in env that are inside the window.
+func (t *insightsTracker) summary(env, key string) insightsResponse {
+	since := t.now().UTC().Truncate(time.Hour).Add(-insightsWindow + time.Hour)
+	resp := insightsResponse{
+		Since:      since,
+		SampleRate: int(t.sampler.n),
+		Variants:   map[string]int{},
+		Reasons:    map[string]int{},
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fi := t.flags[env+"/"+key]
+	if fi == nil {
+		return resp
+	}
+	for _, b := range fi.buckets {
+		if b.hour.Before(since) {
+			continue
+		}
+		resp.On += b.on
+		resp.Off += b.off
+		for variant, n := range b.variants {
+			resp.Variants[variant] += n
+		}
+		for reason, n := range b.reasons {
+			resp.Reasons[reason] += n
+		}
+	}
+	resp.Sampled = resp.On + resp.Off
+	if resp.Sampled > 0 {
+		resp.OnPercent = float64(resp.On) * 100 / float64(resp.Sampled)
+	}
+	return resp
+}
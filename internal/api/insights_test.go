@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestFlagInsights(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.insights.sampler.n = 1 // record every request
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":25}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	for i := 0; i < 400; i++ {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/flags/evaluate?userId=user-%d&keys=checkout", i), nil)
+		req.RemoteAddr = fmt.Sprintf("10.0.%d.%d:1234", i/100, i%100) // stay under the per-IP rate limit
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("evaluate: expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/insights", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp insightsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Sampled != 400 || resp.Rollout != 25 || resp.On+resp.Off != 400 {
+		t.Fatalf("unexpected insights: %+v", resp)
+	}
+	if resp.OnPercent < 18 || resp.OnPercent > 32 {
+		t.Errorf("expected about 25%% on, got %.1f%%", resp.OnPercent)
+	}
+	if resp.Reasons[evaluation.ReasonDefaultRollout] != resp.On || resp.Reasons[evaluation.ReasonRolloutExcluded] != resp.Off {
+		t.Errorf("unexpected reasons: %+v", resp.Reasons)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/missing/insights", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown flag, got %d", rr.Code)
+	}
+}
+
+func TestInsightsTracker_Window(t *testing.T) {
+	tracker := newInsightsTracker()
+	now := time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	tracker.record("prod", []insightResult{{key: "checkout", enabled: true, variant: "a", reason: "DEFAULT_ROLLOUT"}})
+	now = now.Add(23 * time.Hour)
+	tracker.record("prod", []insightResult{{key: "checkout", enabled: false, reason: "DISABLED"}})
+
+	resp := tracker.summary("prod", "checkout")
+	if resp.On != 1 || resp.Off != 1 || resp.Variants["a"] != 1 || resp.OnPercent != 50 {
+		t.Fatalf("expected both evaluations within the window, got %+v", resp)
+	}
+
+	now = now.Add(time.Hour)
+	resp = tracker.summary("prod", "checkout")
+	if resp.On != 0 || resp.Off != 1 || resp.Reasons["DISABLED"] != 1 {
+		t.Fatalf("expected the oldest bucket to fall out of the window, got %+v", resp)
+	}
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/report"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+// API keys created with an expires_at stop working at that time, which is
+// easy to miss for a key buried in a CI secret. A periodic job (hourly by
+// default, KEY_EXPIRY_CHECK_INTERVAL) warns when an enabled key is 14, 7 and
+// 1 days from expiry:
+//
+//	GET /v1/admin/keys/expiring?days=14   keys expiring within days (superadmin)
+//
+// Each warning is sent as an "apikey.expiring" webhook event and, if a mailer
+// is configured (the summary report's REPORT_EMAIL_TO), by email. A key is
+// warned once per threshold; a key first seen at 5 days left gets the 7-day
+// warning only. Sent warnings are remembered in memory, so a restart may
+// repeat the current one. API keys need the Postgres store.
+
+const (
+	// defaultExpiringKeyDays is the window of the expiring keys endpoint.
+	defaultExpiringKeyDays = 14
+
+	// maxExpiringKeyDays caps the window of the expiring keys endpoint.
+	maxExpiringKeyDays = 365
+)
+
+// keyExpiryThresholds are the days before expiry a key is warned at, largest first.
+var keyExpiryThresholds = []int{14, 7, 1}
+
+// KeyExpiryConfig configures delivery of key expiry warnings.
+type KeyExpiryConfig struct {
+	Mailer *report.Mailer // nil disables email
+}
+
+// keyExpiryNotifier remembers the warnings sent per key.
+type keyExpiryNotifier struct {
+	mu       sync.Mutex
+	cfg      KeyExpiryConfig
+	notified map[string]int // key ID -> smallest threshold warned about
+}
+
+// SetKeyExpiry configures how key expiry warnings are sent.
+func (s *Server) SetKeyExpiry(cfg KeyExpiryConfig) {
+	s.keyExpiry.mu.Lock()
+	defer s.keyExpiry.mu.Unlock()
+	s.keyExpiry.cfg = cfg
+}
+
+type expiringKey struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Tenant    string `json:"tenant"`
+	CreatedBy string `json:"created_by"`
+	ExpiresAt string `json:"expires_at"`
+	DaysLeft  int    `json:"days_left"` // whole days until expiry, rounded up
+}
+
+type expiringKeysResponse struct {
+	Days int           `json:"days"`
+	Keys []expiringKey `json:"keys"`
+}
+
+// handleListExpiringKeys handles GET /v1/admin/keys/expiring.
+func (s *Server) handleListExpiringKeys(w http.ResponseWriter, r *http.Request) {
+	days := defaultExpiringKeyDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxExpiringKeyDays {
+			ValidationError(w, r, "Invalid query parameters", map[string]string{
+				"days": fmt.Sprintf("must be between 1 and %d", maxExpiringKeyDays),
+			})
+			return
+		}
+		days = n
+	}
+
+	pgStore := s.requirePostgresStore(w, r)
+	if pgStore == nil {
+		return // Error already written to response
+	}
+	keys, err := pgStore.ListAPIKeys(r.Context())
+	if err != nil {
+		InternalError(w, r, "Failed to list keys")
+		return
+	}
+
+	var visible []dbgen.ApiKey
+	for _, key := range keys {
+		if projectVisible(r, key.Project.String) {
+			visible = append(visible, key)
+		}
+	}
+	resp := expiringKeysResponse{Days: days, Keys: []expiringKey{}}
+	for _, key := range expiringKeys(visible, time.Now(), time.Duration(days)*24*time.Hour) {
+		if tenantOwnsRow(r, key.Tenant) {
+			resp.Keys = append(resp.Keys, key)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// expiringKeys returns the enabled keys that expire within window after now,
+// soonest first.
+func expiringKeys(keys []dbgen.ApiKey, now time.Time, window time.Duration) []expiringKey {
+	var result []expiringKey
+	for _, key := range keys {
+		if !key.Enabled || !key.ExpiresAt.Valid {
+			continue
+		}
+		left := key.ExpiresAt.Time.Sub(now)
+		if left <= 0 || left > window {
+			continue
+		}
+		result = append(result, expiringKey{
+			ID:        formatUUID(key.ID),
+			Name:      key.Name,
+			Role:      string(key.Role),
+			Tenant:    key.Tenant,
+			CreatedBy: key.CreatedBy,
+			ExpiresAt: formatTimestamp(key.ExpiresAt),
+			DaysLeft:  int((left + 24*time.Hour - 1) / (24 * time.Hour)),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ExpiresAt != result[j].ExpiresAt {
+			return result[i].ExpiresAt < result[j].ExpiresAt
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// RunKeyExpiryChecks runs CheckKeyExpiry every interval until ctx is
+// cancelled. It returns immediately if interval is not positive or the store
+// has no API keys.
+func (s *Server) RunKeyExpiryChecks(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	if _, ok := s.store.(PostgresStoreInterface); !ok {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.CheckKeyExpiry(ctx, time.Now()); err != nil {
+			log.Printf("[keys] expiry check failed: %v", err)
+		}
+		worker.Processed(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckKeyExpiry warns about the keys that crossed an expiry threshold since
+// they were last warned about, and returns them. Delivery errors are logged.
+func (s *Server) CheckKeyExpiry(ctx context.Context, now time.Time) ([]expiringKey, error) {
+	pgStore, ok := s.store.(PostgresStoreInterface)
+	if !ok {
+		return nil, nil
+	}
+	keys, err := pgStore.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	window := time.Duration(keyExpiryThresholds[0]) * 24 * time.Hour
+
+	s.keyExpiry.mu.Lock()
+	if s.keyExpiry.notified == nil {
+		s.keyExpiry.notified = map[string]int{}
+	}
+	var due []expiringKey
+	var thresholds []int
+	current := map[string]bool{}
+	for _, key := range expiringKeys(keys, now, window) {
+		current[key.ID] = true
+		threshold := keyExpiryThreshold(key.DaysLeft)
+		if last, ok := s.keyExpiry.notified[key.ID]; ok && last <= threshold {
+			continue
+		}
+		s.keyExpiry.notified[key.ID] = threshold
+		due = append(due, key)
+		thresholds = append(thresholds, threshold)
+	}
+	// Forget keys that expired, were revoked or got a later expiry, so a
+	// renewed key is warned about again.
+	for id := range s.keyExpiry.notified {
+		if !current[id] {
+			delete(s.keyExpiry.notified, id)
+		}
+	}
+	mailer := s.keyExpiry.cfg.Mailer
+	s.keyExpiry.mu.Unlock()
+
+	for i, key := range due {
+		log.Printf("[keys] key expiring: id=%s name=%s tenant=%s expires_at=%s days_left=%d",
+			key.ID, key.Name, key.Tenant, key.ExpiresAt, key.DaysLeft)
+		if s.webhookDispatcher != nil {
+			s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+				ForAPIKey(webhook.EventAPIKeyExpiring, key.ID, expiringKeyData(key, thresholds[i])).
+				Build())
+		}
+		if mailer != nil {
+			if err := mailer.Send(key.subject(), key.text()); err != nil {
+				log.Printf("[keys] %v", err)
+			}
+		}
+	}
+	return due, nil
+}
+
+// keyExpiryThreshold returns the smallest threshold that daysLeft is within.
+func keyExpiryThreshold(daysLeft int) int {
+	threshold := keyExpiryThresholds[0]
+	for _, t := range keyExpiryThresholds {
+		if daysLeft <= t {
+			threshold = t
+		}
+	}
+	return threshold
+}
+
+// expiringKeyData is the webhook payload of an expiry warning.
+func expiringKeyData(key expiringKey, threshold int) map[string]any {
+	return map[string]any{
+		"id":         key.ID,
+		"name":       key.Name,
+		"role":       key.Role,
+		"tenant":     key.Tenant,
+		"created_by": key.CreatedBy,
+		"expires_at": key.ExpiresAt,
+		"days_left":  key.DaysLeft,
+		"threshold":  threshold,
+	}
+}
+
+func (k expiringKey) subject() string {
+	return fmt.Sprintf("[goflagship] API key %q expires in %s", k.Name, pluralDays(k.DaysLeft))
+}
+
+func (k expiringKey) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The API key %q expires at %s (in %s).\n\n", k.Name, k.ExpiresAt, pluralDays(k.DaysLeft))
+	fmt.Fprintf(&b, "ID:         %s\n", k.ID)
+	fmt.Fprintf(&b, "Role:       %s\n", k.Role)
+	fmt.Fprintf(&b, "Tenant:     %s\n", k.Tenant)
+	fmt.Fprintf(&b, "Created by: %s\n\n", k.CreatedBy)
+	b.WriteString("Create a replacement key (POST /v1/admin/keys) and roll it out before\n")
+	b.WriteString("then; requests with the expired key will fail with 401.\n")
+	return b.String()
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
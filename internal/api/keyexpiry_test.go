@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func createExpiringKey(t *testing.T, st *apiKeyMemoryStore, name string, expiresAt time.Time) string {
+	t.Helper()
+	key, err := st.CreateAPIKey(context.Background(), dbgen.CreateAPIKeyParams{
+		Name:      name,
+		Role:      dbgen.ApiKeyRole(auth.RoleAdmin),
+		Enabled:   true,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: !expiresAt.IsZero()},
+		CreatedBy: "ci",
+		Tenant:    auth.DefaultTenant,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return formatUUID(key.ID)
+}
+
+func TestCheckKeyExpiry(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "prod", "test-key")
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	ci := createExpiringKey(t, st, "ci", now.Add(10*24*time.Hour))
+	createExpiringKey(t, st, "later", now.Add(30*24*time.Hour))
+	createExpiringKey(t, st, "expired", now.Add(-time.Hour))
+	createExpiringKey(t, st, "forever", time.Time{})
+
+	check := func(at time.Time, want ...string) []expiringKey {
+		t.Helper()
+		due, err := srv.CheckKeyExpiry(ctx, at)
+		if err != nil {
+			t.Fatalf("CheckKeyExpiry failed: %v", err)
+		}
+		var got []string
+		for _, key := range due {
+			got = append(got, key.Name)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("at %s: expected warnings for %v, got %v", at, want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("at %s: expected warnings for %v, got %v", at, want, got)
+			}
+		}
+		return due
+	}
+
+	if due := check(now, "ci"); due[0].ID != ci || due[0].DaysLeft != 10 || keyExpiryThreshold(due[0].DaysLeft) != 14 {
+		t.Errorf("unexpected 14-day warning: %+v", due[0])
+	}
+	check(now.Add(time.Hour))                             // already warned at 14 days
+	check(now.Add(4*24*time.Hour), "ci")                  // 6 days left: 7-day warning
+	check(now.Add(5 * 24 * time.Hour))                    // 5 days left: still the 7-day warning
+	due := check(now.Add(9*24*time.Hour+time.Hour), "ci") // 23h left
+	if due[0].DaysLeft != 1 {
+		t.Errorf("expected 1 day left, got %d", due[0].DaysLeft)
+	}
+	check(now.Add(11 * 24 * time.Hour)) // expired: no more warnings
+
+	// A key first seen within 7 days only gets the 7-day warning.
+	createExpiringKey(t, st, "new", now.Add(16*24*time.Hour))
+	check(now.Add(11*24*time.Hour), "new")
+	check(now.Add(12 * 24 * time.Hour))
+}
+
+func TestListExpiringKeys(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	now := time.Now()
+	createExpiringKey(t, st, "soon", now.Add(2*24*time.Hour))
+	createExpiringKey(t, st, "sooner", now.Add(time.Hour))
+	createExpiringKey(t, st, "later", now.Add(30*24*time.Hour))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/keys/expiring"+query, nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp expiringKeysResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Days != 14 || len(resp.Keys) != 2 || resp.Keys[0].Name != "sooner" || resp.Keys[1].Name != "soon" || resp.Keys[1].DaysLeft != 2 {
+		t.Fatalf("unexpected expiring keys: %+v", resp)
+	}
+
+	if err := json.Unmarshal(get("?days=60").Body.Bytes(), &resp); err != nil || len(resp.Keys) != 3 {
+		t.Errorf("expected all keys within 60 days, got %+v, %v", resp, err)
+	}
+	if rr := get("?days=0"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for days=0, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Every API key is a credential that can leak, and keys nobody uses anymore
+// are the easiest to forget about. The idle key policy (KEY_IDLE_DAYS, off by
+// default) checks hourly for enabled keys not used for that long, counting
+// from creation for keys never used:
+//
+//   - by default they are only flagged: logged and audited ("idle_detected")
+//     once per key, for a superadmin to review;
+//   - with KEY_IDLE_DISABLE=true they are disabled (disabled_reason
+//     "unused") and audited as a system update. The last enabled superadmin
+//     key is only ever flagged, so the deployment cannot lock itself out.
+//
+// A key disabled for being unused can be re-enabled within the grace period
+// (KEY_REENABLE_GRACE_DAYS, default 14):
+//
+//	POST /v1/admin/keys/{id}/enable   (superadmin)
+//
+// Re-enabling counts as a use, so the key has another idle period. Revoked
+// keys, and unused keys past the grace period, cannot be re-enabled; create a
+// new key instead.
+
+const (
+	// keyIdleCheckInterval is how often keys are checked against the policy.
+	keyIdleCheckInterval = time.Hour
+
+	// keyDisabledUnused and keyDisabledRevoked are the disabled_reason of
+	// keys disabled by the idle key policy and by DELETE /v1/admin/keys/{id}.
+	keyDisabledUnused  = "unused"
+	keyDisabledRevoked = "revoked"
+)
+
+// KeyIdlePolicy configures the idle API key policy.
+type KeyIdlePolicy struct {
+	IdleAfter     time.Duration // how long a key must be unused to be flagged (0 disables)
+	Disable       bool          // disable idle keys instead of only flagging them
+	ReenableGrace time.Duration // how long a disabled key can be re-enabled
+}
+
+// DefaultKeyIdlePolicy is off; disabled keys can be re-enabled for 14 days.
+func DefaultKeyIdlePolicy() KeyIdlePolicy {
+	return KeyIdlePolicy{ReenableGrace: 14 * 24 * time.Hour}
+}
+
+// APIKeyStatusStore is implemented by stores that can disable and re-enable
+// API keys (PostgresStore).
+type APIKeyStatusStore interface {
+	PostgresStoreInterface
+	DisableAPIKey(ctx context.Context, id pgtype.UUID, reason string) (bool, error)
+	ReenableAPIKey(ctx context.Context, id pgtype.UUID, reason string, since time.Time) (bool, error)
+}
+
+// keyIdleState holds the idle key policy and the keys already flagged.
+type keyIdleState struct {
+	mu      sync.Mutex
+	policy  KeyIdlePolicy
+	flagged map[string]bool // key ID -> flagged while idle
+}
+
+// SetKeyIdlePolicy replaces the idle API key policy.
+func (s *Server) SetKeyIdlePolicy(policy KeyIdlePolicy) {
+	s.keyIdle.mu.Lock()
+	defer s.keyIdle.mu.Unlock()
+	s.keyIdle.policy = policy
+}
+
+func (s *Server) keyIdlePolicy() KeyIdlePolicy {
+	s.keyIdle.mu.Lock()
+	defer s.keyIdle.mu.Unlock()
+	return s.keyIdle.policy
+}
+
+// RunKeyIdleChecks runs CheckIdleKeys hourly until ctx is cancelled. It
+// returns immediately if the policy is off or the store has no API keys.
+func (s *Server) RunKeyIdleChecks(ctx context.Context) {
+	if s.keyIdlePolicy().IdleAfter <= 0 {
+		return
+	}
+	if _, ok := s.store.(APIKeyStatusStore); !ok {
+		return
+	}
+	ticker := time.NewTicker(keyIdleCheckInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.CheckIdleKeys(ctx, time.Now()); err != nil {
+			log.Printf("[keys] idle check failed: %v", err)
+		}
+		worker.Processed(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckIdleKeys applies the idle key policy at now and returns the keys it
+// flagged or disabled, as they were before.
+func (s *Server) CheckIdleKeys(ctx context.Context, now time.Time) ([]dbgen.ApiKey, error) {
+	policy := s.keyIdlePolicy()
+	ks, ok := s.store.(APIKeyStatusStore)
+	if policy.IdleAfter <= 0 || !ok {
+		return nil, nil
+	}
+	keys, err := ks.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	superadmins := 0
+	for _, key := range keys {
+		if key.Enabled && key.Role == dbgen.ApiKeyRole(auth.RoleSuperadmin) {
+			superadmins++
+		}
+	}
+
+	s.keyIdle.mu.Lock()
+	defer s.keyIdle.mu.Unlock()
+	flagged := map[string]bool{}
+	var acted []dbgen.ApiKey
+	for _, key := range keys {
+		lastActive := key.CreatedAt.Time
+		if key.LastUsedAt.Valid && key.LastUsedAt.Time.After(lastActive) {
+			lastActive = key.LastUsedAt.Time
+		}
+		if !key.Enabled || now.Sub(lastActive) < policy.IdleAfter {
+			continue
+		}
+		id := formatUUID(key.ID)
+		idleDays := int(now.Sub(lastActive) / (24 * time.Hour))
+		lastSuperadmin := key.Role == dbgen.ApiKeyRole(auth.RoleSuperadmin) && superadmins <= 1
+
+		if policy.Disable && !lastSuperadmin {
+			disabled, err := ks.DisableAPIKey(ctx, key.ID, keyDisabledUnused)
+			if err != nil {
+				log.Printf("[keys] failed to disable idle key id=%s: %v", id, err)
+				continue
+			}
+			if !disabled {
+				continue // revoked meanwhile
+			}
+			if key.Role == dbgen.ApiKeyRole(auth.RoleSuperadmin) {
+				superadmins--
+			}
+			after := apiKeyToMap(key)
+			after["enabled"] = false
+			after["disabled_reason"] = keyDisabledUnused
+			s.logSystemAudit(audit.NewSystemEventBuilder().
+				ForResource(audit.ResourceTypeAPIKey, id).
+				WithAction(audit.ActionUpdated).
+				WithBeforeState(apiKeyToMap(key)).
+				WithAfterState(after).
+				WithNote(fmt.Sprintf("disabled by the idle key policy: unused for %d days", idleDays)))
+			log.Printf("[keys] disabled idle key id=%s name=%s tenant=%s idle_days=%d", id, key.Name, key.Tenant, idleDays)
+			acted = append(acted, key)
+			continue
+		}
+
+		flagged[id] = true
+		if s.keyIdle.flagged[id] {
+			continue
+		}
+		note := fmt.Sprintf("unused for %d days", idleDays)
+		if policy.Disable {
+			note += "; not disabled because it is the last enabled superadmin key"
+		}
+		s.logSystemAudit(audit.NewSystemEventBuilder().
+			ForResource(audit.ResourceTypeAPIKey, id).
+			WithAction(audit.ActionIdleDetected).
+			WithAfterState(apiKeyToMap(key)).
+			WithNote(note))
+		log.Printf("[keys] idle key id=%s name=%s tenant=%s idle_days=%d", id, key.Name, key.Tenant, idleDays)
+		acted = append(acted, key)
+	}
+	// Keys used again or disabled meanwhile are flagged again when they next go idle.
+	s.keyIdle.flagged = flagged
+	return acted, nil
+}
+
+// handleReenableAPIKey handles POST /v1/admin/keys/{id}/enable.
+func (s *Server) handleReenableAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "id")
+	uuid, err := parseUUID(keyID)
+	if err != nil {
+		BadRequestErrorWithFields(w, r, ErrCodeValidation, "Invalid key ID format", map[string]string{
+			"id": "Key ID must be a valid UUID format",
+		})
+		return
+	}
+	ks, ok := s.store.(APIKeyStatusStore)
+	if !ok {
+		InternalError(w, r, "Database store not available")
+		return
+	}
+
+	apiKey, err := ks.GetAPIKeyByID(r.Context(), uuid)
+	if err != nil || (!isOperator(r) && !tenantOwnsRow(r, apiKey.Tenant)) || !projectVisible(r, apiKey.Project.String) {
+		NotFoundError(w, r, "API key not found")
+		return
+	}
+	switch {
+	case apiKey.Enabled:
+		ConflictError(w, r, "API key is already enabled")
+		return
+	case apiKey.DisabledReason != keyDisabledUnused:
+		ConflictError(w, r, "Only keys disabled for being unused can be re-enabled; revoked keys cannot")
+		return
+	}
+	since := time.Now().Add(-s.keyIdlePolicy().ReenableGrace)
+	if !apiKey.DisabledAt.Valid || apiKey.DisabledAt.Time.Before(since) {
+		ConflictError(w, r, "The re-enable grace period has passed; create a new key")
+		return
+	}
+
+	reenabled, err := ks.ReenableAPIKey(r.Context(), uuid, keyDisabledUnused, since)
+	if err != nil {
+		s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeAPIKey, keyID, "", apiKeyToMap(apiKey), nil, nil, audit.StatusFailure, "Failed to re-enable key")
+		InternalError(w, r, "Failed to re-enable key")
+		return
+	}
+	if !reenabled {
+		ConflictError(w, r, "API key can no longer be re-enabled")
+		return
+	}
+	after, err := ks.GetAPIKeyByID(r.Context(), uuid)
+	if err != nil {
+		InternalError(w, r, "Failed to load key")
+		return
+	}
+	s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeAPIKey, keyID, "", apiKeyToMap(apiKey), apiKeyToMap(after), nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, apiKeyInfo(after))
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func addIdleTestKey(st *apiKeyMemoryStore, name string, role auth.Role, lastUsed time.Time) pgtype.UUID {
+	id := pgtype.UUID{Bytes: [16]byte{byte(len(st.apiKeys) + 1)}, Valid: true}
+	st.apiKeys = append(st.apiKeys, dbgen.ApiKey{
+		ID:         id,
+		Name:       name,
+		Role:       dbgen.ApiKeyRole(role),
+		Enabled:    true,
+		CreatedAt:  pgtype.Timestamptz{Time: lastUsed.Add(-time.Hour), Valid: true},
+		LastUsedAt: pgtype.Timestamptz{Time: lastUsed, Valid: true},
+		Tenant:     auth.DefaultTenant,
+	})
+	return id
+}
+
+func TestCheckIdleKeys_Flags(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "prod", "test-key")
+	sink := &recordingSink{}
+	srv.auditService = audit.NewService(sink, nil, nil, nil, 16)
+	t.Cleanup(func() { _ = srv.auditService.Close() })
+	srv.SetKeyIdlePolicy(KeyIdlePolicy{IdleAfter: 30 * 24 * time.Hour, ReenableGrace: 14 * 24 * time.Hour})
+	now := time.Now()
+	addIdleTestKey(st, "old-ci", auth.RoleAdmin, now.Add(-40*24*time.Hour))
+	addIdleTestKey(st, "active", auth.RoleAdmin, now.Add(-time.Hour))
+
+	acted, err := srv.CheckIdleKeys(context.Background(), now)
+	if err != nil || len(acted) != 1 || acted[0].Name != "old-ci" {
+		t.Fatalf("expected old-ci to be flagged, got %+v, %v", acted, err)
+	}
+	if !st.apiKeys[0].Enabled {
+		t.Error("expected a flagged key to stay enabled")
+	}
+	events := sink.waitForEvents(t, 1)
+	if events[0].Action != audit.ActionIdleDetected || events[0].Actor.Kind != audit.ActorKindSystem {
+		t.Errorf("expected a system idle_detected event, got %+v", events[0])
+	}
+
+	if acted, _ := srv.CheckIdleKeys(context.Background(), now.Add(time.Hour)); len(acted) != 0 {
+		t.Errorf("expected the key to be flagged once, got %+v", acted)
+	}
+}
+
+func TestCheckIdleKeys_DisablesAndReenables(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "prod", "test-key")
+	srv.SetKeyIdlePolicy(KeyIdlePolicy{IdleAfter: 30 * 24 * time.Hour, Disable: true, ReenableGrace: 14 * 24 * time.Hour})
+	now := time.Now()
+	ci := addIdleTestKey(st, "old-ci", auth.RoleAdmin, now.Add(-40*24*time.Hour))
+	addIdleTestKey(st, "only-superadmin", auth.RoleSuperadmin, now.Add(-40*24*time.Hour))
+
+	acted, err := srv.CheckIdleKeys(context.Background(), now)
+	if err != nil || len(acted) != 2 {
+		t.Fatalf("expected both keys to be acted on, got %+v, %v", acted, err)
+	}
+	if st.apiKeys[0].Enabled || st.apiKeys[0].DisabledReason != keyDisabledUnused {
+		t.Errorf("expected old-ci to be disabled as unused, got %+v", st.apiKeys[0])
+	}
+	if !st.apiKeys[1].Enabled {
+		t.Error("expected the last superadmin key to stay enabled")
+	}
+
+	handler := srv.Router()
+	enable := func(id pgtype.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/keys/"+formatUUID(id)+"/enable", nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := enable(ci)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var info keyInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if !info.Enabled || info.DisabledReason != "" || info.LastUsedAt == nil {
+		t.Errorf("expected a re-enabled key with a fresh use, got %+v", info)
+	}
+	if rr := enable(ci); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 for an enabled key, got %d", rr.Code)
+	}
+	// The re-enabled key has a new idle period; the superadmin key was already flagged.
+	if acted, _ := srv.CheckIdleKeys(context.Background(), now.Add(time.Hour)); len(acted) != 0 {
+		t.Errorf("expected nothing to act on, got %+v", acted)
+	}
+
+	// Past the grace period, and for revoked keys, re-enabling is refused.
+	st.apiKeys[0].Enabled = false
+	st.apiKeys[0].DisabledReason = keyDisabledUnused
+	st.apiKeys[0].DisabledAt = pgtype.Timestamptz{Time: now.Add(-15 * 24 * time.Hour), Valid: true}
+	if rr := enable(ci); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 past the grace period, got %d", rr.Code)
+	}
+	st.apiKeys[0].DisabledReason = keyDisabledRevoked
+	st.apiKeys[0].DisabledAt = pgtype.Timestamptz{Time: now, Valid: true}
+	if rr := enable(ci); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a revoked key, got %d", rr.Code)
+	}
+	if rr := enable(pgtype.UUID{Bytes: [16]byte{99}, Valid: true}); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown key, got %d", rr.Code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/audit"
@@ -22,6 +23,8 @@ type createKeyRequest struct {
 	Name      string  `json:"name"`
 	Role      string  `json:"role"`
 	ExpiresAt *string `json:"expires_at,omitempty"` // ISO 8601 format
+	Tenant    string  `json:"tenant,omitempty"`     // defaults to the caller's tenant
+	Project   string  `json:"project,omitempty"`    // restricts the key to a project, see projects.go
 }
 
 type createKeyResponse struct {
@@ -31,6 +34,8 @@ type createKeyResponse struct {
 	Role      string  `json:"role"`
 	CreatedAt string  `json:"created_at"`
 	ExpiresAt *string `json:"expires_at,omitempty"`
+	Tenant    string  `json:"tenant"`
+	Project   string  `json:"project,omitempty"`
 }
 
 type listKeysResponse struct {
@@ -45,20 +50,17 @@ type keyInfo struct {
 	CreatedAt  string  `json:"created_at"`
 	LastUsedAt *string `json:"last_used_at,omitempty"`
 	ExpiresAt  *string `json:"expires_at,omitempty"`
+	Tenant     string  `json:"tenant"`
+	Project    string  `json:"project,omitempty"`
+
+	DisabledAt     *string `json:"disabled_at,omitempty"`
+	DisabledReason string  `json:"disabled_reason,omitempty"` // "revoked" or "unused" (idle key policy)
 }
 
 // handleCreateAPIKey creates a new API key (superadmin only)
 func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
-	// Limit request body size to prevent memory exhaustion attacks
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MB limit
-
 	var req createKeyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		if err.Error() == "http: request body too large" {
-			RequestTooLargeError(w, r, "Request body too large")
-			return
-		}
-		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: expected fields 'name', 'role', and optional 'expires_at'")
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
 		return
 	}
 
@@ -73,6 +75,27 @@ func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 		validationErrors["role"] = "Role must be readonly, admin, or superadmin"
 	}
 
+	// Only the operator can create keys for other tenants (provisioning them)
+	callerTenant := auth.GetTenantFromContext(r.Context())
+	if req.Tenant == "" {
+		req.Tenant = callerTenant
+	}
+	switch {
+	case !auth.IsValidTenant(req.Tenant):
+		validationErrors["tenant"] = "Tenant must be 1-32 lowercase letters, digits or underscores"
+	case req.Tenant != callerTenant && callerTenant != auth.DefaultTenant:
+		validationErrors["tenant"] = "Keys can only be created for your own tenant"
+	}
+
+	// Keys of project-scoped callers are restricted to the same project
+	req.Project = strings.TrimSpace(req.Project)
+	if req.Project == "" {
+		req.Project = auth.GetProjectFromContext(r.Context())
+	} else if werr := s.checkProject(r, "project", req.Project); werr != nil {
+		werr.write(w, r)
+		return
+	}
+
 	// Parse expires_at if provided
 	var expiresAt pgtype.Timestamptz
 	if req.ExpiresAt != nil && *req.ExpiresAt != "" {
@@ -123,6 +146,8 @@ func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 		Enabled:   true,
 		ExpiresAt: expiresAt,
 		CreatedBy: createdBy,
+		Tenant:    req.Tenant,
+		Project:   pgtype.Text{String: req.Project, Valid: req.Project != ""},
 	})
 	if err != nil {
 		InternalError(w, r, "Failed to create key")
@@ -130,17 +155,7 @@ func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log the action (using new audit service)
-	afterState := map[string]any{
-		"id":         formatUUID(apiKey.ID),
-		"name":       apiKey.Name,
-		"role":       string(apiKey.Role),
-		"enabled":    apiKey.Enabled,
-		"created_at": formatTimestamp(apiKey.CreatedAt),
-	}
-	if apiKey.ExpiresAt.Valid {
-		afterState["expires_at"] = formatTimestamp(apiKey.ExpiresAt)
-	}
-	s.auditLog(r, audit.ActionCreated, audit.ResourceTypeAPIKey, formatUUID(apiKey.ID), "", nil, afterState, nil, audit.StatusSuccess, "")
+	s.auditLog(r, audit.ActionCreated, audit.ResourceTypeAPIKey, formatUUID(apiKey.ID), "", nil, apiKeyToMap(apiKey), nil, audit.StatusSuccess, "")
 
 	// Build response
 	resp := createKeyResponse{
@@ -150,6 +165,8 @@ func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 		Role:      string(apiKey.Role),
 		CreatedAt: formatTimestamp(apiKey.CreatedAt),
 		ExpiresAt: formatOptionalTimestamp(apiKey.ExpiresAt),
+		Tenant:    apiKey.Tenant,
+		Project:   apiKey.Project.String,
 	}
 
 	writeJSON(w, http.StatusOK, resp)
@@ -174,21 +191,32 @@ func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, key := range keys {
-		info := keyInfo{
-			ID:         formatUUID(key.ID),
-			Name:       key.Name,
-			Role:       string(key.Role),
-			Enabled:    key.Enabled,
-			CreatedAt:  formatTimestamp(key.CreatedAt),
-			LastUsedAt: formatOptionalTimestamp(key.LastUsedAt),
-			ExpiresAt:  formatOptionalTimestamp(key.ExpiresAt),
+		if !tenantOwnsRow(r, key.Tenant) || !projectVisible(r, key.Project.String) {
+			continue
 		}
-		resp.Keys = append(resp.Keys, info)
+		resp.Keys = append(resp.Keys, apiKeyInfo(key))
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// apiKeyInfo returns the listed form of a key, without its hash.
+func apiKeyInfo(key dbgen.ApiKey) keyInfo {
+	return keyInfo{
+		ID:             formatUUID(key.ID),
+		Name:           key.Name,
+		Role:           string(key.Role),
+		Enabled:        key.Enabled,
+		CreatedAt:      formatTimestamp(key.CreatedAt),
+		LastUsedAt:     formatOptionalTimestamp(key.LastUsedAt),
+		ExpiresAt:      formatOptionalTimestamp(key.ExpiresAt),
+		Tenant:         key.Tenant,
+		Project:        key.Project.String,
+		DisabledAt:     formatOptionalTimestamp(key.DisabledAt),
+		DisabledReason: key.DisabledReason,
+	}
+}
+
 // handleRevokeAPIKey revokes an API key (superadmin only)
 func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
 	keyID := chi.URLParam(r, "id")
@@ -214,7 +242,16 @@ func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
 
 	// Capture before state for audit
 	var beforeState map[string]any
-	if apiKey, err := pgStore.GetAPIKeyByID(r.Context(), uuid); err == nil {
+	apiKey, err := pgStore.GetAPIKeyByID(r.Context(), uuid)
+	if !isOperator(r) && (err != nil || !tenantOwnsRow(r, apiKey.Tenant)) {
+		NotFoundError(w, r, "API key not found")
+		return
+	}
+	if err == nil && !projectVisible(r, apiKey.Project.String) {
+		NotFoundError(w, r, "API key not found") // hidden from project-scoped keys like in the list
+		return
+	}
+	if err == nil {
 		beforeState = map[string]any{
 			"id":      formatUUID(apiKey.ID),
 			"name":    apiKey.Name,
@@ -238,6 +275,7 @@ func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
 			afterState[k] = v
 		}
 		afterState["enabled"] = false
+		afterState["disabled_reason"] = keyDisabledRevoked
 	}
 
 	// Log the action (using audit.ActionDeleted for revocation)
@@ -277,11 +315,15 @@ type auditLogInfo struct {
 	IPAddress    string                 `json:"ip_address"`
 	UserAgent    string                 `json:"user_agent"`
 	RequestID    string                 `json:"request_id,omitempty"`
+	TraceID      string                 `json:"trace_id,omitempty"`
 	APIKeyID     *string                `json:"api_key_id,omitempty"`
+	APIKeyName   string                 `json:"api_key_name,omitempty"` // at event time
+	APIKeyRole   string                 `json:"api_key_role,omitempty"` // at event time
 	UserEmail    string                 `json:"user_email,omitempty"`
 	Status       int32                  `json:"status"`
 	ErrorMessage string                 `json:"error_message,omitempty"`
 	Resource     string                 `json:"resource,omitempty"` // Legacy field
+	Tenant       string                 `json:"tenant,omitempty"`
 }
 
 // handleListAuditLogs lists audit logs with pagination and filtering (admin+)
@@ -354,6 +396,7 @@ func (s *Server) handleListAuditLogs(w http.ResponseWriter, r *http.Request) {
 		Action:       action,
 		StartDate:    startDate,
 		EndDate:      endDate,
+		Tenant:       tenantFilter(r),
 	}
 
 	logs, err := pgStore.ListAuditLogs(r.Context(), listParams)
@@ -369,6 +412,7 @@ func (s *Server) handleListAuditLogs(w http.ResponseWriter, r *http.Request) {
 		Action:       action,
 		StartDate:    startDate,
 		EndDate:      endDate,
+		Tenant:       tenantFilter(r),
 	}
 
 	totalCount, err := pgStore.CountAuditLogs(r.Context(), countParams)
@@ -395,81 +439,132 @@ func (s *Server) handleListAuditLogs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, log := range logs {
-		info := auditLogInfo{
-			ID:        formatUUID(log.ID),
-			Timestamp: formatTimestamp(log.Timestamp),
-			Action:    log.Action,
-			IPAddress: log.IpAddress,
-			UserAgent: log.UserAgent,
-			Status:    log.Status,
-		}
-		
-		// Set new fields
-		if log.ResourceType.Valid {
-			info.ResourceType = log.ResourceType.String
-		}
-		
-		if log.ResourceID.Valid {
-			info.ResourceID = log.ResourceID.String
-		}
-		
-		if log.ProjectID.Valid {
-			info.ProjectID = log.ProjectID.String
-		}
-		
-		if log.Environment.Valid {
-			info.Environment = log.Environment.String
-		}
-		
-		if log.RequestID.Valid {
-			info.RequestID = log.RequestID.String
-		}
-		
-		if log.UserEmail.Valid {
-			info.UserEmail = log.UserEmail.String
-		}
-		
-		if log.ErrorMessage.Valid {
-			info.ErrorMessage = log.ErrorMessage.String
+		resp.Logs = append(resp.Logs, auditLogToInfo(log))
+	}
+	resolveAuditActors(r.Context(), pgStore, resp.Logs)
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// auditLogToInfo converts a database audit log row into its API representation.
+func auditLogToInfo(log dbgen.AuditLog) auditLogInfo {
+	info := auditLogInfo{
+		ID:        formatUUID(log.ID),
+		Timestamp: formatTimestamp(log.Timestamp),
+		Action:    log.Action,
+		IPAddress: log.IpAddress,
+		UserAgent: log.UserAgent,
+		Status:    log.Status,
+		Tenant:    log.Tenant,
+	}
+	
+	// Set new fields
+	if log.ResourceType.Valid {
+		info.ResourceType = log.ResourceType.String
+	}
+	
+	if log.ResourceID.Valid {
+		info.ResourceID = log.ResourceID.String
+	}
+	
+	if log.ProjectID.Valid {
+		info.ProjectID = log.ProjectID.String
+	}
+	
+	if log.Environment.Valid {
+		info.Environment = log.Environment.String
+	}
+	
+	if log.RequestID.Valid {
+		info.RequestID = log.RequestID.String
+	}
+	
+	if log.UserEmail.Valid {
+		info.UserEmail = log.UserEmail.String
+	}
+	
+	if log.ErrorMessage.Valid {
+		info.ErrorMessage = log.ErrorMessage.String
+	}
+	
+	// Set legacy resource field for backward compatibility
+	if log.ResourceType.Valid && log.ResourceID.Valid {
+		info.Resource = log.ResourceType.String + "/" + log.ResourceID.String
+	} else if log.Resource.Valid {
+		info.Resource = log.Resource.String
+	}
+	
+	if log.ApiKeyID.Valid {
+		apiKeyIDStr := formatUUID(log.ApiKeyID)
+		info.APIKeyID = &apiKeyIDStr
+	}
+	setAuditDetails(&info, log.Details)
+	
+	// Parse JSONB fields
+	if len(log.BeforeState) > 0 {
+		var beforeState map[string]interface{}
+		if err := json.Unmarshal(log.BeforeState, &beforeState); err == nil {
+			info.BeforeState = beforeState
 		}
-		
-		// Set legacy resource field for backward compatibility
-		if log.ResourceType.Valid && log.ResourceID.Valid {
-			info.Resource = log.ResourceType.String + "/" + log.ResourceID.String
-		} else if log.Resource.Valid {
-			info.Resource = log.Resource.String
+	}
+	
+	if len(log.AfterState) > 0 {
+		var afterState map[string]interface{}
+		if err := json.Unmarshal(log.AfterState, &afterState); err == nil {
+			info.AfterState = afterState
 		}
-		
-		if log.ApiKeyID.Valid {
-			apiKeyIDStr := formatUUID(log.ApiKeyID)
-			info.APIKeyID = &apiKeyIDStr
+	}
+	
+	if len(log.Changes) > 0 {
+		var changes map[string]interface{}
+		if err := json.Unmarshal(log.Changes, &changes); err == nil {
+			info.Changes = changes
 		}
-		
-		// Parse JSONB fields
-		if len(log.BeforeState) > 0 {
-			var beforeState map[string]interface{}
-			if err := json.Unmarshal(log.BeforeState, &beforeState); err == nil {
-				info.BeforeState = beforeState
+	}
+	return info
+}
+
+// setAuditDetails fills the API key name and role and the trace ID recorded
+// with the event in the details column.
+func setAuditDetails(info *auditLogInfo, details []byte) {
+	var d struct {
+		Actor   audit.Actor `json:"actor"`
+		TraceID string      `json:"trace_id"`
+	}
+	if len(details) == 0 || json.Unmarshal(details, &d) != nil {
+		return
+	}
+	info.APIKeyName = d.Actor.Name
+	info.APIKeyRole = d.Actor.Role
+	info.TraceID = d.TraceID
+}
+
+// resolveAuditActors fills the API key name and role of entries recorded
+// before actors were stored with the event, from the key as it is now. Keys
+// deleted since are left unresolved.
+func resolveAuditActors(ctx context.Context, pgStore PostgresStoreInterface, logs []auditLogInfo) {
+	var keys map[string]dbgen.ApiKey
+	for i := range logs {
+		if logs[i].APIKeyID == nil || logs[i].APIKeyName != "" {
+			continue
+		}
+		if keys == nil {
+			list, err := pgStore.ListAPIKeys(ctx)
+			if err != nil {
+				return
 			}
-		}
-		
-		if len(log.AfterState) > 0 {
-			var afterState map[string]interface{}
-			if err := json.Unmarshal(log.AfterState, &afterState); err == nil {
-				info.AfterState = afterState
+			keys = make(map[string]dbgen.ApiKey, len(list))
+			for _, k := range list {
+				keys[formatUUID(k.ID)] = k
 			}
 		}
-		
-		if len(log.Changes) > 0 {
-			var changes map[string]interface{}
-			if err := json.Unmarshal(log.Changes, &changes); err == nil {
-				info.Changes = changes
+		if k, ok := keys[*logs[i].APIKeyID]; ok {
+			logs[i].APIKeyName = k.Name
+			if logs[i].APIKeyRole == "" {
+				logs[i].APIKeyRole = string(k.Role)
 			}
 		}
-		resp.Logs = append(resp.Logs, info)
 	}
-
-	writeJSON(w, http.StatusOK, resp)
 }
 
 // handleExportAuditLogs exports audit logs in various formats (admin+)
@@ -537,6 +632,7 @@ func (s *Server) handleExportAuditLogs(w http.ResponseWriter, r *http.Request) {
 		Action:       action,
 		StartDate:    startDate,
 		EndDate:      endDate,
+		Tenant:       tenantFilter(r),
 	}
 
 	logs, err := pgStore.ListAuditLogs(r.Context(), listParams)
@@ -555,6 +651,7 @@ func (s *Server) handleExportAuditLogs(w http.ResponseWriter, r *http.Request) {
 			IPAddress: log.IpAddress,
 			UserAgent: log.UserAgent,
 			Status:    log.Status,
+			Tenant:    log.Tenant,
 		}
 		
 		if log.ResourceType.Valid {
@@ -589,6 +686,7 @@ func (s *Server) handleExportAuditLogs(w http.ResponseWriter, r *http.Request) {
 			apiKeyIDStr := formatUUID(log.ApiKeyID)
 			info.APIKeyID = &apiKeyIDStr
 		}
+		setAuditDetails(&info, log.Details)
 		
 		// Don't parse JSONB fields for CSV (too complex), but include for JSON
 		if format != "csv" {
@@ -616,6 +714,7 @@ func (s *Server) handleExportAuditLogs(w http.ResponseWriter, r *http.Request) {
 		
 		auditLogs = append(auditLogs, info)
 	}
+	resolveAuditActors(r.Context(), pgStore, auditLogs)
 
 	// Export based on format
 	switch format {
@@ -641,6 +740,7 @@ func exportCSV(w http.ResponseWriter, logs []auditLogInfo) {
 		"ID", "Timestamp", "Action", "ResourceType", "ResourceID", 
 		"ProjectID", "Environment", "IPAddress", "UserAgent", "RequestID", 
 		"APIKeyID", "UserEmail", "Status", "ErrorMessage",
+		"APIKeyName", "APIKeyRole",
 	}); err != nil {
 		// Header already sent, can't return error response - log and return
 		return
@@ -668,6 +768,8 @@ func exportCSV(w http.ResponseWriter, logs []auditLogInfo) {
 			log.UserEmail,
 			fmt.Sprintf("%d", log.Status),
 			log.ErrorMessage,
+			log.APIKeyName,
+			log.APIKeyRole,
 		}); err != nil {
 			// Can't return error at this point, just stop writing
 			return
@@ -696,6 +798,28 @@ func exportJSONL(w http.ResponseWriter, logs []auditLogInfo) {
 
 // --- Helper functions ---
 
+// apiKeyToMap returns the audit state of a key. The key hash is left out.
+func apiKeyToMap(apiKey dbgen.ApiKey) map[string]any {
+	state := map[string]any{
+		"id":         formatUUID(apiKey.ID),
+		"name":       apiKey.Name,
+		"role":       string(apiKey.Role),
+		"enabled":    apiKey.Enabled,
+		"created_at": formatTimestamp(apiKey.CreatedAt),
+		"tenant":     apiKey.Tenant,
+	}
+	if apiKey.ExpiresAt.Valid {
+		state["expires_at"] = formatTimestamp(apiKey.ExpiresAt)
+	}
+	if apiKey.DisabledReason != "" {
+		state["disabled_reason"] = apiKey.DisabledReason
+	}
+	if apiKey.Project.Valid {
+		state["project"] = apiKey.Project.String
+	}
+	return state
+}
+
 // PostgresStoreInterface extends store.Store with postgres-specific methods
 type PostgresStoreInterface interface {
 	store.Store
@@ -704,6 +828,7 @@ type PostgresStoreInterface interface {
 	GetAPIKeyByID(ctx context.Context, id pgtype.UUID) (dbgen.ApiKey, error)
 	RevokeAPIKey(ctx context.Context, id pgtype.UUID) error
 	ListAuditLogs(ctx context.Context, params dbgen.ListAuditLogsParams) ([]dbgen.AuditLog, error)
+	GetAuditLog(ctx context.Context, id pgtype.UUID) (dbgen.AuditLog, error)
 	CountAuditLogs(ctx context.Context, params dbgen.CountAuditLogsParams) (int64, error)
 	CreateAuditLog(ctx context.Context, params dbgen.CreateAuditLogParams) error
 }
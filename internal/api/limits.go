@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// Limits cap how large a shared installation can grow. They are checked at
+// write time on every API write path (v1, v2, GraphQL, apply, environment
+// cloning) and rejected with 409 LIMIT_EXCEEDED. A zero limit is unlimited.
+//
+// Limits are soft: lowering one never touches existing data, and a flag that
+// is already over a per-flag limit can still be updated as long as the update
+// does not add to the excess. Git sync writes straight to the store and is not
+// limited. The only project is "default", so MaxFlags applies per environment.
+type Limits struct {
+	MaxFlags        int // flags per environment
+	MaxRulesPerFlag int // targeting rules per flag
+	MaxVariants     int // variants per flag
+	MaxWebhooks     int // webhook endpoints
+}
+
+// SetLimits replaces the write limits (see Limits). The default is unlimited.
+func (s *Server) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// limitWriteError reports that field would exceed limit.
+func limitWriteError(field, message string, limit int) *flagWriteError {
+	return &flagWriteError{
+		status:  http.StatusConflict,
+		code:    ErrCodeLimitExceeded,
+		message: message,
+		fields:  map[string]string{field: fmt.Sprintf("limit is %d", limit)},
+	}
+}
+
+// checkFlagLimits checks a flag write against the flag count, rule and
+// variant limits. existing is the flag being replaced, or nil on create.
+func (s *Server) checkFlagLimits(ctx context.Context, env string, existing *store.Flag, params store.UpsertParams) *flagWriteError {
+	l := s.limits
+	if existing == nil && l.MaxFlags > 0 {
+		flags, err := s.store.GetAllFlags(ctx, env)
+		if err != nil {
+			return internalWriteError("Failed to count flags")
+		}
+		if len(flags) >= l.MaxFlags {
+			return limitWriteError("key", fmt.Sprintf("Environment %q already has the maximum number of flags", env), l.MaxFlags)
+		}
+	}
+
+	var oldRules, oldVariants int
+	if existing != nil {
+		oldRules, oldVariants = len(existing.TargetingRules), len(existing.Variants)
+	}
+	if exceedsLimit(len(params.TargetingRules), oldRules, l.MaxRulesPerFlag) {
+		return limitWriteError("targeting_rules", "Flag has too many targeting rules", l.MaxRulesPerFlag)
+	}
+	if exceedsLimit(len(params.Variants), oldVariants, l.MaxVariants) {
+		return limitWriteError("variants", "Flag has too many variants", l.MaxVariants)
+	}
+	return nil
+}
+
+// exceedsLimit reports whether growing from old to n items breaks limit.
+// Staying at or shrinking from an over-limit count is allowed.
+func exceedsLimit(n, old, limit int) bool {
+	return limit > 0 && n > limit && n > old
+}
+
+// checkWebhookLimit checks that one more webhook endpoint may be created.
+func (s *Server) checkWebhookLimit(ctx context.Context, queries *dbgen.Queries) *flagWriteError {
+	if s.limits.MaxWebhooks <= 0 {
+		return nil
+	}
+	webhooks, err := queries.ListWebhooks(ctx)
+	if err != nil {
+		return internalWriteError("Failed to count webhooks")
+	}
+	if len(webhooks) >= s.limits.MaxWebhooks {
+		return limitWriteError("url", "The maximum number of webhooks has been reached", s.limits.MaxWebhooks)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func decodeErrorResponse(t *testing.T, body []byte) ErrorResponse {
+	t.Helper()
+	var resp ErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	return resp
+}
+
+func TestLimits_MaxFlagsPerEnvironment(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetLimits(Limits{MaxFlags: 1})
+	handler := srv.Router()
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"a","enabled":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("first create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"b","enabled":true}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("second create: expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Code != ErrCodeLimitExceeded || resp.Fields["key"] != "limit is 1" {
+		t.Errorf("unexpected error response: %+v", resp)
+	}
+
+	// Updating an existing flag and creating in another environment still work.
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"a","enabled":false}`); rr.Code != http.StatusOK {
+		t.Errorf("update: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"b","enabled":true,"env":"staging"}`); rr.Code != http.StatusOK {
+		t.Errorf("other env: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestLimits_RulesAndVariants(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetLimits(Limits{MaxRulesPerFlag: 1, MaxVariants: 2})
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"a","enabled":true,"targeting_rules":[
+		{"id":"r1","conditions":[{"property":"plan","operator":"eq","value":"pro"}],"distribution":{"on":100}},
+		{"id":"r2","conditions":[{"property":"plan","operator":"eq","value":"free"}],"distribution":{"on":100}}]}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("rules: expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields["targeting_rules"] != "limit is 1" {
+		t.Errorf("rules: unexpected fields: %v", resp.Fields)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"a","enabled":true,"variants":[
+		{"name":"a","weight":34},{"name":"b","weight":33},{"name":"c","weight":33}]}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("variants: expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields["variants"] != "limit is 2" {
+		t.Errorf("variants: unexpected fields: %v", resp.Fields)
+	}
+}
+
+func TestLimits_SoftForExistingFlags(t *testing.T) {
+	st := store.NewMemoryStore()
+	err := st.UpsertFlag(context.Background(), store.UpsertParams{
+		Key: "a", Enabled: true, Env: "prod",
+		Variants: []store.Variant{{Name: "a", Weight: 34}, {Name: "b", Weight: 33}, {Name: "c", Weight: 33}},
+	})
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	srv := NewServer(st, "prod", "test-key")
+	srv.SetLimits(Limits{MaxVariants: 2})
+	handler := srv.Router()
+
+	// A flag already over the limit can be updated without growing.
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"a","enabled":false,"variants":[
+		{"name":"a","weight":34},{"name":"b","weight":33},{"name":"c","weight":33}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update at existing size: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"a","enabled":false,"variants":[
+		{"name":"a","weight":25},{"name":"b","weight":25},{"name":"c","weight":25},{"name":"d","weight":25}]}`)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("growing update: expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExceedsLimit(t *testing.T) {
+	tests := []struct {
+		n, old, limit int
+		want          bool
+	}{
+		{n: 5, old: 0, limit: 0, want: false},
+		{n: 2, old: 0, limit: 2, want: false},
+		{n: 3, old: 0, limit: 2, want: true},
+		{n: 3, old: 3, limit: 2, want: false},
+		{n: 2, old: 3, limit: 2, want: false},
+		{n: 4, old: 3, limit: 2, want: true},
+	}
+	for _, tt := range tests {
+		if got := exceedsLimit(tt.n, tt.old, tt.limit); got != tt.want {
+			t.Errorf("exceedsLimit(%d, %d, %d) = %v, want %v", tt.n, tt.old, tt.limit, got, tt.want)
+		}
+	}
+}
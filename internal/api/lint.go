@@ -0,0 +1,328 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/targeting"
+)
+
+// Flag definitions can be checked before they are written:
+//
+//	POST /v1/lint   (admin+)
+//
+// The body is {"flags": [...]}, each flag in the shape of POST /v1/flags (env
+// defaults to the server's). Nothing is read from or written to the store.
+// Each flag gets the field errors a write would reject it with, and warnings
+// about definitions that are valid but unlikely to do what was meant:
+//   - rule_never_matches: a targeting rule whose conditions contradict each
+//     other (eq two different values, eq and neq the same value, eq a value
+//     outside an in list, in an empty list, an empty numeric range), or an
+//     expression that reads no user attribute and is false, so a 100%
+//     rollout reaches no one
+//   - condition_always_true: a condition or expression that every user
+//     matches. Rule conditions have no regex operator; contains "" is the
+//     match-everything pattern, as is an expression that reads no user
+//     attribute and is true
+//   - zero_weight_variant: a variant with weight 0, which is never served
+//   - unused_config_key: a flag config key that is never served because every
+//     variant a user can get has a config of its own, which replaces the flag
+//     config. on_error is read when evaluation fails and is not reported
+//
+// The response is always 200; valid is false if any flag has errors. The
+// checks are conservative: a warning is only raised when it holds for every
+// user.
+
+const (
+	lintRuleNeverMatches    = "rule_never_matches"
+	lintConditionAlwaysTrue = "condition_always_true"
+	lintZeroWeightVariant   = "zero_weight_variant"
+	lintUnusedConfigKey     = "unused_config_key"
+)
+
+type lintRequest struct {
+	Flags []upsertRequest `json:"flags"`
+}
+
+type lintWarning struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type lintResult struct {
+	Key      string            `json:"key"`
+	Env      string            `json:"env"`
+	Valid    bool              `json:"valid"`
+	Errors   map[string]string `json:"errors,omitempty"`
+	Warnings []lintWarning     `json:"warnings"`
+}
+
+type lintResponse struct {
+	Valid   bool         `json:"valid"`
+	Results []lintResult `json:"results"`
+}
+
+func (s *Server) handleLint(w http.ResponseWriter, r *http.Request) {
+	var req lintRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	switch n := len(req.Flags); {
+	case n == 0:
+		ValidationError(w, r, "Missing required fields", map[string]string{"flags": "At least one flag is required"})
+		return
+	case n > maxImportFlags:
+		ValidationError(w, r, "Too many flags", map[string]string{"flags": fmt.Sprintf("At most %d flags can be linted at once", maxImportFlags)})
+		return
+	}
+
+	resp := lintResponse{Valid: true, Results: make([]lintResult, len(req.Flags))}
+	for i := range req.Flags {
+		resp.Results[i] = s.lintFlag(&req.Flags[i])
+		resp.Valid = resp.Valid && resp.Results[i].Valid
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// lintFlag validates f like a write and collects its warnings.
+func (s *Server) lintFlag(f *upsertRequest) lintResult {
+	f.Key = strings.TrimSpace(f.Key)
+	env := s.env
+	if f.Env != nil && strings.TrimSpace(*f.Env) != "" {
+		env = strings.TrimSpace(*f.Env)
+	}
+	res := lintResult{Key: f.Key, Env: env, Warnings: []lintWarning{}}
+
+	errs := map[string]string{}
+	if field, message, ok := validateTargetingRules(f.TargetingRules); !ok {
+		errs[field] = message
+	}
+	if werr := validateUpsertRequest(f, env); werr != nil {
+		for field, message := range werr.fields {
+			errs[field] = message
+		}
+	}
+	if len(errs) > 0 {
+		res.Errors = errs
+	}
+	res.Valid = len(errs) == 0
+
+	res.Warnings = append(res.Warnings, lintExpression(f.Expression)...)
+	for i, rule := range f.TargetingRules {
+		res.Warnings = append(res.Warnings, lintRule(fmt.Sprintf("targeting_rules[%d]", i), rule)...)
+	}
+	for i, v := range f.Variants {
+		if v.Weight == 0 {
+			res.Warnings = append(res.Warnings, lintWarning{
+				Code:    lintZeroWeightVariant,
+				Field:   fmt.Sprintf("variants[%d].weight", i),
+				Message: fmt.Sprintf("variant %q has weight 0 and is never served", v.Name),
+			})
+		}
+	}
+	res.Warnings = append(res.Warnings, lintUnusedConfig(f)...)
+	return res
+}
+
+// lintExpression reports an expression whose result is the same for every
+// user: one that reads no attribute evaluates to a constant.
+func lintExpression(expression *string) []lintWarning {
+	if expression == nil || strings.TrimSpace(*expression) == "" {
+		return nil
+	}
+	var doc any
+	if err := json.Unmarshal([]byte(*expression), &doc); err != nil || readsContext(doc) {
+		return nil
+	}
+	match, err := targeting.Evaluate(*expression, nil)
+	if err != nil {
+		return nil // reported by validation
+	}
+	if match {
+		return []lintWarning{{Code: lintConditionAlwaysTrue, Field: "expression", Message: "expression reads no user attribute and matches every user"}}
+	}
+	return []lintWarning{{Code: lintRuleNeverMatches, Field: "expression", Message: "expression reads no user attribute and matches no user, so the flag is served to no one"}}
+}
+
+// readsContext reports whether a decoded JSON Logic expression reads the
+// evaluation context.
+func readsContext(node any) bool {
+	switch n := node.(type) {
+	case map[string]any:
+		for op, args := range n {
+			if op == "var" || op == "missing" || op == "missing_some" || readsContext(args) {
+				return true
+			}
+		}
+	case []any:
+		for _, arg := range n {
+			if readsContext(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lintRule reports the conditions of rule, at field, that match no user or
+// every user. Conditions are ANDed, so two on the same property can rule each
+// other out.
+func lintRule(field string, rule rules.Rule) []lintWarning {
+	var warnings []lintWarning
+	byProperty := map[string][]rules.Condition{}
+	var properties []string
+	for j, c := range rule.Conditions {
+		if c.Operator == rules.OpContains && c.Value == "" {
+			warnings = append(warnings, lintWarning{
+				Code:    lintConditionAlwaysTrue,
+				Field:   fmt.Sprintf("%s.conditions[%d]", field, j),
+				Message: fmt.Sprintf("contains \"\" matches every user with a %q attribute", c.Property),
+			})
+		}
+		if _, ok := byProperty[c.Property]; !ok {
+			properties = append(properties, c.Property)
+		}
+		byProperty[c.Property] = append(byProperty[c.Property], c)
+	}
+	for _, property := range properties {
+		if reason := contradiction(byProperty[property]); reason != "" {
+			warnings = append(warnings, lintWarning{
+				Code:    lintRuleNeverMatches,
+				Field:   field,
+				Message: fmt.Sprintf("rule %q never matches: %s %s", rule.ID, property, reason),
+			})
+		}
+	}
+	return warnings
+}
+
+// contradiction describes why no value satisfies all conds, which are on the
+// same property, or returns "" if some value may.
+func contradiction(conds []rules.Condition) string {
+	var eq []any
+	lower, upper := math.Inf(-1), math.Inf(1)
+	lowerStrict, upperStrict := false, false
+	for _, c := range conds {
+		switch c.Operator {
+		case rules.OpEq:
+			eq = append(eq, c.Value)
+		case rules.OpIn:
+			if list, ok := c.Value.([]any); ok && len(list) == 0 {
+				return "is in an empty list"
+			}
+		case rules.OpGt, rules.OpGte:
+			if n, ok := lintNumber(c.Value); ok && (n > lower || n == lower && c.Operator == rules.OpGt) {
+				lower, lowerStrict = n, c.Operator == rules.OpGt
+			}
+		case rules.OpLt, rules.OpLte:
+			if n, ok := lintNumber(c.Value); ok && (n < upper || n == upper && c.Operator == rules.OpLt) {
+				upper, upperStrict = n, c.Operator == rules.OpLt
+			}
+		}
+	}
+	if lower > upper || lower == upper && (lowerStrict || upperStrict) {
+		return "has an empty numeric range"
+	}
+	for _, v := range eq[min(1, len(eq)):] {
+		if !sameValue(eq[0], v) {
+			return fmt.Sprintf("equals both %v and %v", eq[0], v)
+		}
+	}
+	if len(eq) == 0 {
+		return ""
+	}
+	for _, c := range conds {
+		switch c.Operator {
+		case rules.OpNeq:
+			if sameValue(eq[0], c.Value) {
+				return fmt.Sprintf("both equals and does not equal %v", eq[0])
+			}
+		case rules.OpIn:
+			if list, ok := c.Value.([]any); ok && !containsValue(list, eq[0]) {
+				return fmt.Sprintf("equals %v, which is not in its in list", eq[0])
+			}
+		}
+	}
+	return ""
+}
+
+// lintUnusedConfig reports the flag config keys no user is served: those of
+// a flag whose variants, and the variants its rules distribute to, all have
+// their own config.
+func lintUnusedConfig(f *upsertRequest) []lintWarning {
+	if len(f.Config) == 0 || len(f.Variants) == 0 {
+		return nil
+	}
+	configured := map[string]bool{}
+	for _, v := range f.Variants {
+		if v.Config == nil {
+			return nil
+		}
+		configured[v.Name] = true
+	}
+	for _, rule := range f.TargetingRules {
+		for variant := range rule.Distribution {
+			if !configured[variant] {
+				return nil
+			}
+		}
+	}
+	keys := make([]string, 0, len(f.Config))
+	for key := range f.Config {
+		if key != rules.OnErrorConfigKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	warnings := make([]lintWarning, len(keys))
+	for i, key := range keys {
+		warnings[i] = lintWarning{
+			Code:    lintUnusedConfigKey,
+			Field:   "config." + key,
+			Message: fmt.Sprintf("config key %q is never served: every variant has its own config", key),
+		}
+	}
+	return warnings
+}
+
+// lintNumber converts a decoded JSON number.
+func lintNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// sameValue compares condition values the way the rules engine does: strings
+// exactly, numbers numerically.
+func sameValue(a, b any) bool {
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	x, ok := lintNumber(a)
+	y, ok2 := lintNumber(b)
+	return ok && ok2 && x == y
+}
+
+func containsValue(list []any, v any) bool {
+	for _, item := range list {
+		if sameValue(item, v) {
+			return true
+		}
+	}
+	return false
+}
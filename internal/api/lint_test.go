@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func lintFlags(t *testing.T, body string) lintResponse {
+	t.Helper()
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/lint", body)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp lintResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func lintCodes(res lintResult) map[string]string {
+	codes := map[string]string{}
+	for _, w := range res.Warnings {
+		codes[w.Field] = w.Code
+	}
+	return codes
+}
+
+func TestLint_Warnings(t *testing.T) {
+	tests := map[string]struct {
+		flag  string
+		field string
+		code  string
+	}{
+		"contradicting eq": {
+			flag:  `"targeting_rules":[{"id":"eu","conditions":[{"property":"country","operator":"eq","value":"DE"},{"property":"country","operator":"eq","value":"FR"}],"distribution":{"on":100}}]`,
+			field: "targeting_rules[0]", code: lintRuleNeverMatches,
+		},
+		"empty range": {
+			flag:  `"targeting_rules":[{"id":"age","conditions":[{"property":"age","operator":"gt","value":30},{"property":"age","operator":"lte","value":30}],"distribution":{"on":100}}]`,
+			field: "targeting_rules[0]", code: lintRuleNeverMatches,
+		},
+		"eq outside in list": {
+			flag:  `"targeting_rules":[{"id":"plan","conditions":[{"property":"plan","operator":"eq","value":"free"},{"property":"plan","operator":"in","value":["pro","team"]}],"distribution":{"on":100}}]`,
+			field: "targeting_rules[0]", code: lintRuleNeverMatches,
+		},
+		"constant false expression": {
+			flag:  `"expression":"{\"==\":[1,2]}"`,
+			field: "expression", code: lintRuleNeverMatches,
+		},
+		"constant true expression": {
+			flag:  `"expression":"true"`,
+			field: "expression", code: lintConditionAlwaysTrue,
+		},
+		"contains empty string": {
+			flag:  `"targeting_rules":[{"id":"all","conditions":[{"property":"email","operator":"contains","value":""}],"distribution":{"on":100}}]`,
+			field: "targeting_rules[0].conditions[0]", code: lintConditionAlwaysTrue,
+		},
+		"zero weight variant": {
+			flag:  `"variants":[{"name":"a","weight":100},{"name":"b","weight":0}]`,
+			field: "variants[1].weight", code: lintZeroWeightVariant,
+		},
+		"shadowed flag config": {
+			flag:  `"config":{"color":"red","on_error":true},"variants":[{"name":"a","weight":50,"config":{"color":"blue"}},{"name":"b","weight":50,"config":{}}]`,
+			field: "config.color", code: lintUnusedConfigKey,
+		},
+	}
+	for name, tt := range tests {
+		resp := lintFlags(t, `{"flags":[{"key":"checkout","enabled":true,"rollout":100,`+tt.flag+`}]}`)
+		if !resp.Valid || len(resp.Results) != 1 {
+			t.Errorf("%s: expected one valid result, got %+v", name, resp)
+			continue
+		}
+		codes := lintCodes(resp.Results[0])
+		if codes[tt.field] != tt.code {
+			t.Errorf("%s: expected %s at %s, got %+v", name, tt.code, tt.field, resp.Results[0].Warnings)
+		}
+		if _, ok := codes["config.on_error"]; ok {
+			t.Errorf("%s: on_error must not be reported", name)
+		}
+	}
+}
+
+func TestLint_NoFalsePositives(t *testing.T) {
+	resp := lintFlags(t, `{"flags":[{"key":"checkout","enabled":true,"rollout":100,
+		"expression":"{\"==\":[{\"var\":\"plan\"},\"pro\"]}",
+		"config":{"color":"red"},
+		"variants":[{"name":"a","weight":50,"config":{"color":"blue"}},{"name":"b","weight":50}],
+		"targeting_rules":[{"id":"adults","conditions":[{"property":"age","operator":"gte","value":18},{"property":"age","operator":"lte","value":18},{"property":"country","operator":"in","value":["DE","FR"]}],"distribution":{"a":100}}]}]}`)
+	if !resp.Valid || len(resp.Results[0].Warnings) != 0 {
+		t.Errorf("expected no warnings, got %+v", resp.Results[0])
+	}
+}
+
+func TestLint_ReportsErrorsAndWritesNothing(t *testing.T) {
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/lint", `{"flags":[
+		{"key":"ok","enabled":true,"rollout":100},
+		{"key":"bad","rollout":150,"targeting_rules":[{"id":"","conditions":[],"distribution":{}}]}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp lintResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Valid || !resp.Results[0].Valid || resp.Results[1].Valid {
+		t.Fatalf("expected only the second flag to be invalid, got %+v", resp)
+	}
+	if errs := resp.Results[1].Errors; errs["rollout"] == "" || errs["targeting_rules[0]"] == "" {
+		t.Errorf("expected rollout and targeting rule errors, got %+v", errs)
+	}
+	if flags, _ := st.GetAllFlags(t.Context(), "prod"); len(flags) != 0 {
+		t.Errorf("lint must not write flags, got %d", len(flags))
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/lint", `{"flags":[]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("empty body: expected 400, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+)
+
+// SetAuthLockout enables brute-force protection on authenticated endpoints
+// (see auth.Lockout): after cfg.Threshold invalid tokens from one IP, or for
+// one token prefix, further attempts get 429 with Retry-After until the
+// lockout expires. Each lockout is audited as "auth_locked_out". A threshold
+// <= 0 disables it (the default).
+func (s *Server) SetAuthLockout(cfg auth.LockoutConfig) {
+	if cfg.Threshold <= 0 {
+		s.auth.SetLockout(nil, nil)
+		return
+	}
+	s.auth.SetLockout(auth.NewLockout(cfg), s.auditAuthLockout)
+}
+
+// auditAuthLockout records a triggered lockout, the suspicious pattern worth
+// a look: many invalid tokens from one IP or for one key.
+func (s *Server) auditAuthLockout(r *http.Request, event auth.LockoutEvent) {
+	if s.auditService == nil {
+		return // No audit service available
+	}
+	s.auditService.Log(audit.NewEventBuilder(r).
+		ForResource(audit.ResourceTypeSystem, event.Subject.Scope+":"+event.Subject.Value).
+		WithAction(audit.ActionAuthLockedOut).
+		Failure(fmt.Sprintf("%d failed authentication attempts; locked out for %s (lockout #%d)", event.Failures, event.LockedFor, event.Lockouts)).
+		Build())
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+)
+
+const (
+	// longPollDefaultWait is how long GET /v1/flags/updates waits when the
+	// client does not say.
+	longPollDefaultWait = 30 * time.Second
+	// longPollMaxWait caps the wait so proxies with idle timeouts of a minute
+	// or more don't cut the request off.
+	longPollMaxWait = 60 * time.Second
+)
+
+// handleFlagUpdates handles GET /v1/flags/updates?etag=...&wait=30s, a
+// long-polling fallback for clients that cannot hold an SSE connection. If
+// the snapshot's ETag differs from etag (or If-None-Match) the snapshot is
+// returned at once; otherwise the request blocks until the snapshot changes,
+// returning it, or the wait expires, returning 304 Not Modified.
+func (s *Server) handleFlagUpdates(w http.ResponseWriter, r *http.Request) {
+	schema, ok := requireSnapshotSchema(w, r)
+	if !ok {
+		return
+	}
+	etag := r.URL.Query().Get("etag")
+	if etag == "" {
+		etag = r.Header.Get("If-None-Match")
+	}
+	wait := longPollDefaultWait
+	if v := r.URL.Query().Get("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			ValidationError(w, r, "Invalid wait", map[string]string{
+				"wait": "must be a non-negative duration such as 30s",
+			})
+			return
+		}
+		wait = min(d, longPollMaxWait)
+	}
+
+	// Subscribe before comparing ETags so an update between the two is not
+	// missed.
+	env := s.requestEnvironment(r)
+	updates, unsubscribe := s.subscribeEnv(env)
+	defer unsubscribe()
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	if snap := s.snapshotForEnv(env); snap.ETag != etag {
+		writeFlagUpdate(w, schema, snap)
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-updates:
+			if !ok {
+				return
+			}
+			// Updates may repeat the current ETag; keep waiting then.
+			if snap := s.snapshotForEnv(env); snap.ETag != etag {
+				writeFlagUpdate(w, schema, snap)
+				return
+			}
+		case <-timer.C:
+			snap := s.snapshotForEnv(env)
+			w.Header().Set("ETag", snap.ETag)
+			setSnapshotHeaders(w, snap)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeFlagUpdate writes snap like GET /v1/flags/snapshot does.
+func writeFlagUpdate(w http.ResponseWriter, schema snapshotSchema, snap *snapshot.Snapshot) {
+	w.Header().Set("ETag", snap.ETag)
+	setSnapshotHeaders(w, snap)
+	writeSnapshot(w, schema, snap)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestFlagUpdates(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "poll_a", Enabled: true, Rollout: 100, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+	etag := snapshot.Load().ETag
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	t.Run("stale etag returns at once", func(t *testing.T) {
+		rr := get("/v1/flags/updates?etag=old&wait=10s")
+		if rr.Code != http.StatusOK || rr.Header().Get("ETag") != etag {
+			t.Fatalf("got %d etag %q, want 200 %q", rr.Code, rr.Header().Get("ETag"), etag)
+		}
+	})
+
+	t.Run("no change returns 304 after wait", func(t *testing.T) {
+		start := time.Now()
+		rr := get("/v1/flags/updates?etag=" + etag + "&wait=50ms")
+		if rr.Code != http.StatusNotModified {
+			t.Fatalf("got %d, want 304", rr.Code)
+		}
+		if waited := time.Since(start); waited < 50*time.Millisecond {
+			t.Errorf("returned after %v, want the full wait", waited)
+		}
+	})
+
+	t.Run("change during wait returns new snapshot", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- get("/v1/flags/updates?etag=" + etag + "&wait=5s") }()
+
+		time.Sleep(50 * time.Millisecond)
+		st.UpsertFlag(ctx, store.UpsertParams{Key: "poll_b", Enabled: true, Rollout: 100, Env: "prod"})
+		srv.RebuildSnapshot(ctx, "prod")
+
+		select {
+		case rr := <-done:
+			if rr.Code != http.StatusOK {
+				t.Fatalf("got %d, want 200", rr.Code)
+			}
+			if got := rr.Header().Get("ETag"); got == etag || got != snapshot.Load().ETag {
+				t.Errorf("ETag = %q, want the rebuilt snapshot's", got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("long poll did not return after the snapshot changed")
+		}
+	})
+
+	t.Run("invalid wait", func(t *testing.T) {
+		if rr := get("/v1/flags/updates?etag=x&wait=soon"); rr.Code != http.StatusBadRequest {
+			t.Fatalf("got %d, want 400", rr.Code)
+		}
+	})
+}
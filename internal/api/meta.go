@@ -0,0 +1,205 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/engine"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/rules"
+)
+
+// Metadata endpoints for rule builders such as the admin UI, so conditions
+// can be picked from dropdowns instead of typed as free text:
+//
+//	GET /v1/meta/operators                 operators and the values they expect (admin+)
+//	GET /v1/meta/attributes?env=<env>      context attributes seen in evaluations (admin+)
+//
+// Attributes are observed, not declared: 1 in attributeSampleRate
+// evaluation requests (evaluate and OFREP endpoints) records the names and
+// JSON types of its context attributes, per environment. The data lives in
+// memory, per server instance; "since" in the response tells how far back it
+// goes.
+
+const (
+	// attributeSampleRate samples 1 in every n evaluation requests.
+	attributeSampleRate = 10
+
+	// maxObservedAttributes caps the attribute names kept per environment,
+	// so clients sending arbitrary keys cannot grow the tracker unbounded.
+	maxObservedAttributes = 500
+)
+
+type operatorsResponse struct {
+	Operators []rules.OperatorInfo `json:"operators"`
+}
+
+type attributesResponse struct {
+	Env        string              `json:"env"`
+	Since      time.Time           `json:"since"`
+	Attributes []observedAttribute `json:"attributes"`
+}
+
+type observedAttribute struct {
+	Name     string    `json:"name"`
+	Types    []string  `json:"types"`
+	Seen     int       `json:"seen"` // sampled requests that sent the attribute
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// handleListOperators handles GET /v1/meta/operators.
+func (s *Server) handleListOperators(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, operatorsResponse{Operators: rules.Operators()})
+}
+
+// handleListAttributes handles GET /v1/meta/attributes.
+func (s *Server) handleListAttributes(w http.ResponseWriter, r *http.Request) {
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = s.env
+	}
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+	writeJSON(w, http.StatusOK, attributesResponse{
+		Env:        env,
+		Since:      s.attributes.startedAt,
+		Attributes: s.attributes.list(env),
+	})
+}
+
+// attributeTracker records the context attributes sent to evaluation
+// endpoints, per environment.
+type attributeTracker struct {
+	sampler   evaluationSampler
+	startedAt time.Time
+	now       func() time.Time
+
+	mu   sync.Mutex
+	envs map[string]map[string]*attributeStats
+}
+
+type attributeStats struct {
+	types    map[string]bool
+	seen     int
+	lastSeen time.Time
+}
+
+func newAttributeTracker() *attributeTracker {
+	return &attributeTracker{
+		sampler:   evaluationSampler{n: attributeSampleRate},
+		startedAt: time.Now().UTC(),
+		now:       time.Now,
+		envs:      make(map[string]map[string]*attributeStats),
+	}
+}
+
+// observeUserContext records the attributes of a sampled /v1/evaluate or
+// OFREP request, named as targeting rules refer to them.
+func (s *Server) observeUserContext(r *http.Request, ctx *engine.UserContext) {
+	if ctx == nil || !s.attributes.sampler.sample() {
+		return
+	}
+	s.attributes.record(s.requestEnvironment(r), userContextAttributes(ctx))
+}
+
+// observeEvaluationContext records the attributes of a sampled
+// /v1/flags/evaluate request.
+func (s *Server) observeEvaluationContext(r *http.Request, ctx evaluation.Context) {
+	if !s.attributes.sampler.sample() {
+		return
+	}
+	s.attributes.record(s.requestEnvironment(r), evaluationContextAttributes(ctx))
+}
+
+// userContextAttributes returns the attributes of ctx, named as targeting
+// rules refer to them.
+func userContextAttributes(ctx *engine.UserContext) map[string]any {
+	attrs := make(map[string]any, len(ctx.Properties)+4)
+	for name, value := range map[string]string{"id": ctx.ID, "email": ctx.Email, "country": ctx.Country, "plan": ctx.Plan} {
+		if value != "" {
+			attrs[name] = value
+		}
+	}
+	for name, value := range ctx.Properties {
+		attrs[name] = value
+	}
+	return attrs
+}
+
+// evaluationContextAttributes returns the attributes of ctx, including the
+// user ID as "id".
+func evaluationContextAttributes(ctx evaluation.Context) map[string]any {
+	attrs := make(map[string]any, len(ctx.Attributes)+1)
+	for name, value := range ctx.Attributes {
+		attrs[name] = value
+	}
+	if ctx.UserID != "" {
+		attrs["id"] = ctx.UserID
+	}
+	return attrs
+}
+
+func (t *attributeTracker) record(env string, attrs map[string]any) {
+	now := t.now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	observed := t.envs[env]
+	if observed == nil {
+		observed = make(map[string]*attributeStats)
+		t.envs[env] = observed
+	}
+	for name, value := range attrs {
+		stats := observed[name]
+		if stats == nil {
+			if len(observed) >= maxObservedAttributes {
+				continue
+			}
+			stats = &attributeStats{types: make(map[string]bool, 1)}
+			observed[name] = stats
+		}
+		stats.types[attributeType(value)] = true
+		stats.seen++
+		stats.lastSeen = now
+	}
+}
+
+// list returns the attributes observed in env, sorted by name.
+func (t *attributeTracker) list(env string) []observedAttribute {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]observedAttribute, 0, len(t.envs[env]))
+	for name, stats := range t.envs[env] {
+		types := make([]string, 0, len(stats.types))
+		for typ := range stats.types {
+			types = append(types, typ)
+		}
+		sort.Strings(types)
+		out = append(out, observedAttribute{Name: name, Types: types, Seen: stats.seen, LastSeen: stats.lastSeen})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// attributeType names the JSON type of a decoded context value.
+func attributeType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int64, int32:
+		return "number"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	default:
+		return "unknown"
+	}
+}
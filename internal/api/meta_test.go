@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestMetaOperators(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/meta/operators", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp operatorsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Operators) != len(rules.Operators()) || resp.Operators[0].Operator != rules.OpEq || resp.Operators[0].ValueType != rules.ValueScalar {
+		t.Errorf("unexpected operators: %+v", resp.Operators)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/meta/operators", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key, got %d", rr.Code)
+	}
+}
+
+func TestMetaAttributes(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.attributes.sampler.n = 1 // record every request
+	handler := srv.Router()
+
+	for _, tc := range []struct{ path, body string }{
+		{"/v1/evaluate", `{"context":{"id":"u1","country":"DE","properties":{"age":31,"beta":true}}}`},
+		{"/v1/flags/evaluate", `{"user":{"id":"u2","attributes":{"age":"unknown","tags":["a"]}}}`},
+		{"/ofrep/v1/evaluate/flags", `{"context":{"targetingKey":"u3","plan":"pro"}}`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tc.path, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/meta/attributes", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp attributesResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]observedAttribute{}
+	var names []string
+	for _, attr := range resp.Attributes {
+		got[attr.Name] = attr
+		names = append(names, attr.Name)
+	}
+	want := []string{"age", "beta", "country", "id", "plan", "tags"}
+	if resp.Env != "prod" || len(names) != len(want) {
+		t.Fatalf("expected attributes %v in prod, got %v (%s)", want, names, resp.Env)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected attributes %v sorted by name, got %v", want, names)
+		}
+	}
+	if age := got["age"]; len(age.Types) != 2 || age.Types[0] != "number" || age.Types[1] != "string" || age.Seen != 2 {
+		t.Errorf("unexpected age attribute: %+v", age)
+	}
+	if id := got["id"]; id.Seen != 3 || id.Types[0] != "string" {
+		t.Errorf("unexpected id attribute: %+v", id)
+	}
+	if tags := got["tags"]; tags.Types[0] != "list" {
+		t.Errorf("unexpected tags attribute: %+v", tags)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/meta/attributes?env=staging", "")
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || len(resp.Attributes) != 0 {
+		t.Errorf("expected no attributes for staging, got %+v (%v)", resp.Attributes, err)
+	}
+}
@@ -0,0 +1,321 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// Applications report named metrics (conversions, errors, ...) for their
+// users, which experiment results and guardrails (see guardrails.go) consume:
+//
+//	POST /v1/events/metrics                    report metric events (client)
+//	GET  /v1/flags/{key}/results?env=&metric=  experiment results of a flag (admin+)
+//
+// Results attribute each user who reported any metric to what the flag
+// currently serves them, evaluated from the user ID and the attributes sent
+// with their latest event, and compare per variant how many of them reported
+// the metric. Like exposures, events are kept in memory per server instance.
+//
+// Only events sent with an SDK key or an admin API key count towards
+// guardrails: anonymous events are stored for results, but cannot roll back
+// or pause a flag.
+
+const (
+	// maxMetricEventBatch caps the number of events accepted per request.
+	maxMetricEventBatch = 1000
+
+	// maxStoredMetricEvents bounds the events kept per environment; older
+	// events are dropped first.
+	maxStoredMetricEvents = 100000
+
+	// metricEventRetention is how long events count in results.
+	metricEventRetention = 7 * 24 * time.Hour
+
+	metricNameMessage = "must be 1-100 letters, digits, '_', '.', ':' or '-'"
+)
+
+var metricNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,100}$`)
+
+type metricEvent struct {
+	Name       string         `json:"name"`
+	UserID     string         `json:"user_id"`
+	Value      *float64       `json:"value,omitempty"`      // defaults to 1
+	Attributes map[string]any `json:"attributes,omitempty"` // evaluation context of the user
+	Timestamp  time.Time      `json:"timestamp,omitempty"`  // defaults to the time received
+}
+
+type metricEventRequest struct {
+	Events []metricEvent `json:"events"`
+}
+
+type metricEventResponse struct {
+	Accepted int `json:"accepted"`
+	Ignored  int `json:"ignored"`
+}
+
+type variantResult struct {
+	Variant        string  `json:"variant"`         // variant name, or "on"/"off" for flags without variants
+	Users          int     `json:"users"`           // users who reported any metric
+	Conversions    int     `json:"conversions"`     // users who reported the metric
+	ConversionRate float64 `json:"conversion_rate"` // conversions / users
+	Events         int     `json:"events"`          // events of the metric
+	Value          float64 `json:"value"`           // sum of the metric's values
+}
+
+type experimentResultsResponse struct {
+	Flag     string          `json:"flag"`
+	Env      string          `json:"env"`
+	Metric   string          `json:"metric"`
+	Variants []variantResult `json:"variants"` // ordered by variant
+}
+
+type storedMetricEvent struct {
+	name       string
+	userID     string
+	value      float64
+	attributes map[string]any
+	at         time.Time
+}
+
+// metricEventLog keeps the metric events received per environment.
+type metricEventLog struct {
+	mu     sync.Mutex
+	events map[string][]storedMetricEvent // env -> events, in order received
+	now    func() time.Time
+}
+
+func newMetricEventLog() *metricEventLog {
+	return &metricEventLog{events: make(map[string][]storedMetricEvent), now: time.Now}
+}
+
+// add stores events for env, dropping the oldest beyond the retention and
+// the per-environment limit.
+func (l *metricEventLog) add(env string, events []storedMetricEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stored := append(l.events[env], events...)
+	cutoff := l.now().Add(-metricEventRetention)
+	i := 0
+	for i < len(stored) && stored[i].at.Before(cutoff) {
+		i++
+	}
+	if over := len(stored) - i - maxStoredMetricEvents; over > 0 {
+		i += over
+	}
+	l.events[env] = stored[i:]
+}
+
+// list returns the events of env within the retention.
+func (l *metricEventLog) list(env string) []storedMetricEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := l.now().Add(-metricEventRetention)
+	result := make([]storedMetricEvent, 0, len(l.events[env]))
+	for _, e := range l.events[env] {
+		if !e.at.Before(cutoff) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// handleIngestMetricEvents handles POST /v1/events/metrics.
+//
+// Events without a valid name or user ID, or older than the retention, are
+// ignored. Accepted events are stored and, if the request is authenticated
+// (see guardrailSource), fed to the guardrails of the request's environment.
+func (s *Server) handleIngestMetricEvents(w http.ResponseWriter, r *http.Request) {
+	var req metricEventRequest
+	if !decodeClientJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	if len(req.Events) > maxMetricEventBatch {
+		ValidationError(w, r, "Too many events", map[string]string{
+			"events": fmt.Sprintf("at most %d events per request", maxMetricEventBatch),
+		})
+		return
+	}
+
+	now := s.metricEvents.now()
+	cutoff := now.Add(-metricEventRetention)
+	var resp metricEventResponse
+	accepted := make([]storedMetricEvent, 0, len(req.Events))
+	for _, e := range req.Events {
+		if !metricNamePattern.MatchString(e.Name) || strings.TrimSpace(e.UserID) == "" {
+			resp.Ignored++
+			continue
+		}
+		stored := storedMetricEvent{name: e.Name, userID: e.UserID, value: 1, attributes: e.Attributes, at: now}
+		if e.Value != nil {
+			stored.value = *e.Value
+		}
+		if !e.Timestamp.IsZero() && e.Timestamp.Before(now) {
+			if e.Timestamp.Before(cutoff) {
+				resp.Ignored++
+				continue
+			}
+			stored.at = e.Timestamp
+		}
+		accepted = append(accepted, stored)
+		resp.Accepted++
+	}
+
+	env := s.requestEnvironment(r)
+	s.metricEvents.add(env, accepted)
+	if s.guardrailSource(r) {
+		s.feedGuardrails(r.Context(), env, accepted)
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// guardrailSource reports whether the metric events of r may trip
+// guardrails: they must come with an SDK key (checked by resolveSDKEnv) or an
+// API key with at least the admin role.
+func (s *Server) guardrailSource(r *http.Request) bool {
+	if _, ok := requestSDKKey(r); ok {
+		return true
+	}
+	header := r.Header.Get("Authorization")
+	if auth.ExtractBearerToken(header) == "" {
+		return false
+	}
+	result := s.auth.Authenticate(r.Context(), header)
+	return result.Authenticated && auth.HasPermission(result.Role, auth.RoleAdmin)
+}
+
+// feedGuardrails counts events against the guardrails of env that have
+// request and error metrics, for the users their flag currently enables.
+// Failures are logged; the events are stored either way.
+func (s *Server) feedGuardrails(ctx context.Context, env string, events []storedMetricEvent) {
+	gs, ok := s.store.(store.GuardrailStore)
+	if !ok || len(events) == 0 {
+		return
+	}
+	guardrails, err := s.envGuardrails(ctx, gs, env)
+	if err != nil {
+		log.Printf("[guardrail] failed to load guardrails for env %s: %v", env, err)
+		return
+	}
+	snap := s.snapshotForEnv(env)
+	for i := range guardrails {
+		g := &guardrails[i]
+		view, ok := snap.Flags[g.Key]
+		if g.RequestMetric == "" || !ok {
+			continue
+		}
+		var requests, errs int64
+		for _, e := range events {
+			if e.name != g.RequestMetric && e.name != g.ErrorMetric {
+				continue
+			}
+			evalCtx := evaluation.Context{UserID: e.userID, Attributes: e.attributes}
+			if !evaluation.EvaluateFlag(view, evalCtx, snap.RolloutSalt).Enabled {
+				continue
+			}
+			if e.name == g.RequestMetric {
+				requests++
+			}
+			if e.name == g.ErrorMetric {
+				errs++
+			}
+		}
+		if requests == 0 && errs == 0 {
+			continue
+		}
+		if _, _, err := s.observeGuardrail(ctx, gs, g, view.Enabled, view.Rollout, requests, errs); err != nil {
+			log.Printf("[guardrail] failed to trip guardrail flag=%s env=%s: %v", g.Key, env, err)
+		}
+	}
+}
+
+// handleExperimentResults handles GET /v1/flags/{key}/results.
+func (s *Server) handleExperimentResults(w http.ResponseWriter, r *http.Request) {
+	metric := r.URL.Query().Get("metric")
+	if !metricNamePattern.MatchString(metric) {
+		ValidationError(w, r, "Invalid metric", map[string]string{"metric": metricNameMessage})
+		return
+	}
+	env, flag, ok := s.routeFlag(w, r)
+	if !ok {
+		return
+	}
+	snap := s.snapshotForEnv(env)
+	view, ok := snap.Flags[flag.Key]
+	if !ok {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, experimentResultsResponse{
+		Flag:     flag.Key,
+		Env:      env,
+		Metric:   metric,
+		Variants: experimentResults(view, snap.RolloutSalt, s.metricEvents.list(env), metric),
+	})
+}
+
+// experimentResults groups the users of events by what flag serves them and
+// sums metric per group.
+func experimentResults(flag snapshot.FlagView, salt string, events []storedMetricEvent, metric string) []variantResult {
+	attributes := make(map[string]map[string]any) // user -> latest attributes
+	for _, e := range events {
+		if _, seen := attributes[e.userID]; !seen || e.attributes != nil {
+			attributes[e.userID] = e.attributes
+		}
+	}
+
+	groups := make(map[string]*variantResult)
+	userGroup := make(map[string]*variantResult, len(attributes))
+	for userID, attrs := range attributes {
+		res := evaluation.EvaluateFlag(flag, evaluation.Context{UserID: userID, Attributes: attrs}, salt)
+		label := "off"
+		if res.Enabled {
+			label = "on"
+			if res.Variant != "" {
+				label = res.Variant
+			}
+		}
+		group := groups[label]
+		if group == nil {
+			group = &variantResult{Variant: label}
+			groups[label] = group
+		}
+		group.Users++
+		userGroup[userID] = group
+	}
+
+	converted := make(map[string]bool)
+	for _, e := range events {
+		if e.name != metric {
+			continue
+		}
+		group := userGroup[e.userID]
+		group.Events++
+		group.Value += e.value
+		if !converted[e.userID] {
+			converted[e.userID] = true
+			group.Conversions++
+		}
+	}
+
+	results := make([]variantResult, 0, len(groups))
+	for _, group := range groups {
+		group.ConversionRate = float64(group.Conversions) / float64(group.Users)
+		results = append(results, *group)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Variant < results[j].Variant })
+	return results
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func postMetricEvents(t *testing.T, handler http.Handler, events ...string) metricEventResponse {
+	t.Helper()
+	return postMetricEventsAs(t, handler, "", events...)
+}
+
+// postMetricEventsAs posts events with token as bearer token, if not empty.
+func postMetricEventsAs(t *testing.T, handler http.Handler, token string, events ...string) metricEventResponse {
+	t.Helper()
+	body := `{"events":[` + strings.Join(events, ",") + `]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/events/metrics", bytes.NewBufferString(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp metricEventResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestExperimentResults(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	premium := `{"==": [{"var": "plan"}, "premium"]}`
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Expression: &premium, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	resp := postMetricEvents(t, handler,
+		`{"name":"visit","user_id":"u1","attributes":{"plan":"premium"}}`,
+		`{"name":"visit","user_id":"u2","attributes":{"plan":"premium"}}`,
+		`{"name":"visit","user_id":"u3","attributes":{"plan":"free"}}`,
+		`{"name":"purchase","user_id":"u1","value":20}`,
+		`{"name":"purchase","user_id":"u1","value":30}`,
+		`{"name":"purchase","user_id":"u3","value":5}`,
+		`{"name":"bad name!","user_id":"u1"}`,
+		`{"name":"visit"}`,
+	)
+	if resp.Accepted != 6 || resp.Ignored != 2 {
+		t.Fatalf("expected 6 accepted and 2 ignored, got %+v", resp)
+	}
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/results", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a metric, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/results?metric=purchase", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var results experimentResultsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	want := []variantResult{
+		{Variant: "off", Users: 1, Conversions: 1, ConversionRate: 1, Events: 1, Value: 5},
+		{Variant: "on", Users: 2, Conversions: 1, ConversionRate: 0.5, Events: 2, Value: 50},
+	}
+	if fmt.Sprint(results.Variants) != fmt.Sprint(want) {
+		t.Errorf("expected %+v, got %+v", want, results.Variants)
+	}
+}
+
+func TestIngestMetricEvents_FeedsGuardrail(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/guardrail",
+		`{"max_error_rate":0.1,"min_requests":4,"action":"pause","request_metric":"checkout.request"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 with only a request metric, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/guardrail",
+		`{"max_error_rate":0.1,"min_requests":4,"action":"pause","request_metric":"checkout.request","error_metric":"checkout.error"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var events []string
+	for i := 0; i < 4; i++ {
+		events = append(events, fmt.Sprintf(`{"name":"checkout.request","user_id":"u%d"}`, i))
+	}
+	postMetricEventsAs(t, handler, "test-key", events...)
+	if g, _ := st.GetGuardrail(ctx, "checkout", "prod"); g.TrippedAt != nil {
+		t.Fatal("expected no trip without errors")
+	}
+
+	// Anonymous events are stored but never trip a guardrail.
+	for i := 0; i < 4; i++ {
+		if resp := postMetricEvents(t, handler, `{"name":"checkout.error","user_id":"u1"}`); resp.Accepted != 1 {
+			t.Fatalf("expected the anonymous event to be accepted, got %+v", resp)
+		}
+	}
+	postMetricEventsAs(t, handler, "not-a-key", `{"name":"checkout.error","user_id":"u1"}`)
+	if g, _ := st.GetGuardrail(ctx, "checkout", "prod"); g.TrippedAt != nil {
+		t.Fatal("expected anonymous events not to trip the guardrail")
+	}
+
+	postMetricEventsAs(t, handler, "test-key", `{"name":"checkout.error","user_id":"u1"}`)
+	g, _ := st.GetGuardrail(ctx, "checkout", "prod")
+	if g.TrippedAt == nil {
+		t.Fatal("expected the reported errors to trip the guardrail")
+	}
+	if flag, _ := st.GetFlagByKey(ctx, "checkout", "prod"); flag.Rollout != 100 {
+		t.Errorf("expected the pause action to keep the rollout, got %d", flag.Rollout)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+)
+
+// Every successful flag change (create, update, delete, restore, from any
+// write path or background job) is summarized in one log line, whether or
+// not an audit service is configured, so deployments without the audit
+// database still have basic change tracking:
+//
+//	[flag] action=updated key=checkout env=prod actor=api_key:1a2b3c4d changed=enabled,rollout etag=W/"..." request_id=host/abc-000001
+//
+// changed lists the fields that differ between the before and after states
+// ("none" for deletes and no-op writes), etag is the environment's snapshot
+// ETag after the change, and note carries the reason of system changes such
+// as guardrail rollbacks. Failed writes and non-flag resources are not logged.
+
+// logFlagMutation logs the summary line of event if it records a successful
+// flag change. It is called for every audit event, before the event is
+// handed to the audit service.
+func (s *Server) logFlagMutation(event audit.AuditEvent) {
+	if event.ResourceType != audit.ResourceTypeFlag || event.Status != audit.StatusSuccess {
+		return
+	}
+	switch event.Action {
+	case audit.ActionCreated, audit.ActionUpdated, audit.ActionDeleted, audit.ActionRestored:
+	default:
+		return
+	}
+	var env string
+	if event.Environment != nil {
+		env = *event.Environment
+	}
+	log.Print(formatFlagMutation(event, env, s.snapshotForEnv(env).ETag))
+}
+
+// formatFlagMutation returns the summary line of a flag change event.
+func formatFlagMutation(event audit.AuditEvent, env, etag string) string {
+	changed := "none"
+	if len(event.Changes) > 0 {
+		changed = strings.Join(slices.Sorted(maps.Keys(event.Changes)), ",")
+	}
+	line := fmt.Sprintf("[flag] action=%s key=%s env=%s actor=%s changed=%s etag=%s",
+		event.Action, event.ResourceID, env, event.Actor.Display, changed, etag)
+	if event.RequestID != "" {
+		line += " request_id=" + event.RequestID
+	}
+	if event.Note != nil {
+		line += " note=" + strconv.Quote(*event.Note)
+	}
+	return line
+}
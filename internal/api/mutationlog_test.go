@@ -0,0 +1,66 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestFlagMutationLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key") // no audit service
+	handler := srv.Router()
+
+	mutationLines := func() []string {
+		var lines []string
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if strings.Contains(line, "[flag] ") {
+				lines = append(lines, line)
+			}
+		}
+		buf.Reset()
+		return lines
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":false,"rollout":100,"env":"prod"}`); rr.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	lines := mutationLines()
+	if len(lines) != 1 || !strings.Contains(lines[0], "action=created key=checkout env=prod actor=system") ||
+		!strings.Contains(lines[0], "enabled,") || !strings.Contains(lines[0], " etag="+srv.snapshotForEnv("prod").ETag) {
+		t.Fatalf("unexpected create log: %q", lines)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":50,"env":"prod"}`); rr.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	lines = mutationLines()
+	if len(lines) != 1 || !strings.Contains(lines[0], "action=updated key=checkout env=prod") ||
+		!strings.Contains(lines[0], "changed=enabled,rollout etag=") || !strings.Contains(lines[0], " etag="+srv.snapshotForEnv("prod").ETag) {
+		t.Fatalf("unexpected update log: %q", lines)
+	}
+
+	// Failed writes are not logged.
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":500,"env":"prod"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid update: expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if lines := mutationLines(); len(lines) != 0 {
+		t.Fatalf("expected no log for a failed write, got %q", lines)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v1/flags?key=checkout&env=prod", ""); rr.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	lines = mutationLines()
+	if len(lines) != 1 || !strings.Contains(lines[0], "action=deleted key=checkout env=prod actor=system changed=none") {
+		t.Fatalf("unexpected delete log: %q", lines)
+	}
+}
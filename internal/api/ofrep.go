@@ -0,0 +1,264 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/engine"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/go-chi/chi/v5"
+)
+
+// OpenFeature Remote Evaluation Protocol (OFREP) endpoints, so any
+// OFREP-compatible OpenFeature provider can evaluate flags without a bespoke
+// client:
+//
+//	POST /ofrep/v1/evaluate/flags/{key}   single flag
+//	POST /ofrep/v1/evaluate/flags         all flags (supports If-None-Match)
+//
+// Evaluation uses the same engine as POST /v1/evaluate. The OFREP context's
+// targetingKey becomes the user ID; email, country and plan map onto the
+// matching context fields and every other attribute is a custom property.
+//
+// Values: a flag serves its resolved config. A config of the form
+// {"value": x} serves x, which lets flags return booleans, strings and
+// numbers (this is also how `flagship import` converts typed variations).
+// Flags without config serve their enabled state as a boolean.
+//
+// Errors use the OFREP error body ({key, errorCode, errorDetails}) rather
+// than the API's ErrorResponse, as required by the protocol.
+
+// OFREP reasons and error codes.
+const (
+	ofrepReasonStatic         = "STATIC"
+	ofrepReasonTargetingMatch = "TARGETING_MATCH"
+	ofrepReasonSplit          = "SPLIT"
+	ofrepReasonDisabled       = "DISABLED"
+	ofrepReasonError          = "ERROR"
+
+	ofrepErrParse        = "PARSE_ERROR"
+	ofrepErrFlagNotFound = "FLAG_NOT_FOUND"
+	ofrepErrInvalidCtx   = "INVALID_CONTEXT"
+)
+
+type ofrepRequest struct {
+	Context map[string]any `json:"context"`
+}
+
+// ofrepEvaluation is one successful flag evaluation.
+type ofrepEvaluation struct {
+	Key      string         `json:"key"`
+	Value    any            `json:"value"`
+	Reason   string         `json:"reason"`
+	Variant  string         `json:"variant,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	enabled bool  // for insights
+	err     error // evaluation failure behind ofrepReasonError, see evalerrors.go
+}
+
+// ofrepError is the OFREP error body for a single flag.
+type ofrepError struct {
+	Key          string `json:"key,omitempty"`
+	ErrorCode    string `json:"errorCode"`
+	ErrorDetails string `json:"errorDetails,omitempty"`
+}
+
+type ofrepBulkResponse struct {
+	Flags []ofrepEvaluation `json:"flags"`
+}
+
+// handleOFREPEvaluateFlag handles POST /ofrep/v1/evaluate/flags/{key}.
+func (s *Server) handleOFREPEvaluateFlag(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	ctx, errBody := decodeOFREPContext(w, r)
+	if errBody != nil {
+		errBody.Key = key
+		writeJSON(w, http.StatusBadRequest, errBody)
+		return
+	}
+	if violations := s.checkUserContextLimits(ctx); violations != nil {
+		writeJSON(w, http.StatusBadRequest, ofrepError{Key: key, ErrorCode: ofrepErrInvalidCtx, ErrorDetails: contextViolationDetails(violations)})
+		return
+	}
+	if violations := s.checkUserContext(r, ctx); violations != nil {
+		writeJSON(w, http.StatusBadRequest, ofrepError{Key: key, ErrorCode: ofrepErrInvalidCtx, ErrorDetails: contextViolationDetails(violations)})
+		return
+	}
+	s.observeUserContext(r, ctx)
+
+	snap := s.requestSnapshot(r)
+	flag, exists := snap.Flags[key]
+	if !exists {
+		writeJSON(w, http.StatusNotFound, ofrepError{
+			Key:          key,
+			ErrorCode:    ofrepErrFlagNotFound,
+			ErrorDetails: "flag '" + key + "' not found",
+		})
+		return
+	}
+	overrides := s.userOverrides.forUser(s.requestEnvironment(r), ctx.ID)
+	eval := evaluateOFREPFlag(flag, ctx, overrides)
+	s.evalTracker.touch(s.requestEnvironment(r), key)
+	s.recordOFREPResults(r, []ofrepEvaluation{eval})
+	s.compareShadows(r, ctx.ID, 1, func(int) shadowOutcome { return eval.outcome() },
+		func(flag snapshot.FlagView) shadowOutcome { return evaluateOFREPFlag(flag, ctx, overrides).outcome() })
+	telemetry.ObserveEvaluatedFlags(telemetry.EvalHandlerOFREP, 1)
+	s.auditEvaluation(r, []string{key}, 1)
+	writeJSON(w, http.StatusOK, eval)
+}
+
+// handleOFREPEvaluateFlags handles POST /ofrep/v1/evaluate/flags.
+// The ETag is the snapshot ETag: providers re-fetch on context changes
+// themselves, so an unchanged snapshot means unchanged results.
+func (s *Server) handleOFREPEvaluateFlags(w http.ResponseWriter, r *http.Request) {
+	ctx, errBody := decodeOFREPContext(w, r)
+	if errBody != nil {
+		writeJSON(w, http.StatusBadRequest, errBody)
+		return
+	}
+	if violations := s.checkUserContextLimits(ctx); violations != nil {
+		writeJSON(w, http.StatusBadRequest, ofrepError{ErrorCode: ofrepErrInvalidCtx, ErrorDetails: contextViolationDetails(violations)})
+		return
+	}
+	if violations := s.checkUserContext(r, ctx); violations != nil {
+		writeJSON(w, http.StatusBadRequest, ofrepError{ErrorCode: ofrepErrInvalidCtx, ErrorDetails: contextViolationDetails(violations)})
+		return
+	}
+	s.observeUserContext(r, ctx)
+
+	snap := s.requestSnapshot(r)
+
+	w.Header().Set("ETag", snap.ETag)
+	if inm := r.Header.Get("If-None-Match"); inm != "" && inm == snap.ETag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	keys := make([]string, 0, len(snap.Flags))
+	for key := range snap.Flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	overrides := s.userOverrides.forUser(s.requestEnvironment(r), ctx.ID)
+	resp := ofrepBulkResponse{Flags: make([]ofrepEvaluation, 0, len(keys))}
+	for _, key := range keys {
+		resp.Flags = append(resp.Flags, evaluateOFREPFlag(snap.Flags[key], ctx, overrides))
+	}
+	s.evalTracker.touch(s.requestEnvironment(r), keys...)
+	s.recordOFREPResults(r, resp.Flags)
+	s.compareShadows(r, ctx.ID, len(resp.Flags), func(i int) shadowOutcome { return resp.Flags[i].outcome() },
+		func(flag snapshot.FlagView) shadowOutcome { return evaluateOFREPFlag(flag, ctx, overrides).outcome() })
+	telemetry.ObserveEvaluatedFlags(telemetry.EvalHandlerOFREP, len(resp.Flags))
+	s.auditEvaluation(r, nil, len(resp.Flags))
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// decodeOFREPContext parses the request body into an engine context.
+// An empty body is treated as an empty context.
+func decodeOFREPContext(w http.ResponseWriter, r *http.Request) (*engine.UserContext, *ofrepError) {
+	var req ofrepRequest
+	if err := readJSONBody(w, r, &req, maxJSONBodySize, true); err != nil && !errors.Is(err, io.EOF) {
+		return nil, &ofrepError{ErrorCode: ofrepErrParse, ErrorDetails: "invalid JSON: " + err.Error()}
+	}
+	ctx, err := ofrepUserContext(req.Context)
+	if err != nil {
+		return nil, &ofrepError{ErrorCode: ofrepErrInvalidCtx, ErrorDetails: err.Error()}
+	}
+	return ctx, nil
+}
+
+// ofrepUserContext maps an OpenFeature evaluation context onto the engine context.
+func ofrepUserContext(attrs map[string]any) (*engine.UserContext, error) {
+	ctx := &engine.UserContext{}
+	for name, value := range attrs {
+		var field *string
+		switch name {
+		case "targetingKey":
+			field = &ctx.ID
+		case "email":
+			field = &ctx.Email
+		case "country":
+			field = &ctx.Country
+		case "plan":
+			field = &ctx.Plan
+		}
+		if field == nil {
+			if ctx.Properties == nil {
+				ctx.Properties = make(map[string]any)
+			}
+			ctx.Properties[name] = value
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("context attribute '" + name + "' must be a string")
+		}
+		*field = strings.TrimSpace(s)
+	}
+	return ctx, nil
+}
+
+func evaluateOFREPFlag(flag snapshot.FlagView, ctx *engine.UserContext, overrides map[string]evaluation.Override) ofrepEvaluation {
+	var result engine.EvaluationResult
+	var enabled bool
+	if o, ok := overrides[flag.Key]; ok && flag.Enabled {
+		result, enabled = overrideEngineResult(flag, ctx, o)
+	} else {
+		result = engine.Evaluate(toStoreFlag(flag), ctx)
+		enabled = result.Reason != string(engine.ReasonDisabled)
+	}
+	if result.Err != nil {
+		enabled = rules.OnErrorEnabled(flag.Config)
+	}
+	eval := ofrepEvaluation{
+		Key:     flag.Key,
+		Value:   ofrepValue(result.Value, enabled),
+		Variant: result.Variant,
+		enabled: enabled,
+		err:     result.Err,
+	}
+
+	switch engine.Reason(result.Reason) {
+	case engine.ReasonError:
+		eval.Reason = ofrepReasonError
+	case engine.ReasonDisabled:
+		eval.Reason = ofrepReasonDisabled
+	case engine.ReasonTargetingMatch:
+		eval.Reason = ofrepReasonTargetingMatch
+		eval.Metadata = map[string]any{"matchedRule": result.MatchedRule}
+	case engine.ReasonOverride:
+		eval.Reason = ofrepReasonTargetingMatch
+		eval.Metadata = map[string]any{"override": true}
+	default:
+		eval.Reason = ofrepReasonStatic
+		if len(flag.Variants) > 1 {
+			eval.Reason = ofrepReasonSplit
+		}
+	}
+	return eval
+}
+
+// ofrepValue unwraps {"value": x} configs and falls back to the enabled
+// state for flags without config.
+func ofrepValue(value any, enabled bool) any {
+	switch v := value.(type) {
+	case nil:
+		return enabled
+	case map[string]any:
+		if len(v) == 0 {
+			return enabled
+		}
+		if inner, ok := v["value"]; ok && len(v) == 1 {
+			return inner
+		}
+	}
+	return value
+}
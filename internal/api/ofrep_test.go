@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func setupOFREPSnapshot() {
+	setupEvaluationSnapshot([]store.Flag{
+		{
+			Key:     "checkout",
+			Enabled: true,
+			Variants: []store.Variant{
+				{Name: "on", Weight: 100, Config: map[string]any{"value": true}},
+				{Name: "off", Weight: 0, Config: map[string]any{"value": false}},
+			},
+			TargetingRules: []rules.Rule{{
+				ID:           "germany",
+				Conditions:   []rules.Condition{{Property: "country", Operator: rules.OpEq, Value: "DE"}},
+				Distribution: map[string]int{"off": 100},
+			}},
+		},
+		{Key: "banner", Enabled: true, Config: map[string]any{"text": "hi"}},
+		{Key: "legacy", Enabled: false},
+	})
+}
+
+func doOFREP(t *testing.T, handler http.Handler, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestOFREP_EvaluateFlag(t *testing.T) {
+	setupOFREPSnapshot()
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	tests := []struct {
+		name       string
+		key        string
+		body       string
+		wantStatus int
+		wantValue  any
+		wantReason string
+	}{
+		{"typed value from variant", "checkout", `{"context":{"targetingKey":"u1"}}`, http.StatusOK, true, "SPLIT"},
+		{"targeting match", "checkout", `{"context":{"targetingKey":"u1","country":"DE"}}`, http.StatusOK, false, "TARGETING_MATCH"},
+		{"object config", "banner", `{"context":{"targetingKey":"u1"}}`, http.StatusOK, map[string]any{"text": "hi"}, "STATIC"},
+		{"disabled flag serves false", "legacy", `{"context":{}}`, http.StatusOK, false, "DISABLED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := doOFREP(t, handler, "/ofrep/v1/evaluate/flags/"+tt.key, tt.body, nil)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			var got ofrepEvaluation
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got.Value)
+			wantJSON, _ := json.Marshal(tt.wantValue)
+			if string(gotJSON) != string(wantJSON) || got.Reason != tt.wantReason || got.Key != tt.key {
+				t.Errorf("got %+v, want value=%v reason=%s", got, tt.wantValue, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestOFREP_Errors(t *testing.T) {
+	setupOFREPSnapshot()
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	tests := []struct {
+		name       string
+		key        string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown flag", "missing", `{"context":{}}`, http.StatusNotFound, ofrepErrFlagNotFound},
+		{"invalid JSON", "checkout", `{`, http.StatusBadRequest, ofrepErrParse},
+		{"non-string targeting key", "checkout", `{"context":{"targetingKey":42}}`, http.StatusBadRequest, ofrepErrInvalidCtx},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := doOFREP(t, handler, "/ofrep/v1/evaluate/flags/"+tt.key, tt.body, nil)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, rr.Code)
+			}
+			var got ofrepError
+			_ = json.NewDecoder(rr.Body).Decode(&got)
+			if got.ErrorCode != tt.wantCode || got.Key != tt.key {
+				t.Errorf("unexpected error body: %+v", got)
+			}
+		})
+	}
+}
+
+func TestOFREP_BulkEvaluate(t *testing.T) {
+	setupOFREPSnapshot()
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doOFREP(t, handler, "/ofrep/v1/evaluate/flags", `{"context":{"targetingKey":"u1"}}`, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Error("expected ETag header")
+	}
+	var resp ofrepBulkResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Flags) != 3 || resp.Flags[0].Key != "banner" {
+		t.Errorf("expected 3 flags sorted by key, got %+v", resp.Flags)
+	}
+
+	rr = doOFREP(t, handler, "/ofrep/v1/evaluate/flags", `{"context":{"targetingKey":"u1"}}`, map[string]string{"If-None-Match": etag})
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for matching ETag, got %d", rr.Code)
+	}
+}
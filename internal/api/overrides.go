@@ -0,0 +1,335 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/engine"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// A user can be given an override for a flag, forcing its result for that
+// user only, e.g. to put a tester in the treatment group:
+//
+//	GET    /v1/flags/{key}/overrides?env=            unexpired overrides of the flag (admin+)
+//	PUT    /v1/flags/{key}/overrides/{userId}?env=   create or replace an override (admin+)
+//	DELETE /v1/flags/{key}/overrides/{userId}?env=   remove an override (admin+)
+//
+// An override serves {"enabled": true, "variant": "treatment"} (variant
+// optional) or {"enabled": false} in place of the flag's expression, rules
+// and rollout, on the evaluate, /v1/evaluate and OFREP endpoints, with reason
+// OVERRIDE. A disabled flag stays disabled for everyone. With a "ttl" (a Go
+// duration such as "24h") the override expires; expired overrides are no
+// longer served and are deleted the next time an override is set. Overrides
+// are not part of the snapshot, so SDKs evaluating locally do not see them;
+// other replicas load them with their next snapshot rebuild.
+
+type userOverrideRequest struct {
+	Enabled *bool  `json:"enabled,omitempty"` // defaults to true
+	Variant string `json:"variant,omitempty"`
+	TTL     string `json:"ttl,omitempty"`
+}
+
+type userOverridesResponse struct {
+	Overrides []store.UserOverride `json:"overrides"`
+}
+
+// userOverrideTracker holds the unexpired overrides of each environment for
+// evaluation, by user.
+type userOverrideTracker struct {
+	mu    sync.RWMutex
+	byEnv map[string]map[string]map[string]store.UserOverride // env -> user ID -> flag key -> override
+	now   func() time.Time
+}
+
+func newUserOverrideTracker() *userOverrideTracker {
+	return &userOverrideTracker{
+		byEnv: make(map[string]map[string]map[string]store.UserOverride),
+		now:   time.Now,
+	}
+}
+
+// setOverrides replaces the overrides of env.
+func (t *userOverrideTracker) setOverrides(env string, overrides []store.UserOverride) {
+	byUser := make(map[string]map[string]store.UserOverride)
+	for _, o := range overrides {
+		if byUser[o.UserID] == nil {
+			byUser[o.UserID] = make(map[string]store.UserOverride)
+		}
+		byUser[o.UserID][o.Key] = o
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.byEnv[env] = byUser
+}
+
+// forUser returns the unexpired overrides of userID in env, by flag key, or
+// nil if there are none.
+func (t *userOverrideTracker) forUser(env, userID string) map[string]evaluation.Override {
+	if userID == "" {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	stored := t.byEnv[env][userID]
+	if len(stored) == 0 {
+		return nil
+	}
+	now := t.now()
+	overrides := make(map[string]evaluation.Override, len(stored))
+	for key, o := range stored {
+		if !o.Expired(now) {
+			overrides[key] = evaluation.Override{Enabled: o.Enabled, Variant: o.Variant}
+		}
+	}
+	return overrides
+}
+
+// refreshUserOverrides reloads the overrides of env from the store.
+func (s *Server) refreshUserOverrides(ctx context.Context, env string) error {
+	us, ok := s.store.(store.FlagUserOverrideStore)
+	if !ok {
+		return nil
+	}
+	overrides, err := us.ListUserOverrides(ctx, env)
+	if err != nil {
+		return err
+	}
+	s.userOverrides.setOverrides(env, overrides)
+	return nil
+}
+
+// LoadUserOverrides loads the user overrides of env for evaluation. Snapshot
+// rebuilds reload them; call it once after loading the initial snapshot.
+func (s *Server) LoadUserOverrides(ctx context.Context, env string) error {
+	return s.refreshUserOverrides(store.ReadPrimary(ctx), env)
+}
+
+// evaluateFlag is evaluation.EvaluateFlag, serving the user's override of the
+// flag if overrides has one.
+func evaluateFlag(flag snapshot.FlagView, ctx evaluation.Context, salt string, overrides map[string]evaluation.Override) evaluation.Result {
+	if o, ok := overrides[flag.Key]; ok {
+		return evaluation.EvaluateOverride(flag, ctx, salt, o)
+	}
+	return evaluation.EvaluateFlag(flag, ctx, salt)
+}
+
+// evaluateAll is evaluation.EvaluateAll with the user's overrides.
+func evaluateAll(flags map[string]snapshot.FlagView, ctx evaluation.Context, salt string, keys []string, overrides map[string]evaluation.Override) []evaluation.Result {
+	if len(overrides) == 0 {
+		return evaluation.EvaluateAll(flags, ctx, salt, keys)
+	}
+	if len(keys) == 0 {
+		keys = make([]string, 0, len(flags))
+		for key := range flags {
+			keys = append(keys, key)
+		}
+	}
+	results := make([]evaluation.Result, 0, len(keys))
+	for _, key := range keys {
+		if flag, exists := flags[key]; exists {
+			results = append(results, evaluateFlag(flag, ctx, salt, overrides))
+		} else {
+			results = append(results, evaluation.NotFound(key))
+		}
+	}
+	return results
+}
+
+// overrideEngineResult is the engine result of an enabled flag for a user
+// with override o, and whether the flag is enabled for the user.
+func overrideEngineResult(flag snapshot.FlagView, ctx *engine.UserContext, o evaluation.Override) (engine.EvaluationResult, bool) {
+	storeFlag := toStoreFlag(flag)
+	if !o.Enabled {
+		return engine.EvaluationResult{Value: storeFlag.Config, Reason: string(engine.ReasonOverride)}, false
+	}
+	return engine.EvaluateOverride(storeFlag, ctx, o.Variant), true
+}
+
+// requireUserOverrideStore returns the store as a FlagUserOverrideStore,
+// writing an error response and returning nil if it does not keep overrides.
+func (s *Server) requireUserOverrideStore(w http.ResponseWriter, r *http.Request) store.FlagUserOverrideStore {
+	if us, ok := s.store.(store.FlagUserOverrideStore); ok {
+		return us
+	}
+	InternalError(w, r, "User overrides are not supported by this store")
+	return nil
+}
+
+func (s *Server) handleListUserOverrides(w http.ResponseWriter, r *http.Request) {
+	us := s.requireUserOverrideStore(w, r)
+	if us == nil {
+		return
+	}
+	env, flag, ok := s.routeFlag(w, r)
+	if !ok {
+		return
+	}
+	all, err := us.ListUserOverrides(r.Context(), env)
+	if err != nil {
+		InternalError(w, r, "Failed to list user overrides")
+		return
+	}
+	resp := userOverridesResponse{Overrides: make([]store.UserOverride, 0)}
+	for _, o := range all {
+		if o.Key == flag.Key {
+			resp.Overrides = append(resp.Overrides, o)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSetUserOverride(w http.ResponseWriter, r *http.Request) {
+	var req userOverrideRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	us := s.requireUserOverrideStore(w, r)
+	if us == nil {
+		return
+	}
+	env, flag, ok := s.routeFlag(w, r)
+	if !ok {
+		return
+	}
+
+	o := store.UserOverride{
+		Key:       flag.Key,
+		Env:       env,
+		UserID:    strings.TrimSpace(chi.URLParam(r, "userId")),
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		Variant:   strings.TrimSpace(req.Variant),
+		UpdatedBy: createdByFromRequest(r),
+	}
+	fields := make(map[string]string)
+	if o.UserID == "" {
+		fields["userId"] = "User ID is required"
+	}
+	if o.Variant != "" && !o.Enabled {
+		fields["variant"] = "A variant can only be forced with enabled=true"
+	} else if o.Variant != "" && !flagHasVariant(flag, o.Variant) {
+		fields["variant"] = "Flag has no variant '" + o.Variant + "'"
+	}
+	if req.TTL != "" {
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			fields["ttl"] = "TTL must be a positive duration such as \"24h\""
+		} else {
+			expiresAt := time.Now().UTC().Add(ttl)
+			o.ExpiresAt = &expiresAt
+		}
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+
+	before := findUserOverride(r.Context(), us, flag.Key, env, o.UserID)
+	stored, err := us.SetUserOverride(r.Context(), o)
+	if err != nil {
+		s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeFlagOverride, flag.Key+"/"+o.UserID, env, nil, nil, nil, audit.StatusFailure, "Failed to save user override")
+		InternalError(w, r, "Failed to save user override")
+		return
+	}
+	if purged, err := us.DeleteExpiredUserOverrides(r.Context(), time.Now()); err != nil {
+		log.Printf("[overrides] failed to delete expired overrides: %v", err)
+	} else if purged > 0 {
+		log.Printf("[overrides] deleted %d expired overrides", purged)
+	}
+	if err := s.refreshUserOverrides(r.Context(), env); err != nil {
+		InternalError(w, r, "Failed to load user overrides")
+		return
+	}
+
+	action := audit.ActionUpdated
+	var beforeState map[string]any
+	if before != nil {
+		beforeState = userOverrideToMap(before)
+	} else {
+		action = audit.ActionCreated
+	}
+	afterState := userOverrideToMap(stored)
+	s.auditLog(r, action, audit.ResourceTypeFlagOverride, flag.Key+"/"+o.UserID, env, beforeState, afterState, audit.ComputeChanges(beforeState, afterState), audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, stored)
+}
+
+func (s *Server) handleDeleteUserOverride(w http.ResponseWriter, r *http.Request) {
+	us := s.requireUserOverrideStore(w, r)
+	if us == nil {
+		return
+	}
+	env, flag, ok := s.routeFlag(w, r)
+	if !ok {
+		return
+	}
+	userID := strings.TrimSpace(chi.URLParam(r, "userId"))
+	before := findUserOverride(r.Context(), us, flag.Key, env, userID)
+	if err := us.DeleteUserOverride(r.Context(), flag.Key, env, userID); err != nil {
+		if errors.Is(err, store.ErrUserOverrideNotFound) {
+			NotFoundError(w, r, "User has no override for this flag")
+			return
+		}
+		InternalError(w, r, "Failed to delete user override")
+		return
+	}
+	if err := s.refreshUserOverrides(r.Context(), env); err != nil {
+		InternalError(w, r, "Failed to load user overrides")
+		return
+	}
+
+	var beforeState map[string]any
+	if before != nil {
+		beforeState = userOverrideToMap(before)
+	}
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeFlagOverride, flag.Key+"/"+userID, env, beforeState, nil, audit.ComputeChanges(beforeState, nil), audit.StatusSuccess, "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findUserOverride returns the unexpired override of userID for key in env,
+// or nil.
+func findUserOverride(ctx context.Context, us store.FlagUserOverrideStore, key, env, userID string) *store.UserOverride {
+	overrides, err := us.ListUserOverrides(ctx, env)
+	if err != nil {
+		return nil
+	}
+	for _, o := range overrides {
+		if o.Key == key && o.UserID == userID {
+			return &o
+		}
+	}
+	return nil
+}
+
+func flagHasVariant(flag *store.Flag, name string) bool {
+	for _, v := range flag.Variants {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// userOverrideToMap is the audit state of a user override.
+func userOverrideToMap(o *store.UserOverride) map[string]any {
+	m := map[string]any{
+		"user_id": o.UserID,
+		"enabled": o.Enabled,
+	}
+	if o.Variant != "" {
+		m["variant"] = o.Variant
+	}
+	if o.ExpiresAt != nil {
+		m["expires_at"] = o.ExpiresAt.Format(time.RFC3339)
+	}
+	return m
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestUserOverrides(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{
+		Key: "checkout", Enabled: true, Rollout: 0, Env: "prod",
+		Variants: []store.Variant{
+			{Name: "control", Weight: 50},
+			{Name: "treatment", Weight: 50, Config: map[string]any{"color": "green"}},
+		},
+	})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	evaluate := func(userID string) evaluation.Result {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/flags/evaluate?userId="+userID, nil))
+		var resp evaluateResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || len(resp.Flags) != 1 {
+			t.Fatalf("evaluate: %d %s", rr.Code, rr.Body.String())
+		}
+		return resp.Flags[0]
+	}
+	if res := evaluate("alice"); res.Enabled || res.Reason != evaluation.ReasonRolloutExcluded {
+		t.Fatalf("expected alice to be outside the 0%% rollout, got %+v", res)
+	}
+
+	for body, field := range map[string]string{
+		`{"variant":"missing"}`:                   "variant",
+		`{"enabled":false,"variant":"treatment"}`: "variant",
+		`{"ttl":"soon"}`:                          "ttl",
+	} {
+		rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/overrides/alice", body)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"`+field+`"`) {
+			t.Errorf("%s: expected 400 on %s, got %d: %s", body, field, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/overrides/alice", `{"variant":"treatment","ttl":"1h"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	res := evaluate("alice")
+	if !res.Enabled || res.Variant != "treatment" || res.Reason != evaluation.ReasonOverride || res.Config["color"] != "green" {
+		t.Errorf("expected alice's override to be served, got %+v", res)
+	}
+	if res := evaluate("bob"); res.Enabled {
+		t.Errorf("expected other users to be unaffected, got %+v", res)
+	}
+
+	// The context endpoint serves the override too.
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/evaluate",
+		strings.NewReader(`{"flagKey":"checkout","context":{"id":"alice"}}`)))
+	var ctxResp EvaluationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&ctxResp); err != nil || len(ctxResp.Results) != 1 {
+		t.Fatalf("/v1/evaluate: %d %s", rr.Code, rr.Body.String())
+	}
+	if got := ctxResp.Results[0]; !got.Enabled || got.Variant != "treatment" || got.Reason != "OVERRIDE" {
+		t.Errorf("/v1/evaluate: expected the override, got %+v", got)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/overrides", "")
+	var list userOverridesResponse
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil || len(list.Overrides) != 1 {
+		t.Fatalf("list: %d %s", rr.Code, rr.Body.String())
+	}
+	if o := list.Overrides[0]; o.UserID != "alice" || o.ExpiresAt == nil {
+		t.Errorf("unexpected override: %+v", o)
+	}
+
+	// Expired overrides are not served.
+	srv.userOverrides.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if res := evaluate("alice"); res.Reason == evaluation.ReasonOverride {
+		t.Errorf("expected the expired override to be ignored, got %+v", res)
+	}
+	srv.userOverrides.now = time.Now
+
+	rr = doV2Request(t, handler, http.MethodDelete, "/v1/flags/checkout/overrides/alice", "")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if res := evaluate("alice"); res.Reason == evaluation.ReasonOverride {
+		t.Errorf("expected the deleted override to be gone, got %+v", res)
+	}
+	rr = doV2Request(t, handler, http.MethodDelete, "/v1/flags/checkout/overrides/alice", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing override, got %d", rr.Code)
+	}
+}
+
+func TestUserOverrides_DisabledFlag(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: false, Rollout: 100, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+	if rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/overrides/alice", `{}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/ofrep/v1/evaluate/flags/checkout",
+		strings.NewReader(`{"context":{"targetingKey":"alice"}}`)))
+	var eval ofrepEvaluation
+	if err := json.NewDecoder(rr.Body).Decode(&eval); err != nil {
+		t.Fatal(err)
+	}
+	if eval.Reason != ofrepReasonDisabled || eval.Value != false {
+		t.Errorf("expected a disabled flag to stay disabled despite the override, got %+v", eval)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import "github.com/TimurManjosov/goflagship/internal/store"
+
+// Flags carry ownership fields so that every flag has someone responsible
+// for it:
+//
+//	{"owner": "team-checkout", "ticket_url": "https://jira.example.com/browse/CHK-12",
+//	 "metadata": {"cost_center": "42", "slack": "#checkout"}}
+//
+// owner is free-form (at most 128 characters), ticket_url must be an absolute
+// http(s) URL and metadata holds up to 50 string entries (see
+// validation.ValidateMetadata). The fields are returned by the admin APIs,
+// exports, trash and history and are recorded in audit logs, but they are
+// not part of the public snapshot served to SDKs.
+//
+// Like protected, a write that omits a field keeps its current value, so
+// clients unaware of the fields do not clear them; send "" or {} to clear
+// one. Apply documents are the full desired state and clear omitted fields.
+
+// applyOwnership sets the ownership fields of params from req, keeping those
+// of oldFlag (nil for a new flag) that req omits.
+func applyOwnership(params *store.UpsertParams, req upsertRequest, oldFlag *store.Flag) {
+	if oldFlag != nil {
+		params.Owner, params.TicketURL, params.Metadata = oldFlag.Owner, oldFlag.TicketURL, oldFlag.Metadata
+	}
+	if req.Owner != nil {
+		params.Owner = *req.Owner
+	}
+	if req.TicketURL != nil {
+		params.TicketURL = *req.TicketURL
+	}
+	if req.Metadata != nil {
+		params.Metadata = req.Metadata
+	}
+}
+
+// keepOwnership copies the ownership fields req omits from existing into
+// desired, mirroring applyOwnership for diffs.
+func keepOwnership(desired *store.Flag, req upsertRequest, existing *store.Flag) {
+	if req.Owner == nil {
+		desired.Owner = existing.Owner
+	}
+	if req.TicketURL == nil {
+		desired.TicketURL = existing.TicketURL
+	}
+	if req.Metadata == nil {
+		desired.Metadata = existing.Metadata
+	}
+}
+
+// derefString returns *s, or "" if s is nil.
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func getFlagResponse(t *testing.T, handler http.Handler, key string) flagResponse {
+	t.Helper()
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/"+key+"?env=prod", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get %s: expected 200, got %d: %s", key, rr.Code, rr.Body.String())
+	}
+	var resp flagResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestOwnership_StoredReturnedAndKept(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":100,"env":"prod",
+		"owner":"team-checkout","ticket_url":"https://jira.example.com/browse/CHK-12","metadata":{"cost_center":"42"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := getFlagResponse(t, handler, "checkout")
+	if got.Owner != "team-checkout" || got.TicketURL != "https://jira.example.com/browse/CHK-12" || got.Metadata["cost_center"] != "42" {
+		t.Fatalf("unexpected ownership fields: %+v", got)
+	}
+
+	// A write that omits the fields keeps them.
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":false,"rollout":50,"env":"prod"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := getFlagResponse(t, handler, "checkout"); got.Owner != "team-checkout" || got.Metadata["cost_center"] != "42" {
+		t.Errorf("expected omitted ownership fields to be kept, got %+v", got)
+	}
+
+	// Empty values clear them.
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":false,"rollout":50,"env":"prod","owner":"","metadata":{}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("clear: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := getFlagResponse(t, handler, "checkout"); got.Owner != "" || len(got.Metadata) != 0 || got.TicketURL == "" {
+		t.Errorf("expected owner and metadata to be cleared and ticket_url kept, got %+v", got)
+	}
+}
+
+func TestOwnership_NotInSnapshot(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":100,"env":"prod",
+		"owner":"team-checkout","ticket_url":"https://jira.example.com/browse/CHK-12","metadata":{"cost_center":"42"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/snapshot", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("snapshot: expected 200, got %d", rr.Code)
+	}
+	for _, leaked := range []string{"team-checkout", "jira.example.com", "cost_center"} {
+		if strings.Contains(rr.Body.String(), leaked) {
+			t.Errorf("snapshot must not contain %q: %s", leaked, rr.Body.String())
+		}
+	}
+}
+
+func TestOwnership_Validation(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+	tests := map[string]string{
+		"ticket_url": `"ticket_url":"ftp://example.com/CHK-12"`,
+		"owner":      `"owner":"` + strings.Repeat("a", 129) + `"`,
+		"metadata":   `"metadata":{"cost center":"42"}`,
+	}
+	for field, body := range tests {
+		rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","env":"prod",`+body+`}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", field, rr.Code, rr.Body.String())
+			continue
+		}
+		if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields[field] == "" {
+			t.Errorf("%s: expected a field error, got %+v", field, resp.Fields)
+		}
+	}
+}
+
+func TestOwnership_PatchV2(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":100,"env":"prod","owner":"team-checkout"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doV2Request(t, handler, http.MethodPatch, "/v2/projects/default/environments/prod/flags/checkout", `{"ticket_url":"https://jira.example.com/browse/CHK-12"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("patch: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := getFlagResponse(t, handler, "checkout"); got.Owner != "team-checkout" || got.TicketURL != "https://jira.example.com/browse/CHK-12" {
+		t.Errorf("unexpected ownership fields after patch: %+v", got)
+	}
+}
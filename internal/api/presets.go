@@ -0,0 +1,250 @@
+package api
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// Context presets are named evaluation contexts, so QA can evaluate flags for
+// "qa-premium-us" or "android-beta-tester" instead of retyping attributes:
+//
+//	GET    /v1/context-presets          list presets (admin+)
+//	GET    /v1/context-presets/{name}   get a preset (admin+)
+//	PUT    /v1/context-presets/{name}   create or replace a preset (admin+)
+//	DELETE /v1/context-presets/{name}   remove a preset (admin+)
+//
+// A preset holds context attributes as sent in a request, with the user ID as
+// "id". The evaluate endpoints take a preset name ("preset" in the body of
+// POST /v1/evaluate and POST /v1/flags/evaluate, ?preset= on GET
+// /v1/flags/evaluate) and evaluate the preset's context, with any user ID and
+// attributes of the request replacing the preset's.
+
+// maxPresetDescriptionLength is the maximum description length in characters.
+const maxPresetDescriptionLength = 1000
+
+// presetNamePattern matches preset names: a letter or digit followed by
+// letters, digits, '_', '-' or '.', at most 100 characters.
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,99}$`)
+
+// presetStringAttributes are the attributes that map to string fields of the
+// /v1/evaluate context, see presetUserContext.
+var presetStringAttributes = []string{"id", "email", "country"}
+
+type putContextPresetRequest struct {
+	Description string         `json:"description"`
+	Attributes  map[string]any `json:"attributes"`
+}
+
+type contextPresetsResponse struct {
+	Presets []store.ContextPreset `json:"presets"`
+}
+
+// requireContextPresetStore returns the store as a ContextPresetStore,
+// writing an error response and returning nil if it does not keep presets.
+func (s *Server) requireContextPresetStore(w http.ResponseWriter, r *http.Request) store.ContextPresetStore {
+	if ps, ok := s.store.(store.ContextPresetStore); ok {
+		return ps
+	}
+	InternalError(w, r, "Context presets are not supported by this store")
+	return nil
+}
+
+// handleListContextPresets handles GET /v1/context-presets.
+func (s *Server) handleListContextPresets(w http.ResponseWriter, r *http.Request) {
+	ps := s.requireContextPresetStore(w, r)
+	if ps == nil {
+		return
+	}
+	presets, err := ps.ListContextPresets(r.Context())
+	if err != nil {
+		InternalError(w, r, "Failed to list context presets")
+		return
+	}
+	writeJSON(w, http.StatusOK, contextPresetsResponse{Presets: presets})
+}
+
+// handleGetContextPreset handles GET /v1/context-presets/{name}.
+func (s *Server) handleGetContextPreset(w http.ResponseWriter, r *http.Request) {
+	ps := s.requireContextPresetStore(w, r)
+	if ps == nil {
+		return
+	}
+	preset, err := ps.GetContextPreset(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		if errors.Is(err, store.ErrContextPresetNotFound) {
+			NotFoundError(w, r, "Context preset not found")
+			return
+		}
+		InternalError(w, r, "Failed to get context preset")
+		return
+	}
+	writeJSON(w, http.StatusOK, preset)
+}
+
+// handlePutContextPreset handles PUT /v1/context-presets/{name}.
+func (s *Server) handlePutContextPreset(w http.ResponseWriter, r *http.Request) {
+	var req putContextPresetRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	name := chi.URLParam(r, "name")
+	req.Description = strings.TrimSpace(req.Description)
+
+	fields := map[string]string{}
+	if !presetNamePattern.MatchString(name) {
+		fields["name"] = "Must start with a letter or digit and contain only letters, digits, '_', '-' or '.' (max 100 characters)"
+	}
+	if len(req.Attributes) == 0 {
+		fields["attributes"] = "At least one attribute is required"
+	}
+	for _, attr := range presetStringAttributes {
+		if value, ok := req.Attributes[attr]; ok {
+			if _, isString := value.(string); !isString {
+				fields["attributes."+attr] = "Must be a string"
+			}
+		}
+	}
+	if utf8.RuneCountInString(req.Description) > maxPresetDescriptionLength {
+		fields["description"] = "Description must not exceed 1000 characters"
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+
+	ps := s.requireContextPresetStore(w, r)
+	if ps == nil {
+		return
+	}
+	var before map[string]any
+	existing, err := ps.GetContextPreset(r.Context(), name)
+	switch {
+	case err == nil:
+		before = contextPresetToMap(existing)
+	case !errors.Is(err, store.ErrContextPresetNotFound):
+		InternalError(w, r, "Failed to load context preset")
+		return
+	}
+
+	preset, err := ps.PutContextPreset(r.Context(), store.ContextPreset{
+		Name:        name,
+		Description: req.Description,
+		Attributes:  req.Attributes,
+		UpdatedBy:   createdByFromRequest(r),
+	})
+	if err != nil {
+		InternalError(w, r, "Failed to save context preset")
+		return
+	}
+
+	action, after := audit.ActionUpdated, contextPresetToMap(preset)
+	if before == nil {
+		action = audit.ActionCreated
+	}
+	s.auditLog(r, action, audit.ResourceTypeContextPreset, name, "", before, after, audit.ComputeChanges(before, after), audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, preset)
+}
+
+// handleDeleteContextPreset handles DELETE /v1/context-presets/{name}.
+func (s *Server) handleDeleteContextPreset(w http.ResponseWriter, r *http.Request) {
+	ps := s.requireContextPresetStore(w, r)
+	if ps == nil {
+		return
+	}
+	name := chi.URLParam(r, "name")
+	if err := ps.DeleteContextPreset(r.Context(), name); err != nil {
+		if errors.Is(err, store.ErrContextPresetNotFound) {
+			NotFoundError(w, r, "Context preset not found")
+			return
+		}
+		InternalError(w, r, "Failed to delete context preset")
+		return
+	}
+
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeContextPreset, name, "", nil, nil, nil, audit.StatusSuccess, "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func contextPresetToMap(preset store.ContextPreset) map[string]any {
+	return map[string]any{
+		"description": preset.Description,
+		"attributes":  preset.Attributes,
+	}
+}
+
+// loadContextPreset returns the preset named by an evaluate request. It
+// writes a 400 response and returns false if there is no such preset.
+func (s *Server) loadContextPreset(w http.ResponseWriter, r *http.Request, name string) (store.ContextPreset, bool) {
+	ps := s.requireContextPresetStore(w, r)
+	if ps == nil {
+		return store.ContextPreset{}, false
+	}
+	preset, err := ps.GetContextPreset(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, store.ErrContextPresetNotFound) {
+			ValidationError(w, r, "Validation failed for one or more fields", map[string]string{
+				"preset": "Unknown context preset '" + name + "'",
+			})
+			return store.ContextPreset{}, false
+		}
+		InternalError(w, r, "Failed to load context preset")
+		return store.ContextPreset{}, false
+	}
+	return preset, true
+}
+
+// presetEvaluationContext is the /v1/flags/evaluate context of preset, with
+// the user ID and attributes of ctx replacing the preset's.
+func presetEvaluationContext(preset store.ContextPreset, ctx evaluation.Context) evaluation.Context {
+	attrs := maps.Clone(preset.Attributes)
+	if attrs == nil {
+		attrs = map[string]any{}
+	}
+	userID, _ := attrs["id"].(string)
+	delete(attrs, "id")
+	maps.Copy(attrs, ctx.Attributes)
+	if ctx.UserID != "" {
+		userID = ctx.UserID
+	}
+	return evaluation.Context{UserID: userID, Attributes: attrs}
+}
+
+// presetUserContext is the /v1/evaluate context of preset, with the fields and
+// properties of dto replacing the preset's.
+func presetUserContext(preset store.ContextPreset, dto EvaluationContextDTO) EvaluationContextDTO {
+	merged := EvaluationContextDTO{Properties: map[string]any{}}
+	for name, value := range preset.Attributes {
+		s, _ := value.(string)
+		switch name {
+		case "id":
+			merged.ID = s
+		case "email":
+			merged.Email = s
+		case "country":
+			merged.Country = s
+		default:
+			merged.Properties[name] = value
+		}
+	}
+	if dto.ID != "" {
+		merged.ID = dto.ID
+	}
+	if dto.Email != "" {
+		merged.Email = dto.Email
+	}
+	if dto.Country != "" {
+		merged.Country = dto.Country
+	}
+	maps.Copy(merged.Properties, dto.Properties)
+	return merged
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestContextPresets(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	expr := `{"==": [{"var": "tier"}, "premium"]}`
+	st.UpsertFlag(ctx, store.UpsertParams{
+		Key: "premium_banner", Enabled: true, Rollout: 100, Env: "prod", Expression: &expr,
+		TargetingRules: []rules.Rule{{
+			ID:           "premium",
+			Conditions:   []rules.Condition{{Property: "tier", Operator: rules.OpEq, Value: "premium"}},
+			Distribution: map[string]int{"on": 100},
+		}},
+		Variants: []store.Variant{{Name: "on", Weight: 100}},
+	})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	for body, field := range map[string]string{
+		`{"attributes":{}}`:                 "attributes",
+		`{"attributes":{"id":42}}`:          "attributes.id",
+		`{"attributes":{"tier":"premium"}}`: "",
+	} {
+		rr := doV2Request(t, handler, http.MethodPut, "/v1/context-presets/qa-premium-us", body)
+		if field == "" {
+			if rr.Code != http.StatusOK {
+				t.Fatalf("%s: expected 200, got %d: %s", body, rr.Code, rr.Body.String())
+			}
+		} else if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"`+field+`"`) {
+			t.Errorf("%s: expected 400 on %s, got %d: %s", body, field, rr.Code, rr.Body.String())
+		}
+	}
+	rr := doV2Request(t, handler, http.MethodPut, "/v1/context-presets/qa-premium-us",
+		`{"description":"Premium customer in the US","attributes":{"id":"qa-1","country":"US","tier":"premium"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodPut, "/v1/context-presets/bad%20name", `{"attributes":{"a":"b"}}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid name, got %d", rr.Code)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/context-presets", "")
+	var list contextPresetsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil || len(list.Presets) != 1 {
+		t.Fatalf("list: %d %s", rr.Code, rr.Body.String())
+	}
+	if p := list.Presets[0]; p.Name != "qa-premium-us" || p.Attributes["tier"] != "premium" || p.Description == "" {
+		t.Errorf("unexpected preset: %+v", p)
+	}
+
+	evaluate := func(method, path, body string) string {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr.Body.String()
+	}
+	for _, tc := range []struct{ name, method, path, body, want string }{
+		{"GET preset", http.MethodGet, "/v1/flags/evaluate?preset=qa-premium-us", "", `"reason":"TARGETING_MATCH"`},
+		{"GET preset, attribute replaced", http.MethodGet, "/v1/flags/evaluate?preset=qa-premium-us&tier=free", "", `"reason":"NO_MATCH"`},
+		{"POST preset", http.MethodPost, "/v1/flags/evaluate", `{"preset":"qa-premium-us"}`, `"reason":"TARGETING_MATCH"`},
+		{"context preset", http.MethodPost, "/v1/evaluate", `{"preset":"qa-premium-us","flagKey":"premium_banner"}`, `"reason":"TARGETING_MATCH"`},
+		{"context preset, user replaced", http.MethodPost, "/v1/evaluate", `{"preset":"qa-premium-us","context":{"id":"qa-2"}}`, `"enabled":true`},
+		{"unknown preset", http.MethodGet, "/v1/flags/evaluate?preset=missing", "", `"preset"`},
+		{"no user", http.MethodGet, "/v1/flags/evaluate", "", `"userId"`},
+	} {
+		if got := evaluate(tc.method, tc.path, tc.body); !strings.Contains(got, tc.want) {
+			t.Errorf("%s: expected %s in %s", tc.name, tc.want, got)
+		}
+	}
+
+	rr = doV2Request(t, handler, http.MethodDelete, "/v1/context-presets/qa-premium-us", "")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/context-presets/qa-premium-us", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// Projects group flags, API keys and webhooks, e.g. one per product:
+//
+//	GET    /v1/projects         list projects (admin+)
+//	POST   /v1/projects         create a project (admin+)
+//	GET    /v1/projects/{key}   get a project (admin+)
+//	PUT    /v1/projects/{key}   rename or describe a project (admin+)
+//	DELETE /v1/projects/{key}   remove an unused project (admin+)
+//
+// Every flag belongs to one project, store.DefaultProject unless written with
+// "project". Flag keys stay unique per environment, so moving a flag to
+// another project is an update of its "project". GET /v1/flags, the v2 routes
+// and GET /v1/flags/snapshot take a project (?project= on v1) to list one
+// project's flags.
+//
+// An API key created with "project" is restricted to it: it only sees and
+// writes that project's flags and webhooks, and cannot use endpoints that
+// span projects (bulk actions, import and export, apply, trash, change sets,
+// project management, SDK keys, context presets and rule builder metadata). Keys without a project are not restricted. Webhooks
+// with a project_id only receive events of that project's flags.
+
+// maxProjectDescriptionLength is the maximum description length in characters.
+const maxProjectDescriptionLength = 1000
+
+// projectKeyPattern matches project keys: a lowercase letter or digit followed
+// by lowercase letters, digits, '_' or '-', at most 64 characters.
+var projectKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type projectRequest struct {
+	Key         string `json:"key"` // POST only; PUT takes it from the path
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type projectsResponse struct {
+	Projects []store.Project `json:"projects"`
+}
+
+// requireProjectStore returns the store as a ProjectStore, writing an error
+// response and returning nil if it does not keep projects.
+func (s *Server) requireProjectStore(w http.ResponseWriter, r *http.Request) store.ProjectStore {
+	if ps, ok := s.store.(store.ProjectStore); ok {
+		return ps
+	}
+	InternalError(w, r, "Projects are not supported by this store")
+	return nil
+}
+
+// handleListProjects handles GET /v1/projects. Project-scoped keys only see
+// their own project.
+func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	ps := s.requireProjectStore(w, r)
+	if ps == nil {
+		return
+	}
+	projects, err := ps.ListProjects(r.Context())
+	if err != nil {
+		InternalError(w, r, "Failed to list projects")
+		return
+	}
+	visible := make([]store.Project, 0, len(projects))
+	for _, project := range projects {
+		if projectVisible(r, project.Key) {
+			visible = append(visible, project)
+		}
+	}
+	writeJSON(w, http.StatusOK, projectsResponse{Projects: visible})
+}
+
+// handleGetProject handles GET /v1/projects/{key}.
+func (s *Server) handleGetProject(w http.ResponseWriter, r *http.Request) {
+	ps := s.requireProjectStore(w, r)
+	if ps == nil {
+		return
+	}
+	key := chi.URLParam(r, "key")
+	if !projectVisible(r, key) {
+		NotFoundError(w, r, "Project not found")
+		return
+	}
+	project, err := ps.GetProject(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, store.ErrProjectNotFound) {
+			NotFoundError(w, r, "Project not found")
+			return
+		}
+		InternalError(w, r, "Failed to get project")
+		return
+	}
+	writeJSON(w, http.StatusOK, project)
+}
+
+// handleCreateProject handles POST /v1/projects.
+func (s *Server) handleCreateProject(w http.ResponseWriter, r *http.Request) {
+	var req projectRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	req.Key = strings.TrimSpace(req.Key)
+	fields := validateProjectRequest(&req)
+	if !projectKeyPattern.MatchString(req.Key) {
+		fields["key"] = "Must start with a lowercase letter or digit and contain only lowercase letters, digits, '_' or '-' (max 64 characters)"
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+
+	ps := s.requireProjectStore(w, r)
+	if ps == nil {
+		return
+	}
+	project, err := ps.CreateProject(r.Context(), store.Project{Key: req.Key, Name: req.Name, Description: req.Description})
+	if err != nil {
+		if errors.Is(err, store.ErrProjectExists) {
+			ConflictError(w, r, "Project '"+req.Key+"' already exists")
+			return
+		}
+		InternalError(w, r, "Failed to create project")
+		return
+	}
+
+	s.auditLog(r, audit.ActionCreated, audit.ResourceTypeProject, project.Key, "", nil, projectToMap(project), nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusCreated, project)
+}
+
+// handleUpdateProject handles PUT /v1/projects/{key}.
+func (s *Server) handleUpdateProject(w http.ResponseWriter, r *http.Request) {
+	var req projectRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	key := chi.URLParam(r, "key")
+	fields := validateProjectRequest(&req)
+	if req.Key != "" && req.Key != key {
+		fields["key"] = "key in body must match path key"
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+
+	ps := s.requireProjectStore(w, r)
+	if ps == nil {
+		return
+	}
+	existing, err := ps.GetProject(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, store.ErrProjectNotFound) {
+			NotFoundError(w, r, "Project not found")
+			return
+		}
+		InternalError(w, r, "Failed to load project")
+		return
+	}
+	project, err := ps.UpdateProject(r.Context(), store.Project{Key: key, Name: req.Name, Description: req.Description})
+	if err != nil {
+		if errors.Is(err, store.ErrProjectNotFound) {
+			NotFoundError(w, r, "Project not found")
+			return
+		}
+		InternalError(w, r, "Failed to update project")
+		return
+	}
+
+	before, after := projectToMap(existing), projectToMap(project)
+	s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeProject, key, "", before, after, audit.ComputeChanges(before, after), audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, project)
+}
+
+// handleDeleteProject handles DELETE /v1/projects/{key}. The default project
+// and projects that flags (or API keys or webhooks) still belong to cannot be
+// deleted.
+func (s *Server) handleDeleteProject(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	if key == store.DefaultProject {
+		ConflictError(w, r, "The default project cannot be deleted")
+		return
+	}
+	ps := s.requireProjectStore(w, r)
+	if ps == nil {
+		return
+	}
+	existing, err := ps.GetProject(r.Context(), key)
+	if err == nil {
+		err = ps.DeleteProject(r.Context(), key)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrProjectNotFound):
+			NotFoundError(w, r, "Project not found")
+		case errors.Is(err, store.ErrProjectInUse):
+			ConflictError(w, r, "Project '"+key+"' still has flags, API keys or webhooks")
+		default:
+			InternalError(w, r, "Failed to delete project")
+		}
+		return
+	}
+
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeProject, key, "", projectToMap(existing), nil, nil, audit.StatusSuccess, "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateProjectRequest trims req and checks its name and description.
+func validateProjectRequest(req *projectRequest) map[string]string {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	fields := map[string]string{}
+	if req.Name == "" {
+		fields["name"] = "Name is required"
+	} else if utf8.RuneCountInString(req.Name) > 100 {
+		fields["name"] = "Name must not exceed 100 characters"
+	}
+	if utf8.RuneCountInString(req.Description) > maxProjectDescriptionLength {
+		fields["description"] = "Description must not exceed 1000 characters"
+	}
+	return fields
+}
+
+func projectToMap(project store.Project) map[string]any {
+	return map[string]any{
+		"name":        project.Name,
+		"description": project.Description,
+	}
+}
+
+// projectVisible reports whether the caller's API key may access project.
+func projectVisible(r *http.Request, project string) bool {
+	scope := auth.GetProjectFromContext(r.Context())
+	return scope == "" || scope == project
+}
+
+// requireAllProjects rejects project-scoped API keys on endpoints that span
+// projects.
+func requireAllProjects(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.GetProjectFromContext(r.Context()) != "" {
+			ForbiddenError(w, r, "This endpoint is not available to project-scoped API keys")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// knownProject reports whether project exists. Stores without a project
+// registry only know store.DefaultProject.
+func (s *Server) knownProject(r *http.Request, project string) (bool, error) {
+	ps, ok := s.store.(store.ProjectStore)
+	if !ok {
+		return project == store.DefaultProject, nil
+	}
+	_, err := ps.GetProject(r.Context(), project)
+	if errors.Is(err, store.ErrProjectNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// checkProject checks that the caller may assign something to project: it
+// must exist and be the caller's project if the API key is scoped. field
+// names the request field in the validation error.
+func (s *Server) checkProject(r *http.Request, field, project string) *flagWriteError {
+	if !projectVisible(r, project) {
+		return &flagWriteError{
+			status:  http.StatusForbidden,
+			code:    ErrCodeForbidden,
+			message: "Project is not accessible with this API key",
+			fields:  map[string]string{field: "This API key is restricted to project " + auth.GetProjectFromContext(r.Context())},
+		}
+	}
+	known, err := s.knownProject(r, project)
+	if err != nil {
+		return internalWriteError("Failed to load project")
+	}
+	if !known {
+		return validationWriteError("Validation failed for one or more fields", map[string]string{
+			field: "Unknown project '" + project + "'",
+		})
+	}
+	return nil
+}
+
+// defaultRequestProject is the project of resources created without one:
+// the caller's project if its API key is scoped, else store.DefaultProject.
+func defaultRequestProject(r *http.Request) string {
+	if scope := auth.GetProjectFromContext(r.Context()); scope != "" {
+		return scope
+	}
+	return store.DefaultProject
+}
+
+// flagWriteProject returns the project a flag write stores: req.Project if
+// set, else the project of oldFlag, else defaultRequestProject. Flags of
+// other projects cannot be written by project-scoped keys.
+func (s *Server) flagWriteProject(r *http.Request, req upsertRequest, oldFlag *store.Flag) (string, *flagWriteError) {
+	if oldFlag != nil && !projectVisible(r, oldFlag.Project) {
+		return "", notFoundWriteError("Flag not found")
+	}
+	switch {
+	case req.Project != nil && strings.TrimSpace(*req.Project) != "":
+		project := strings.TrimSpace(*req.Project)
+		if oldFlag != nil && project == oldFlag.Project {
+			return project, nil // no need to look it up again
+		}
+		return project, s.checkProject(r, "project", project)
+	case oldFlag != nil:
+		return oldFlag.Project, nil
+	default:
+		return defaultRequestProject(r), nil
+	}
+}
+
+// requireFlagProject responds 404 to /v1/flags/{id}/... requests of
+// project-scoped API keys for flags of other projects. Missing flags are left
+// to the handlers.
+func (s *Server) requireFlagProject(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.GetProjectFromContext(r.Context()) != "" {
+			key := strings.TrimSpace(chi.URLParam(r, "id"))
+			if flag, ok := s.loadEnvFlag(r, key, s.queryEnv(r)); ok && !projectVisible(r, flag.Project) {
+				NotFoundError(w, r, "Flag not found")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireKnownProject rejects /v2/projects/{project} requests for projects
+// that do not exist or that the caller's API key is not scoped to.
+func (s *Server) requireKnownProject(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project := chi.URLParam(r, "project")
+		if !projectVisible(r, project) {
+			NotFoundError(w, r, "Project not found")
+			return
+		}
+		known, err := s.knownProject(r, project)
+		if err != nil {
+			InternalError(w, r, "Failed to load project")
+			return
+		}
+		if !known {
+			NotFoundError(w, r, "Project not found")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// webhookProject returns the project_id to store for a webhook: the project
+// named by projectID, checked with checkProject, or the caller's project if
+// its API key is scoped. Webhooks without a project receive events of every
+// project.
+func (s *Server) webhookProject(r *http.Request, projectID *string) (pgtype.Text, *flagWriteError) {
+	project := auth.GetProjectFromContext(r.Context())
+	if projectID != nil && strings.TrimSpace(*projectID) != "" {
+		project = strings.TrimSpace(*projectID)
+		if werr := s.checkProject(r, "project_id", project); werr != nil {
+			return pgtype.Text{}, werr
+		}
+	}
+	return pgtype.Text{String: project, Valid: project != ""}, nil
+}
+
+// webhookInScope reports whether the caller's API key may access wh:
+// project-scoped keys only reach their project's webhooks.
+func webhookInScope(r *http.Request, wh dbgen.Webhook) bool {
+	scope := auth.GetProjectFromContext(r.Context())
+	return scope == "" || wh.ProjectID.String == scope
+}
+
+// stateProject returns the project of a flag from its flagToMap states, the
+// state after a change taking precedence.
+func stateProject(beforeState, afterState map[string]any) string {
+	state := afterState
+	if state == nil {
+		state = beforeState
+	}
+	if project, ok := state["project"].(string); ok {
+		return project
+	}
+	return store.DefaultProject
+}
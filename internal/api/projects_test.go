@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestProjects_CRUD(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	for body, field := range map[string]string{
+		`{"key":"Checkout","name":"Checkout"}`: "key",
+		`{"key":"checkout"}`:                   "name",
+	} {
+		if rr := doV2Request(t, handler, http.MethodPost, "/v1/projects", body); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"`+field+`"`) {
+			t.Errorf("%s: expected 400 on %s, got %d: %s", body, field, rr.Code, rr.Body.String())
+		}
+	}
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/projects", `{"key":"checkout","name":"Checkout"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/projects", `{"key":"checkout","name":"Again"}`); rr.Code != http.StatusConflict {
+		t.Errorf("duplicate: expected 409, got %d", rr.Code)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPut, "/v1/projects/checkout", `{"name":"Checkout team","description":"Cart and payment"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"Cart and payment"`) {
+		t.Fatalf("update: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodPut, "/v1/projects/missing", `{"name":"Missing"}`); rr.Code != http.StatusNotFound {
+		t.Errorf("update missing: expected 404, got %d", rr.Code)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/projects", "")
+	var list projectsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil || len(list.Projects) != 2 {
+		t.Fatalf("list: %d %+v", rr.Code, list)
+	}
+	if list.Projects[0].Key != "checkout" || list.Projects[1].Key != store.DefaultProject {
+		t.Errorf("expected projects sorted by key, got %+v", list.Projects)
+	}
+
+	// A project with flags cannot be deleted; the default project never can.
+	flag := `{"key":"new_cart","enabled":true,"rollout":100,"env":"prod","project":"checkout"}`
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", flag); rr.Code != http.StatusOK {
+		t.Fatalf("create flag: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v1/projects/checkout", ""); rr.Code != http.StatusConflict {
+		t.Errorf("in use: expected 409, got %d", rr.Code)
+	}
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v1/projects/default", ""); rr.Code != http.StatusConflict {
+		t.Errorf("default: expected 409, got %d", rr.Code)
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"new_cart","enabled":true,"rollout":100,"env":"prod","project":"default"}`); rr.Code != http.StatusOK {
+		t.Fatalf("move flag: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v1/projects/checkout", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/projects/checkout", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", rr.Code)
+	}
+}
+
+// newProjectTestServer returns a server with the flags "cart" in project
+// checkout and "search" in the default project, both in prod.
+func newProjectTestServer(t *testing.T) *Server {
+	t.Helper()
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if _, err := st.CreateProject(ctx, store.Project{Key: "checkout", Name: "Checkout"}); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	for key, project := range map[string]string{"cart": "checkout", "search": ""} {
+		if err := st.UpsertFlag(ctx, store.UpsertParams{Key: key, Enabled: true, Rollout: 100, Env: "prod", Project: project}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	srv := NewServer(st, "prod", "test-key")
+	if err := srv.RebuildSnapshot(ctx, "prod"); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	return srv
+}
+
+func TestProjects_Filters(t *testing.T) {
+	srv := newProjectTestServer(t)
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags?env=prod&project=checkout", "")
+	var list listFlagsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil || list.Total != 1 || list.Flags[0].Key != "cart" || list.Flags[0].Project != "checkout" {
+		t.Fatalf("list: %d %+v", rr.Code, list)
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/flags?env=prod&project=missing", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown project: expected 400, got %d", rr.Code)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/snapshot?env=prod&project=default", "")
+	var snap snapshot.Snapshot
+	if err := json.NewDecoder(rr.Body).Decode(&snap); err != nil || len(snap.Flags) != 1 || snap.Flags["search"].Project != store.DefaultProject {
+		t.Fatalf("snapshot: %d %+v", rr.Code, snap.Flags)
+	}
+	if snap.ETag == srv.snapshotForEnv("prod").ETag {
+		t.Error("expected the filtered snapshot to have its own ETag")
+	}
+
+	// v2 routes only reach the flags of {project}.
+	base := "/v2/projects/checkout/environments/prod/flags"
+	rr = doV2Request(t, handler, http.MethodGet, base, "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"cart"`) || strings.Contains(rr.Body.String(), `"search"`) {
+		t.Errorf("v2 list: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, base+"/search", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("v2 get of another project's flag: expected 404, got %d", rr.Code)
+	}
+	if rr := doV2Request(t, handler, http.MethodPut, base+"/search", `{"enabled":false}`); rr.Code != http.StatusConflict {
+		t.Errorf("v2 put of another project's key: expected 409, got %d", rr.Code)
+	}
+	if rr := doV2Request(t, handler, http.MethodPatch, base+"/cart", `{"rollout":50}`); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"project":"checkout"`) {
+		t.Errorf("v2 patch: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, "/v2/projects/missing/environments/prod/flags", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown project: expected 404, got %d", rr.Code)
+	}
+
+	// Writes without "project" keep the flag's project.
+	if rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/cart", `{"enabled":false,"rollout":0,"env":"prod"}`); rr.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rr.Code, rr.Body.String())
+	}
+	if flag, _ := srv.store.GetFlagByKey(context.Background(), "cart", "prod"); flag.Project != "checkout" {
+		t.Errorf("expected the flag to stay in checkout, got %q", flag.Project)
+	}
+	if rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/cart", `{"enabled":false,"env":"prod","project":"missing"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown project on write: expected 400, got %d", rr.Code)
+	}
+}
+
+// projectScopedRequest builds an admin request whose API key is restricted to
+// project.
+func projectScopedRequest(project, method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(r.Context(), auth.ContextKeyRole, auth.RoleAdmin)
+	return r.WithContext(context.WithValue(ctx, auth.ContextKeyProject, project))
+}
+
+func TestProjects_ScopedAPIKey(t *testing.T) {
+	srv := newProjectTestServer(t)
+
+	rr := httptest.NewRecorder()
+	srv.handleListFlags(rr, projectScopedRequest("checkout", http.MethodGet, "/v1/flags?env=prod", ""))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"cart"`) || strings.Contains(rr.Body.String(), `"search"`) {
+		t.Errorf("list: expected only the key's project, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	srv.handleListFlags(rr, projectScopedRequest("checkout", http.MethodGet, "/v1/flags?env=prod&project=default", ""))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("list of another project: expected 403, got %d", rr.Code)
+	}
+
+	// Per-flag routes hide the flags of other projects.
+	get := srv.requireFlagProject(http.HandlerFunc(srv.handleGetFlag))
+	for key, want := range map[string]int{"cart": http.StatusOK, "search": http.StatusNotFound} {
+		req := projectScopedRequest("checkout", http.MethodGet, "/v1/flags/"+key+"?env=prod", "")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", key)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+		get.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Errorf("get %s: expected %d, got %d", key, want, rr.Code)
+		}
+	}
+
+	// New flags default to the key's project; other projects' flags cannot
+	// be written or deleted.
+	rr = httptest.NewRecorder()
+	srv.handleUpsertFlag(rr, projectScopedRequest("checkout", http.MethodPost, "/v1/flags", `{"key":"coupons","enabled":true,"rollout":100,"env":"prod"}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create: %d %s", rr.Code, rr.Body.String())
+	}
+	if flag, _ := srv.store.GetFlagByKey(context.Background(), "coupons", "prod"); flag.Project != "checkout" {
+		t.Errorf("expected the key's project, got %q", flag.Project)
+	}
+	for _, body := range []string{
+		`{"key":"search","enabled":false,"env":"prod"}`,
+		`{"key":"cart","enabled":false,"env":"prod","project":"default"}`,
+	} {
+		rr = httptest.NewRecorder()
+		srv.handleUpsertFlag(rr, projectScopedRequest("checkout", http.MethodPost, "/v1/flags", body))
+		if rr.Code != http.StatusNotFound && rr.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 404 or 403, got %d", body, rr.Code)
+		}
+	}
+	rr = httptest.NewRecorder()
+	srv.handleDeleteFlag(rr, projectScopedRequest("checkout", http.MethodDelete, "/v1/flags?key=search&env=prod", ""))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("delete of another project's flag: expected 404, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	requireAllProjects(http.HandlerFunc(srv.handleBulkAction)).ServeHTTP(rr, projectScopedRequest("checkout", http.MethodPost, "/v1/flags/bulk-action", `{}`))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("bulk action: expected 403, got %d", rr.Code)
+	}
+}
+
+func TestProjects_ScopedKeyManagement(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "prod", "test-key")
+	soon := pgtype.Timestamptz{Time: time.Now().Add(48 * time.Hour), Valid: true}
+	disabled := pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+	st.apiKeys = []dbgen.ApiKey{
+		{ID: pgtype.UUID{Bytes: [16]byte{1}, Valid: true}, Name: "root", Role: dbgen.ApiKeyRoleSuperadmin, Enabled: true, ExpiresAt: soon, Tenant: auth.DefaultTenant},
+		{ID: pgtype.UUID{Bytes: [16]byte{2}, Valid: true}, Name: "search-ci", Role: dbgen.ApiKeyRoleAdmin, DisabledAt: disabled, DisabledReason: keyDisabledUnused, Tenant: auth.DefaultTenant, Project: pgtype.Text{String: "default", Valid: true}},
+		{ID: pgtype.UUID{Bytes: [16]byte{3}, Valid: true}, Name: "cart-ci", Role: dbgen.ApiKeyRoleAdmin, Enabled: true, ExpiresAt: soon, Tenant: auth.DefaultTenant, Project: pgtype.Text{String: "checkout", Valid: true}},
+	}
+
+	scoped := func(method, target, id string) *http.Request {
+		r := projectScopedRequest("checkout", method, target, "")
+		ctx := context.WithValue(r.Context(), auth.ContextKeyRole, auth.RoleSuperadmin)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		return r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+	}
+
+	// Keys outside the caller's project are hidden, like in the key list.
+	rr := httptest.NewRecorder()
+	srv.handleRevokeAPIKey(rr, scoped(http.MethodDelete, "/v1/admin/keys/x", formatUUID(st.apiKeys[0].ID)))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("revoke of an unscoped key: expected 404, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleReenableAPIKey(rr, scoped(http.MethodPost, "/v1/admin/keys/x/enable", formatUUID(st.apiKeys[1].ID)))
+	if rr.Code != http.StatusNotFound || st.apiKeys[1].Enabled {
+		t.Errorf("re-enable of another project's key: expected 404, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	srv.handleListExpiringKeys(rr, scoped(http.MethodGet, "/v1/admin/keys/expiring", ""))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"cart-ci"`) || strings.Contains(rr.Body.String(), `"root"`) {
+		t.Errorf("expiring: expected only the project's keys, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.handleRevokeAPIKey(rr, scoped(http.MethodDelete, "/v1/admin/keys/x", formatUUID(st.apiKeys[2].ID)))
+	if rr.Code != http.StatusOK && rr.Code != http.StatusNoContent {
+		t.Errorf("revoke of the project's own key: expected success, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestProjects_ScopedKeyCrossProjectRoutes(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	hash, err := auth.HashAPIKey("checkout-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.apiKeys = []dbgen.ApiKey{{
+		ID: pgtype.UUID{Bytes: [16]byte{1}, Valid: true}, Name: "checkout", KeyHash: hash, Role: dbgen.ApiKeyRoleAdmin,
+		Enabled: true, Tenant: auth.DefaultTenant, Project: pgtype.Text{String: "checkout", Valid: true},
+	}}
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	// Audit entries carry the states of every project's flags, and an SDK key
+	// reads every flag of its environment.
+	for _, route := range []struct{ method, target, body string }{
+		{http.MethodGet, "/v1/admin/audit-logs", ""},
+		{http.MethodGet, "/v1/admin/audit-logs/export?format=json", ""},
+		{http.MethodGet, "/v1/admin/activity", ""},
+		{http.MethodPost, "/v1/admin/sdk-keys", `{"name":"web","env":"prod"}`},
+		{http.MethodGet, "/v1/admin/sdk-keys", ""},
+		{http.MethodDelete, "/v1/admin/sdk-keys/550e8400-e29b-41d4-a716-446655440000", ""},
+		{http.MethodGet, "/v1/context-presets", ""},
+		{http.MethodPut, "/v1/context-presets/qa", `{"context":{"id":"u1"}}`},
+		{http.MethodGet, "/v1/meta/operators", ""},
+		{http.MethodGet, "/v1/meta/attributes", ""},
+	} {
+		for _, token := range []string{"checkout-key", "test-key"} {
+			req := httptest.NewRequest(route.method, route.target, strings.NewReader(route.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if forbidden := rr.Code == http.StatusForbidden; forbidden != (token == "checkout-key") {
+				t.Errorf("%s %s with %s: got %d: %s", route.method, route.target, token, rr.Code, rr.Body.String())
+			}
+		}
+	}
+}
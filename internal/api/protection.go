@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// Protected flags (payment or auth kill switches, for example) can only be
+// deleted, disabled or unprotected by a superadmin key. Admin keys may do so
+// by passing ?force=true together with a ?reason=, which is recorded in a
+// separate "protection_overridden" audit event.
+//
+// The check lives in saveFlag and removeFlag, so every write path (v1, v2,
+// GraphQL, apply and environment cloning) enforces it. Bulk actions write
+// through the batch store and call it themselves, see bulk.go. With step-up
+// authentication enabled, superadmins and admins forcing the change also need
+// a one-time code, see stepup.go.
+
+// checkFlagProtection decides whether r may make a destructive change to
+// existing. It returns the override reason to audit when ?force=true was
+// used, or a *flagWriteError if the change is not allowed.
+func (s *Server) checkFlagProtection(r *http.Request, existing *store.Flag, destructive bool) (string, *flagWriteError) {
+	if existing == nil || !existing.Protected || !destructive {
+		return "", nil
+	}
+	if isSuperadmin(r) {
+		// Dry runs don't spend the single-use step-up code.
+		if !isDryRun(r) && !s.verifyStepUp(r, audit.ResourceTypeFlag, existing.Key) {
+			return "", stepUpWriteError()
+		}
+		return "", nil
+	}
+	if r.URL.Query().Get("force") != "true" {
+		return "", &flagWriteError{
+			status:  http.StatusForbidden,
+			code:    ErrCodeFlagProtected,
+			message: "Flag is protected: deleting, disabling or unprotecting it requires a superadmin key or ?force=true&reason=...",
+		}
+	}
+	reason := strings.TrimSpace(r.URL.Query().Get("reason"))
+	if reason == "" {
+		return "", validationWriteError("A reason is required to override flag protection", map[string]string{
+			"reason": "reason query parameter is required with force=true",
+		})
+	}
+	if !isDryRun(r) && !s.verifyStepUp(r, audit.ResourceTypeFlag, existing.Key) {
+		return "", stepUpWriteError()
+	}
+	return reason, nil
+}
+
+// isDestructiveUpdate reports whether req would disable or unprotect existing.
+func isDestructiveUpdate(existing *store.Flag, req upsertRequest) bool {
+	if existing == nil {
+		return false
+	}
+	disables := existing.Enabled && !req.Enabled
+	unprotects := req.Protected != nil && !*req.Protected
+	return disables || unprotects
+}
+
+// auditProtectionOverride records that a protected flag was changed with ?force=true.
+func (s *Server) auditProtectionOverride(r *http.Request, key, env, reason string) {
+	if s.auditService == nil {
+		return // No audit service available
+	}
+	s.auditService.Log(audit.NewEventBuilder(r).
+		ForResource(audit.ResourceTypeFlag, key).
+		WithAction(audit.ActionProtectionOverridden).
+		WithEnvironment(env).
+		WithNote(reason).
+		Build())
+}
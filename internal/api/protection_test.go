@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// adminRequest returns a request authenticated with the admin (not superadmin) role.
+func adminRequest(method, target string) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	return r.WithContext(context.WithValue(r.Context(), auth.ContextKeyRole, auth.RoleAdmin))
+}
+
+func newProtectedFlagServer(t *testing.T) (*Server, *store.MemoryStore) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	if err := st.UpsertFlag(context.Background(), store.UpsertParams{Key: "payments", Enabled: true, Rollout: 100, Protected: true, Env: "prod"}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return NewServer(st, "prod", "test-key"), st
+}
+
+func TestProtectedFlag_AdminCannotDeleteOrDisable(t *testing.T) {
+	srv, st := newProtectedFlagServer(t)
+	ctx := context.Background()
+
+	if werr := srv.removeFlag(adminRequest(http.MethodDelete, "/v1/flags?key=payments&env=prod"), "payments", "prod"); werr == nil || werr.code != ErrCodeFlagProtected {
+		t.Fatalf("expected FLAG_PROTECTED on delete, got %v", werr)
+	}
+
+	env := "prod"
+	disable := upsertRequest{Key: "payments", Enabled: false, Rollout: 100, Env: &env}
+	if _, werr := srv.saveFlag(adminRequest(http.MethodPost, "/v1/flags"), disable); werr == nil || werr.status != http.StatusForbidden {
+		t.Fatalf("expected 403 on disable, got %v", werr)
+	}
+
+	unprotect := new(bool)
+	update := upsertRequest{Key: "payments", Enabled: true, Rollout: 100, Protected: unprotect, Env: &env}
+	if _, werr := srv.saveFlag(adminRequest(http.MethodPost, "/v1/flags"), update); werr == nil {
+		t.Fatal("expected unprotecting to be rejected")
+	}
+
+	// Non-destructive updates keep the flag protected.
+	harmless := upsertRequest{Key: "payments", Description: "card payments", Enabled: true, Rollout: 50, Env: &env}
+	if _, werr := srv.saveFlag(adminRequest(http.MethodPost, "/v1/flags"), harmless); werr != nil {
+		t.Fatalf("harmless update rejected: %v", werr)
+	}
+	flag, err := st.GetFlagByKey(ctx, "payments", "prod")
+	if err != nil || !flag.Protected || !flag.Enabled || flag.Rollout != 50 {
+		t.Fatalf("unexpected flag after update: %+v, %v", flag, err)
+	}
+}
+
+func TestProtectedFlag_ForceRequiresReason(t *testing.T) {
+	srv, st := newProtectedFlagServer(t)
+
+	werr := srv.removeFlag(adminRequest(http.MethodDelete, "/v1/flags?key=payments&env=prod&force=true"), "payments", "prod")
+	if werr == nil || werr.fields["reason"] == "" {
+		t.Fatalf("expected reason validation error, got %v", werr)
+	}
+
+	if werr := srv.removeFlag(adminRequest(http.MethodDelete, "/v1/flags?key=payments&env=prod&force=true&reason=incident+42"), "payments", "prod"); werr != nil {
+		t.Fatalf("forced delete failed: %v", werr)
+	}
+	if _, err := st.GetFlagByKey(context.Background(), "payments", "prod"); err == nil {
+		t.Error("flag should be deleted")
+	}
+}
+
+func TestProtectedFlag_SuperadminCanDelete(t *testing.T) {
+	srv, _ := newProtectedFlagServer(t)
+
+	// The legacy admin key authenticates as superadmin.
+	req := httptest.NewRequest(http.MethodDelete, "/v1/flags?key=payments&env=prod", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/httprate"
+
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+)
+
+// Requests are rate limited per client IP (limitByIP) and, when configured,
+// per tenant (SetTenantRateLimit). Evaluation requests have their own
+// buckets (SetEvaluationRateLimits): per SDK key when one is presented and
+// per IP otherwise, so anonymous callers behind a NAT cannot starve SDK
+// clients sharing the address. Every limited response carries
+//
+//	X-RateLimit-Limit       requests allowed per window
+//	X-RateLimit-Remaining   requests left in the window
+//	X-RateLimit-Reset       Unix time the window ends
+//
+// so clients can slow down before they are refused. When several limits
+// apply to a request, the headers describe the one with the fewest requests
+// remaining, which is the one the client runs into first.
+
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// EvaluationRateLimits are the per-minute request budgets of the evaluation
+// routes (evaluate, OFREP, exposures and metric events). 0 disables a limit.
+type EvaluationRateLimits struct {
+	Anonymous int // per client IP, for requests without an SDK key
+	SDKKey    int // per SDK key, shared by all clients using the key
+}
+
+// DefaultEvaluationRateLimits are the evaluation rate limits of a new server.
+var DefaultEvaluationRateLimits = EvaluationRateLimits{
+	Anonymous: 300,
+	SDKKey:    3000,
+}
+
+// evaluationLimiters holds the limiters of EvaluationRateLimits; nil
+// limiters are disabled.
+type evaluationLimiters struct {
+	anonymous *httprate.RateLimiter
+	sdkKey    *httprate.RateLimiter
+}
+
+// SetEvaluationRateLimits replaces the evaluation rate limits (see
+// EvaluationRateLimits). Request counts start over.
+func (s *Server) SetEvaluationRateLimits(limits EvaluationRateLimits) {
+	l := &evaluationLimiters{}
+	if limits.Anonymous > 0 {
+		l.anonymous = newRateLimiter(limits.Anonymous, "ip")
+	}
+	if limits.SDKKey > 0 {
+		l.sdkKey = newRateLimiter(limits.SDKKey, "sdk_key")
+	}
+	s.evalLimiters = l
+}
+
+// limitEvaluations applies the evaluation rate limits. It runs after
+// resolveSDKEnv, so only valid SDK keys get their own bucket.
+func (s *Server) limitEvaluations(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiters := s.evalLimiters
+		if key, ok := requestSDKKey(r); ok {
+			if limiters.sdkKey != nil && respondOnLimit(limiters.sdkKey, w, r, "sdk_key:"+key.ID) {
+				return
+			}
+		} else if limiters.anonymous != nil {
+			ip, _ := httprate.KeyByIP(r)
+			if respondOnLimit(limiters.anonymous, w, r, ip) {
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newRateLimiter allows n requests per minute per key, counting refusals in
+// rate_limit_hits_total{type=kind}.
+func newRateLimiter(n int, kind string) *httprate.RateLimiter {
+	return httprate.NewRateLimiter(n, time.Minute,
+		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+			telemetry.RateLimitHits.WithLabelValues(kind).Inc()
+			errResp := NewErrorResponse(http.StatusTooManyRequests, ErrCodeRateLimited, "Rate limit exceeded")
+			writeErrorResponse(w, r, http.StatusTooManyRequests, errResp)
+		}),
+	)
+}
+
+// limitByIP limits each client IP to n requests per minute.
+func limitByIP(n int) func(http.Handler) http.Handler {
+	limiter := newRateLimiter(n, "ip")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, _ := httprate.KeyByIP(r)
+			if respondOnLimit(limiter, w, r, ip) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// respondOnLimit applies limiter to key like RateLimiter.RespondOnLimit, but
+// keeps the rate limit headers of an earlier limit with fewer requests
+// remaining.
+func respondOnLimit(limiter *httprate.RateLimiter, w http.ResponseWriter, r *http.Request, key string) bool {
+	h := w.Header()
+	earlier := [3]string{h.Get(headerRateLimitLimit), h.Get(headerRateLimitRemaining), h.Get(headerRateLimitReset)}
+	if limiter.RespondOnLimit(w, r, key) {
+		return true
+	}
+	if before, err := strconv.Atoi(earlier[1]); err == nil {
+		if now, err := strconv.Atoi(h.Get(headerRateLimitRemaining)); err == nil && before < now {
+			h.Set(headerRateLimitLimit, earlier[0])
+			h.Set(headerRateLimitRemaining, earlier[1])
+			h.Set(headerRateLimitReset, earlier[2])
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	handler := srv.Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if got := rr.Header().Get(headerRateLimitLimit); got != "100" {
+		t.Errorf("expected limit 100, got %q", got)
+	}
+	if got := rr.Header().Get(headerRateLimitRemaining); got != "99" {
+		t.Errorf("expected 99 remaining, got %q", got)
+	}
+	if rr.Header().Get(headerRateLimitReset) == "" {
+		t.Error("expected a reset time")
+	}
+
+	// Evaluation has its own buckets and does not count towards the limit
+	// of the other routes.
+	req = httptest.NewRequest(http.MethodPost, "/v1/evaluate", strings.NewReader(`{"context":{"id":"u1"}}`))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if got := rr.Header().Get(headerRateLimitLimit); got != "300" {
+		t.Errorf("expected the evaluation limit of 300, got %q", got)
+	}
+	if got := rr.Header().Get(headerRateLimitRemaining); got != "299" {
+		t.Errorf("expected 299 remaining, got %q", got)
+	}
+}
+
+func TestEvaluationRateLimits_SeparateBuckets(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetEvaluationRateLimits(EvaluationRateLimits{Anonymous: 2, SDKKey: 3})
+	handler := srv.Router()
+	key := createSDKKeyForTest(t, handler, "web", "prod").Key
+
+	evaluate := func(sdkKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/flags/evaluate?userId=u1", nil)
+		if sdkKey != "" {
+			req.Header.Set("Authorization", "Bearer "+sdkKey)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := evaluate(""); rr.Code != http.StatusOK {
+			t.Fatalf("anonymous request %d: expected 200, got %d", i, rr.Code)
+		}
+	}
+	if rr := evaluate(""); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("anonymous budget exhausted: expected 429, got %d", rr.Code)
+	}
+
+	// SDK key requests from the same IP have their own budget.
+	for i := 0; i < 3; i++ {
+		rr := evaluate(key)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("SDK key request %d: expected 200, got %d", i, rr.Code)
+		}
+		if got := rr.Header().Get(headerRateLimitLimit); got != "3" {
+			t.Errorf("expected the SDK key limit of 3, got %q", got)
+		}
+	}
+	if rr := evaluate(key); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("SDK key budget exhausted: expected 429, got %d", rr.Code)
+	}
+
+	// 0 disables a limit.
+	srv.SetEvaluationRateLimits(EvaluationRateLimits{SDKKey: 3})
+	if rr := evaluate(""); rr.Code != http.StatusOK || rr.Header().Get(headerRateLimitLimit) != "" {
+		t.Errorf("disabled anonymous limit: expected 200 without rate limit headers, got %d %q", rr.Code, rr.Header().Get(headerRateLimitLimit))
+	}
+}
+
+func TestTenants_RateLimitHeaders(t *testing.T) {
+	srv := newTenantTestServer(t)
+	srv.SetTenantRateLimit(5)
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags", "")
+	if got := rr.Header().Get(headerRateLimitLimit); got != "5" {
+		t.Errorf("expected the tenant limit of 5, got %q", got)
+	}
+	if got := rr.Header().Get(headerRateLimitRemaining); got != "4" {
+		t.Errorf("expected 4 remaining, got %q", got)
+	}
+}
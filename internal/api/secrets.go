@@ -0,0 +1,19 @@
+package api
+
+import "github.com/TimurManjosov/goflagship/internal/webhook"
+
+// SetAdminAPIKey replaces the legacy ADMIN_API_KEY at runtime, e.g. after it
+// was rotated in a secrets backend. Requests using the old key are rejected
+// from then on.
+func (s *Server) SetAdminAPIKey(key string) {
+	s.auth.SetLegacyAdminKey(key)
+}
+
+// SetWebhookSecretLookup signs outgoing webhook deliveries with secrets
+// managed outside the database (see webhook.Dispatcher.SetSecretLookup). It
+// is a no-op without a Postgres store, which has no webhooks.
+func (s *Server) SetWebhookSecretLookup(lookup webhook.SecretLookup) {
+	if s.webhookDispatcher != nil {
+		s.webhookDispatcher.SetSecretLookup(lookup)
+	}
+}
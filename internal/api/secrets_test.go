@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestSetAdminAPIKey_RotatesLegacyKey(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	handler := srv.Router()
+
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/flags", ""); rr.Code != http.StatusOK {
+		t.Fatalf("old key before rotation: expected 200, got %d", rr.Code)
+	}
+
+	srv.SetAdminAPIKey("rotated-key")
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/flags", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("old key after rotation: expected 401, got %d", rr.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/flags", nil)
+	req.Header.Set("Authorization", "Bearer rotated-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("new key: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	for header, want := range map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "DENY",
+		"Referrer-Policy":         "no-referrer",
+		"Content-Security-Policy": apiContentSecurityPolicy,
+	} {
+		if got := rr.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if rr.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("HSTS should only be sent over HTTPS")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Header().Get("Strict-Transport-Security") == "" {
+		t.Error("expected HSTS behind a TLS-terminating proxy")
+	}
+}
+
+func TestRequireJSONContentType(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+	body := `{"key":"banner","enabled":true,"rollout":100}`
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		auth        bool
+		want        int
+	}{
+		{"admin without content type", "/v1/flags", "", true, http.StatusUnsupportedMediaType},
+		{"admin form encoded", "/v1/flags", "application/x-www-form-urlencoded", true, http.StatusUnsupportedMediaType},
+		{"admin json with charset", "/v1/flags", "application/json; charset=utf-8", true, http.StatusOK},
+		{"unauthenticated is still 401", "/v1/flags", "text/plain", false, http.StatusUnauthorized},
+		{"client endpoints stay lenient", "/v1/evaluate", "", false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody := body
+			if tt.path == "/v1/evaluate" {
+				reqBody = `{"context":{"id":"u1"}}`
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(reqBody))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.auth {
+				req.Header.Set("Authorization", "Bearer test-key")
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+			if tt.want == http.StatusUnsupportedMediaType && decodeErrorResponse(t, rr.Body.Bytes()).Code != ErrCodeUnsupportedMediaType {
+				t.Errorf("expected UNSUPPORTED_MEDIA_TYPE, got %s", rr.Body.String())
+			}
+		})
+	}
+
+	// Bodyless mutations need no content type.
+	req := httptest.NewRequest(http.MethodPost, "/v1/flags/bulk-action", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code == http.StatusUnsupportedMediaType {
+		t.Error("a request without a body should not be rejected with 415")
+	}
+}
+
+func TestStrictJSONDecoding(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+		wantMsg   string
+	}{
+		{"unknown field", `{"key":"banner","enabled":true,"rolout":50}`, "rolout", `unknown field "rolout"`},
+		{"wrong type", `{"key":"banner","enabled":"yes"}`, "enabled", "field has the wrong type"},
+		{"syntax error", `{"key":"banner",}`, "", "syntax error at byte"},
+		{"empty body", ``, "", "request body is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", tt.body)
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			resp := decodeErrorResponse(t, rr.Body.Bytes())
+			if resp.Code != ErrCodeInvalidJSON || !strings.Contains(resp.Message, tt.wantMsg) {
+				t.Errorf("unexpected error: %+v", resp)
+			}
+			if tt.wantField != "" && resp.Fields[tt.wantField] == "" {
+				t.Errorf("expected field %q in %v", tt.wantField, resp.Fields)
+			}
+		})
+	}
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"banner","config":"`+strings.Repeat("x", maxJSONBodySize)+`"}`)
+	if rr.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rr.Body.String(), "1MB") {
+		t.Errorf("expected 413 mentioning the 1MB limit, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
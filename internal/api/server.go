@@ -35,13 +35,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/audit"
 	"github.com/TimurManjosov/goflagship/internal/auth"
 	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+	"github.com/TimurManjosov/goflagship/internal/gitsync"
 	"github.com/TimurManjosov/goflagship/internal/rules"
 	"github.com/TimurManjosov/goflagship/internal/snapshot"
 	"github.com/TimurManjosov/goflagship/internal/store"
@@ -49,6 +53,7 @@ import (
 	"github.com/TimurManjosov/goflagship/internal/telemetry"
 	"github.com/TimurManjosov/goflagship/internal/validation"
 	"github.com/TimurManjosov/goflagship/internal/webhook"
+	"github.com/TimurManjosov/goflagship/internal/worker"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -62,23 +67,64 @@ const (
 	// maxAuditExportLimit is the maximum number of audit logs that can be exported at once
 	maxAuditExportLimit = 10000
 
-	// maxFlagRequestBodySize limits flag write request payloads to 1 MB.
-	maxFlagRequestBodySize = 1 << 20
+	// streamHeartbeatInterval is how often idle SSE connections receive a ping.
+	streamHeartbeatInterval = 25 * time.Second
+
+	// Snapshot response headers, see setSnapshotHeaders.
+	headerFlagCount   = "X-Flagship-Flag-Count"
+	headerSnapshotAge = "X-Flagship-Snapshot-Age" // Seconds since the snapshot was built
 )
 
 type Server struct {
 	store             store.Store
 	env               string
-	adminAPIKey       string
 	auth              *auth.Authenticator
 	auditService      *audit.Service
 	webhookDispatcher *webhook.Dispatcher
+
+	gitSync              *gitsync.Syncer // optional, see SetGitSync
+	gitSyncWebhookSecret string
+
+	requireSDKKey bool // see SetRequireSDKKey
+
+	snapshotPublisher SnapshotPublisher // optional, see SetSnapshotPublisher
+
+	evalTracker      *evaluationTracker    // last evaluation per flag, see evaltracker.go
+	attributes       *attributeTracker     // context attributes seen, see meta.go
+	insights         *insightsTracker      // sampled evaluation results, see insights.go
+	evalErrors       *evalErrorTracker     // failing flag evaluations, see evalerrors.go
+	shadows          *shadowTracker        // shadow flag versions, see shadows.go
+	userOverrides    *userOverrideTracker  // per-user flag overrides, see overrides.go
+	guardrailMetrics *guardrailMetrics     // error reports for guardrails, see guardrails.go
+	metricEvents     *metricEventLog       // reported metric events, see metricevents.go
+	cohorts          *cohortTracker        // users exposed per flag variant, see cohorts.go
+	evalSampler      *evaluationSampler    // see SetEvaluationAuditSampling
+	cleanupPolicy    CleanupPolicy         // see SetCleanupPolicy
+	flagExpiryAction FlagExpiryAction      // see SetFlagExpiryAction
+	summaryReport    SummaryReportConfig   // see SetSummaryReport
+	trashRetention   time.Duration         // see SetTrashRetention
+	webhookRetention time.Duration         // see SetWebhookDeliveryRetention
+	limits           Limits                // see SetLimits
+	timeouts         Timeouts              // see SetTimeouts
+	contextLimits    ContextLimits         // see SetContextLimits
+	tenantLimiter    *httprate.RateLimiter // see SetTenantRateLimit
+	evalLimiters     *evaluationLimiters   // see SetEvaluationRateLimits
+	keyring          *encryption.Keyring   // see SetEncryption
+	stepUp           *auth.TOTP            // see SetStepUpTOTP
+	webhookPolicy    *webhook.URLPolicy    // see SetWebhookURLPolicy
+	setup            setupState            // pending first-run setup, see setup.go
+	keyExpiry        keyExpiryNotifier     // key expiry warnings sent, see keyexpiry.go
+	keyIdle          keyIdleState          // see SetKeyIdlePolicy
+	workers          *worker.Registry      // supervised background workers, see workers.go
+
+	attrEnforcement AttributeEnforcement // see SetAttributeEnforcement
+	registry        *attributeRegistry   // declared context attributes, see attributes.go
 }
 
 // NewServer creates a new API server with the given store, environment, and admin key.
 //
 // Parameters:
-//   - s: Store implementation (postgres or memory). Must not be nil.
+//   - s: Store implementation (postgres, memory or file). Must not be nil.
 //   - env: Environment name for flag operations (e.g., "prod", "dev"). Must not be empty.
 //   - adminKey: Legacy admin API key for backward compatibility. May be empty if using database keys.
 //
@@ -127,13 +173,43 @@ func NewServer(s store.Store, env, adminKey string) *Server {
 		}
 	}
 
+	// Block internal webhook targets until SetWebhookURLPolicy says otherwise
+	webhookPolicy, _ := webhook.NewURLPolicy(webhook.URLPolicyConfig{})
+	if webhookDisp != nil {
+		webhookDisp.SetURLPolicy(webhookPolicy)
+	}
+
 	srv := &Server{
 		store:             s,
 		env:               env,
-		adminAPIKey:       adminKey,
 		auth:              authenticator,
 		auditService:      auditSvc,
 		webhookDispatcher: webhookDisp,
+		timeouts:          DefaultTimeouts,
+		contextLimits:     DefaultContextLimits,
+		evalTracker:       newEvaluationTracker(),
+		attributes:        newAttributeTracker(),
+		insights:          newInsightsTracker(),
+		evalErrors:        newEvalErrorTracker(),
+		shadows:           newShadowTracker(),
+		userOverrides:     newUserOverrideTracker(),
+		guardrailMetrics:  newGuardrailMetrics(),
+		metricEvents:      newMetricEventLog(),
+		cohorts:           newCohortTracker(),
+		registry:          newAttributeRegistry(),
+		cleanupPolicy:     DefaultCleanupPolicy(),
+		trashRetention:    DefaultTrashRetention,
+		webhookRetention:  DefaultWebhookDeliveryRetention,
+		keyIdle:           keyIdleState{policy: DefaultKeyIdlePolicy()},
+		webhookPolicy:     webhookPolicy,
+		workers:           worker.NewRegistry(),
+	}
+	srv.SetEvaluationRateLimits(DefaultEvaluationRateLimits)
+	if auditSvc != nil {
+		srv.workers.Add(auditSvc.Workers()...)
+	}
+	if webhookDisp != nil {
+		srv.workers.Add(webhookDisp.Worker())
 	}
 
 	return srv
@@ -195,101 +271,314 @@ func (s *Server) requireQueries(w http.ResponseWriter, r *http.Request) *dbgen.Q
 	return queries
 }
 
+// readPrimaryForWrites keeps every read of a mutating request on the primary
+// database (see store.ReadPrimary): writes are checked against what they
+// read, which a lagging replica could have missed.
+func readPrimaryForWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			r = r.WithContext(store.ReadPrimary(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) Router() http.Handler {
 	// inside (s *Server) Router():
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID, middleware.RealIP, middleware.Recoverer)
+	r.Use(telemetry.TraceMiddleware)
 	r.Use(telemetry.Middleware)
+	r.Use(securityHeaders) // see security.go
+	r.Use(readPrimaryForWrites)
 
 	// CORS for browser clients (adjust origins as needed)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:5173", "http://localhost:8080"},
-		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-None-Match"},
-		ExposedHeaders:   []string{"ETag"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match", "If-None-Match"},
+		ExposedHeaders:   []string{"ETag", headerFlagCount, headerSnapshotAge, telemetry.HeaderRequestID, headerRateLimitLimit, headerRateLimitRemaining, headerRateLimitReset},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
+	// Evaluate endpoint - public, no auth required by default. Evaluations
+	// have their own rate limit buckets, per SDK key or IP, see ratelimit.go
+	r.Group(func(r chi.Router) {
+		r.Use(s.withTimeout(evaluateTimeout))
+		r.Use(s.resolveSDKEnv) // SDK keys select the environment, see sdk_keys.go
+		r.Use(s.limitEvaluations)
+		r.Post("/v1/evaluate", telemetry.TimeEvaluation(telemetry.EvalHandlerContext, s.handleContextEvaluate))
+		r.Post("/v1/flags/evaluate", telemetry.TimeEvaluation(telemetry.EvalHandlerFlags, s.handleEvaluate))
+		r.Get("/v1/flags/evaluate", telemetry.TimeEvaluation(telemetry.EvalHandlerFlags, s.handleEvaluateGET))
+
+		// OpenFeature Remote Evaluation Protocol, see ofrep.go
+		r.Post("/ofrep/v1/evaluate/flags", telemetry.TimeEvaluation(telemetry.EvalHandlerOFREP, s.handleOFREPEvaluateFlags))
+		r.Post("/ofrep/v1/evaluate/flags/{key}", telemetry.TimeEvaluation(telemetry.EvalHandlerOFREP, s.handleOFREPEvaluateFlag))
+
+		// SDK exposure events, see exposures.go
+		r.Post("/v1/exposures", s.handleIngestExposures)
+
+		// Metric events for experiment results and guardrails, see metricevents.go
+		r.Post("/v1/events/metrics", s.handleIngestMetricEvents)
+	})
+
 	// Normal routes with timeout + rate limit
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.Timeout(5 * time.Second))
-		r.Use(httprate.LimitByIP(100, time.Minute)) // 100 req/min per IP
+		r.Use(s.withTimeout(defaultTimeout)) // see timeouts.go
+		r.Use(limitByIP(100))                // 100 req/min per IP, see ratelimit.go
 
 		r.Get("/healthz", s.handleHealth)
-		r.Get("/v1/flags/snapshot", s.handleSnapshot)
-
-		// Evaluate endpoint - public, no auth required by default
-		// Higher rate limit for evaluation (300 req/min per IP)
-		r.Group(func(r chi.Router) {
-			r.Use(httprate.LimitByIP(300, time.Minute))
-			r.Post("/v1/evaluate", s.handleContextEvaluate)
-			r.Post("/v1/flags/evaluate", s.handleEvaluate)
-			r.Get("/v1/flags/evaluate", s.handleEvaluateGET)
-		})
+		r.Get("/readyz", s.handleReadyz)   // see workers.go
+		r.Post("/v1/setup", s.handleSetup) // gated by the setup token, see setup.go
+		r.With(s.resolveSDKEnv).Get("/v1/flags/snapshot", s.handleSnapshot)
+		r.With(s.resolveSDKEnv).Get("/v1/client/bootstrap", s.handleClientBootstrap)
 
 		r.Route("/v1/flags", func(r chi.Router) {
-			r.Use(s.auth.RequireAuth(auth.RoleAdmin))
+			r.Use(s.requireAuth(auth.RoleAdmin))
 			r.Get("/", s.handleListFlags)
 			r.Post("/", s.handleUpsertFlag)
-			r.Get("/{id}", s.handleGetFlag)
-			r.Put("/{id}", s.handleUpdateFlag)
+			r.With(s.withTimeout(bulkTimeout), requireAllProjects).Post("/bulk-action", s.handleBulkAction) // see bulk.go
+			r.With(requireOperator, requireAllProjects).Get("/cleanup-report", s.handleCleanupReport)       // see cleanup.go
+			r.With(requireAllProjects).Get("/export", s.handleExportFlags)                                  // see export.go
+			r.With(requireAllProjects).Get("/stale", s.handleListStaleFlags)                                // see stale.go
+			r.With(s.withTimeout(bulkTimeout), requireAllProjects).Post("/import", s.handleImportFlags)
+			r.With(requireAllProjects).Post("/{id}/restore", s.handleRestoreFlag) // see trash.go
+			r.Group(func(r chi.Router) {
+				r.Use(s.requireFlagProject) // see projects.go
+				r.Get("/{id}", s.handleGetFlag)
+				r.Get("/{id}/comments", s.handleListFlagComments)   // see comments.go
+				r.Post("/{id}/comments", s.handleCreateFlagComment) // see comments.go
+				r.Get("/{id}/insights", s.handleFlagInsights)       // see insights.go
+				r.Get("/{id}/results", s.handleExperimentResults)   // see metricevents.go
+				r.Get("/{id}/aa-test", s.handleAATest)              // see aatest.go
+				r.Get("/{id}/cohort", s.handleFlagCohort)           // see cohorts.go
+				r.Get("/{id}/shadow", s.handleGetShadow)            // see shadows.go
+				r.Put("/{id}/shadow", s.handleSetShadow)
+				r.Delete("/{id}/shadow", s.handleDeleteShadow)
+				r.Post("/{id}/shadow/promote", s.handlePromoteShadow)
+				r.Get("/{id}/overrides", s.handleListUserOverrides) // see overrides.go
+				r.Put("/{id}/overrides/{userId}", s.handleSetUserOverride)
+				r.Delete("/{id}/overrides/{userId}", s.handleDeleteUserOverride)
+				r.Get("/{id}/guardrail", s.handleGetGuardrail) // see guardrails.go
+				r.Put("/{id}/guardrail", s.handleSetGuardrail)
+				r.Delete("/{id}/guardrail", s.handleDeleteGuardrail)
+				r.Post("/{id}/guardrail/metrics", s.handleGuardrailMetrics)
+				r.Post("/{id}/guardrail/reset", s.handleResetGuardrail)
+				r.Post("/{id}/archive", s.handleArchiveFlag) // see archive.go
+				r.Post("/{id}/unarchive", s.handleUnarchiveFlag)
+				r.Get("/{id}/history", s.handleFlagHistory)               // see history.go
+				r.Get("/{id}/environments", s.handleListFlagEnvironments) // see flagenvs.go
+				r.Put("/{id}/star", s.handleStarFlag)                     // see stars.go
+				r.Delete("/{id}/star", s.handleUnstarFlag)
+				r.Post("/{id}/rollback/{version}", s.handleRollbackFlag)
+				r.Put("/{id}", s.handleUpdateFlag)
+			})
 			r.Delete("/", s.handleDeleteFlag)
 		})
 
+		// Deleted flags (admin+), see trash.go
+		r.Route("/v1/trash", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), requireAllProjects)
+			r.Get("/", s.handleListTrash)
+			r.Post("/{key}/restore", s.handleRestoreFlag)
+		})
+
+		// Environment cloning and ephemeral environments (admin+), see environments.go and ephemeral.go
+		r.Route("/v1/environments", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), requireAllProjects)
+			r.With(requireOperator).Get("/ephemeral", s.handleListEphemeralEnvironments)
+			r.With(s.withTimeout(bulkTimeout)).Post("/{env}/clone", s.handleCloneEnvironment)
+		})
+
+		// Declarative desired-state apply (admin+), see apply.go
+		r.With(s.withTimeout(bulkTimeout), s.requireAuth(auth.RoleAdmin), requireAllProjects).Post("/v1/apply", s.handleApply)
+		r.With(s.withTimeout(bulkTimeout), s.requireAuth(auth.RoleAdmin), requireAllProjects).Post("/v1/transactions", s.handleTransaction) // see transactions.go
+
+		// Flag definition linting (admin+), see lint.go
+		r.With(s.requireAuth(auth.RoleAdmin)).Post("/v1/lint", s.handleLint)
+
+		// Staged change sets (admin+), see changesets.go
+		r.Route("/v1/changesets", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), requireAllProjects)
+			r.Get("/", s.handleListChangeSets)
+			r.Post("/", s.handleCreateChangeSet)
+			r.Get("/{id}", s.handleGetChangeSet)
+			r.Put("/{id}/operations/{key}", s.handleStageChangeSetOperation)
+			r.Delete("/{id}/operations/{key}", s.handleUnstageChangeSetOperation)
+			r.Get("/{id}/diff", s.handleChangeSetDiff)
+			r.With(s.withTimeout(bulkTimeout)).Post("/{id}/apply", s.handleApplyChangeSet)
+			r.Post("/{id}/discard", s.handleDiscardChangeSet)
+		})
+
+		// Projects (admin+; changes need an unscoped operator key), see projects.go
+		r.Route("/v1/projects", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin))
+			r.Get("/", s.handleListProjects)
+			r.Get("/{key}", s.handleGetProject)
+			r.Group(func(r chi.Router) {
+				r.Use(requireOperator, requireAllProjects)
+				r.Post("/", s.handleCreateProject)
+				r.Put("/{key}", s.handleUpdateProject)
+				r.With(s.requireStepUp(audit.ResourceTypeProject, "key")).Delete("/{key}", s.handleDeleteProject) // see stepup.go
+			})
+		})
+
+		// Named evaluation contexts (admin+), see presets.go
+		r.Route("/v1/context-presets", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), requireAllProjects)
+			r.Get("/", s.handleListContextPresets)
+			r.Get("/{name}", s.handleGetContextPreset)
+			r.Put("/{name}", s.handlePutContextPreset)
+			r.Delete("/{name}", s.handleDeleteContextPreset)
+		})
+
+		// Rule builder metadata (see meta.go)
+		r.Route("/v1/meta", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), requireAllProjects)
+			r.Get("/operators", s.handleListOperators)
+			r.Get("/attributes", s.handleListAttributes)
+		})
+
 		// Admin API key management routes (superadmin only)
 		r.Route("/v1/admin/keys", func(r chi.Router) {
-			r.Use(s.auth.RequireAuth(auth.RoleSuperadmin))
+			r.Use(s.requireAuth(auth.RoleSuperadmin))
 			r.Post("/", s.handleCreateAPIKey)
 			r.Get("/", s.handleListAPIKeys)
-			r.Delete("/{id}", s.handleRevokeAPIKey)
+			r.Get("/expiring", s.handleListExpiringKeys)
+			r.Post("/{id}/enable", s.handleReenableAPIKey)
+			r.With(s.requireStepUp(audit.ResourceTypeAPIKey, "id")).Delete("/{id}", s.handleRevokeAPIKey)
+		})
+
+		// Per-environment SDK keys (admin+), see sdk_keys.go. An SDK key reads
+		// every flag of its environment, so it spans projects.
+		r.Route("/v1/admin/sdk-keys", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), requireAllProjects)
+			r.Post("/", s.handleCreateSDKKey)
+			r.Get("/", s.handleListSDKKeys)
+			r.Delete("/{id}", s.handleRevokeSDKKey)
 		})
 
 		// Webhook management routes (admin+)
 		r.Route("/v1/admin/webhooks", func(r chi.Router) {
-			r.Use(s.auth.RequireAuth(auth.RoleAdmin))
+			r.Use(s.requireAuth(auth.RoleAdmin))
 			r.Get("/", s.handleListWebhooks)
 			r.Post("/", s.handleCreateWebhook)
 			r.Get("/{id}", s.handleGetWebhook)
 			r.Put("/{id}", s.handleUpdateWebhook)
-			r.Delete("/{id}", s.handleDeleteWebhook)
+			r.With(s.requireStepUp(audit.ResourceTypeWebhook, "id")).Delete("/{id}", s.handleDeleteWebhook) // see stepup.go
 			r.Get("/{id}/deliveries", s.handleListWebhookDeliveries)
+			r.Delete("/{id}/deliveries", s.handlePurgeWebhookDeliveries) // see webhook_retention.go
 			r.Post("/{id}/test", s.handleTestWebhook)
+			r.Post("/{id}/secret", s.handleRotateWebhookSecret) // see encryption.go
 		})
 
-		// Audit logs routes (admin+)
-		r.With(s.auth.RequireAuth(auth.RoleAdmin)).Get("/v1/admin/audit-logs", s.handleListAuditLogs)
-		r.With(s.auth.RequireAuth(auth.RoleAdmin)).Get("/v1/admin/audit-logs/export", s.handleExportAuditLogs)
+		// Re-encrypt stored secrets with the primary key (superadmin), see encryption.go
+		r.With(s.requireAuth(auth.RoleSuperadmin), requireOperator, s.requireStepUp(audit.ResourceTypeSystem, "")).Post("/v1/admin/encryption/rotate", s.handleRotateEncryption)
+
+		// Audit logs routes (admin+); they span projects
+		r.With(s.requireAuth(auth.RoleAdmin), requireAllProjects).Get("/v1/admin/audit-logs", s.handleListAuditLogs)
+		r.With(s.withTimeout(bulkTimeout), s.requireAuth(auth.RoleAdmin), requireAllProjects).Get("/v1/admin/audit-logs/export", s.handleExportAuditLogs)
+		r.With(s.requireAuth(auth.RoleAdmin), requireAllProjects).Get("/v1/admin/audit-logs/{id}/diff", s.handleAuditLogDiff) // see auditdiff.go
+
+		// Recent activity feed (admin+), see activity.go
+		r.With(s.requireAuth(auth.RoleAdmin), requireOperator, requireAllProjects).Get("/v1/admin/activity", s.handleActivityFeed)
+
+		// Summary report preview (admin+), see summary.go
+		r.With(s.requireAuth(auth.RoleAdmin), requireOperator).Get("/v1/admin/reports/summary", s.handleSummaryReport)
+
+		// Flags failing evaluation (admin+), see evalerrors.go
+		r.With(s.requireAuth(auth.RoleAdmin)).Get("/v1/admin/stats", s.handleAdminStats)
+
+		// Search across flags, webhooks and audit logs (admin+), see search.go
+		r.With(s.requireAuth(auth.RoleAdmin), requireAllProjects).Get("/v1/search", s.handleSearch)
+
+		// Git sync (admin+), see gitsync.go
+		r.Route("/v1/admin/gitsync", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), requireOperator, requireAllProjects)
+			r.Get("/status", s.handleGitSyncStatus)
+			r.With(s.withTimeout(bulkTimeout)).Post("/sync", s.handleGitSyncRun)
+		})
+		r.Post("/v1/gitsync/webhook", s.handleGitSyncWebhook)
+
+		// GraphQL admin API (admin+), see graphql.go
+		r.With(s.requireAuth(auth.RoleAdmin), requireOperator, requireAllProjects).Post("/graphql", s.handleGraphQL)
+
+		// v2 resource-oriented routes (admin+), see v2.go
+		r.Route("/v2/projects/{project}/environments/{env}/flags", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin))
+			r.Use(s.requireKnownProject, requireTenantEnvParam)
+			r.Get("/", s.handleV2ListFlags)
+			r.Get("/{key}", s.handleV2GetFlag)
+			r.Put("/{key}", s.handleV2PutFlag)
+			r.Patch("/{key}", s.handleV2PatchFlag)
+			r.Delete("/{key}", s.handleV2DeleteFlag)
+		})
+
+		// Context attribute registry (admin+), see attributes.go
+		r.Route("/v2/projects/{project}/attributes", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), s.requireKnownProject)
+			r.Get("/", s.handleListContextAttributes)
+			r.With(requireOperator).Put("/{name}", s.handlePutContextAttribute)
+			r.With(requireOperator).Delete("/{name}", s.handleDeleteContextAttribute)
+		})
+
+		// Readiness checklist for enabling flags (admin+), see readiness.go
+		r.Route("/v2/projects/{project}/readiness", func(r chi.Router) {
+			r.Use(s.requireAuth(auth.RoleAdmin), s.requireKnownProject)
+			r.Get("/", s.handleGetReadiness)
+			r.With(requireOperator).Put("/", s.handlePutReadiness)
+			r.With(requireOperator).Delete("/", s.handleDeleteReadiness)
+		})
 	})
 
 	// SSE route: no timeout, but optional gentle rate limit on connects
 	r.Group(func(r chi.Router) {
-		r.Use(httprate.LimitByIP(30, time.Minute)) // 30 connects/min per IP
+		r.Use(limitByIP(30)) // 30 connects/min per IP
+		r.Use(s.resolveSDKEnv)
 		r.Get("/v1/flags/stream", s.handleStream)
 	})
 
+	// Long-polling fallback for clients without SSE: held up to a minute,
+	// so it also runs outside the timeouts, see longpoll.go
+	r.Group(func(r chi.Router) {
+		r.Use(limitByIP(60))
+		r.Use(s.resolveSDKEnv)
+		r.Get("/v1/flags/updates", s.handleFlagUpdates)
+	})
+
 	// Serve static files from ./sdk directory
 	// This allows accessing admin.html and index.html from the API server
 	fileServer := http.FileServer(http.Dir("./sdk"))
-	r.Handle("/*", fileServer)
+	r.Handle("/*", staticPageHeaders(fileServer))
 
 	return r
 }
 
 func (s *Server) handleSnapshot(w http.ResponseWriter, req *http.Request) {
-	snap := snapshot.Load()
+	schema, ok := requireSnapshotSchema(w, req)
+	if !ok {
+		return
+	}
+	snap := s.requestSnapshot(req)
+	if project := strings.TrimSpace(req.URL.Query().Get("project")); project != "" {
+		snap = snap.FilterByProject(project) // see projects.go
+	}
+	if tag := strings.TrimSpace(req.URL.Query().Get("tag")); tag != "" {
+		snap = snap.FilterByTag(tag) // only the flags carrying tag, with their own ETag
+	}
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	w.Header().Set("Pragma", "no-cache")
 	w.Header().Set("Expires", "0")
 	w.Header().Set("ETag", snap.ETag)
+	setSnapshotHeaders(w, snap)
 
 	if inm := req.Header.Get("If-None-Match"); inm != "" && inm == snap.ETag {
 		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(snap)
+	writeSnapshot(w, schema, snap)
 }
 
 func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
@@ -306,16 +595,17 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Subscribe to updates
-	updates, unsubscribe := snapshot.Subscribe()
+	// Subscribe to updates for the request's environment
+	env := s.requestEnvironment(r)
+	updates, unsubscribe := s.subscribeEnv(env)
 	defer unsubscribe()
 
 	// Send init immediately
-	snap := snapshot.Load()
+	snap := s.snapshotForEnv(env)
 	writeSSE(w, "init", map[string]string{"etag": snap.ETag})
 	flusher.Flush()
 
-	ticker := time.NewTicker(25 * time.Second)
+	ticker := time.NewTicker(streamHeartbeatInterval)
 	defer ticker.Stop()
 
 	ctx := r.Context()
@@ -338,6 +628,18 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// setSnapshotHeaders describes snap in response headers, so load balancers
+// and SDKs can spot empty or stale snapshots without parsing the body (and on
+// 304 responses, which have none).
+func setSnapshotHeaders(w http.ResponseWriter, snap *snapshot.Snapshot) {
+	age := time.Duration(0)
+	if !snap.UpdatedAt.IsZero() {
+		age = max(time.Since(snap.UpdatedAt), 0)
+	}
+	w.Header().Set(headerFlagCount, strconv.Itoa(len(snap.Flags)))
+	w.Header().Set(headerSnapshotAge, strconv.Itoa(int(age/time.Second)))
+}
+
 func writeSSE(w http.ResponseWriter, event string, data any) {
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
@@ -365,37 +667,64 @@ type variantRequest struct {
 }
 
 type upsertRequest struct {
-	Key            string           `json:"key"`
-	Description    string           `json:"description"`
-	Enabled        bool             `json:"enabled"`
-	Rollout        int32            `json:"rollout"`
-	Expression     *string          `json:"expression,omitempty"`
-	Config         map[string]any   `json:"config,omitempty"`
-	TargetingRules []rules.Rule     `json:"targeting_rules,omitempty"`
-	Variants       []variantRequest `json:"variants,omitempty"` // For A/B testing
-	Env            *string          `json:"env,omitempty"`      // defaults to s.env
+	Key            string            `json:"key"`
+	Description    string            `json:"description"`
+	Enabled        bool              `json:"enabled"`
+	Rollout        int32             `json:"rollout"`
+	Expression     *string           `json:"expression,omitempty"`
+	Config         map[string]any    `json:"config,omitempty"`
+	TargetingRules []rules.Rule      `json:"targeting_rules,omitempty"`
+	Variants       []variantRequest  `json:"variants,omitempty"`  // For A/B testing
+	Protected      *bool             `json:"protected,omitempty"` // nil keeps the current value
+	Tags           []string          `json:"tags,omitempty"`
+	Owner          *string           `json:"owner,omitempty"`      // nil keeps the current value, see ownership.go
+	TicketURL      *string           `json:"ticket_url,omitempty"` // nil keeps the current value
+	Metadata       map[string]string `json:"metadata,omitempty"`   // nil keeps the current value
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"` // nil means the flag does not expire, see expiry.go
+	Env            *string           `json:"env,omitempty"`        // defaults to s.env
+	Project        *string           `json:"project,omitempty"`    // nil keeps the current value, see projects.go
+
+	// ExpectedVersion makes the write conditional, see versions.go. It can
+	// also be sent as an If-Match header.
+	ExpectedVersion *int64 `json:"expected_version,omitempty"`
+
+	configJSON json.RawMessage // Config encoded by validateUpsertRequest
 }
 
 type upsertResponse struct {
-	OK   bool   `json:"ok"`
-	ETag string `json:"etag"`
+	OK      bool   `json:"ok"`
+	ETag    string `json:"etag"`
+	Version int64  `json:"version,omitempty"` // the flag's new version, for the next If-Match
 }
 
 type flagResponse struct {
-	Key            string          `json:"key"`
-	Description    string          `json:"description"`
-	Enabled        bool            `json:"enabled"`
-	Rollout        int32           `json:"rollout"`
-	Expression     *string         `json:"expression,omitempty"`
-	Config         map[string]any  `json:"config,omitempty"`
-	TargetingRules []rules.Rule    `json:"targeting_rules,omitempty"`
-	Variants       []store.Variant `json:"variants,omitempty"`
-	Env            string          `json:"env"`
-	UpdatedAt      time.Time       `json:"updated_at"`
+	Key            string            `json:"key"`
+	Description    string            `json:"description"`
+	Enabled        bool              `json:"enabled"`
+	Rollout        int32             `json:"rollout"`
+	Expression     *string           `json:"expression,omitempty"`
+	Config         map[string]any    `json:"config,omitempty"`
+	TargetingRules []rules.Rule      `json:"targeting_rules,omitempty"`
+	Variants       []store.Variant   `json:"variants,omitempty"`
+	Protected      bool              `json:"protected"`
+	Tags           []string          `json:"tags,omitempty"`
+	Archived       bool              `json:"archived"`
+	Owner          string            `json:"owner,omitempty"`
+	TicketURL      string            `json:"ticket_url,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+	Env            string            `json:"env"`
+	Project        string            `json:"project"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	Version        int64             `json:"version"`
+	DeletedAt      *time.Time        `json:"deleted_at,omitempty"` // only set by ?include_deleted=true, see trash.go
 }
 
 type listFlagsResponse struct {
-	Flags []flagResponse `json:"flags"`
+	Flags  []flagResponse `json:"flags"`
+	Total  int            `json:"total"` // flags matching the filters, across all pages
+	Limit  int            `json:"limit,omitempty"`
+	Offset int            `json:"offset,omitempty"`
 }
 
 func toFlagResponse(flag *store.Flag) flagResponse {
@@ -408,8 +737,17 @@ func toFlagResponse(flag *store.Flag) flagResponse {
 		Config:         flag.Config,
 		TargetingRules: flag.TargetingRules,
 		Variants:       flag.Variants,
+		Protected:      flag.Protected,
+		Tags:           flag.Tags,
+		Archived:       flag.Archived,
+		Owner:          flag.Owner,
+		TicketURL:      flag.TicketURL,
+		Metadata:       flag.Metadata,
+		ExpiresAt:      flag.ExpiresAt,
 		Env:            flag.Env,
+		Project:        flag.Project,
 		UpdatedAt:      flag.UpdatedAt,
+		Version:        flag.Version,
 	}
 }
 
@@ -427,17 +765,51 @@ func (s *Server) handleListFlags(w http.ResponseWriter, r *http.Request) {
 	if env == "" {
 		env = s.env
 	}
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+	opts, fields := parseFlagListQuery(r, env)
+	if len(fields) > 0 {
+		ValidationError(w, r, "Invalid query parameters", fields)
+		return
+	}
+	if opts.query.Project == "" {
+		opts.query.Project = auth.GetProjectFromContext(r.Context()) // "" lists every project
+	} else if werr := s.checkProject(r, "project", opts.query.Project); werr != nil {
+		werr.write(w, r)
+		return
+	}
+	if opts.starred {
+		keys, ok := s.starredFlagKeys(w, r, env)
+		if !ok {
+			return
+		}
+		opts.query.Keys = keys
+	}
 
-	flags, err := s.store.GetAllFlags(r.Context(), env)
+	flags, total, err := s.queryFlags(r.Context(), opts.query)
 	if err != nil {
 		InternalError(w, r, "Failed to load flags")
 		return
 	}
 
-	resp := listFlagsResponse{Flags: make([]flagResponse, len(flags))}
+	resp := listFlagsResponse{Flags: make([]flagResponse, len(flags)), Total: total, Limit: opts.query.Limit, Offset: opts.query.Offset}
 	for i := range flags {
 		resp.Flags[i] = toFlagResponse(&flags[i])
 	}
+	if opts.includeDeleted && !opts.starred { // deleting a flag removes its stars
+		deleted, err := s.listDeletedFlags(r.Context(), env)
+		if err != nil {
+			InternalError(w, r, "Failed to list deleted flags")
+			return
+		}
+		for _, flag := range deleted {
+			if opts.query.Project == "" || flag.Project == opts.query.Project {
+				resp.Flags = append(resp.Flags, flag)
+				resp.Total++
+			}
+		}
+	}
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -448,21 +820,21 @@ func (s *Server) handleGetFlag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	flag, err := s.store.GetFlagByKey(r.Context(), key)
-	if err != nil {
-		NotFoundError(w, r, "Flag not found")
-		return
-	}
-
 	env := strings.TrimSpace(r.URL.Query().Get("env"))
 	if env == "" {
 		env = s.env
 	}
-	if flag.Env != env {
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+
+	flag, err := s.store.GetFlagByKey(r.Context(), key, env)
+	if err != nil {
 		NotFoundError(w, r, "Flag not found")
 		return
 	}
 
+	w.Header().Set("ETag", flagVersionETag(flag.Version))
 	writeJSON(w, http.StatusOK, toFlagResponse(flag))
 }
 
@@ -474,14 +846,7 @@ func (s *Server) handleUpdateFlag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req upsertRequest
-	r.Body = http.MaxBytesReader(w, r.Body, maxFlagRequestBodySize)
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		var maxBytesErr *http.MaxBytesError
-		if errors.As(err, &maxBytesErr) {
-			RequestTooLargeError(w, r, "Request body exceeds 1MB limit")
-			return
-		}
-		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: "+err.Error())
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
 		return
 	}
 
@@ -504,14 +869,7 @@ func (s *Server) handleUpdateFlag(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleUpsertFlag(w http.ResponseWriter, r *http.Request) {
 	var req upsertRequest
-	r.Body = http.MaxBytesReader(w, r.Body, maxFlagRequestBodySize)
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		var maxBytesErr *http.MaxBytesError
-		if errors.As(err, &maxBytesErr) {
-			RequestTooLargeError(w, r, "Request body exceeds 1MB limit")
-			return
-		}
-		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: "+err.Error())
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
 		return
 	}
 	if field, message, ok := validateTargetingRules(req.TargetingRules); !ok {
@@ -522,43 +880,107 @@ func (s *Server) handleUpsertFlag(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleUpsertFlagRequest(w http.ResponseWriter, r *http.Request, req upsertRequest) {
-	// default env
-	env := s.env
-	if req.Env != nil && strings.TrimSpace(*req.Env) != "" {
-		env = strings.TrimSpace(*req.Env)
+	if !applyIfMatch(w, r, &req) {
+		return
+	}
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	if isDryRun(r) {
+		resp, werr := s.dryRunSaveFlag(r, req)
+		if werr != nil {
+			werr.write(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
 	}
 
-	// Convert variants for validation
-	var variantParams []validation.VariantValidationParams
-	for _, v := range req.Variants {
-		variantParams = append(variantParams, validation.VariantValidationParams{
-			Name:   v.Name,
-			Weight: v.Weight,
-		})
+	flag, werr := s.saveFlag(r, req)
+	if werr != nil {
+		werr.write(w, r)
+		return
 	}
 
-	// Validate all fields using the validation package
-	validationResult := validation.ValidateFlag(validation.FlagValidationParams{
-		Key:         req.Key,
-		Env:         env,
-		Description: req.Description,
-		Rollout:     req.Rollout,
-		Variants:    variantParams,
-	})
+	// respond with new ETag
+	resp := upsertResponse{OK: true, ETag: s.snapshotForEnv(s.requestEnv(req)).ETag}
+	if flag != nil {
+		resp.Version = flag.Version
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
 
-	if !validationResult.Valid {
-		ValidationError(w, r, "Validation failed for one or more fields", validationResult.Errors)
-		return
+// saveFlag validates and persists a flag write, then rebuilds the snapshot,
+// records the audit event and dispatches webhooks. It is shared by the v1 and
+// v2 write handlers so both versions behave identically.
+//
+// Returns the stored flag on success, or a *flagWriteError describing the
+// failure. Nothing is written to the response.
+func (s *Server) saveFlag(r *http.Request, req upsertRequest) (*store.Flag, *flagWriteError) {
+	fw, werr := s.prepareFlagWrite(r, req)
+	if werr != nil {
+		return nil, werr
 	}
+	env, params, oldFlag := fw.params.Env, fw.params, fw.oldFlag
+	isCreate := oldFlag == nil
+	beforeState := flagToMap(oldFlag)
 
-	// Validate expression if provided (expression validation is separate)
-	if req.Expression != nil && *req.Expression != "" {
-		if err := targeting.ValidateExpression(*req.Expression); err != nil {
-			BadRequestErrorWithFields(w, r, ErrCodeInvalidExpression, "Invalid expression", map[string]string{
-				"expression": err.Error(),
-			})
-			return
-		}
+	if err := s.store.UpsertFlag(r.Context(), params); err != nil {
+		// Log failed audit event
+		s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeFlag, req.Key, env, nil, nil, nil, audit.StatusFailure, "Failed to save flag")
+		return nil, flagStoreWriteError(err, "Failed to save flag")
+	}
+
+	// Capture after state for audit
+	var afterState map[string]any
+	newFlag, err := s.store.GetFlagByKey(r.Context(), req.Key, env)
+	if err == nil {
+		afterState = flagToMap(newFlag)
+	}
+
+	// rebuild in-memory snapshot (read fresh rows for env)
+	if err := s.RebuildSnapshot(r.Context(), env); err != nil {
+		return nil, internalWriteError("Failed to rebuild snapshot")
+	}
+
+	// Log successful audit event
+	action := audit.ActionUpdated
+	if isCreate {
+		action = audit.ActionCreated
+	}
+	changes := audit.ComputeChanges(beforeState, afterState)
+	auditID := s.auditLog(r, action, audit.ResourceTypeFlag, req.Key, env, beforeState, afterState, changes, audit.StatusSuccess, "")
+	if fw.overrideReason != "" {
+		s.auditProtectionOverride(r, req.Key, env, fw.overrideReason)
+	}
+
+	// Dispatch webhook event
+	s.dispatchWebhookEvent(r, auditID, req.Key, env, beforeState, afterState, changes)
+
+	return newFlag, nil
+}
+
+// flagWrite is a flag write that passed every check and is ready to store.
+type flagWrite struct {
+	params         store.UpsertParams
+	oldFlag        *store.Flag // nil when the write creates the flag
+	overrideReason string      // set when a protection override was used
+}
+
+// prepareFlagWrite runs every check of saveFlag (validation, tenant scope,
+// project, attribute registry, protection, limits, guardrails, readiness) without
+// writing anything. Dry runs stop here, see dryrun.go.
+func (s *Server) prepareFlagWrite(r *http.Request, req upsertRequest) (*flagWrite, *flagWriteError) {
+	env := s.requestEnv(req)
+	if werr := validateUpsertRequest(&req, env); werr != nil {
+		return nil, werr
+	}
+	if werr := tenantEnvWriteError(r, env); werr != nil {
+		return nil, werr
+	}
+	if werr := s.checkRuleAttributes(r, req.Key, req.TargetingRules); werr != nil {
+		return nil, werr
 	}
 
 	// Convert variants to store type
@@ -574,13 +996,25 @@ func (s *Server) handleUpsertFlagRequest(w http.ResponseWriter, r *http.Request,
 		}
 	}
 
-	// Capture before state for audit
-	var beforeState map[string]any
-	isCreate := false
-	if oldFlag, err := s.store.GetFlagByKey(r.Context(), req.Key); err == nil {
-		beforeState = flagToMap(oldFlag)
-	} else {
-		isCreate = true
+	oldFlag, err := s.store.GetFlagByKey(r.Context(), req.Key, env)
+	if err != nil {
+		oldFlag = nil
+	}
+	if werr := checkExpectedVersion(oldFlag, req.ExpectedVersion); werr != nil {
+		return nil, werr
+	}
+	project, werr := s.flagWriteProject(r, req, oldFlag)
+	if werr != nil {
+		return nil, werr
+	}
+
+	overrideReason, werr := s.checkFlagProtection(r, oldFlag, isDestructiveUpdate(oldFlag, req))
+	if werr != nil {
+		return nil, werr
+	}
+	protected := oldFlag != nil && oldFlag.Protected
+	if req.Protected != nil {
+		protected = *req.Protected
 	}
 
 	// upsert via store
@@ -591,45 +1025,97 @@ func (s *Server) handleUpsertFlagRequest(w http.ResponseWriter, r *http.Request,
 		Rollout:        req.Rollout,
 		Expression:     req.Expression,
 		Config:         req.Config,
+		ConfigJSON:     req.configJSON,
 		TargetingRules: req.TargetingRules,
 		Variants:       variants,
+		Protected:      protected,
+		Tags:           req.Tags,
+		Archived:       oldFlag != nil && oldFlag.Archived, // see archive.go
+		ExpiresAt:      req.ExpiresAt,
 		Env:            env,
+		Project:        project,
+		// Checked above for a clear error; the store checks again atomically.
+		ExpectedVersion: req.ExpectedVersion,
 	}
-	if err := s.store.UpsertFlag(r.Context(), params); err != nil {
-		// Log failed audit event
-		s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeFlag, req.Key, env, nil, nil, nil, audit.StatusFailure, "Failed to save flag")
-		InternalError(w, r, "Failed to save flag")
-		return
+	applyOwnership(&params, req, oldFlag)
+	if werr := s.checkFlagLimits(r.Context(), env, oldFlag, params); werr != nil {
+		return nil, werr
 	}
-
-	// Capture after state for audit
-	var afterState map[string]any
-	if newFlag, err := s.store.GetFlagByKey(r.Context(), req.Key); err == nil {
-		afterState = flagToMap(newFlag)
+	if werr := s.checkGuardrail(r.Context(), oldFlag, params); werr != nil {
+		return nil, werr
 	}
+	if werr := s.checkReadiness(r.Context(), oldFlag, params); werr != nil {
+		return nil, werr
+	}
+	return &flagWrite{params: params, oldFlag: oldFlag, overrideReason: overrideReason}, nil
+}
 
-	// rebuild in-memory snapshot (read fresh rows for env)
-	if err := s.RebuildSnapshot(r.Context(), env); err != nil {
-		InternalError(w, r, "Failed to rebuild snapshot")
-		return
+// requestEnv returns the environment targeted by req, defaulting to s.env.
+func (s *Server) requestEnv(req upsertRequest) string {
+	if req.Env != nil && strings.TrimSpace(*req.Env) != "" {
+		return strings.TrimSpace(*req.Env)
 	}
+	return s.env
+}
 
-	// Log successful audit event
-	action := audit.ActionUpdated
-	if isCreate {
-		action = audit.ActionCreated
+// validateUpsertRequest checks flag fields and the targeting expression.
+// Targeting rules are validated separately by validateTargetingRules.
+//
+// The config size is checked on its JSON encoding, which is kept in
+// req.configJSON and handed to the store, so a large config is marshaled once
+// per write rather than again by the store. A request validated before (apply
+// validates every flag up front) is not encoded again.
+func validateUpsertRequest(req *upsertRequest, env string) *flagWriteError {
+	if req.configJSON == nil && req.Config != nil {
+		configJSON, err := json.Marshal(req.Config)
+		if err != nil {
+			return validationWriteError("Validation failed for one or more fields", map[string]string{
+				"config": "Config must be a valid JSON object",
+			})
+		}
+		req.configJSON = configJSON
 	}
-	changes := audit.ComputeChanges(beforeState, afterState)
-	s.auditLog(r, action, audit.ResourceTypeFlag, req.Key, env, beforeState, afterState, changes, audit.StatusSuccess, "")
 
-	// Dispatch webhook event
-	s.dispatchWebhookEvent(r, req.Key, env, beforeState, afterState, changes)
+	// Convert variants for validation
+	var variantParams []validation.VariantValidationParams
+	for _, v := range req.Variants {
+		variantParams = append(variantParams, validation.VariantValidationParams{
+			Name:   v.Name,
+			Weight: v.Weight,
+		})
+	}
 
-	// respond with new ETag
-	writeJSON(w, http.StatusOK, upsertResponse{
-		OK:   true,
-		ETag: snapshot.Load().ETag,
+	// Validate all fields using the validation package
+	validationResult := validation.ValidateFlag(validation.FlagValidationParams{
+		Key:         req.Key,
+		Env:         env,
+		Description: req.Description,
+		Rollout:     req.Rollout,
+		Config:      req.Config,
+		ConfigJSON:  string(req.configJSON),
+		Variants:    variantParams,
+		Tags:        req.Tags,
+		Owner:       derefString(req.Owner),
+		TicketURL:   derefString(req.TicketURL),
+		Metadata:    req.Metadata,
 	})
+
+	if !validationResult.Valid {
+		return validationWriteError("Validation failed for one or more fields", validationResult.Errors)
+	}
+
+	// Validate expression if provided (expression validation is separate)
+	if req.Expression != nil && *req.Expression != "" {
+		if err := targeting.ValidateExpression(*req.Expression); err != nil {
+			return &flagWriteError{
+				status:  http.StatusBadRequest,
+				code:    ErrCodeInvalidExpression,
+				message: "Invalid expression",
+				fields:  map[string]string{"expression": err.Error()},
+			}
+		}
+	}
+	return nil
 }
 
 func (s *Server) handleDeleteFlag(w http.ResponseWriter, r *http.Request) {
@@ -650,51 +1136,148 @@ func (s *Server) handleDeleteFlag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Capture before state for audit
-	var beforeState map[string]any
-	if oldFlag, err := s.store.GetFlagByKey(r.Context(), key); err == nil {
-		beforeState = flagToMap(oldFlag)
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	if isDryRun(r) {
+		resp, werr := s.dryRunRemoveFlag(r, key, env)
+		if werr != nil {
+			werr.write(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if werr := s.removeFlag(r, key, env); werr != nil {
+		werr.write(w, r)
+		return
 	}
 
+	// Respond with new ETag (idempotent: always returns success)
+	writeJSON(w, http.StatusOK, upsertResponse{
+		OK:   true,
+		ETag: s.snapshotForEnv(env).ETag,
+	})
+}
+
+// flagStoreWriteError reports a failed flag write to the store: 409 CONFLICT
+// if the store is read-only (STORE_TYPE=file), 409 VERSION_CONFLICT if the
+// flag changed since the expected version, 500 with message otherwise.
+func flagStoreWriteError(err error, message string) *flagWriteError {
+	if errors.Is(err, store.ErrReadOnly) {
+		return &flagWriteError{status: http.StatusConflict, code: ErrCodeConflict, message: "Flags are read-only: they are managed in flag files"}
+	}
+	if errors.Is(err, store.ErrVersionConflict) {
+		return versionConflictWriteError(nil)
+	}
+	return internalWriteError(message)
+}
+
+// removeFlag deletes a flag, rebuilds the snapshot, records the audit event and
+// dispatches webhooks. Shared by the v1 and v2 delete handlers.
+// Returns a *flagWriteError on failure; nothing is written to the response.
+func (s *Server) removeFlag(r *http.Request, key, env string) *flagWriteError {
+	oldFlag, overrideReason, werr := s.prepareFlagDelete(r, key, env)
+	if werr != nil {
+		return werr
+	}
+	beforeState := flagToMap(oldFlag) // nil if the flag does not exist
+
 	// Delete from store
 	if err := s.store.DeleteFlag(r.Context(), key, env); err != nil {
 		// Log failed audit event
 		s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeFlag, key, env, beforeState, nil, nil, audit.StatusFailure, "Failed to delete flag")
-		InternalError(w, r, "Failed to delete flag")
-		return
+		return flagStoreWriteError(err, "Failed to delete flag")
 	}
 
 	// Rebuild snapshot
 	if err := s.RebuildSnapshot(r.Context(), env); err != nil {
-		InternalError(w, r, "Failed to rebuild snapshot")
-		return
+		return internalWriteError("Failed to rebuild snapshot")
 	}
 
 	// Log successful audit event (after state is nil for delete)
-	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeFlag, key, env, beforeState, nil, nil, audit.StatusSuccess, "")
+	auditID := s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeFlag, key, env, beforeState, nil, nil, audit.StatusSuccess, "")
+	if overrideReason != "" {
+		s.auditProtectionOverride(r, key, env, overrideReason)
+	}
 
 	// Dispatch webhook event for deletion
-	s.dispatchWebhookEvent(r, key, env, beforeState, nil, nil)
+	s.dispatchWebhookEvent(r, auditID, key, env, beforeState, nil, nil)
+	return nil
+}
 
-	// Respond with new ETag (idempotent: always returns success)
-	writeJSON(w, http.StatusOK, upsertResponse{
-		OK:   true,
-		ETag: snapshot.Load().ETag,
-	})
+// prepareFlagDelete runs the checks of removeFlag (tenant scope, project,
+// protection) and returns the flag to be deleted (nil if it does not exist)
+// and the protection override reason.
+func (s *Server) prepareFlagDelete(r *http.Request, key, env string) (*store.Flag, string, *flagWriteError) {
+	if werr := tenantEnvWriteError(r, env); werr != nil {
+		return nil, "", werr
+	}
+	oldFlag, err := s.store.GetFlagByKey(r.Context(), key, env)
+	if err != nil {
+		oldFlag = nil
+	}
+	if oldFlag != nil && !projectVisible(r, oldFlag.Project) {
+		return nil, "", notFoundWriteError("Flag not found")
+	}
+	overrideReason, werr := s.checkFlagProtection(r, oldFlag, true)
+	if werr != nil {
+		return nil, "", werr
+	}
+	return oldFlag, overrideReason, nil
 }
 
 // RebuildSnapshot loads flags for env and swaps the atomic snapshot.
+// The server's default env replaces the global snapshot; other environments
+// (served to SDK keys, see sdk_keys.go) are stored per env. Flags are read
+// from the primary, so the snapshot includes the write that triggered it.
+// Shadow versions (see shadows.go) and user overrides (see overrides.go) are
+// reloaded with it and the cached guardrails (see guardrails.go) dropped.
+// Other replicas are told about the new snapshot when a publisher is set
+// (see cluster.go). Rebuild durations, failures and the time of the last
+// success are recorded per env in the snapshot_rebuild_* metrics.
 func (s *Server) RebuildSnapshot(ctx context.Context, env string) error {
-	flags, err := s.store.GetAllFlags(ctx, env)
+	snap, err := s.rebuildSnapshot(ctx, env)
 	if err != nil {
 		return err
 	}
-	snap := snapshot.BuildFromFlags(flags)
-	snapshot.Update(snap)
-	telemetry.SnapshotFlags.Set(float64(len(snap.Flags)))
+	if s.snapshotPublisher != nil {
+		s.snapshotPublisher.Publish(ctx, env, snap.ETag)
+	}
 	return nil
 }
 
+func (s *Server) rebuildSnapshot(ctx context.Context, env string) (*snapshot.Snapshot, error) {
+	start := time.Now()
+	flags, err := s.store.GetAllFlags(store.ReadPrimary(ctx), env)
+	if err != nil {
+		telemetry.ObserveSnapshotRebuild(env, start, err)
+		log.Printf("[snapshot] rebuild failed env=%s: %v", env, err)
+		return nil, err
+	}
+	snap := snapshot.BuildFromFlags(flags)
+	if env != s.env {
+		snapshot.UpdateEnv(env, snap)
+	} else {
+		snapshot.Update(snap)
+		telemetry.SnapshotFlags.Set(float64(len(snap.Flags)))
+	}
+	telemetry.ObserveSnapshotRebuild(env, start, nil)
+	if correlation := telemetry.Correlation(ctx); correlation != "" {
+		log.Printf("[snapshot] rebuilt env=%s etag=%s %s", env, snap.ETag, correlation)
+	}
+	s.guardrailMetrics.invalidate(env)
+	if err := s.refreshShadows(store.ReadPrimary(ctx), env); err != nil {
+		log.Printf("[shadow] failed to load shadow versions for env %s: %v", env, err)
+	}
+	if err := s.refreshUserOverrides(store.ReadPrimary(ctx), env); err != nil {
+		log.Printf("[overrides] failed to load user overrides for env %s: %v", env, err)
+	}
+	return snap, nil
+}
+
 // ---- middleware & helpers ----
 
 func (s *Server) authAdmin(next http.HandlerFunc) http.HandlerFunc {
@@ -706,7 +1289,7 @@ func (s *Server) authAdmin(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 		// constant-time compare
-		if subtle.ConstantTimeCompare([]byte(got), []byte(s.adminAPIKey)) != 1 {
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.auth.LegacyAdminKey())) != 1 {
 			ForbiddenError(w, r, "Invalid token")
 			return
 		}
@@ -716,11 +1299,10 @@ func (s *Server) authAdmin(next http.HandlerFunc) http.HandlerFunc {
 
 // auditLog logs an audit event (convenience method for backward compatibility during migration).
 // Consider using audit.NewEventBuilder(r) directly with the builder pattern for new code.
-func (s *Server) auditLog(r *http.Request, action, resourceType, resourceID, environment string, beforeState, afterState, changes map[string]any, status, errorMsg string) {
-	if s.auditService == nil {
-		return // No audit service available
-	}
-
+// It returns the event's ID for webhook events about the same change, or ""
+// if no audit service is configured. Flag events are recorded with the
+// flag's project (see stateProject).
+func (s *Server) auditLog(r *http.Request, action, resourceType, resourceID, environment string, beforeState, afterState, changes map[string]any, status, errorMsg string) string {
 	builder := audit.NewEventBuilder(r).
 		ForResource(resourceType, resourceID).
 		WithAction(action).
@@ -728,30 +1310,44 @@ func (s *Server) auditLog(r *http.Request, action, resourceType, resourceID, env
 		WithBeforeState(beforeState).
 		WithAfterState(afterState).
 		WithChanges(changes)
+	if resourceType == audit.ResourceTypeFlag && (beforeState != nil || afterState != nil) {
+		builder = builder.WithProject(stateProject(beforeState, afterState))
+	}
 
 	if status == audit.StatusFailure && errorMsg != "" {
 		builder = builder.Failure(errorMsg)
 	}
 
 	event := builder.Build()
+	s.logFlagMutation(event)
+	if s.auditService == nil {
+		return "" // No audit service available
+	}
 	s.auditService.Log(event)
+	return event.ID
 }
 
 // dispatchWebhookEvent dispatches a webhook event for flag changes using the EventBuilder pattern.
 // Event type (created/updated/deleted) is automatically determined based on before/after states.
-func (s *Server) dispatchWebhookEvent(r *http.Request, key, env string, beforeState, afterState, changes map[string]any) {
+// auditID is the audit event of the change (see auditLog), or "" if none.
+func (s *Server) dispatchWebhookEvent(r *http.Request, auditID, key, env string, beforeState, afterState, changes map[string]any) {
 	if s.webhookDispatcher == nil {
 		return // No webhook dispatcher available
 	}
 
-	// Build and dispatch event using fluent API
-	// Event type is automatically determined based on states
-	event := webhook.NewEventBuilder(r).
+	// Dispatch asynchronously (non-blocking)
+	s.webhookDispatcher.Dispatch(flagEvent(r, auditID, key, env, beforeState, afterState, changes))
+}
+
+// flagEvent builds the webhook event for a flag change. The event type
+// (created/updated/deleted) is determined from the states, the project from
+// the flag's state after the change (see stateProject).
+func flagEvent(r *http.Request, auditID, key, env string, beforeState, afterState, changes map[string]any) webhook.Event {
+	return webhook.NewEventBuilder(r).
 		ForFlag(key, env).
+		WithProject(stateProject(beforeState, afterState)).
 		WithStates(beforeState, afterState).
 		WithChanges(changes).
+		WithAuditEventID(auditID).
 		Build()
-
-	// Dispatch asynchronously (non-blocking)
-	s.webhookDispatcher.Dispatch(event)
 }
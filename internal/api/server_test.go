@@ -7,12 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"github.com/TimurManjosov/goflagship/internal/rules"
 	"github.com/TimurManjosov/goflagship/internal/snapshot"
 	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 )
 
 func TestHandleHealth(t *testing.T) {
@@ -122,6 +127,39 @@ func TestSnapshotEndpoint_CacheHeaders(t *testing.T) {
 	}
 }
 
+func TestSnapshotEndpoint_FlagCountAndAgeHeaders(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b"} {
+		st.UpsertFlag(ctx, store.UpsertParams{Key: key, Enabled: true, Rollout: 100, Env: "prod"})
+	}
+	srv.RebuildSnapshot(ctx, "prod")
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/flags/snapshot", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("X-Flagship-Flag-Count"); got != "2" {
+		t.Errorf("Expected flag count 2, got %q", got)
+	}
+	if got := rr.Header().Get("X-Flagship-Snapshot-Age"); got != "0" {
+		t.Errorf("Expected snapshot age 0, got %q", got)
+	}
+
+	// 304 responses carry the headers too
+	req = httptest.NewRequest(http.MethodGet, "/v1/flags/snapshot", nil)
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotModified || rr.Header().Get("X-Flagship-Flag-Count") != "2" {
+		t.Errorf("Expected 304 with flag count header, got %d %q", rr.Code, rr.Header().Get("X-Flagship-Flag-Count"))
+	}
+}
+
 func TestSnapshotEndpoint_ETag_NotModified(t *testing.T) {
 	st := store.NewMemoryStore()
 	srv := NewServer(st, "prod", "test-key")
@@ -411,7 +449,7 @@ func TestUpsertFlag_WithTargetingRules(t *testing.T) {
 		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
 	}
 
-	flag, err := st.GetFlagByKey(context.Background(), "test_flag")
+	flag, err := st.GetFlagByKey(context.Background(), "test_flag", "prod")
 	if err != nil {
 		t.Fatalf("Failed to load stored flag: %v", err)
 	}
@@ -475,6 +513,43 @@ func TestUpsertFlag_RequestTooLarge(t *testing.T) {
 	}
 }
 
+func TestUpsertFlag_LargeConfig(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "admin-key")
+	handler := srv.Router()
+
+	post := func(text string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"key":"big","enabled":true,"rollout":100,"config":{"text":%q}}`, text)
+		req := httptest.NewRequest(http.MethodPost, "/v1/flags", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer admin-key")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	near := strings.Repeat("x", 99*1024)
+	if rr := post(near); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for a config under 100KB, got %d: %s", rr.Code, rr.Body.String())
+	}
+	flag, err := st.GetFlagByKey(context.Background(), "big", "prod")
+	if err != nil {
+		t.Fatalf("GetFlagByKey: %v", err)
+	}
+	want, _ := json.Marshal(map[string]any{"text": near})
+	if flag.Config["text"] != near || string(flag.ConfigJSON) != string(want) {
+		t.Error("Expected the config and its encoding to be stored")
+	}
+
+	rr := post(strings.Repeat("x", 100*1024))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for a config over 100KB, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields["config"] != "Config must not exceed 100KB" {
+		t.Errorf("Expected a config field error, got %+v", resp.Fields)
+	}
+}
+
 func TestUpdateFlag_WithTargetingRules(t *testing.T) {
 	st := store.NewMemoryStore()
 	srv := NewServer(st, "prod", "admin-key")
@@ -504,7 +579,7 @@ func TestUpdateFlag_WithTargetingRules(t *testing.T) {
 		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
 	}
 
-	flag, err := st.GetFlagByKey(ctx, "test_flag")
+	flag, err := st.GetFlagByKey(ctx, "test_flag", "prod")
 	if err != nil {
 		t.Fatalf("Failed to load updated flag: %v", err)
 	}
@@ -763,3 +838,98 @@ func TestSnapshot_EnvironmentFiltering(t *testing.T) {
 		t.Error("Did not expect dev_flag in prod snapshot")
 	}
 }
+
+func TestSnapshot_TagFiltering(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "admin-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Env: "prod", Tags: []string{"payments"}})
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "banner", Enabled: true, Rollout: 100, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/flags/snapshot?tag=payments", nil))
+	var snap snapshot.Snapshot
+	json.NewDecoder(rr.Body).Decode(&snap)
+	if len(snap.Flags) != 1 || len(snap.Flags["checkout"].Tags) != 1 {
+		t.Fatalf("expected only checkout with its tags, got %+v", snap.Flags)
+	}
+	if rr.Header().Get("ETag") != snap.ETag || snap.ETag == srv.snapshotForEnv("prod").ETag {
+		t.Errorf("expected the filtered snapshot's own ETag, got header %q body %q", rr.Header().Get("ETag"), snap.ETag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/flags/snapshot?tag=payments", nil)
+	req.Header.Set("If-None-Match", snap.ETag)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for the filtered ETag, got %d", rr.Code)
+	}
+}
+
+// unreadableStore fails to list flags while fail is set.
+type unreadableStore struct {
+	*store.MemoryStore
+	fail bool
+}
+
+func (s *unreadableStore) GetAllFlags(ctx context.Context, env string) ([]store.Flag, error) {
+	if s.fail {
+		return nil, fmt.Errorf("connection refused")
+	}
+	return s.MemoryStore.GetAllFlags(ctx, env)
+}
+
+func TestRebuildSnapshot_Metrics(t *testing.T) {
+	st := &unreadableStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "metrics-env", "test-key")
+	ctx := context.Background()
+
+	failures0 := testutil.ToFloat64(telemetry.SnapshotRebuildFailures.WithLabelValues("metrics-env"))
+	if err := srv.RebuildSnapshot(ctx, "metrics-env"); err != nil {
+		t.Fatalf("RebuildSnapshot: %v", err)
+	}
+	lastSuccess := testutil.ToFloat64(telemetry.SnapshotLastRebuild.WithLabelValues("metrics-env"))
+	if lastSuccess == 0 {
+		t.Error("expected the last success time to be set")
+	}
+
+	st.fail = true
+	if err := srv.RebuildSnapshot(ctx, "metrics-env"); err == nil {
+		t.Fatal("expected the rebuild to fail")
+	}
+	if got := testutil.ToFloat64(telemetry.SnapshotRebuildFailures.WithLabelValues("metrics-env")) - failures0; got != 1 {
+		t.Errorf("expected 1 failure, got %v", got)
+	}
+	if got := testutil.ToFloat64(telemetry.SnapshotLastRebuild.WithLabelValues("metrics-env")); got != lastSuccess {
+		t.Errorf("a failed rebuild must not move the last success time, got %v want %v", got, lastSuccess)
+	}
+	if n := testutil.CollectAndCount(telemetry.SnapshotRebuildDuration, "snapshot_rebuild_duration_seconds"); n == 0 {
+		t.Error("expected rebuild durations to be recorded")
+	}
+}
+
+func TestFlagWrites_ReadOnlyStore(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "flags.yaml"), []byte("flags:\n  - key: checkout\n    enabled: true\n    rollout: 100\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := store.NewFileStore(dir, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(fs, "prod", "test-key")
+	handler := srv.Router()
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":false,"rollout":100,"env":"prod"}`},
+		{http.MethodDelete, "/v1/flags?key=checkout&env=prod", ""},
+	} {
+		rr := doV2Request(t, handler, tc.method, tc.path, tc.body)
+		if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "CONFLICT") {
+			t.Errorf("%s %s: expected 409 CONFLICT, got %d: %s", tc.method, tc.path, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// Bootstrap makes a fresh deployment usable without manual SQL. It is run
+// once at startup (BOOTSTRAP_ENVIRONMENTS, BOOTSTRAP_SUPERADMIN) and only
+// acts on a deployment that has not been set up yet, so it is safe to leave
+// enabled:
+//
+//   - Environments exist as soon as they hold flags; what clients need to
+//     connect to one is an SDK key. Every listed environment that has no SDK
+//     key yet gets one named "bootstrap".
+//   - A superadmin API key named "bootstrap-superadmin" is created if no API
+//     keys exist at all (Postgres store only).
+//
+// The plaintext keys are returned once, for the caller to print; only their
+// hashes are stored. Each key is audited as created by the system.
+
+// BootstrapConfig selects what Bootstrap creates.
+type BootstrapConfig struct {
+	Environments   []string // environments that get an SDK key
+	SeedSuperadmin bool     // create a superadmin key if there are no API keys
+}
+
+// BootstrapResult holds the plaintext keys created by Bootstrap.
+type BootstrapResult struct {
+	SuperadminKey string            // "" if no key was created
+	SDKKeys       map[string]string // environment -> SDK key
+}
+
+const (
+	bootstrapSuperadminKeyName = "bootstrap-superadmin"
+	bootstrapSDKKeyName        = "bootstrap"
+	bootstrapCreatedBy         = "bootstrap"
+)
+
+// Bootstrap creates the keys selected by cfg that do not exist yet. On error
+// the keys created so far are still returned.
+func (s *Server) Bootstrap(ctx context.Context, cfg BootstrapConfig) (BootstrapResult, error) {
+	result := BootstrapResult{SDKKeys: map[string]string{}}
+
+	if len(cfg.Environments) > 0 {
+		ks, ok := s.store.(store.SDKKeyStore)
+		if !ok {
+			return result, errors.New("the store does not support SDK keys")
+		}
+		for _, env := range cfg.Environments {
+			key, err := s.bootstrapSDKKey(ctx, ks, env)
+			if err != nil {
+				return result, fmt.Errorf("environment %s: %w", env, err)
+			}
+			if key != "" {
+				result.SDKKeys[env] = key
+			}
+		}
+	}
+
+	if cfg.SeedSuperadmin {
+		pgStore, ok := s.store.(PostgresStoreInterface)
+		if !ok {
+			return result, errors.New("API keys require the postgres store")
+		}
+		key, err := s.bootstrapSuperadminKey(ctx, pgStore)
+		if err != nil {
+			return result, fmt.Errorf("superadmin key: %w", err)
+		}
+		result.SuperadminKey = key
+	}
+	return result, nil
+}
+
+// bootstrapSDKKey creates an SDK key for env unless it already has one, and
+// returns the plaintext key ("" if none was created).
+func (s *Server) bootstrapSDKKey(ctx context.Context, ks store.SDKKeyStore, env string) (string, error) {
+	existing, err := ks.ListSDKKeys(ctx, env)
+	if err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		return "", nil
+	}
+
+	key, err := auth.GenerateSDKKey()
+	if err != nil {
+		return "", err
+	}
+	sdkKey, err := ks.CreateSDKKey(ctx, store.CreateSDKKeyParams{
+		Name:      bootstrapSDKKeyName,
+		Env:       env,
+		KeyHash:   auth.HashSDKKey(key),
+		KeyPrefix: key[:sdkKeyPrefixLength],
+		CreatedBy: bootstrapCreatedBy,
+	})
+	if err != nil {
+		return "", err
+	}
+	s.logSystemAudit(audit.NewSystemEventBuilder().
+		ForResource(audit.ResourceTypeSDKKey, sdkKey.ID).
+		WithAction(audit.ActionCreated).
+		WithEnvironment(env).
+		WithAfterState(sdkKeyToMap(sdkKey)).
+		WithNote("created by startup bootstrap"))
+	return key, nil
+}
+
+// bootstrapSuperadminKey creates a superadmin key if there are no API keys,
+// and returns the plaintext key ("" if none was created).
+func (s *Server) bootstrapSuperadminKey(ctx context.Context, pgStore PostgresStoreInterface) (string, error) {
+	existing, err := pgStore.ListAPIKeys(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		return "", nil
+	}
+
+	apiKey, key, err := createSuperadminKey(ctx, pgStore.CreateAPIKey, bootstrapSuperadminKeyName, bootstrapCreatedBy)
+	if err != nil {
+		return "", err
+	}
+	s.logSystemAudit(audit.NewSystemEventBuilder().
+		ForResource(audit.ResourceTypeAPIKey, formatUUID(apiKey.ID)).
+		WithAction(audit.ActionCreated).
+		WithAfterState(apiKeyToMap(apiKey)).
+		WithNote("created by startup bootstrap"))
+	return key, nil
+}
+
+// createSuperadminKey stores a new superadmin key of the default tenant with
+// create and returns it with its plaintext key.
+func createSuperadminKey(ctx context.Context, create func(context.Context, dbgen.CreateAPIKeyParams) (dbgen.ApiKey, error), name, createdBy string) (dbgen.ApiKey, string, error) {
+	key, err := auth.GenerateAPIKey()
+	if err != nil {
+		return dbgen.ApiKey{}, "", err
+	}
+	keyHash, err := auth.HashAPIKey(key)
+	if err != nil {
+		return dbgen.ApiKey{}, "", err
+	}
+	apiKey, err := create(ctx, dbgen.CreateAPIKeyParams{
+		Name:      name,
+		KeyHash:   keyHash,
+		Role:      dbgen.ApiKeyRole(auth.RoleSuperadmin),
+		Enabled:   true,
+		CreatedBy: createdBy,
+		Tenant:    auth.DefaultTenant,
+	})
+	if err != nil {
+		return dbgen.ApiKey{}, "", err
+	}
+	return apiKey, key, nil
+}
+
+// POST /v1/setup creates the first superadmin key of a deployment that has no
+// admin credentials yet: no API keys and no ADMIN_API_KEY. At startup such a
+// deployment gets a one-time setup token (EnableSetup), printed to the log, so
+// only someone with access to the logs can claim it:
+//
+//	curl -X POST localhost:8080/v1/setup -d '{"token": "setup_...", "name": "ops"}'
+//
+// The response shows the new key once. With the Postgres store it is a
+// regular API key; other stores have no API keys, so it becomes the
+// runtime admin key (like ADMIN_API_KEY) and is lost on restart, when a new
+// token is printed. The token is single-use and setup is closed afterwards.
+// With several replicas, each prints its own token; the first one used wins.
+// The Postgres store checks that no API key exists and creates the key in one
+// transaction, so two tokens used at the same time cannot both succeed.
+
+type setupRequest struct {
+	Token string `json:"token"`
+	Name  string `json:"name,omitempty"` // default "setup-superadmin"
+}
+
+type setupResponse struct {
+	ID         string `json:"id,omitempty"` // empty for a runtime admin key
+	Name       string `json:"name"`
+	Key        string `json:"key"` // Only shown once!
+	Role       string `json:"role"`
+	Persistent bool   `json:"persistent"` // false if the key only lasts until restart
+}
+
+const (
+	setupSuperadminKeyName = "setup-superadmin"
+	setupCreatedBy         = "setup"
+)
+
+// FirstAPIKeyStore is implemented by stores that can create an API key only
+// if none exists yet, atomically (PostgresStore). CreateFirstAPIKey returns
+// store.ErrAPIKeysExist otherwise.
+type FirstAPIKeyStore interface {
+	PostgresStoreInterface
+	CreateFirstAPIKey(ctx context.Context, params dbgen.CreateAPIKeyParams) (dbgen.ApiKey, error)
+}
+
+// setupState holds the pending setup token.
+type setupState struct {
+	mu    sync.Mutex
+	token string // "" if no setup is pending
+}
+
+// EnableSetup opens the first-run setup if the deployment has no admin
+// credentials, and returns the setup token to print ("" if setup is not
+// needed).
+func (s *Server) EnableSetup(ctx context.Context) (string, error) {
+	if s.auth.LegacyAdminKey() != "" {
+		return "", nil
+	}
+	if pgStore, ok := s.store.(PostgresStoreInterface); ok {
+		existing, err := pgStore.ListAPIKeys(ctx)
+		if err != nil {
+			return "", err
+		}
+		if len(existing) > 0 {
+			return "", nil
+		}
+	}
+
+	token, err := auth.GenerateSetupToken()
+	if err != nil {
+		return "", err
+	}
+	s.setup.mu.Lock()
+	s.setup.token = token
+	s.setup.mu.Unlock()
+	return token, nil
+}
+
+func (s *Server) handleSetup(w http.ResponseWriter, r *http.Request) {
+	var req setupRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = setupSuperadminKeyName
+	}
+
+	s.setup.mu.Lock()
+	defer s.setup.mu.Unlock()
+	if s.setup.token == "" {
+		NotFoundError(w, r, "No setup is pending")
+		return
+	}
+	if !auth.VerifyAPIKeyConstantTime(req.Token, s.setup.token) {
+		UnauthorizedError(w, r, "Invalid setup token")
+		return
+	}
+
+	pgStore, persistent := s.store.(PostgresStoreInterface)
+	if !persistent {
+		key, err := auth.GenerateAPIKey()
+		if err != nil {
+			InternalError(w, r, "Failed to generate key")
+			return
+		}
+		s.SetAdminAPIKey(key)
+		s.setup.token = ""
+		log.Printf("[setup] runtime admin key created, setup closed")
+		writeJSON(w, http.StatusCreated, setupResponse{Name: req.Name, Key: key, Role: string(auth.RoleSuperadmin)})
+		return
+	}
+
+	// Another replica may have completed the setup.
+	create := pgStore.CreateAPIKey
+	if fs, ok := pgStore.(FirstAPIKeyStore); ok {
+		create = fs.CreateFirstAPIKey
+	} else {
+		existing, err := pgStore.ListAPIKeys(r.Context())
+		if err != nil {
+			InternalError(w, r, "Failed to list keys")
+			return
+		}
+		if len(existing) > 0 {
+			s.setup.token = ""
+			ConflictError(w, r, "Setup has already been completed")
+			return
+		}
+	}
+	apiKey, key, err := createSuperadminKey(r.Context(), create, req.Name, setupCreatedBy)
+	if errors.Is(err, store.ErrAPIKeysExist) {
+		s.setup.token = ""
+		ConflictError(w, r, "Setup has already been completed")
+		return
+	}
+	if err != nil {
+		InternalError(w, r, "Failed to create key")
+		return
+	}
+	s.setup.token = ""
+	s.auditLog(r, audit.ActionCreated, audit.ResourceTypeAPIKey, formatUUID(apiKey.ID), "", nil, apiKeyToMap(apiKey), nil, audit.StatusSuccess, "")
+	log.Printf("[setup] superadmin key %s created, setup closed", formatUUID(apiKey.ID))
+	writeJSON(w, http.StatusCreated, setupResponse{
+		ID:         formatUUID(apiKey.ID),
+		Name:       apiKey.Name,
+		Key:        key,
+		Role:       string(apiKey.Role),
+		Persistent: true,
+	})
+}
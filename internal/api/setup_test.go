@@ -0,0 +1,273 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// apiKeyMemoryStore adds in-memory API keys to a MemoryStore, so it
+// satisfies PostgresStoreInterface.
+type apiKeyMemoryStore struct {
+	*store.MemoryStore
+	apiKeys []dbgen.ApiKey
+}
+
+func (m *apiKeyMemoryStore) ListAPIKeys(context.Context) ([]dbgen.ApiKey, error) {
+	return m.apiKeys, nil
+}
+
+func (m *apiKeyMemoryStore) CreateAPIKey(_ context.Context, params dbgen.CreateAPIKeyParams) (dbgen.ApiKey, error) {
+	key := dbgen.ApiKey{
+		ID:        pgtype.UUID{Bytes: [16]byte{byte(len(m.apiKeys) + 1)}, Valid: true},
+		Name:      params.Name,
+		KeyHash:   params.KeyHash,
+		Role:      params.Role,
+		Enabled:   params.Enabled,
+		ExpiresAt: params.ExpiresAt,
+		CreatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		CreatedBy: params.CreatedBy,
+		Tenant:    params.Tenant,
+	}
+	m.apiKeys = append(m.apiKeys, key)
+	return key, nil
+}
+
+func (m *apiKeyMemoryStore) CreateFirstAPIKey(ctx context.Context, params dbgen.CreateAPIKeyParams) (dbgen.ApiKey, error) {
+	if len(m.apiKeys) > 0 {
+		return dbgen.ApiKey{}, store.ErrAPIKeysExist
+	}
+	return m.CreateAPIKey(ctx, params)
+}
+
+func (m *apiKeyMemoryStore) GetAPIKeyByID(_ context.Context, id pgtype.UUID) (dbgen.ApiKey, error) {
+	for _, key := range m.apiKeys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return dbgen.ApiKey{}, errors.New("not found")
+}
+
+func (m *apiKeyMemoryStore) RevokeAPIKey(context.Context, pgtype.UUID) error { return nil }
+
+func (m *apiKeyMemoryStore) UpdateAPIKeyLastUsed(context.Context, pgtype.UUID) error { return nil }
+
+func (m *apiKeyMemoryStore) DisableAPIKey(_ context.Context, id pgtype.UUID, reason string) (bool, error) {
+	for i := range m.apiKeys {
+		if key := &m.apiKeys[i]; key.ID == id && key.Enabled {
+			key.Enabled = false
+			key.DisabledAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+			key.DisabledReason = reason
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *apiKeyMemoryStore) ReenableAPIKey(_ context.Context, id pgtype.UUID, reason string, since time.Time) (bool, error) {
+	for i := range m.apiKeys {
+		if key := &m.apiKeys[i]; key.ID == id && !key.Enabled && key.DisabledReason == reason && !key.DisabledAt.Time.Before(since) {
+			key.Enabled = true
+			key.DisabledAt = pgtype.Timestamptz{}
+			key.DisabledReason = ""
+			key.LastUsedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *apiKeyMemoryStore) ListAuditLogs(context.Context, dbgen.ListAuditLogsParams) ([]dbgen.AuditLog, error) {
+	return nil, nil
+}
+
+func (m *apiKeyMemoryStore) GetAuditLog(context.Context, pgtype.UUID) (dbgen.AuditLog, error) {
+	return dbgen.AuditLog{}, nil
+}
+
+func (m *apiKeyMemoryStore) CountAuditLogs(context.Context, dbgen.CountAuditLogsParams) (int64, error) {
+	return 0, nil
+}
+
+func (m *apiKeyMemoryStore) CreateAuditLog(context.Context, dbgen.CreateAuditLogParams) error {
+	return nil
+}
+
+func TestBootstrap(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "prod", "test-key")
+	sink := &recordingSink{}
+	srv.auditService = audit.NewService(sink, nil, nil, nil, 16)
+	t.Cleanup(func() { _ = srv.auditService.Close() })
+	ctx := context.Background()
+
+	// staging already has a key and is left alone.
+	if _, err := st.CreateSDKKey(ctx, store.CreateSDKKeyParams{Name: "existing", Env: "staging", KeyHash: "h", KeyPrefix: "p"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := BootstrapConfig{Environments: []string{"dev", "staging", "prod"}, SeedSuperadmin: true}
+	result, err := srv.Bootstrap(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	if len(result.SDKKeys) != 2 || result.SDKKeys["dev"] == "" || result.SDKKeys["prod"] == "" {
+		t.Fatalf("expected SDK keys for dev and prod, got %v", result.SDKKeys)
+	}
+	for env, key := range result.SDKKeys {
+		sdkKey, err := st.GetSDKKeyByHash(ctx, auth.HashSDKKey(key))
+		if err != nil || sdkKey.Env != env {
+			t.Errorf("SDK key for %s not stored for its environment: %+v, %v", env, sdkKey, err)
+		}
+	}
+
+	if result.SuperadminKey == "" || len(st.apiKeys) != 1 {
+		t.Fatalf("expected one superadmin key, got %q and %d keys", result.SuperadminKey, len(st.apiKeys))
+	}
+	if st.apiKeys[0].Role != dbgen.ApiKeyRole(auth.RoleSuperadmin) || !auth.VerifyAPIKey(result.SuperadminKey, st.apiKeys[0].KeyHash) {
+		t.Errorf("stored key does not match the printed superadmin key: %+v", st.apiKeys[0])
+	}
+
+	events := sink.waitForEvents(t, 3)
+	for _, event := range events {
+		if event.Action != audit.ActionCreated || event.Actor.Kind != audit.ActorKindSystem {
+			t.Errorf("expected system create events, got %+v", event)
+		}
+	}
+
+	// A second start creates nothing.
+	result, err = srv.Bootstrap(ctx, cfg)
+	if err != nil {
+		t.Fatalf("second Bootstrap failed: %v", err)
+	}
+	if len(result.SDKKeys) != 0 || result.SuperadminKey != "" || len(st.apiKeys) != 1 {
+		t.Errorf("expected the second bootstrap to create nothing, got %+v", result)
+	}
+}
+
+func TestBootstrap_SuperadminRequiresPostgres(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	result, err := srv.Bootstrap(context.Background(), BootstrapConfig{Environments: []string{"dev"}, SeedSuperadmin: true})
+	if err == nil {
+		t.Fatal("expected an error without API key support")
+	}
+	if result.SDKKeys["dev"] == "" {
+		t.Error("expected the SDK key created before the error to be returned")
+	}
+}
+
+func TestSetup(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "prod", "")
+	handler := srv.Router()
+
+	if rr := doSetup(t, handler, "setup_anything"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before setup is enabled, got %d: %s", rr.Code, rr.Body.String())
+	}
+	token, err := srv.EnableSetup(context.Background())
+	if err != nil || !strings.HasPrefix(token, auth.SetupTokenPrefix) {
+		t.Fatalf("expected a setup token, got %q, %v", token, err)
+	}
+	if rr := doSetup(t, handler, "setup_wrong"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong token, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr := doSetup(t, handler, token)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp setupResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Persistent || resp.Role != string(auth.RoleSuperadmin) || len(st.apiKeys) != 1 || !auth.VerifyAPIKey(resp.Key, st.apiKeys[0].KeyHash) {
+		t.Fatalf("expected a stored superadmin key, got %+v and %d keys", resp, len(st.apiKeys))
+	}
+
+	if rr := doSetup(t, handler, token); rr.Code != http.StatusNotFound {
+		t.Errorf("expected the token to be single-use, got %d", rr.Code)
+	}
+	if token, _ := srv.EnableSetup(context.Background()); token != "" {
+		t.Error("expected no setup once an API key exists")
+	}
+}
+
+func TestSetup_CompletedByAnotherReplica(t *testing.T) {
+	st := &apiKeyMemoryStore{MemoryStore: store.NewMemoryStore()}
+	srv := NewServer(st, "prod", "")
+	token, err := srv.EnableSetup(context.Background())
+	if err != nil || token == "" {
+		t.Fatalf("expected a setup token, got %q, %v", token, err)
+	}
+	// Another replica's setup created a key in the meantime.
+	if _, err := st.CreateAPIKey(context.Background(), dbgen.CreateAPIKeyParams{Name: "other", Role: dbgen.ApiKeyRole(auth.RoleSuperadmin)}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := srv.Router()
+	if rr := doSetup(t, handler, token); rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(st.apiKeys) != 1 {
+		t.Errorf("expected no second key, got %d keys", len(st.apiKeys))
+	}
+	if rr := doSetup(t, handler, token); rr.Code != http.StatusNotFound {
+		t.Errorf("expected setup to be closed, got %d", rr.Code)
+	}
+}
+
+func TestSetup_RuntimeAdminKey(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "")
+	handler := srv.Router()
+	token, err := srv.EnableSetup(context.Background())
+	if err != nil || token == "" {
+		t.Fatalf("expected a setup token, got %q, %v", token, err)
+	}
+
+	rr := doSetup(t, handler, token)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp setupResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Persistent || resp.ID != "" {
+		t.Errorf("expected a runtime key, got %+v", resp)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/flags", strings.NewReader(`{"key":"checkout","enabled":true,"rollout":100,"env":"prod"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+resp.Key)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the setup key to administer the server, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if token, _ := NewServer(store.NewMemoryStore(), "prod", "configured-key").EnableSetup(context.Background()); token != "" {
+		t.Error("expected no setup with ADMIN_API_KEY configured")
+	}
+}
+
+func doSetup(t *testing.T, handler http.Handler, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/setup", strings.NewReader(`{"token":"`+token+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
@@ -0,0 +1,422 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/targeting"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+)
+
+// A flag can have a shadow version: a pending rewrite of its targeting
+// (enabled, rollout, expression and targeting rules) that is evaluated
+// alongside the active version on every evaluate and OFREP request but never
+// served. Each comparison is counted in flag_shadow_evaluations_total and
+// summed per flag, so a rules rewrite can be checked against real traffic
+// before it goes live:
+//
+//	GET    /v1/flags/{key}/shadow?env=           shadow version and comparison (admin+)
+//	PUT    /v1/flags/{key}/shadow?env=           create or replace the shadow version (admin+)
+//	DELETE /v1/flags/{key}/shadow?env=           discard the shadow version (admin+)
+//	POST   /v1/flags/{key}/shadow/promote?env=   make the shadow version active (admin+)
+//
+// Results match when they agree on the enabled state and variant. Changing
+// the shadow version restarts the comparison. Promoting is a regular flag
+// update (validation, protection, audit and webhooks) that also discards the
+// shadow version. Comparisons are kept in memory per server instance; errors
+// of a shadow version count in the flag's evaluation error metrics.
+
+// maxShadowMismatchSamples is how many recent mismatches are kept per flag.
+const maxShadowMismatchSamples = 10
+
+type shadowRequest struct {
+	Enabled        bool         `json:"enabled"`
+	Rollout        int32        `json:"rollout"`
+	Expression     *string      `json:"expression,omitempty"`
+	TargetingRules []rules.Rule `json:"targeting_rules,omitempty"`
+}
+
+type shadowResponse struct {
+	Shadow     store.ShadowFlag `json:"shadow"`
+	Comparison shadowStats      `json:"comparison"`
+}
+
+// shadowOutcome is the compared part of an evaluation result.
+type shadowOutcome struct {
+	Key     string `json:"-"`
+	Enabled bool   `json:"enabled"`
+	Variant string `json:"variant,omitempty"`
+	Reason  string `json:"reason,omitempty"` // informational, not compared
+}
+
+func (o shadowOutcome) matches(other shadowOutcome) bool {
+	return o.Enabled == other.Enabled && o.Variant == other.Variant
+}
+
+type shadowMismatch struct {
+	UserID string        `json:"user_id,omitempty"`
+	Active shadowOutcome `json:"active"`
+	Shadow shadowOutcome `json:"shadow"`
+	At     time.Time     `json:"at"`
+}
+
+type shadowStats struct {
+	Since            time.Time        `json:"since"`
+	Evaluations      int64            `json:"evaluations"`
+	Mismatches       int64            `json:"mismatches"`
+	MismatchPercent  float64          `json:"mismatch_percent"`
+	RecentMismatches []shadowMismatch `json:"recent_mismatches"` // most recent first
+}
+
+// shadowTracker holds the shadow versions being evaluated, as flag views
+// ready for evaluation, and their comparison with the active versions.
+type shadowTracker struct {
+	mu    sync.RWMutex
+	views map[string]map[string]snapshot.FlagView // env -> key -> shadow view
+	stats map[string]*shadowStats                 // "env/key" -> comparison
+	now   func() time.Time
+}
+
+func newShadowTracker() *shadowTracker {
+	return &shadowTracker{
+		views: make(map[string]map[string]snapshot.FlagView),
+		stats: make(map[string]*shadowStats),
+		now:   time.Now,
+	}
+}
+
+// forEnv returns the shadow views of env. The map must not be modified.
+func (t *shadowTracker) forEnv(env string) map[string]snapshot.FlagView {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.views[env]
+}
+
+// setViews replaces the shadow views of env.
+func (t *shadowTracker) setViews(env string, views map[string]snapshot.FlagView) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.views[env] = views
+}
+
+// reset restarts the comparison of key in env.
+func (t *shadowTracker) reset(env, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.stats, env+"/"+key)
+}
+
+// record adds a comparison of the active and shadow results of a flag.
+func (t *shadowTracker) record(env, userID string, active, shadow shadowOutcome) {
+	match := active.matches(shadow)
+	result := "match"
+	if !match {
+		result = "mismatch"
+	}
+	telemetry.ShadowEvaluations.WithLabelValues(active.Key, result).Inc()
+
+	now := t.now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	id := env + "/" + active.Key
+	stats := t.stats[id]
+	if stats == nil {
+		stats = &shadowStats{Since: now}
+		t.stats[id] = stats
+	}
+	stats.Evaluations++
+	if match {
+		return
+	}
+	if stats.Mismatches == 0 {
+		log.Printf("[shadow] flag %s: shadow version disagrees with the active version (active enabled=%t variant=%q, shadow enabled=%t variant=%q)",
+			id, active.Enabled, active.Variant, shadow.Enabled, shadow.Variant)
+	}
+	stats.Mismatches++
+	stats.RecentMismatches = append([]shadowMismatch{{UserID: userID, Active: active, Shadow: shadow, At: now}}, stats.RecentMismatches...)
+	if len(stats.RecentMismatches) > maxShadowMismatchSamples {
+		stats.RecentMismatches = stats.RecentMismatches[:maxShadowMismatchSamples]
+	}
+}
+
+// comparison returns the comparison of key in env so far.
+func (t *shadowTracker) comparison(env, key string) shadowStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	stats, ok := t.stats[env+"/"+key]
+	if !ok {
+		return shadowStats{RecentMismatches: []shadowMismatch{}}
+	}
+	out := *stats
+	out.RecentMismatches = append([]shadowMismatch{}, stats.RecentMismatches...)
+	if out.Evaluations > 0 {
+		out.MismatchPercent = float64(out.Mismatches) * 100 / float64(out.Evaluations)
+	}
+	return out
+}
+
+// compareShadows evaluates the shadow version of each of the n flags served
+// to userID that has one and records whether the results agree. served
+// returns the i-th served result and evaluate evaluates a flag the way the
+// request did.
+func (s *Server) compareShadows(r *http.Request, userID string, n int, served func(i int) shadowOutcome, evaluate func(snapshot.FlagView) shadowOutcome) {
+	env := s.requestEnvironment(r)
+	views := s.shadows.forEnv(env)
+	if len(views) == 0 {
+		return
+	}
+	for i := 0; i < n; i++ {
+		active := served(i)
+		if view, ok := views[active.Key]; ok {
+			s.shadows.record(env, userID, active, evaluate(view))
+		}
+	}
+}
+
+// evaluationOutcome is the compared part of a /v1/flags/evaluate result.
+func evaluationOutcome(res evaluation.Result) shadowOutcome {
+	return shadowOutcome{Key: res.Key, Enabled: res.Enabled, Variant: res.Variant, Reason: res.Reason}
+}
+
+// outcome is the compared part of a /v1/evaluate result.
+func (res FlagResult) outcome() shadowOutcome {
+	return shadowOutcome{Key: res.Key, Enabled: res.Enabled, Variant: res.Variant, Reason: res.Reason}
+}
+
+// outcome is the compared part of an OFREP result.
+func (eval ofrepEvaluation) outcome() shadowOutcome {
+	return shadowOutcome{Key: eval.Key, Enabled: eval.enabled, Variant: eval.Variant, Reason: eval.Reason}
+}
+
+// refreshShadows rebuilds the shadow views of env from the store and the
+// current snapshot of env. Shadow versions of flags left out of the snapshot
+// are not evaluated.
+func (s *Server) refreshShadows(ctx context.Context, env string) error {
+	ss, ok := s.store.(store.FlagShadowStore)
+	if !ok {
+		return nil
+	}
+	shadows, err := ss.ListShadowFlags(ctx, env)
+	if err != nil {
+		return err
+	}
+	active := s.snapshotForEnv(env).Flags
+	views := make(map[string]snapshot.FlagView, len(shadows))
+	for _, shadow := range shadows {
+		view, ok := active[shadow.Key]
+		if !ok {
+			continue
+		}
+		view.Enabled = shadow.Enabled
+		view.Rollout = shadow.Rollout
+		view.Expression = shadow.Expression
+		view.TargetingRules = shadow.TargetingRules
+		views[shadow.Key] = view
+	}
+	s.shadows.setViews(env, views)
+	return nil
+}
+
+// LoadShadowFlags loads the shadow versions of env for evaluation. Snapshot
+// rebuilds reload them; call it once after loading the initial snapshot.
+func (s *Server) LoadShadowFlags(ctx context.Context, env string) error {
+	return s.refreshShadows(store.ReadPrimary(ctx), env)
+}
+
+// requireShadowStore returns the store as a FlagShadowStore, writing an error
+// response and returning nil if it does not keep shadow versions.
+func (s *Server) requireShadowStore(w http.ResponseWriter, r *http.Request) store.FlagShadowStore {
+	if ss, ok := s.store.(store.FlagShadowStore); ok {
+		return ss
+	}
+	InternalError(w, r, "Shadow versions are not supported by this store")
+	return nil
+}
+
+// shadowTarget resolves the store, environment and flag of a shadow request,
+// writing an error response and returning ok=false on failure.
+func (s *Server) shadowTarget(w http.ResponseWriter, r *http.Request) (ss store.FlagShadowStore, env string, flag *store.Flag, ok bool) {
+	if ss = s.requireShadowStore(w, r); ss == nil {
+		return nil, "", nil, false
+	}
+	if env, flag, ok = s.routeFlag(w, r); !ok {
+		return nil, "", nil, false
+	}
+	return ss, env, flag, true
+}
+
+func (s *Server) handleGetShadow(w http.ResponseWriter, r *http.Request) {
+	ss, env, flag, ok := s.shadowTarget(w, r)
+	if !ok {
+		return
+	}
+	shadow, err := ss.GetShadowFlag(r.Context(), flag.Key, env)
+	if err != nil {
+		if errors.Is(err, store.ErrShadowFlagNotFound) {
+			NotFoundError(w, r, "Flag has no shadow version")
+			return
+		}
+		InternalError(w, r, "Failed to load shadow version")
+		return
+	}
+	writeJSON(w, http.StatusOK, shadowResponse{Shadow: *shadow, Comparison: s.shadows.comparison(env, flag.Key)})
+}
+
+func (s *Server) handleSetShadow(w http.ResponseWriter, r *http.Request) {
+	var req shadowRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	ss, env, flag, ok := s.shadowTarget(w, r)
+	if !ok {
+		return
+	}
+	if werr := s.validateShadowRequest(r, flag, req); werr != nil {
+		werr.write(w, r)
+		return
+	}
+
+	var beforeState map[string]any
+	before, err := ss.GetShadowFlag(r.Context(), flag.Key, env)
+	if err == nil {
+		beforeState = shadowToMap(before)
+	}
+	shadow, err := ss.SetShadowFlag(r.Context(), store.ShadowFlag{
+		Key:            flag.Key,
+		Env:            env,
+		Enabled:        req.Enabled,
+		Rollout:        req.Rollout,
+		Expression:     req.Expression,
+		TargetingRules: req.TargetingRules,
+	})
+	if err != nil {
+		s.auditLog(r, audit.ActionUpdated, audit.ResourceTypeFlagShadow, flag.Key, env, nil, nil, nil, audit.StatusFailure, "Failed to save shadow version")
+		InternalError(w, r, "Failed to save shadow version")
+		return
+	}
+	s.shadows.reset(env, flag.Key)
+	if err := s.refreshShadows(r.Context(), env); err != nil {
+		InternalError(w, r, "Failed to load shadow versions")
+		return
+	}
+
+	action := audit.ActionUpdated
+	if beforeState == nil {
+		action = audit.ActionCreated
+	}
+	afterState := shadowToMap(shadow)
+	s.auditLog(r, action, audit.ResourceTypeFlagShadow, flag.Key, env, beforeState, afterState, audit.ComputeChanges(beforeState, afterState), audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, shadowResponse{Shadow: *shadow, Comparison: s.shadows.comparison(env, flag.Key)})
+}
+
+// validateShadowRequest checks a shadow version like the same fields of a
+// flag write.
+func (s *Server) validateShadowRequest(r *http.Request, flag *store.Flag, req shadowRequest) *flagWriteError {
+	if message, ok := validation.ValidateRollout(req.Rollout).Errors["rollout"]; ok {
+		return validationWriteError("Validation failed for one or more fields", map[string]string{"rollout": message})
+	}
+	if field, message, ok := validateTargetingRules(req.TargetingRules); !ok {
+		return validationWriteError("invalid targeting_rules", map[string]string{field: message})
+	}
+	if req.Expression != nil && *req.Expression != "" {
+		if err := targeting.ValidateExpression(*req.Expression); err != nil {
+			return &flagWriteError{
+				status:  http.StatusBadRequest,
+				code:    ErrCodeInvalidExpression,
+				message: "Invalid expression",
+				fields:  map[string]string{"expression": err.Error()},
+			}
+		}
+	}
+	if exceedsLimit(len(req.TargetingRules), len(flag.TargetingRules), s.limits.MaxRulesPerFlag) {
+		return limitWriteError("targeting_rules", "Flag has too many targeting rules", s.limits.MaxRulesPerFlag)
+	}
+	return s.checkRuleAttributes(r, flag.Key, req.TargetingRules)
+}
+
+func (s *Server) handleDeleteShadow(w http.ResponseWriter, r *http.Request) {
+	ss, env, flag, ok := s.shadowTarget(w, r)
+	if !ok {
+		return
+	}
+	before, err := ss.GetShadowFlag(r.Context(), flag.Key, env)
+	if err == nil {
+		err = ss.DeleteShadowFlag(r.Context(), flag.Key, env)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrShadowFlagNotFound) {
+			NotFoundError(w, r, "Flag has no shadow version")
+			return
+		}
+		InternalError(w, r, "Failed to discard shadow version")
+		return
+	}
+	s.shadows.reset(env, flag.Key)
+	if err := s.refreshShadows(r.Context(), env); err != nil {
+		InternalError(w, r, "Failed to load shadow versions")
+		return
+	}
+
+	beforeState := shadowToMap(before)
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeFlagShadow, flag.Key, env, beforeState, nil, audit.ComputeChanges(beforeState, nil), audit.StatusSuccess, "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePromoteShadow(w http.ResponseWriter, r *http.Request) {
+	ss, env, flag, ok := s.shadowTarget(w, r)
+	if !ok {
+		return
+	}
+	shadow, err := ss.GetShadowFlag(r.Context(), flag.Key, env)
+	if err != nil {
+		if errors.Is(err, store.ErrShadowFlagNotFound) {
+			NotFoundError(w, r, "Flag has no shadow version")
+			return
+		}
+		InternalError(w, r, "Failed to load shadow version")
+		return
+	}
+
+	req := upsertRequestFromFlag(flag)
+	req.Enabled = shadow.Enabled
+	req.Rollout = shadow.Rollout
+	req.Expression = shadow.Expression
+	req.TargetingRules = shadow.TargetingRules
+	if _, werr := s.saveFlag(r, req); werr != nil {
+		werr.write(w, r)
+		return
+	}
+	if err := ss.DeleteShadowFlag(r.Context(), flag.Key, env); err != nil && !errors.Is(err, store.ErrShadowFlagNotFound) {
+		InternalError(w, r, "Failed to discard shadow version")
+		return
+	}
+	s.shadows.reset(env, flag.Key)
+	if err := s.refreshShadows(r.Context(), env); err != nil {
+		InternalError(w, r, "Failed to load shadow versions")
+		return
+	}
+	writeJSON(w, http.StatusOK, upsertResponse{OK: true, ETag: s.snapshotForEnv(env).ETag})
+}
+
+// shadowToMap is the audit state of a shadow version.
+func shadowToMap(shadow *store.ShadowFlag) map[string]any {
+	m := map[string]any{
+		"enabled":         shadow.Enabled,
+		"rollout":         shadow.Rollout,
+		"targeting_rules": shadow.TargetingRules,
+	}
+	if shadow.Expression != nil {
+		m["expression"] = *shadow.Expression
+	}
+	return m
+}
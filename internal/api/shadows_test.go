@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestShadowFlag_CompareAndPromote(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/missing/shadow", `{"enabled":true,"rollout":100}`)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown flag, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/shadow", `{"enabled":true,"rollout":150}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid rollout, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/shadow",
+		`{"enabled":true,"rollout":100,"expression":"{\"==\": [{\"var\": \"plan\"}, \"premium\"]}"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	evaluate := func(plan string) evaluateResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/v1/flags/evaluate?userId=user-1&plan="+plan, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("evaluate: expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp evaluateResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	for _, plan := range []string{"premium", "free"} {
+		if resp := evaluate(plan); len(resp.Flags) != 1 || !resp.Flags[0].Enabled {
+			t.Fatalf("expected the active version to be served for plan %s, got %+v", plan, resp.Flags)
+		}
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/shadow", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp shadowResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	cmp := resp.Comparison
+	if cmp.Evaluations != 2 || cmp.Mismatches != 1 || cmp.MismatchPercent != 50 || len(cmp.RecentMismatches) != 1 {
+		t.Fatalf("unexpected comparison: %+v", cmp)
+	}
+	if m := cmp.RecentMismatches[0]; m.UserID != "user-1" || !m.Active.Enabled || m.Shadow.Enabled {
+		t.Errorf("unexpected mismatch: %+v", m)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout/shadow/promote", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("promote: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp := evaluate("free"); resp.Flags[0].Enabled {
+		t.Errorf("expected the promoted expression to be served, got %+v", resp.Flags[0])
+	}
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout/shadow", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected the shadow version to be discarded on promote, got %d", rr.Code)
+	}
+}
+
+func TestShadowFlag_Discard(t *testing.T) {
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	ctx := context.Background()
+
+	st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Env: "prod"})
+	srv.RebuildSnapshot(ctx, "prod")
+
+	rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout/shadow", `{"enabled":false}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(srv.shadows.forEnv("prod")) != 1 {
+		t.Fatal("expected the shadow version to be evaluated")
+	}
+	rr = doV2Request(t, handler, http.MethodDelete, "/v1/flags/checkout/shadow", "")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(srv.shadows.forEnv("prod")) != 0 {
+		t.Error("expected the discarded shadow version to stop being evaluated")
+	}
+	rr = doV2Request(t, handler, http.MethodDelete, "/v1/flags/checkout/shadow", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a shadow version, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+)
+
+// The snapshot payload carries a schemaVersion (see snapshot.SchemaVersion)
+// so its format can evolve without breaking SDKs that are already deployed.
+// A client pins the version it understands with the Accept header:
+//
+//	GET /v1/flags/snapshot   Accept: application/vnd.flagship.v1+json
+//
+// and is answered in that version with the same media type. Clients that send
+// no versioned media type get the current version as application/json. If a
+// client accepts only versions this server cannot render, it gets 406
+// NOT_ACCEPTABLE. The same negotiation applies to GET /v1/flags/updates and to
+// the snapshot embedded in GET /v1/client/bootstrap.
+//
+// When the format changes, bump snapshot.SchemaVersion and add a renderer to
+// snapshotRenderers that converts the current snapshot to the old format.
+
+const snapshotMediaTypePrefix = "application/vnd.flagship.v"
+
+// snapshotRenderers renders a snapshot in each supported schema version.
+var snapshotRenderers = map[int]func(*snapshot.Snapshot) any{
+	1: func(snap *snapshot.Snapshot) any { return snap },
+}
+
+// snapshotSchema is the outcome of negotiating a snapshot schema version.
+type snapshotSchema struct {
+	version     int
+	contentType string
+}
+
+func (sc snapshotSchema) render(snap *snapshot.Snapshot) any {
+	return snapshotRenderers[sc.version](snap)
+}
+
+// snapshotMediaType is the media type of schema version v.
+func snapshotMediaType(v int) string {
+	return snapshotMediaTypePrefix + strconv.Itoa(v) + "+json"
+}
+
+// negotiateSnapshotSchema picks the snapshot schema version for r from its
+// Accept header, preferring the highest supported version the client lists.
+// It reports false if the client only accepts unsupported versions.
+func negotiateSnapshotSchema(r *http.Request) (snapshotSchema, bool) {
+	current := snapshotSchema{version: snapshot.SchemaVersion, contentType: "application/json"}
+	best, versioned, fallback := 0, false, false
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+				continue
+			}
+			switch mediaType {
+			case "application/json", "application/*", "*/*":
+				fallback = true
+				continue
+			}
+			raw, ok := strings.CutPrefix(mediaType, snapshotMediaTypePrefix)
+			if !ok {
+				continue
+			}
+			versioned = true
+			v, err := strconv.Atoi(strings.TrimSuffix(raw, "+json"))
+			if _, supported := snapshotRenderers[v]; err == nil && supported && v > best {
+				best = v
+			}
+		}
+	}
+	switch {
+	case best > 0:
+		return snapshotSchema{version: best, contentType: snapshotMediaType(best)}, true
+	case versioned && !fallback:
+		return snapshotSchema{}, false
+	default:
+		return current, true
+	}
+}
+
+// requireSnapshotSchema negotiates the snapshot schema version for r, writing
+// a 406 response and returning false if none is acceptable.
+func requireSnapshotSchema(w http.ResponseWriter, r *http.Request) (snapshotSchema, bool) {
+	w.Header().Add("Vary", "Accept")
+	schema, ok := negotiateSnapshotSchema(r)
+	if ok {
+		return schema, true
+	}
+	supported := make([]string, 0, len(snapshotRenderers))
+	for v := 1; v <= snapshot.SchemaVersion; v++ {
+		if _, ok := snapshotRenderers[v]; ok {
+			supported = append(supported, snapshotMediaType(v))
+		}
+	}
+	errResp := NewErrorResponse(http.StatusNotAcceptable, ErrCodeNotAcceptable,
+		"Requested snapshot schema version is not supported").
+		WithFields(map[string]string{"Accept": "must include one of " + strings.Join(supported, ", ")})
+	writeErrorResponse(w, r, http.StatusNotAcceptable, errResp)
+	return snapshotSchema{}, false
+}
+
+// writeSnapshot writes snap in the negotiated schema version.
+func writeSnapshot(w http.ResponseWriter, schema snapshotSchema, snap *snapshot.Snapshot) {
+	w.Header().Set("Content-Type", schema.contentType)
+	_ = json.NewEncoder(w).Encode(schema.render(snap))
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestSnapshotSchemaNegotiation(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	srv := NewServer(st, "prod", "test-key")
+	handler := srv.Router()
+	_ = st.UpsertFlag(ctx, store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Env: "prod"})
+	if err := srv.RebuildSnapshot(ctx, "prod"); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		accept     string
+		wantStatus int
+		wantType   string
+	}{
+		{"no accept header", "", http.StatusOK, "application/json"},
+		{"plain json", "application/json", http.StatusOK, "application/json"},
+		{"pinned v1", "application/vnd.flagship.v1+json", http.StatusOK, "application/vnd.flagship.v1+json"},
+		{"highest supported version wins", "application/vnd.flagship.v99+json, application/vnd.flagship.v1+json;q=0.5", http.StatusOK, "application/vnd.flagship.v1+json"},
+		{"unsupported version with fallback", "application/vnd.flagship.v99+json, */*;q=0.1", http.StatusOK, "application/json"},
+		{"unsupported version only", "application/vnd.flagship.v99+json", http.StatusNotAcceptable, ""},
+		{"refused version", "application/vnd.flagship.v1+json;q=0, application/vnd.flagship.v99+json", http.StatusNotAcceptable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/flags/snapshot", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if !slices.Contains(rr.Header().Values("Vary"), "Accept") {
+				t.Errorf("expected Vary: Accept, got %q", rr.Header().Values("Vary"))
+			}
+			if tt.wantStatus != http.StatusOK {
+				var errResp ErrorResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &errResp); err != nil || errResp.Code != ErrCodeNotAcceptable {
+					t.Errorf("expected NOT_ACCEPTABLE, got %s", rr.Body.String())
+				}
+				return
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantType, got)
+			}
+			var snap snapshot.Snapshot
+			if err := json.Unmarshal(rr.Body.Bytes(), &snap); err != nil {
+				t.Fatal(err)
+			}
+			if snap.SchemaVersion != snapshot.SchemaVersion {
+				t.Errorf("expected schemaVersion %d, got %d", snapshot.SchemaVersion, snap.SchemaVersion)
+			}
+			if _, ok := snap.Flags["checkout"]; !ok {
+				t.Errorf("expected checkout in the snapshot, got %v", snap.Flags)
+			}
+		})
+	}
+
+	t.Run("long poll and bootstrap negotiate too", func(t *testing.T) {
+		for _, path := range []string{"/v1/flags/updates?etag=old", "/v1/client/bootstrap"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Accept", "application/vnd.flagship.v99+json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusNotAcceptable {
+				t.Errorf("%s: expected 406, got %d", path, rr.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flags nobody uses or touches any more can be listed for cleanup:
+//
+//	GET /v1/flags/stale?days=30&env=<env>   (admin+)
+//
+// A flag of env (default: the server's environment) is listed when, for the
+// last days (default 30, at most 3650), it was
+//   - not_evaluated: not evaluated by the server or reported via /v1/exposures
+//   - not_modified: not changed
+//
+// Evaluation times are kept in memory and written to the store every
+// EVALUATION_FLUSH_INTERVAL (see evaltracker.go), so a flag last evaluated
+// before that was deployed, or on a store without evaluation times before the
+// last restart, counts as never evaluated. Archived flags are not listed.
+// Flags are ordered by their last activity, oldest first. Unlike the cleanup
+// report (see cleanup.go), this endpoint never archives anything.
+
+const (
+	staleReasonNotEvaluated = "not_evaluated"
+	staleReasonNotModified  = "not_modified"
+
+	defaultStaleDays = 30
+	maxStaleDays     = 3650
+)
+
+type staleFlag struct {
+	Key             string     `json:"key"`
+	Reasons         []string   `json:"reasons"`
+	Enabled         bool       `json:"enabled"`
+	Rollout         int32      `json:"rollout"`
+	Owner           string     `json:"owner,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at"` // null if never evaluated
+}
+
+type staleFlagsResponse struct {
+	Env    string      `json:"env"`
+	Days   int         `json:"days"`
+	Cutoff time.Time   `json:"cutoff"`
+	Flags  []staleFlag `json:"flags"`
+}
+
+func (s *Server) handleListStaleFlags(w http.ResponseWriter, r *http.Request) {
+	days := defaultStaleDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxStaleDays {
+			ValidationError(w, r, "Invalid query parameters", map[string]string{
+				"days": fmt.Sprintf("must be between 1 and %d", maxStaleDays),
+			})
+			return
+		}
+		days = n
+	}
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = s.env
+	}
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+
+	flags, err := s.store.GetAllFlags(r.Context(), env)
+	if err != nil {
+		InternalError(w, r, "Failed to load flags")
+		return
+	}
+	evaluated, err := s.lastEvaluations(r.Context(), env)
+	if err != nil {
+		InternalError(w, r, "Failed to load evaluation times")
+		return
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -days)
+	resp := staleFlagsResponse{Env: env, Days: days, Cutoff: cutoff, Flags: []staleFlag{}}
+	for i := range flags {
+		flag := &flags[i]
+		if flag.Archived {
+			continue
+		}
+		sf := staleFlag{Key: flag.Key, Enabled: flag.Enabled, Rollout: flag.Rollout, Owner: flag.Owner, UpdatedAt: flag.UpdatedAt}
+		lastEval, ok := evaluated[flag.Key]
+		if ok {
+			sf.LastEvaluatedAt = &lastEval
+		}
+		if !ok || lastEval.Before(cutoff) {
+			sf.Reasons = append(sf.Reasons, staleReasonNotEvaluated)
+		}
+		if flag.UpdatedAt.Before(cutoff) {
+			sf.Reasons = append(sf.Reasons, staleReasonNotModified)
+		}
+		if len(sf.Reasons) > 0 {
+			resp.Flags = append(resp.Flags, sf)
+		}
+	}
+	sort.Slice(resp.Flags, func(i, j int) bool {
+		a, b := lastActivity(resp.Flags[i]), lastActivity(resp.Flags[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return resp.Flags[i].Key < resp.Flags[j].Key
+	})
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// lastActivity is when f was last changed or evaluated.
+func lastActivity(f staleFlag) time.Time {
+	if f.LastEvaluatedAt != nil {
+		return latest(f.UpdatedAt, *f.LastEvaluatedAt)
+	}
+	return f.UpdatedAt
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func getStaleFlags(t *testing.T, handler http.Handler, query string) staleFlagsResponse {
+	t.Helper()
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags/stale"+query, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp staleFlagsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestStaleFlags_ListsUnevaluatedFlags(t *testing.T) {
+	st := store.NewMemoryStore()
+	for _, p := range []store.UpsertParams{
+		{Key: "active", Enabled: true, Rollout: 100, Env: "prod"},
+		{Key: "unused", Enabled: true, Rollout: 100, Env: "prod", Owner: "team-search"},
+		{Key: "retired", Enabled: false, Env: "prod", Archived: true},
+	} {
+		if err := st.UpsertFlag(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(st, "prod", "test-key")
+	srv.evalTracker.touch("prod", "active")
+
+	resp := getStaleFlags(t, srv.Router(), "?days=7")
+	if resp.Env != "prod" || resp.Days != 7 || len(resp.Flags) != 1 {
+		t.Fatalf("expected only unused to be stale, got %+v", resp)
+	}
+	if f := resp.Flags[0]; f.Key != "unused" || f.Owner != "team-search" || f.LastEvaluatedAt != nil ||
+		len(f.Reasons) != 1 || f.Reasons[0] != staleReasonNotEvaluated {
+		t.Errorf("unexpected stale flag: %+v", f)
+	}
+
+	rr := doV2Request(t, srv.Router(), http.MethodGet, "/v1/flags/stale?days=0", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("days=0: expected 400, got %d", rr.Code)
+	}
+}
+
+func TestStaleFlags_EvaluationTimesOutliveRestarts(t *testing.T) {
+	st := store.NewMemoryStore()
+	if err := st.UpsertFlag(context.Background(), store.UpsertParams{Key: "active", Enabled: true, Rollout: 100, Env: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(st, "prod", "test-key")
+	srv.evalTracker.startPending()
+	evaluatedAt := time.Now().UTC().Add(-time.Hour)
+	srv.evalTracker.now = func() time.Time { return evaluatedAt }
+	srv.evalTracker.touch("prod", "active", "unknown")
+	if err := srv.FlushEvaluations(context.Background()); err != nil {
+		t.Fatalf("FlushEvaluations: %v", err)
+	}
+	if batch := srv.evalTracker.takePending(); batch != nil {
+		t.Errorf("expected the flush to take every pending evaluation, left %v", batch)
+	}
+
+	restarted := NewServer(st, "prod", "test-key")
+	resp := getStaleFlags(t, restarted.Router(), "")
+	if len(resp.Flags) != 0 {
+		t.Errorf("expected the flushed evaluation to keep active fresh after a restart, got %+v", resp.Flags)
+	}
+}
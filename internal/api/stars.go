@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// Stars pin the handful of flags that matter to a caller, e.g. the flags an
+// on-call dashboard shows during an incident:
+//
+//	PUT    /v1/flags/{key}/star?env=       star a flag (admin+)
+//	DELETE /v1/flags/{key}/star?env=       unstar it (admin+)
+//	GET    /v1/flags?env=&starred=true     list only starred flags
+//
+// Stars belong to the calling API key; requests authenticated with the legacy
+// admin key share one set. Both writes are idempotent and answer 204. Deleting
+// a flag removes its stars.
+
+// starOwner identifies the caller whose stars r reads and writes.
+func starOwner(r *http.Request) string {
+	if apiKeyID, ok := auth.GetAPIKeyIDFromContext(r.Context()); ok && apiKeyID.Valid {
+		return "api_key:" + formatUUID(apiKeyID)
+	}
+	return "legacy-admin"
+}
+
+// requireFlagStarStore returns the store as a FlagStarStore, writing an error
+// response and returning nil if it does not support stars.
+func (s *Server) requireFlagStarStore(w http.ResponseWriter, r *http.Request) store.FlagStarStore {
+	if ss, ok := s.store.(store.FlagStarStore); ok {
+		return ss
+	}
+	InternalError(w, r, "Flag stars are not supported by this store")
+	return nil
+}
+
+func (s *Server) handleStarFlag(w http.ResponseWriter, r *http.Request) {
+	ss := s.requireFlagStarStore(w, r)
+	if ss == nil {
+		return
+	}
+	key, env := strings.TrimSpace(chi.URLParam(r, "id")), s.queryEnv(r)
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+	if _, ok := s.loadEnvFlag(r, key, env); !ok {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+	if err := ss.StarFlag(r.Context(), starOwner(r), key, env); err != nil {
+		InternalError(w, r, "Failed to star flag")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleUnstarFlag(w http.ResponseWriter, r *http.Request) {
+	ss := s.requireFlagStarStore(w, r)
+	if ss == nil {
+		return
+	}
+	key, env := strings.TrimSpace(chi.URLParam(r, "id")), s.queryEnv(r)
+	if !requireTenantEnv(w, r, env) {
+		return
+	}
+	if err := ss.UnstarFlag(r.Context(), starOwner(r), key, env); err != nil {
+		InternalError(w, r, "Failed to unstar flag")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// starredFlagKeys returns the keys of the flags the caller of r starred in
+// env, writing an error response and returning false on failure.
+func (s *Server) starredFlagKeys(w http.ResponseWriter, r *http.Request, env string) ([]string, bool) {
+	ss := s.requireFlagStarStore(w, r)
+	if ss == nil {
+		return nil, false
+	}
+	keys, err := ss.ListStarredFlags(r.Context(), starOwner(r), env)
+	if err != nil {
+		InternalError(w, r, "Failed to list starred flags")
+		return nil, false
+	}
+	return keys, true
+}
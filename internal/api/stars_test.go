@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func listFlagKeys(t *testing.T, rr *httptest.ResponseRecorder) []string {
+	t.Helper()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp listFlagsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, len(resp.Flags))
+	for i, f := range resp.Flags {
+		keys[i] = f.Key
+	}
+	return keys
+}
+
+func TestStars_StarUnstarAndList(t *testing.T) {
+	st := store.NewMemoryStore()
+	for _, key := range []string{"checkout", "search", "banner"} {
+		if err := st.UpsertFlag(context.Background(), store.UpsertParams{Key: key, Enabled: true, Rollout: 100, Env: "prod"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	for _, key := range []string{"search", "checkout", "checkout"} {
+		if rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/"+key+"/star", ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("star %s: expected 204, got %d: %s", key, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/missing/star", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("star missing flag: expected 404, got %d", rr.Code)
+	}
+
+	keys := listFlagKeys(t, doV2Request(t, handler, http.MethodGet, "/v1/flags?starred=true", ""))
+	if len(keys) != 2 {
+		t.Fatalf("expected the 2 starred flags, got %v", keys)
+	}
+	if keys := listFlagKeys(t, doV2Request(t, handler, http.MethodGet, "/v1/flags", "")); len(keys) != 3 {
+		t.Errorf("expected all 3 flags without starred, got %v", keys)
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := doV2Request(t, handler, http.MethodDelete, "/v1/flags/search/star", ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("unstar: expected 204, got %d", rr.Code)
+		}
+	}
+	if keys := listFlagKeys(t, doV2Request(t, handler, http.MethodGet, "/v1/flags?starred=true", "")); len(keys) != 1 || keys[0] != "checkout" {
+		t.Errorf("expected only checkout after unstarring search, got %v", keys)
+	}
+
+	// Deleting a flag removes its stars.
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v1/flags?key=checkout&env=prod", ""); rr.Code >= 300 {
+		t.Fatalf("delete: got %d: %s", rr.Code, rr.Body.String())
+	}
+	if keys := listFlagKeys(t, doV2Request(t, handler, http.MethodGet, "/v1/flags?starred=true&include_deleted=true", "")); len(keys) != 0 {
+		t.Errorf("expected no starred flags after delete, got %v", keys)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/flags?starred=maybe", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid starred: expected 400, got %d", rr.Code)
+	}
+}
+
+func TestStars_PerAPIKey(t *testing.T) {
+	st := store.NewMemoryStore()
+	if err := st.UpsertFlag(context.Background(), store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Env: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(st, "prod", "test-key")
+	keyRequest := func(id byte, method, target string) *http.Request {
+		r := tenantRequest(auth.DefaultTenant, method, target, "")
+		return r.WithContext(context.WithValue(r.Context(), auth.ContextKeyAPIKey, pgtype.UUID{Bytes: [16]byte{id}, Valid: true}))
+	}
+
+	if err := st.StarFlag(context.Background(), starOwner(keyRequest(1, http.MethodGet, "/")), "checkout", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[byte]int{1: 1, 2: 0} {
+		rr := httptest.NewRecorder()
+		srv.handleListFlags(rr, keyRequest(id, http.MethodGet, "/v1/flags?starred=true"))
+		if keys := listFlagKeys(t, rr); len(keys) != want {
+			t.Errorf("key %d: expected %d starred flags, got %v", id, want, keys)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base32"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+var stepUpTestSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// newStepUpServer returns a server requiring step-up codes, with two
+// protected flags tagged "payments".
+func newStepUpServer(t *testing.T) (*Server, *store.MemoryStore, *auth.TOTP) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	for _, key := range []string{"card_payments", "wallet_payments"} {
+		if err := st.UpsertFlag(context.Background(), store.UpsertParams{Key: key, Enabled: true, Rollout: 100, Protected: true, Tags: []string{"payments"}, Env: "prod"}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	totp, err := auth.NewTOTP(stepUpTestSecret)
+	if err != nil {
+		t.Fatalf("NewTOTP: %v", err)
+	}
+	srv := NewServer(st, "prod", "test-key")
+	srv.SetStepUpTOTP(totp)
+	return srv, st, totp
+}
+
+// doStepUpRequest sends a superadmin request with the given step-up code.
+func doStepUpRequest(handler http.Handler, method, path, body, code string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-key")
+	if code != "" {
+		req.Header.Set(StepUpHeader, code)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestStepUp_RevokeAPIKey(t *testing.T) {
+	srv, _, totp := newStepUpServer(t)
+	handler := srv.Router()
+
+	rr := doStepUpRequest(handler, http.MethodDelete, "/v1/admin/keys/not-a-uuid", "", "")
+	if rr.Code != http.StatusForbidden || decodeErrorResponse(t, rr.Body.Bytes()).Code != ErrCodeStepUpRequired {
+		t.Fatalf("without code: expected 403 STEP_UP_REQUIRED, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doStepUpRequest(handler, http.MethodDelete, "/v1/admin/keys/not-a-uuid", "", "000000")
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("wrong code: expected 403, got %d", rr.Code)
+	}
+
+	// A valid code reaches the handler, which rejects the malformed ID.
+	code := totp.Code(time.Now())
+	rr = doStepUpRequest(handler, http.MethodDelete, "/v1/admin/keys/not-a-uuid", "", code)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("valid code: expected 400 from handler, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doStepUpRequest(handler, http.MethodDelete, "/v1/admin/keys/not-a-uuid", "", code)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("replayed code: expected 403, got %d", rr.Code)
+	}
+}
+
+func TestStepUp_ProtectedFlagChanges(t *testing.T) {
+	srv, st, totp := newStepUpServer(t)
+	handler := srv.Router()
+	ctx := context.Background()
+
+	rr := doStepUpRequest(handler, http.MethodDelete, "/v1/flags?key=card_payments&env=prod", "", "")
+	if rr.Code != http.StatusForbidden || decodeErrorResponse(t, rr.Body.Bytes()).Code != ErrCodeStepUpRequired {
+		t.Fatalf("delete without code: expected 403 STEP_UP_REQUIRED, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := st.GetFlagByKey(ctx, "card_payments", "prod"); err != nil {
+		t.Fatal("flag should not have been deleted")
+	}
+
+	// Non-destructive changes don't need a code.
+	rr = doStepUpRequest(handler, http.MethodPost, "/v1/flags", `{"key":"card_payments","enabled":true,"rollout":50,"tags":["payments"],"env":"prod"}`, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("harmless update: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// One code covers every protected flag in a bulk action.
+	rr = doStepUpRequest(handler, http.MethodPost, "/v1/flags/bulk-action", `{"env":"prod","tags":["payments"],"action":"disable"}`, totp.Code(time.Now()))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("bulk disable with code: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	for _, key := range []string{"card_payments", "wallet_payments"} {
+		if flag, err := st.GetFlagByKey(ctx, key, "prod"); err != nil || flag.Enabled {
+			t.Errorf("%s should be disabled: %+v, %v", key, flag, err)
+		}
+	}
+}
+
+func TestStepUp_ForcedAdminChange(t *testing.T) {
+	srv, st, totp := newStepUpServer(t)
+	target := "/v1/flags?key=card_payments&env=prod&force=true&reason=incident+42"
+
+	werr := srv.removeFlag(adminRequest(http.MethodDelete, target), "card_payments", "prod")
+	if werr == nil || werr.code != ErrCodeStepUpRequired {
+		t.Fatalf("forced delete without code: expected STEP_UP_REQUIRED, got %+v", werr)
+	}
+	if _, err := st.GetFlagByKey(context.Background(), "card_payments", "prod"); err != nil {
+		t.Fatal("flag should not have been deleted")
+	}
+
+	req := adminRequest(http.MethodDelete, target)
+	req.Header.Set(StepUpHeader, totp.Code(time.Now()))
+	if werr := srv.removeFlag(req, "card_payments", "prod"); werr != nil {
+		t.Fatalf("forced delete with code: %+v", werr)
+	}
+}
+
+func TestStepUp_DisabledByDefault(t *testing.T) {
+	srv, _ := newProtectedFlagServer(t)
+	rr := doV2Request(t, srv.Router(), http.MethodDelete, "/v1/flags?key=payments&env=prod", "")
+	if rr.Code != http.StatusNoContent && rr.Code != http.StatusOK {
+		t.Fatalf("expected superadmin delete without step-up to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestStepUp_DestructiveAdminRoutes(t *testing.T) {
+	srv, st, totp := newStepUpServer(t)
+	handler := srv.Router()
+	if _, err := st.CreateProject(context.Background(), store.Project{Key: "checkout", Name: "Checkout"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodDelete, "/v1/projects/checkout"},
+		{http.MethodDelete, "/v1/admin/webhooks/550e8400-e29b-41d4-a716-446655440000"},
+		{http.MethodPost, "/v1/admin/encryption/rotate"},
+	} {
+		rr := doStepUpRequest(handler, route.method, route.path, "", "")
+		if rr.Code != http.StatusForbidden || decodeErrorResponse(t, rr.Body.Bytes()).Code != ErrCodeStepUpRequired {
+			t.Errorf("%s %s without code: expected 403 STEP_UP_REQUIRED, got %d: %s", route.method, route.path, rr.Code, rr.Body.String())
+		}
+	}
+	if _, err := st.GetProject(context.Background(), "checkout"); err != nil {
+		t.Fatal("project should not have been deleted")
+	}
+
+	rr := doStepUpRequest(handler, http.MethodDelete, "/v1/projects/checkout", "", totp.Code(time.Now()))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("delete with code: expected 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/report"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// A periodic job (weekly by default) summarizes each project's default
+// environment over the last period: new, changed and deleted flags, stale
+// flags (from the cleanup report), running experiments and audit anomalies.
+//
+//	GET /v1/admin/reports/summary?format=json|text   preview the last 7 days (admin+)
+//
+// The report is rendered with a text/template (report.DefaultTemplate unless
+// SetSummaryReport says otherwise), dispatched as a "report.summary" webhook
+// event carrying both the data and the text, and emailed if a mailer is
+// configured. The only project is "default".
+
+const (
+	// summaryPreviewPeriod is the period covered by the preview endpoint.
+	summaryPreviewPeriod = 7 * 24 * time.Hour
+
+	// maxSummaryAuditLogs caps the audit entries read for one report.
+	maxSummaryAuditLogs = maxAuditExportLimit
+)
+
+// summaryAnomalies are the audit actions reported as anomalies, in report order.
+var summaryAnomalies = []struct{ action, description string }{
+	{audit.ActionAuthFailed, "Failed authentications"},
+	{audit.ActionAuthLockedOut, "Authentication lockouts"},
+	{audit.ActionProtectionOverridden, "Protected flag overrides"},
+	{audit.ActionCircuitOpened, "Webhook circuits opened"},
+}
+
+// SummaryReportConfig configures delivery of the summary report.
+type SummaryReportConfig struct {
+	Template *report.Template // nil uses report.DefaultTemplate
+	Mailer   *report.Mailer   // nil disables email
+}
+
+// SetSummaryReport configures how the summary report is rendered and sent.
+func (s *Server) SetSummaryReport(cfg SummaryReportConfig) {
+	s.summaryReport = cfg
+}
+
+type summaryReportResponse struct {
+	report.Summary
+	Text string `json:"text"`
+}
+
+// handleSummaryReport handles GET /v1/admin/reports/summary.
+func (s *Server) handleSummaryReport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		ValidationError(w, r, "Invalid query parameters", map[string]string{"format": "must be json or text"})
+		return
+	}
+	now := time.Now().UTC()
+	summary, err := s.buildSummary(r.Context(), now.Add(-summaryPreviewPeriod), now)
+	if err != nil {
+		InternalError(w, r, "Failed to build summary report")
+		return
+	}
+	text, err := s.summaryTemplate().Render(summary)
+	if err != nil {
+		InternalError(w, r, "Failed to render summary report")
+		return
+	}
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(text))
+		return
+	}
+	writeJSON(w, http.StatusOK, summaryReportResponse{Summary: summary, Text: text})
+}
+
+// RunSummaryReports runs RunSummaryReport every interval until ctx is
+// cancelled, each covering the preceding interval. It returns immediately if
+// interval is not positive.
+func (s *Server) RunSummaryReports(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().UTC()
+			if _, err := s.RunSummaryReport(ctx, now.Add(-interval), now); err != nil {
+				log.Printf("[report] summary failed: %v", err)
+			}
+			worker.Processed(ctx)
+		}
+	}
+}
+
+// RunSummaryReport builds the summary report for [from, to), renders it and
+// sends it as a webhook event and, if configured, by email. Delivery errors
+// are logged; the rendered text is returned.
+func (s *Server) RunSummaryReport(ctx context.Context, from, to time.Time) (string, error) {
+	summary, err := s.buildSummary(ctx, from, to)
+	if err != nil {
+		return "", err
+	}
+	text, err := s.summaryTemplate().Render(summary)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("[report] summary project=%s env=%s new=%d changed=%d stale=%d anomalies=%d",
+		summary.Project, summary.Env, len(summary.NewFlags), len(summary.ChangedFlags), len(summary.StaleFlags), len(summary.Anomalies))
+
+	if s.webhookDispatcher != nil {
+		data := summaryReportData(summaryReportResponse{Summary: summary, Text: text})
+		s.webhookDispatcher.Dispatch(webhook.NewSystemEventBuilder().
+			ForReport(webhook.EventSummaryReport, "summary", summary.Env, data).
+			Build())
+	}
+	if mailer := s.summaryReport.Mailer; mailer != nil {
+		if err := mailer.Send(summary.Subject(), text); err != nil {
+			log.Printf("[report] %v", err)
+		}
+	}
+	return text, nil
+}
+
+func (s *Server) summaryTemplate() *report.Template {
+	if s.summaryReport.Template != nil {
+		return s.summaryReport.Template
+	}
+	tmpl, _ := report.ParseTemplate(report.DefaultTemplate)
+	return tmpl
+}
+
+// buildSummary gathers the summary of the default project and environment
+// for [from, to).
+func (s *Server) buildSummary(ctx context.Context, from, to time.Time) (report.Summary, error) {
+	summary := report.Summary{
+		Project:      store.DefaultProject,
+		Env:          s.env,
+		From:         from,
+		To:           to,
+		GeneratedAt:  time.Now().UTC(),
+		NewFlags:     []string{},
+		ChangedFlags: []report.FlagChange{},
+		DeletedFlags: []string{},
+		StaleFlags:   []report.StaleFlag{},
+		Experiments:  []report.Experiment{},
+		Anomalies:    []report.Anomaly{},
+	}
+
+	flags, err := s.store.GetAllFlags(ctx, s.env)
+	if err != nil {
+		return summary, err
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	for _, flag := range flags {
+		if !flag.Enabled || flag.Archived || len(flag.Variants) < 2 {
+			continue
+		}
+		exp := report.Experiment{Key: flag.Key}
+		for _, v := range flag.Variants {
+			exp.Variants = append(exp.Variants, fmt.Sprintf("%s (%d%%)", v.Name, v.Weight))
+		}
+		summary.Experiments = append(summary.Experiments, exp)
+	}
+
+	cleanup, err := s.buildCleanupReport(ctx, to)
+	if err != nil {
+		return summary, err
+	}
+	for _, c := range cleanup.Flags {
+		summary.StaleFlags = append(summary.StaleFlags, report.StaleFlag{Key: c.Key, Reasons: c.Reasons})
+	}
+
+	pgStore, ok := s.store.(PostgresStoreInterface)
+	if !ok {
+		// No audit log: changes can only be read from flag timestamps.
+		for _, flag := range flags {
+			if !flag.UpdatedAt.Before(from) && flag.UpdatedAt.Before(to) {
+				summary.ChangedFlags = append(summary.ChangedFlags, report.FlagChange{Key: flag.Key, Changes: 1})
+			}
+		}
+		return summary, nil
+	}
+	summary.AuditAvailable = true
+
+	logs, err := pgStore.ListAuditLogs(ctx, dbgen.ListAuditLogsParams{
+		Limit:     maxSummaryAuditLogs,
+		StartDate: pgtype.Timestamptz{Time: from, Valid: true},
+		EndDate:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return summary, err
+	}
+	if len(logs) == maxSummaryAuditLogs {
+		log.Printf("[report] more than %d audit entries in the period; the summary covers the newest", maxSummaryAuditLogs)
+	}
+	addAuditToSummary(&summary, logs)
+
+	if queries := getQueriesFromStore(pgStore); queries != nil {
+		failed, err := queries.ListFailedWebhookDeliveriesSince(ctx, dbgen.ListFailedWebhookDeliveriesSinceParams{
+			Timestamp: pgtype.Timestamptz{Time: from, Valid: true},
+			Limit:     maxSummaryAuditLogs,
+		})
+		if err != nil {
+			return summary, err
+		}
+		if n := len(failed); n > 0 {
+			summary.Anomalies = append(summary.Anomalies, report.Anomaly{Kind: "webhook_failures", Count: n, Description: "Failed webhook delivery attempts"})
+		}
+	}
+	return summary, nil
+}
+
+// addAuditToSummary adds the flag changes and anomalies recorded in logs.
+func addAuditToSummary(summary *report.Summary, logs []dbgen.AuditLog) {
+	changes := map[string]*report.FlagChange{}
+	counts := map[string]int{}
+	failures := 0
+	for _, entry := range logs {
+		item := auditActivityItem(entry)
+		counts[entry.Action]++
+		if entry.Status >= 400 {
+			if !isSummaryAnomaly(entry.Action) {
+				failures++
+			}
+			continue
+		}
+		if item.ResourceType != audit.ResourceTypeFlag || item.Environment != summary.Env {
+			continue
+		}
+		switch entry.Action {
+		case audit.ActionCreated:
+			if !slices.Contains(summary.NewFlags, item.ResourceID) {
+				summary.NewFlags = append(summary.NewFlags, item.ResourceID)
+			}
+		case audit.ActionDeleted:
+			if !slices.Contains(summary.DeletedFlags, item.ResourceID) {
+				summary.DeletedFlags = append(summary.DeletedFlags, item.ResourceID)
+			}
+		case audit.ActionUpdated:
+			c := changes[item.ResourceID]
+			if c == nil {
+				c = &report.FlagChange{Key: item.ResourceID}
+				changes[item.ResourceID] = c
+			}
+			c.Changes++
+			if !slices.Contains(c.Actors, item.Actor) {
+				c.Actors = append(c.Actors, item.Actor)
+			}
+		}
+	}
+
+	sort.Strings(summary.NewFlags)
+	sort.Strings(summary.DeletedFlags)
+	for _, c := range changes {
+		sort.Strings(c.Actors)
+		summary.ChangedFlags = append(summary.ChangedFlags, *c)
+	}
+	sort.Slice(summary.ChangedFlags, func(i, j int) bool { return summary.ChangedFlags[i].Key < summary.ChangedFlags[j].Key })
+
+	for _, a := range summaryAnomalies {
+		if n := counts[a.action]; n > 0 {
+			summary.Anomalies = append(summary.Anomalies, report.Anomaly{Kind: a.action, Count: n, Description: a.description})
+		}
+	}
+	if failures > 0 {
+		summary.Anomalies = append(summary.Anomalies, report.Anomaly{Kind: "failed_operations", Count: failures, Description: "Failed operations"})
+	}
+}
+
+// isSummaryAnomaly reports whether action is counted as its own anomaly,
+// which keeps failed authentications out of the failed operations count.
+func isSummaryAnomaly(action string) bool {
+	for _, a := range summaryAnomalies {
+		if a.action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// summaryReportData converts the report into the generic webhook payload.
+func summaryReportData(resp summaryReportResponse) map[string]any {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return nil
+	}
+	var data map[string]any
+	_ = json.Unmarshal(b, &data)
+	return data
+}
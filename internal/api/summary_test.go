@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/report"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestBuildSummary_MemoryStore(t *testing.T) {
+	srv, st := newCleanupTestServer(t)
+	ctx := context.Background()
+	if err := st.UpsertFlag(ctx, store.UpsertParams{
+		Key: "pricing", Enabled: true, Rollout: 100, Env: "prod",
+		Variants: []store.Variant{{Name: "control", Weight: 50}, {Name: "test", Weight: 50}},
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	now := time.Now().UTC()
+	summary, err := srv.buildSummary(ctx, now.Add(-time.Hour), now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("buildSummary: %v", err)
+	}
+	if summary.AuditAvailable {
+		t.Error("memory store has no audit log")
+	}
+	if len(summary.ChangedFlags) != 5 {
+		t.Errorf("all seeded flags changed in the window, got %+v", summary.ChangedFlags)
+	}
+	if len(summary.Experiments) != 1 || !slices.Equal(summary.Experiments[0].Variants, []string{"control (50%)", "test (50%)"}) {
+		t.Errorf("unexpected experiments %+v", summary.Experiments)
+	}
+
+	later := now.Add(31 * 24 * time.Hour)
+	srv.evalTracker.now = func() time.Time { return later.Add(-time.Hour) }
+	srv.evalTracker.touch("prod", "active")
+	summary, err = srv.buildSummary(ctx, now.Add(24*time.Hour), later)
+	if err != nil {
+		t.Fatalf("buildSummary: %v", err)
+	}
+	if len(summary.ChangedFlags) != 0 {
+		t.Errorf("no flags changed in a later window, got %+v", summary.ChangedFlags)
+	}
+	var stale []string
+	for _, f := range summary.StaleFlags {
+		stale = append(stale, f.Key)
+	}
+	if !slices.Contains(stale, "old_off") || slices.Contains(stale, "active") {
+		t.Errorf("stale flags should come from the cleanup report, got %v", stale)
+	}
+}
+
+func TestAddAuditToSummary(t *testing.T) {
+	entry := func(action, key string, status int32, actor string) dbgen.AuditLog {
+		details, _ := json.Marshal(map[string]any{"actor": audit.Actor{Display: actor}})
+		return dbgen.AuditLog{
+			Action:       action,
+			Status:       status,
+			Details:      details,
+			ResourceType: pgtype.Text{String: audit.ResourceTypeFlag, Valid: key != ""},
+			ResourceID:   pgtype.Text{String: key, Valid: key != ""},
+			Environment:  pgtype.Text{String: "prod", Valid: key != ""},
+		}
+	}
+	logs := []dbgen.AuditLog{
+		entry(audit.ActionCreated, "new_flag", 200, "alice"),
+		entry(audit.ActionUpdated, "banner", 200, "bob"),
+		entry(audit.ActionUpdated, "banner", 200, "alice"),
+		entry(audit.ActionUpdated, "banner", 200, "bob"),
+		entry(audit.ActionUpdated, "broken", 500, "bob"),
+		entry(audit.ActionDeleted, "gone", 200, "alice"),
+		entry(audit.ActionAuthFailed, "", 500, ""),
+		entry(audit.ActionAuthFailed, "", 500, ""),
+	}
+	summary := report.Summary{Env: "prod"}
+	addAuditToSummary(&summary, logs)
+
+	if !slices.Equal(summary.NewFlags, []string{"new_flag"}) || !slices.Equal(summary.DeletedFlags, []string{"gone"}) {
+		t.Errorf("new=%v deleted=%v", summary.NewFlags, summary.DeletedFlags)
+	}
+	if len(summary.ChangedFlags) != 1 || summary.ChangedFlags[0].Changes != 3 ||
+		!slices.Equal(summary.ChangedFlags[0].Actors, []string{"alice", "bob"}) {
+		t.Errorf("unexpected changes %+v", summary.ChangedFlags)
+	}
+	want := []report.Anomaly{
+		{Kind: audit.ActionAuthFailed, Count: 2, Description: "Failed authentications"},
+		{Kind: "failed_operations", Count: 1, Description: "Failed operations"},
+	}
+	if !slices.Equal(summary.Anomalies, want) {
+		t.Errorf("anomalies = %+v, want %+v", summary.Anomalies, want)
+	}
+}
+
+func TestSummaryReportEndpoint_Text(t *testing.T) {
+	srv, _ := newCleanupTestServer(t)
+	handler := srv.Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/admin/reports/summary?format=text", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "environment prod") || !strings.Contains(body, "No audit log") {
+		t.Errorf("unexpected report:\n%s", body)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/admin/reports/summary?format=pdf", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", rr.Code)
+	}
+}
+
+func TestRunSummaryReport_CustomTemplate(t *testing.T) {
+	srv, _ := newCleanupTestServer(t)
+	tmpl, err := report.ParseTemplate("{{.Env}}: {{len .ChangedFlags}} changed")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	srv.SetSummaryReport(SummaryReportConfig{Template: tmpl})
+
+	now := time.Now().UTC()
+	text, err := srv.RunSummaryReport(context.Background(), now.Add(-time.Hour), now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("RunSummaryReport: %v", err)
+	}
+	if text != "prod: 4 changed" {
+		t.Errorf("text = %q", text)
+	}
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Tenancy lets one installation serve several teams. Every API key belongs
+// to a tenant (auth.DefaultTenant unless created with "tenant"), and the
+// caller's tenant scopes what the key can see:
+//
+//   - environments: a tenant only reaches "<tenant>" and "<tenant>-*"
+//     environments (auth.TenantOwnsEnv), which isolates its flags, SDK keys
+//     and comments in every project
+//   - API keys, webhooks and audit logs are stored with their tenant and
+//     listed, read and changed only by that tenant
+//   - requests are counted per tenant (tenant_requests_total) and optionally
+//     rate limited per tenant (SetTenantRateLimit)
+//
+// The default tenant is the operator: it is not restricted, sees every
+// tenant's keys, webhooks and audit logs, and is the only tenant allowed on
+// installation-wide endpoints (GraphQL, git sync, the activity feed and the
+// cleanup report). Client endpoints (snapshot, evaluate, stream) stay
+// selected by SDK key or env, as before.
+
+// SetTenantRateLimit limits each tenant to n authenticated requests per
+// minute across all of its API keys. n <= 0 disables the limit (the default).
+func (s *Server) SetTenantRateLimit(n int) {
+	if n <= 0 {
+		s.tenantLimiter = nil
+		return
+	}
+	s.tenantLimiter = httprate.NewRateLimiter(n, time.Minute,
+		httprate.WithKeyFuncs(func(r *http.Request) (string, error) {
+			return auth.GetTenantFromContext(r.Context()), nil
+		}),
+		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+			tenant := auth.GetTenantFromContext(r.Context())
+			telemetry.RateLimitHits.WithLabelValues("tenant").Inc()
+			telemetry.TenantRateLimitHits.WithLabelValues(tenant).Inc()
+			errResp := NewErrorResponse(http.StatusTooManyRequests, ErrCodeRateLimited, "Rate limit exceeded for tenant "+tenant)
+			writeErrorResponse(w, r, http.StatusTooManyRequests, errResp)
+		}),
+	)
+}
+
+// requireAuth authenticates the request for role (see auth.RequireAuth), then
+// counts it for the caller's tenant and applies the tenant rate limit.
+// Authenticated requests can record a step-up check (see stepup.go) and
+// must send JSON bodies (see security.go).
+func (s *Server) requireAuth(role auth.Role) func(http.Handler) http.Handler {
+	authenticate := s.auth.RequireAuth(role)
+	return func(next http.Handler) http.Handler {
+		next = requireJSONContentType(next)
+		return authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			telemetry.TenantRequests.WithLabelValues(auth.GetTenantFromContext(r.Context())).Inc()
+			if l := s.tenantLimiter; l != nil {
+				if respondOnLimit(l, w, r, auth.GetTenantFromContext(r.Context())) {
+					return
+				}
+			}
+			next.ServeHTTP(w, withStepUpState(r))
+		}))
+	}
+}
+
+// requireOperator restricts installation-wide endpoints to the default tenant.
+func requireOperator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isOperator(r) {
+			ForbiddenError(w, r, "This endpoint is only available to the default tenant")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isOperator reports whether the caller belongs to the default tenant.
+func isOperator(r *http.Request) bool {
+	return auth.GetTenantFromContext(r.Context()) == auth.DefaultTenant
+}
+
+// tenantOwnsEnv reports whether the caller's tenant may access env.
+func tenantOwnsEnv(r *http.Request, env string) bool {
+	return auth.TenantOwnsEnv(auth.GetTenantFromContext(r.Context()), env)
+}
+
+// requireTenantEnv writes a 403 response and returns false if the caller's
+// tenant may not access env.
+func requireTenantEnv(w http.ResponseWriter, r *http.Request, env string) bool {
+	if werr := tenantEnvWriteError(r, env); werr != nil {
+		werr.write(w, r)
+		return false
+	}
+	return true
+}
+
+// requireTenantEnvs is requireTenantEnv for each of envs.
+func requireTenantEnvs(w http.ResponseWriter, r *http.Request, envs []string) bool {
+	for _, env := range envs {
+		if !requireTenantEnv(w, r, env) {
+			return false
+		}
+	}
+	return true
+}
+
+// requireTenantEnvParam rejects requests whose {env} route parameter belongs
+// to another tenant (the v2 routes).
+func requireTenantEnvParam(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !requireTenantEnv(w, r, chi.URLParam(r, "env")) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tenantEnvWriteError is requireTenantEnv for the shared write paths.
+func tenantEnvWriteError(r *http.Request, env string) *flagWriteError {
+	if tenantOwnsEnv(r, env) {
+		return nil
+	}
+	return &flagWriteError{
+		status:  http.StatusForbidden,
+		code:    ErrCodeForbidden,
+		message: "Environment belongs to another tenant",
+		fields:  map[string]string{"env": "Tenant " + auth.GetTenantFromContext(r.Context()) + " cannot access environment " + env},
+	}
+}
+
+// tenantFilter is the tenant to filter audit logs by: the caller's tenant,
+// or none (all tenants) for the operator.
+func tenantFilter(r *http.Request) pgtype.Text {
+	if isOperator(r) {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: auth.GetTenantFromContext(r.Context()), Valid: true}
+}
+
+// tenantOwnsRow reports whether a row stored with tenant is visible to the
+// caller. The operator sees every tenant's rows.
+func tenantOwnsRow(r *http.Request, tenant string) bool {
+	return isOperator(r) || tenant == auth.GetTenantFromContext(r.Context())
+}
+
+// requireTenantWebhook writes a 404 response and returns false if the
+// webhook id belongs to another tenant or, for project-scoped API keys,
+// another project. The unscoped operator is not checked, so operator deletes
+// stay idempotent for unknown IDs.
+func requireTenantWebhook(w http.ResponseWriter, r *http.Request, queries *dbgen.Queries, id pgtype.UUID) bool {
+	if isOperator(r) && auth.GetProjectFromContext(r.Context()) == "" {
+		return true
+	}
+	wh, err := queries.GetWebhook(r.Context(), id)
+	if err != nil || !tenantOwnsRow(r, wh.Tenant) || !webhookInScope(r, wh) {
+		NotFoundError(w, r, "Webhook not found")
+		return false
+	}
+	return true
+}
+
+// testEventEnvironment is the environment of a webhook's test event. Tenant
+// webhooks only receive events from their own environments, so theirs use
+// the tenant's base environment.
+func testEventEnvironment(wh dbgen.Webhook) string {
+	if wh.Tenant != "" && wh.Tenant != auth.DefaultTenant {
+		return wh.Tenant
+	}
+	return "test"
+}
+
+// visibleWebhooks filters webhooks down to those the caller's tenant owns
+// and, for project-scoped API keys, to the key's project.
+func visibleWebhooks(r *http.Request, webhooks []dbgen.Webhook) []dbgen.Webhook {
+	if isOperator(r) && auth.GetProjectFromContext(r.Context()) == "" {
+		return webhooks
+	}
+	visible := make([]dbgen.Webhook, 0, len(webhooks))
+	for _, wh := range webhooks {
+		if tenantOwnsRow(r, wh.Tenant) && webhookInScope(r, wh) {
+			visible = append(visible, wh)
+		}
+	}
+	return visible
+}
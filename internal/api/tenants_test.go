@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// tenantRequest builds an admin request authenticated as tenant.
+func tenantRequest(tenant, method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(r.Context(), auth.ContextKeyRole, auth.RoleAdmin)
+	return r.WithContext(context.WithValue(ctx, auth.ContextKeyTenant, tenant))
+}
+
+func newTenantTestServer(t *testing.T) *Server {
+	t.Helper()
+	st := store.NewMemoryStore()
+	for _, env := range []string{"prod", "acme-prod"} {
+		if err := st.UpsertFlag(context.Background(), store.UpsertParams{Key: "checkout", Enabled: true, Rollout: 100, Env: env}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return NewServer(st, "prod", "test-key")
+}
+
+func TestTenants_EnvironmentIsolation(t *testing.T) {
+	srv := newTenantTestServer(t)
+
+	rr := httptest.NewRecorder()
+	srv.handleListFlags(rr, tenantRequest("acme", http.MethodGet, "/v1/flags?env=acme-prod", ""))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("own env: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Another tenant's environment, and the default environment when env is omitted.
+	for _, target := range []string{"/v1/flags?env=prod", "/v1/flags"} {
+		rr = httptest.NewRecorder()
+		srv.handleListFlags(rr, tenantRequest("acme", http.MethodGet, target, ""))
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d: %s", target, rr.Code, rr.Body.String())
+		}
+	}
+
+	// The operator is not restricted.
+	rr = httptest.NewRecorder()
+	srv.handleListFlags(rr, tenantRequest(auth.DefaultTenant, http.MethodGet, "/v1/flags?env=acme-prod", ""))
+	if rr.Code != http.StatusOK {
+		t.Errorf("operator: expected 200, got %d", rr.Code)
+	}
+}
+
+func TestTenants_WritePathsCheckEnvironment(t *testing.T) {
+	srv := newTenantTestServer(t)
+	env := "prod"
+
+	r := tenantRequest("acme", http.MethodPost, "/v1/flags", "")
+	if _, werr := srv.saveFlag(r, upsertRequest{Key: "new_flag", Enabled: true, Env: &env}); werr == nil || werr.status != http.StatusForbidden {
+		t.Errorf("saveFlag in foreign env: expected 403, got %+v", werr)
+	}
+	if werr := srv.removeFlag(r, "checkout", "prod"); werr == nil || werr.status != http.StatusForbidden {
+		t.Errorf("removeFlag in foreign env: expected 403, got %+v", werr)
+	}
+	if _, err := srv.store.GetFlagByKey(context.Background(), "checkout", "prod"); err != nil {
+		t.Errorf("flag in foreign env was deleted: %v", err)
+	}
+
+	own := "acme-prod"
+	if _, werr := srv.saveFlag(r, upsertRequest{Key: "new_flag", Enabled: true, Env: &own}); werr != nil {
+		t.Errorf("saveFlag in own env: unexpected error %+v", werr)
+	}
+}
+
+func TestTenants_CloneChecksBothEnvironments(t *testing.T) {
+	srv := newTenantTestServer(t)
+
+	req := tenantRequest("acme", http.MethodPost, "/v1/environments/acme-prod/clone", `{"target":"prod"}`)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("env", "acme-prod")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	srv.handleCloneEnvironment(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("clone into foreign env: expected 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestTenants_OperatorOnlyEndpoints(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	rr := httptest.NewRecorder()
+	requireOperator(next).ServeHTTP(rr, tenantRequest("acme", http.MethodPost, "/graphql", ""))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("tenant: expected 403, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	requireOperator(next).ServeHTTP(rr, tenantRequest(auth.DefaultTenant, http.MethodPost, "/graphql", ""))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("operator: expected 204, got %d", rr.Code)
+	}
+}
+
+func TestTenants_RateLimit(t *testing.T) {
+	srv := newTenantTestServer(t)
+	srv.SetTenantRateLimit(2)
+	handler := srv.Router()
+
+	for i := 0; i < 2; i++ {
+		if rr := doV2Request(t, handler, http.MethodGet, "/v1/flags", ""); rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rr.Code)
+		}
+	}
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/flags", "")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the limit, got %d", rr.Code)
+	}
+	if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Code != ErrCodeRateLimited {
+		t.Errorf("code = %s, want RATE_LIMITED", resp.Code)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Timeouts bounds how long a request may take, per route group. A request
+// that runs out of time has its context canceled and, if nothing was written
+// yet, gets 504 Gateway Timeout. 0 disables a timeout. The flag stream is
+// never timed out.
+type Timeouts struct {
+	Default  time.Duration // admin and client routes not listed below
+	Evaluate time.Duration // evaluation, OFREP, exposures and metric events
+	Bulk     time.Duration // bulk actions, apply, transactions, change set apply, environment clones, git sync and audit log export
+}
+
+// DefaultTimeouts are the timeouts of a new server.
+var DefaultTimeouts = Timeouts{
+	Default:  5 * time.Second,
+	Evaluate: 2 * time.Second,
+	Bulk:     60 * time.Second,
+}
+
+// SetTimeouts replaces the request timeouts (see Timeouts).
+func (s *Server) SetTimeouts(t Timeouts) {
+	s.timeouts = t
+}
+
+// Timeout selectors for withTimeout.
+var (
+	defaultTimeout  = func(t Timeouts) time.Duration { return t.Default }
+	evaluateTimeout = func(t Timeouts) time.Duration { return t.Evaluate }
+	bulkTimeout     = func(t Timeouts) time.Duration { return t.Bulk }
+)
+
+type requestTimerKey struct{}
+
+// requestTimer cancels a request's context when its timeout expires. Route
+// groups nested in another group replace the outer timeout, so a group can
+// also allow more time than the routes around it.
+type requestTimer struct {
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// withTimeout applies the timeout selected by pick from the server's
+// Timeouts, read per request.
+func (s *Server) withTimeout(pick func(Timeouts) time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := pick(s.timeouts)
+			if t, ok := r.Context().Value(requestTimerKey{}).(*requestTimer); ok {
+				// Nested group: only replace the outer timeout.
+				if t.timer.Stop() && d > 0 {
+					t.timer.Reset(d)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if d <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithCancelCause(r.Context())
+			t := &requestTimer{}
+			t.timer = time.AfterFunc(d, func() {
+				t.expired.Store(true)
+				cancel(context.DeadlineExceeded)
+			})
+			defer func() {
+				t.timer.Stop()
+				cancel(nil)
+				if t.expired.Load() {
+					w.WriteHeader(http.StatusGatewayTimeout)
+				}
+			}()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, requestTimerKey{}, t)))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestWithTimeout(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	srv.SetTimeouts(Timeouts{Default: 20 * time.Millisecond, Evaluate: 0, Bulk: time.Second})
+
+	// slow waits 60ms, or until the request is canceled.
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(60 * time.Millisecond):
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+			if !errors.Is(context.Cause(r.Context()), context.DeadlineExceeded) {
+				t.Errorf("expected a deadline cause, got %v", context.Cause(r.Context()))
+			}
+		}
+	})
+	tests := []struct {
+		name    string
+		handler http.Handler
+		want    int
+	}{
+		{"default", srv.withTimeout(defaultTimeout)(slow), http.StatusGatewayTimeout},
+		{"nested longer", srv.withTimeout(defaultTimeout)(srv.withTimeout(bulkTimeout)(slow)), http.StatusOK},
+		{"nested disabled", srv.withTimeout(defaultTimeout)(srv.withTimeout(evaluateTimeout)(slow)), http.StatusOK},
+		{"disabled", srv.withTimeout(evaluateTimeout)(slow), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rr.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+)
+
+// POST /v1/transactions applies several flag changes in one environment
+// atomically, for coordinated launches where flags must flip together:
+//
+//	{"env": "prod", "operations": [
+//	  {"action": "patch", "key": "new_checkout", "patch": {"enabled": true}},
+//	  {"action": "patch", "key": "old_checkout", "patch": {"enabled": false}},
+//	  {"action": "upsert", "flag": {"key": "checkout_banner", "enabled": true, "rollout": 100}},
+//	  {"action": "delete", "key": "checkout_beta"}
+//	]}
+//
+// Every operation is checked like a single write (validation, tenant scope,
+// protection, limits, attribute registry) and one failure rejects the whole
+// transaction. The changes are then stored in one store transaction followed
+// by a single snapshot swap, so clients see all of them or none. Audit events
+// and webhooks fire per flag after the commit. ?dry_run=true is supported,
+// see dryrun.go.
+
+const (
+	txActionUpsert = "upsert" // create or replace, like POST /v1/flags
+	txActionPatch  = "patch"  // partial update of an existing flag, like v2 PATCH
+	txActionDelete = "delete" // delete an existing flag
+
+	maxTransactionOperations = 100
+)
+
+var txActions = []string{txActionUpsert, txActionPatch, txActionDelete}
+
+type transactionRequest struct {
+	Env        string                 `json:"env,omitempty"` // defaults to s.env
+	Operations []transactionOperation `json:"operations"`
+}
+
+type transactionOperation struct {
+	Action string          `json:"action"`
+	Key    string          `json:"key,omitempty"`   // patch and delete; upsert uses flag.key
+	Flag   *upsertRequest  `json:"flag,omitempty"`  // upsert
+	Patch  *v2PatchRequest `json:"patch,omitempty"` // patch
+}
+
+type transactionResponse struct {
+	Env     string        `json:"env"`
+	Applied bool          `json:"applied"`
+	Changes []applyChange `json:"changes"` // same shape as POST /v1/apply
+	ETag    string        `json:"etag"`
+}
+
+// txChange is a checked operation, ready to be written.
+type txChange struct {
+	key            string
+	before         *store.Flag         // nil on create
+	params         *store.UpsertParams // nil on delete
+	overrideReason string
+}
+
+func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	var req transactionRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	req.Env = strings.TrimSpace(req.Env)
+	if req.Env == "" {
+		req.Env = s.env
+	}
+	resp, werr := s.runTransaction(r, req)
+	if werr != nil {
+		werr.write(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// runTransaction checks req and applies it, returning a transactionResponse,
+// or describes it with a dryRunResponse if r is a dry run.
+func (s *Server) runTransaction(r *http.Request, req transactionRequest) (any, *flagWriteError) {
+	if fields := validateTransaction(req); len(fields) > 0 {
+		return nil, validationWriteError("Validation failed for one or more operations", fields)
+	}
+	if werr := tenantEnvWriteError(r, req.Env); werr != nil {
+		return nil, werr
+	}
+	bs, ok := s.store.(store.BatchFlagStore)
+	if !ok {
+		return nil, internalWriteError("Transactions are not supported by this store")
+	}
+
+	changes, werr := s.prepareTransaction(r, req)
+	if werr != nil {
+		return nil, werr
+	}
+	return s.commitTransaction(r, bs, req.Env, changes)
+}
+
+// commitTransaction stores checked changes in one store transaction and
+// records them, or describes them with a dryRunResponse if r is a dry run.
+func (s *Server) commitTransaction(r *http.Request, bs store.BatchFlagStore, env string, changes []txChange) (any, *flagWriteError) {
+	if isDryRun(r) {
+		befores, afters := make([]*store.Flag, len(changes)), make([]*store.Flag, len(changes))
+		for i, c := range changes {
+			befores[i] = c.before
+			if c.params != nil {
+				afters[i] = flagFromParams(*c.params)
+			}
+		}
+		return s.dryRunFlagChanges(r, env, befores, afters)
+	}
+
+	var batch store.FlagBatch
+	for _, c := range changes {
+		if c.params == nil {
+			batch.Deletes = append(batch.Deletes, store.FlagRef{Key: c.key, Env: env})
+		} else {
+			batch.Upserts = append(batch.Upserts, *c.params)
+		}
+	}
+	if err := bs.ApplyFlagBatch(r.Context(), batch); err != nil {
+		return nil, internalWriteError("Failed to apply transaction")
+	}
+	if err := s.RebuildSnapshot(r.Context(), env); err != nil {
+		return nil, internalWriteError("Failed to rebuild snapshot")
+	}
+	return &transactionResponse{
+		Env:     env,
+		Applied: true,
+		Changes: s.recordTransaction(r, env, changes),
+		ETag:    s.snapshotForEnv(env).ETag,
+	}, nil
+}
+
+// validateTransaction checks the shape of req; the flags themselves are
+// checked by prepareTransaction.
+func validateTransaction(req transactionRequest) map[string]string {
+	fields := map[string]string{}
+	if message, ok := validation.ValidateEnv(req.Env).Errors["env"]; ok {
+		fields["env"] = message
+	}
+	switch n := len(req.Operations); {
+	case n == 0:
+		fields["operations"] = "At least one operation is required"
+	case n > maxTransactionOperations:
+		fields["operations"] = fmt.Sprintf("At most %d operations are allowed", maxTransactionOperations)
+	}
+
+	seen := map[string]bool{}
+	for i, op := range req.Operations {
+		prefix := fmt.Sprintf("operations[%d]", i)
+		switch op.Action {
+		case txActionUpsert:
+			if op.Flag == nil {
+				fields[prefix+".flag"] = "flag is required for upsert"
+				continue
+			}
+			if op.Key != "" && op.Key != op.Flag.Key {
+				fields[prefix+".key"] = "key must match flag.key"
+			}
+			if op.Flag.Env != nil && strings.TrimSpace(*op.Flag.Env) != "" && strings.TrimSpace(*op.Flag.Env) != req.Env {
+				fields[prefix+".flag.env"] = "flag env must match the transaction env"
+			}
+		case txActionPatch:
+			if op.Patch == nil {
+				fields[prefix+".patch"] = "patch is required for patch"
+			}
+		case txActionDelete:
+		default:
+			fields[prefix+".action"] = "Action must be one of: " + strings.Join(txActions, ", ")
+			continue
+		}
+		key := op.key()
+		if key == "" {
+			fields[prefix+".key"] = "key is required"
+			continue
+		}
+		if seen[key] {
+			fields[prefix+".key"] = "each flag may appear in only one operation"
+		}
+		seen[key] = true
+	}
+	return fields
+}
+
+// key returns the flag the operation writes.
+func (op transactionOperation) key() string {
+	if op.Action == txActionUpsert && op.Flag != nil {
+		return strings.TrimSpace(op.Flag.Key)
+	}
+	return strings.TrimSpace(op.Key)
+}
+
+// prepareTransaction runs the checks of a single write for every operation,
+// then checks the flag count limit for the transaction as a whole.
+func (s *Server) prepareTransaction(r *http.Request, req transactionRequest) ([]txChange, *flagWriteError) {
+	changes := make([]txChange, 0, len(req.Operations))
+	creates, deletes := 0, 0
+	for i, op := range req.Operations {
+		c, werr := s.prepareTransactionOperation(r, req.Env, op)
+		if werr != nil {
+			return nil, operationWriteError(werr, i, op.key())
+		}
+		switch {
+		case c.before == nil:
+			creates++
+		case c.params == nil:
+			deletes++
+		}
+		changes = append(changes, c)
+	}
+
+	if werr := s.checkBatchFlagLimit(r, req.Env, "operations", "Transaction", creates, deletes); werr != nil {
+		return nil, werr
+	}
+	return changes, nil
+}
+
+// checkBatchFlagLimit checks the flag count limit for a batch of changes,
+// named by field and what in the error. Each create was checked against the
+// current count on its own, so this only matters for several creates.
+func (s *Server) checkBatchFlagLimit(r *http.Request, env, field, what string, creates, deletes int) *flagWriteError {
+	limit := s.limits.MaxFlags
+	if limit <= 0 || creates <= 1 {
+		return nil
+	}
+	flags, err := s.store.GetAllFlags(r.Context(), env)
+	if err != nil {
+		return internalWriteError("Failed to count flags")
+	}
+	if len(flags)+creates-deletes > limit {
+		return limitWriteError(field, fmt.Sprintf("%s would exceed the maximum number of flags in environment %q", what, env), limit)
+	}
+	return nil
+}
+
+func (s *Server) prepareTransactionOperation(r *http.Request, env string, op transactionOperation) (txChange, *flagWriteError) {
+	key := op.key()
+	var fr upsertRequest
+	switch op.Action {
+	case txActionDelete:
+		before, reason, werr := s.prepareFlagDelete(r, key, env)
+		if werr != nil {
+			return txChange{}, werr
+		}
+		if before == nil {
+			return txChange{}, notFoundWriteError("Flag not found")
+		}
+		return txChange{key: key, before: before, overrideReason: reason}, nil
+	case txActionPatch:
+		existing, ok := s.loadEnvFlag(r, key, env)
+		if !ok {
+			return txChange{}, notFoundWriteError("Flag not found")
+		}
+		fr = upsertRequestFromFlag(existing)
+		op.Patch.applyTo(&fr)
+	default:
+		fr = *op.Flag
+		fr.Key = key
+	}
+	fr.Env = &env
+
+	if field, message, ok := validateTargetingRules(fr.TargetingRules); !ok {
+		return txChange{}, validationWriteError("invalid targeting_rules", map[string]string{field: message})
+	}
+	fw, werr := s.prepareFlagWrite(r, fr)
+	if werr != nil {
+		return txChange{}, werr
+	}
+	return txChange{key: key, before: fw.oldFlag, params: &fw.params, overrideReason: fw.overrideReason}, nil
+}
+
+// operationWriteError names the failing operation in werr.
+func operationWriteError(werr *flagWriteError, i int, key string) *flagWriteError {
+	out := *werr
+	out.message = fmt.Sprintf("Operation %d (%s): %s", i, key, werr.message)
+	if len(werr.fields) > 0 {
+		out.fields = make(map[string]string, len(werr.fields))
+		for field, message := range werr.fields {
+			out.fields[fmt.Sprintf("operations[%d].%s", i, field)] = message
+		}
+	}
+	return &out
+}
+
+// recordTransaction writes the audit events and webhooks of a committed
+// transaction and returns its changes.
+func (s *Server) recordTransaction(r *http.Request, env string, changes []txChange) []applyChange {
+	var keys []string
+	for _, c := range changes {
+		if c.params != nil {
+			keys = append(keys, c.key)
+		}
+	}
+	stored := map[string]*store.Flag{}
+	if flags, err := s.store.GetFlagsByKeys(r.Context(), env, keys); err == nil {
+		for i := range flags {
+			stored[flags[i].Key] = &flags[i]
+		}
+	}
+
+	result := make([]applyChange, 0, len(changes))
+	for _, c := range changes {
+		beforeState := flagToMap(c.before)
+		var afterState map[string]any
+		after := stored[c.key]
+		if after != nil {
+			afterState = flagToMap(after)
+		}
+
+		action, change := audit.ActionUpdated, applyChange{Action: applyActionUpdate, ResourceType: applyResourceFlag, Key: c.key}
+		switch {
+		case c.before == nil:
+			action, change.Action = audit.ActionCreated, applyActionCreate
+		case c.params == nil:
+			action, change.Action = audit.ActionDeleted, applyActionDelete
+		}
+		var planBefore, planAfter map[string]any
+		if c.before != nil {
+			planBefore = dryRunFlagState(c.before)
+		}
+		if after != nil {
+			planAfter = dryRunFlagState(after)
+		}
+		change.Diff = audit.ComputeChanges(planBefore, planAfter)
+		result = append(result, change)
+
+		auditChanges := audit.ComputeChanges(beforeState, afterState)
+		auditID := s.auditLog(r, action, audit.ResourceTypeFlag, c.key, env, beforeState, afterState, auditChanges, audit.StatusSuccess, "")
+		if c.overrideReason != "" {
+			s.auditProtectionOverride(r, c.key, env, c.overrideReason)
+		}
+		s.dispatchWebhookEvent(r, auditID, c.key, env, beforeState, afterState, auditChanges)
+	}
+	return result
+}
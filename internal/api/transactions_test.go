@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func newTransactionTestServer(t *testing.T) (*Server, *store.MemoryStore) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	for _, p := range []store.UpsertParams{
+		{Key: "new_checkout", Enabled: false, Rollout: 100, Env: "prod"},
+		{Key: "old_checkout", Enabled: true, Rollout: 100, Env: "prod"},
+		{Key: "checkout_beta", Enabled: true, Rollout: 10, Env: "prod"},
+	} {
+		if err := st.UpsertFlag(ctx, p); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	srv := NewServer(st, "prod", "test-key")
+	if err := srv.RebuildSnapshot(ctx, "prod"); err != nil {
+		t.Fatalf("RebuildSnapshot: %v", err)
+	}
+	return srv, st
+}
+
+func TestTransaction_AppliesAllOperations(t *testing.T) {
+	srv, st := newTransactionTestServer(t)
+	ctx := context.Background()
+
+	rr := doV2Request(t, srv.Router(), http.MethodPost, "/v1/transactions", `{"env":"prod","operations":[
+		{"action":"patch","key":"new_checkout","patch":{"enabled":true}},
+		{"action":"patch","key":"old_checkout","patch":{"enabled":false}},
+		{"action":"upsert","flag":{"key":"checkout_banner","enabled":true,"rollout":100}},
+		{"action":"delete","key":"checkout_beta"}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp transactionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.Applied || len(resp.Changes) != 4 || resp.ETag != snapshot.Load().ETag {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if f, err := st.GetFlagByKey(ctx, "new_checkout", "prod"); err != nil || !f.Enabled || f.Rollout != 100 {
+		t.Errorf("new_checkout should be enabled with its rollout kept, got %+v, %v", f, err)
+	}
+	if f, err := st.GetFlagByKey(ctx, "old_checkout", "prod"); err != nil || f.Enabled {
+		t.Errorf("old_checkout should be disabled, got %+v, %v", f, err)
+	}
+	if _, err := st.GetFlagByKey(ctx, "checkout_banner", "prod"); err != nil {
+		t.Errorf("checkout_banner should be created: %v", err)
+	}
+	if _, err := st.GetFlagByKey(ctx, "checkout_beta", "prod"); err == nil {
+		t.Error("checkout_beta should be deleted")
+	}
+	snap := snapshot.Load()
+	if !snap.Flags["new_checkout"].Enabled || snap.Flags["old_checkout"].Enabled {
+		t.Error("snapshot should reflect the transaction")
+	}
+}
+
+func TestTransaction_OneFailureWritesNothing(t *testing.T) {
+	srv, st := newTransactionTestServer(t)
+	ctx := context.Background()
+
+	rr := doV2Request(t, srv.Router(), http.MethodPost, "/v1/transactions", `{"env":"prod","operations":[
+		{"action":"patch","key":"new_checkout","patch":{"enabled":true}},
+		{"action":"patch","key":"old_checkout","patch":{"rollout":150}}]}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields["operations[1].rollout"] == "" {
+		t.Errorf("expected the failing operation in fields, got %+v", resp.Fields)
+	}
+	if f, _ := st.GetFlagByKey(ctx, "new_checkout", "prod"); f == nil || f.Enabled {
+		t.Fatalf("a rejected transaction must not write, got %+v", f)
+	}
+
+	rr = doV2Request(t, srv.Router(), http.MethodPost, "/v1/transactions", `{"env":"prod","operations":[
+		{"action":"patch","key":"new_checkout","patch":{"enabled":true}},
+		{"action":"delete","key":"missing"}]}`)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing flag, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestTransaction_Validation(t *testing.T) {
+	srv, _ := newTransactionTestServer(t)
+
+	rr := doV2Request(t, srv.Router(), http.MethodPost, "/v1/transactions", `{"env":"prod","operations":[
+		{"action":"patch","key":"new_checkout","patch":{"enabled":true}},
+		{"action":"delete","key":"new_checkout"},
+		{"action":"rename","key":"old_checkout"},
+		{"action":"upsert"}]}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	fields := decodeErrorResponse(t, rr.Body.Bytes()).Fields
+	for _, field := range []string{"operations[1].key", "operations[2].action", "operations[3].flag"} {
+		if fields[field] == "" {
+			t.Errorf("expected an error for %s, got %+v", field, fields)
+		}
+	}
+}
+
+func TestTransaction_FlagLimitCountsAllCreates(t *testing.T) {
+	srv, _ := newTransactionTestServer(t)
+	srv.SetLimits(Limits{MaxFlags: 4})
+
+	rr := doV2Request(t, srv.Router(), http.MethodPost, "/v1/transactions", `{"env":"prod","operations":[
+		{"action":"upsert","flag":{"key":"a"}},
+		{"action":"upsert","flag":{"key":"b"}}]}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
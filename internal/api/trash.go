@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+	"github.com/go-chi/chi/v5"
+)
+
+// Deleted flags go to a trash instead of disappearing (see
+// store.FlagTrashStore) and can be restored until the retention period has
+// passed:
+//
+//	GET  /v1/trash?env=                  list deleted flags, most recent first (admin+)
+//	POST /v1/trash/{key}/restore?env=    restore a deleted flag (admin+)
+//
+// The flag endpoints offer the same under the flag's own path:
+//
+//	GET  /v1/flags?include_deleted=true  live flags followed by deleted ones, with deleted_at
+//	POST /v1/flags/{key}/restore?env=    same as /v1/trash/{key}/restore
+//
+// env defaults to the server's environment. A restored flag comes back as it
+// was when deleted and counts as a create: it is checked against the flag
+// limit, audited as "restored" and sent to webhooks as flag.created. Creating
+// a flag with the same key does not empty the trash, but the trashed flag
+// can't be restored while the new one exists. Flags are purged permanently
+// once the retention (SetTrashRetention) has passed.
+
+const (
+	// DefaultTrashRetention is how long deleted flags can be restored.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+
+	// trashPurgeInterval is how often RunTrashPurge purges expired flags.
+	trashPurgeInterval = time.Hour
+)
+
+type trashedFlagResponse struct {
+	store.TrashedFlag
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // unset when deleted flags are kept forever
+}
+
+type listTrashResponse struct {
+	Env   string                `json:"env"`
+	Flags []trashedFlagResponse `json:"flags"`
+}
+
+type restoreFlagResponse struct {
+	Flag store.Flag `json:"flag"`
+	ETag string     `json:"etag"`
+}
+
+// SetTrashRetention sets how long deleted flags can be restored before they
+// are purged; 0 keeps them forever.
+func (s *Server) SetTrashRetention(retention time.Duration) {
+	s.trashRetention = retention
+}
+
+// requireTrashStore returns the store as a FlagTrashStore, writing an error
+// response and returning nil if it does not keep deleted flags.
+func (s *Server) requireTrashStore(w http.ResponseWriter, r *http.Request) store.FlagTrashStore {
+	if ts, ok := s.store.(store.FlagTrashStore); ok {
+		return ts
+	}
+	InternalError(w, r, "Trash is not supported by this store")
+	return nil
+}
+
+// trashEnv returns the ?env of a trash request, writing an error response and
+// returning false if it is invalid or belongs to another tenant.
+func (s *Server) trashEnv(w http.ResponseWriter, r *http.Request) (string, bool) {
+	env := strings.TrimSpace(r.URL.Query().Get("env"))
+	if env == "" {
+		env = s.env
+	}
+	if message, ok := validation.ValidateEnv(env).Errors["env"]; ok {
+		ValidationError(w, r, "Invalid query parameters", map[string]string{"env": message})
+		return "", false
+	}
+	return env, requireTenantEnv(w, r, env)
+}
+
+// trashExpiry returns when a flag deleted at deletedAt is purged, or nil if
+// deleted flags are kept forever.
+func (s *Server) trashExpiry(deletedAt time.Time) *time.Time {
+	if s.trashRetention <= 0 {
+		return nil
+	}
+	expiresAt := deletedAt.Add(s.trashRetention)
+	return &expiresAt
+}
+
+func (s *Server) handleListTrash(w http.ResponseWriter, r *http.Request) {
+	ts := s.requireTrashStore(w, r)
+	if ts == nil {
+		return
+	}
+	env, ok := s.trashEnv(w, r)
+	if !ok {
+		return
+	}
+	flags, err := ts.ListTrashedFlags(r.Context(), env)
+	if err != nil {
+		InternalError(w, r, "Failed to list deleted flags")
+		return
+	}
+
+	now := time.Now()
+	resp := listTrashResponse{Env: env, Flags: make([]trashedFlagResponse, 0, len(flags))}
+	for _, flag := range flags {
+		expiresAt := s.trashExpiry(flag.DeletedAt)
+		if expiresAt != nil && !expiresAt.After(now) {
+			continue // waiting to be purged
+		}
+		resp.Flags = append(resp.Flags, trashedFlagResponse{TrashedFlag: flag, ExpiresAt: expiresAt})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleRestoreFlag(w http.ResponseWriter, r *http.Request) {
+	ts := s.requireTrashStore(w, r)
+	if ts == nil {
+		return
+	}
+	env, ok := s.trashEnv(w, r)
+	if !ok {
+		return
+	}
+	key := chi.URLParam(r, "key")
+	if key == "" {
+		key = chi.URLParam(r, "id") // POST /v1/flags/{id}/restore
+	}
+
+	trashed, err := ts.GetTrashedFlag(r.Context(), key, env)
+	if err != nil {
+		if errors.Is(err, store.ErrTrashedFlagNotFound) {
+			NotFoundError(w, r, "Flag not found in trash")
+			return
+		}
+		InternalError(w, r, "Failed to load deleted flag")
+		return
+	}
+	if expiresAt := s.trashExpiry(trashed.DeletedAt); expiresAt != nil && !expiresAt.After(time.Now()) {
+		NotFoundError(w, r, "Flag not found in trash")
+		return
+	}
+	if limit := s.limits.MaxFlags; limit > 0 {
+		flags, err := s.store.GetAllFlags(r.Context(), env)
+		if err != nil {
+			InternalError(w, r, "Failed to count flags")
+			return
+		}
+		if len(flags) >= limit {
+			limitWriteError("key", fmt.Sprintf("Environment %q already has the maximum number of flags", env), limit).write(w, r)
+			return
+		}
+	}
+
+	flag, err := ts.RestoreFlag(r.Context(), key, env)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrTrashedFlagNotFound):
+			NotFoundError(w, r, "Flag not found in trash")
+		case errors.Is(err, store.ErrFlagExists):
+			ConflictError(w, r, "A flag with this key exists; delete it before restoring")
+		default:
+			s.auditLog(r, audit.ActionRestored, audit.ResourceTypeFlag, key, env, nil, nil, nil, audit.StatusFailure, "Failed to restore flag")
+			InternalError(w, r, "Failed to restore flag")
+		}
+		return
+	}
+	if err := s.RebuildSnapshot(r.Context(), env); err != nil {
+		InternalError(w, r, "Failed to rebuild snapshot")
+		return
+	}
+
+	afterState := flagToMap(flag)
+	changes := audit.ComputeChanges(nil, afterState)
+	auditID := s.auditLog(r, audit.ActionRestored, audit.ResourceTypeFlag, key, env, nil, afterState, changes, audit.StatusSuccess, "")
+	s.dispatchWebhookEvent(r, auditID, key, env, nil, afterState, changes)
+	writeJSON(w, http.StatusOK, restoreFlagResponse{Flag: *flag, ETag: s.snapshotForEnv(env).ETag})
+}
+
+// listDeletedFlags returns the restorable flags of env as flag responses
+// with DeletedAt set, for GET /v1/flags?include_deleted=true.
+func (s *Server) listDeletedFlags(ctx context.Context, env string) ([]flagResponse, error) {
+	ts, ok := s.store.(store.FlagTrashStore)
+	if !ok {
+		return nil, nil // the store deletes flags for good
+	}
+	flags, err := ts.ListTrashedFlags(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]flagResponse, 0, len(flags))
+	for _, flag := range flags {
+		if expiresAt := s.trashExpiry(flag.DeletedAt); expiresAt != nil && !expiresAt.After(now) {
+			continue // waiting to be purged
+		}
+		resp := toFlagResponse(&flag.Flag)
+		deletedAt := flag.DeletedAt
+		resp.DeletedAt = &deletedAt
+		result = append(result, resp)
+	}
+	return result, nil
+}
+
+// RunTrashPurge permanently removes deleted flags past the retention period
+// every hour until ctx is cancelled. It returns immediately if the store
+// has no trash or deleted flags are kept forever.
+func (s *Server) RunTrashPurge(ctx context.Context) {
+	if _, ok := s.store.(store.FlagTrashStore); !ok || s.trashRetention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.PurgeTrash(ctx, time.Now()); err != nil {
+				log.Printf("[trash] purge failed: %v", err)
+			}
+			worker.Processed(ctx)
+		}
+	}
+}
+
+// PurgeTrash permanently removes the flags whose retention ended before now
+// and returns how many were removed.
+func (s *Server) PurgeTrash(ctx context.Context, now time.Time) (int64, error) {
+	ts, ok := s.store.(store.FlagTrashStore)
+	if !ok || s.trashRetention <= 0 {
+		return 0, nil
+	}
+	purged, err := ts.PurgeTrash(ctx, now.Add(-s.trashRetention))
+	if err != nil {
+		return 0, err
+	}
+	if purged > 0 {
+		log.Printf("[trash] purged %d deleted flags", purged)
+	}
+	return purged, nil
+}
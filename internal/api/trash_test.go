@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+)
+
+func TestTrash_DeleteListRestore(t *testing.T) {
+	srv, st := newTransactionTestServer(t)
+	handler := srv.Router()
+	ctx := context.Background()
+
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v1/flags?key=checkout_beta&env=prod", ""); rr.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v1/trash?env=prod", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var list listTrashResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Flags) != 1 || list.Flags[0].Key != "checkout_beta" || list.Flags[0].ExpiresAt == nil {
+		t.Fatalf("unexpected trash: %+v", list.Flags)
+	}
+	if want := list.Flags[0].DeletedAt.Add(DefaultTrashRetention); !list.Flags[0].ExpiresAt.Equal(want) {
+		t.Errorf("expiresAt = %v, want %v", list.Flags[0].ExpiresAt, want)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPost, "/v1/trash/checkout_beta/restore?env=prod", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	flag, err := st.GetFlagByKey(ctx, "checkout_beta", "prod")
+	if err != nil || !flag.Enabled || flag.Rollout != 10 {
+		t.Fatalf("expected the flag restored as deleted, got %+v, %v", flag, err)
+	}
+	if _, ok := snapshot.Load().Flags["checkout_beta"]; !ok {
+		t.Error("expected the restored flag in the snapshot")
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/trash/checkout_beta/restore?env=prod", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("restore again: expected 404, got %d", rr.Code)
+	}
+}
+
+func TestTrash_RestoreConflictsAndExpiry(t *testing.T) {
+	srv, st := newTransactionTestServer(t)
+	handler := srv.Router()
+	ctx := context.Background()
+
+	_ = st.DeleteFlag(ctx, "old_checkout", "prod")
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"old_checkout","env":"prod"}`); rr.Code != http.StatusOK {
+		t.Fatalf("recreate: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/trash/old_checkout/restore?env=prod", ""); rr.Code != http.StatusConflict {
+		t.Errorf("restore over a new flag: expected 409, got %d", rr.Code)
+	}
+
+	_ = st.DeleteFlag(ctx, "new_checkout", "prod")
+	srv.SetTrashRetention(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/trash/new_checkout/restore?env=prod", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("restore after retention: expected 404, got %d", rr.Code)
+	}
+	if purged, err := srv.PurgeTrash(ctx, time.Now()); err != nil || purged != 2 {
+		t.Errorf("PurgeTrash: got %d, %v; want 2", purged, err)
+	}
+}
+
+func TestTrash_FlagEndpoints(t *testing.T) {
+	srv, st := newTransactionTestServer(t)
+	handler := srv.Router()
+
+	if rr := doV2Request(t, handler, http.MethodDelete, "/v1/flags?key=checkout_beta&env=prod", ""); rr.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	listed := func(query string) map[string]flagResponse {
+		t.Helper()
+		rr := doV2Request(t, handler, http.MethodGet, "/v1/flags?env=prod"+query, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("list: expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp listFlagsResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		flags := map[string]flagResponse{}
+		for _, flag := range resp.Flags {
+			flags[flag.Key] = flag
+		}
+		return flags
+	}
+	if _, ok := listed("")["checkout_beta"]; ok {
+		t.Error("expected the deleted flag to be left out by default")
+	}
+	if flag, ok := listed("&include_deleted=true")["checkout_beta"]; !ok || flag.DeletedAt == nil {
+		t.Errorf("expected the deleted flag with deleted_at, got %+v", flag)
+	}
+	if rr := doV2Request(t, handler, http.MethodGet, "/v1/flags?include_deleted=maybe", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid include_deleted: expected 400, got %d", rr.Code)
+	}
+
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags/checkout_beta/restore?env=prod", ""); rr.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := st.GetFlagByKey(context.Background(), "checkout_beta", "prod"); err != nil {
+		t.Errorf("expected the flag to be restored: %v", err)
+	}
+	if flag := listed("&include_deleted=true")["checkout_beta"]; flag.DeletedAt != nil {
+		t.Errorf("expected the restored flag to be listed as live, got %+v", flag)
+	}
+}
@@ -0,0 +1,386 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// API v2 exposes flags as resources nested under a project and environment:
+//
+//	/v2/projects/{project}/environments/{env}/flags
+//	/v2/projects/{project}/environments/{env}/flags/{key}
+//
+// Only flags of {project} are listed and reachable; a key used by a flag of
+// another project in the same environment conflicts on PUT.
+//
+// Every successful response uses the same envelope ({"data": ..., "meta": ...}),
+// list endpoints use opaque cursor pagination, and partial updates use PATCH.
+// Writes go through the same saveFlag/removeFlag paths as /v1, so validation,
+// audit logging, snapshot rebuilds and webhooks behave identically.
+
+const (
+	// v2DefaultPageSize is the page size used when ?limit is not provided.
+	v2DefaultPageSize = 50
+	// v2MaxPageSize caps ?limit on list endpoints.
+	v2MaxPageSize = 200
+)
+
+// v2Envelope is the uniform response body for all v2 endpoints.
+type v2Envelope struct {
+	Data any     `json:"data"`
+	Meta *v2Meta `json:"meta,omitempty"`
+}
+
+// v2Meta carries response metadata such as the snapshot ETag and pagination cursor.
+type v2Meta struct {
+	ETag       string `json:"etag,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+// v2PatchRequest is a partial flag update. Nil fields are left unchanged.
+type v2PatchRequest struct {
+	Description    *string            `json:"description,omitempty"`
+	Enabled        *bool              `json:"enabled,omitempty"`
+	Rollout        *int32             `json:"rollout,omitempty"`
+	Expression     *string            `json:"expression,omitempty"`
+	Config         *map[string]any    `json:"config,omitempty"`
+	TargetingRules *[]rules.Rule      `json:"targeting_rules,omitempty"`
+	Variants       *[]variantRequest  `json:"variants,omitempty"`
+	Protected      *bool              `json:"protected,omitempty"`
+	Tags           *[]string          `json:"tags,omitempty"`
+	Owner          *string            `json:"owner,omitempty"`
+	TicketURL      *string            `json:"ticket_url,omitempty"`
+	Metadata       *map[string]string `json:"metadata,omitempty"`
+	ExpiresAt      *time.Time         `json:"expires_at,omitempty"` // can be set but not removed, see expiry.go
+}
+
+// applyTo sets the fields present in p on req.
+func (p v2PatchRequest) applyTo(req *upsertRequest) {
+	if p.Description != nil {
+		req.Description = *p.Description
+	}
+	if p.Enabled != nil {
+		req.Enabled = *p.Enabled
+	}
+	if p.Rollout != nil {
+		req.Rollout = *p.Rollout
+	}
+	if p.Expression != nil {
+		req.Expression = p.Expression
+	}
+	if p.Config != nil {
+		req.Config = *p.Config
+	}
+	if p.TargetingRules != nil {
+		req.TargetingRules = *p.TargetingRules
+	}
+	if p.Variants != nil {
+		req.Variants = *p.Variants
+	}
+	if p.Protected != nil {
+		req.Protected = p.Protected
+	}
+	if p.Tags != nil {
+		req.Tags = *p.Tags
+	}
+	if p.Owner != nil {
+		req.Owner = p.Owner
+	}
+	if p.TicketURL != nil {
+		req.TicketURL = p.TicketURL
+	}
+	if p.Metadata != nil {
+		req.Metadata = *p.Metadata
+	}
+	if p.ExpiresAt != nil {
+		req.ExpiresAt = p.ExpiresAt
+	}
+}
+
+// encodeCursor turns the last key of a page into an opaque cursor.
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// decodeCursor reverses encodeCursor.
+func decodeCursor(cursor string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parsePageLimit parses ?limit, applying the v2 default and maximum.
+func parsePageLimit(raw string) (int, bool) {
+	if raw == "" {
+		return v2DefaultPageSize, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 {
+		return 0, false
+	}
+	if limit > v2MaxPageSize {
+		limit = v2MaxPageSize
+	}
+	return limit, true
+}
+
+// loadEnvFlag fetches the flag with key in env.
+func (s *Server) loadEnvFlag(r *http.Request, key, env string) (*store.Flag, bool) {
+	flag, err := s.store.GetFlagByKey(r.Context(), key, env)
+	if err != nil {
+		return nil, false
+	}
+	return flag, true
+}
+
+// loadProjectFlag fetches the flag with key in the {env} of a v2 request,
+// reporting it as missing if it belongs to another project than {project}.
+func (s *Server) loadProjectFlag(r *http.Request, key string) (*store.Flag, bool) {
+	flag, ok := s.loadEnvFlag(r, key, chi.URLParam(r, "env"))
+	if !ok || flag.Project != chi.URLParam(r, "project") {
+		return nil, false
+	}
+	return flag, true
+}
+
+// routeFlag loads the flag of a /v1/flags/{id}/... request in its ?env,
+// writing an error response and returning ok=false if the environment
+// belongs to another tenant or the flag does not exist.
+func (s *Server) routeFlag(w http.ResponseWriter, r *http.Request) (env string, flag *store.Flag, ok bool) {
+	key, env := strings.TrimSpace(chi.URLParam(r, "id")), s.queryEnv(r)
+	if !requireTenantEnv(w, r, env) {
+		return "", nil, false
+	}
+	if flag, ok = s.loadEnvFlag(r, key, env); !ok {
+		NotFoundError(w, r, "Flag not found")
+		return "", nil, false
+	}
+	return env, flag, true
+}
+
+func (s *Server) handleV2ListFlags(w http.ResponseWriter, r *http.Request) {
+	env := chi.URLParam(r, "env")
+
+	limit, ok := parsePageLimit(r.URL.Query().Get("limit"))
+	if !ok {
+		ValidationError(w, r, "Invalid pagination parameters", map[string]string{
+			"limit": "limit must be a positive integer",
+		})
+		return
+	}
+
+	var after string
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		key, err := decodeCursor(cursor)
+		if err != nil {
+			ValidationError(w, r, "Invalid pagination parameters", map[string]string{
+				"cursor": "cursor is malformed",
+			})
+			return
+		}
+		after = key
+	}
+
+	flags, _, err := s.queryFlags(r.Context(), store.FlagQuery{Env: env, Project: chi.URLParam(r, "project")})
+	if err != nil {
+		InternalError(w, r, "Failed to load flags")
+		return
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+
+	start := sort.Search(len(flags), func(i int) bool { return flags[i].Key > after })
+	end := start + limit
+	if end > len(flags) {
+		end = len(flags)
+	}
+
+	page := make([]flagResponse, 0, end-start)
+	for i := start; i < end; i++ {
+		page = append(page, toFlagResponse(&flags[i]))
+	}
+
+	meta := &v2Meta{Limit: limit}
+	if end < len(flags) {
+		meta.NextCursor = encodeCursor(flags[end-1].Key)
+	}
+	writeJSON(w, http.StatusOK, v2Envelope{Data: page, Meta: meta})
+}
+
+func (s *Server) handleV2GetFlag(w http.ResponseWriter, r *http.Request) {
+	flag, ok := s.loadProjectFlag(r, chi.URLParam(r, "key"))
+	if !ok {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, v2Envelope{Data: toFlagResponse(flag)})
+}
+
+// handleV2PutFlag creates or fully replaces a flag.
+func (s *Server) handleV2PutFlag(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	env := chi.URLParam(r, "env")
+	project := chi.URLParam(r, "project")
+
+	var req upsertRequest
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	if req.Key != "" && req.Key != key {
+		ValidationError(w, r, "Validation failed for one or more fields", map[string]string{
+			"key": "key in body must match path key",
+		})
+		return
+	}
+	if req.Env != nil && strings.TrimSpace(*req.Env) != "" && strings.TrimSpace(*req.Env) != env {
+		ValidationError(w, r, "Validation failed for one or more fields", map[string]string{
+			"env": "env in body must match path environment",
+		})
+		return
+	}
+	if req.Project != nil && strings.TrimSpace(*req.Project) != "" && strings.TrimSpace(*req.Project) != project {
+		ValidationError(w, r, "Validation failed for one or more fields", map[string]string{
+			"project": "project in body must match path project",
+		})
+		return
+	}
+	if existing, ok := s.loadEnvFlag(r, key, env); ok && existing.Project != project {
+		ConflictError(w, r, "Flag '"+key+"' belongs to project '"+existing.Project+"' in this environment")
+		return
+	}
+	req.Key = key
+	req.Env = &env
+	req.Project = &project
+
+	if field, message, ok := validateTargetingRules(req.TargetingRules); !ok {
+		ValidationError(w, r, "invalid targeting_rules", map[string]string{field: message})
+		return
+	}
+
+	s.writeV2FlagResult(w, r, req)
+}
+
+// handleV2PatchFlag applies a partial update to an existing flag.
+func (s *Server) handleV2PatchFlag(w http.ResponseWriter, r *http.Request) {
+	existing, ok := s.loadProjectFlag(r, chi.URLParam(r, "key"))
+	if !ok {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+
+	var patch v2PatchRequest
+	if !decodeJSON(w, r, &patch, maxJSONBodySize) {
+		return
+	}
+
+	req := upsertRequestFromFlag(existing)
+	patch.applyTo(&req)
+
+	if field, message, ok := validateTargetingRules(req.TargetingRules); !ok {
+		ValidationError(w, r, "invalid targeting_rules", map[string]string{field: message})
+		return
+	}
+
+	s.writeV2FlagResult(w, r, req)
+}
+
+func (s *Server) handleV2DeleteFlag(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	env := chi.URLParam(r, "env")
+
+	if _, ok := s.loadProjectFlag(r, key); !ok {
+		NotFoundError(w, r, "Flag not found")
+		return
+	}
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	if isDryRun(r) {
+		resp, werr := s.dryRunRemoveFlag(r, key, env)
+		if werr != nil {
+			werr.write(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, v2Envelope{Data: resp})
+		return
+	}
+	if werr := s.removeFlag(r, key, env); werr != nil {
+		werr.write(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeV2FlagResult saves req and responds with the stored flag in a v2 envelope.
+func (s *Server) writeV2FlagResult(w http.ResponseWriter, r *http.Request, req upsertRequest) {
+	if !applyIfMatch(w, r, &req) {
+		return
+	}
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	if isDryRun(r) {
+		resp, werr := s.dryRunSaveFlag(r, req)
+		if werr != nil {
+			werr.write(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, v2Envelope{Data: resp})
+		return
+	}
+
+	flag, werr := s.saveFlag(r, req)
+	if werr != nil {
+		werr.write(w, r)
+		return
+	}
+	var data any
+	if flag != nil {
+		data = toFlagResponse(flag)
+	}
+	writeJSON(w, http.StatusOK, v2Envelope{
+		Data: data,
+		Meta: &v2Meta{ETag: s.snapshotForEnv(s.requestEnv(req)).ETag},
+	})
+}
+
+// upsertRequestFromFlag converts a stored flag back into a write request,
+// used as the base state for PATCH.
+func upsertRequestFromFlag(flag *store.Flag) upsertRequest {
+	env, project := flag.Env, flag.Project
+	protected := flag.Protected
+	owner, ticketURL := flag.Owner, flag.TicketURL
+	req := upsertRequest{
+		Key:            flag.Key,
+		Description:    flag.Description,
+		Enabled:        flag.Enabled,
+		Rollout:        flag.Rollout,
+		Expression:     flag.Expression,
+		Config:         flag.Config,
+		TargetingRules: flag.TargetingRules,
+		Protected:      &protected,
+		Tags:           flag.Tags,
+		Owner:          &owner,
+		TicketURL:      &ticketURL,
+		Metadata:       flag.Metadata,
+		ExpiresAt:      flag.ExpiresAt,
+		Env:            &env,
+		Project:        &project,
+	}
+	for _, v := range flag.Variants {
+		req.Variants = append(req.Variants, variantRequest{Name: v.Name, Weight: v.Weight, Config: v.Config})
+	}
+	return req
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func doV2Request(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestV2_PutGetPatchDelete(t *testing.T) {
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+	base := "/v2/projects/default/environments/staging/flags/new_checkout"
+
+	rr := doV2Request(t, handler, http.MethodPut, base, `{"description":"checkout","enabled":true,"rollout":25}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var put struct {
+		Data flagResponse `json:"data"`
+		Meta v2Meta       `json:"meta"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&put); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if put.Data.Env != "staging" || put.Data.Rollout != 25 || put.Meta.ETag == "" {
+		t.Fatalf("unexpected PUT response: %+v", put)
+	}
+
+	rr = doV2Request(t, handler, http.MethodPatch, base, `{"rollout":75}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PATCH: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	flag, err := st.GetFlagByKey(context.Background(), "new_checkout", "staging")
+	if err != nil {
+		t.Fatalf("flag missing after patch: %v", err)
+	}
+	if flag.Rollout != 75 || !flag.Enabled || flag.Description != "checkout" {
+		t.Errorf("PATCH should only change rollout, got %+v", flag)
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v2/projects/default/environments/prod/flags/new_checkout", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET from other env: expected 404, got %d", rr.Code)
+	}
+
+	rr = doV2Request(t, handler, http.MethodDelete, base, "")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: expected 204, got %d", rr.Code)
+	}
+	rr = doV2Request(t, handler, http.MethodGet, base, "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET after delete: expected 404, got %d", rr.Code)
+	}
+}
+
+func TestV2_PatchMissingFlag(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPatch, "/v2/projects/default/environments/prod/flags/missing", `{"enabled":true}`)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestV2_UnknownProject(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v2/projects/other/environments/prod/flags", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestV2_ListCursorPagination(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_ = st.UpsertFlag(ctx, store.UpsertParams{Key: fmt.Sprintf("flag_%d", i), Env: "prod"})
+	}
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	var seen []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		path := "/v2/projects/default/environments/prod/flags?limit=2"
+		if cursor != "" {
+			path += "&cursor=" + cursor
+		}
+		rr := doV2Request(t, handler, http.MethodGet, path, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Data []flagResponse `json:"data"`
+			Meta v2Meta         `json:"meta"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		for _, f := range resp.Data {
+			seen = append(seen, f.Key)
+		}
+		cursor = resp.Meta.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 flags across pages, got %v", seen)
+	}
+	for i, key := range seen {
+		if key != fmt.Sprintf("flag_%d", i) {
+			t.Errorf("page order: expected flag_%d at %d, got %s", i, i, key)
+		}
+	}
+}
+
+func TestV2_InvalidCursor(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodGet, "/v2/projects/default/environments/prod/flags?cursor=***", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestFlagVersions_IfMatch(t *testing.T) {
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+
+	// Version 0: create only if the flag does not exist.
+	create := `{"key":"checkout","enabled":false,"rollout":0,"expected_version":0}`
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", create)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp upsertResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp.Version != 1 {
+		t.Fatalf("expected version 1, got %+v (%v)", resp, err)
+	}
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", create); rr.Code != http.StatusConflict {
+		t.Fatalf("second create: expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = doV2Request(t, handler, http.MethodGet, "/v1/flags/checkout", "")
+	if got := rr.Header().Get("ETag"); got != `"1"` {
+		t.Fatalf(`expected ETag "1", got %q`, got)
+	}
+
+	if rr := doIfMatchRequest(t, handler, `"1"`, `{"enabled":true,"rollout":50}`); rr.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doIfMatchRequest(t, handler, `"1"`, `{"enabled":false,"rollout":0}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("stale update: expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var errResp ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &errResp); err != nil {
+		t.Fatal(err)
+	}
+	if errResp.Code != ErrCodeVersionConflict || errResp.Fields["version"] != "flag is at version 2" {
+		t.Errorf("unexpected conflict response: %+v", errResp)
+	}
+	if flag, _ := st.GetFlagByKey(context.Background(), "checkout", "prod"); flag.Rollout != 50 || flag.Version != 2 {
+		t.Errorf("expected the stale update to be rejected, got %+v", flag)
+	}
+
+	if rr := doIfMatchRequest(t, handler, "*", `{"enabled":true}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("non-numeric If-Match: expected 400, got %d", rr.Code)
+	}
+	if rr := doIfMatchRequest(t, handler, `"2"`, `{"enabled":true,"expected_version":1}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("If-Match disagreeing with the body: expected 400, got %d", rr.Code)
+	}
+
+	// Without an expected version the last write still wins.
+	if rr := doV2Request(t, handler, http.MethodPut, "/v1/flags/checkout", `{"enabled":true,"rollout":10}`); rr.Code != http.StatusOK {
+		t.Errorf("unconditional update: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestFlagVersions_ConcurrentEdits(t *testing.T) {
+	st := store.NewMemoryStore()
+	handler := NewServer(st, "prod", "test-key").Router()
+	if rr := doV2Request(t, handler, http.MethodPost, "/v1/flags", `{"key":"checkout","rollout":0}`); rr.Code != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Every editor read version 1; exactly one of them may win.
+	var wg sync.WaitGroup
+	codes := make([]int, 20)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = doIfMatchRequest(t, handler, `"1"`, fmt.Sprintf(`{"rollout":%d}`, i+1)).Code
+		}(i)
+	}
+	wg.Wait()
+
+	won := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			won++
+		case http.StatusConflict:
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+	if won != 1 {
+		t.Errorf("expected exactly one edit to win, got %d", won)
+	}
+	if flag, _ := st.GetFlagByKey(context.Background(), "checkout", "prod"); flag.Version != 2 {
+		t.Errorf("expected version 2, got %d", flag.Version)
+	}
+}
+
+func doIfMatchRequest(t *testing.T, handler http.Handler, ifMatch, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPut, "/v1/flags/checkout", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-key")
+	req.Header.Set("If-Match", ifMatch)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
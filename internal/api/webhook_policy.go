@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+)
+
+// SetWebhookURLPolicy sets the policy webhook URLs are validated against
+// when webhooks are created, updated or applied, and that the dispatcher
+// enforces at delivery (see webhook.URLPolicy). NewServer installs a policy
+// that blocks private and reserved addresses.
+func (s *Server) SetWebhookURLPolicy(policy *webhook.URLPolicy) {
+	s.webhookPolicy = policy
+	if s.webhookDispatcher != nil {
+		s.webhookDispatcher.SetURLPolicy(policy)
+	}
+}
+
+// SetWebhookTransport configures the proxy and trusted CAs webhook
+// deliveries use (see webhook.TransportConfig). It is a no-op without a
+// Postgres store, which has no webhooks.
+func (s *Server) SetWebhookTransport(cfg webhook.TransportConfig) error {
+	if s.webhookDispatcher == nil {
+		return nil
+	}
+	return s.webhookDispatcher.SetTransport(cfg)
+}
+
+// SetWebhookCircuitBreaker stops deliveries to a webhook for cfg.Cooldown
+// after cfg.Threshold consecutive failed attempts (see
+// webhook.BreakerConfig). Each opened circuit is audited as
+// "circuit_opened" and so shows up in the activity feed. A threshold <= 0
+// disables it.
+func (s *Server) SetWebhookCircuitBreaker(cfg webhook.BreakerConfig) {
+	if s.webhookDispatcher != nil {
+		s.webhookDispatcher.SetCircuitBreaker(cfg, s.auditCircuitOpened)
+	}
+}
+
+// SetWebhookWorkers sets how many webhook deliveries run at once (see
+// webhook.Dispatcher.SetConcurrency).
+func (s *Server) SetWebhookWorkers(n int) {
+	if s.webhookDispatcher != nil {
+		s.webhookDispatcher.SetConcurrency(n)
+	}
+}
+
+func (s *Server) auditCircuitOpened(event webhook.CircuitEvent) {
+	s.logSystemAudit(audit.NewSystemEventBuilder().
+		ForResource(audit.ResourceTypeWebhook, event.WebhookID).
+		WithAction(audit.ActionCircuitOpened).
+		Failure(fmt.Sprintf("%d consecutive failed deliveries to %s; deliveries skipped for %s", event.Failures, event.URL, event.OpenFor)))
+}
+
+// validateWebhookURL returns why rawURL may not be used as a webhook target,
+// or "" if it may.
+func (s *Server) validateWebhookURL(ctx context.Context, rawURL string) string {
+	if s.webhookPolicy == nil {
+		return ""
+	}
+	if err := s.webhookPolicy.ValidateURL(ctx, rawURL); err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+)
+
+func TestWebhookURLPolicy_RejectsInternalTargets(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	handler := srv.Router()
+
+	for _, path := range []string{"/v1/admin/webhooks", "/v1/apply"} {
+		body := `{"url":"http://169.254.169.254/latest/meta-data/","events":["flag.updated"]}`
+		field := "url"
+		if path == "/v1/apply" {
+			body = `{"env":"prod","flags":[],"webhooks":[{"url":"http://10.0.0.5/hook","events":["flag.updated"]}]}`
+			field = "webhooks[0].url"
+		}
+		rr := doV2Request(t, handler, http.MethodPost, path, body)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+		if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields[field] == "" {
+			t.Errorf("%s: expected a %s field error, got %+v", path, field, resp)
+		}
+	}
+
+	// An allowlisted network passes validation (and then fails for lack of a
+	// Postgres store, which has no webhooks).
+	policy, _ := webhook.NewURLPolicy(webhook.URLPolicyConfig{Allowlist: []string{"169.254.0.0/16"}})
+	srv.SetWebhookURLPolicy(policy)
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/admin/webhooks", `{"url":"http://169.254.169.254/","events":["flag.updated"]}`)
+	if rr.Code == http.StatusBadRequest {
+		t.Errorf("allowlisted target should pass validation, got %s", rr.Body.String())
+	}
+}
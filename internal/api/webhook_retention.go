@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Every delivery attempt is recorded (see GET /v1/admin/webhooks/{id}/deliveries)
+// and pruned hourly. A webhook keeps
+//   - deliveries for delivery_retention_days, or for the server-wide
+//     retention (SetWebhookDeliveryRetention) when that is 0
+//   - at most its newest delivery_max_rows deliveries, if that is not 0
+//
+// A webhook's deliveries can also be deleted at once:
+//
+//	DELETE /v1/admin/webhooks/{id}/deliveries   (admin+)
+//
+// The purge is audited; pruning by the job is only logged. Webhooks need the
+// Postgres store.
+
+const (
+	// DefaultWebhookDeliveryRetention is how long deliveries are kept when
+	// a webhook sets no retention of its own.
+	DefaultWebhookDeliveryRetention = 30 * 24 * time.Hour
+
+	// webhookDeliveryPruneInterval is how often RunWebhookDeliveryPruning runs.
+	webhookDeliveryPruneInterval = time.Hour
+
+	// maxWebhookDeliveryRetentionDays and maxWebhookDeliveryMaxRows bound
+	// the retention settings of a webhook.
+	maxWebhookDeliveryRetentionDays = 3650
+	maxWebhookDeliveryMaxRows       = 1_000_000
+)
+
+type purgeWebhookDeliveriesResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// SetWebhookDeliveryRetention sets how long deliveries are kept for webhooks
+// without a retention of their own. 0 keeps them forever.
+func (s *Server) SetWebhookDeliveryRetention(retention time.Duration) {
+	s.webhookRetention = retention
+}
+
+// validateDeliveryRetention adds errors for out-of-range retention settings.
+func validateDeliveryRetention(errors map[string]string, days, maxRows int32) {
+	if days < 0 || days > maxWebhookDeliveryRetentionDays {
+		errors["delivery_retention_days"] = fmt.Sprintf("must be between 0 and %d", maxWebhookDeliveryRetentionDays)
+	}
+	if maxRows < 0 || maxRows > maxWebhookDeliveryMaxRows {
+		errors["delivery_max_rows"] = fmt.Sprintf("must be between 0 and %d", maxWebhookDeliveryMaxRows)
+	}
+}
+
+func (s *Server) handlePurgeWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	var webhookID pgtype.UUID
+	if err := webhookID.Scan(chi.URLParam(r, "id")); err != nil {
+		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid webhook ID format")
+		return
+	}
+	queries := s.requireQueries(w, r)
+	if queries == nil {
+		return // Error already written to response
+	}
+	if !requireTenantWebhook(w, r, queries, webhookID) {
+		return
+	}
+
+	deleted, err := queries.DeleteWebhookDeliveries(r.Context(), webhookID)
+	if err != nil {
+		InternalError(w, r, "Failed to delete deliveries")
+		return
+	}
+	s.auditLog(r, audit.ActionDeleted, audit.ResourceTypeWebhook, formatUUID(webhookID), "", nil, nil,
+		map[string]any{"deliveries_deleted": deleted}, audit.StatusSuccess, "")
+	writeJSON(w, http.StatusOK, purgeWebhookDeliveriesResponse{Deleted: deleted})
+}
+
+// RunWebhookDeliveryPruning prunes deliveries every hour until ctx is
+// cancelled. It returns immediately if the store has no webhooks.
+func (s *Server) RunWebhookDeliveryPruning(ctx context.Context) {
+	if _, ok := s.store.(PostgresStoreInterface); !ok {
+		return
+	}
+	ticker := time.NewTicker(webhookDeliveryPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.PruneWebhookDeliveries(ctx, time.Now()); err != nil {
+				log.Printf("[webhook] delivery pruning failed: %v", err)
+			}
+			worker.Processed(ctx)
+		}
+	}
+}
+
+// PruneWebhookDeliveries deletes the deliveries each webhook no longer keeps
+// as of now and returns how many were deleted. A webhook whose deliveries
+// fail to be pruned is logged and retried on the next run.
+func (s *Server) PruneWebhookDeliveries(ctx context.Context, now time.Time) (int64, error) {
+	pgStore, ok := s.store.(PostgresStoreInterface)
+	if !ok {
+		return 0, nil
+	}
+	queries := getQueriesFromStore(pgStore)
+	if queries == nil {
+		return 0, nil
+	}
+	webhooks, err := queries.ListWebhooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var pruned int64
+	for _, wh := range webhooks {
+		n, err := s.pruneDeliveries(ctx, queries, wh, now)
+		if err != nil {
+			log.Printf("[webhook] failed to prune deliveries of webhook=%s: %v", formatUUID(wh.ID), err)
+		}
+		pruned += n
+	}
+	if pruned > 0 {
+		log.Printf("[webhook] pruned %d deliveries", pruned)
+	}
+	return pruned, nil
+}
+
+// pruneDeliveries applies wh's retention settings to its deliveries.
+func (s *Server) pruneDeliveries(ctx context.Context, queries *dbgen.Queries, wh dbgen.Webhook, now time.Time) (int64, error) {
+	var pruned int64
+	retention := s.webhookRetention
+	if wh.DeliveryRetentionDays > 0 {
+		retention = time.Duration(wh.DeliveryRetentionDays) * 24 * time.Hour
+	}
+	if retention > 0 {
+		n, err := queries.DeleteWebhookDeliveriesBefore(ctx, dbgen.DeleteWebhookDeliveriesBeforeParams{
+			WebhookID: wh.ID,
+			Timestamp: pgtype.Timestamptz{Time: now.Add(-retention), Valid: true},
+		})
+		if err != nil {
+			return pruned, err
+		}
+		pruned += n
+	}
+	if wh.DeliveryMaxRows > 0 {
+		n, err := queries.DeleteWebhookDeliveriesBeyond(ctx, dbgen.DeleteWebhookDeliveriesBeyondParams{
+			WebhookID: wh.ID,
+			Offset:    wh.DeliveryMaxRows,
+		})
+		if err != nil {
+			return pruned, err
+		}
+		pruned += n
+	}
+	return pruned, nil
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestWebhookDeliveryRetention_Validated(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	for _, path := range []string{"/v1/admin/webhooks", "/v1/apply"} {
+		body := `{"url":"https://hooks.example.com/","events":["flag.updated"],"delivery_retention_days":-1,"delivery_max_rows":2000000}`
+		prefix := ""
+		if path == "/v1/apply" {
+			body = `{"env":"prod","flags":[],"webhooks":[{"url":"https://hooks.example.com/","events":["flag.updated"],"delivery_retention_days":-1,"delivery_max_rows":2000000}]}`
+			prefix = "webhooks[0]."
+		}
+		rr := doV2Request(t, handler, http.MethodPost, path, body)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+		resp := decodeErrorResponse(t, rr.Body.Bytes())
+		for _, field := range []string{"delivery_retention_days", "delivery_max_rows"} {
+			if resp.Fields[prefix+field] == "" {
+				t.Errorf("%s: expected a %s field error, got %+v", path, prefix+field, resp)
+			}
+		}
+	}
+}
+
+func TestPurgeWebhookDeliveries_RequiresPostgres(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodDelete, "/v1/admin/webhooks/not-a-uuid/deliveries", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid id: expected 400, got %d", rr.Code)
+	}
+	rr = doV2Request(t, handler, http.MethodDelete, "/v1/admin/webhooks/7b0a6c5e-1f2d-4e3c-8a9b-0c1d2e3f4a5b/deliveries", "")
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("memory store: expected 500, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -1,14 +1,17 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	"github.com/TimurManjosov/goflagship/internal/auth"
 	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 	"github.com/TimurManjosov/goflagship/internal/webhook"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -21,6 +24,15 @@ type CreateWebhookRequest struct {
 	Environments   []string `json:"environments,omitempty"`
 	MaxRetries     int32    `json:"max_retries,omitempty"`
 	TimeoutSeconds int32    `json:"timeout_seconds,omitempty"`
+
+	// Delivery retention, see webhook_retention.go. 0 uses the server-wide
+	// retention and keeps any number of deliveries.
+	DeliveryRetentionDays int32 `json:"delivery_retention_days,omitempty"`
+	DeliveryMaxRows       int32 `json:"delivery_max_rows,omitempty"`
+
+	// Headers are sent with every delivery and stored encrypted, see
+	// webhook.EncodeHeaders.
+	Headers map[string]string `json:"headers,omitempty"`
 }
 
 // UpdateWebhookRequest represents the request body for updating a webhook
@@ -33,8 +45,20 @@ type UpdateWebhookRequest struct {
 	Environments   []string `json:"environments,omitempty"`
 	MaxRetries     int32    `json:"max_retries,omitempty"`
 	TimeoutSeconds int32    `json:"timeout_seconds,omitempty"`
+
+	// Delivery retention, see webhook_retention.go. 0 uses the server-wide
+	// retention and keeps any number of deliveries.
+	DeliveryRetentionDays int32 `json:"delivery_retention_days,omitempty"`
+	DeliveryMaxRows       int32 `json:"delivery_max_rows,omitempty"`
+
+	// Headers replaces the custom headers; without it they are kept, since
+	// responses only return them redacted.
+	Headers *map[string]string `json:"headers,omitempty"`
 }
 
+// redactedHeaderValue replaces custom header values in responses.
+const redactedHeaderValue = "[REDACTED]"
+
 // WebhookResponse represents the response for a webhook
 type WebhookResponse struct {
 	ID              string    `json:"id"`
@@ -44,12 +68,18 @@ type WebhookResponse struct {
 	Events          []string  `json:"events"`
 	ProjectID       string    `json:"project_id,omitempty"`
 	Environments    []string  `json:"environments,omitempty"`
-	Secret          string    `json:"secret"`
+	Secret          string    `json:"secret,omitempty"` // only when generated, see encryption.go
 	MaxRetries      int32     `json:"max_retries"`
 	TimeoutSeconds  int32     `json:"timeout_seconds"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	Tenant          string     `json:"tenant"`
+
+	DeliveryRetentionDays int32 `json:"delivery_retention_days"`
+	DeliveryMaxRows       int32 `json:"delivery_max_rows"`
+
+	Headers map[string]string `json:"headers,omitempty"` // values are redacted
 }
 
 // WebhookDeliveryResponse represents a webhook delivery record
@@ -62,6 +92,7 @@ type WebhookDeliveryResponse struct {
 	Success      bool      `json:"success"`
 	RetryCount   int32     `json:"retry_count"`
 	ErrorMessage string    `json:"error_message,omitempty"`
+	AuditEventID string    `json:"audit_event_id,omitempty"` // audit event of the change delivered
 }
 
 // PaginatedDeliveriesResponse represents paginated webhook deliveries
@@ -80,8 +111,11 @@ type PaginationInfo struct {
 // handleCreateWebhook creates a new webhook
 func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 	var req CreateWebhookRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: "+err.Error())
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	r, ok := withDryRun(w, r)
+	if !ok {
 		return
 	}
 
@@ -89,14 +123,23 @@ func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 	errors := make(map[string]string)
 	if req.URL == "" {
 		errors["url"] = "URL is required"
+	} else if msg := s.validateWebhookURL(r.Context(), req.URL); msg != "" {
+		errors["url"] = msg
 	}
 	if len(req.Events) == 0 {
 		errors["events"] = "At least one event type is required"
 	}
+	validateDeliveryRetention(errors, req.DeliveryRetentionDays, req.DeliveryMaxRows)
+	if msg := webhook.ValidateHeaders(req.Headers); msg != "" {
+		errors["headers"] = msg
+	}
 	if len(errors) > 0 {
 		ValidationError(w, r, "Validation failed", errors)
 		return
 	}
+	if !requireTenantEnvs(w, r, req.Environments) {
+		return
+	}
 
 	// Set defaults
 	if req.MaxRetries == 0 {
@@ -106,47 +149,68 @@ func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 		req.TimeoutSeconds = 10
 	}
 
-	// Generate webhook secret
-	secret, err := webhook.GenerateSecret()
+	// Generate webhook secret; only its encrypted form is stored
+	secret, storedSecret, err := s.newWebhookSecret()
 	if err != nil {
 		InternalError(w, r, "Failed to generate webhook secret")
 		return
 	}
+	storedHeaders, err := webhook.EncodeHeaders(s.keyring, req.Headers)
+	if err != nil {
+		InternalError(w, r, "Failed to encrypt webhook headers")
+		return
+	}
 
 	// Get queries from store
 	queries := s.requireQueries(w, r)
 	if queries == nil {
 		return // Error already written to response
 	}
+	if werr := s.checkWebhookLimit(r.Context(), queries); werr != nil {
+		werr.write(w, r)
+		return
+	}
 
 	// Prepare parameters
 	params := dbgen.CreateWebhookParams{
 		Url:            req.URL,
 		Enabled:        true,
 		Events:         req.Events,
-		Secret:         secret,
+		Secret:         storedSecret,
 		MaxRetries:     req.MaxRetries,
 		TimeoutSeconds: req.TimeoutSeconds,
+		Tenant:         auth.GetTenantFromContext(r.Context()),
+
+		DeliveryRetentionDays: req.DeliveryRetentionDays,
+		DeliveryMaxRows:       req.DeliveryMaxRows,
+		Headers:               storedHeaders,
 	}
 
 	if req.Description != "" {
 		params.Description = pgtype.Text{String: req.Description, Valid: true}
 	}
 
-	if req.ProjectID != nil && *req.ProjectID != "" {
-		// Parse project ID as UUID
-		var projectUUID pgtype.UUID
-		if err := projectUUID.Scan(*req.ProjectID); err != nil {
-			BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid project_id format")
-			return
-		}
-		params.ProjectID = projectUUID
+	projectID, werr := s.webhookProject(r, req.ProjectID)
+	if werr != nil {
+		werr.write(w, r)
+		return
 	}
+	params.ProjectID = projectID
 
 	if len(req.Environments) > 0 {
 		params.Environments = req.Environments
 	}
 
+	if isDryRun(r) {
+		after := applyWebhookStateFromDB(dbgen.Webhook{
+			Url: params.Url, Description: params.Description, Enabled: params.Enabled, Events: params.Events,
+			Environments: params.Environments, MaxRetries: params.MaxRetries, TimeoutSeconds: params.TimeoutSeconds,
+			DeliveryRetentionDays: params.DeliveryRetentionDays, DeliveryMaxRows: params.DeliveryMaxRows,
+		})
+		writeWebhookDryRun(w, applyActionCreate, req.URL, nil, after)
+		return
+	}
+
 	// Create webhook
 	wh, err := queries.CreateWebhook(r.Context(), params)
 	if err != nil {
@@ -154,7 +218,9 @@ func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, webhookToResponse(wh))
+	resp := webhookToResponse(wh)
+	resp.Secret = secret // returned once, on creation
+	writeJSON(w, http.StatusCreated, resp)
 }
 
 // handleListWebhooks lists all webhooks
@@ -169,6 +235,7 @@ func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
 		InternalError(w, r, "Failed to list webhooks")
 		return
 	}
+	webhooks = visibleWebhooks(r, webhooks)
 
 	response := make([]WebhookResponse, len(webhooks))
 	for i, wh := range webhooks {
@@ -198,7 +265,7 @@ func (s *Server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	wh, err := queries.GetWebhook(r.Context(), webhookID)
-	if err != nil {
+	if err != nil || !tenantOwnsRow(r, wh.Tenant) {
 		NotFoundError(w, r, "Webhook not found")
 		return
 	}
@@ -221,8 +288,11 @@ func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req UpdateWebhookRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid JSON: "+err.Error())
+	if !decodeJSON(w, r, &req, maxJSONBodySize) {
+		return
+	}
+	r, ok := withDryRun(w, r)
+	if !ok {
 		return
 	}
 
@@ -230,19 +300,33 @@ func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 	errors := make(map[string]string)
 	if req.URL == "" {
 		errors["url"] = "URL is required"
+	} else if msg := s.validateWebhookURL(r.Context(), req.URL); msg != "" {
+		errors["url"] = msg
 	}
 	if len(req.Events) == 0 {
 		errors["events"] = "At least one event type is required"
 	}
+	validateDeliveryRetention(errors, req.DeliveryRetentionDays, req.DeliveryMaxRows)
+	if req.Headers != nil {
+		if msg := webhook.ValidateHeaders(*req.Headers); msg != "" {
+			errors["headers"] = msg
+		}
+	}
 	if len(errors) > 0 {
 		ValidationError(w, r, "Validation failed", errors)
 		return
 	}
+	if !requireTenantEnvs(w, r, req.Environments) {
+		return
+	}
 
 	queries := s.requireQueries(w, r)
 	if queries == nil {
 		return // Error already written to response
 	}
+	if !requireTenantWebhook(w, r, queries, webhookID) {
+		return
+	}
 
 	// Prepare parameters
 	params := dbgen.UpdateWebhookParams{
@@ -252,25 +336,49 @@ func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 		Events:         req.Events,
 		MaxRetries:     req.MaxRetries,
 		TimeoutSeconds: req.TimeoutSeconds,
+
+		DeliveryRetentionDays: req.DeliveryRetentionDays,
+		DeliveryMaxRows:       req.DeliveryMaxRows,
+	}
+	if req.Headers != nil {
+		stored, err := webhook.EncodeHeaders(s.keyring, *req.Headers)
+		if err != nil {
+			InternalError(w, r, "Failed to encrypt webhook headers")
+			return
+		}
+		params.Headers = stored
 	}
 
 	if req.Description != "" {
 		params.Description = pgtype.Text{String: req.Description, Valid: true}
 	}
 
-	if req.ProjectID != nil && *req.ProjectID != "" {
-		var projectUUID pgtype.UUID
-		if err := projectUUID.Scan(*req.ProjectID); err != nil {
-			BadRequestError(w, r, ErrCodeInvalidJSON, "Invalid project_id format")
-			return
-		}
-		params.ProjectID = projectUUID
+	projectID, werr := s.webhookProject(r, req.ProjectID)
+	if werr != nil {
+		werr.write(w, r)
+		return
 	}
+	params.ProjectID = projectID
 
 	if len(req.Environments) > 0 {
 		params.Environments = req.Environments
 	}
 
+	if isDryRun(r) {
+		existing, err := queries.GetWebhook(r.Context(), webhookID)
+		if err != nil {
+			InternalError(w, r, "Failed to fetch webhook")
+			return
+		}
+		after := applyWebhookStateFromDB(dbgen.Webhook{
+			Url: params.Url, Description: params.Description, Enabled: params.Enabled, Events: params.Events,
+			Environments: params.Environments, MaxRetries: params.MaxRetries, TimeoutSeconds: params.TimeoutSeconds,
+			DeliveryRetentionDays: params.DeliveryRetentionDays, DeliveryMaxRows: params.DeliveryMaxRows,
+		})
+		writeWebhookDryRun(w, applyActionUpdate, existing.Url, applyWebhookStateFromDB(existing), after)
+		return
+	}
+
 	// Update webhook
 	if err := queries.UpdateWebhook(r.Context(), params); err != nil {
 		InternalError(w, r, "Failed to update webhook")
@@ -305,6 +413,23 @@ func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
 	if queries == nil {
 		return // Error already written to response
 	}
+	if !requireTenantWebhook(w, r, queries, webhookID) {
+		return
+	}
+
+	r, ok := withDryRun(w, r)
+	if !ok {
+		return
+	}
+	if isDryRun(r) {
+		existing, err := queries.GetWebhook(r.Context(), webhookID)
+		if err != nil {
+			InternalError(w, r, "Failed to fetch webhook")
+			return
+		}
+		writeWebhookDryRun(w, applyActionDelete, existing.Url, applyWebhookStateFromDB(existing), nil)
+		return
+	}
 
 	if err := queries.DeleteWebhook(r.Context(), webhookID); err != nil {
 		InternalError(w, r, "Failed to delete webhook")
@@ -314,6 +439,20 @@ func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
 }
 
+// writeWebhookDryRun responds to a webhook mutation sent with ?dry_run=true,
+// see dryrun.go.
+func writeWebhookDryRun(w http.ResponseWriter, action, url string, before, after map[string]any) {
+	writeJSON(w, http.StatusOK, dryRunResponse{
+		DryRun: true,
+		Changes: []applyChange{{
+			Action:       action,
+			ResourceType: applyResourceWebhook,
+			Key:          url,
+			Diff:         audit.ComputeChanges(before, after),
+		}},
+	})
+}
+
 // handleListWebhookDeliveries lists webhook delivery attempts
 func (s *Server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -348,6 +487,9 @@ func (s *Server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Requ
 	if queries == nil {
 		return // Error already written to response
 	}
+	if !requireTenantWebhook(w, r, queries, webhookID) {
+		return
+	}
 
 	// Get deliveries
 	deliveries, err := queries.ListWebhookDeliveries(r.Context(), dbgen.ListWebhookDeliveriesParams{
@@ -404,7 +546,7 @@ func (s *Server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	wh, err := queries.GetWebhook(r.Context(), webhookID)
-	if err != nil {
+	if err != nil || !tenantOwnsRow(r, wh.Tenant) {
 		NotFoundError(w, r, "Webhook not found")
 		return
 	}
@@ -414,7 +556,7 @@ func (s *Server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
 		testEvent := webhook.Event{
 			Type:        "webhook.test",
 			Timestamp:   time.Now(),
-			Environment: "test",
+			Environment: testEventEnvironment(wh),
 			Resource: webhook.Resource{
 				Type: "webhook",
 				Key:  formatUUID(wh.ID),
@@ -425,7 +567,8 @@ func (s *Server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
 				},
 			},
 			Metadata: webhook.Metadata{
-				RequestID: r.Header.Get("X-Request-ID"),
+				RequestID: middleware.GetReqID(r.Context()),
+				TraceID:   telemetry.TraceID(r.Context()),
 			},
 		}
 
@@ -445,11 +588,14 @@ func webhookToResponse(wh dbgen.Webhook) WebhookResponse {
 		URL:            wh.Url,
 		Enabled:        wh.Enabled,
 		Events:         wh.Events,
-		Secret:         wh.Secret,
 		MaxRetries:     wh.MaxRetries,
 		TimeoutSeconds: wh.TimeoutSeconds,
 		CreatedAt:      wh.CreatedAt.Time,
 		UpdatedAt:      wh.UpdatedAt.Time,
+		Tenant:         wh.Tenant,
+
+		DeliveryRetentionDays: wh.DeliveryRetentionDays,
+		DeliveryMaxRows:       wh.DeliveryMaxRows,
 	}
 
 	if wh.Description.Valid {
@@ -457,7 +603,7 @@ func webhookToResponse(wh dbgen.Webhook) WebhookResponse {
 	}
 
 	if wh.ProjectID.Valid {
-		resp.ProjectID = formatUUID(wh.ProjectID)
+		resp.ProjectID = wh.ProjectID.String
 	}
 
 	if len(wh.Environments) > 0 {
@@ -469,6 +615,13 @@ func webhookToResponse(wh dbgen.Webhook) WebhookResponse {
 		resp.LastTriggeredAt = &t
 	}
 
+	if names := webhook.HeaderNames(wh.Headers); len(names) > 0 {
+		resp.Headers = make(map[string]string, len(names))
+		for _, name := range names {
+			resp.Headers[name] = redactedHeaderValue
+		}
+	}
+
 	return resp
 }
 
@@ -494,5 +647,9 @@ func deliveryToResponse(d dbgen.WebhookDelivery) WebhookDeliveryResponse {
 		resp.ErrorMessage = d.ErrorMessage.String
 	}
 
+	if d.AuditEventID.Valid {
+		resp.AuditEventID = formatUUID(d.AuditEventID)
+	}
+
 	return resp
 }
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/TimurManjosov/goflagship/internal/audit"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/webhook"
+)
+
+// recordingWebhookQueries serves one webhook and records its deliveries.
+type recordingWebhookQueries struct {
+	url        string
+	mu         sync.Mutex
+	deliveries []dbgen.CreateWebhookDeliveryParams
+}
+
+func (q *recordingWebhookQueries) GetActiveWebhooks(context.Context) ([]dbgen.Webhook, error) {
+	return []dbgen.Webhook{{
+		ID:             pgtype.UUID{Bytes: [16]byte{1}, Valid: true},
+		Url:            q.url,
+		Enabled:        true,
+		Events:         []string{webhook.EventFlagCreated},
+		Secret:         "test-secret",
+		TimeoutSeconds: 5,
+	}}, nil
+}
+
+func (q *recordingWebhookQueries) UpdateWebhookLastTriggered(context.Context, pgtype.UUID) error {
+	return nil
+}
+
+func (q *recordingWebhookQueries) CreateWebhookDelivery(_ context.Context, params dbgen.CreateWebhookDeliveryParams) (dbgen.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deliveries = append(q.deliveries, params)
+	return dbgen.WebhookDelivery{}, nil
+}
+
+func TestFlagWebhook_CarriesAuditEventID(t *testing.T) {
+	received := make(chan webhook.Event, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhook.Event
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer receiver.Close()
+
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	sink := &recordingSink{}
+	srv.auditService = audit.NewService(sink, nil, nil, nil, 16)
+	t.Cleanup(func() { _ = srv.auditService.Close() })
+	queries := &recordingWebhookQueries{url: receiver.URL}
+	srv.webhookDispatcher = webhook.NewDispatcher(queries)
+	srv.webhookDispatcher.Start()
+	defer srv.webhookDispatcher.Stop()
+
+	if rr := doV2Request(t, srv.Router(), http.MethodPost, "/v1/flags", `{"key":"checkout","enabled":true,"rollout":100,"env":"prod"}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	events := sink.waitForEvents(t, 1)
+	if len(events) != 1 || events[0].ID == "" {
+		t.Fatalf("expected one audit event with an ID, got %+v", events)
+	}
+
+	var event webhook.Event
+	select {
+	case event = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for webhook delivery")
+	}
+	if event.Metadata.AuditEventID != events[0].ID {
+		t.Errorf("expected payload auditEventId %s, got %q", events[0].ID, event.Metadata.AuditEventID)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		queries.mu.Lock()
+		deliveries := append([]dbgen.CreateWebhookDeliveryParams(nil), queries.deliveries...)
+		queries.mu.Unlock()
+		if len(deliveries) > 0 {
+			if got := formatUUID(deliveries[0].AuditEventID); got != events[0].ID {
+				t.Errorf("expected the delivery to record audit event %s, got %q", events[0].ID, got)
+			}
+			if resp := deliveryToResponse(dbgen.WebhookDelivery{AuditEventID: deliveries[0].AuditEventID}); resp.AuditEventID != events[0].ID {
+				t.Errorf("expected the delivery response to carry the audit event, got %q", resp.AuditEventID)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the delivery to be recorded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookHeaders_ValidatedAndRedacted(t *testing.T) {
+	handler := NewServer(store.NewMemoryStore(), "prod", "test-key").Router()
+
+	rr := doV2Request(t, handler, http.MethodPost, "/v1/admin/webhooks", `{"url":"https://hooks.example.com/","events":["flag.updated"],"headers":{"X-Flagship-Signature":"forged"}}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp := decodeErrorResponse(t, rr.Body.Bytes()); resp.Fields["headers"] == "" {
+		t.Errorf("expected a headers field error, got %+v", resp)
+	}
+
+	stored, _ := webhook.EncodeHeaders(nil, map[string]string{"Authorization": "Bearer receiver-token"})
+	resp := webhookToResponse(dbgen.Webhook{Url: "https://hooks.example.com/", Headers: stored})
+	if len(resp.Headers) != 1 || resp.Headers["Authorization"] != redactedHeaderValue {
+		t.Errorf("headers = %v, want Authorization redacted", resp.Headers)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+// Background workers (the audit writer, the webhook dispatcher and the
+// periodic jobs started with RunWorker) are supervised: a worker that panics
+// is logged and restarted with backoff instead of taking the process down.
+// Their health is reported by
+//
+//	GET /readyz   200 {"status":"ready","workers":[...]}, or 503 with
+//	              "not_ready" while a crashed worker waits to be restarted
+//
+// and by the worker_* metrics (see telemetry.RegisterWorkerStats). Each
+// worker lists its state, queue depth (audit and webhook workers), when it
+// last processed an event or finished a run, and how often it was restarted.
+// GET /healthz stays a plain liveness check.
+
+type readyzResponse struct {
+	Status  string          `json:"status"` // "ready" or "not_ready"
+	Workers []worker.Status `json:"workers"`
+}
+
+// RunWorker runs fn as the supervised worker name until it returns or ctx is
+// cancelled, restarting it if it panics. Periodic jobs call worker.Processed
+// with the context they are given after every run. A job that returns on its
+// own, usually because it is disabled, is no longer reported. RunWorker
+// blocks; start it with go.
+func (s *Server) RunWorker(ctx context.Context, name string, fn func(ctx context.Context)) {
+	w := worker.New(name, nil)
+	s.workers.Add(w)
+	w.Run(ctx, fn)
+	if ctx.Err() == nil {
+		s.workers.Remove(w)
+	}
+}
+
+// WorkerStatuses returns the health of all supervised workers, sorted by
+// name.
+func (s *Server) WorkerStatuses() []worker.Status {
+	return s.workers.Statuses()
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	resp := readyzResponse{Status: "ready", Workers: s.WorkerStatuses()}
+	status := http.StatusOK
+	for _, ws := range resp.Workers {
+		if ws.State == worker.StateRestarting {
+			resp.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, status, resp)
+}
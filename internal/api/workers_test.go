@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+func getReadyz(t *testing.T, handler http.Handler) (int, readyzResponse) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp readyzResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return rr.Code, resp
+}
+
+func TestReadyz_ReportsWorkers(t *testing.T) {
+	srv := NewServer(store.NewMemoryStore(), "prod", "test-key")
+	handler := srv.Router()
+
+	if code, resp := getReadyz(t, handler); code != http.StatusOK || resp.Status != "ready" || len(resp.Workers) != 0 {
+		t.Fatalf("expected ready without workers, got %d %+v", code, resp)
+	}
+
+	// A disabled job returns at once and is not reported.
+	srv.RunWorker(context.Background(), "disabled", func(context.Context) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ran := make(chan struct{})
+	go srv.RunWorker(ctx, "jobs", func(ctx context.Context) {
+		worker.Processed(ctx)
+		close(ran)
+		panic("boom")
+	})
+	<-ran
+
+	// The crashed job waits a second before its restart.
+	deadline := time.Now().Add(time.Second)
+	for {
+		code, resp := getReadyz(t, handler)
+		if code == http.StatusServiceUnavailable {
+			if resp.Status != "not_ready" || len(resp.Workers) != 1 {
+				t.Fatalf("unexpected response %+v", resp)
+			}
+			if ws := resp.Workers[0]; ws.Name != "jobs" || ws.State != worker.StateRestarting || ws.LastPanic != "boom" || ws.LastProcessed == nil {
+				t.Errorf("unexpected worker status %+v", ws)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 503 while the job restarts, got %d %+v", code, resp)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
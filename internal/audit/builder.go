@@ -4,7 +4,9 @@ import (
 	"net/http"
 
 	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
 )
 
 // EventBuilder provides a fluent API for constructing audit events.
@@ -45,21 +47,50 @@ func NewEventBuilder(r *http.Request) *EventBuilder {
 			ID:      &idStr,
 			Display: display,
 		}
+		if name, ok := auth.GetAPIKeyNameFromContext(r.Context()); ok && name != "" {
+			actor.Name = name
+		}
+	}
+	if role, ok := auth.GetRoleFromContext(r.Context()); ok {
+		actor.Role = string(role)
 	}
 
 	return &EventBuilder{
 		event: AuditEvent{
 			RequestID: middleware.GetReqID(r.Context()),
+			TraceID:   telemetry.TraceID(r.Context()),
 			Actor:     actor,
 			Source: Source{
 				IPAddress: auth.GetIPAddress(r),
 				UserAgent: r.UserAgent(),
 			},
 			Status: StatusSuccess, // Default to success, caller can override
+			Tenant: auth.GetTenantFromContext(r.Context()),
 		},
 	}
 }
 
+// NewSystemEventBuilder creates a builder for events raised by background jobs
+// rather than HTTP requests. The actor is the system and no source is recorded.
+func NewSystemEventBuilder() *EventBuilder {
+	return &EventBuilder{
+		event: AuditEvent{
+			Actor: Actor{
+				Kind:    ActorKindSystem,
+				Display: "system",
+			},
+			Status: StatusSuccess,
+		},
+	}
+}
+
+// WithActor replaces the actor derived from the request, for callers that
+// are not identified by an API key (e.g. SDK keys on client endpoints).
+func (b *EventBuilder) WithActor(actor Actor) *EventBuilder {
+	b.event.Actor = actor
+	return b
+}
+
 // ForResource sets the resource type and ID for the event.
 func (b *EventBuilder) ForResource(resourceType, resourceID string) *EventBuilder {
 	b.event.ResourceType = resourceType
@@ -81,6 +112,14 @@ func (b *EventBuilder) WithEnvironment(env string) *EventBuilder {
 	return b
 }
 
+// WithProject sets the project of the event's resource.
+func (b *EventBuilder) WithProject(project string) *EventBuilder {
+	if project != "" {
+		b.event.ProjectID = &project
+	}
+	return b
+}
+
 // WithBeforeState sets the before state for the event.
 func (b *EventBuilder) WithBeforeState(state map[string]any) *EventBuilder {
 	if state != nil {
@@ -105,6 +144,14 @@ func (b *EventBuilder) WithChanges(changes map[string]any) *EventBuilder {
 	return b
 }
 
+// WithNote attaches a free-text justification to the event.
+func (b *EventBuilder) WithNote(note string) *EventBuilder {
+	if note != "" {
+		b.event.Note = &note
+	}
+	return b
+}
+
 // Success marks the event as successful (default).
 func (b *EventBuilder) Success() *EventBuilder {
 	b.event.Status = StatusSuccess
@@ -122,6 +169,11 @@ func (b *EventBuilder) Failure(errorMsg string) *EventBuilder {
 
 // Build returns the constructed AuditEvent.
 // The returned event is ready to be logged via service.Log().
+// The event gets its ID here, so callers can reference it (e.g. in webhook
+// metadata) before it is persisted; building twice returns the same ID.
 func (b *EventBuilder) Build() AuditEvent {
+	if b.event.ID == "" {
+		b.event.ID = uuid.NewString()
+	}
 	return b.event
 }
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FileQueue is a durable queue of audit events: a local append-only file
+// with one JSON event per line. Events are synced to disk before Append
+// returns, so they survive a full in-memory queue, an unreachable sink and
+// a crash. The offset of the first event not yet written to the sink is kept
+// in <path>.offset; once every event is written the file is truncated.
+//
+// Delivery is at least once: an event written to the sink just before a
+// crash is written again after the restart. PostgresSink ignores events it
+// already stored.
+type FileQueue struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	size   int64 // bytes in file
+	offset int64 // bytes of file already written to the sink
+}
+
+// OpenFileQueue opens (or creates) the queue at path. A partial last event,
+// left by a crash during Append, is discarded.
+func OpenFileQueue(path string) (*FileQueue, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit queue: %w", err)
+	}
+	q := &FileQueue{path: path, file: file}
+	if err := q.recover(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit queue: %w", err)
+	}
+	return q, nil
+}
+
+// recover sets size to the end of the last complete event and reads the
+// persisted offset.
+func (q *FileQueue) recover() error {
+	data, err := io.ReadAll(q.file)
+	if err != nil {
+		return err
+	}
+	q.size = int64(bytes.LastIndexByte(data, '\n') + 1)
+	if q.size < int64(len(data)) {
+		if err := q.file.Truncate(q.size); err != nil {
+			return err
+		}
+	}
+
+	raw, err := os.ReadFile(q.offsetPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil || offset < 0 || offset > q.size {
+		return fmt.Errorf("invalid offset %q in %s", raw, q.offsetPath())
+	}
+	q.offset = offset
+	return nil
+}
+
+func (q *FileQueue) offsetPath() string {
+	return q.path + ".offset"
+}
+
+// Append adds event to the end of the queue.
+func (q *FileQueue) Append(event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n, err := q.file.Write(line)
+	if err != nil {
+		// Drop a partial line, so later events stay readable.
+		_ = q.file.Truncate(q.size)
+		return fmt.Errorf("audit queue: %w", err)
+	}
+	if err := q.file.Sync(); err != nil {
+		return fmt.Errorf("audit queue: %w", err)
+	}
+	q.size += int64(n)
+	return nil
+}
+
+// Next returns the first event not yet written to the sink and the offset
+// to Ack once it is, or ok=false if the queue is empty. Events that cannot
+// be decoded are skipped.
+func (q *FileQueue) Next() (AuditEvent, int64, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.offset < q.size {
+		line, err := bufio.NewReader(io.NewSectionReader(q.file, q.offset, q.size-q.offset)).ReadBytes('\n')
+		if err != nil {
+			return AuditEvent{}, 0, false, fmt.Errorf("audit queue: %w", err)
+		}
+		next := q.offset + int64(len(line))
+		var event AuditEvent
+		if err := json.Unmarshal(line, &event); err == nil {
+			return event, next, true, nil
+		}
+		q.offset = next // undecodable; can never be written
+	}
+	return AuditEvent{}, 0, false, nil
+}
+
+// Ack marks the events before next as written to the sink. When the queue
+// is empty the file is truncated.
+func (q *FileQueue) Ack(next int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.offset = next
+	if q.offset < q.size {
+		return os.WriteFile(q.offsetPath(), []byte(strconv.FormatInt(q.offset, 10)), 0o600)
+	}
+	// Without an offset a crash before the truncation replays the events,
+	// rather than leaving an offset past the end of the file.
+	if err := os.Remove(q.offsetPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := q.file.Truncate(0); err != nil {
+		return fmt.Errorf("audit queue: %w", err)
+	}
+	q.size, q.offset = 0, 0
+	return nil
+}
+
+// Close closes the queue file. Events not yet written stay queued for the
+// next OpenFileQueue.
+func (q *FileQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.file.Close()
+}
@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileQueue_SurvivesReopenAndPartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.queue")
+	q, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := q.Append(AuditEvent{ID: id, Action: ActionUpdated}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, next, _, _ := q.Next()
+	if err := q.Ack(next); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+
+	// A crash in the middle of an append leaves a partial line.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"id":"d","act`)
+	f.Close()
+
+	q, err = OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer q.Close()
+	if err := q.Append(AuditEvent{ID: "e"}); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for {
+		event, next, ok, err := q.Next()
+		if err != nil || !ok {
+			break
+		}
+		ids = append(ids, event.ID)
+		q.Ack(next)
+	}
+	if len(ids) != 3 || ids[0] != "b" || ids[1] != "c" || ids[2] != "e" {
+		t.Errorf("events after reopen = %v, want [b c e]", ids)
+	}
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Errorf("drained queue should be truncated, has %d bytes", info.Size())
+	}
+}
+
+// flakySink fails until healthy is set.
+type flakySink struct {
+	mu      sync.Mutex
+	healthy bool
+	events  []AuditEvent
+}
+
+func (s *flakySink) Write(_ context.Context, event AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.healthy {
+		return errors.New("database unavailable")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *flakySink) stored() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+func TestService_DurableQueueKeepsEventsWhileSinkFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.queue")
+	q, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &flakySink{}
+	svc := NewService(sink, nil, nil, nil, 1)
+	svc.SetDurableQueue(q)
+
+	// More events than the in-memory queue holds, none of them storable yet.
+	for range 5 {
+		svc.Log(AuditEvent{Action: ActionUpdated, ResourceType: ResourceTypeFlag, ResourceID: "banner"})
+	}
+	time.Sleep(50 * time.Millisecond)
+	svc.Close()
+	q.Close()
+	if sink.stored() != 0 {
+		t.Fatalf("sink stored %d events while failing", sink.stored())
+	}
+
+	// After a restart the queued events are written once the sink recovers.
+	sink.mu.Lock()
+	sink.healthy = true
+	sink.mu.Unlock()
+	q, err = OpenFileQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	svc = NewService(sink, nil, nil, nil, 1)
+	defer svc.Close()
+	svc.SetDurableQueue(q)
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.stored() < 5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sink.stored() != 5 {
+		t.Errorf("sink stored %d events, want 5", sink.stored())
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/TimurManjosov/goflagship/internal/worker"
 )
 
 // Action constants for audit logging
@@ -20,14 +23,35 @@ const (
 	ActionAuthFailed  = "auth_failed"
 	ActionEvaluated   = "evaluated"
 	ActionAccessed    = "accessed"
+
+	ActionProtectionOverridden = "protection_overridden"
+	ActionStepUpVerified       = "step_up_verified"
+	ActionAuthLockedOut        = "auth_locked_out"
+	ActionCircuitOpened        = "circuit_opened"
+	ActionRestored             = "restored"
+	ActionGuardrailTripped     = "guardrail_tripped"
+	ActionIdleDetected         = "idle_detected"
 )
 
 // ResourceType constants for audit logging
 const (
-	ResourceTypeFlag    = "flag"
-	ResourceTypeProject = "project"
-	ResourceTypeAPIKey  = "api_key"
-	ResourceTypeSystem  = "system"
+	ResourceTypeFlag        = "flag"
+	ResourceTypeProject     = "project"
+	ResourceTypeAPIKey      = "api_key"
+	ResourceTypeSDKKey      = "sdk_key"
+	ResourceTypeEnvironment = "environment"
+	ResourceTypeSystem      = "system"
+	ResourceTypeEvaluation  = "evaluation"
+	ResourceTypeFlagComment = "flag_comment"
+	ResourceTypeWebhook     = "webhook"
+
+	ResourceTypeContextAttribute   = "context_attribute"
+	ResourceTypeContextPreset      = "context_preset"
+	ResourceTypeReadinessChecklist = "readiness_checklist"
+	ResourceTypeChangeSet          = "change_set"
+	ResourceTypeFlagShadow         = "flag_shadow"
+	ResourceTypeFlagGuardrail      = "flag_guardrail"
+	ResourceTypeFlagOverride       = "flag_override"
 )
 
 // Status constants for audit logging
@@ -38,9 +62,11 @@ const (
 
 // ActorKind constants for audit logging
 const (
-	ActorKindAPIKey = "api_key"
-	ActorKindUser   = "user"
-	ActorKindSystem = "system"
+	ActorKindAPIKey    = "api_key"
+	ActorKindUser      = "user"
+	ActorKindSystem    = "system"
+	ActorKindSDKKey    = "sdk_key"
+	ActorKindAnonymous = "anonymous"
 )
 
 // Clock interface for testable time operations
@@ -115,10 +141,12 @@ func (r *DefaultRedactor) Redact(data map[string]any) map[string]any {
 
 // Actor represents who performed the action
 type Actor struct {
-	Kind    string  `json:"kind"`    // api_key, user, system
+	Kind    string  `json:"kind"`    // api_key, user, system, sdk_key, anonymous
 	ID      *string `json:"id,omitempty"`
 	Email   *string `json:"email,omitempty"`
-	Display string  `json:"display"` // Human-readable identifier
+	Display string  `json:"display"`        // Human-readable identifier
+	Name    string  `json:"name,omitempty"` // API key name at event time
+	Role    string  `json:"role,omitempty"` // Role at event time
 }
 
 // Source represents request metadata
@@ -129,8 +157,10 @@ type Source struct {
 
 // AuditEvent represents a canonical audit event
 type AuditEvent struct {
+	ID           string         `json:"id"` // UUID of the audit_logs row; webhook deliveries reference it
 	OccurredAt   time.Time      `json:"occurred_at"`
 	RequestID    string         `json:"request_id"`
+	TraceID      string         `json:"trace_id,omitempty"` // W3C trace ID of the request, if sent
 	Actor        Actor          `json:"actor"`
 	Source       Source         `json:"source"`
 	Action       string         `json:"action"`        // created, updated, deleted, etc.
@@ -143,6 +173,8 @@ type AuditEvent struct {
 	Changes      map[string]any `json:"changes,omitempty"`
 	Status       string         `json:"status"` // success, failure
 	ErrorMessage *string        `json:"error_message,omitempty"`
+	Note         *string        `json:"note,omitempty"` // Free-text justification, e.g. for forced overrides
+	Tenant       string         `json:"tenant,omitempty"` // Tenant of the actor; empty means auth.DefaultTenant
 }
 
 // AuditSink defines the interface for persisting audit events
@@ -159,8 +191,18 @@ type Service struct {
 	queue    chan AuditEvent
 	stopCh   chan struct{}
 	closed   int32 // atomic flag to prevent double-close
+
+	durable atomic.Pointer[FileQueue] // see SetDurableQueue
+	wake    chan struct{}             // signals the durable queue drainer
+
+	health        *worker.Worker // supervises worker, see Workers
+	durableHealth *worker.Worker // supervises drainDurable
 }
 
+// durableRetryInterval is how often the durable queue drainer retries after
+// the sink failed.
+const durableRetryInterval = 5 * time.Second
+
 // NewService creates a new audit service
 func NewService(sink AuditSink, clock Clock, idgen IDGenerator, redactor Redactor, queueSize int) *Service {
 	if clock == nil {
@@ -180,14 +222,27 @@ func NewService(sink AuditSink, clock Clock, idgen IDGenerator, redactor Redacto
 		redactor: redactor,
 		queue:    make(chan AuditEvent, queueSize),
 		stopCh:   make(chan struct{}),
+		wake:     make(chan struct{}, 1),
 	}
+	s.health = worker.New("audit", func() int { return len(s.queue) })
+	s.durableHealth = worker.New("audit_durable_queue", nil)
 	
-	// Start background worker
-	go s.worker()
+	// Start background worker, restarted if a sink panics
+	go s.health.Run(context.Background(), func(context.Context) { s.worker() })
 	
 	return s
 }
 
+// Workers returns the supervised goroutines of the service: the worker
+// writing queued events and, once SetDurableQueue was called, the durable
+// queue drainer.
+func (s *Service) Workers() []*worker.Worker {
+	if s.durable.Load() == nil {
+		return []*worker.Worker{s.health}
+	}
+	return []*worker.Worker{s.health, s.durableHealth}
+}
+
 // worker processes audit events in the background
 func (s *Service) worker() {
 	for {
@@ -197,10 +252,11 @@ func (s *Service) worker() {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			if err := s.sink.Write(ctx, event); err != nil {
 				// Log error but don't fail - audit logging must be non-blocking
-				log.Printf("[audit] failed to persist event: action=%s resource=%s/%s actor=%s request_id=%s error=%v",
-					event.Action, event.ResourceType, event.ResourceID, event.Actor.Display, event.RequestID, err)
+				log.Printf("[audit] failed to persist event: action=%s resource=%s/%s actor=%s request_id=%s trace_id=%s error=%v",
+					event.Action, event.ResourceType, event.ResourceID, event.Actor.Display, event.RequestID, event.TraceID, err)
 			}
 			cancel()
+			s.health.MarkProcessed()
 		case <-s.stopCh:
 			// Drain remaining events before stopping
 			for len(s.queue) > 0 {
@@ -214,6 +270,59 @@ func (s *Service) worker() {
 	}
 }
 
+// SetDurableQueue makes Log append events to q instead of the in-memory
+// queue, so they are kept until the sink has stored them instead of being
+// dropped when the queue is full, the sink fails or the process exits. Events
+// already in q are written first. Call it once, before logging starts.
+func (s *Service) SetDurableQueue(q *FileQueue) {
+	s.durable.Store(q)
+	go s.durableHealth.Run(context.Background(), func(context.Context) { s.drainDurable(q) })
+}
+
+// drainDurable writes the events of q to the sink whenever new ones are
+// appended, retrying every durableRetryInterval while the sink fails.
+func (s *Service) drainDurable(q *FileQueue) {
+	ticker := time.NewTicker(durableRetryInterval)
+	defer ticker.Stop()
+	for {
+		s.writeDurable(q)
+		select {
+		case <-s.stopCh:
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeDurable writes events from q to the sink, in order, until q is empty
+// or a write fails.
+func (s *Service) writeDurable(q *FileQueue) {
+	for {
+		event, next, ok, err := q.Next()
+		if err != nil {
+			log.Printf("[audit] failed to read durable queue: %v", err)
+			return
+		}
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = s.sink.Write(ctx, event)
+		cancel()
+		if err != nil {
+			log.Printf("[audit] failed to persist queued event, retrying in %s: action=%s resource=%s/%s request_id=%s error=%v",
+				durableRetryInterval, event.Action, event.ResourceType, event.ResourceID, event.RequestID, err)
+			return
+		}
+		if err := q.Ack(next); err != nil {
+			log.Printf("[audit] failed to advance durable queue: %v", err)
+			return
+		}
+		s.durableHealth.MarkProcessed()
+	}
+}
+
 // Close gracefully shuts down the audit service.
 // It signals the background worker to stop and drains any remaining events in the queue.
 // After Close is called, no new events should be logged.
@@ -238,6 +347,11 @@ func (s *Service) Log(event AuditEvent) {
 		event.OccurredAt = s.clock.Now()
 	}
 	
+	// Ensure id is set
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+
 	// Ensure request_id is set
 	if event.RequestID == "" {
 		event.RequestID = s.idgen.Generate()
@@ -251,6 +365,19 @@ func (s *Service) Log(event AuditEvent) {
 		event.AfterState = s.redactor.Redact(event.AfterState)
 	}
 	
+	if q := s.durable.Load(); q != nil {
+		err := q.Append(event)
+		if err == nil {
+			select {
+			case s.wake <- struct{}{}:
+			default: // the drainer is already signalled
+			}
+			return
+		}
+		log.Printf("[audit] CRITICAL: durable queue write failed, falling back to the in-memory queue: action=%s resource=%s/%s error=%v",
+			event.Action, event.ResourceType, event.ResourceID, err)
+	}
+
 	// Try to queue, drop if full
 	select {
 	case s.queue <- event:
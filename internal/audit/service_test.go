@@ -2,8 +2,13 @@ package audit
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // MockSink is a test implementation of AuditSink
@@ -194,6 +199,10 @@ func TestService_Log(t *testing.T) {
 	if event.RequestID != "test-req-123" {
 		t.Errorf("expected request ID test-req-123, got %s", event.RequestID)
 	}
+
+	if _, err := parseUUIDString(event.ID); err != nil {
+		t.Errorf("expected a UUID event ID, got %q", event.ID)
+	}
 	
 	if !event.OccurredAt.Equal(clock.now) {
 		t.Errorf("expected occurred_at %v, got %v", clock.now, event.OccurredAt)
@@ -238,3 +247,33 @@ func TestService_Redaction(t *testing.T) {
 		t.Errorf("before_state name should not be redacted: %v", event.BeforeState["name"])
 	}
 }
+
+func TestNewEventBuilder_ActorFromContext(t *testing.T) {
+	id := pgtype.UUID{Bytes: [16]byte{0x12, 0x34, 0x56, 0x78, 1}, Valid: true}
+	ctx := context.WithValue(context.Background(), auth.ContextKeyAPIKey, id)
+	ctx = context.WithValue(ctx, auth.ContextKeyAPIKeyName, "ci-deployer")
+	ctx = context.WithValue(ctx, auth.ContextKeyRole, auth.RoleAdmin)
+	r := httptest.NewRequest(http.MethodPost, "/v1/flags", nil).WithContext(ctx)
+
+	actor := NewEventBuilder(r).Build().Actor
+	if actor.Kind != ActorKindAPIKey || actor.Display != "api_key:12345678" {
+		t.Errorf("unexpected actor %+v", actor)
+	}
+	if actor.Name != "ci-deployer" || actor.Role != "admin" {
+		t.Errorf("expected the key name and role at event time, got %+v", actor)
+	}
+}
+
+func TestEventBuilder_ID(t *testing.T) {
+	builder := NewSystemEventBuilder().ForResource(ResourceTypeFlag, "checkout")
+	event := builder.Build()
+	if _, err := parseUUIDString(event.ID); err != nil {
+		t.Fatalf("expected a UUID event ID, got %q", event.ID)
+	}
+	if again := builder.Build(); again.ID != event.ID {
+		t.Errorf("expected building twice to keep the ID, got %q and %q", event.ID, again.ID)
+	}
+	if other := NewSystemEventBuilder().Build(); other.ID == event.ID {
+		t.Error("expected events to get distinct IDs")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/TimurManjosov/goflagship/internal/auth"
 	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -27,6 +28,13 @@ func (s *PostgresSink) Write(ctx context.Context, event AuditEvent) error {
 		IpAddress: event.Source.IPAddress,
 		UserAgent: event.Source.UserAgent,
 		Status:    httpStatusFromString(event.Status),
+		Tenant:    event.Tenant,
+	}
+	if params.Tenant == "" {
+		params.Tenant = auth.DefaultTenant
+	}
+	if id, err := parseUUIDString(event.ID); err == nil {
+		params.ID = id // otherwise the database generates one
 	}
 	
 	// Set optional text fields
@@ -94,6 +102,12 @@ func (s *PostgresSink) Write(ctx context.Context, event AuditEvent) error {
 	details := map[string]any{
 		"actor": event.Actor,
 	}
+	if event.Note != nil {
+		details["note"] = *event.Note
+	}
+	if event.TraceID != "" {
+		details["trace_id"] = event.TraceID
+	}
 	if b, err := json.Marshal(details); err == nil {
 		params.Details = b
 	} else {
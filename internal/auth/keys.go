@@ -2,8 +2,10 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
@@ -13,6 +15,10 @@ import (
 const (
 	// KeyPrefix is the prefix for all generated API keys
 	KeyPrefix = "fsk_"
+	// SDKKeyPrefix is the prefix for per-environment SDK keys
+	SDKKeyPrefix = "sdk_"
+	// SetupTokenPrefix is the prefix for one-time first-run setup tokens
+	SetupTokenPrefix = "setup_"
 	// KeyLength is the length of the random part of the key (32 bytes = 256 bits)
 	KeyLength = 32
 	// BCryptCost is the cost factor for bcrypt hashing
@@ -43,6 +49,38 @@ func GenerateAPIKey() (string, error) {
 	return KeyPrefix + encoded, nil
 }
 
+// GenerateSDKKey generates a new per-environment SDK key
+func GenerateSDKKey() (string, error) {
+	randomBytes := make([]byte, KeyLength)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return SDKKeyPrefix + base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// GenerateSetupToken generates a one-time token for the first-run setup
+func GenerateSetupToken() (string, error) {
+	randomBytes := make([]byte, KeyLength)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return SetupTokenPrefix + base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// IsSDKKey reports whether token looks like an SDK key (as opposed to an API key)
+func IsSDKKey(token string) bool {
+	return strings.HasPrefix(token, SDKKeyPrefix)
+}
+
+// HashSDKKey returns the hex SHA-256 of an SDK key.
+// SDK keys are presented on every evaluation request, so unlike API keys they
+// are hashed with a fast, deterministic hash that can be looked up directly.
+// That is safe because the keys carry 256 bits of randomness.
+func HashSDKKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
 // HashAPIKey hashes an API key using bcrypt
 func HashAPIKey(key string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(key), BCryptCost)
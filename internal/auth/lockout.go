@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Lockout protects authentication against brute force. It counts failed
+// attempts per client IP and per token prefix; after Threshold failures the
+// IP or prefix is locked out, first for BaseDelay, and each further lockout
+// doubles the delay up to MaxDelay. A successful authentication clears the
+// counts. State is kept in memory, per server instance.
+type Lockout struct {
+	cfg LockoutConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[LockoutSubject]*lockoutEntry
+	lastSweep time.Time
+}
+
+// LockoutConfig configures a Lockout.
+type LockoutConfig struct {
+	Threshold int           // failed attempts that trigger a lockout
+	BaseDelay time.Duration // duration of the first lockout
+	MaxDelay  time.Duration // cap of the doubling lockout duration
+}
+
+// LockoutSubject is what failed attempts are counted against.
+type LockoutSubject struct {
+	Scope string // "ip" or "key"
+	Value string // the client IP or token prefix
+}
+
+// LockoutEvent describes a lockout that was just triggered.
+type LockoutEvent struct {
+	Subject   LockoutSubject
+	Failures  int // failed attempts that triggered the lockout
+	Lockouts  int // consecutive lockouts of Subject, including this one
+	LockedFor time.Duration
+}
+
+type lockoutEntry struct {
+	failures    int
+	lockouts    int
+	lockedUntil time.Time
+	lastSeen    time.Time // last failure or end of the last lockout
+}
+
+// tokenPrefixLen is how much of a token identifies it for lockout purposes:
+// the "fsk_" prefix and the first characters of the key.
+const tokenPrefixLen = 12
+
+// NewLockout creates a Lockout. Zero delays default to one minute and one
+// hour respectively.
+func NewLockout(cfg LockoutConfig) *Lockout {
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = time.Minute
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = time.Hour
+	}
+	cfg.MaxDelay = max(cfg.MaxDelay, cfg.BaseDelay)
+	return &Lockout{cfg: cfg, now: time.Now, entries: map[LockoutSubject]*lockoutEntry{}}
+}
+
+// lockoutSubjects returns what a request's failures count against: its
+// client IP and, when a token was sent, the token's prefix.
+func lockoutSubjects(r *http.Request, token string) []LockoutSubject {
+	subjects := []LockoutSubject{{Scope: "ip", Value: ClientIP(r)}}
+	if token != "" {
+		if len(token) > tokenPrefixLen {
+			token = token[:tokenPrefixLen]
+		}
+		subjects = append(subjects, LockoutSubject{Scope: "key", Value: token})
+	}
+	return subjects
+}
+
+// ClientIP returns the request's client address without the port. Behind a
+// proxy it relies on middleware.RealIP having rewritten RemoteAddr.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// Locked reports whether any of subjects is locked out, and for how long.
+func (l *Lockout) Locked(subjects []LockoutSubject) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var retryAfter time.Duration
+	for _, s := range subjects {
+		if e, ok := l.entries[s]; ok && now.Before(e.lockedUntil) {
+			retryAfter = max(retryAfter, e.lockedUntil.Sub(now))
+		}
+	}
+	return retryAfter, retryAfter > 0
+}
+
+// Fail records a failed attempt for each of subjects and returns the
+// lockouts it triggered.
+func (l *Lockout) Fail(subjects []LockoutSubject) []LockoutEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	var events []LockoutEvent
+	for _, s := range subjects {
+		e, ok := l.entries[s]
+		if !ok || l.idle(e, now) {
+			e = &lockoutEntry{}
+			l.entries[s] = e
+		}
+		e.failures++
+		e.lastSeen = now
+		if e.failures < l.cfg.Threshold {
+			continue
+		}
+
+		e.lockouts++
+		delay := l.cfg.BaseDelay
+		for i := 1; i < e.lockouts && delay < l.cfg.MaxDelay; i++ {
+			delay *= 2
+		}
+		delay = min(delay, l.cfg.MaxDelay)
+		e.lockedUntil = now.Add(delay)
+		e.lastSeen = e.lockedUntil
+		events = append(events, LockoutEvent{Subject: s, Failures: e.failures, Lockouts: e.lockouts, LockedFor: delay})
+		e.failures = 0
+	}
+	return events
+}
+
+// Succeed clears the failure counts of subjects.
+func (l *Lockout) Succeed(subjects []LockoutSubject) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range subjects {
+		delete(l.entries, s)
+	}
+}
+
+// idle reports whether e has been quiet for MaxDelay, after which its
+// failures and lockouts are forgotten.
+func (l *Lockout) idle(e *lockoutEntry, now time.Time) bool {
+	return now.Sub(e.lastSeen) > l.cfg.MaxDelay
+}
+
+// sweep drops idle entries, at most once a minute. l.mu must be held.
+func (l *Lockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for s, e := range l.entries {
+		if l.idle(e, now) {
+			delete(l.entries, s)
+		}
+	}
+}
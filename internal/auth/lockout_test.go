@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLockout(threshold int) (*Lockout, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	l := NewLockout(LockoutConfig{Threshold: threshold, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute})
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLockout_ExponentialDelay(t *testing.T) {
+	l, now := newTestLockout(3)
+	ip := []LockoutSubject{{Scope: "ip", Value: "10.0.0.1"}}
+
+	for i := 0; i < 2; i++ {
+		if events := l.Fail(ip); len(events) != 0 {
+			t.Fatalf("failure %d: unexpected lockout %+v", i+1, events)
+		}
+	}
+	if _, locked := l.Locked(ip); locked {
+		t.Fatal("should not be locked below the threshold")
+	}
+
+	// Lockouts double: 1m, 2m, 4m, then capped at 5m.
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		var events []LockoutEvent
+		for len(events) == 0 {
+			events = l.Fail(ip)
+		}
+		if events[0].LockedFor != want || events[0].Lockouts != i+1 || events[0].Subject != ip[0] {
+			t.Fatalf("lockout %d: got %+v, want %s", i+1, events[0], want)
+		}
+		if retry, locked := l.Locked(ip); !locked || retry != want {
+			t.Fatalf("lockout %d: Locked = %s, %v", i+1, retry, locked)
+		}
+		*now = now.Add(want)
+		if _, locked := l.Locked(ip); locked {
+			t.Fatalf("lockout %d should have expired", i+1)
+		}
+	}
+
+	// After a quiet period the subject starts over.
+	*now = now.Add(6 * time.Minute)
+	l.Fail(ip)
+	l.Fail(ip)
+	if events := l.Fail(ip); len(events) != 1 || events[0].LockedFor != time.Minute {
+		t.Fatalf("expected a fresh 1m lockout, got %+v", events)
+	}
+}
+
+func TestLockout_SucceedClearsCount(t *testing.T) {
+	l, _ := newTestLockout(2)
+	key := []LockoutSubject{{Scope: "key", Value: "fsk_abcdefgh"}}
+
+	l.Fail(key)
+	l.Succeed(key)
+	if events := l.Fail(key); len(events) != 0 {
+		t.Fatalf("count should have been cleared, got %+v", events)
+	}
+}
+
+func TestRequireAuth_Lockout(t *testing.T) {
+	a := NewAuthenticator(nil, "the-admin-key")
+	defer a.Close()
+	var events []LockoutEvent
+	a.SetLockout(NewLockout(LockoutConfig{Threshold: 3, BaseDelay: time.Minute}), func(_ *http.Request, e LockoutEvent) {
+		events = append(events, e)
+	})
+	handler := a.RequireAuth(RoleReadonly)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(ip, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Missing tokens are not guessing attempts.
+	for i := 0; i < 5; i++ {
+		do("10.0.0.1", "")
+	}
+	for i, token := range []string{"wrong-1", "wrong-2", "wrong-3"} {
+		if rr := do("10.0.0.1", token); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i+1, rr.Code)
+		}
+	}
+	if len(events) != 1 || events[0].Subject != (LockoutSubject{Scope: "ip", Value: "10.0.0.1"}) {
+		t.Fatalf("expected one ip lockout, got %+v", events)
+	}
+
+	// Locked out, even with the right key.
+	rr := do("10.0.0.1", "the-admin-key")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected 429 with Retry-After 60, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if rr := do("10.0.0.2", "the-admin-key"); rr.Code != http.StatusOK {
+		t.Fatalf("other IPs should not be affected, got %d", rr.Code)
+	}
+}
@@ -2,11 +2,14 @@ package auth
 
 import (
 	"context"
+	"math"
 	"net/http"
+	"strconv"
 	"sync/atomic"
 	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -18,6 +21,8 @@ const (
 	ContextKeyAPIKey contextKey = "api_key_id"
 	// ContextKeyRole is the context key for storing the user role
 	ContextKeyRole contextKey = "role"
+	// ContextKeyAPIKeyName is the context key for storing the API key name
+	ContextKeyAPIKeyName contextKey = "api_key_name"
 )
 
 // KeyStore defines the interface for API key storage operations
@@ -34,18 +39,21 @@ type lastUsedUpdate struct {
 // Authenticator handles authentication for API requests
 type Authenticator struct {
 	keyStore       KeyStore
-	legacyAdminKey string // For backward compatibility
+	legacyAdminKey atomic.Value // string; for backward compatibility, see SetLegacyAdminKey
 	updateChan     chan lastUsedUpdate
 	closed         int32 // atomic flag to prevent double-close
+
+	lockout   *Lockout                          // optional, see SetLockout
+	onLockout func(*http.Request, LockoutEvent) // optional, see SetLockout
 }
 
 // NewAuthenticator creates a new Authenticator with a background worker
 func NewAuthenticator(keyStore KeyStore, legacyAdminKey string) *Authenticator {
 	auth := &Authenticator{
-		keyStore:       keyStore,
-		legacyAdminKey: legacyAdminKey,
-		updateChan:     make(chan lastUsedUpdate, 100), // Buffered channel to prevent blocking
+		keyStore:   keyStore,
+		updateChan: make(chan lastUsedUpdate, 100), // Buffered channel to prevent blocking
 	}
+	auth.legacyAdminKey.Store(legacyAdminKey)
 
 	// Start background worker for updating last_used_at timestamps
 	go auth.lastUsedWorker()
@@ -53,6 +61,28 @@ func NewAuthenticator(keyStore KeyStore, legacyAdminKey string) *Authenticator {
 	return auth
 }
 
+// LegacyAdminKey returns the current legacy ADMIN_API_KEY ("" if disabled).
+func (a *Authenticator) LegacyAdminKey() string {
+	key, _ := a.legacyAdminKey.Load().(string)
+	return key
+}
+
+// SetLegacyAdminKey replaces the legacy ADMIN_API_KEY, e.g. after it was
+// rotated in a secrets backend. Safe for concurrent use with authentication.
+func (a *Authenticator) SetLegacyAdminKey(key string) {
+	a.legacyAdminKey.Store(key)
+}
+
+// SetLockout enables brute-force protection in RequireAuth: failed attempts
+// are counted per client IP and token prefix, and locked out subjects get
+// 429 responses until the lockout expires. onLockout, if set, is called for
+// every lockout triggered (e.g. to audit it). A nil Lockout disables it.
+// Call it before serving requests.
+func (a *Authenticator) SetLockout(l *Lockout, onLockout func(*http.Request, LockoutEvent)) {
+	a.lockout = l
+	a.onLockout = onLockout
+}
+
 // lastUsedWorker processes last_used_at updates in the background.
 // It runs until the updateChan is closed.
 func (a *Authenticator) lastUsedWorker() {
@@ -83,11 +113,30 @@ func (a *Authenticator) Close() error {
 	return nil
 }
 
+// Authentication failure messages, returned in AuthResult.Error.
+const (
+	errMissingToken = "missing bearer token"
+	errInvalidToken = "invalid token"
+	errUnavailable  = "authentication service unavailable"
+	errKeyExpired   = "api key expired"
+)
+
+// failureReasons are the auth_failures_total labels of the failure messages.
+var failureReasons = map[string]string{
+	errMissingToken: "missing_token",
+	errInvalidToken: "invalid_token",
+	errUnavailable:  "unavailable",
+	errKeyExpired:   "expired_token",
+}
+
 // AuthResult contains the result of an authentication attempt
 type AuthResult struct {
 	Authenticated bool
 	Role          Role
 	APIKeyID      pgtype.UUID
+	APIKeyName    string
+	Tenant        string
+	Project       string // "" if the key is not restricted to a project
 	Error         string
 }
 
@@ -99,15 +148,16 @@ func (a *Authenticator) Authenticate(ctx context.Context, authHeader string) Aut
 	if token == "" {
 		return AuthResult{
 			Authenticated: false,
-			Error:         "missing bearer token",
+			Error:         errMissingToken,
 		}
 	}
 
 	// First, try legacy admin key (for backward compatibility)
-	if a.legacyAdminKey != "" && VerifyAPIKeyConstantTime(token, a.legacyAdminKey) {
+	if legacyKey := a.LegacyAdminKey(); legacyKey != "" && VerifyAPIKeyConstantTime(token, legacyKey) {
 		return AuthResult{
 			Authenticated: true,
 			Role:          RoleSuperadmin,
+			Tenant:        DefaultTenant,
 		}
 	}
 
@@ -121,7 +171,7 @@ func (a *Authenticator) Authenticate(ctx context.Context, authHeader string) Aut
 	if a.keyStore == nil {
 		return AuthResult{
 			Authenticated: false,
-			Error:         "invalid token",
+			Error:         errInvalidToken,
 		}
 	}
 
@@ -129,7 +179,7 @@ func (a *Authenticator) Authenticate(ctx context.Context, authHeader string) Aut
 	if err != nil {
 		return AuthResult{
 			Authenticated: false,
-			Error:         "authentication service unavailable",
+			Error:         errUnavailable,
 		}
 	}
 
@@ -144,7 +194,7 @@ func (a *Authenticator) Authenticate(ctx context.Context, authHeader string) Aut
 	if apiKey == nil {
 		return AuthResult{
 			Authenticated: false,
-			Error:         "invalid token",
+			Error:         errInvalidToken,
 		}
 	}
 
@@ -154,7 +204,7 @@ func (a *Authenticator) Authenticate(ctx context.Context, authHeader string) Aut
 		if time.Now().After(expiresAt) {
 			return AuthResult{
 				Authenticated: false,
-				Error:         "api key expired",
+				Error:         errKeyExpired,
 			}
 		}
 	}
@@ -169,10 +219,17 @@ func (a *Authenticator) Authenticate(ctx context.Context, authHeader string) Aut
 		}
 	}
 
+	tenant := apiKey.Tenant
+	if tenant == "" {
+		tenant = DefaultTenant
+	}
 	return AuthResult{
 		Authenticated: true,
 		Role:          Role(apiKey.Role),
 		APIKeyID:      apiKey.ID,
+		APIKeyName:    apiKey.Name,
+		Tenant:        tenant,
+		Project:       apiKey.Project.String,
 	}
 }
 
@@ -181,12 +238,31 @@ func (a *Authenticator) RequireAuth(requiredRole Role) func(http.Handler) http.H
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
+
+			var subjects []LockoutSubject
+			if a.lockout != nil {
+				subjects = lockoutSubjects(r, ExtractBearerToken(authHeader))
+				if retryAfter, locked := a.lockout.Locked(subjects); locked {
+					telemetry.AuthFailures.WithLabelValues("locked_out").Inc()
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+					http.Error(w, "too many failed authentication attempts", http.StatusTooManyRequests)
+					return
+				}
+			}
+
 			result := a.Authenticate(r.Context(), authHeader)
 
 			if !result.Authenticated {
+				telemetry.AuthFailures.WithLabelValues(failureReasons[result.Error]).Inc()
+				a.recordFailure(r, subjects, result.Error)
 				http.Error(w, result.Error, http.StatusUnauthorized)
 				return
 			}
+			if a.lockout != nil {
+				// Only the token's count is cleared: a valid key must not
+				// reset the count of an IP trying other tokens.
+				a.lockout.Succeed(subjects[1:])
+			}
 
 			// Check if user has required permission
 			if !HasPermission(result.Role, requiredRole) {
@@ -196,8 +272,13 @@ func (a *Authenticator) RequireAuth(requiredRole Role) func(http.Handler) http.H
 
 			// Add auth info to context
 			ctx := context.WithValue(r.Context(), ContextKeyRole, result.Role)
+			ctx = context.WithValue(ctx, ContextKeyTenant, result.Tenant)
+			if result.Project != "" {
+				ctx = context.WithValue(ctx, ContextKeyProject, result.Project)
+			}
 			if result.APIKeyID.Valid {
 				ctx = context.WithValue(ctx, ContextKeyAPIKey, result.APIKeyID)
+				ctx = context.WithValue(ctx, ContextKeyAPIKeyName, result.APIKeyName)
 			}
 
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -205,6 +286,20 @@ func (a *Authenticator) RequireAuth(requiredRole Role) func(http.Handler) http.H
 	}
 }
 
+// recordFailure counts a failed attempt towards a lockout. Missing tokens and
+// backend outages are not guessing attempts and are not counted.
+func (a *Authenticator) recordFailure(r *http.Request, subjects []LockoutSubject, reason string) {
+	if a.lockout == nil || (reason != errInvalidToken && reason != errKeyExpired) {
+		return
+	}
+	for _, event := range a.lockout.Fail(subjects) {
+		telemetry.AuthLockouts.WithLabelValues(event.Subject.Scope).Inc()
+		if a.onLockout != nil {
+			a.onLockout(r, event)
+		}
+	}
+}
+
 // GetRoleFromContext extracts the role from the request context
 func GetRoleFromContext(ctx context.Context) (Role, bool) {
 	role, ok := ctx.Value(ContextKeyRole).(Role)
@@ -216,3 +311,9 @@ func GetAPIKeyIDFromContext(ctx context.Context) (pgtype.UUID, bool) {
 	id, ok := ctx.Value(ContextKeyAPIKey).(pgtype.UUID)
 	return id, ok
 }
+
+// GetAPIKeyNameFromContext extracts the API key name from the request context
+func GetAPIKeyNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(ContextKeyAPIKeyName).(string)
+	return name, ok
+}
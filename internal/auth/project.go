@@ -0,0 +1,15 @@
+package auth
+
+import "context"
+
+// ContextKeyProject is the context key for storing the project the caller's
+// API key is restricted to.
+const ContextKeyProject contextKey = "project"
+
+// GetProjectFromContext returns the project the caller's API key is
+// restricted to, or "" if the key may access every project (the legacy
+// ADMIN_API_KEY and keys created without "project").
+func GetProjectFromContext(ctx context.Context) string {
+	project, _ := ctx.Value(ContextKeyProject).(string)
+	return project
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// DefaultTenant is the operator tenant. The legacy ADMIN_API_KEY and every key
+// created before tenancy belong to it, and it is not restricted to any
+// environments, so single-tenant installations behave exactly as before.
+const DefaultTenant = "default"
+
+// ContextKeyTenant is the context key for storing the caller's tenant
+const ContextKeyTenant contextKey = "tenant"
+
+// tenantPattern keeps tenant names short and free of "-", so the
+// "<tenant>-<env>" environment prefix is unambiguous.
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,31}$`)
+
+// IsValidTenant reports whether name is a valid tenant name: 1-32 lowercase
+// letters, digits or underscores, starting with a letter or digit.
+func IsValidTenant(name string) bool {
+	return tenantPattern.MatchString(name)
+}
+
+// TenantOwnsEnv reports whether tenant may access env. The default tenant
+// owns every environment; any other tenant owns the environment named after
+// it and those prefixed with "<tenant>-" (e.g. "acme" owns "acme-prod").
+func TenantOwnsEnv(tenant, env string) bool {
+	if tenant == "" || tenant == DefaultTenant {
+		return true
+	}
+	return env == tenant || strings.HasPrefix(env, tenant+"-")
+}
+
+// GetTenantFromContext returns the caller's tenant, or DefaultTenant if the
+// request was not authenticated with a tenant.
+func GetTenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(ContextKeyTenant).(string); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTenantOwnsEnv(t *testing.T) {
+	tests := []struct {
+		tenant, env string
+		want        bool
+	}{
+		{DefaultTenant, "prod", true},
+		{"", "acme-prod", true},
+		{"acme", "acme", true},
+		{"acme", "acme-prod", true},
+		{"acme", "prod", false},
+		{"acme", "acmecorp-prod", false},
+		{"acme", "other-acme-prod", false},
+	}
+	for _, tt := range tests {
+		if got := TenantOwnsEnv(tt.tenant, tt.env); got != tt.want {
+			t.Errorf("TenantOwnsEnv(%q, %q) = %v, want %v", tt.tenant, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidTenant(t *testing.T) {
+	for _, name := range []string{"acme", "team_42", "a"} {
+		if !IsValidTenant(name) {
+			t.Errorf("IsValidTenant(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"", "Acme", "acme-prod", "_acme", "a23456789012345678901234567890123"} {
+		if IsValidTenant(name) {
+			t.Errorf("IsValidTenant(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestGetTenantFromContext(t *testing.T) {
+	if got := GetTenantFromContext(context.Background()); got != DefaultTenant {
+		t.Errorf("empty context: got %q, want %q", got, DefaultTenant)
+	}
+	ctx := context.WithValue(context.Background(), ContextKeyTenant, "acme")
+	if got := GetTenantFromContext(ctx); got != "acme" {
+		t.Errorf("got %q, want acme", got)
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors.
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	totp, err := NewTOTP(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("NewTOTP: %v", err)
+	}
+	// The RFC lists 8-digit codes; 6-digit codes are their last 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if got := totp.Code(time.Unix(tt.unix, 0)); got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTP_VerifyRejectsReplayAndStaleCodes(t *testing.T) {
+	totp, _ := NewTOTP(rfc6238Secret)
+	now := time.Unix(1234567890, 0)
+	totp.now = func() time.Time { return now }
+
+	if !totp.Verify(totp.Code(now)) {
+		t.Fatal("current code should verify")
+	}
+	if totp.Verify(totp.Code(now)) {
+		t.Error("a code must not be accepted twice")
+	}
+	if !totp.Verify(totp.Code(now.Add(-30 * time.Second))) {
+		t.Error("previous step should be accepted for clock drift")
+	}
+	if totp.Verify(totp.Code(now.Add(-2 * time.Minute))) {
+		t.Error("stale code should be rejected")
+	}
+	if totp.Verify("abc") || totp.Verify("") {
+		t.Error("malformed codes should be rejected")
+	}
+}
+
+func TestNewTOTP_InvalidSecret(t *testing.T) {
+	for _, secret := range []string{"not base32!", "GEZDGNBV"} {
+		if _, err := NewTOTP(secret); err == nil {
+			t.Errorf("NewTOTP(%q): expected error", secret)
+		}
+	}
+	if _, err := NewTOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq"); err != nil {
+		t.Errorf("lowercase spaced secret: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/TimurManjosov/goflagship/internal/client"
 	"github.com/TimurManjosov/goflagship/internal/store"
 	"github.com/olekukonko/tablewriter"
 	"gopkg.in/yaml.v3"
@@ -47,6 +48,25 @@ func PrintFlag(flag *store.Flag, format OutputFormat) error {
 	}
 }
 
+// PrintEvaluationResults outputs evaluated flags in the specified format
+func PrintEvaluationResults(results []client.EvaluationResult, format OutputFormat) error {
+	switch format {
+	case FormatJSON:
+		return printJSON(map[string][]client.EvaluationResult{"results": results})
+	case FormatYAML:
+		return printYAML(results)
+	case FormatTable:
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header("Key", "Enabled", "Variant", "Reason")
+		for _, result := range results {
+			table.Append(result.Key, fmt.Sprintf("%t", result.Enabled), result.Variant, result.Reason)
+		}
+		return table.Render()
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
 func printJSON(data interface{}) error {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
@@ -4,183 +4,366 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/TimurManjosov/goflagship/internal/rules"
 	"github.com/TimurManjosov/goflagship/internal/store"
 )
 
+// ErrFlagNotFound is returned by GetFlag when the snapshot has no such flag.
+var ErrFlagNotFound = errors.New("flag not found")
+
 // Client is an HTTP client for the flagship API
 type Client struct {
 	BaseURL    string
 	APIKey     string
 	HTTPClient *http.Client
+
+	// Timeout bounds each request attempt (default 30s). The caller's context
+	// bounds the whole call, including retries.
+	Timeout time.Duration
+	// Retry controls how failed idempotent calls are retried.
+	Retry RetryPolicy
 }
 
-// NewClient creates a new API client
-func NewClient(baseURL, apiKey string) *Client {
-	return &Client{
-		BaseURL: baseURL,
-		APIKey:  apiKey,
-		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+// RetryPolicy configures retries with exponential backoff and full jitter.
+// Only idempotent calls are retried, and only on network errors, timeouts,
+// 429 and 502-504 responses. A Retry-After header overrides the backoff.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first (default 3; 1 disables retries)
+	InitialBackoff time.Duration // Backoff before the first retry (default 200ms)
+	MaxBackoff     time.Duration // Upper bound for a single backoff (default 5s)
 }
 
-// CreateFlag creates or updates a flag
-func (c *Client) CreateFlag(ctx context.Context, params store.UpsertParams) error {
-	body, err := json.Marshal(params)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
+// APIError is a non-2xx response from the API. Code, Message, Fields and
+// RequestID are taken from the server's JSON error body when present.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Fields     map[string]string
+	RequestID  string
+	RetryAfter time.Duration // Parsed Retry-After header, 0 if absent
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/v1/flags", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error (status %d", e.StatusCode)
+	if e.Code != "" {
+		msg += ", " + e.Code
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+	msg += ")"
+	if e.Message != "" {
+		msg += ": " + e.Message
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
 	}
-
-	return nil
+	sort.Strings(fields)
+	for _, field := range fields {
+		msg += fmt.Sprintf("; %s: %s", field, e.Fields[field])
+	}
+	return msg
 }
 
-// GetFlag retrieves a single flag by key
-func (c *Client) GetFlag(ctx context.Context, key, env string) (*store.Flag, error) {
-	u, err := url.Parse(c.BaseURL + "/v1/flags/snapshot")
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
+// Temporary reports whether the request may succeed if retried.
+func (e *APIError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
 	}
+	return false
+}
 
-	q := u.Query()
-	q.Set("env", env)
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// NewClient creates a new API client
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{},
+		Timeout:    30 * time.Second,
+		Retry:      RetryPolicy{MaxAttempts: 3, InitialBackoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second},
 	}
+}
 
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+// flagRequest is the POST /v1/flags body. It mirrors store.UpsertParams but
+// uses the snake_case field name the API expects for targeting rules, and
+// only the fields the API accepts (it rejects unknown ones).
+type flagRequest struct {
+	Key            string            `json:"key"`
+	Description    string            `json:"description"`
+	Enabled        bool              `json:"enabled"`
+	Rollout        int32             `json:"rollout"`
+	Expression     *string           `json:"expression,omitempty"`
+	Config         map[string]any    `json:"config,omitempty"`
+	TargetingRules []rules.Rule      `json:"targeting_rules,omitempty"`
+	Variants       []store.Variant   `json:"variants,omitempty"`
+	Protected      bool              `json:"protected,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Owner          string            `json:"owner,omitempty"` // omitted fields keep the stored value
+	TicketURL      string            `json:"ticket_url,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+	Env            string            `json:"env"`
+}
 
-	resp, err := c.HTTPClient.Do(req)
+// CreateFlag creates or updates a flag. The API upserts by key and env, so
+// the call is idempotent and retried like reads.
+func (c *Client) CreateFlag(ctx context.Context, params store.UpsertParams) error {
+	body, err := json.Marshal(flagRequest{
+		Key:            params.Key,
+		Description:    params.Description,
+		Enabled:        params.Enabled,
+		Rollout:        params.Rollout,
+		Expression:     params.Expression,
+		Config:         params.Config,
+		TargetingRules: params.TargetingRules,
+		Variants:       params.Variants,
+		Protected:      params.Protected,
+		Tags:           params.Tags,
+		Owner:          params.Owner,
+		TicketURL:      params.TicketURL,
+		Metadata:       params.Metadata,
+		ExpiresAt:      params.ExpiresAt,
+		Env:            params.Env,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var result struct {
-		Flags []store.Flag `json:"flags"`
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
+	return c.do(ctx, http.MethodPost, "/v1/flags", nil, body, true, nil)
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// GetFlag retrieves a single flag by key
+func (c *Client) GetFlag(ctx context.Context, key, env string) (*store.Flag, error) {
+	flags, err := c.ListFlags(ctx, env)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find the flag by key
-	for _, flag := range result.Flags {
+	for _, flag := range flags {
 		if flag.Key == key {
 			return &flag, nil
 		}
 	}
 
-	return nil, fmt.Errorf("flag not found: %s", key)
+	return nil, fmt.Errorf("%w: %s", ErrFlagNotFound, key)
 }
 
 // ListFlags retrieves all flags for an environment
 func (c *Client) ListFlags(ctx context.Context, env string) ([]store.Flag, error) {
-	u, err := url.Parse(c.BaseURL + "/v1/flags/snapshot")
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	var result struct {
+		Flags []store.Flag `json:"flags"`
 	}
+	if err := c.do(ctx, http.MethodGet, "/v1/flags/snapshot", url.Values{"env": {env}}, nil, true, &result); err != nil {
+		return nil, err
+	}
+	return result.Flags, nil
+}
 
-	q := u.Query()
-	q.Set("env", env)
-	u.RawQuery = q.Encode()
+// UpdateFlag updates an existing flag
+func (c *Client) UpdateFlag(ctx context.Context, params store.UpsertParams) error {
+	return c.CreateFlag(ctx, params) // Same endpoint for create/update
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+// DeleteFlag deletes a flag
+func (c *Client) DeleteFlag(ctx context.Context, key, env string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/flags", url.Values{"key": {key}, "env": {env}}, nil, true, nil)
+}
 
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+// EvaluationContext is the user context of an Evaluate call.
+type EvaluationContext struct {
+	ID         string         `json:"id,omitempty"`
+	Email      string         `json:"email,omitempty"`
+	Country    string         `json:"country,omitempty"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+// EvaluationResult is one flag evaluated by Evaluate.
+type EvaluationResult struct {
+	Key     string `json:"key"`
+	Enabled bool   `json:"enabled"`
+	Value   any    `json:"value,omitempty"`
+	Variant string `json:"variant,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+// Evaluate evaluates flagKey, or every flag if flagKey is empty, for the
+// context preset named preset (if any) with evalCtx replacing its attributes.
+// Evaluation has no side effects, so it is retried like reads.
+func (c *Client) Evaluate(ctx context.Context, flagKey, preset string, evalCtx EvaluationContext) ([]EvaluationResult, error) {
+	body, err := json.Marshal(struct {
+		Context EvaluationContext `json:"context"`
+		FlagKey string            `json:"flagKey,omitempty"`
+		Preset  string            `json:"preset,omitempty"`
+	}{evalCtx, flagKey, preset})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
-
 	var result struct {
-		Flags []store.Flag `json:"flags"`
+		Results []EvaluationResult `json:"results"`
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.do(ctx, http.MethodPost, "/v1/evaluate", nil, body, true, &result); err != nil {
+		return nil, err
 	}
+	return result.Results, nil
+}
 
-	return result.Flags, nil
+// EncryptionRotation is the result of RotateEncryption.
+type EncryptionRotation struct {
+	KeyID          string `json:"key_id"`
+	WebhookSecrets int    `json:"webhook_secrets"`
+	WebhookHeaders int    `json:"webhook_headers"`
+	FlagConfigs    int    `json:"flag_configs"`
 }
 
-// UpdateFlag updates an existing flag
-func (c *Client) UpdateFlag(ctx context.Context, params store.UpsertParams) error {
-	return c.CreateFlag(ctx, params) // Same endpoint for create/update
+// RotateEncryption re-encrypts stored webhook secrets, webhook headers and
+// flag configs with the server's primary encryption key. Re-running it is
+// harmless, so it is retried like reads.
+func (c *Client) RotateEncryption(ctx context.Context) (*EncryptionRotation, error) {
+	var result EncryptionRotation
+	if err := c.do(ctx, http.MethodPost, "/v1/admin/encryption/rotate", nil, nil, true, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
-// DeleteFlag deletes a flag
-func (c *Client) DeleteFlag(ctx context.Context, key, env string) error {
-	u, err := url.Parse(c.BaseURL + "/v1/flags")
+// do sends a request, retrying idempotent calls according to c.Retry, and
+// decodes a successful JSON response into out (if non-nil).
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body []byte, idempotent bool, out any) error {
+	u, err := url.Parse(c.BaseURL + path)
 	if err != nil {
 		return fmt.Errorf("failed to parse URL: %w", err)
 	}
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
 
-	q := u.Query()
-	q.Set("key", key)
-	q.Set("env", env)
-	u.RawQuery = q.Encode()
+	attempts := 1
+	if idempotent {
+		attempts = max(c.Retry.MaxAttempts, 1)
+	}
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := c.attempt(ctx, method, u.String(), body, out)
+		if err == nil || attempt >= attempts || !retryable(ctx, err) {
+			return err
+		}
+		wait := c.backoff(attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, "DELETE", u.String(), nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+// attempt performs a single request under c.Timeout.
+func (c *Client) attempt(ctx context.Context, method, rawURL string, body []byte, out any) (time.Duration, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
 	}
 
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req.Header.Set("Authorization", "Bearer "+c.APIKey)
 
-	resp, err := c.HTTPClient.Do(req)
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return 0, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := decodeAPIError(resp)
+		return apiErr.RetryAfter, apiErr
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return 0, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return 0, nil
+}
+
+// backoff returns the jittered delay before retry number attempt (1-based).
+func (c *Client) backoff(attempt int) time.Duration {
+	initial, maxBackoff := c.Retry.InitialBackoff, c.Retry.MaxBackoff
+	if initial <= 0 {
+		initial = 200 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 5 * time.Second
+	}
+	d := initial << (attempt - 1)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
 	}
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
 
-	return nil
+// retryable reports whether err is worth another attempt. Errors caused by
+// the caller's own context are not.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// decodeAPIError builds an APIError from an error response, falling back to
+// the raw body when it is not a JSON ErrorResponse.
+func decodeAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		apiErr.RetryAfter = time.Duration(secs) * time.Second
+	}
+
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Message   string            `json:"message"`
+		Code      string            `json:"code"`
+		Fields    map[string]string `json:"fields"`
+		RequestID string            `json:"request_id"`
+	}
+	if json.Unmarshal(bodyBytes, &body) == nil && (body.Code != "" || body.Message != "") {
+		apiErr.Code = body.Code
+		apiErr.Message = body.Message
+		apiErr.Fields = body.Fields
+		apiErr.RequestID = body.RequestID
+	} else {
+		apiErr.Message = strings.TrimSpace(string(bodyBytes))
+	}
+	return apiErr
 }
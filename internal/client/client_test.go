@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func newTestClient(url string) *Client {
+	c := NewClient(url, "test-key")
+	c.Retry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	return c
+}
+
+func TestClient_RetriesTemporaryErrors(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"flags":[{"key":"a","enabled":true}]}`))
+	}))
+	defer ts.Close()
+
+	flags, err := newTestClient(ts.URL).ListFlags(context.Background(), "prod")
+	if err != nil {
+		t.Fatalf("ListFlags: %v", err)
+	}
+	if len(flags) != 1 || calls.Load() != 3 {
+		t.Errorf("flags=%v calls=%d", flags, calls.Load())
+	}
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"Bad Request","message":"Validation failed","code":"VALIDATION_ERROR","fields":{"key":"invalid"},"request_id":"req-1"}`))
+	}))
+	defer ts.Close()
+
+	err := newTestClient(ts.URL).CreateFlag(context.Background(), store.UpsertParams{Key: "bad key", Env: "prod"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != 400 || apiErr.Code != "VALIDATION_ERROR" || apiErr.Fields["key"] != "invalid" || apiErr.RequestID != "req-1" {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("client errors must not be retried, got %d calls", calls.Load())
+	}
+	if got := err.Error(); got != "API error (status 400, VALIDATION_ERROR): Validation failed; key: invalid" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestClient_PerAttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := newTestClient(ts.URL)
+	c.Timeout = 20 * time.Millisecond
+	if err := c.DeleteFlag(context.Background(), "a", "prod"); err != nil {
+		t.Fatalf("DeleteFlag should succeed after a timed-out attempt: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+}
+
+func TestClient_NonJSONErrorBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream exploded", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	_, err := newTestClient(ts.URL).GetFlag(context.Background(), "a", "prod")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 || apiErr.Message != "upstream exploded" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
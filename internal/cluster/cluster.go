@@ -0,0 +1,113 @@
+// Package cluster propagates snapshot updates between API replicas.
+//
+// Each replica keeps its flag snapshots in memory and only notifies its own
+// SSE and long-poll clients when it rebuilds one. With several replicas, a
+// flag changed through one of them would reach the clients of the others
+// only on their next restart. A Bridge closes that gap:
+//
+//  1. After a replica rebuilds a snapshot it publishes {env, etag}
+//  2. Every other replica receives the message and, if its own snapshot of
+//     env has a different ETag, rebuilds it from the store
+//  3. The rebuild notifies that replica's clients as usual
+//
+// Replicas ignore their own messages and do not publish rebuilds caused by a
+// message, so an update crosses the cluster exactly once.
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// publishTimeout bounds how long a rebuild waits for its message to be
+// published; the rebuild itself has already succeeded.
+const publishTimeout = 2 * time.Second
+
+// Transport carries messages between replicas, e.g. Redis pub/sub (see
+// NewRedisTransport).
+type Transport interface {
+	// Publish sends payload to every subscribed replica, including this one.
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe returns the payloads published by any replica until ctx is
+	// cancelled, when the channel is closed.
+	Subscribe(ctx context.Context) (<-chan []byte, error)
+	Close() error
+}
+
+// ApplyFunc brings the local snapshot of env up to date with etag. It must
+// not publish the resulting rebuild again.
+type ApplyFunc func(ctx context.Context, env, etag string) error
+
+// message is the payload published for every snapshot rebuild.
+type message struct {
+	Origin string `json:"origin"` // Replica that rebuilt the snapshot
+	Env    string `json:"env"`
+	ETag   string `json:"etag"`
+}
+
+// Bridge publishes local snapshot rebuilds and applies those of other
+// replicas.
+type Bridge struct {
+	transport Transport
+	apply     ApplyFunc
+	origin    string
+}
+
+// New creates a Bridge over transport. apply is called for every update
+// published by another replica.
+func New(transport Transport, apply ApplyFunc) *Bridge {
+	return &Bridge{transport: transport, apply: apply, origin: newOrigin()}
+}
+
+// Publish announces that the snapshot of env was rebuilt with etag. Failures
+// are logged: other replicas then catch up with the next update.
+func (b *Bridge) Publish(ctx context.Context, env, etag string) {
+	payload, err := json.Marshal(message{Origin: b.origin, Env: env, ETag: etag})
+	if err != nil {
+		log.Printf("[cluster] encode update failed: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+	if err := b.transport.Publish(ctx, payload); err != nil {
+		log.Printf("[cluster] publish update failed: env=%s etag=%s error=%v", env, etag, err)
+	}
+}
+
+// Run applies the updates of other replicas until ctx is cancelled.
+func (b *Bridge) Run(ctx context.Context) error {
+	payloads, err := b.transport.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	for payload := range payloads {
+		var msg message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			log.Printf("[cluster] ignoring malformed update: %v", err)
+			continue
+		}
+		if msg.Origin == b.origin || msg.Env == "" {
+			continue
+		}
+		if err := b.apply(ctx, msg.Env, msg.ETag); err != nil {
+			log.Printf("[cluster] apply update failed: env=%s etag=%s error=%v", msg.Env, msg.ETag, err)
+		}
+	}
+	return ctx.Err()
+}
+
+// Close closes the transport.
+func (b *Bridge) Close() error {
+	return b.transport.Close()
+}
+
+// newOrigin returns a random ID for this replica.
+func newOrigin() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hub is an in-memory Transport shared by several bridges.
+type hub struct {
+	mu   sync.Mutex
+	subs []chan []byte
+}
+
+func (h *hub) Publish(_ context.Context, payload []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subs {
+		ch <- payload
+	}
+	return nil
+}
+
+func (h *hub) Subscribe(ctx context.Context) (<-chan []byte, error) {
+	ch := make(chan []byte, 16)
+	h.mu.Lock()
+	h.subs = append(h.subs, ch)
+	h.mu.Unlock()
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case p := <-ch:
+				out <- p
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (h *hub) Close() error { return nil }
+
+type applied struct{ env, etag string }
+
+func TestBridge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := &hub{}
+	gotA := make(chan applied, 4)
+	gotB := make(chan applied, 4)
+	a := New(h, func(_ context.Context, env, etag string) error {
+		gotA <- applied{env, etag}
+		return nil
+	})
+	b := New(h, func(_ context.Context, env, etag string) error {
+		gotB <- applied{env, etag}
+		return nil
+	})
+	go a.Run(ctx)
+	go b.Run(ctx)
+	for { // wait for both subscriptions
+		h.mu.Lock()
+		n := len(h.subs)
+		h.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	a.Publish(ctx, "prod", "W/\"abc\"")
+
+	select {
+	case got := <-gotB:
+		if got != (applied{"prod", "W/\"abc\""}) {
+			t.Errorf("applied %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("update was not applied by the other replica")
+	}
+	select {
+	case got := <-gotA:
+		t.Errorf("publishing replica applied its own update: %+v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
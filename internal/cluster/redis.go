@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisChannel is the Redis pub/sub channel used when none is
+// configured.
+const DefaultRedisChannel = "goflagship:snapshots"
+
+// RedisTransport is a Transport over a Redis pub/sub channel. The Redis
+// client reconnects on its own; updates published while a replica is
+// disconnected are lost, and it catches up with the next one.
+type RedisTransport struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisTransport connects to the Redis server at url
+// ("redis://[:password@]host:port/db", or rediss:// for TLS) and uses
+// channel, or DefaultRedisChannel when channel is empty.
+func NewRedisTransport(url, channel string) (*RedisTransport, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: invalid redis url: %w", err)
+	}
+	if channel == "" {
+		channel = DefaultRedisChannel
+	}
+	return &RedisTransport{client: redis.NewClient(opts), channel: channel}, nil
+}
+
+// Publish implements Transport.
+func (t *RedisTransport) Publish(ctx context.Context, payload []byte) error {
+	return t.client.Publish(ctx, t.channel, payload).Err()
+}
+
+// Subscribe implements Transport. It fails if the subscription cannot be
+// confirmed by the server.
+func (t *RedisTransport) Subscribe(ctx context.Context) (<-chan []byte, error) {
+	sub := t.client.Subscribe(ctx, t.channel)
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, fmt.Errorf("cluster: subscribe to %s: %w", t.channel, err)
+	}
+
+	payloads := make(chan []byte)
+	go func() {
+		defer close(payloads)
+		defer sub.Close()
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case payloads <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return payloads, nil
+}
+
+// Close implements Transport.
+func (t *RedisTransport) Close() error {
+	return t.client.Close()
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad_DefaultValues(t *testing.T) {
@@ -11,6 +17,7 @@ func TestLoad_DefaultValues(t *testing.T) {
 		"APP_ENV", "APP_HTTP_ADDR", "DB_DSN", "ENV", "ADMIN_API_KEY",
 		"CLIENT_API_KEY", "METRICS_ADDR", "STORE_TYPE", "RATE_LIMIT_PER_IP",
 		"RATE_LIMIT_PER_KEY", "RATE_LIMIT_ADMIN_PER_KEY", "AUTH_TOKEN_PREFIX",
+		"HTTP_TIMEOUT", "HTTP_TIMEOUT_EVALUATE", "HTTP_TIMEOUT_BULK",
 	}
 	
 	for _, key := range env {
@@ -32,8 +39,8 @@ func TestLoad_DefaultValues(t *testing.T) {
 	if cfg.Env != "prod" {
 		t.Errorf("Expected Env='prod', got '%s'", cfg.Env)
 	}
-	if cfg.AdminAPIKey != "admin-123" {
-		t.Errorf("Expected AdminAPIKey='admin-123', got '%s'", cfg.AdminAPIKey)
+	if cfg.AdminAPIKey != "" {
+		t.Errorf("Expected no default AdminAPIKey (first-run setup), got '%s'", cfg.AdminAPIKey)
 	}
 	if cfg.MetricsAddr != ":9090" {
 		t.Errorf("Expected MetricsAddr=':9090', got '%s'", cfg.MetricsAddr)
@@ -47,6 +54,9 @@ func TestLoad_DefaultValues(t *testing.T) {
 	if cfg.AuthTokenPrefix != "fsk_" {
 		t.Errorf("Expected AuthTokenPrefix='fsk_', got '%s'", cfg.AuthTokenPrefix)
 	}
+	if cfg.HTTPTimeout != 5*time.Second || cfg.HTTPTimeoutEvaluate != 2*time.Second || cfg.HTTPTimeoutBulk != time.Minute {
+		t.Errorf("Expected timeouts 5s/2s/1m, got %v/%v/%v", cfg.HTTPTimeout, cfg.HTTPTimeoutEvaluate, cfg.HTTPTimeoutBulk)
+	}
 }
 
 func TestLoad_EnvironmentOverrides(t *testing.T) {
@@ -156,6 +166,188 @@ func TestValidate_ValidConfig(t *testing.T) {
 	}
 }
 
+func TestValidate_StepUpTOTPSecret(t *testing.T) {
+	cfg := &Config{
+		AppEnv:           "dev",
+		HTTPAddr:         ":8080",
+		MetricsAddr:      ":9090",
+		Env:              "prod",
+		StoreType:        "memory",
+		RolloutSalt:      "test-salt",
+		AdminAPIKey:      "test-key",
+		StepUpTOTPSecret: "not base32!",
+	}
+
+	err := cfg.Validate()
+	if verr, ok := err.(ValidationError); !ok || verr.Field != "STEP_UP_TOTP_SECRET" {
+		t.Fatalf("Expected STEP_UP_TOTP_SECRET ValidationError, got %v", err)
+	}
+
+	cfg.StepUpTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() should accept a base32 secret: %v", err)
+	}
+}
+
+func TestWebhookURLPolicy(t *testing.T) {
+	cfg := &Config{
+		AppEnv:              "dev",
+		HTTPAddr:            ":8080",
+		MetricsAddr:         ":9090",
+		Env:                 "prod",
+		StoreType:           "memory",
+		RolloutSalt:         "test-salt",
+		AdminAPIKey:         "test-key",
+		WebhookAllowTargets: "10.0.0.0/8, hooks.internal",
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() should accept the allowlist: %v", err)
+	}
+	if policy := cfg.WebhookURLPolicy(); policy.RequireHTTPS || len(policy.Allowlist) != 2 {
+		t.Errorf("unexpected dev policy %+v", policy)
+	}
+	cfg.AppEnv = "prod"
+	if !cfg.WebhookURLPolicy().RequireHTTPS {
+		t.Error("APP_ENV=prod should require https webhooks")
+	}
+
+	cfg.AppEnv = "dev"
+	cfg.WebhookAllowTargets = "10.0.0.0/99"
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "WEBHOOK_ALLOWED_TARGETS" {
+		t.Fatalf("Expected WEBHOOK_ALLOWED_TARGETS ValidationError, got %v", cfg.Validate())
+	}
+}
+
+func TestValidate_WebhookTransport(t *testing.T) {
+	cfg := &Config{
+		AppEnv:          "dev",
+		HTTPAddr:        ":8080",
+		MetricsAddr:     ":9090",
+		Env:             "prod",
+		StoreType:       "memory",
+		RolloutSalt:     "test-salt",
+		AdminAPIKey:     "test-key",
+		WebhookProxyURL: "http://proxy.internal:3128",
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() should accept the proxy: %v", err)
+	}
+
+	cfg.WebhookProxyURL = "ftp://proxy.internal"
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "WEBHOOK_PROXY_URL" {
+		t.Fatalf("Expected WEBHOOK_PROXY_URL ValidationError, got %v", cfg.Validate())
+	}
+
+	cfg.WebhookProxyURL = ""
+	cfg.WebhookCABundle = "/nonexistent/ca.pem"
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "WEBHOOK_CA_BUNDLE" {
+		t.Fatalf("Expected WEBHOOK_CA_BUNDLE ValidationError, got %v", cfg.Validate())
+	}
+
+	cfg.WebhookCABundle = ""
+	cfg.WebhookBreakerFails = 5
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "WEBHOOK_BREAKER_COOLDOWN" {
+		t.Fatalf("Expected WEBHOOK_BREAKER_COOLDOWN ValidationError, got %v", cfg.Validate())
+	}
+}
+
+func TestValidate_AttributeEnforcement(t *testing.T) {
+	cfg := &Config{
+		AppEnv:               "dev",
+		HTTPAddr:             ":8080",
+		MetricsAddr:          ":9090",
+		Env:                  "prod",
+		StoreType:            "memory",
+		RolloutSalt:          "test-salt",
+		AttributeEnforcement: "reject",
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() should accept reject: %v", err)
+	}
+
+	cfg.AttributeEnforcement = "strict"
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "ATTRIBUTE_ENFORCEMENT" {
+		t.Fatalf("Expected ATTRIBUTE_ENFORCEMENT ValidationError, got %v", cfg.Validate())
+	}
+}
+
+func TestValidate_ReportEmail(t *testing.T) {
+	cfg := &Config{
+		AppEnv:          "dev",
+		HTTPAddr:        ":8080",
+		MetricsAddr:     ":9090",
+		Env:             "prod",
+		StoreType:       "memory",
+		RolloutSalt:     "test-salt",
+		ReportEmailTo:   "team@example.com, lead@example.com",
+		ReportEmailFrom: "flagship@example.com",
+		ReportSMTPAddr:  "smtp.example.com:587",
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() should accept a complete email setup: %v", err)
+	}
+	if to := cfg.ReportMail().To; len(to) != 2 || to[1] != "lead@example.com" {
+		t.Errorf("ReportMail().To = %v", to)
+	}
+
+	cfg.ReportSMTPAddr = ""
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "REPORT_EMAIL_TO" {
+		t.Fatalf("Expected REPORT_EMAIL_TO ValidationError, got %v", cfg.Validate())
+	}
+
+	cfg.ReportEmailTo, cfg.ReportTemplate = "", "/nonexistent/summary.tmpl"
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "REPORT_TEMPLATE" {
+		t.Fatalf("Expected REPORT_TEMPLATE ValidationError, got %v", cfg.Validate())
+	}
+}
+
+func TestValidate_DBPool(t *testing.T) {
+	cfg := &Config{
+		AppEnv:      "dev",
+		HTTPAddr:    ":8080",
+		MetricsAddr: ":9090",
+		Env:         "prod",
+		StoreType:   "memory",
+		RolloutSalt: "test-salt",
+		DBMaxConns:  20,
+		DBMinConns:  5,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() should accept the pool settings: %v", err)
+	}
+	if pool := cfg.DBPool(); pool.MaxConns != 20 || pool.MinConns != 5 {
+		t.Errorf("DBPool() = %+v", pool)
+	}
+
+	cfg.DBMinConns = 21
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "DB_MIN_CONNS" {
+		t.Fatalf("Expected DB_MIN_CONNS ValidationError, got %v", cfg.Validate())
+	}
+	cfg.DBMinConns, cfg.DBMaxConnLifetime = 1, -time.Minute
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "DB_MAX_CONN_LIFETIME" {
+		t.Fatalf("Expected DB_MAX_CONN_LIFETIME ValidationError, got %v", cfg.Validate())
+	}
+}
+
+func TestValidate_DBReplica(t *testing.T) {
+	cfg := &Config{
+		AppEnv:       "dev",
+		HTTPAddr:     ":8080",
+		MetricsAddr:  ":9090",
+		Env:          "prod",
+		StoreType:    "memory",
+		RolloutSalt:  "test-salt",
+		DBReplicaDSN: "postgres://replica/flagship",
+	}
+	if verr, ok := cfg.Validate().(ValidationError); !ok || verr.Field != "DB_REPLICA_MAX_LAG" {
+		t.Fatalf("Expected DB_REPLICA_MAX_LAG ValidationError, got %v", cfg.Validate())
+	}
+	cfg.DBReplicaMaxLag = 5 * time.Second
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() should accept the replica settings: %v", err)
+	}
+}
+
 func TestValidate_PostgresRequiresDSN(t *testing.T) {
 	cfg := &Config{
 		AppEnv:      "dev",
@@ -177,6 +369,32 @@ func TestValidate_PostgresRequiresDSN(t *testing.T) {
 	}
 }
 
+func TestValidate_FileStore(t *testing.T) {
+	cfg := &Config{
+		AppEnv:       "dev",
+		HTTPAddr:     ":8080",
+		MetricsAddr:  ":9090",
+		Env:          "prod",
+		StoreType:    "file",
+		FileStoreDir: "flags",
+		RolloutSalt:  "test-salt",
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+
+	cfg.GitSyncRepoURL = "https://example.com/flags.git"
+	if err, ok := cfg.Validate().(ValidationError); !ok || err.Field != "GITSYNC_REPO_URL" {
+		t.Errorf("expected git sync to be rejected with the file store, got %v", err)
+	}
+
+	cfg.GitSyncRepoURL = ""
+	cfg.FileStoreDir = ""
+	if err, ok := cfg.Validate().(ValidationError); !ok || err.Field != "FILE_STORE_DIR" {
+		t.Errorf("expected FILE_STORE_DIR to be required, got %v", err)
+	}
+}
+
 func TestValidate_InvalidStoreType(t *testing.T) {
 	cfg := &Config{
 		AppEnv:      "dev",
@@ -284,6 +502,12 @@ func TestValidate_ProductionSafety(t *testing.T) {
 	if valErr.Field != "ADMIN_API_KEY" {
 		t.Errorf("Expected ADMIN_API_KEY error, got %s", valErr.Field)
 	}
+
+	// Without a legacy key, admin access comes from API keys (POST /v1/setup).
+	cfg.AdminAPIKey = ""
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() should allow production without ADMIN_API_KEY, got %v", err)
+	}
 }
 
 func TestValidate_ProductionAutoGeneratedSalt(t *testing.T) {
@@ -312,3 +536,68 @@ func TestValidate_ProductionAutoGeneratedSalt(t *testing.T) {
 		t.Errorf("Expected ROLLOUT_SALT error, got %s", valErr.Field)
 	}
 }
+
+func TestLoad_SecretsBackendOverridesEnvironment(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data":{"data":{"ADMIN_API_KEY":"vault-key","ROLLOUT_SALT":"vault-salt"},"metadata":{"version":1}}}`)
+	}))
+	defer vault.Close()
+
+	t.Setenv("ADMIN_API_KEY", "env-key")
+	t.Setenv("DB_DSN", "postgres://env")
+	t.Setenv("SECRETS_BACKEND", "vault")
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "tok")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.AdminAPIKey != "vault-key" || cfg.RolloutSalt != "vault-salt" {
+		t.Errorf("secrets not applied: admin=%q salt=%q", cfg.AdminAPIKey, cfg.RolloutSalt)
+	}
+	if cfg.DatabaseDSN != "postgres://env" {
+		t.Errorf("DB_DSN missing from the secret should come from the environment, got %q", cfg.DatabaseDSN)
+	}
+	if cfg.Secrets == nil {
+		t.Error("Expected Secrets source to be set")
+	}
+}
+
+func TestLoad_SecretsBackendUnavailable(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer vault.Close()
+
+	t.Setenv("SECRETS_BACKEND", "vault")
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "bad")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() should fail when the secrets backend cannot be read")
+	}
+}
+
+func TestBootstrapEnvironments(t *testing.T) {
+	cfg := &Config{
+		HTTPAddr:      ":8080",
+		MetricsAddr:   ":9090",
+		Env:           "prod",
+		StoreType:     "memory",
+		RolloutSalt:   "test-salt",
+		BootstrapEnvs: " dev, staging,,prod,dev ",
+	}
+	if got := cfg.BootstrapEnvironments(); !reflect.DeepEqual(got, []string{"dev", "staging", "prod"}) {
+		t.Errorf("BootstrapEnvironments() = %v", got)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+
+	cfg.BootstrapEnvs = "dev," + strings.Repeat("x", 40)
+	err := cfg.Validate()
+	if valErr, ok := err.(ValidationError); !ok || valErr.Field != "BOOTSTRAP_ENVIRONMENTS" {
+		t.Errorf("expected a BOOTSTRAP_ENVIRONMENTS error for an overlong name, got %v", err)
+	}
+}
@@ -11,10 +11,21 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countAPIKeys = `-- name: CountAPIKeys :one
+SELECT count(*) FROM api_keys
+`
+
+func (q *Queries) CountAPIKeys(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countAPIKeys)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAPIKey = `-- name: CreateAPIKey :one
-INSERT INTO api_keys (name, key_hash, role, enabled, expires_at, created_by)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, name, key_hash, role, enabled, expires_at, created_at, last_used_at, created_by
+INSERT INTO api_keys (name, key_hash, role, enabled, expires_at, created_by, tenant, project)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, key_hash, role, enabled, expires_at, created_at, last_used_at, created_by, tenant, disabled_at, disabled_reason, project
 `
 
 type CreateAPIKeyParams struct {
@@ -24,6 +35,8 @@ type CreateAPIKeyParams struct {
 	Enabled   bool               `json:"enabled"`
 	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
 	CreatedBy string             `json:"created_by"`
+	Tenant    string             `json:"tenant"`
+	Project   pgtype.Text        `json:"project"`
 }
 
 func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
@@ -34,6 +47,8 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (Api
 		arg.Enabled,
 		arg.ExpiresAt,
 		arg.CreatedBy,
+		arg.Tenant,
+		arg.Project,
 	)
 	var i ApiKey
 	err := row.Scan(
@@ -46,6 +61,10 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (Api
 		&i.CreatedAt,
 		&i.LastUsedAt,
 		&i.CreatedBy,
+		&i.Tenant,
+		&i.DisabledAt,
+		&i.DisabledReason,
+		&i.Project,
 	)
 	return i, err
 }
@@ -59,8 +78,26 @@ func (q *Queries) DeleteAPIKey(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const disableAPIKey = `-- name: DisableAPIKey :execrows
+UPDATE api_keys SET enabled = false, disabled_at = now(), disabled_reason = $2
+WHERE id = $1 AND enabled = true
+`
+
+type DisableAPIKeyParams struct {
+	ID             pgtype.UUID `json:"id"`
+	DisabledReason string      `json:"disabled_reason"`
+}
+
+func (q *Queries) DisableAPIKey(ctx context.Context, arg DisableAPIKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, disableAPIKey, arg.ID, arg.DisabledReason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
-SELECT id, name, key_hash, role, enabled, expires_at, created_at, last_used_at, created_by FROM api_keys WHERE key_hash = $1 AND enabled = true
+SELECT id, name, key_hash, role, enabled, expires_at, created_at, last_used_at, created_by, tenant, disabled_at, disabled_reason, project FROM api_keys WHERE key_hash = $1 AND enabled = true
 `
 
 func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error) {
@@ -76,12 +113,16 @@ func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey,
 		&i.CreatedAt,
 		&i.LastUsedAt,
 		&i.CreatedBy,
+		&i.Tenant,
+		&i.DisabledAt,
+		&i.DisabledReason,
+		&i.Project,
 	)
 	return i, err
 }
 
 const getAPIKeyByID = `-- name: GetAPIKeyByID :one
-SELECT id, name, key_hash, role, enabled, expires_at, created_at, last_used_at, created_by FROM api_keys WHERE id = $1
+SELECT id, name, key_hash, role, enabled, expires_at, created_at, last_used_at, created_by, tenant, disabled_at, disabled_reason, project FROM api_keys WHERE id = $1
 `
 
 func (q *Queries) GetAPIKeyByID(ctx context.Context, id pgtype.UUID) (ApiKey, error) {
@@ -97,12 +138,16 @@ func (q *Queries) GetAPIKeyByID(ctx context.Context, id pgtype.UUID) (ApiKey, er
 		&i.CreatedAt,
 		&i.LastUsedAt,
 		&i.CreatedBy,
+		&i.Tenant,
+		&i.DisabledAt,
+		&i.DisabledReason,
+		&i.Project,
 	)
 	return i, err
 }
 
 const listAPIKeys = `-- name: ListAPIKeys :many
-SELECT id, name, key_hash, role, enabled, expires_at, created_at, last_used_at, created_by FROM api_keys ORDER BY created_at DESC
+SELECT id, name, key_hash, role, enabled, expires_at, created_at, last_used_at, created_by, tenant, disabled_at, disabled_reason, project FROM api_keys ORDER BY created_at DESC
 `
 
 func (q *Queries) ListAPIKeys(ctx context.Context) ([]ApiKey, error) {
@@ -124,6 +169,10 @@ func (q *Queries) ListAPIKeys(ctx context.Context) ([]ApiKey, error) {
 			&i.CreatedAt,
 			&i.LastUsedAt,
 			&i.CreatedBy,
+			&i.Tenant,
+			&i.DisabledAt,
+			&i.DisabledReason,
+			&i.Project,
 		); err != nil {
 			return nil, err
 		}
@@ -135,8 +184,36 @@ func (q *Queries) ListAPIKeys(ctx context.Context) ([]ApiKey, error) {
 	return items, nil
 }
 
+const lockAPIKeySetup = `-- name: LockAPIKeySetup :exec
+SELECT pg_advisory_xact_lock(hashtext('goflagship:api_key_setup'))
+`
+
+func (q *Queries) LockAPIKeySetup(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockAPIKeySetup)
+	return err
+}
+
+const reenableAPIKey = `-- name: ReenableAPIKey :execrows
+UPDATE api_keys SET enabled = true, disabled_at = NULL, disabled_reason = '', last_used_at = now()
+WHERE id = $1 AND enabled = false AND disabled_reason = $2 AND disabled_at >= $3
+`
+
+type ReenableAPIKeyParams struct {
+	ID             pgtype.UUID        `json:"id"`
+	DisabledReason string             `json:"disabled_reason"`
+	DisabledSince  pgtype.Timestamptz `json:"disabled_since"`
+}
+
+func (q *Queries) ReenableAPIKey(ctx context.Context, arg ReenableAPIKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reenableAPIKey, arg.ID, arg.DisabledReason, arg.DisabledSince)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const revokeAPIKey = `-- name: RevokeAPIKey :exec
-UPDATE api_keys SET enabled = false WHERE id = $1
+UPDATE api_keys SET enabled = false, disabled_at = now(), disabled_reason = 'revoked' WHERE id = $1
 `
 
 func (q *Queries) RevokeAPIKey(ctx context.Context, id pgtype.UUID) error {
@@ -20,6 +20,7 @@ WHERE
   AND ($4::text IS NULL OR action = $4)
   AND ($5::timestamptz IS NULL OR timestamp >= $5)
   AND ($6::timestamptz IS NULL OR timestamp <= $6)
+  AND ($7::text IS NULL OR tenant = $7)
 `
 
 type CountAuditLogsParams struct {
@@ -29,6 +30,7 @@ type CountAuditLogsParams struct {
 	Action       pgtype.Text        `json:"action"`
 	StartDate    pgtype.Timestamptz `json:"start_date"`
 	EndDate      pgtype.Timestamptz `json:"end_date"`
+	Tenant       pgtype.Text        `json:"tenant"`
 }
 
 func (q *Queries) CountAuditLogs(ctx context.Context, arg CountAuditLogsParams) (int64, error) {
@@ -39,6 +41,7 @@ func (q *Queries) CountAuditLogs(ctx context.Context, arg CountAuditLogsParams)
 		arg.Action,
 		arg.StartDate,
 		arg.EndDate,
+		arg.Tenant,
 	)
 	var count int64
 	err := row.Scan(&count)
@@ -50,10 +53,12 @@ INSERT INTO audit_logs (
   api_key_id, user_email, action, resource_type, resource_id,
   project_id, environment, before_state, after_state, changes,
   ip_address, user_agent, request_id, status, error_message,
-  resource, details
+  resource, details, tenant, id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+  COALESCE($19::uuid, uuid_generate_v4())
 )
+ON CONFLICT (id) DO NOTHING
 `
 
 type CreateAuditLogParams struct {
@@ -74,6 +79,8 @@ type CreateAuditLogParams struct {
 	ErrorMessage pgtype.Text `json:"error_message"`
 	Resource     pgtype.Text `json:"resource"`
 	Details      []byte      `json:"details"`
+	Tenant       string      `json:"tenant"`
+	ID           pgtype.UUID `json:"id"`
 }
 
 func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error {
@@ -95,12 +102,46 @@ func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams)
 		arg.ErrorMessage,
 		arg.Resource,
 		arg.Details,
+		arg.Tenant,
+		arg.ID,
 	)
 	return err
 }
 
+const getAuditLog = `-- name: GetAuditLog :one
+SELECT id, timestamp, api_key_id, action, resource, ip_address, user_agent, status, details, resource_type, resource_id, project_id, environment, before_state, after_state, changes, request_id, user_email, error_message, tenant FROM audit_logs WHERE id = $1
+`
+
+func (q *Queries) GetAuditLog(ctx context.Context, id pgtype.UUID) (AuditLog, error) {
+	row := q.db.QueryRow(ctx, getAuditLog, id)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Timestamp,
+		&i.ApiKeyID,
+		&i.Action,
+		&i.Resource,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Status,
+		&i.Details,
+		&i.ResourceType,
+		&i.ResourceID,
+		&i.ProjectID,
+		&i.Environment,
+		&i.BeforeState,
+		&i.AfterState,
+		&i.Changes,
+		&i.RequestID,
+		&i.UserEmail,
+		&i.ErrorMessage,
+		&i.Tenant,
+	)
+	return i, err
+}
+
 const getAuditLogsByAPIKey = `-- name: GetAuditLogsByAPIKey :many
-SELECT id, timestamp, api_key_id, action, resource, ip_address, user_agent, status, details, resource_type, resource_id, project_id, environment, before_state, after_state, changes, request_id, user_email, error_message FROM audit_logs
+SELECT id, timestamp, api_key_id, action, resource, ip_address, user_agent, status, details, resource_type, resource_id, project_id, environment, before_state, after_state, changes, request_id, user_email, error_message, tenant FROM audit_logs
 WHERE api_key_id = $1
 ORDER BY timestamp DESC, id
 LIMIT $2 OFFSET $3
@@ -141,6 +182,7 @@ func (q *Queries) GetAuditLogsByAPIKey(ctx context.Context, arg GetAuditLogsByAP
 			&i.RequestID,
 			&i.UserEmail,
 			&i.ErrorMessage,
+			&i.Tenant,
 		); err != nil {
 			return nil, err
 		}
@@ -153,7 +195,7 @@ func (q *Queries) GetAuditLogsByAPIKey(ctx context.Context, arg GetAuditLogsByAP
 }
 
 const listAuditLogs = `-- name: ListAuditLogs :many
-SELECT id, timestamp, api_key_id, action, resource, ip_address, user_agent, status, details, resource_type, resource_id, project_id, environment, before_state, after_state, changes, request_id, user_email, error_message FROM audit_logs 
+SELECT id, timestamp, api_key_id, action, resource, ip_address, user_agent, status, details, resource_type, resource_id, project_id, environment, before_state, after_state, changes, request_id, user_email, error_message, tenant FROM audit_logs 
 WHERE 
   ($3::text IS NULL OR project_id = $3)
   AND ($4::text IS NULL OR resource_type = $4)
@@ -161,6 +203,7 @@ WHERE
   AND ($6::text IS NULL OR action = $6)
   AND ($7::timestamptz IS NULL OR timestamp >= $7)
   AND ($8::timestamptz IS NULL OR timestamp <= $8)
+  AND ($9::text IS NULL OR tenant = $9)
 ORDER BY timestamp DESC, id
 LIMIT $1 OFFSET $2
 `
@@ -174,6 +217,7 @@ type ListAuditLogsParams struct {
 	Action       pgtype.Text        `json:"action"`
 	StartDate    pgtype.Timestamptz `json:"start_date"`
 	EndDate      pgtype.Timestamptz `json:"end_date"`
+	Tenant       pgtype.Text        `json:"tenant"`
 }
 
 func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
@@ -186,6 +230,7 @@ func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([
 		arg.Action,
 		arg.StartDate,
 		arg.EndDate,
+		arg.Tenant,
 	)
 	if err != nil {
 		return nil, err
@@ -214,6 +259,66 @@ func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([
 			&i.RequestID,
 			&i.UserEmail,
 			&i.ErrorMessage,
+			&i.Tenant,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchAuditLogs = `-- name: SearchAuditLogs :many
+SELECT id, timestamp, api_key_id, action, resource, ip_address, user_agent, status, details, resource_type, resource_id, project_id, environment, before_state, after_state, changes, request_id, user_email, error_message, tenant FROM audit_logs
+WHERE
+  (strpos(lower(coalesce(resource_id, '')), lower($2::text)) > 0
+    OR strpos(lower(coalesce(resource, '')), lower($2::text)) > 0
+    OR strpos(lower(action), lower($2::text)) > 0
+    OR strpos(lower(coalesce(user_email, '')), lower($2::text)) > 0)
+  AND ($3::text IS NULL OR tenant = $3)
+ORDER BY timestamp DESC, id
+LIMIT $1
+`
+
+type SearchAuditLogsParams struct {
+	Limit  int32       `json:"limit"`
+	Query  string      `json:"query"`
+	Tenant pgtype.Text `json:"tenant"`
+}
+
+func (q *Queries) SearchAuditLogs(ctx context.Context, arg SearchAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, searchAuditLogs, arg.Limit, arg.Query, arg.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Timestamp,
+			&i.ApiKeyID,
+			&i.Action,
+			&i.Resource,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.Status,
+			&i.Details,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.ProjectID,
+			&i.Environment,
+			&i.BeforeState,
+			&i.AfterState,
+			&i.Changes,
+			&i.RequestID,
+			&i.UserEmail,
+			&i.ErrorMessage,
+			&i.Tenant,
 		); err != nil {
 			return nil, err
 		}
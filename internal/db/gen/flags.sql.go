@@ -11,8 +11,70 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countFlagsPage = `-- name: CountFlagsPage :one
+SELECT COUNT(*) FROM flags
+WHERE env = $1
+  AND ($2::boolean IS NULL OR enabled = $2)
+  AND ($3::text IS NULL OR $3 = ANY(tags))
+  AND ($4::text IS NULL
+       OR strpos(lower(key), $4) > 0
+       OR strpos(lower(COALESCE(description, '')), $4) > 0)
+  AND ($5::text[] IS NULL OR key = ANY($5::text[]))
+  AND ($6::boolean IS NULL OR archived = $6)
+  AND ($7::text IS NULL OR project = $7)
+`
+
+type CountFlagsPageParams struct {
+	Env      string      `json:"env"`
+	Enabled  pgtype.Bool `json:"enabled"`
+	Tag      pgtype.Text `json:"tag"`
+	Query    pgtype.Text `json:"query"`
+	Keys     []string    `json:"keys"`
+	Archived pgtype.Bool `json:"archived"`
+	Project  pgtype.Text `json:"project"`
+}
+
+func (q *Queries) CountFlagsPage(ctx context.Context, arg CountFlagsPageParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFlagsPage,
+		arg.Env,
+		arg.Enabled,
+		arg.Tag,
+		arg.Query,
+		arg.Keys,
+		arg.Archived,
+		arg.Project,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const deleteFlag = `-- name: DeleteFlag :exec
-DELETE FROM flags WHERE key = $1 AND env = $2
+WITH deleted AS (
+  DELETE FROM flags WHERE key = $1 AND env = $2
+  RETURNING id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project
+)
+INSERT INTO flag_trash (id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project)
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM deleted
+ON CONFLICT (key, env) DO UPDATE SET
+  id          = EXCLUDED.id,
+  description = EXCLUDED.description,
+  enabled     = EXCLUDED.enabled,
+  rollout     = EXCLUDED.rollout,
+  expression  = EXCLUDED.expression,
+  config      = EXCLUDED.config,
+  targeting_rules = EXCLUDED.targeting_rules,
+  updated_at  = EXCLUDED.updated_at,
+  protected   = EXCLUDED.protected,
+  tags        = EXCLUDED.tags,
+  archived    = EXCLUDED.archived,
+  version     = EXCLUDED.version,
+  owner       = EXCLUDED.owner,
+  ticket_url  = EXCLUDED.ticket_url,
+  metadata    = EXCLUDED.metadata,
+  expires_at  = EXCLUDED.expires_at,
+  project     = EXCLUDED.project,
+  deleted_at  = now()
 `
 
 type DeleteFlagParams struct {
@@ -26,7 +88,7 @@ func (q *Queries) DeleteFlag(ctx context.Context, arg DeleteFlagParams) error {
 }
 
 const getAllFlags = `-- name: GetAllFlags :many
-SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at FROM flags WHERE env = $1 ORDER BY key
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM flags WHERE env = $1 ORDER BY key
 `
 
 func (q *Queries) GetAllFlags(ctx context.Context, env string) ([]Flag, error) {
@@ -49,6 +111,15 @@ func (q *Queries) GetAllFlags(ctx context.Context, env string) ([]Flag, error) {
 			&i.TargetingRules,
 			&i.Env,
 			&i.UpdatedAt,
+			&i.Protected,
+			&i.Tags,
+			&i.Archived,
+			&i.Version,
+			&i.Owner,
+			&i.TicketUrl,
+			&i.Metadata,
+			&i.ExpiresAt,
+			&i.Project,
 		); err != nil {
 			return nil, err
 		}
@@ -61,11 +132,16 @@ func (q *Queries) GetAllFlags(ctx context.Context, env string) ([]Flag, error) {
 }
 
 const getFlagByKey = `-- name: GetFlagByKey :one
-SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at FROM flags WHERE key = $1
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM flags WHERE key = $1 AND env = $2
 `
 
-func (q *Queries) GetFlagByKey(ctx context.Context, key string) (Flag, error) {
-	row := q.db.QueryRow(ctx, getFlagByKey, key)
+type GetFlagByKeyParams struct {
+	Key string `json:"key"`
+	Env string `json:"env"`
+}
+
+func (q *Queries) GetFlagByKey(ctx context.Context, arg GetFlagByKeyParams) (Flag, error) {
+	row := q.db.QueryRow(ctx, getFlagByKey, arg.Key, arg.Env)
 	var i Flag
 	err := row.Scan(
 		&i.ID,
@@ -78,33 +154,505 @@ func (q *Queries) GetFlagByKey(ctx context.Context, key string) (Flag, error) {
 		&i.TargetingRules,
 		&i.Env,
 		&i.UpdatedAt,
+		&i.Protected,
+		&i.Tags,
+		&i.Archived,
+		&i.Version,
+		&i.Owner,
+		&i.TicketUrl,
+		&i.Metadata,
+		&i.ExpiresAt,
+		&i.Project,
 	)
 	return i, err
 }
 
+const getFlagsByKeys = `-- name: GetFlagsByKeys :many
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM flags WHERE env = $1 AND key = ANY($2::text[]) ORDER BY key
+`
+
+type GetFlagsByKeysParams struct {
+	Env  string   `json:"env"`
+	Keys []string `json:"keys"`
+}
+
+func (q *Queries) GetFlagsByKeys(ctx context.Context, arg GetFlagsByKeysParams) ([]Flag, error) {
+	rows, err := q.db.Query(ctx, getFlagsByKeys, arg.Env, arg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Flag
+	for rows.Next() {
+		var i Flag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Key,
+			&i.Description,
+			&i.Enabled,
+			&i.Rollout,
+			&i.Expression,
+			&i.Config,
+			&i.TargetingRules,
+			&i.Env,
+			&i.UpdatedAt,
+			&i.Protected,
+			&i.Tags,
+			&i.Archived,
+			&i.Version,
+			&i.Owner,
+			&i.TicketUrl,
+			&i.Metadata,
+			&i.ExpiresAt,
+			&i.Project,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFlagsUpdatedSince = `-- name: GetFlagsUpdatedSince :many
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM flags WHERE env = $1 AND updated_at > $2 ORDER BY updated_at, key
+`
+
+type GetFlagsUpdatedSinceParams struct {
+	Env       string             `json:"env"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) GetFlagsUpdatedSince(ctx context.Context, arg GetFlagsUpdatedSinceParams) ([]Flag, error) {
+	rows, err := q.db.Query(ctx, getFlagsUpdatedSince, arg.Env, arg.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Flag
+	for rows.Next() {
+		var i Flag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Key,
+			&i.Description,
+			&i.Enabled,
+			&i.Rollout,
+			&i.Expression,
+			&i.Config,
+			&i.TargetingRules,
+			&i.Env,
+			&i.UpdatedAt,
+			&i.Protected,
+			&i.Tags,
+			&i.Archived,
+			&i.Version,
+			&i.Owner,
+			&i.TicketUrl,
+			&i.Metadata,
+			&i.ExpiresAt,
+			&i.Project,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertFlag = `-- name: InsertFlag :execrows
+WITH written AS (
+  INSERT INTO flags (key, description, enabled, rollout, expression, config, targeting_rules, env, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, version)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, COALESCE((SELECT max(r.version) FROM flag_revisions r WHERE r.key = $1 AND r.env = $8), 0) + 1)
+  ON CONFLICT (key, env) DO NOTHING
+  RETURNING key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at
+)
+INSERT INTO flag_revisions (key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at)
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at FROM written
+`
+
+type InsertFlagParams struct {
+	Key            string             `json:"key"`
+	Description    pgtype.Text        `json:"description"`
+	Enabled        bool               `json:"enabled"`
+	Rollout        int32              `json:"rollout"`
+	Expression     *string            `json:"expression"`
+	Config         []byte             `json:"config"`
+	TargetingRules []byte             `json:"targeting_rules"`
+	Env            string             `json:"env"`
+	Protected      bool               `json:"protected"`
+	Tags           []string           `json:"tags"`
+	Archived       bool               `json:"archived"`
+	Owner          string             `json:"owner"`
+	TicketUrl      string             `json:"ticket_url"`
+	Metadata       []byte             `json:"metadata"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	Project        string             `json:"project"`
+}
+
+func (q *Queries) InsertFlag(ctx context.Context, arg InsertFlagParams) (int64, error) {
+	result, err := q.db.Exec(ctx, insertFlag,
+		arg.Key,
+		arg.Description,
+		arg.Enabled,
+		arg.Rollout,
+		arg.Expression,
+		arg.Config,
+		arg.TargetingRules,
+		arg.Env,
+		arg.Protected,
+		arg.Tags,
+		arg.Archived,
+		arg.Owner,
+		arg.TicketUrl,
+		arg.Metadata,
+		arg.ExpiresAt,
+		arg.Project,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listExpiredFlags = `-- name: ListExpiredFlags :many
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM flags WHERE expires_at <= $1 AND NOT archived ORDER BY env, key
+`
+
+// Unarchived flags whose expires_at is at or before $1, across all environments.
+func (q *Queries) ListExpiredFlags(ctx context.Context, expiresAt pgtype.Timestamptz) ([]Flag, error) {
+	rows, err := q.db.Query(ctx, listExpiredFlags, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Flag
+	for rows.Next() {
+		var i Flag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Key,
+			&i.Description,
+			&i.Enabled,
+			&i.Rollout,
+			&i.Expression,
+			&i.Config,
+			&i.TargetingRules,
+			&i.Env,
+			&i.UpdatedAt,
+			&i.Protected,
+			&i.Tags,
+			&i.Archived,
+			&i.Version,
+			&i.Owner,
+			&i.TicketUrl,
+			&i.Metadata,
+			&i.ExpiresAt,
+			&i.Project,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFlagConfigs = `-- name: ListFlagConfigs :many
+SELECT key, env, config FROM flags ORDER BY env, key
+`
+
+type ListFlagConfigsRow struct {
+	Key    string `json:"key"`
+	Env    string `json:"env"`
+	Config []byte `json:"config"`
+}
+
+func (q *Queries) ListFlagConfigs(ctx context.Context) ([]ListFlagConfigsRow, error) {
+	rows, err := q.db.Query(ctx, listFlagConfigs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFlagConfigsRow
+	for rows.Next() {
+		var i ListFlagConfigsRow
+		if err := rows.Scan(&i.Key, &i.Env, &i.Config); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFlagEnvironments = `-- name: ListFlagEnvironments :many
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM flags WHERE key = $1 ORDER BY env
+`
+
+func (q *Queries) ListFlagEnvironments(ctx context.Context, key string) ([]Flag, error) {
+	rows, err := q.db.Query(ctx, listFlagEnvironments, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Flag
+	for rows.Next() {
+		var i Flag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Key,
+			&i.Description,
+			&i.Enabled,
+			&i.Rollout,
+			&i.Expression,
+			&i.Config,
+			&i.TargetingRules,
+			&i.Env,
+			&i.UpdatedAt,
+			&i.Protected,
+			&i.Tags,
+			&i.Archived,
+			&i.Version,
+			&i.Owner,
+			&i.TicketUrl,
+			&i.Metadata,
+			&i.ExpiresAt,
+			&i.Project,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFlagsPage = `-- name: ListFlagsPage :many
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM flags
+WHERE env = $1
+  AND ($4::boolean IS NULL OR enabled = $4)
+  AND ($5::text IS NULL OR $5 = ANY(tags))
+  AND ($6::text IS NULL
+       OR strpos(lower(key), $6) > 0
+       OR strpos(lower(COALESCE(description, '')), $6) > 0)
+  AND ($7::text[] IS NULL OR key = ANY($7::text[]))
+  AND ($8::boolean IS NULL OR archived = $8)
+  AND ($9::text IS NULL OR project = $9)
+ORDER BY
+  CASE WHEN $10::text = '-key' THEN key END DESC,
+  CASE WHEN $10::text = 'updated_at' THEN updated_at END ASC,
+  CASE WHEN $10::text = '-updated_at' THEN updated_at END DESC,
+  key
+LIMIT $2 OFFSET $3
+`
+
+type ListFlagsPageParams struct {
+	Env      string      `json:"env"`
+	Limit    int32       `json:"limit"`
+	Offset   int32       `json:"offset"`
+	Enabled  pgtype.Bool `json:"enabled"`
+	Tag      pgtype.Text `json:"tag"`
+	Query    pgtype.Text `json:"query"`
+	Keys     []string    `json:"keys"`
+	Archived pgtype.Bool `json:"archived"`
+	Project  pgtype.Text `json:"project"`
+	Sort     string      `json:"sort"`
+}
+
+func (q *Queries) ListFlagsPage(ctx context.Context, arg ListFlagsPageParams) ([]Flag, error) {
+	rows, err := q.db.Query(ctx, listFlagsPage,
+		arg.Env,
+		arg.Limit,
+		arg.Offset,
+		arg.Enabled,
+		arg.Tag,
+		arg.Query,
+		arg.Keys,
+		arg.Archived,
+		arg.Project,
+		arg.Sort,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Flag
+	for rows.Next() {
+		var i Flag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Key,
+			&i.Description,
+			&i.Enabled,
+			&i.Rollout,
+			&i.Expression,
+			&i.Config,
+			&i.TargetingRules,
+			&i.Env,
+			&i.UpdatedAt,
+			&i.Protected,
+			&i.Tags,
+			&i.Archived,
+			&i.Version,
+			&i.Owner,
+			&i.TicketUrl,
+			&i.Metadata,
+			&i.ExpiresAt,
+			&i.Project,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFlagConfig = `-- name: UpdateFlagConfig :exec
+UPDATE flags SET config = $3 WHERE key = $1 AND env = $2
+`
+
+type UpdateFlagConfigParams struct {
+	Key    string `json:"key"`
+	Env    string `json:"env"`
+	Config []byte `json:"config"`
+}
+
+func (q *Queries) UpdateFlagConfig(ctx context.Context, arg UpdateFlagConfigParams) error {
+	_, err := q.db.Exec(ctx, updateFlagConfig, arg.Key, arg.Env, arg.Config)
+	return err
+}
+
+const updateFlagIfVersion = `-- name: UpdateFlagIfVersion :execrows
+WITH written AS (
+  UPDATE flags SET
+    description = $2,
+    enabled     = $3,
+    rollout     = $4,
+    expression  = $5,
+    config      = $6,
+    targeting_rules = $7,
+    protected   = $9,
+    tags        = $10,
+    archived    = $11,
+    owner       = $12,
+    ticket_url  = $13,
+    metadata    = $14,
+    expires_at  = $15,
+    project     = $16,
+    version     = version + 1,
+    updated_at  = now()
+  WHERE key = $1 AND env = $8 AND version = $17
+  RETURNING key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at
+)
+INSERT INTO flag_revisions (key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at)
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at FROM written
+`
+
+type UpdateFlagIfVersionParams struct {
+	Key             string             `json:"key"`
+	Description     pgtype.Text        `json:"description"`
+	Enabled         bool               `json:"enabled"`
+	Rollout         int32              `json:"rollout"`
+	Expression      *string            `json:"expression"`
+	Config          []byte             `json:"config"`
+	TargetingRules  []byte             `json:"targeting_rules"`
+	Env             string             `json:"env"`
+	Protected       bool               `json:"protected"`
+	Tags            []string           `json:"tags"`
+	Archived        bool               `json:"archived"`
+	Owner           string             `json:"owner"`
+	TicketUrl       string             `json:"ticket_url"`
+	Metadata        []byte             `json:"metadata"`
+	ExpiresAt       pgtype.Timestamptz `json:"expires_at"`
+	Project         string             `json:"project"`
+	ExpectedVersion int64              `json:"expected_version"`
+}
+
+func (q *Queries) UpdateFlagIfVersion(ctx context.Context, arg UpdateFlagIfVersionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateFlagIfVersion,
+		arg.Key,
+		arg.Description,
+		arg.Enabled,
+		arg.Rollout,
+		arg.Expression,
+		arg.Config,
+		arg.TargetingRules,
+		arg.Env,
+		arg.Protected,
+		arg.Tags,
+		arg.Archived,
+		arg.Owner,
+		arg.TicketUrl,
+		arg.Metadata,
+		arg.ExpiresAt,
+		arg.Project,
+		arg.ExpectedVersion,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const upsertFlag = `-- name: UpsertFlag :exec
-INSERT INTO flags (key, description, enabled, rollout, expression, config, targeting_rules, env)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-ON CONFLICT (key) DO UPDATE SET
-  description = EXCLUDED.description,
-  enabled     = EXCLUDED.enabled,
-  rollout     = EXCLUDED.rollout,
-  expression  = EXCLUDED.expression,
-  config      = EXCLUDED.config,
-  targeting_rules = EXCLUDED.targeting_rules,
-  env         = EXCLUDED.env,
-  updated_at  = now()
+WITH written AS (
+  INSERT INTO flags (key, description, enabled, rollout, expression, config, targeting_rules, env, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, version)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, COALESCE((SELECT max(r.version) FROM flag_revisions r WHERE r.key = $1 AND r.env = $8), 0) + 1)
+  ON CONFLICT (key, env) DO UPDATE SET
+    description = EXCLUDED.description,
+    enabled     = EXCLUDED.enabled,
+    rollout     = EXCLUDED.rollout,
+    expression  = EXCLUDED.expression,
+    config      = EXCLUDED.config,
+    targeting_rules = EXCLUDED.targeting_rules,
+    protected   = EXCLUDED.protected,
+    tags        = EXCLUDED.tags,
+    archived    = EXCLUDED.archived,
+    owner       = EXCLUDED.owner,
+    ticket_url  = EXCLUDED.ticket_url,
+    metadata    = EXCLUDED.metadata,
+    expires_at  = EXCLUDED.expires_at,
+    project     = EXCLUDED.project,
+    version     = flags.version + 1,
+    updated_at  = now()
+  RETURNING key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at
+)
+INSERT INTO flag_revisions (key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at)
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at FROM written
 `
 
 type UpsertFlagParams struct {
-	Key            string      `json:"key"`
-	Description    pgtype.Text `json:"description"`
-	Enabled        bool        `json:"enabled"`
-	Rollout        int32       `json:"rollout"`
-	Expression     *string     `json:"expression"`
-	Config         []byte      `json:"config"`
-	TargetingRules []byte      `json:"targeting_rules"`
-	Env            string      `json:"env"`
+	Key            string             `json:"key"`
+	Description    pgtype.Text        `json:"description"`
+	Enabled        bool               `json:"enabled"`
+	Rollout        int32              `json:"rollout"`
+	Expression     *string            `json:"expression"`
+	Config         []byte             `json:"config"`
+	TargetingRules []byte             `json:"targeting_rules"`
+	Env            string             `json:"env"`
+	Protected      bool               `json:"protected"`
+	Tags           []string           `json:"tags"`
+	Archived       bool               `json:"archived"`
+	Owner          string             `json:"owner"`
+	TicketUrl      string             `json:"ticket_url"`
+	Metadata       []byte             `json:"metadata"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	Project        string             `json:"project"`
 }
 
 func (q *Queries) UpsertFlag(ctx context.Context, arg UpsertFlagParams) error {
@@ -117,6 +665,14 @@ func (q *Queries) UpsertFlag(ctx context.Context, arg UpsertFlagParams) error {
 		arg.Config,
 		arg.TargetingRules,
 		arg.Env,
+		arg.Protected,
+		arg.Tags,
+		arg.Archived,
+		arg.Owner,
+		arg.TicketUrl,
+		arg.Metadata,
+		arg.ExpiresAt,
+		arg.Project,
 	)
 	return err
 }
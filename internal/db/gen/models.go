@@ -55,15 +55,19 @@ func (ns NullApiKeyRole) Value() (driver.Value, error) {
 }
 
 type ApiKey struct {
-	ID         pgtype.UUID        `json:"id"`
-	Name       string             `json:"name"`
-	KeyHash    string             `json:"key_hash"`
-	Role       ApiKeyRole         `json:"role"`
-	Enabled    bool               `json:"enabled"`
-	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
-	CreatedBy  string             `json:"created_by"`
+	ID             pgtype.UUID        `json:"id"`
+	Name           string             `json:"name"`
+	KeyHash        string             `json:"key_hash"`
+	Role           ApiKeyRole         `json:"role"`
+	Enabled        bool               `json:"enabled"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	LastUsedAt     pgtype.Timestamptz `json:"last_used_at"`
+	CreatedBy      string             `json:"created_by"`
+	Tenant         string             `json:"tenant"`
+	DisabledAt     pgtype.Timestamptz `json:"disabled_at"`
+	DisabledReason string             `json:"disabled_reason"`
+	Project        pgtype.Text        `json:"project"`
 }
 
 type AuditLog struct {
@@ -86,6 +90,46 @@ type AuditLog struct {
 	RequestID    pgtype.Text        `json:"request_id"`
 	UserEmail    pgtype.Text        `json:"user_email"`
 	ErrorMessage pgtype.Text        `json:"error_message"`
+	Tenant       string             `json:"tenant"`
+}
+
+type ChangeSet struct {
+	ID          pgtype.UUID        `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Env         string             `json:"env"`
+	Operations  []byte             `json:"operations"`
+	Status      string             `json:"status"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	CreatedBy   string             `json:"created_by"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	ClosedAt    pgtype.Timestamptz `json:"closed_at"`
+	ClosedBy    string             `json:"closed_by"`
+}
+
+type ContextAttribute struct {
+	Project     string             `json:"project"`
+	Name        string             `json:"name"`
+	Type        string             `json:"type"`
+	Description string             `json:"description"`
+	Example     []byte             `json:"example"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	UpdatedBy   string             `json:"updated_by"`
+}
+
+type ContextPreset struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Attributes  []byte             `json:"attributes"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	UpdatedBy   string             `json:"updated_by"`
+}
+
+type EphemeralEnvironment struct {
+	Name      string             `json:"name"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	CreatedBy string             `json:"created_by"`
 }
 
 type Flag struct {
@@ -99,22 +143,166 @@ type Flag struct {
 	TargetingRules []byte             `json:"targeting_rules"`
 	Env            string             `json:"env"`
 	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Protected      bool               `json:"protected"`
+	Tags           []string           `json:"tags"`
+	Archived       bool               `json:"archived"`
+	Version        int64              `json:"version"`
+	Owner          string             `json:"owner"`
+	TicketUrl      string             `json:"ticket_url"`
+	Metadata       []byte             `json:"metadata"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	Project        string             `json:"project"`
 }
 
-type Webhook struct {
-	ID              pgtype.UUID        `json:"id"`
-	Url             string             `json:"url"`
-	Description     pgtype.Text        `json:"description"`
-	Enabled         bool               `json:"enabled"`
-	Events          []string           `json:"events"`
-	ProjectID       pgtype.UUID        `json:"project_id"`
-	Environments    []string           `json:"environments"`
-	Secret          string             `json:"secret"`
-	MaxRetries      int32              `json:"max_retries"`
-	TimeoutSeconds  int32              `json:"timeout_seconds"`
-	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+type FlagTrash struct {
+	ID             pgtype.UUID        `json:"id"`
+	Key            string             `json:"key"`
+	Description    pgtype.Text        `json:"description"`
+	Enabled        bool               `json:"enabled"`
+	Rollout        int32              `json:"rollout"`
+	Expression     *string            `json:"expression"`
+	Config         []byte             `json:"config"`
+	TargetingRules []byte             `json:"targeting_rules"`
+	Env            string             `json:"env"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Protected      bool               `json:"protected"`
+	Tags           []string           `json:"tags"`
+	Archived       bool               `json:"archived"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	Version        int64              `json:"version"`
+	Owner          string             `json:"owner"`
+	TicketUrl      string             `json:"ticket_url"`
+	Metadata       []byte             `json:"metadata"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	Project        string             `json:"project"`
+}
+
+type FlagComment struct {
+	ID         pgtype.UUID        `json:"id"`
+	FlagKey    string             `json:"flag_key"`
+	Env        string             `json:"env"`
+	Body       string             `json:"body"`
+	AuditLogID pgtype.UUID        `json:"audit_log_id"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	CreatedBy  string             `json:"created_by"`
+}
+
+type FlagEvaluation struct {
+	Key             string             `json:"key"`
+	Env             string             `json:"env"`
+	LastEvaluatedAt pgtype.Timestamptz `json:"last_evaluated_at"`
+}
+
+type FlagGuardrail struct {
+	Key             string             `json:"key"`
+	Env             string             `json:"env"`
+	MaxErrorRate    float64            `json:"max_error_rate"`
+	MinRequests     int64              `json:"min_requests"`
+	WindowSeconds   int32              `json:"window_seconds"`
+	Action          string             `json:"action"`
+	RollbackRollout int32              `json:"rollback_rollout"`
+	TrippedAt       pgtype.Timestamptz `json:"tripped_at"`
+	TripReason      string             `json:"trip_reason"`
 	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
-	LastTriggeredAt pgtype.Timestamptz `json:"last_triggered_at"`
+	RequestMetric   string             `json:"request_metric"`
+	ErrorMetric     string             `json:"error_metric"`
+}
+
+type FlagRevision struct {
+	Key            string             `json:"key"`
+	Env            string             `json:"env"`
+	Version        int64              `json:"version"`
+	Description    pgtype.Text        `json:"description"`
+	Enabled        bool               `json:"enabled"`
+	Rollout        int32              `json:"rollout"`
+	Expression     *string            `json:"expression"`
+	Config         []byte             `json:"config"`
+	TargetingRules []byte             `json:"targeting_rules"`
+	Protected      bool               `json:"protected"`
+	Tags           []string           `json:"tags"`
+	Archived       bool               `json:"archived"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	Owner          string             `json:"owner"`
+	TicketUrl      string             `json:"ticket_url"`
+	Metadata       []byte             `json:"metadata"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	Project        string             `json:"project"`
+}
+
+type FlagShadow struct {
+	Key            string             `json:"key"`
+	Env            string             `json:"env"`
+	Enabled        bool               `json:"enabled"`
+	Rollout        int32              `json:"rollout"`
+	Expression     *string            `json:"expression"`
+	TargetingRules []byte             `json:"targeting_rules"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+type FlagStar struct {
+	Owner     string             `json:"owner"`
+	Key       string             `json:"key"`
+	Env       string             `json:"env"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type FlagUserOverride struct {
+	Key       string             `json:"key"`
+	Env       string             `json:"env"`
+	UserID    string             `json:"user_id"`
+	Enabled   bool               `json:"enabled"`
+	Variant   string             `json:"variant"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	UpdatedBy string             `json:"updated_by"`
+}
+
+type Project struct {
+	Key         string             `json:"key"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type ReadinessChecklist struct {
+	Project             string             `json:"project"`
+	Environments        []string           `json:"environments"`
+	RequireDescription  bool               `json:"require_description"`
+	RequiredTagPrefixes []string           `json:"required_tag_prefixes"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	UpdatedBy           string             `json:"updated_by"`
+}
+
+type SdkKey struct {
+	ID        pgtype.UUID        `json:"id"`
+	Name      string             `json:"name"`
+	Env       string             `json:"env"`
+	KeyHash   string             `json:"key_hash"`
+	KeyPrefix string             `json:"key_prefix"`
+	Enabled   bool               `json:"enabled"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	CreatedBy string             `json:"created_by"`
+}
+
+type Webhook struct {
+	ID                    pgtype.UUID        `json:"id"`
+	Url                   string             `json:"url"`
+	Description           pgtype.Text        `json:"description"`
+	Enabled               bool               `json:"enabled"`
+	Events                []string           `json:"events"`
+	ProjectID             pgtype.Text        `json:"project_id"`
+	Environments          []string           `json:"environments"`
+	Secret                string             `json:"secret"`
+	MaxRetries            int32              `json:"max_retries"`
+	TimeoutSeconds        int32              `json:"timeout_seconds"`
+	CreatedAt             pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt             pgtype.Timestamptz `json:"updated_at"`
+	LastTriggeredAt       pgtype.Timestamptz `json:"last_triggered_at"`
+	Tenant                string             `json:"tenant"`
+	DeliveryRetentionDays int32              `json:"delivery_retention_days"`
+	DeliveryMaxRows       int32              `json:"delivery_max_rows"`
+	Headers               []byte             `json:"headers"`
 }
 
 type WebhookDelivery struct {
@@ -129,4 +317,5 @@ type WebhookDelivery struct {
 	DurationMs   pgtype.Int4        `json:"duration_ms"`
 	Success      bool               `json:"success"`
 	RetryCount   int32              `json:"retry_count"`
+	AuditEventID pgtype.UUID        `json:"audit_event_id"`
 }
@@ -23,21 +23,25 @@ func (q *Queries) CountWebhookDeliveries(ctx context.Context, webhookID pgtype.U
 }
 
 const createWebhook = `-- name: CreateWebhook :one
-INSERT INTO webhooks (url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, created_at, updated_at, last_triggered_at
+INSERT INTO webhooks (url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, tenant, delivery_retention_days, delivery_max_rows, headers)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE($13::jsonb, '{}'))
+RETURNING id, url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, created_at, updated_at, last_triggered_at, tenant, delivery_retention_days, delivery_max_rows, headers
 `
 
 type CreateWebhookParams struct {
-	Url            string      `json:"url"`
-	Description    pgtype.Text `json:"description"`
-	Enabled        bool        `json:"enabled"`
-	Events         []string    `json:"events"`
-	ProjectID      pgtype.UUID `json:"project_id"`
-	Environments   []string    `json:"environments"`
-	Secret         string      `json:"secret"`
-	MaxRetries     int32       `json:"max_retries"`
-	TimeoutSeconds int32       `json:"timeout_seconds"`
+	Url                   string      `json:"url"`
+	Description           pgtype.Text `json:"description"`
+	Enabled               bool        `json:"enabled"`
+	Events                []string    `json:"events"`
+	ProjectID             pgtype.Text `json:"project_id"`
+	Environments          []string    `json:"environments"`
+	Secret                string      `json:"secret"`
+	MaxRetries            int32       `json:"max_retries"`
+	TimeoutSeconds        int32       `json:"timeout_seconds"`
+	Tenant                string      `json:"tenant"`
+	DeliveryRetentionDays int32       `json:"delivery_retention_days"`
+	DeliveryMaxRows       int32       `json:"delivery_max_rows"`
+	Headers               []byte      `json:"headers"`
 }
 
 func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
@@ -51,6 +55,10 @@ func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (W
 		arg.Secret,
 		arg.MaxRetries,
 		arg.TimeoutSeconds,
+		arg.Tenant,
+		arg.DeliveryRetentionDays,
+		arg.DeliveryMaxRows,
+		arg.Headers,
 	)
 	var i Webhook
 	err := row.Scan(
@@ -67,6 +75,10 @@ func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (W
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.LastTriggeredAt,
+		&i.Tenant,
+		&i.DeliveryRetentionDays,
+		&i.DeliveryMaxRows,
+		&i.Headers,
 	)
 	return i, err
 }
@@ -81,10 +93,11 @@ INSERT INTO webhook_deliveries (
   error_message, 
   duration_ms, 
   success, 
-  retry_count
+  retry_count,
+  audit_event_id
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, webhook_id, event_type, payload, timestamp, status_code, response_body, error_message, duration_ms, success, retry_count
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, webhook_id, event_type, payload, timestamp, status_code, response_body, error_message, duration_ms, success, retry_count, audit_event_id
 `
 
 type CreateWebhookDeliveryParams struct {
@@ -97,6 +110,7 @@ type CreateWebhookDeliveryParams struct {
 	DurationMs   pgtype.Int4 `json:"duration_ms"`
 	Success      bool        `json:"success"`
 	RetryCount   int32       `json:"retry_count"`
+	AuditEventID pgtype.UUID `json:"audit_event_id"`
 }
 
 func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
@@ -110,6 +124,7 @@ func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDe
 		arg.DurationMs,
 		arg.Success,
 		arg.RetryCount,
+		arg.AuditEventID,
 	)
 	var i WebhookDelivery
 	err := row.Scan(
@@ -124,6 +139,7 @@ func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDe
 		&i.DurationMs,
 		&i.Success,
 		&i.RetryCount,
+		&i.AuditEventID,
 	)
 	return i, err
 }
@@ -137,8 +153,60 @@ func (q *Queries) DeleteWebhook(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const deleteWebhookDeliveries = `-- name: DeleteWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries WHERE webhook_id = $1
+`
+
+func (q *Queries) DeleteWebhookDeliveries(ctx context.Context, webhookID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookDeliveries, webhookID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWebhookDeliveriesBefore = `-- name: DeleteWebhookDeliveriesBefore :execrows
+DELETE FROM webhook_deliveries WHERE webhook_id = $1 AND timestamp < $2
+`
+
+type DeleteWebhookDeliveriesBeforeParams struct {
+	WebhookID pgtype.UUID        `json:"webhook_id"`
+	Timestamp pgtype.Timestamptz `json:"timestamp"`
+}
+
+func (q *Queries) DeleteWebhookDeliveriesBefore(ctx context.Context, arg DeleteWebhookDeliveriesBeforeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookDeliveriesBefore, arg.WebhookID, arg.Timestamp)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWebhookDeliveriesBeyond = `-- name: DeleteWebhookDeliveriesBeyond :execrows
+DELETE FROM webhook_deliveries WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE webhook_id = $1
+  ORDER BY timestamp DESC
+  OFFSET $2
+)
+`
+
+type DeleteWebhookDeliveriesBeyondParams struct {
+	WebhookID pgtype.UUID `json:"webhook_id"`
+	Offset    int32       `json:"offset"`
+}
+
+// Keeps the newest $2 deliveries of a webhook.
+func (q *Queries) DeleteWebhookDeliveriesBeyond(ctx context.Context, arg DeleteWebhookDeliveriesBeyondParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookDeliveriesBeyond, arg.WebhookID, arg.Offset)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getActiveWebhooks = `-- name: GetActiveWebhooks :many
-SELECT id, url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, created_at, updated_at, last_triggered_at FROM webhooks WHERE enabled = true ORDER BY created_at DESC
+SELECT id, url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, created_at, updated_at, last_triggered_at, tenant, delivery_retention_days, delivery_max_rows, headers FROM webhooks WHERE enabled = true ORDER BY created_at DESC
 `
 
 func (q *Queries) GetActiveWebhooks(ctx context.Context) ([]Webhook, error) {
@@ -164,6 +232,10 @@ func (q *Queries) GetActiveWebhooks(ctx context.Context) ([]Webhook, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.LastTriggeredAt,
+			&i.Tenant,
+			&i.DeliveryRetentionDays,
+			&i.DeliveryMaxRows,
+			&i.Headers,
 		); err != nil {
 			return nil, err
 		}
@@ -176,7 +248,7 @@ func (q *Queries) GetActiveWebhooks(ctx context.Context) ([]Webhook, error) {
 }
 
 const getWebhook = `-- name: GetWebhook :one
-SELECT id, url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, created_at, updated_at, last_triggered_at FROM webhooks WHERE id = $1
+SELECT id, url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, created_at, updated_at, last_triggered_at, tenant, delivery_retention_days, delivery_max_rows, headers FROM webhooks WHERE id = $1
 `
 
 func (q *Queries) GetWebhook(ctx context.Context, id pgtype.UUID) (Webhook, error) {
@@ -196,12 +268,61 @@ func (q *Queries) GetWebhook(ctx context.Context, id pgtype.UUID) (Webhook, erro
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.LastTriggeredAt,
+		&i.Tenant,
+		&i.DeliveryRetentionDays,
+		&i.DeliveryMaxRows,
+		&i.Headers,
 	)
 	return i, err
 }
 
+const listFailedWebhookDeliveriesSince = `-- name: ListFailedWebhookDeliveriesSince :many
+SELECT id, webhook_id, event_type, payload, timestamp, status_code, response_body, error_message, duration_ms, success, retry_count, audit_event_id FROM webhook_deliveries
+WHERE success = false AND timestamp >= $1
+ORDER BY timestamp DESC
+LIMIT $2
+`
+
+type ListFailedWebhookDeliveriesSinceParams struct {
+	Timestamp pgtype.Timestamptz `json:"timestamp"`
+	Limit     int32              `json:"limit"`
+}
+
+func (q *Queries) ListFailedWebhookDeliveriesSince(ctx context.Context, arg ListFailedWebhookDeliveriesSinceParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listFailedWebhookDeliveriesSince, arg.Timestamp, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventType,
+			&i.Payload,
+			&i.Timestamp,
+			&i.StatusCode,
+			&i.ResponseBody,
+			&i.ErrorMessage,
+			&i.DurationMs,
+			&i.Success,
+			&i.RetryCount,
+			&i.AuditEventID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
-SELECT id, webhook_id, event_type, payload, timestamp, status_code, response_body, error_message, duration_ms, success, retry_count FROM webhook_deliveries 
+SELECT id, webhook_id, event_type, payload, timestamp, status_code, response_body, error_message, duration_ms, success, retry_count, audit_event_id FROM webhook_deliveries 
 WHERE webhook_id = $1 
 ORDER BY timestamp DESC 
 LIMIT $2 OFFSET $3
@@ -234,6 +355,7 @@ func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeli
 			&i.DurationMs,
 			&i.Success,
 			&i.RetryCount,
+			&i.AuditEventID,
 		); err != nil {
 			return nil, err
 		}
@@ -246,7 +368,7 @@ func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeli
 }
 
 const listWebhooks = `-- name: ListWebhooks :many
-SELECT id, url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, created_at, updated_at, last_triggered_at FROM webhooks ORDER BY created_at DESC
+SELECT id, url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, created_at, updated_at, last_triggered_at, tenant, delivery_retention_days, delivery_max_rows, headers FROM webhooks ORDER BY created_at DESC
 `
 
 func (q *Queries) ListWebhooks(ctx context.Context) ([]Webhook, error) {
@@ -272,6 +394,10 @@ func (q *Queries) ListWebhooks(ctx context.Context) ([]Webhook, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.LastTriggeredAt,
+			&i.Tenant,
+			&i.DeliveryRetentionDays,
+			&i.DeliveryMaxRows,
+			&i.Headers,
 		); err != nil {
 			return nil, err
 		}
@@ -293,20 +419,26 @@ UPDATE webhooks SET
   environments = $7, 
   max_retries = $8,
   timeout_seconds = $9, 
+  delivery_retention_days = $10,
+  delivery_max_rows = $11,
+  headers = COALESCE($12::jsonb, headers),
   updated_at = now()
 WHERE id = $1
 `
 
 type UpdateWebhookParams struct {
-	ID             pgtype.UUID `json:"id"`
-	Url            string      `json:"url"`
-	Description    pgtype.Text `json:"description"`
-	Enabled        bool        `json:"enabled"`
-	Events         []string    `json:"events"`
-	ProjectID      pgtype.UUID `json:"project_id"`
-	Environments   []string    `json:"environments"`
-	MaxRetries     int32       `json:"max_retries"`
-	TimeoutSeconds int32       `json:"timeout_seconds"`
+	ID                    pgtype.UUID `json:"id"`
+	Url                   string      `json:"url"`
+	Description           pgtype.Text `json:"description"`
+	Enabled               bool        `json:"enabled"`
+	Events                []string    `json:"events"`
+	ProjectID             pgtype.Text `json:"project_id"`
+	Environments          []string    `json:"environments"`
+	MaxRetries            int32       `json:"max_retries"`
+	TimeoutSeconds        int32       `json:"timeout_seconds"`
+	DeliveryRetentionDays int32       `json:"delivery_retention_days"`
+	DeliveryMaxRows       int32       `json:"delivery_max_rows"`
+	Headers               []byte      `json:"headers"`
 }
 
 func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) error {
@@ -320,10 +452,27 @@ func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) er
 		arg.Environments,
 		arg.MaxRetries,
 		arg.TimeoutSeconds,
+		arg.DeliveryRetentionDays,
+		arg.DeliveryMaxRows,
+		arg.Headers,
 	)
 	return err
 }
 
+const updateWebhookHeaders = `-- name: UpdateWebhookHeaders :exec
+UPDATE webhooks SET headers = $2 WHERE id = $1
+`
+
+type UpdateWebhookHeadersParams struct {
+	ID      pgtype.UUID `json:"id"`
+	Headers []byte      `json:"headers"`
+}
+
+func (q *Queries) UpdateWebhookHeaders(ctx context.Context, arg UpdateWebhookHeadersParams) error {
+	_, err := q.db.Exec(ctx, updateWebhookHeaders, arg.ID, arg.Headers)
+	return err
+}
+
 const updateWebhookLastTriggered = `-- name: UpdateWebhookLastTriggered :exec
 UPDATE webhooks SET last_triggered_at = now() WHERE id = $1
 `
@@ -332,3 +481,17 @@ func (q *Queries) UpdateWebhookLastTriggered(ctx context.Context, id pgtype.UUID
 	_, err := q.db.Exec(ctx, updateWebhookLastTriggered, id)
 	return err
 }
+
+const updateWebhookSecret = `-- name: UpdateWebhookSecret :exec
+UPDATE webhooks SET secret = $2 WHERE id = $1
+`
+
+type UpdateWebhookSecretParams struct {
+	ID     pgtype.UUID `json:"id"`
+	Secret string      `json:"secret"`
+}
+
+func (q *Queries) UpdateWebhookSecret(ctx context.Context, arg UpdateWebhookSecretParams) error {
+	_, err := q.db.Exec(ctx, updateWebhookSecret, arg.ID, arg.Secret)
+	return err
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// otherQuery labels statements that are not sqlc queries.
+const otherQuery = "other"
+
+// Instrument wraps db so every statement records its latency in
+// telemetry.DBQueryDuration and failures in telemetry.DBQueryErrors, labelled
+// by the sqlc query name ("-- name: GetAllFlags :many" gives "GetAllFlags").
+// The latency of a query includes reading its rows. pgx.ErrNoRows is not
+// counted as an error: not finding a row is an answer. Failures are logged
+// with the request and trace IDs of the query's context.
+func Instrument(db dbgen.DBTX) dbgen.DBTX {
+	return instrumentedDB{db: db}
+}
+
+type instrumentedDB struct {
+	db dbgen.DBTX
+}
+
+func (i instrumentedDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	start := time.Now()
+	tag, err := i.db.Exec(ctx, sql, args...)
+	observeQuery(ctx, QueryName(sql), start, err)
+	return tag, err
+}
+
+func (i instrumentedDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	start := time.Now()
+	rows, err := i.db.Query(ctx, sql, args...)
+	if err != nil {
+		observeQuery(ctx, QueryName(sql), start, err)
+		return rows, err
+	}
+	return &instrumentedRows{Rows: rows, ctx: ctx, name: QueryName(sql), start: start}, nil
+}
+
+func (i instrumentedDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return instrumentedRow{row: i.db.QueryRow(ctx, sql, args...), ctx: ctx, name: QueryName(sql), start: time.Now()}
+}
+
+// instrumentedRows observes the query when the rows are closed, which sqlc
+// does once it has read them all.
+type instrumentedRows struct {
+	pgx.Rows
+	ctx   context.Context
+	name  string
+	start time.Time
+	once  sync.Once
+}
+
+func (r *instrumentedRows) Close() {
+	r.Rows.Close()
+	r.once.Do(func() { observeQuery(r.ctx, r.name, r.start, r.Rows.Err()) })
+}
+
+// instrumentedRow observes the query when it is scanned; pgx runs it then.
+type instrumentedRow struct {
+	row   pgx.Row
+	ctx   context.Context
+	name  string
+	start time.Time
+}
+
+func (r instrumentedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	observeQuery(r.ctx, r.name, r.start, err)
+	return err
+}
+
+func observeQuery(ctx context.Context, name string, start time.Time, err error) {
+	telemetry.DBQueryDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		telemetry.DBQueryErrors.WithLabelValues(name).Inc()
+		log.Printf("[db] query failed: name=%s %s error=%v", name, telemetry.Correlation(ctx), err)
+	}
+}
+
+// QueryName returns the sqlc name of a query, or "other" if it has none.
+func QueryName(sql string) string {
+	rest, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return otherQuery
+	}
+	if name, _, ok := strings.Cut(rest, " "); ok && name != "" {
+		return name
+	}
+	return otherQuery
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := map[string]string{
+		"-- name: GetAllFlags :many\nSELECT * FROM flags": "GetAllFlags",
+		"-- name: DeleteFlag :exec\nDELETE FROM flags":    "DeleteFlag",
+		"SELECT 1":   "other",
+		"-- name: ":  "other",
+		"-- comment": "other",
+	}
+	for sql, want := range tests {
+		if got := QueryName(sql); got != want {
+			t.Errorf("QueryName(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+type fakeDB struct {
+	err error
+}
+
+func (f fakeDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, f.err
+}
+
+func (f fakeDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, f.err
+}
+
+func (f fakeDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return fakeRow{err: f.err}
+}
+
+type fakeRow struct {
+	err error
+}
+
+func (r fakeRow) Scan(...any) error { return r.err }
+
+func TestInstrument_CountsErrors(t *testing.T) {
+	ctx := context.Background()
+	errors0 := testutil.ToFloat64(telemetry.DBQueryErrors.WithLabelValues("UpsertFlag"))
+
+	if _, err := Instrument(fakeDB{}).Exec(ctx, "-- name: UpsertFlag :exec\nINSERT"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if _, err := Instrument(fakeDB{err: errors.New("boom")}).Exec(ctx, "-- name: UpsertFlag :exec\nINSERT"); err == nil {
+		t.Fatal("Exec should return the error")
+	}
+	if got := testutil.ToFloat64(telemetry.DBQueryErrors.WithLabelValues("UpsertFlag")) - errors0; got != 1 {
+		t.Errorf("expected 1 UpsertFlag error, got %v", got)
+	}
+
+	// Not finding a row is not an error.
+	notFound0 := testutil.ToFloat64(telemetry.DBQueryErrors.WithLabelValues("GetFlagByKey"))
+	row := Instrument(fakeDB{err: pgx.ErrNoRows}).QueryRow(ctx, "-- name: GetFlagByKey :one\nSELECT")
+	if err := row.Scan(); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("Scan should return pgx.ErrNoRows, got %v", err)
+	}
+	if got := testutil.ToFloat64(telemetry.DBQueryErrors.WithLabelValues("GetFlagByKey")) - notFound0; got != 0 {
+		t.Errorf("pgx.ErrNoRows should not be counted, got %v", got)
+	}
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE flags DROP CONSTRAINT IF EXISTS flags_key_key;
+ALTER TABLE flags ADD CONSTRAINT flags_key_env_key UNIQUE (key, env);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE flags DROP CONSTRAINT IF EXISTS flags_key_env_key;
+ALTER TABLE flags ADD CONSTRAINT flags_key_key UNIQUE (key);
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS ephemeral_environments (
+  name TEXT PRIMARY KEY,
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  created_by TEXT NOT NULL DEFAULT 'system'
+);
+
+CREATE INDEX idx_ephemeral_environments_expires_at ON ephemeral_environments(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS ephemeral_environments;
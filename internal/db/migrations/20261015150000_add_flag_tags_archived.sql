@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE flags
+ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN archived BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_flags_tags ON flags USING GIN (tags);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_flags_tags;
+ALTER TABLE flags DROP COLUMN archived, DROP COLUMN tags;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS flag_comments (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  flag_key TEXT NOT NULL,
+  env TEXT NOT NULL,
+  body TEXT NOT NULL,
+  audit_log_id UUID,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  created_by TEXT NOT NULL DEFAULT 'system'
+);
+
+CREATE INDEX idx_flag_comments_flag ON flag_comments(env, flag_key, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS flag_comments;
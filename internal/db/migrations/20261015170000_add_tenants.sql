@@ -0,0 +1,19 @@
+-- +goose Up
+-- Tenants are selected by API key. Rows created before tenancy belong to the
+-- "default" (operator) tenant.
+ALTER TABLE api_keys ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhooks ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE audit_logs ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_api_keys_tenant ON api_keys(tenant);
+CREATE INDEX idx_webhooks_tenant ON webhooks(tenant);
+CREATE INDEX idx_audit_logs_tenant_timestamp ON audit_logs(tenant, timestamp DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_logs_tenant_timestamp;
+DROP INDEX IF EXISTS idx_webhooks_tenant;
+DROP INDEX IF EXISTS idx_api_keys_tenant;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS tenant;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS tenant;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS context_attributes (
+  project TEXT NOT NULL,
+  name TEXT NOT NULL,
+  type TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  example JSONB,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_by TEXT NOT NULL DEFAULT 'system',
+  PRIMARY KEY (project, name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS context_attributes;
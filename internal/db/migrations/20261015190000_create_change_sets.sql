@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS change_sets (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  env TEXT NOT NULL,
+  operations JSONB NOT NULL DEFAULT '[]'::jsonb,
+  status TEXT NOT NULL DEFAULT 'open',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  created_by TEXT NOT NULL DEFAULT 'system',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  closed_at TIMESTAMPTZ,
+  closed_by TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_change_sets_env_status ON change_sets(env, status, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS change_sets;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Supports partial reads of the flags changed since a point in time.
+CREATE INDEX IF NOT EXISTS idx_flags_env_updated_at ON flags(env, updated_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_flags_env_updated_at;
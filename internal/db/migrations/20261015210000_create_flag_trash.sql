@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS flag_trash (
+  id UUID PRIMARY KEY,
+  key TEXT NOT NULL,
+  description TEXT DEFAULT '',
+  enabled BOOLEAN NOT NULL DEFAULT false,
+  rollout INTEGER NOT NULL DEFAULT 0,
+  expression TEXT,
+  config JSONB NOT NULL DEFAULT '{}'::jsonb,
+  targeting_rules JSONB NOT NULL DEFAULT '[]'::jsonb,
+  env TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  protected BOOLEAN NOT NULL DEFAULT false,
+  tags TEXT[] NOT NULL DEFAULT '{}',
+  archived BOOLEAN NOT NULL DEFAULT false,
+  deleted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT flag_trash_key_env_key UNIQUE (key, env)
+);
+
+CREATE INDEX idx_flag_trash_deleted_at ON flag_trash(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS flag_trash;
+-- +goose StatementEnd
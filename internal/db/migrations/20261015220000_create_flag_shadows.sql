@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS flag_shadows (
+  key TEXT NOT NULL,
+  env TEXT NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT false,
+  rollout INTEGER NOT NULL DEFAULT 0,
+  expression TEXT,
+  targeting_rules JSONB NOT NULL DEFAULT '[]'::jsonb,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (key, env),
+  CONSTRAINT flag_shadows_flag_fkey FOREIGN KEY (key, env) REFERENCES flags(key, env) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS flag_shadows;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS flag_guardrails (
+  key TEXT NOT NULL,
+  env TEXT NOT NULL,
+  max_error_rate DOUBLE PRECISION NOT NULL,
+  min_requests BIGINT NOT NULL DEFAULT 0,
+  window_seconds INTEGER NOT NULL,
+  action TEXT NOT NULL,
+  rollback_rollout INTEGER NOT NULL DEFAULT 0,
+  tripped_at TIMESTAMPTZ,
+  trip_reason TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (key, env),
+  CONSTRAINT flag_guardrails_flag_fkey FOREIGN KEY (key, env) REFERENCES flags(key, env) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS flag_guardrails;
+-- +goose StatementEnd
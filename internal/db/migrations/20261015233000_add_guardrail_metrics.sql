@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE flag_guardrails
+  ADD COLUMN IF NOT EXISTS request_metric TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS error_metric TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS flag_guardrails_env_idx ON flag_guardrails (env);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS flag_guardrails_env_idx;
+ALTER TABLE flag_guardrails
+  DROP COLUMN IF EXISTS request_metric,
+  DROP COLUMN IF EXISTS error_metric;
+-- +goose StatementEnd
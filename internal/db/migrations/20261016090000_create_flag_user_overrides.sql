@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS flag_user_overrides (
+  key TEXT NOT NULL,
+  env TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT true,
+  variant TEXT NOT NULL DEFAULT '',
+  expires_at TIMESTAMPTZ,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_by TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY (key, env, user_id),
+  CONSTRAINT flag_user_overrides_flag_fkey FOREIGN KEY (key, env) REFERENCES flags(key, env) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_flag_user_overrides_expires_at ON flag_user_overrides (expires_at) WHERE expires_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS flag_user_overrides;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS context_presets (
+  name TEXT PRIMARY KEY,
+  description TEXT NOT NULL DEFAULT '',
+  attributes JSONB NOT NULL DEFAULT '{}'::jsonb,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_by TEXT NOT NULL DEFAULT 'system'
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS context_presets;
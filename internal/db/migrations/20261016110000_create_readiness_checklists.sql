@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS readiness_checklists (
+  project TEXT PRIMARY KEY,
+  environments TEXT[] NOT NULL DEFAULT '{}',
+  require_description BOOLEAN NOT NULL DEFAULT false,
+  required_tag_prefixes TEXT[] NOT NULL DEFAULT '{}',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_by TEXT NOT NULL DEFAULT 'system'
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS readiness_checklists;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS audit_event_id UUID;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_audit_event_id ON webhook_deliveries(audit_event_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_deliveries_audit_event_id;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS audit_event_id;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS disabled_reason TEXT NOT NULL DEFAULT '';
+UPDATE api_keys SET disabled_reason = 'revoked' WHERE enabled = false;
+
+-- +goose Down
+ALTER TABLE api_keys DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS disabled_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE flags ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE flag_trash ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE flag_trash DROP COLUMN IF EXISTS version;
+ALTER TABLE flags DROP COLUMN IF EXISTS version;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS flag_revisions (
+  key TEXT NOT NULL,
+  env TEXT NOT NULL,
+  version BIGINT NOT NULL,
+  description TEXT DEFAULT '',
+  enabled BOOLEAN NOT NULL DEFAULT false,
+  rollout INTEGER NOT NULL DEFAULT 0,
+  expression TEXT,
+  config JSONB NOT NULL DEFAULT '{}'::jsonb,
+  targeting_rules JSONB NOT NULL DEFAULT '[]'::jsonb,
+  protected BOOLEAN NOT NULL DEFAULT false,
+  tags TEXT[] NOT NULL DEFAULT '{}',
+  archived BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (key, env, version)
+);
+
+-- The current definition of every flag is its first recorded revision.
+INSERT INTO flag_revisions (key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, created_at)
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, updated_at FROM flags
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS flag_revisions;
+-- +goose StatementEnd
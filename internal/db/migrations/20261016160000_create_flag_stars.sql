@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS flag_stars (
+  owner TEXT NOT NULL,
+  key TEXT NOT NULL,
+  env TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (owner, env, key),
+  CONSTRAINT flag_stars_flag_fkey FOREIGN KEY (key, env) REFERENCES flags(key, env) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_flag_stars_flag ON flag_stars (key, env);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS flag_stars;
+-- +goose StatementEnd
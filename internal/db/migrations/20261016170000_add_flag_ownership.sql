@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE flags
+ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS ticket_url TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+ALTER TABLE flag_trash
+ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS ticket_url TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+ALTER TABLE flag_revisions
+ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS ticket_url TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- +goose Down
+ALTER TABLE flag_revisions DROP COLUMN IF EXISTS metadata, DROP COLUMN IF EXISTS ticket_url, DROP COLUMN IF EXISTS owner;
+ALTER TABLE flag_trash DROP COLUMN IF EXISTS metadata, DROP COLUMN IF EXISTS ticket_url, DROP COLUMN IF EXISTS owner;
+ALTER TABLE flags DROP COLUMN IF EXISTS metadata, DROP COLUMN IF EXISTS ticket_url, DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS flag_evaluations (
+  key TEXT NOT NULL,
+  env TEXT NOT NULL,
+  last_evaluated_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (key, env),
+  CONSTRAINT flag_evaluations_flag_fkey FOREIGN KEY (key, env) REFERENCES flags(key, env) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS flag_evaluations;
+-- +goose StatementEnd
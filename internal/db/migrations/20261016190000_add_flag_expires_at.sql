@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE flags ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE flag_trash ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE flag_revisions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_flags_expires_at ON flags(expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_flags_expires_at;
+ALTER TABLE flag_revisions DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE flag_trash DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE flags DROP COLUMN IF EXISTS expires_at;
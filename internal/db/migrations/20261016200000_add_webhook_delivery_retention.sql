@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE webhooks
+ADD COLUMN IF NOT EXISTS delivery_retention_days INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS delivery_max_rows INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_timestamp ON webhook_deliveries(webhook_id, timestamp DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook_timestamp;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS delivery_max_rows, DROP COLUMN IF EXISTS delivery_retention_days;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Header name -> value, each value encrypted like webhooks.secret.
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS headers JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE webhooks DROP COLUMN IF EXISTS headers;
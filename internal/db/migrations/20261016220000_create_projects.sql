@@ -0,0 +1,35 @@
+-- +goose Up
+-- Projects group flags, API keys and webhooks. Every flag belongs to one
+-- project; flags created before projects belong to "default".
+CREATE TABLE IF NOT EXISTS projects (
+  key TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO projects (key, name) VALUES ('default', 'Default') ON CONFLICT (key) DO NOTHING;
+
+ALTER TABLE flags ADD COLUMN IF NOT EXISTS project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE flag_trash ADD COLUMN IF NOT EXISTS project TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE flag_revisions ADD COLUMN IF NOT EXISTS project TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_flags_env_project ON flags(env, project);
+
+-- NULL: the key is not restricted to a project.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS project TEXT;
+
+-- project_id was never resolved to anything, so webhooks with one received
+-- every event. They keep doing so; the column now holds a project key.
+ALTER TABLE webhooks ALTER COLUMN project_id TYPE TEXT USING NULL;
+
+-- +goose Down
+ALTER TABLE webhooks ALTER COLUMN project_id TYPE UUID USING NULL;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS project;
+
+DROP INDEX IF EXISTS idx_flags_env_project;
+ALTER TABLE flag_revisions DROP COLUMN IF EXISTS project;
+ALTER TABLE flag_trash DROP COLUMN IF EXISTS project;
+ALTER TABLE flags DROP COLUMN IF EXISTS project;
+
+DROP TABLE IF EXISTS projects;
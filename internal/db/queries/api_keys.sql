@@ -1,6 +1,6 @@
 -- name: CreateAPIKey :one
-INSERT INTO api_keys (name, key_hash, role, enabled, expires_at, created_by)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO api_keys (name, key_hash, role, enabled, expires_at, created_by, tenant, project)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: GetAPIKeyByID :one
@@ -12,11 +12,25 @@ SELECT * FROM api_keys WHERE key_hash = $1 AND enabled = true;
 -- name: ListAPIKeys :many
 SELECT * FROM api_keys ORDER BY created_at DESC;
 
+-- name: CountAPIKeys :one
+SELECT count(*) FROM api_keys;
+
+-- name: LockAPIKeySetup :exec
+SELECT pg_advisory_xact_lock(hashtext('goflagship:api_key_setup'));
+
 -- name: UpdateAPIKeyLastUsed :exec
 UPDATE api_keys SET last_used_at = now() WHERE id = $1;
 
 -- name: RevokeAPIKey :exec
-UPDATE api_keys SET enabled = false WHERE id = $1;
+UPDATE api_keys SET enabled = false, disabled_at = now(), disabled_reason = 'revoked' WHERE id = $1;
+
+-- name: DisableAPIKey :execrows
+UPDATE api_keys SET enabled = false, disabled_at = now(), disabled_reason = $2
+WHERE id = $1 AND enabled = true;
+
+-- name: ReenableAPIKey :execrows
+UPDATE api_keys SET enabled = true, disabled_at = NULL, disabled_reason = '', last_used_at = now()
+WHERE id = $1 AND enabled = false AND disabled_reason = $2 AND disabled_at >= sqlc.arg('disabled_since');
 
 -- name: DeleteAPIKey :exec
 DELETE FROM api_keys WHERE id = $1;
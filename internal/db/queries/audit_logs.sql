@@ -3,10 +3,12 @@ INSERT INTO audit_logs (
   api_key_id, user_email, action, resource_type, resource_id,
   project_id, environment, before_state, after_state, changes,
   ip_address, user_agent, request_id, status, error_message,
-  resource, details
+  resource, details, tenant, id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
-);
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+  COALESCE(sqlc.narg('id')::uuid, uuid_generate_v4())
+)
+ON CONFLICT (id) DO NOTHING;
 
 -- name: ListAuditLogs :many
 SELECT * FROM audit_logs 
@@ -17,6 +19,7 @@ WHERE
   AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
   AND (sqlc.narg('start_date')::timestamptz IS NULL OR timestamp >= sqlc.narg('start_date'))
   AND (sqlc.narg('end_date')::timestamptz IS NULL OR timestamp <= sqlc.narg('end_date'))
+  AND (sqlc.narg('tenant')::text IS NULL OR tenant = sqlc.narg('tenant'))
 ORDER BY timestamp DESC, id
 LIMIT $1 OFFSET $2;
 
@@ -28,10 +31,25 @@ WHERE
   AND (sqlc.narg('resource_id')::text IS NULL OR resource_id = sqlc.narg('resource_id'))
   AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
   AND (sqlc.narg('start_date')::timestamptz IS NULL OR timestamp >= sqlc.narg('start_date'))
-  AND (sqlc.narg('end_date')::timestamptz IS NULL OR timestamp <= sqlc.narg('end_date'));
+  AND (sqlc.narg('end_date')::timestamptz IS NULL OR timestamp <= sqlc.narg('end_date'))
+  AND (sqlc.narg('tenant')::text IS NULL OR tenant = sqlc.narg('tenant'));
 
 -- name: GetAuditLogsByAPIKey :many
 SELECT * FROM audit_logs
 WHERE api_key_id = $1
 ORDER BY timestamp DESC, id
 LIMIT $2 OFFSET $3;
+
+-- name: GetAuditLog :one
+SELECT * FROM audit_logs WHERE id = $1;
+
+-- name: SearchAuditLogs :many
+SELECT * FROM audit_logs
+WHERE
+  (strpos(lower(coalesce(resource_id, '')), lower(sqlc.arg('query')::text)) > 0
+    OR strpos(lower(coalesce(resource, '')), lower(sqlc.arg('query')::text)) > 0
+    OR strpos(lower(action), lower(sqlc.arg('query')::text)) > 0
+    OR strpos(lower(coalesce(user_email, '')), lower(sqlc.arg('query')::text)) > 0)
+  AND (sqlc.narg('tenant')::text IS NULL OR tenant = sqlc.narg('tenant'))
+ORDER BY timestamp DESC, id
+LIMIT $1;
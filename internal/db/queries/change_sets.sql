@@ -0,0 +1,25 @@
+-- name: CreateChangeSet :one
+INSERT INTO change_sets (name, description, env, operations, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetChangeSet :one
+SELECT * FROM change_sets WHERE id = $1;
+
+-- name: ListChangeSets :many
+SELECT * FROM change_sets
+WHERE (sqlc.narg('env')::text IS NULL OR env = sqlc.narg('env'))
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+ORDER BY created_at DESC, id;
+
+-- name: UpdateChangeSetOperations :one
+UPDATE change_sets
+SET operations = $2, updated_at = now()
+WHERE id = $1 AND status = 'open'
+RETURNING *;
+
+-- name: CloseChangeSet :one
+UPDATE change_sets
+SET status = $2, closed_by = $3, closed_at = now(), updated_at = now()
+WHERE id = $1 AND status = 'open'
+RETURNING *;
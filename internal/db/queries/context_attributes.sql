@@ -0,0 +1,18 @@
+-- name: ListContextAttributes :many
+SELECT * FROM context_attributes
+WHERE project = $1
+ORDER BY name;
+
+-- name: UpsertContextAttribute :one
+INSERT INTO context_attributes (project, name, type, description, example, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (project, name) DO UPDATE
+SET type = EXCLUDED.type,
+    description = EXCLUDED.description,
+    example = EXCLUDED.example,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteContextAttribute :execrows
+DELETE FROM context_attributes WHERE project = $1 AND name = $2;
@@ -0,0 +1,20 @@
+-- name: ListContextPresets :many
+SELECT * FROM context_presets
+ORDER BY name;
+
+-- name: GetContextPreset :one
+SELECT * FROM context_presets
+WHERE name = $1;
+
+-- name: UpsertContextPreset :one
+INSERT INTO context_presets (name, description, attributes, updated_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (name) DO UPDATE
+SET description = EXCLUDED.description,
+    attributes = EXCLUDED.attributes,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteContextPreset :execrows
+DELETE FROM context_presets WHERE name = $1;
@@ -0,0 +1,14 @@
+-- name: UpsertEphemeralEnvironment :one
+INSERT INTO ephemeral_environments (name, expires_at, created_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE SET expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: ListEphemeralEnvironments :many
+SELECT * FROM ephemeral_environments ORDER BY expires_at;
+
+-- name: ListExpiredEphemeralEnvironments :many
+SELECT * FROM ephemeral_environments WHERE expires_at <= $1 ORDER BY expires_at;
+
+-- name: DeleteEphemeralEnvironment :exec
+DELETE FROM ephemeral_environments WHERE name = $1;
@@ -0,0 +1,11 @@
+-- name: RecordFlagEvaluations :exec
+-- Keys that are not flags in env are skipped; times never move backwards.
+INSERT INTO flag_evaluations (key, env, last_evaluated_at)
+SELECT f.key, f.env, t.at
+FROM unnest(sqlc.arg('keys')::text[], sqlc.arg('times')::timestamptz[]) AS t(key, at)
+JOIN flags f ON f.key = t.key AND f.env = sqlc.arg('env')
+ON CONFLICT (key, env) DO UPDATE SET
+  last_evaluated_at = GREATEST(flag_evaluations.last_evaluated_at, EXCLUDED.last_evaluated_at);
+
+-- name: ListFlagEvaluations :many
+SELECT key, env, last_evaluated_at FROM flag_evaluations WHERE env = $1 ORDER BY key;
@@ -0,0 +1,9 @@
+-- name: CreateFlagComment :one
+INSERT INTO flag_comments (flag_key, env, body, audit_log_id, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListFlagComments :many
+SELECT * FROM flag_comments
+WHERE env = $1 AND flag_key = $2
+ORDER BY created_at, id;
@@ -2,20 +2,141 @@
 SELECT * FROM flags WHERE env = $1 ORDER BY key;
 
 -- name: GetFlagByKey :one
-SELECT * FROM flags WHERE key = $1;
+SELECT * FROM flags WHERE key = $1 AND env = $2;
+
+-- name: ListFlagConfigs :many
+SELECT key, env, config FROM flags ORDER BY env, key;
+
+-- name: ListExpiredFlags :many
+-- Unarchived flags whose expires_at is at or before $1, across all environments.
+SELECT * FROM flags WHERE expires_at <= $1 AND NOT archived ORDER BY env, key;
+
+-- name: ListFlagEnvironments :many
+SELECT * FROM flags WHERE key = $1 ORDER BY env;
+
+-- name: UpdateFlagConfig :exec
+UPDATE flags SET config = $3 WHERE key = $1 AND env = $2;
 
 -- name: UpsertFlag :exec
-INSERT INTO flags (key, description, enabled, rollout, expression, config, targeting_rules, env)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-ON CONFLICT (key) DO UPDATE SET
+WITH written AS (
+  INSERT INTO flags (key, description, enabled, rollout, expression, config, targeting_rules, env, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, version)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, COALESCE((SELECT max(r.version) FROM flag_revisions r WHERE r.key = $1 AND r.env = $8), 0) + 1)
+  ON CONFLICT (key, env) DO UPDATE SET
+    description = EXCLUDED.description,
+    enabled     = EXCLUDED.enabled,
+    rollout     = EXCLUDED.rollout,
+    expression  = EXCLUDED.expression,
+    config      = EXCLUDED.config,
+    targeting_rules = EXCLUDED.targeting_rules,
+    protected   = EXCLUDED.protected,
+    tags        = EXCLUDED.tags,
+    archived    = EXCLUDED.archived,
+    owner       = EXCLUDED.owner,
+    ticket_url  = EXCLUDED.ticket_url,
+    metadata    = EXCLUDED.metadata,
+    expires_at  = EXCLUDED.expires_at,
+    project     = EXCLUDED.project,
+    version     = flags.version + 1,
+    updated_at  = now()
+  RETURNING key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at
+)
+INSERT INTO flag_revisions (key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at)
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at FROM written;
+
+-- name: InsertFlag :execrows
+WITH written AS (
+  INSERT INTO flags (key, description, enabled, rollout, expression, config, targeting_rules, env, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, version)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, COALESCE((SELECT max(r.version) FROM flag_revisions r WHERE r.key = $1 AND r.env = $8), 0) + 1)
+  ON CONFLICT (key, env) DO NOTHING
+  RETURNING key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at
+)
+INSERT INTO flag_revisions (key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at)
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at FROM written;
+
+-- name: UpdateFlagIfVersion :execrows
+WITH written AS (
+  UPDATE flags SET
+    description = $2,
+    enabled     = $3,
+    rollout     = $4,
+    expression  = $5,
+    config      = $6,
+    targeting_rules = $7,
+    protected   = $9,
+    tags        = $10,
+    archived    = $11,
+    owner       = $12,
+    ticket_url  = $13,
+    metadata    = $14,
+    expires_at  = $15,
+    project     = $16,
+    version     = version + 1,
+    updated_at  = now()
+  WHERE key = $1 AND env = $8 AND version = sqlc.arg('expected_version')
+  RETURNING key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at
+)
+INSERT INTO flag_revisions (key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at)
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at FROM written;
+
+-- name: DeleteFlag :exec
+WITH deleted AS (
+  DELETE FROM flags WHERE key = $1 AND env = $2
+  RETURNING id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project
+)
+INSERT INTO flag_trash (id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project)
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM deleted
+ON CONFLICT (key, env) DO UPDATE SET
+  id          = EXCLUDED.id,
   description = EXCLUDED.description,
   enabled     = EXCLUDED.enabled,
   rollout     = EXCLUDED.rollout,
   expression  = EXCLUDED.expression,
   config      = EXCLUDED.config,
   targeting_rules = EXCLUDED.targeting_rules,
-  env         = EXCLUDED.env,
-  updated_at  = now();
+  updated_at  = EXCLUDED.updated_at,
+  protected   = EXCLUDED.protected,
+  tags        = EXCLUDED.tags,
+  archived    = EXCLUDED.archived,
+  version     = EXCLUDED.version,
+  owner       = EXCLUDED.owner,
+  ticket_url  = EXCLUDED.ticket_url,
+  metadata    = EXCLUDED.metadata,
+  expires_at  = EXCLUDED.expires_at,
+  project     = EXCLUDED.project,
+  deleted_at  = now();
 
--- name: DeleteFlag :exec
-DELETE FROM flags WHERE key = $1 AND env = $2;
+-- name: GetFlagsByKeys :many
+SELECT * FROM flags WHERE env = $1 AND key = ANY(sqlc.arg('keys')::text[]) ORDER BY key;
+
+-- name: GetFlagsUpdatedSince :many
+SELECT * FROM flags WHERE env = $1 AND updated_at > $2 ORDER BY updated_at, key;
+
+-- name: ListFlagsPage :many
+SELECT * FROM flags
+WHERE env = $1
+  AND (sqlc.narg('enabled')::boolean IS NULL OR enabled = sqlc.narg('enabled'))
+  AND (sqlc.narg('tag')::text IS NULL OR sqlc.narg('tag') = ANY(tags))
+  AND (sqlc.narg('query')::text IS NULL
+       OR strpos(lower(key), sqlc.narg('query')) > 0
+       OR strpos(lower(COALESCE(description, '')), sqlc.narg('query')) > 0)
+  AND (sqlc.narg('keys')::text[] IS NULL OR key = ANY(sqlc.narg('keys')::text[]))
+  AND (sqlc.narg('archived')::boolean IS NULL OR archived = sqlc.narg('archived'))
+  AND (sqlc.narg('project')::text IS NULL OR project = sqlc.narg('project'))
+ORDER BY
+  CASE WHEN sqlc.arg('sort')::text = '-key' THEN key END DESC,
+  CASE WHEN sqlc.arg('sort')::text = 'updated_at' THEN updated_at END ASC,
+  CASE WHEN sqlc.arg('sort')::text = '-updated_at' THEN updated_at END DESC,
+  key
+LIMIT $2 OFFSET $3;
+
+-- name: CountFlagsPage :one
+SELECT COUNT(*) FROM flags
+WHERE env = $1
+  AND (sqlc.narg('enabled')::boolean IS NULL OR enabled = sqlc.narg('enabled'))
+  AND (sqlc.narg('tag')::text IS NULL OR sqlc.narg('tag') = ANY(tags))
+  AND (sqlc.narg('query')::text IS NULL
+       OR strpos(lower(key), sqlc.narg('query')) > 0
+       OR strpos(lower(COALESCE(description, '')), sqlc.narg('query')) > 0)
+  AND (sqlc.narg('keys')::text[] IS NULL OR key = ANY(sqlc.narg('keys')::text[]))
+  AND (sqlc.narg('archived')::boolean IS NULL OR archived = sqlc.narg('archived'))
+  AND (sqlc.narg('project')::text IS NULL OR project = sqlc.narg('project'));
@@ -0,0 +1,29 @@
+-- name: GetGuardrail :one
+SELECT key, env, max_error_rate, min_requests, window_seconds, action, rollback_rollout, tripped_at, trip_reason, updated_at, request_metric, error_metric FROM flag_guardrails WHERE key = $1 AND env = $2;
+
+-- name: ListGuardrails :many
+SELECT key, env, max_error_rate, min_requests, window_seconds, action, rollback_rollout, tripped_at, trip_reason, updated_at, request_metric, error_metric FROM flag_guardrails WHERE env = $1 ORDER BY key;
+
+-- name: UpsertGuardrail :one
+INSERT INTO flag_guardrails (key, env, max_error_rate, min_requests, window_seconds, action, rollback_rollout, tripped_at, trip_reason, updated_at, request_metric, error_metric)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NULL, '', now(), $8, $9)
+ON CONFLICT (key, env) DO UPDATE SET
+  max_error_rate = EXCLUDED.max_error_rate,
+  min_requests = EXCLUDED.min_requests,
+  window_seconds = EXCLUDED.window_seconds,
+  action = EXCLUDED.action,
+  rollback_rollout = EXCLUDED.rollback_rollout,
+  request_metric = EXCLUDED.request_metric,
+  error_metric = EXCLUDED.error_metric,
+  tripped_at = NULL,
+  trip_reason = '',
+  updated_at = now()
+RETURNING key, env, max_error_rate, min_requests, window_seconds, action, rollback_rollout, tripped_at, trip_reason, updated_at, request_metric, error_metric;
+
+-- name: SetGuardrailTripped :one
+UPDATE flag_guardrails SET tripped_at = $3, trip_reason = $4, updated_at = now()
+WHERE key = $1 AND env = $2
+RETURNING key, env, max_error_rate, min_requests, window_seconds, action, rollback_rollout, tripped_at, trip_reason, updated_at, request_metric, error_metric;
+
+-- name: DeleteGuardrail :execrows
+DELETE FROM flag_guardrails WHERE key = $1 AND env = $2;
@@ -0,0 +1,24 @@
+-- name: ListProjects :many
+SELECT * FROM projects ORDER BY key;
+
+-- name: GetProject :one
+SELECT * FROM projects WHERE key = $1;
+
+-- name: CreateProject :one
+INSERT INTO projects (key, name, description)
+VALUES ($1, $2, $3)
+ON CONFLICT (key) DO NOTHING
+RETURNING *;
+
+-- name: UpdateProject :one
+UPDATE projects SET name = $2, description = $3, updated_at = now()
+WHERE key = $1
+RETURNING *;
+
+-- name: DeleteProject :execrows
+-- Projects that flags, enabled API keys or webhooks belong to are kept.
+DELETE FROM projects p
+WHERE p.key = $1
+  AND NOT EXISTS (SELECT 1 FROM flags f WHERE f.project = p.key)
+  AND NOT EXISTS (SELECT 1 FROM api_keys k WHERE k.project = p.key AND k.enabled)
+  AND NOT EXISTS (SELECT 1 FROM webhooks w WHERE w.project_id = p.key);
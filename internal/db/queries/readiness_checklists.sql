@@ -0,0 +1,17 @@
+-- name: GetReadinessChecklist :one
+SELECT * FROM readiness_checklists
+WHERE project = $1;
+
+-- name: UpsertReadinessChecklist :one
+INSERT INTO readiness_checklists (project, environments, require_description, required_tag_prefixes, updated_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (project) DO UPDATE
+SET environments = EXCLUDED.environments,
+    require_description = EXCLUDED.require_description,
+    required_tag_prefixes = EXCLUDED.required_tag_prefixes,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteReadinessChecklist :execrows
+DELETE FROM readiness_checklists WHERE project = $1;
@@ -0,0 +1,5 @@
+-- name: ReplicaLagSeconds :one
+SELECT (CASE
+  WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+  ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END)::float8 AS lag_seconds;
@@ -0,0 +1,11 @@
+-- name: ListFlagRevisions :many
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at FROM flag_revisions WHERE key = $1 AND env = $2 ORDER BY version DESC;
+
+-- name: GetFlagRevision :one
+SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at FROM flag_revisions WHERE key = $1 AND env = $2 AND version = $3;
+
+-- name: ListFlagRevisionConfigs :many
+SELECT key, env, version, config FROM flag_revisions ORDER BY env, key, version;
+
+-- name: UpdateFlagRevisionConfig :exec
+UPDATE flag_revisions SET config = $4 WHERE key = $1 AND env = $2 AND version = $3;
@@ -0,0 +1,15 @@
+-- name: CreateSDKKey :one
+INSERT INTO sdk_keys (name, env, key_hash, key_prefix, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetSDKKeyByHash :one
+SELECT * FROM sdk_keys WHERE key_hash = $1;
+
+-- name: ListSDKKeys :many
+SELECT * FROM sdk_keys
+WHERE (sqlc.narg('env')::text IS NULL OR env = sqlc.narg('env'))
+ORDER BY created_at DESC;
+
+-- name: RevokeSDKKey :execrows
+UPDATE sdk_keys SET enabled = false WHERE id = $1;
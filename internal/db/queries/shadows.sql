@@ -0,0 +1,19 @@
+-- name: ListShadowFlags :many
+SELECT key, env, enabled, rollout, expression, targeting_rules, updated_at FROM flag_shadows WHERE env = $1 ORDER BY key;
+
+-- name: GetShadowFlag :one
+SELECT key, env, enabled, rollout, expression, targeting_rules, updated_at FROM flag_shadows WHERE key = $1 AND env = $2;
+
+-- name: UpsertShadowFlag :one
+INSERT INTO flag_shadows (key, env, enabled, rollout, expression, targeting_rules, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+ON CONFLICT (key, env) DO UPDATE SET
+  enabled = EXCLUDED.enabled,
+  rollout = EXCLUDED.rollout,
+  expression = EXCLUDED.expression,
+  targeting_rules = EXCLUDED.targeting_rules,
+  updated_at = now()
+RETURNING key, env, enabled, rollout, expression, targeting_rules, updated_at;
+
+-- name: DeleteShadowFlag :execrows
+DELETE FROM flag_shadows WHERE key = $1 AND env = $2;
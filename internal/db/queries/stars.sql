@@ -0,0 +1,12 @@
+-- name: StarFlag :exec
+INSERT INTO flag_stars (owner, key, env)
+VALUES ($1, $2, $3)
+ON CONFLICT (owner, env, key) DO NOTHING;
+
+-- name: UnstarFlag :exec
+DELETE FROM flag_stars WHERE owner = $1 AND key = $2 AND env = $3;
+
+-- name: ListStarredFlagKeys :many
+SELECT key FROM flag_stars
+WHERE owner = $1 AND env = $2
+ORDER BY key;
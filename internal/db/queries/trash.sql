@@ -0,0 +1,26 @@
+-- name: ListTrashedFlags :many
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, deleted_at, owner, ticket_url, metadata, expires_at, project FROM flag_trash WHERE env = $1 ORDER BY deleted_at DESC, key;
+
+-- name: GetTrashedFlag :one
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, deleted_at, owner, ticket_url, metadata, expires_at, project FROM flag_trash WHERE key = $1 AND env = $2;
+
+-- name: RestoreFlag :one
+WITH restored AS (
+  DELETE FROM flag_trash t
+  WHERE t.key = $1 AND t.env = $2
+    AND NOT EXISTS (SELECT 1 FROM flags f WHERE f.key = $1 AND f.env = $2)
+  RETURNING id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project
+), written AS (
+  INSERT INTO flags (id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, version)
+  SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, now(), protected, tags, archived, owner, ticket_url, metadata, expires_at, project,
+    GREATEST(version, COALESCE((SELECT max(r.version) FROM flag_revisions r WHERE r.key = $1 AND r.env = $2), 0)) + 1
+  FROM restored
+  RETURNING id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project
+), revision AS (
+  INSERT INTO flag_revisions (key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, created_at)
+  SELECT key, env, version, description, enabled, rollout, expression, config, targeting_rules, protected, tags, archived, owner, ticket_url, metadata, expires_at, project, updated_at FROM written
+)
+SELECT id, key, description, enabled, rollout, expression, config, targeting_rules, env, updated_at, protected, tags, archived, version, owner, ticket_url, metadata, expires_at, project FROM written;
+
+-- name: PurgeTrash :execrows
+DELETE FROM flag_trash WHERE deleted_at < $1;
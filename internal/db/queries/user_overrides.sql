@@ -0,0 +1,21 @@
+-- name: ListUserOverrides :many
+SELECT key, env, user_id, enabled, variant, expires_at, updated_at, updated_by FROM flag_user_overrides
+WHERE env = $1 AND (expires_at IS NULL OR expires_at > now())
+ORDER BY key, user_id;
+
+-- name: UpsertUserOverride :one
+INSERT INTO flag_user_overrides (key, env, user_id, enabled, variant, expires_at, updated_at, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, now(), $7)
+ON CONFLICT (key, env, user_id) DO UPDATE SET
+  enabled = EXCLUDED.enabled,
+  variant = EXCLUDED.variant,
+  expires_at = EXCLUDED.expires_at,
+  updated_at = now(),
+  updated_by = EXCLUDED.updated_by
+RETURNING key, env, user_id, enabled, variant, expires_at, updated_at, updated_by;
+
+-- name: DeleteUserOverride :execrows
+DELETE FROM flag_user_overrides WHERE key = $1 AND env = $2 AND user_id = $3;
+
+-- name: DeleteExpiredUserOverrides :execrows
+DELETE FROM flag_user_overrides WHERE expires_at <= $1;
@@ -1,6 +1,6 @@
 -- name: CreateWebhook :one
-INSERT INTO webhooks (url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+INSERT INTO webhooks (url, description, enabled, events, project_id, environments, secret, max_retries, timeout_seconds, tenant, delivery_retention_days, delivery_max_rows, headers)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE(sqlc.narg('headers')::jsonb, '{}'))
 RETURNING *;
 
 -- name: ListWebhooks :many
@@ -19,6 +19,9 @@ UPDATE webhooks SET
   environments = $7, 
   max_retries = $8,
   timeout_seconds = $9, 
+  delivery_retention_days = $10,
+  delivery_max_rows = $11,
+  headers = COALESCE(sqlc.narg('headers')::jsonb, headers),
   updated_at = now()
 WHERE id = $1;
 
@@ -31,6 +34,12 @@ SELECT * FROM webhooks WHERE enabled = true ORDER BY created_at DESC;
 -- name: UpdateWebhookLastTriggered :exec
 UPDATE webhooks SET last_triggered_at = now() WHERE id = $1;
 
+-- name: UpdateWebhookSecret :exec
+UPDATE webhooks SET secret = $2 WHERE id = $1;
+
+-- name: UpdateWebhookHeaders :exec
+UPDATE webhooks SET headers = $2 WHERE id = $1;
+
 -- name: CreateWebhookDelivery :one
 INSERT INTO webhook_deliveries (
   webhook_id, 
@@ -41,9 +50,10 @@ INSERT INTO webhook_deliveries (
   error_message, 
   duration_ms, 
   success, 
-  retry_count
+  retry_count,
+  audit_event_id
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING *;
 
 -- name: ListWebhookDeliveries :many
@@ -52,5 +62,26 @@ WHERE webhook_id = $1
 ORDER BY timestamp DESC 
 LIMIT $2 OFFSET $3;
 
+-- name: ListFailedWebhookDeliveriesSince :many
+SELECT * FROM webhook_deliveries
+WHERE success = false AND timestamp >= $1
+ORDER BY timestamp DESC
+LIMIT $2;
+
 -- name: CountWebhookDeliveries :one
 SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1;
+
+-- name: DeleteWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries WHERE webhook_id = $1;
+
+-- name: DeleteWebhookDeliveriesBefore :execrows
+DELETE FROM webhook_deliveries WHERE webhook_id = $1 AND timestamp < $2;
+
+-- name: DeleteWebhookDeliveriesBeyond :execrows
+-- Keeps the newest $2 deliveries of a webhook.
+DELETE FROM webhook_deliveries WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE webhook_id = $1
+  ORDER BY timestamp DESC
+  OFFSET $2
+);
@@ -0,0 +1,213 @@
+// Package encryption encrypts sensitive values (webhook secrets, flag
+// configs) before they are written to the database.
+//
+// Values are envelope encrypted: each value gets a fresh random 256-bit data
+// key, the value is sealed with the data key (AES-256-GCM), and the data key
+// is wrapped with a key encryption key (KEK) from the Keyring. The stored form
+// is a self-describing string:
+//
+//	enc:v1:<kek id>:<base64 wrapped data key>:<base64 sealed value>
+//
+// The Keyring holds any number of KEKs; the first (primary) one encrypts new
+// values and all of them decrypt, so a KEK is rotated by adding a new primary
+// key, re-encrypting stored values (Keyring.Rotate) and then removing the old
+// key. Values without the "enc:" prefix are plaintext written before
+// encryption was enabled and are returned unchanged.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	prefix  = "enc:v1:"
+	keySize = 32 // AES-256
+)
+
+// ErrNoKey is returned when decrypting a value whose KEK is not in the
+// keyring (or no keyring is configured).
+var ErrNoKey = errors.New("encryption: key not available")
+
+// Keyring holds the key encryption keys. A nil *Keyring is valid and means
+// encryption is disabled: Encrypt returns values unchanged and only
+// plaintext values can be decrypted.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// ParseKeys builds a Keyring from a comma-separated list of "<id>:<key>"
+// pairs, where key is a base64-encoded 32-byte AES key. The first key is the
+// primary. An empty spec returns a nil Keyring (encryption disabled).
+func ParseKeys(spec string) (*Keyring, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	k := &Keyring{keys: map[string]cipher.AEAD{}}
+	for _, entry := range strings.Split(spec, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("encryption: key %q must be <id>:<base64 key>", entry)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != keySize {
+			return nil, fmt.Errorf("encryption: key %q must be %d bytes, base64-encoded", id, keySize)
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("encryption: duplicate key id %q", id)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+		if k.primary == "" {
+			k.primary = id
+		}
+	}
+	return k, nil
+}
+
+// PrimaryKeyID is the ID of the key new values are encrypted with ("" when
+// encryption is disabled).
+func (k *Keyring) PrimaryKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.primary
+}
+
+// IsEncrypted reports whether value is in the encrypted format.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt encrypts value with a new data key wrapped by the primary KEK. A
+// nil keyring returns value unchanged.
+func (k *Keyring) Encrypt(value string) (string, error) {
+	if k == nil {
+		return value, nil
+	}
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("encryption: generate data key: %w", err)
+	}
+	wrapped, err := seal(k.keys[k.primary], dataKey)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(aead, []byte(value))
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.primary + ":" + base64.StdEncoding.EncodeToString(wrapped) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of value. Plaintext values are returned
+// unchanged. ErrNoKey is returned if the value's KEK is unknown.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("encryption: malformed encrypted value")
+	}
+	if k == nil {
+		return "", ErrNoKey
+	}
+	kek, ok := k.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrNoKey, parts[0])
+	}
+	wrapped, err1 := base64.StdEncoding.DecodeString(parts[1])
+	sealed, err2 := base64.StdEncoding.DecodeString(parts[2])
+	if err1 != nil || err2 != nil {
+		return "", errors.New("encryption: malformed encrypted value")
+	}
+	dataKey, err := open(kek, wrapped)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether value should be re-encrypted: it is
+// plaintext or encrypted with a key other than the primary.
+func (k *Keyring) NeedsRotation(value string) bool {
+	if k == nil {
+		return false
+	}
+	return !strings.HasPrefix(value, prefix+k.primary+":")
+}
+
+// Rotate re-encrypts value with the primary key. It returns value unchanged
+// and false if it is already encrypted with the primary key.
+func (k *Keyring) Rotate(value string) (string, bool, error) {
+	if !k.NeedsRotation(value) {
+		return value, false, nil
+	}
+	plaintext, err := k.Decrypt(value)
+	if err != nil {
+		return "", false, err
+	}
+	rotated, err := k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return rotated, true, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	return aead, nil
+}
+
+// seal encrypts plaintext, prefixing the result with a random nonce.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("encryption: generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open reverses seal.
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encryption: malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("encryption: decryption failed (wrong key or corrupted value)")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,112 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	k, err := ParseKeys("k1:" + testKey(1))
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+
+	enc, err := k.Encrypt("s3cret")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !IsEncrypted(enc) || !strings.HasPrefix(enc, "enc:v1:k1:") || strings.Contains(enc, "s3cret") {
+		t.Errorf("unexpected ciphertext %q", enc)
+	}
+	again, _ := k.Encrypt("s3cret")
+	if again == enc {
+		t.Error("encrypting twice should use fresh data keys and nonces")
+	}
+
+	got, err := k.Decrypt(enc)
+	if err != nil || got != "s3cret" {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+	if got, err := k.Decrypt("legacy-plaintext"); err != nil || got != "legacy-plaintext" {
+		t.Errorf("plaintext passthrough = %q, %v", got, err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, _ := ParseKeys("k1:" + testKey(1))
+	enc, _ := old.Encrypt("s3cret")
+
+	k, err := ParseKeys("k2:" + testKey(2) + ", k1:" + testKey(1))
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	if k.PrimaryKeyID() != "k2" {
+		t.Errorf("primary = %q, want k2", k.PrimaryKeyID())
+	}
+	if !k.NeedsRotation(enc) || !k.NeedsRotation("plaintext") {
+		t.Error("old-key and plaintext values should need rotation")
+	}
+
+	rotated, changed, err := k.Rotate(enc)
+	if err != nil || !changed || !strings.HasPrefix(rotated, "enc:v1:k2:") {
+		t.Fatalf("Rotate = %q, %v, %v", rotated, changed, err)
+	}
+	if _, changed, _ := k.Rotate(rotated); changed {
+		t.Error("value under the primary key should not be rotated again")
+	}
+
+	newOnly, _ := ParseKeys("k2:" + testKey(2))
+	if got, err := newOnly.Decrypt(rotated); err != nil || got != "s3cret" {
+		t.Errorf("decrypt after removing old key = %q, %v", got, err)
+	}
+	if _, err := newOnly.Decrypt(enc); !errors.Is(err, ErrNoKey) {
+		t.Errorf("decrypt with removed key: expected ErrNoKey, got %v", err)
+	}
+}
+
+func TestKeyring_Nil(t *testing.T) {
+	var k *Keyring
+	if got, err := k.Encrypt("x"); err != nil || got != "x" {
+		t.Errorf("nil Encrypt = %q, %v", got, err)
+	}
+	if k.NeedsRotation("x") {
+		t.Error("nil keyring should never rotate")
+	}
+	other, _ := ParseKeys("k1:" + testKey(1))
+	enc, _ := other.Encrypt("x")
+	if _, err := k.Decrypt(enc); !errors.Is(err, ErrNoKey) {
+		t.Errorf("nil Decrypt of encrypted value: expected ErrNoKey, got %v", err)
+	}
+}
+
+func TestKeyring_WrongKeyFails(t *testing.T) {
+	a, _ := ParseKeys("k1:" + testKey(1))
+	b, _ := ParseKeys("k1:" + testKey(2))
+	enc, _ := a.Encrypt("x")
+	if _, err := b.Decrypt(enc); err == nil {
+		t.Error("expected decryption with a different key to fail")
+	}
+}
+
+func TestParseKeys_Invalid(t *testing.T) {
+	if k, err := ParseKeys("  "); k != nil || err != nil {
+		t.Errorf("empty spec: got %v, %v", k, err)
+	}
+	for _, spec := range []string{
+		"nokey",
+		"k1:notbase64!",
+		"k1:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"k1:" + testKey(1) + ",k1:" + testKey(2),
+	} {
+		if _, err := ParseKeys(spec); err == nil {
+			t.Errorf("ParseKeys(%q): expected error", spec)
+		}
+	}
+}
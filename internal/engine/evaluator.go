@@ -1,17 +1,27 @@
 package engine
 
 import (
+	"fmt"
+	"log"
+	"runtime/debug"
 	"sort"
 	"strings"
 
 	"github.com/TimurManjosov/goflagship/internal/rules"
 	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 	"github.com/cespare/xxhash/v2"
 )
 
 // Evaluate computes deterministic rule-based evaluation for a flag and user context.
-func Evaluate(flag *store.Flag, context *UserContext) EvaluationResult {
-	result := EvaluationResult{Variant: defaultVariant}
+// If a rule cannot be evaluated, the result has ReasonError, the flag config
+// as value and the error in Err; the caller serves the flag's on_error state.
+// A panic while evaluating a corrupt flag is recovered and reported the same
+// way (see recoverFlag).
+func Evaluate(flag *store.Flag, context *UserContext) (result EvaluationResult) {
+	defer recoverFlag(flag, &result)
+
+	result = EvaluationResult{Variant: defaultVariant}
 	if flag == nil {
 		result.Reason = string(ReasonDisabled)
 		return result
@@ -25,7 +35,13 @@ func Evaluate(flag *store.Flag, context *UserContext) EvaluationResult {
 	}
 
 	for _, rule := range flag.TargetingRules {
-		if !matchesAllConditions(context, rule.Conditions) {
+		matched, err := matchesAllConditions(context, rule.Conditions)
+		if err != nil {
+			telemetry.FlagEvaluationErrors.WithLabelValues(flag.Key).Inc()
+			result.Reason, result.Err = string(ReasonError), fmt.Errorf("rule %q: %w", rule.ID, err)
+			return result
+		}
+		if !matched {
 			continue
 		}
 
@@ -42,18 +58,59 @@ func Evaluate(flag *store.Flag, context *UserContext) EvaluationResult {
 	return result
 }
 
-func matchesAllConditions(ctx *UserContext, conditions []rules.Condition) bool {
+// EvaluateOverride computes the result of an enabled flag for a user whose
+// override serves variant instead of the targeting rules. An empty variant
+// keeps the user's default variant assignment. Disabled flags and overrides
+// that disable the flag are handled by the caller.
+func EvaluateOverride(flag *store.Flag, context *UserContext, variant string) (result EvaluationResult) {
+	defer recoverFlag(flag, &result)
+
+	if variant == "" {
+		variant = selectVariant(flag.Key, context, flag.Config, defaultDistribution(flag))
+	}
+	return EvaluationResult{
+		Value:   resolveValue(flag, variant),
+		Variant: variant,
+		Reason:  string(ReasonOverride),
+	}
+}
+
+// recoverFlag recovers a panic while evaluating flag. Deferred by Evaluate and
+// EvaluateOverride, it replaces *result with a ReasonError result, so one
+// corrupt flag fails on its own instead of failing every flag of the request.
+func recoverFlag(flag *store.Flag, result *EvaluationResult) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	var key string
+	*result = EvaluationResult{Variant: defaultVariant, Reason: string(ReasonError), Err: fmt.Errorf("evaluation panicked: %v", r)}
+	if flag != nil {
+		key, result.Value = flag.Key, flag.Config
+	}
+	log.Printf("[engine] recovered panic evaluating flag: key=%s error=%v\n%s", key, r, debug.Stack())
+	telemetry.EvaluationErrors.WithLabelValues(telemetry.EvalErrorPanic).Inc()
+	telemetry.FlagEvaluationErrors.WithLabelValues(key).Inc()
+}
+
+// matchesAllConditions reports whether ctx meets every condition. It fails
+// on conditions it cannot evaluate, such as unknown operators.
+func matchesAllConditions(ctx *UserContext, conditions []rules.Condition) (bool, error) {
 	for _, condition := range conditions {
 		userValue, ok := getContextValue(ctx, condition.Property)
 		if !ok {
-			return false
+			return false, nil
 		}
 		handler, ok := getOperatorHandler(condition.Operator)
-		if !ok || !handler.Check(userValue, condition.Value) {
-			return false
+		if !ok {
+			telemetry.EvaluationErrors.WithLabelValues(telemetry.EvalErrorUnknownOperator).Inc()
+			return false, fmt.Errorf("unknown operator %q", condition.Operator)
+		}
+		if !handler.Check(userValue, condition.Value) {
+			return false, nil
 		}
 	}
-	return true
+	return true, nil
 }
 
 func getContextValue(ctx *UserContext, property string) (any, bool) {
@@ -8,6 +8,8 @@ import (
 
 	"github.com/TimurManjosov/goflagship/internal/rules"
 	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestOperatorHandlers(t *testing.T) {
@@ -194,7 +196,67 @@ func TestEvaluate_ContextLookupAndSafety(t *testing.T) {
 		}},
 	}
 	res := Evaluate(unknownOperator, ctx)
-	if res.Reason != string(ReasonDefaultRollout) {
-		t.Fatalf("unknown operator should fail condition and fallback to default rollout")
+	if res.Reason != string(ReasonError) || res.Err == nil || res.Variant != defaultVariant {
+		t.Fatalf("unknown operator should fail the evaluation, got %+v", res)
+	}
+}
+
+func TestEvaluate_UnknownOperatorCountsError(t *testing.T) {
+	flag := &store.Flag{
+		Key:     "unknown_op",
+		Enabled: true,
+		TargetingRules: []rules.Rule{{
+			ID:           "r1",
+			Conditions:   []rules.Condition{{Property: "plan", Operator: rules.Operator("approximately"), Value: "pro"}},
+			Distribution: map[string]int{"on": 100},
+		}},
+	}
+	counter := telemetry.EvaluationErrors.WithLabelValues(telemetry.EvalErrorUnknownOperator)
+	before := testutil.ToFloat64(counter)
+
+	result := Evaluate(flag, &UserContext{ID: "user-1", Plan: "pro"})
+
+	if result.Reason == string(ReasonTargetingMatch) {
+		t.Error("rule with unknown operator must not match")
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("unknown operator errors incremented by %v, want 1", got)
+	}
+}
+
+type panicHandler struct{}
+
+func (panicHandler) Check(userValue, ruleValue any) bool {
+	return ruleValue.(map[string]any)["limit"] == userValue // panics unless ruleValue is a map
+}
+
+func TestEvaluate_RecoversPanic(t *testing.T) {
+	operatorHandlers["panics"] = panicHandler{}
+	defer delete(operatorHandlers, "panics")
+
+	flag := &store.Flag{
+		Key:     "corrupt",
+		Enabled: true,
+		Config:  map[string]any{"on_error": true},
+		TargetingRules: []rules.Rule{{
+			ID:           "r1",
+			Conditions:   []rules.Condition{{Property: "plan", Operator: "panics", Value: "pro"}},
+			Distribution: map[string]int{"on": 100},
+		}},
+	}
+	counter := telemetry.EvaluationErrors.WithLabelValues(telemetry.EvalErrorPanic)
+	before := testutil.ToFloat64(counter)
+
+	result := Evaluate(flag, &UserContext{ID: "user-1", Plan: "pro"})
+	if result.Reason != string(ReasonError) || result.Err == nil || !reflect.DeepEqual(result.Value, flag.Config) {
+		t.Errorf("expected an error result with the flag config, got %+v", result)
+	}
+
+	result = EvaluateOverride(nil, &UserContext{ID: "user-1"}, "")
+	if result.Reason != string(ReasonError) || result.Err == nil {
+		t.Errorf("expected an error result for a nil flag, got %+v", result)
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("panic errors incremented by %v, want 2", got)
 	}
 }
@@ -7,6 +7,8 @@ const (
 	ReasonDisabled       Reason = "DISABLED"
 	ReasonTargetingMatch Reason = "TARGETING_MATCH"
 	ReasonDefaultRollout Reason = "DEFAULT_ROLLOUT"
+	ReasonError          Reason = "ERROR"    // a rule failed; see rules.OnErrorEnabled
+	ReasonOverride       Reason = "OVERRIDE" // the user has an override, see EvaluateOverride
 
 	defaultVariant = "control"
 )
@@ -26,4 +28,5 @@ type EvaluationResult struct {
 	Variant     string `json:"variant"`
 	Reason      string `json:"reason"`
 	MatchedRule string `json:"matchedRule,omitempty"`
+	Err         error  `json:"-"` // why evaluation failed, with ReasonError
 }
@@ -29,7 +29,7 @@
 //   - Empty userID: rollout should fail, expression may still pass
 //   - Rollout 0%: should always return disabled
 //   - Rollout 100%: should always return enabled
-//   - Invalid expression: should return disabled (error handled internally)
+//   - Invalid expression: should return the on_error value with ReasonError
 //   - No variants: should return flag-level config
 //   - Variants with no config: should fall back to flag-level config
 //   - Empty salt: evaluation works but reduces hash quality
@@ -48,11 +48,16 @@
 package evaluation
 
 import (
+	"fmt"
+	"log"
+	"runtime/debug"
 	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/rollout"
+	"github.com/TimurManjosov/goflagship/internal/rules"
 	"github.com/TimurManjosov/goflagship/internal/snapshot"
 	"github.com/TimurManjosov/goflagship/internal/targeting"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 )
 
 // Context represents user context for flag evaluation.
@@ -67,6 +72,27 @@ type Result struct {
 	Enabled bool           `json:"enabled"`
 	Variant string         `json:"variant,omitempty"`
 	Config  map[string]any `json:"config,omitempty"`
+	Reason  string         `json:"reason,omitempty"` // one of the Reason constants
+	Err     error          `json:"-"`                // why evaluation failed, with ReasonError
+}
+
+// Reasons explain a Result: which evaluation step decided it.
+const (
+	ReasonDisabled        = "DISABLED"         // the flag is disabled
+	ReasonNoMatch         = "NO_MATCH"         // the expression did not match
+	ReasonRolloutExcluded = "ROLLOUT_EXCLUDED" // the user is outside the rollout percentage
+	ReasonTargetingMatch  = "TARGETING_MATCH"  // the expression matched and the user is rolled out
+	ReasonDefaultRollout  = "DEFAULT_ROLLOUT"  // no expression; the user is rolled out
+	ReasonError           = "ERROR"            // the expression failed; the flag's on_error value was served
+	ReasonOverride        = "OVERRIDE"         // the user has an override for the flag, see EvaluateOverride
+	ReasonFlagNotFound    = "FLAG_NOT_FOUND"   // the requested flag is not served in the environment
+)
+
+// Override forces the result of a flag for one user. An empty Variant keeps
+// the user's normal variant assignment.
+type Override struct {
+	Enabled bool
+	Variant string
 }
 
 // EvaluateResponse represents the response from the evaluate endpoint.
@@ -86,13 +112,18 @@ type EvaluateResponse struct {
 //
 // Postconditions:
 //   - Always returns a Result with Key matching flag.Key
-//   - Returns Enabled=false if any evaluation step fails or user doesn't match
+//   - Returns Enabled=false if the user doesn't match
+//   - Returns the flag's on_error value (rules.OnErrorEnabled) with Reason=ReasonError
+//     and Err set if the expression fails at runtime
 //   - Result.Variant is empty string when no variants configured or assignment fails
 //   - Result.Config is nil when neither flag nor variant has config
+//   - Result.Reason names the step that decided the result
+//   - A panic while evaluating a corrupt flag is recovered and reported like
+//     an expression failure (see recoverFlag); it never reaches the caller
 //
 // Evaluation order (each step can short-circuit to disabled):
 //   1. Check enabled field → if false, return disabled
-//   2. Evaluate expression (if present) → if false or error, return disabled
+//   2. Evaluate expression (if present) → if false, return disabled; on error, return the on_error value
 //   3. Check rollout (if <100) → hash user ID to determine inclusion
 //      - Special cases: empty userID always excluded, rollout=0 always disabled, rollout=100 always enabled
 //   4. Determine variant (if configured) → assign based on user bucket
@@ -103,13 +134,16 @@ type EvaluateResponse struct {
 //   - Empty salt: hashing works but produces less random distribution
 //   - flag.Rollout = 0: fast-path returns disabled without hashing
 //   - flag.Rollout = 100: fast-path returns enabled without hashing
-//   - Invalid expression: treated as evaluation failure, returns disabled
+//   - Invalid expression: returns the on_error value (enabled with flag-level config, or disabled)
 //   - No variants: returns flag-level config
 //   - Variant with no config: falls back to flag-level config
-func EvaluateFlag(flag snapshot.FlagView, ctx Context, salt string) Result {
-	result := Result{
+func EvaluateFlag(flag snapshot.FlagView, ctx Context, salt string) (result Result) {
+	defer recoverFlag(flag, &result)
+
+	result = Result{
 		Key:     flag.Key,
 		Enabled: false,
+		Reason:  ReasonDisabled,
 	}
 
 	// Step 1: Check enabled field
@@ -118,26 +152,40 @@ func EvaluateFlag(flag snapshot.FlagView, ctx Context, salt string) Result {
 	}
 
 	// Step 2: Evaluate expression (if present)
+	reason := ReasonDefaultRollout
 	if flag.Expression != nil && *flag.Expression != "" {
 		// Build targeting context from user attributes
 		targetCtx := buildTargetingContext(ctx)
 
-		match, err := targeting.Evaluate(*flag.Expression, targetCtx)
-		if err != nil || !match {
+		result.Reason = ReasonNoMatch
+		match, err := evaluateExpression(*flag.Expression, targetCtx)
+		if err != nil {
+			telemetry.EvaluationErrors.WithLabelValues(telemetry.EvalErrorExpression).Inc()
+			telemetry.FlagEvaluationErrors.WithLabelValues(flag.Key).Inc()
+			result.Reason, result.Err = ReasonError, err
+			if rules.OnErrorEnabled(flag.Config) {
+				result.Enabled, result.Config = true, flag.Config
+			}
+			return result
+		}
+		if !match {
 			return result
 		}
+		reason = ReasonTargetingMatch
 	}
 
 	// Step 3: Check rollout
 	if flag.Rollout < 100 {
 		isRolledOut, err := rollout.IsRolledOut(ctx.UserID, flag.Key, flag.Rollout, salt)
 		if err != nil || !isRolledOut {
+			result.Reason = ReasonRolloutExcluded
 			return result
 		}
 	}
 
 	// Flag is enabled for this user
 	result.Enabled = true
+	result.Reason = reason
 
 	// Step 4: Determine variant and resolve config
 	result.Variant, result.Config = resolveVariantAndConfig(flag, ctx.UserID, salt)
@@ -145,6 +193,35 @@ func EvaluateFlag(flag snapshot.FlagView, ctx Context, salt string) Result {
 	return result
 }
 
+// EvaluateOverride evaluates a flag for a user with override o, which takes
+// the place of the expression and rollout. A disabled flag stays disabled:
+// overrides do not bypass the kill switch. The result has ReasonOverride and,
+// when enabled, the config of o.Variant.
+func EvaluateOverride(flag snapshot.FlagView, ctx Context, salt string, o Override) (result Result) {
+	defer recoverFlag(flag, &result)
+
+	result = Result{Key: flag.Key, Reason: ReasonDisabled}
+	if !flag.Enabled {
+		return result
+	}
+	result.Reason = ReasonOverride
+	if !o.Enabled {
+		return result
+	}
+	result.Enabled = true
+	if o.Variant == "" {
+		result.Variant, result.Config = resolveVariantAndConfig(flag, ctx.UserID, salt)
+		return result
+	}
+	result.Variant, result.Config = o.Variant, flag.Config
+	for _, v := range flag.Variants {
+		if v.Name == o.Variant && v.Config != nil {
+			result.Config = v.Config
+		}
+	}
+	return result
+}
+
 // EvaluateAll evaluates all flags for the given context.
 //
 // Preconditions:
@@ -156,13 +233,13 @@ func EvaluateFlag(flag snapshot.FlagView, ctx Context, salt string) Result {
 // Postconditions:
 //   - Returns slice of Results (never nil, may be empty)
 //   - When keys is empty, evaluates all flags in map
-//   - When keys is non-empty, only evaluates flags that exist in map
-//   - Non-existent keys in filter are silently ignored (no error)
-//   - Result order is non-deterministic (map iteration order)
+//   - When keys is non-empty, returns one Result per key, in keys order
+//   - Keys not in map get a disabled Result with ReasonFlagNotFound (see NotFound)
+//   - Result order is non-deterministic when keys is empty (map iteration order)
 //
 // Edge Cases:
 //   - Empty flags map: returns empty slice (not nil)
-//   - keys contains non-existent flag keys: those keys are skipped
+//   - keys contains non-existent flag keys: those keys are reported as not found
 //   - keys is empty: evaluates all flags
 //   - flags is nil: returns empty slice
 func EvaluateAll(flags map[string]snapshot.FlagView, ctx Context, salt string, keys []string) []Result {
@@ -175,8 +252,9 @@ func EvaluateAll(flags map[string]snapshot.FlagView, ctx Context, salt string, k
 		for _, key := range keys {
 			if flag, exists := flags[key]; exists {
 				results = append(results, EvaluateFlag(flag, ctx, salt))
+			} else {
+				results = append(results, NotFound(key))
 			}
-			// Non-existent keys are silently ignored
 		}
 	} else {
 		// When evaluating all flags, allocate exact size needed
@@ -190,6 +268,36 @@ func EvaluateAll(flags map[string]snapshot.FlagView, ctx Context, salt string, k
 	return results
 }
 
+// NotFound is the Result of a requested key that is not in the flags being
+// evaluated, e.g. a flag that is only defined in another environment or is
+// archived.
+func NotFound(key string) Result {
+	return Result{Key: key, Reason: ReasonFlagNotFound}
+}
+
+// evaluateExpression evaluates targeting expressions; tests replace it to
+// simulate a corrupt flag.
+var evaluateExpression = targeting.Evaluate
+
+// recoverFlag recovers a panic while evaluating flag. Deferred by EvaluateFlag
+// and EvaluateOverride, it replaces *result with an error result serving the
+// flag's on_error value, so one corrupt flag fails on its own instead of
+// failing every flag of the request. The panic is logged with its stack and
+// counted like other evaluation errors.
+func recoverFlag(flag snapshot.FlagView, result *Result) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	log.Printf("[evaluation] recovered panic evaluating flag: key=%s error=%v\n%s", flag.Key, r, debug.Stack())
+	telemetry.EvaluationErrors.WithLabelValues(telemetry.EvalErrorPanic).Inc()
+	telemetry.FlagEvaluationErrors.WithLabelValues(flag.Key).Inc()
+	*result = Result{Key: flag.Key, Reason: ReasonError, Err: fmt.Errorf("evaluation panicked: %v", r)}
+	if flag.Enabled && rules.OnErrorEnabled(flag.Config) {
+		result.Enabled, result.Config = true, flag.Config
+	}
+}
+
 // buildTargetingContext creates a targeting.UserContext from evaluation context.
 //
 // Preconditions:
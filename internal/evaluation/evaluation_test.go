@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/targeting"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestEvaluateFlag_DisabledFlag(t *testing.T) {
@@ -107,6 +110,88 @@ func TestEvaluateFlag_WithExpression_NoMatch(t *testing.T) {
 	}
 }
 
+func TestEvaluateFlag_InvalidExpressionCountsError(t *testing.T) {
+	expr := `{"no_such_operator": [{"var": "plan"}, "premium"]}`
+	flag := snapshot.FlagView{
+		Key:        "broken_expr",
+		Enabled:    true,
+		Rollout:    100,
+		Expression: &expr,
+	}
+	counter := telemetry.EvaluationErrors.WithLabelValues(telemetry.EvalErrorExpression)
+	before := testutil.ToFloat64(counter)
+
+	result := EvaluateFlag(flag, Context{UserID: "user-123"}, "test-salt")
+
+	if result.Enabled {
+		t.Error("Expected flag to be disabled when its expression fails")
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("expression errors incremented by %v, want 1", got)
+	}
+}
+
+func TestEvaluateFlag_InvalidExpressionServesOnError(t *testing.T) {
+	expr := `{"no_such_operator": [{"var": "plan"}, "premium"]}`
+	flag := snapshot.FlagView{
+		Key:        "broken_kill_switch",
+		Enabled:    true,
+		Rollout:    100,
+		Expression: &expr,
+		Config:     map[string]any{"on_error": true, "limit": 5.0},
+	}
+	counter := telemetry.FlagEvaluationErrors.WithLabelValues(flag.Key)
+	before := testutil.ToFloat64(counter)
+
+	result := EvaluateFlag(flag, Context{UserID: "user-123"}, "test-salt")
+
+	if !result.Enabled || result.Reason != ReasonError || result.Err == nil || result.Config["limit"] != 5.0 {
+		t.Errorf("expected the on_error value with ReasonError, got %+v", result)
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("flag evaluation errors incremented by %v, want 1", got)
+	}
+}
+
+func TestEvaluateFlag_RecoversPanic(t *testing.T) {
+	orig := evaluateExpression
+	defer func() { evaluateExpression = orig }()
+	evaluateExpression = func(expression string, _ targeting.UserContext) (bool, error) {
+		if expression == "corrupt" {
+			var attrs map[string]any
+			attrs["plan"] = "premium" // assignment to a nil map
+		}
+		return true, nil
+	}
+
+	corrupt, ok := "corrupt", "ok"
+	flags := map[string]snapshot.FlagView{
+		"corrupt":      {Key: "corrupt", Enabled: true, Rollout: 100, Expression: &corrupt},
+		"corrupt_safe": {Key: "corrupt_safe", Enabled: true, Rollout: 100, Expression: &corrupt, Config: map[string]any{"on_error": true}},
+		"healthy":      {Key: "healthy", Enabled: true, Rollout: 100, Expression: &ok},
+	}
+	counter := telemetry.EvaluationErrors.WithLabelValues(telemetry.EvalErrorPanic)
+	before := testutil.ToFloat64(counter)
+
+	results := EvaluateAll(flags, Context{UserID: "user-123"}, "test-salt", []string{"corrupt", "corrupt_safe", "healthy"})
+
+	if len(results) != 3 {
+		t.Fatalf("expected a result for every flag, got %+v", results)
+	}
+	if r := results[0]; r.Key != "corrupt" || r.Enabled || r.Reason != ReasonError || r.Err == nil {
+		t.Errorf("expected the corrupt flag to fail off, got %+v", r)
+	}
+	if r := results[1]; r.Key != "corrupt_safe" || !r.Enabled || r.Reason != ReasonError {
+		t.Errorf("expected the corrupt flag to serve on_error, got %+v", r)
+	}
+	if r := results[2]; r.Key != "healthy" || !r.Enabled || r.Reason != ReasonTargetingMatch {
+		t.Errorf("expected the healthy flag to evaluate normally, got %+v", r)
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("panic errors incremented by %v, want 2", got)
+	}
+}
+
 func TestEvaluateFlag_WithRollout_ZeroPercent(t *testing.T) {
 	flag := snapshot.FlagView{
 		Key:     "rollout_flag",
@@ -270,12 +355,15 @@ func TestEvaluateAll_NonExistentKey(t *testing.T) {
 
 	results := EvaluateAll(flags, ctx, salt, keys)
 
-	// Should only return the existing flag
-	if len(results) != 1 {
-		t.Errorf("Expected 1 result, got %d", len(results))
+	// The missing key is reported, not skipped
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Key != "flag1" || !results[0].Enabled {
+		t.Errorf("Expected flag1 enabled, got %+v", results[0])
 	}
-	if results[0].Key != "flag1" {
-		t.Errorf("Expected flag1, got %s", results[0].Key)
+	if results[1].Key != "nonexistent" || results[1].Enabled || results[1].Reason != ReasonFlagNotFound {
+		t.Errorf("Expected nonexistent to be not found, got %+v", results[1])
 	}
 }
 
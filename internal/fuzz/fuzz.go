@@ -0,0 +1,179 @@
+// Package fuzz holds fuzz targets for the flag evaluation path: JSON Logic
+// expressions, targeting rule validation and flag evaluation. A target takes
+// arbitrary bytes and fails if the code under test panics or breaks one of
+// its invariants; rejecting malformed input is not a failure.
+//
+// The targets run under go test -fuzz (see fuzz_test.go):
+//
+//	go test ./internal/fuzz -run '^$' -fuzz FuzzExpression -fuzztime 1m
+//
+// and in the `flagship fuzz` command, which mutates the seed corpus with Run
+// for a fixed time and needs no Go toolchain.
+package fuzz
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"slices"
+
+	"github.com/TimurManjosov/goflagship/internal/engine"
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/targeting"
+)
+
+// Target is a fuzz target and the seed corpus it starts from.
+type Target struct {
+	Name  string
+	Seeds []string
+	Fn    func(data []byte) error
+}
+
+// Targets lists every fuzz target, by name.
+var Targets = []Target{
+	{Name: "expression", Seeds: expressionSeeds, Fn: Expression},
+	{Name: "rules", Seeds: ruleSeeds, Fn: Rule},
+	{Name: "evaluation", Seeds: evaluationSeeds, Fn: Evaluation},
+}
+
+// LookupTarget returns the target called name.
+func LookupTarget(name string) (Target, bool) {
+	i := slices.IndexFunc(Targets, func(t Target) bool { return t.Name == name })
+	if i < 0 {
+		return Target{}, false
+	}
+	return Targets[i], true
+}
+
+// PanicError is returned by a target when the code under test panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// guard runs fn, turning a panic into a *PanicError.
+func guard(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+var expressionSeeds = []string{
+	`{"==": [{"var": "plan"}, "premium"]}`,
+	`{"in": [{"var": "country"}, ["US", "CA"]]}`,
+	`{"and": [{">=": [{"var": "age"}, 18]}, {"!": [{"var": "isBeta"}]}]}`,
+	`{"or": [{"in": ["@acme.com", {"var": "email"}]}, {"==": [{"var": "id"}, "user-1"]}]}`,
+	`{"if": [{"var": "isBeta"}, true, {"<": [{"var": "age"}, 30]}]}`,
+	`{"some": [{"var": "groups"}, {"==": [{"var": ""}, "qa"]}]}`,
+	`{"!": [{"!": [{"!": [true]}]}]}`,
+	`true`,
+}
+
+// expressionContext is the context expressions are evaluated against.
+var expressionContext = targeting.UserContext{
+	"id": "user-1", "email": "dev@acme.com", "plan": "premium", "country": "US",
+	"age": 30, "isBeta": true, "groups": []any{"qa", "beta"},
+}
+
+// Expression validates data as a JSON Logic expression and evaluates it. An
+// expression must never both match and fail.
+func Expression(data []byte) error {
+	return guard(func() error {
+		expression := string(data)
+		_ = targeting.ValidateExpression(expression)
+		match, err := targeting.Evaluate(expression, expressionContext)
+		if match && err != nil {
+			return fmt.Errorf("expression matched with error: %w", err)
+		}
+		return nil
+	})
+}
+
+var ruleSeeds = []string{
+	`{"id": "r1", "conditions": [{"property": "plan", "operator": "eq", "value": "premium"}], "distribution": {"on": 100}}`,
+	`{"id": "r2", "conditions": [{"property": "country", "operator": "in", "value": ["US", "CA"]}], "distribution": {"a": 50, "b": 50}}`,
+	`{"id": "r3", "conditions": [{"property": "age", "operator": "gte", "value": 18}, {"property": "email", "operator": "contains", "value": "@acme.com"}], "distribution": {"a": 2500, "b": 7500}}`,
+	`{"id": "r4", "conditions": [{"property": "version", "operator": "semver_gt", "value": "2.1.0"}], "distribution": {"new": 100}}`,
+	`{"id": "r5", "conditions": [{"property": "beta", "operator": "neq", "value": true}], "distribution": {"off": 100}}`,
+}
+
+// ruleContext is the context rules are evaluated against.
+var ruleContext = &engine.UserContext{
+	ID: "user-1", Email: "dev@acme.com", Country: "US", Plan: "premium",
+	Properties: map[string]any{"age": 30.0, "version": "2.3.1", "beta": false},
+}
+
+// Rule decodes data as a targeting rule, validates it and, if valid,
+// evaluates a flag with the rule. A valid rule must never fail evaluation and
+// must only assign the variants of its distribution.
+func Rule(data []byte) error {
+	return guard(func() error {
+		var rule rules.Rule
+		if json.Unmarshal(data, &rule) != nil {
+			return nil
+		}
+		if rules.ValidateRule(rule) != nil {
+			return nil
+		}
+		flag := &store.Flag{Key: "fuzz", Enabled: true, TargetingRules: []rules.Rule{rule}}
+		result := engine.Evaluate(flag, ruleContext)
+		if result.Reason == string(engine.ReasonError) {
+			return fmt.Errorf("valid rule failed evaluation: %w", result.Err)
+		}
+		if result.Reason == string(engine.ReasonTargetingMatch) {
+			if _, ok := rule.Distribution[result.Variant]; !ok {
+				return fmt.Errorf("variant %q is not in the distribution", result.Variant)
+			}
+		}
+		return nil
+	})
+}
+
+var evaluationSeeds = []string{
+	`{"flag": {"key": "banner", "enabled": true, "rollout": 100}, "context": {"id": "user-1"}}`,
+	`{"flag": {"key": "banner", "enabled": false, "rollout": 100}, "context": {"id": "user-1"}}`,
+	`{"flag": {"key": "checkout", "enabled": true, "rollout": 50, "variants": [{"name": "a", "weight": 50}, {"name": "b", "weight": 50, "config": {"color": "blue"}}]}, "context": {"id": "user-2"}}`,
+	`{"flag": {"key": "beta", "enabled": true, "rollout": 100, "expression": "{\"==\": [{\"var\": \"plan\"}, \"premium\"]}"}, "context": {"id": "user-3", "attributes": {"plan": "premium"}}}`,
+	`{"flag": {"key": "safe", "enabled": true, "rollout": 100, "expression": "{\"bogus\": 1}", "config": {"on_error": true}}, "context": {}}`,
+}
+
+type evaluationInput struct {
+	Flag    snapshot.FlagView  `json:"flag"`
+	Context evaluation.Context `json:"context"`
+}
+
+// Evaluation decodes data as a flag and a context and evaluates the flag.
+// The result must be for the flag, disabled if the flag is, enabled on error
+// only if the flag's on_error says so, and assign only the flag's variants.
+func Evaluation(data []byte) error {
+	return guard(func() error {
+		var in evaluationInput
+		if json.Unmarshal(data, &in) != nil {
+			return nil
+		}
+		flag := in.Flag
+		result := evaluation.EvaluateFlag(flag, in.Context, "fuzz-salt")
+		switch {
+		case result.Key != flag.Key:
+			return fmt.Errorf("result is for flag %q, want %q", result.Key, flag.Key)
+		case !flag.Enabled && result.Enabled:
+			return fmt.Errorf("disabled flag evaluated as enabled")
+		case result.Reason == evaluation.ReasonError && result.Enabled && !rules.OnErrorEnabled(flag.Config):
+			return fmt.Errorf("failed evaluation served enabled without on_error")
+		}
+		if result.Variant != "" && !slices.ContainsFunc(flag.Variants, func(v snapshot.Variant) bool { return v.Name == result.Variant }) {
+			return fmt.Errorf("variant %q is not a variant of the flag", result.Variant)
+		}
+		return nil
+	})
+}
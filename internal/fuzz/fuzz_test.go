@@ -0,0 +1,80 @@
+package fuzz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fuzzTarget runs target under go test -fuzz, seeded with its corpus.
+func fuzzTarget(f *testing.F, target Target) {
+	for _, seed := range target.Seeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := target.Fn(data); err != nil {
+			t.Fatalf("%s(%q): %v", target.Name, data, err)
+		}
+	})
+}
+
+func FuzzExpression(f *testing.F) { fuzzTarget(f, Targets[0]) }
+func FuzzRules(f *testing.F)      { fuzzTarget(f, Targets[1]) }
+func FuzzEvaluation(f *testing.F) { fuzzTarget(f, Targets[2]) }
+
+func TestExpression_DeepNesting(t *testing.T) {
+	for _, depth := range []int{10, 1000, 100000} {
+		expression := strings.Repeat(`{"!": [`, depth) + "true" + strings.Repeat("]}", depth)
+		if err := Expression([]byte(expression)); err != nil {
+			t.Errorf("depth %d: %v", depth, err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, target := range Targets {
+		report := Run(context.Background(), target, Options{Duration: 200 * time.Millisecond, Seed: 1})
+		if report.Failure != nil {
+			t.Errorf("%s failed on %q: %v", target.Name, report.Failure.Input, report.Failure.Err)
+		}
+		if report.Execs <= len(target.Seeds) {
+			t.Errorf("%s: expected mutated inputs to run, got %d execs", target.Name, report.Execs)
+		}
+	}
+}
+
+func TestRun_ReportsFailures(t *testing.T) {
+	panics := Target{Name: "panics", Seeds: []string{"ok"}, Fn: func(data []byte) error {
+		return guard(func() error {
+			if len(data) > 2 {
+				panic("too long")
+			}
+			return nil
+		})
+	}}
+	report := Run(context.Background(), panics, Options{Duration: 5 * time.Second, Seed: 1})
+	var panicErr *PanicError
+	if report.Failure == nil || !errors.As(report.Failure.Err, &panicErr) || panicErr.Value != "too long" {
+		t.Fatalf("expected a panic failure, got %+v", report.Failure)
+	}
+
+	hangs := Target{Name: "hangs", Seeds: []string{"x"}, Fn: func([]byte) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+	report = Run(context.Background(), hangs, Options{Duration: 5 * time.Second, InputTimeout: 10 * time.Millisecond})
+	if report.Failure == nil || string(report.Failure.Input) != "x" {
+		t.Fatalf("expected the seed to time out, got %+v", report.Failure)
+	}
+}
+
+func TestLookupTarget(t *testing.T) {
+	if target, ok := LookupTarget("rules"); !ok || target.Name != "rules" {
+		t.Errorf("LookupTarget(rules) = %v, %v", target.Name, ok)
+	}
+	if _, ok := LookupTarget("unknown"); ok {
+		t.Error("expected unknown target to be missing")
+	}
+}
@@ -0,0 +1,155 @@
+package fuzz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// maxInputSize caps mutated inputs; request bodies are capped at 1MB.
+	maxInputSize = 64 << 10
+
+	// maxCorpusSize caps the inputs Run keeps for further mutation.
+	maxCorpusSize = 1024
+
+	defaultInputTimeout = time.Second
+)
+
+// Options configure Run.
+type Options struct {
+	Duration     time.Duration // how long to fuzz
+	Seed         int64         // seeds the mutator; the same seed repeats a run
+	InputTimeout time.Duration // an input running longer fails (default 1s)
+}
+
+// Failure is an input a target failed on.
+type Failure struct {
+	Input []byte
+	Err   error
+}
+
+// Report summarizes a Run of one target.
+type Report struct {
+	Target  string
+	Execs   int
+	Failure *Failure // the first failure, nil if none
+}
+
+// Run feeds target's seeds and mutations of them to the target until
+// opts.Duration has passed, ctx is done or the target fails. Mutations flip,
+// insert, delete and duplicate bytes, splice inputs and wrap them in deep
+// nesting. Unlike go test -fuzz, Run is not coverage guided; it is meant as
+// a quick hardening check where no Go toolchain is available.
+//
+// An input that runs longer than opts.InputTimeout is reported as a failure.
+// Its goroutine cannot be stopped and keeps running in the background, so
+// callers should exit soon after such a failure.
+func Run(ctx context.Context, target Target, opts Options) Report {
+	if opts.InputTimeout <= 0 {
+		opts.InputTimeout = defaultInputTimeout
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	corpus := make([][]byte, 0, len(target.Seeds))
+	for _, seed := range target.Seeds {
+		corpus = append(corpus, []byte(seed))
+	}
+	report := Report{Target: target.Name}
+	deadline := time.Now().Add(opts.Duration)
+
+	for i := 0; ctx.Err() == nil && time.Now().Before(deadline); i++ {
+		var input []byte
+		if i < len(corpus) {
+			input = corpus[i]
+		} else {
+			input = mutate(rng, corpus)
+		}
+		report.Execs++
+		if err := runInput(target, input, opts.InputTimeout); err != nil {
+			report.Failure = &Failure{Input: input, Err: err}
+			return report
+		}
+		if len(corpus) < maxCorpusSize && rng.Intn(16) == 0 {
+			corpus = append(corpus, input)
+		}
+	}
+	return report
+}
+
+// runInput runs target on input, failing if it takes longer than timeout.
+func runInput(target Target, input []byte, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() { done <- target.Fn(input) }()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("input ran longer than %s", timeout)
+	}
+}
+
+// tokens are inserted by mutate, to keep mutations close to valid JSON.
+var tokens = []string{
+	"{", "}", "[", "]", `"`, ":", ",", "null", "true", "false", "0", "-1",
+	"1e308", `"var"`, `"!"`, `"and"`, `"if"`, `"in"`, `"=="`, `"eq"`,
+	`"some"`, `"semver_gt"`, `"\u0000"`, `"\ud800"`,
+}
+
+// nestings are the wrappers mutate nests inputs in.
+var nestings = [][2]string{
+	{"[", "]"},
+	{`{"!": [`, "]}"},
+	{`{"and": [`, "]}"},
+	{`{"if": [`, ", 1, 2]}"},
+	{`{"a": `, "}"},
+}
+
+// mutate returns a mutation of a random corpus input.
+func mutate(rng *rand.Rand, corpus [][]byte) []byte {
+	data := bytes.Clone(corpus[rng.Intn(len(corpus))])
+	for n := 1 + rng.Intn(4); n > 0; n-- {
+		switch rng.Intn(6) {
+		case 0: // replace a byte
+			if len(data) > 0 {
+				data[rng.Intn(len(data))] = byte(rng.Intn(256))
+			}
+		case 1: // insert a token
+			at := rng.Intn(len(data) + 1)
+			data = append(data[:at:at], append([]byte(tokens[rng.Intn(len(tokens))]), data[at:]...)...)
+		case 2: // delete a range
+			if len(data) > 0 {
+				from := rng.Intn(len(data))
+				to := from + rng.Intn(len(data)-from) + 1
+				data = append(data[:from], data[to:]...)
+			}
+		case 3: // duplicate a range
+			if len(data) > 0 {
+				from := rng.Intn(len(data))
+				to := from + rng.Intn(len(data)-from) + 1
+				at := rng.Intn(len(data) + 1)
+				chunk := bytes.Clone(data[from:to])
+				data = append(data[:at:at], append(chunk, data[at:]...)...)
+			}
+		case 4: // splice with another input
+			other := corpus[rng.Intn(len(corpus))]
+			data = append(data[:rng.Intn(len(data)+1)], other[rng.Intn(len(other)+1):]...)
+		case 5: // wrap in nesting, up to a few thousand levels
+			nesting := nestings[rng.Intn(len(nestings))]
+			depth := 1 + rng.Intn(1<<uint(rng.Intn(12)))
+			var b strings.Builder
+			b.WriteString(strings.Repeat(nesting[0], depth))
+			b.Write(data)
+			b.WriteString(strings.Repeat(nesting[1], depth))
+			data = []byte(b.String())
+		}
+	}
+	if len(data) > maxInputSize {
+		data = data[:maxInputSize]
+	}
+	return data
+}
@@ -0,0 +1,449 @@
+// Package gitsync keeps the flag store in sync with flag definition files kept
+// in a Git repository.
+//
+// Sync Flow:
+//  1. Clone the repository (first run) or fetch and hard-reset to the branch head
+//  2. Read every .yaml/.yml/.json file under Config.Path (same format as `flagship export`)
+//  3. Reconcile the store for Config.Env: create, update or delete flags to match
+//  4. Invoke the OnApplied callback (e.g. snapshot rebuild) if anything changed
+//
+// Conflict Detection:
+//
+//	The syncer remembers a fingerprint of every flag it wrote. If a flag in the
+//	store no longer matches that fingerprint, someone edited it outside of Git.
+//	Such flags are reported as conflicts and left untouched until the manual
+//	edit is reverted or the sync is forced. Flags that were never written by the
+//	syncer are adopted (overwritten) on first sight, and flags that Git never
+//	managed are never deleted.
+//
+// Git is invoked through the git binary, which must be available on PATH.
+package gitsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+// ErrSyncInProgress is returned by Sync when another sync is already running.
+var ErrSyncInProgress = errors.New("gitsync: sync already in progress")
+
+// Config describes the repository to sync from.
+type Config struct {
+	RepoURL  string        // Repository URL (anything `git clone` accepts)
+	Branch   string        // Branch to track (default "main")
+	Path     string        // Directory inside the repository holding flag files (default ".")
+	Env      string        // Environment managed by the sync
+	Interval time.Duration // Polling interval; 0 disables polling (webhook/manual only)
+	WorkDir  string        // Checkout directory; a temp dir is used when empty
+}
+
+// Conflict reports a flag that was not synced because it was edited manually.
+type Conflict struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// Result summarizes a single sync run.
+type Result struct {
+	Commit    string     `json:"commit"`
+	Created   []string   `json:"created"`
+	Updated   []string   `json:"updated"`
+	Deleted   []string   `json:"deleted"`
+	Unchanged int        `json:"unchanged"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Changed reports whether the run modified the store.
+func (r *Result) Changed() bool {
+	return len(r.Created)+len(r.Updated)+len(r.Deleted) > 0
+}
+
+// Status is the externally visible state of the syncer.
+type Status struct {
+	RepoURL    string     `json:"repo_url"`
+	Branch     string     `json:"branch"`
+	Path       string     `json:"path"`
+	Env        string     `json:"env"`
+	InProgress bool       `json:"in_progress"`
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	LastResult *Result    `json:"last_result,omitempty"`
+}
+
+// Syncer pulls flag definitions from Git and reconciles the store.
+// It is safe for concurrent use; only one sync runs at a time.
+type Syncer struct {
+	cfg       Config
+	store     store.Store
+	onApplied func(ctx context.Context, env string) error
+
+	runMu   sync.Mutex // serializes syncs
+	mu      sync.Mutex // guards fields below
+	status  Status
+	applied map[string]string // flag key -> fingerprint last written by the syncer
+	trigger chan struct{}
+}
+
+// New creates a Syncer. onApplied may be nil.
+func New(cfg Config, st store.Store, onApplied func(ctx context.Context, env string) error) *Syncer {
+	if cfg.Branch == "" {
+		cfg.Branch = "main"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "."
+	}
+	return &Syncer{
+		cfg:       cfg,
+		store:     st,
+		onApplied: onApplied,
+		status: Status{
+			RepoURL: redactURL(cfg.RepoURL),
+			Branch:  cfg.Branch,
+			Path:    cfg.Path,
+			Env:     cfg.Env,
+		},
+		applied: make(map[string]string),
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// Run syncs once immediately, then on every interval tick or Trigger call,
+// until ctx is cancelled. Errors are recorded in Status and logged.
+func (s *Syncer) Run(ctx context.Context) {
+	var tick <-chan time.Time
+	if s.cfg.Interval > 0 {
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	s.runLogged(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			s.runLogged(ctx)
+		case <-s.trigger:
+			s.runLogged(ctx)
+		}
+	}
+}
+
+func (s *Syncer) runLogged(ctx context.Context) {
+	if _, err := s.Sync(ctx, false); err != nil && !errors.Is(err, ErrSyncInProgress) {
+		log.Printf("[gitsync] sync failed: %v", err)
+	}
+	worker.Processed(ctx)
+}
+
+// Trigger requests an asynchronous sync from Run. Multiple triggers while a
+// sync is pending collapse into one.
+func (s *Syncer) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns a copy of the current status.
+func (s *Syncer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Sync fetches the repository and reconciles the store.
+// With force=true, conflicting manual edits are overwritten.
+func (s *Syncer) Sync(ctx context.Context, force bool) (*Result, error) {
+	if !s.runMu.TryLock() {
+		return nil, ErrSyncInProgress
+	}
+	defer s.runMu.Unlock()
+
+	s.mu.Lock()
+	s.status.InProgress = true
+	s.mu.Unlock()
+
+	result, err := s.sync(ctx, force)
+
+	now := time.Now().UTC()
+	s.mu.Lock()
+	s.status.InProgress = false
+	s.status.LastSyncAt = &now
+	if err != nil {
+		s.status.LastError = err.Error()
+	} else {
+		s.status.LastError = ""
+		s.status.LastResult = result
+	}
+	s.mu.Unlock()
+	return result, err
+}
+
+func (s *Syncer) sync(ctx context.Context, force bool) (*Result, error) {
+	dir, err := s.checkout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := runGit(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := LoadFlags(dir, s.cfg.Path, s.cfg.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.reconcile(ctx, desired, force)
+	if err != nil {
+		return nil, err
+	}
+	result.Commit = commit
+
+	if result.Changed() && s.onApplied != nil {
+		if err := s.onApplied(ctx, s.cfg.Env); err != nil {
+			return result, fmt.Errorf("post-sync hook: %w", err)
+		}
+	}
+	log.Printf("[gitsync] synced commit %s: created=%d updated=%d deleted=%d conflicts=%d",
+		shortCommit(commit), len(result.Created), len(result.Updated), len(result.Deleted), len(result.Conflicts))
+	return result, nil
+}
+
+// checkout clones the repository on first use and fast-forwards it afterwards.
+func (s *Syncer) checkout(ctx context.Context) (string, error) {
+	if s.cfg.WorkDir == "" {
+		dir, err := os.MkdirTemp("", "flagship-gitsync-")
+		if err != nil {
+			return "", fmt.Errorf("create work dir: %w", err)
+		}
+		s.cfg.WorkDir = dir
+	}
+	dir := s.cfg.WorkDir
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if _, err := runGit(ctx, "", "clone", "--depth", "1", "--single-branch", "--branch", s.cfg.Branch, s.cfg.RepoURL, dir); err != nil {
+			return "", err
+		}
+		return dir, nil
+	}
+
+	if _, err := runGit(ctx, dir, "fetch", "--depth", "1", "origin", s.cfg.Branch); err != nil {
+		return "", err
+	}
+	if _, err := runGit(ctx, dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// reconcile applies desired flags to the store. See package docs for the
+// conflict rules.
+func (s *Syncer) reconcile(ctx context.Context, desired []store.Flag, force bool) (*Result, error) {
+	current, err := s.store.GetAllFlags(ctx, s.cfg.Env)
+	if err != nil {
+		return nil, fmt.Errorf("load flags: %w", err)
+	}
+	currentByKey := make(map[string]store.Flag, len(current))
+	for _, f := range current {
+		currentByKey[f.Key] = f
+	}
+
+	s.mu.Lock()
+	applied := make(map[string]string, len(s.applied))
+	for k, v := range s.applied {
+		applied[k] = v
+	}
+	s.mu.Unlock()
+
+	result := &Result{Created: []string{}, Updated: []string{}, Deleted: []string{}, Conflicts: []Conflict{}}
+	desiredKeys := make(map[string]bool, len(desired))
+
+	for _, want := range desired {
+		desiredKeys[want.Key] = true
+		wantFP := fingerprint(want)
+
+		have, exists := currentByKey[want.Key]
+		if exists {
+			haveFP := fingerprint(have)
+			if haveFP == wantFP {
+				applied[want.Key] = wantFP
+				result.Unchanged++
+				continue
+			}
+			if last, ok := applied[want.Key]; ok && last != haveFP && !force {
+				result.Conflicts = append(result.Conflicts, Conflict{Key: want.Key, Reason: "flag was modified outside of git since the last sync"})
+				continue
+			}
+		}
+
+		if err := s.store.UpsertFlag(ctx, toUpsertParams(want)); err != nil {
+			return nil, fmt.Errorf("upsert flag %q: %w", want.Key, err)
+		}
+		applied[want.Key] = wantFP
+		if exists {
+			result.Updated = append(result.Updated, want.Key)
+		} else {
+			result.Created = append(result.Created, want.Key)
+		}
+	}
+
+	// Delete flags that git used to manage but no longer defines
+	for key, last := range applied {
+		if desiredKeys[key] {
+			continue
+		}
+		have, exists := currentByKey[key]
+		if !exists {
+			delete(applied, key)
+			continue
+		}
+		if fingerprint(have) != last && !force {
+			result.Conflicts = append(result.Conflicts, Conflict{Key: key, Reason: "flag was removed from git but modified outside of git"})
+			continue
+		}
+		if err := s.store.DeleteFlag(ctx, key, s.cfg.Env); err != nil {
+			return nil, fmt.Errorf("delete flag %q: %w", key, err)
+		}
+		delete(applied, key)
+		result.Deleted = append(result.Deleted, key)
+	}
+
+	s.mu.Lock()
+	s.applied = applied
+	s.mu.Unlock()
+
+	sort.Strings(result.Created)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Deleted)
+	sort.Slice(result.Conflicts, func(i, j int) bool { return result.Conflicts[i].Key < result.Conflicts[j].Key })
+	return result, nil
+}
+
+// LoadFlags reads all flag files below root/path (see store.LoadFlagFiles).
+// Flags without an env are assigned env; flags for other environments are
+// ignored.
+func LoadFlags(root, path, env string) ([]store.Flag, error) {
+	base := filepath.Join(root, path)
+	rel, err := filepath.Rel(root, base)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("path %q escapes the repository", path)
+	}
+
+	loaded, err := store.LoadFlagFiles(base, env)
+	if err != nil {
+		return nil, err
+	}
+	var flags []store.Flag
+	for _, f := range loaded {
+		if f.Env == env {
+			flags = append(flags, f)
+		}
+	}
+	return flags, nil
+}
+
+// fingerprint hashes the user-editable fields of a flag.
+func fingerprint(f store.Flag) string {
+	ruleset := f.TargetingRules
+	if ruleset == nil {
+		ruleset = []rules.Rule{}
+	}
+	project := f.Project
+	if project == store.DefaultProject {
+		project = "" // files may omit the default project
+	}
+	b, _ := json.Marshal(struct {
+		Description    string            `json:"d"`
+		Enabled        bool              `json:"e"`
+		Rollout        int32             `json:"r"`
+		Expression     *string           `json:"x"`
+		Config         map[string]any    `json:"c"`
+		TargetingRules []rules.Rule      `json:"t"`
+		Variants       []store.Variant   `json:"v"`
+		Tags           []string          `json:"g,omitempty"`
+		Archived       bool              `json:"a,omitempty"`
+		Owner          string            `json:"o,omitempty"`
+		TicketURL      string            `json:"u,omitempty"`
+		Metadata       map[string]string `json:"m,omitempty"`
+		ExpiresAt      *time.Time        `json:"z,omitempty"`
+		Project        string            `json:"p,omitempty"`
+	}{f.Description, f.Enabled, f.Rollout, f.Expression, f.Config, ruleset, f.Variants, f.Tags, f.Archived, f.Owner, f.TicketURL, f.Metadata, f.ExpiresAt, project})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func toUpsertParams(f store.Flag) store.UpsertParams {
+	return store.UpsertParams{
+		Key:            f.Key,
+		Description:    f.Description,
+		Enabled:        f.Enabled,
+		Rollout:        f.Rollout,
+		Expression:     f.Expression,
+		Config:         f.Config,
+		TargetingRules: f.TargetingRules,
+		Variants:       f.Variants,
+		Protected:      f.Protected,
+		Tags:           f.Tags,
+		Archived:       f.Archived,
+		Owner:          f.Owner,
+		TicketURL:      f.TicketURL,
+		Metadata:       f.Metadata,
+		ExpiresAt:      f.ExpiresAt,
+		Env:            f.Env,
+		Project:        f.Project,
+	}
+}
+
+// runGit runs a git command and returns its trimmed stdout.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if dir != "" {
+		cmd.Dir = dir
+	}
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// redactURL strips credentials from a repository URL before it is exposed.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil // tokens are often passed as the username
+	return u.String()
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
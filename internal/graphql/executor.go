@@ -0,0 +1,267 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Resolver produces the value of a field. Resolvers may return:
+//   - scalars, or maps/slices used as opaque JSON when no sub-selection is requested
+//   - map[string]any objects whose entries are themselves values or Resolvers
+//   - slices of objects
+//
+// A Resolver stored inside an object map is only invoked when the field is
+// selected, which keeps nested lookups (e.g. webhook deliveries) lazy. Fields
+// missing from an object resolve to null, matching omitempty JSON encoding.
+type Resolver func(ctx context.Context, args map[string]any) (any, error)
+
+// Schema is the set of root fields available to queries and mutations.
+type Schema struct {
+	Query    map[string]Resolver
+	Mutation map[string]Resolver
+}
+
+// Error is a GraphQL error entry with the path of the failing field.
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Response is the standard GraphQL response body.
+type Response struct {
+	Data   *Object `json:"data"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Object is a result object that preserves selection order when marshaled.
+type Object struct {
+	keys   []string
+	values map[string]any
+}
+
+func newObject() *Object {
+	return &Object{values: map[string]any{}}
+}
+
+func (o *Object) set(key string, value any) {
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Get returns the value stored under key.
+func (o *Object) Get(key string) any {
+	return o.values[key]
+}
+
+// MarshalJSON encodes the object with keys in selection order.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Execute parses and runs a single operation against schema.
+//
+// Postconditions:
+//   - Parse errors return a Response with nil Data and a single error
+//   - Field errors set the field to null and are reported with their path;
+//     sibling fields still resolve
+//   - Mutation root fields run sequentially in document order
+func Execute(ctx context.Context, schema *Schema, query string, variables map[string]any) *Response {
+	op, err := Parse(query)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	roots := schema.Query
+	if op.Type == "mutation" {
+		roots = schema.Mutation
+	}
+
+	vars := make(map[string]any, len(op.Variables)+len(variables))
+	for k, v := range op.Variables {
+		vars[k] = v
+	}
+	for k, v := range variables {
+		vars[k] = v
+	}
+
+	ex := &executor{vars: vars}
+	data := newObject()
+	for _, field := range op.Selections {
+		path := []any{field.ResponseKey()}
+		resolve, ok := roots[field.Name]
+		if !ok {
+			ex.addError(path, "Cannot query field %q on type %q", field.Name, rootTypeName(op.Type))
+			data.set(field.ResponseKey(), nil)
+			continue
+		}
+		data.set(field.ResponseKey(), ex.resolveField(ctx, resolve, field, path))
+	}
+	return &Response{Data: data, Errors: ex.errors}
+}
+
+func rootTypeName(opType string) string {
+	if opType == "mutation" {
+		return "Mutation"
+	}
+	return "Query"
+}
+
+type executor struct {
+	vars   map[string]any
+	errors []Error
+}
+
+func (ex *executor) addError(path []any, format string, args ...any) {
+	ex.errors = append(ex.errors, Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+func (ex *executor) resolveField(ctx context.Context, resolve Resolver, field Field, path []any) any {
+	value, err := resolve(ctx, ex.bindArgs(field.Args))
+	if err != nil {
+		ex.addError(path, "%s", err.Error())
+		return nil
+	}
+	return ex.complete(ctx, value, field, path)
+}
+
+// complete projects value onto the field's selection set.
+func (ex *executor) complete(ctx context.Context, value any, field Field, path []any) any {
+	if value == nil || len(field.Selections) == 0 {
+		return value
+	}
+
+	if obj, ok := value.(map[string]any); ok {
+		result := newObject()
+		for _, sub := range field.Selections {
+			subPath := childPath(path, sub.ResponseKey())
+			raw := obj[sub.Name]
+			if nested, ok := raw.(Resolver); ok {
+				result.set(sub.ResponseKey(), ex.resolveField(ctx, nested, sub, subPath))
+				continue
+			}
+			result.set(sub.ResponseKey(), ex.complete(ctx, raw, sub, subPath))
+		}
+		return result
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice {
+		items := make([]any, rv.Len())
+		for i := range items {
+			items[i] = ex.complete(ctx, rv.Index(i).Interface(), field, childPath(path, i))
+		}
+		return items
+	}
+
+	ex.addError(path, "Field %q is a scalar and cannot have a selection set", field.Name)
+	return nil
+}
+
+// childPath returns a copy of path extended with elem.
+func childPath(path []any, elem any) []any {
+	p := make([]any, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, elem)
+}
+
+// bindArgs substitutes variable references with their values.
+func (ex *executor) bindArgs(args map[string]any) map[string]any {
+	bound := make(map[string]any, len(args))
+	for k, v := range args {
+		bound[k] = ex.bindValue(v)
+	}
+	return bound
+}
+
+func (ex *executor) bindValue(v any) any {
+	switch val := v.(type) {
+	case variableRef:
+		return ex.vars[string(val)]
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = ex.bindValue(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = ex.bindValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// ---- argument helpers for resolvers ----
+
+// StringArg returns args[name] as a string, or def if it is absent or null.
+func StringArg(args map[string]any, name, def string) (string, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q must be a string", name)
+	}
+	return s, nil
+}
+
+// IntArg returns args[name] as an int, or def if it is absent or null.
+// Integer literals and JSON numbers from variables are both accepted.
+func IntArg(args map[string]any, name string, def int) (int, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return def, nil
+	}
+	switch n := v.(type) {
+	case int64:
+		return int(n), nil
+	case int:
+		return n, nil
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// DecodeArg converts args[name] into dst by round-tripping through JSON.
+// Useful for input objects that map onto existing request structs.
+func DecodeArg(args map[string]any, name string, dst any) error {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return fmt.Errorf("argument %q is required", name)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return fmt.Errorf("argument %q is invalid: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testSchema() *Schema {
+	items := []any{
+		map[string]any{"key": "a", "enabled": true},
+		map[string]any{"key": "b", "enabled": false},
+	}
+	return &Schema{
+		Query: map[string]Resolver{
+			"items": func(ctx context.Context, args map[string]any) (any, error) {
+				return items, nil
+			},
+			"item": func(ctx context.Context, args map[string]any) (any, error) {
+				key, err := StringArg(args, "key", "")
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{
+					"key": key,
+					"children": Resolver(func(ctx context.Context, args map[string]any) (any, error) {
+						n, err := IntArg(args, "n", 1)
+						if err != nil {
+							return nil, err
+						}
+						out := make([]any, n)
+						for i := range out {
+							out[i] = map[string]any{"index": i}
+						}
+						return out, nil
+					}),
+				}, nil
+			},
+			"boom": func(ctx context.Context, args map[string]any) (any, error) {
+				return nil, errors.New("boom")
+			},
+		},
+		Mutation: map[string]Resolver{
+			"echo": func(ctx context.Context, args map[string]any) (any, error) {
+				var input struct {
+					Name string `json:"name"`
+				}
+				if err := DecodeArg(args, "input", &input); err != nil {
+					return nil, err
+				}
+				return input.Name, nil
+			},
+		},
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestExecute_SelectionProjection(t *testing.T) {
+	resp := Execute(context.Background(), testSchema(), `{ items { key } }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", resp.Errors)
+	}
+	got := mustJSON(t, resp.Data)
+	want := `{"items":[{"key":"a"},{"key":"b"}]}`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestExecute_AliasesArgsAndNestedResolvers(t *testing.T) {
+	query := `query Q($k: String!) {
+		first: item(key: $k) { key children(n: 2) { index } }
+		second: item(key: "literal") { key }
+	}`
+	resp := Execute(context.Background(), testSchema(), query, map[string]any{"k": "x"})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", resp.Errors)
+	}
+	got := mustJSON(t, resp.Data)
+	want := `{"first":{"key":"x","children":[{"index":0},{"index":1}]},"second":{"key":"literal"}}`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestExecute_FieldErrorsArePartial(t *testing.T) {
+	resp := Execute(context.Background(), testSchema(), `{ boom items { key } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "boom" {
+		t.Fatalf("expected single boom error, got %v", resp.Errors)
+	}
+	if resp.Data.Get("boom") != nil {
+		t.Error("failed field should be null")
+	}
+	if resp.Data.Get("items") == nil {
+		t.Error("sibling field should still resolve")
+	}
+}
+
+func TestExecute_UnknownRootField(t *testing.T) {
+	resp := Execute(context.Background(), testSchema(), `{ nope }`, nil)
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "nope") {
+		t.Fatalf("expected unknown field error, got %v", resp.Errors)
+	}
+}
+
+func TestExecute_Mutation(t *testing.T) {
+	resp := Execute(context.Background(), testSchema(), `mutation { echo(input: {name: "hi"}) }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", resp.Errors)
+	}
+	if resp.Data.Get("echo") != "hi" {
+		t.Errorf("expected echo=hi, got %v", resp.Data.Get("echo"))
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []string{
+		``,
+		`{ items { key }`,
+		`{ ...frag }`,
+		`subscription { items }`,
+		`{ items } { items }`,
+		`{ item(key: "unterminated) }`,
+	}
+	for _, q := range tests {
+		if _, err := Parse(q); err == nil {
+			t.Errorf("Parse(%q) should fail", q)
+		}
+	}
+}
+
+func TestParse_Depth(t *testing.T) {
+	nest := func(open, inner, close string, n int) string {
+		return strings.Repeat(open, n) + inner + strings.Repeat(close, n)
+	}
+	// A megabyte of '[' must fail fast instead of recursing a million times.
+	if _, err := Parse(`{ f(a: ` + strings.Repeat("[", 1<<20) + `) }`); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+		t.Errorf("expected a nesting error, got %v", err)
+	}
+	for _, q := range []string{
+		`{ f(a: ` + nest("[", "1", "]", MaxDepth+1) + `) }`,
+		`{ f(a: ` + nest("{x: ", "1", "}", MaxDepth+1) + `) }`,
+		nest("{ a ", "", " }", MaxDepth+1),
+		`query ($v: ` + nest("[", "Int", "]", MaxDepth+1) + `) { f }`,
+	} {
+		if _, err := Parse(q); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+			t.Errorf("expected a nesting error, got %v", err)
+		}
+	}
+
+	// The selection set of the operation is one level.
+	if _, err := Parse(`{ f(a: ` + nest("[", "1", "]", MaxDepth-1) + `) }`); err != nil {
+		t.Errorf("expected nesting within the limit to parse, got %v", err)
+	}
+}
+
+func TestParse_Values(t *testing.T) {
+	op, err := Parse(`{ f(a: 1, b: -2.5, c: "s\n", d: true, e: null, g: ENUM, h: [1, 2], i: {x: "y"}) }`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	args := op.Selections[0].Args
+	if args["a"] != int64(1) || args["b"] != -2.5 || args["c"] != "s\n" || args["d"] != true || args["e"] != nil || args["g"] != "ENUM" {
+		t.Errorf("unexpected scalar args: %#v", args)
+	}
+	if list, ok := args["h"].([]any); !ok || len(list) != 2 {
+		t.Errorf("unexpected list arg: %#v", args["h"])
+	}
+	if obj, ok := args["i"].(map[string]any); !ok || obj["x"] != "y" {
+		t.Errorf("unexpected object arg: %#v", args["i"])
+	}
+}
@@ -0,0 +1,604 @@
+// Package graphql implements the subset of GraphQL needed by the admin API.
+//
+// Supported:
+//   - A single query or mutation operation (named or anonymous)
+//   - Variable definitions ($name: Type) with optional defaults
+//   - Field aliases, arguments and nested selection sets
+//   - String, Int, Float, Boolean, null, enum, list and object literals
+//
+// Not supported (rejected with a parse error):
+//   - Fragments, directives and subscriptions
+//   - Multiple operations in one document
+//   - Selection sets, values and types nested deeper than MaxDepth
+//
+// There is no schema language or type system: resolvers return plain Go values
+// (maps, slices, scalars) and the executor projects them onto the requested
+// selection set. See Execute in executor.go.
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxDepth is the deepest nesting of selection sets, list and object values
+// or list types the parser accepts. It bounds the parser's recursion, so a
+// document of a million '[' cannot grow a request's stack without limit.
+const MaxDepth = 64
+
+// Operation is a parsed GraphQL operation.
+type Operation struct {
+	Type       string // "query" or "mutation"
+	Name       string
+	Variables  map[string]any // default values from variable definitions
+	Selections []Field
+}
+
+// Field is a single field selection.
+type Field struct {
+	Alias      string
+	Name       string
+	Args       map[string]any
+	Selections []Field
+}
+
+// ResponseKey returns the key under which the field appears in the result.
+func (f Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// variableRef is an unresolved $variable inside an argument value.
+type variableRef string
+
+// Parse parses a GraphQL document containing exactly one operation.
+func Parse(source string) (*Operation, error) {
+	p := &parser{lex: newLexer(source)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	op, err := p.parseOperation()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q after operation (only one operation per document is supported)", p.tok.value)
+	}
+	return op, nil
+}
+
+type parser struct {
+	lex   *lexer
+	tok   token
+	depth int // current nesting, see enter
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at position %d: %s", p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+// enter descends one nesting level, failing past MaxDepth. Every successful
+// call must be matched by a deferred leave.
+func (p *parser) enter() error {
+	if p.depth >= MaxDepth {
+		return p.errorf("document is nested deeper than %d levels", MaxDepth)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if p.tok.kind != kind || (value != "" && p.tok.value != value) {
+		want := value
+		if want == "" {
+			want = kind.String()
+		}
+		return p.errorf("expected %s, got %q", want, p.tok.value)
+	}
+	return p.advance()
+}
+
+func (p *parser) isPunct(value string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == value
+}
+
+func (p *parser) parseOperation() (*Operation, error) {
+	op := &Operation{Type: "query", Variables: map[string]any{}}
+
+	if p.tok.kind == tokName {
+		switch p.tok.value {
+		case "query", "mutation":
+			op.Type = p.tok.value
+		case "subscription":
+			return nil, p.errorf("subscriptions are not supported")
+		case "fragment":
+			return nil, p.errorf("fragments are not supported")
+		default:
+			return nil, p.errorf("unexpected %q", p.tok.value)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokName {
+			op.Name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.isPunct("(") {
+			if err := p.parseVariableDefinitions(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.Selections = selections
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinitions(op *Operation) error {
+	if err := p.expect(tokPunct, "("); err != nil {
+		return err
+	}
+	for !p.isPunct(")") {
+		if err := p.expect(tokPunct, "$"); err != nil {
+			return err
+		}
+		if p.tok.kind != tokName {
+			return p.errorf("expected variable name")
+		}
+		name := p.tok.value
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.expect(tokPunct, ":"); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if p.isPunct("=") {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			def, err := p.parseValue(true)
+			if err != nil {
+				return err
+			}
+			op.Variables[name] = def
+		}
+	}
+	return p.advance()
+}
+
+// skipType consumes a type reference such as String!, [Int] or [Flag!]!.
+// Types are not enforced; resolvers validate their own arguments.
+func (p *parser) skipType() error {
+	if p.isPunct("[") {
+		if err := p.enter(); err != nil {
+			return err
+		}
+		defer p.leave()
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect(tokPunct, "]"); err != nil {
+			return err
+		}
+	} else if err := p.expect(tokName, ""); err != nil {
+		return err
+	}
+	if p.isPunct("!") {
+		return p.advance()
+	}
+	return nil
+}
+
+func (p *parser) parseSelectionSet() ([]Field, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return nil, err
+	}
+	var fields []Field
+	for !p.isPunct("}") {
+		if p.tok.kind == tokEOF {
+			return nil, p.errorf("unterminated selection set")
+		}
+		if p.isPunct("...") {
+			return nil, p.errorf("fragments are not supported")
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, p.errorf("selection set must not be empty")
+	}
+	return fields, p.advance()
+}
+
+func (p *parser) parseField() (Field, error) {
+	var f Field
+	if p.tok.kind != tokName {
+		return f, p.errorf("expected field name, got %q", p.tok.value)
+	}
+	f.Name = p.tok.value
+	if err := p.advance(); err != nil {
+		return f, err
+	}
+	if p.isPunct(":") {
+		if err := p.advance(); err != nil {
+			return f, err
+		}
+		if p.tok.kind != tokName {
+			return f, p.errorf("expected field name after alias")
+		}
+		f.Alias, f.Name = f.Name, p.tok.value
+		if err := p.advance(); err != nil {
+			return f, err
+		}
+	}
+	if p.isPunct("(") {
+		args, err := p.parseArguments()
+		if err != nil {
+			return f, err
+		}
+		f.Args = args
+	}
+	if p.isPunct("@") {
+		return f, p.errorf("directives are not supported")
+	}
+	if p.isPunct("{") {
+		selections, err := p.parseSelectionSet()
+		if err != nil {
+			return f, err
+		}
+		f.Selections = selections
+	}
+	return f, nil
+}
+
+func (p *parser) parseArguments() (map[string]any, error) {
+	if err := p.expect(tokPunct, "("); err != nil {
+		return nil, err
+	}
+	args := map[string]any{}
+	for !p.isPunct(")") {
+		if p.tok.kind != tokName {
+			return nil, p.errorf("expected argument name, got %q", p.tok.value)
+		}
+		name := p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, ":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parseValue(constant bool) (any, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokPunct && tok.value == "$":
+		if constant {
+			return nil, p.errorf("variables are not allowed here")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokName {
+			return nil, p.errorf("expected variable name")
+		}
+		name := p.tok.value
+		return variableRef(name), p.advance()
+
+	case tok.kind == tokString:
+		return tok.value, p.advance()
+
+	case tok.kind == tokInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer %q", tok.value)
+		}
+		return n, p.advance()
+
+	case tok.kind == tokFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %q", tok.value)
+		}
+		return f, p.advance()
+
+	case tok.kind == tokName:
+		var v any
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = tok.value // enum values are passed through as strings
+		}
+		return v, p.advance()
+
+	case tok.kind == tokPunct && tok.value == "[":
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for !p.isPunct("]") {
+			if p.tok.kind == tokEOF {
+				return nil, p.errorf("unterminated list")
+			}
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+
+	case tok.kind == tokPunct && tok.value == "{":
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		obj := map[string]any{}
+		for !p.isPunct("}") {
+			if p.tok.kind != tokName {
+				return nil, p.errorf("expected object field name, got %q", p.tok.value)
+			}
+			name := p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokPunct, ":"); err != nil {
+				return nil, err
+			}
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = item
+		}
+		return obj, p.advance()
+	}
+	return nil, p.errorf("unexpected %q", tok.value)
+}
+
+// ---- lexer ----
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of document"
+	case tokPunct:
+		return "punctuator"
+	case tokName:
+		return "name"
+	case tokInt:
+		return "integer"
+	case tokFloat:
+		return "float"
+	case tokString:
+		return "string"
+	}
+	return "token"
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, value: string(c), pos: start}, nil
+
+	case c == '.':
+		if strings.HasPrefix(l.src[l.pos:], "...") {
+			l.pos += 3
+			return token{kind: tokPunct, value: "...", pos: start}, nil
+		}
+
+	case c == '"':
+		return l.readString()
+
+	case c == '-' || isDigit(c):
+		return l.readNumber()
+
+	case isNameStart(c):
+		for l.pos < len(l.src) && isNameContinue(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokName, value: l.src[start:l.pos], pos: start}, nil
+	}
+	return token{}, fmt.Errorf("syntax error at position %d: unexpected character %q", start, c)
+}
+
+// skipIgnored skips whitespace, commas and # comments.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) readNumber() (token, error) {
+	start := l.pos
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	kind := tokInt
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokFloat
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	value := l.src[start:l.pos]
+	if value == "-" {
+		return token{}, fmt.Errorf("syntax error at position %d: invalid number", start)
+	}
+	return token{kind: kind, value: value, pos: start}, nil
+}
+
+func (l *lexer) readString() (token, error) {
+	start := l.pos
+	l.pos++ // opening quote
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return token{kind: tokString, value: sb.String(), pos: start}, nil
+		case '\n':
+			return token{}, fmt.Errorf("syntax error at position %d: unterminated string", start)
+		case '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, fmt.Errorf("syntax error at position %d: unterminated string", start)
+			}
+			esc := l.src[l.pos+1]
+			l.pos += 2
+			switch esc {
+			case '"', '\\', '/':
+				sb.WriteByte(esc)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return token{}, fmt.Errorf("syntax error at position %d: invalid unicode escape", l.pos)
+				}
+				code, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, fmt.Errorf("syntax error at position %d: invalid unicode escape", l.pos)
+				}
+				sb.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, fmt.Errorf("syntax error at position %d: invalid escape \\%c", l.pos-1, esc)
+			}
+		default:
+			sb.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, fmt.Errorf("syntax error at position %d: unterminated string", start)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
@@ -0,0 +1,173 @@
+// Package importer converts feature flags from other flag services into
+// goflagship flags.
+//
+// Each source fetches its flags over the vendor's REST API and maps them onto
+// the goflagship model:
+//   - on/off state -> Enabled
+//   - default percentage rollout -> Rollout plus on/off Variants (boolean flags)
+//     or weighted Variants (multivariate flags)
+//   - targeting rules and individual targets -> TargetingRules (first match wins)
+//   - segments -> inlined into the conditions of the rules that reference them
+//
+// Anything that cannot be represented exactly (unsupported operators,
+// prerequisites, custom strategies, negated clauses...) is dropped and recorded
+// as a Note so the operator can review the mapping before importing.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+)
+
+// Variant names used for flags without explicit variants (boolean flags).
+const (
+	VariantOn  = "on"
+	VariantOff = "off"
+)
+
+// booleanVariants represents an on/off flag rolled out to rollout percent of
+// users, so default evaluation and targeting rules share the same variant names.
+func booleanVariants(rollout int32) []store.Variant {
+	return []store.Variant{
+		{Name: VariantOn, Weight: int(rollout), Config: map[string]any{"value": true}},
+		{Name: VariantOff, Weight: 100 - int(rollout), Config: map[string]any{"value": false}},
+	}
+}
+
+// Note describes a feature of a source flag that was not (fully) imported.
+type Note struct {
+	Flag    string `json:"flag"`
+	Message string `json:"message"`
+}
+
+// Result is the outcome of a conversion: the flags to import and a mapping
+// report of everything that was changed or dropped along the way.
+type Result struct {
+	Flags []store.Flag `json:"flags"`
+	Notes []Note       `json:"notes"`
+}
+
+func (r *Result) note(flag, format string, args ...any) {
+	r.Notes = append(r.Notes, Note{Flag: flag, Message: fmt.Sprintf(format, args...)})
+}
+
+// defaultHTTPClient is used when an Options struct has no client set.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// getJSON performs an authenticated GET and decodes the JSON response into dst.
+func getJSON(ctx context.Context, client *http.Client, rawURL, authorization string, dst any) error {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+var invalidKeyChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// normalizeKey maps a source flag key onto the goflagship key format
+// (alphanumeric, underscores and hyphens, at most 64 characters).
+func normalizeKey(key string) string {
+	k := invalidKeyChars.ReplaceAllString(key, "_")
+	if len(k) > validation.MaxKeyLength {
+		k = k[:validation.MaxKeyLength]
+	}
+	return k
+}
+
+// addFlag normalizes the key, reports renames and collisions, and appends f.
+func (r *Result) addFlag(sourceKey string, f store.Flag) {
+	f.Key = normalizeKey(sourceKey)
+	if f.Key != sourceKey {
+		r.note(sourceKey, "key renamed to %q", f.Key)
+	}
+	for _, existing := range r.Flags {
+		if existing.Key == f.Key {
+			r.note(sourceKey, "skipped: key %q collides with another imported flag", f.Key)
+			return
+		}
+	}
+	if desc := []rune(f.Description); len(desc) > validation.MaxDescriptionLength {
+		f.Description = string(desc[:validation.MaxDescriptionLength])
+		r.note(sourceKey, "description truncated to %d characters", validation.MaxDescriptionLength)
+	}
+	r.Flags = append(r.Flags, f)
+}
+
+// normalizeProperty maps source attribute names onto goflagship context properties.
+func normalizeProperty(attr string) string {
+	switch attr {
+	case "key", "userId", "user_id":
+		return "id"
+	}
+	return attr
+}
+
+// valuesCondition builds an equality (one value) or membership (several values) condition.
+func valuesCondition(property string, values []any) rules.Condition {
+	if len(values) == 1 {
+		return rules.Condition{Property: property, Operator: rules.OpEq, Value: values[0]}
+	}
+	return rules.Condition{Property: property, Operator: rules.OpIn, Value: values}
+}
+
+// scaleWeights rescales weights expressed out of total so they sum to exactly
+// target (100 for percentages, 10000 for basis points). Rounding remainders
+// are assigned to the largest weight.
+func scaleWeights(weights []int, total, target int) []int {
+	out := make([]int, len(weights))
+	if total <= 0 || len(weights) == 0 {
+		return out
+	}
+	sum, largest := 0, 0
+	for i, w := range weights {
+		out[i] = (w*target + total/2) / total
+		sum += out[i]
+		if weights[i] > weights[largest] {
+			largest = i
+		}
+	}
+	out[largest] += target - sum
+	return out
+}
+
+// distribution builds a rule distribution, dropping zero weights as required
+// by rules.ValidateRule.
+func distribution(names []string, weights []int) map[string]int {
+	d := make(map[string]int, len(names))
+	for i, name := range names {
+		if weights[i] > 0 {
+			d[name] += weights[i]
+		}
+	}
+	return d
+}
@@ -0,0 +1,223 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func findFlag(t *testing.T, res *Result, key string) store.Flag {
+	t.Helper()
+	for _, f := range res.Flags {
+		if f.Key == key {
+			return f
+		}
+	}
+	t.Fatalf("flag %q not in result", key)
+	return store.Flag{}
+}
+
+func hasNote(res *Result, flag, substr string) bool {
+	for _, n := range res.Notes {
+		if n.Flag == flag && strings.Contains(n.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateRules(t *testing.T, f store.Flag) {
+	t.Helper()
+	for _, r := range f.TargetingRules {
+		if err := rules.ValidateRule(r); err != nil {
+			t.Errorf("flag %s rule %s invalid: %v", f.Key, r.ID, err)
+		}
+	}
+}
+
+const ldFlagsJSON = `{
+  "items": [
+    {
+      "key": "new.checkout",
+      "name": "New checkout",
+      "kind": "boolean",
+      "variations": [{"value": true}, {"value": false}],
+      "environments": {
+        "production": {
+          "on": true,
+          "offVariation": 1,
+          "fallthrough": {"rollout": {"variations": [{"variation": 0, "weight": 25000}, {"variation": 1, "weight": 75000}]}},
+          "targets": [{"values": ["u1", "u2"], "variation": 0}],
+          "rules": [
+            {"_id": "r1", "clauses": [{"attribute": "country", "op": "in", "values": ["DE"]}], "variation": 0},
+            {"_id": "r2", "clauses": [{"attribute": "segmentMatch", "op": "segmentMatch", "values": ["beta"]}], "variation": 0},
+            {"_id": "r3", "clauses": [{"attribute": "email", "op": "endsWith", "values": ["@acme.com"]}], "variation": 0}
+          ],
+          "prerequisites": [{"key": "other", "variation": 0}]
+        }
+      }
+    },
+    {
+      "key": "theme",
+      "kind": "multivariate",
+      "variations": [{"value": "light", "name": "light"}, {"value": "dark", "name": "dark"}],
+      "environments": {
+        "production": {
+          "on": false,
+          "fallthrough": {"variation": 1},
+          "rules": [
+            {"clauses": [{"attribute": "plan", "op": "in", "values": ["pro", "team"]}],
+             "rollout": {"variations": [{"variation": 0, "weight": 50000}, {"variation": 1, "weight": 50000}]}}
+          ]
+        }
+      }
+    }
+  ],
+  "_links": {}
+}`
+
+const ldSegmentsJSON = `{"items": [{"key": "beta", "included": ["b1"], "excluded": ["b2"]}]}`
+
+func TestFromLaunchDarkly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "ld-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v2/flags/web":
+			_, _ = w.Write([]byte(ldFlagsJSON))
+		case "/api/v2/segments/web/production":
+			_, _ = w.Write([]byte(ldSegmentsJSON))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	res, err := FromLaunchDarkly(context.Background(), LaunchDarklyOptions{
+		BaseURL: srv.URL, APIToken: "ld-token", Project: "web", TargetEnv: "prod",
+	})
+	if err != nil {
+		t.Fatalf("FromLaunchDarkly failed: %v", err)
+	}
+
+	checkout := findFlag(t, res, "new_checkout")
+	if !checkout.Enabled || checkout.Rollout != 25 || checkout.Env != "prod" {
+		t.Errorf("unexpected checkout flag: %+v", checkout)
+	}
+	if len(checkout.TargetingRules) != 3 {
+		t.Fatalf("expected target + 2 rules, got %+v", checkout.TargetingRules)
+	}
+	if got := checkout.TargetingRules[0].Conditions[0]; got.Operator != rules.OpIn || got.Property != "id" {
+		t.Errorf("individual targets should become an id rule, got %+v", got)
+	}
+	if got := checkout.TargetingRules[1]; got.ID != "r1" || got.Distribution[VariantOn] != 100 {
+		t.Errorf("unexpected rule r1: %+v", got)
+	}
+	validateRules(t, checkout)
+	for _, want := range []string{"key renamed", "endsWith", "prerequisites", "excluded users"} {
+		if !hasNote(res, "new.checkout", want) {
+			t.Errorf("expected note containing %q, got %+v", want, res.Notes)
+		}
+	}
+
+	theme := findFlag(t, res, "theme")
+	if theme.Enabled || len(theme.Variants) != 2 || theme.Variants[1].Weight != 100 {
+		t.Errorf("unexpected theme flag: %+v", theme)
+	}
+	if d := theme.TargetingRules[0].Distribution; d["light"] != 5000 || d["dark"] != 5000 {
+		t.Errorf("rollout rule should use basis points, got %v", d)
+	}
+	validateRules(t, theme)
+}
+
+func TestFromLaunchDarkly_RequiresTokenAndProject(t *testing.T) {
+	if _, err := FromLaunchDarkly(context.Background(), LaunchDarklyOptions{Project: "web"}); err == nil {
+		t.Error("expected error without API token")
+	}
+}
+
+const unleashJSON = `{
+  "features": [
+    {
+      "name": "search-v2",
+      "enabled": true,
+      "strategies": [
+        {"name": "flexibleRollout", "parameters": {"rollout": "30", "stickiness": "default"}},
+        {"name": "userWithId", "parameters": {"userIds": "a, b"}},
+        {"name": "flexibleRollout", "parameters": {"rollout": "50"}, "segments": [1]},
+        {"name": "remoteAddress", "parameters": {"IPs": "10.0.0.1"}}
+      ]
+    },
+    {
+      "name": "banner",
+      "enabled": true,
+      "strategies": [{"name": "default", "constraints": [{"contextName": "appVersion", "operator": "SEMVER_GT", "value": "2.0.0"}]}],
+      "variants": [
+        {"name": "blue", "weight": 500, "payload": {"type": "json", "value": "{\"color\":\"blue\"}"}},
+        {"name": "red", "weight": 500}
+      ]
+    }
+  ],
+  "segments": [{"id": 1, "constraints": [{"contextName": "country", "operator": "IN", "values": ["US", "CA"]}]}]
+}`
+
+func TestFromUnleash(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/client/features" || r.Header.Get("Authorization") != "un-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(unleashJSON))
+	}))
+	defer srv.Close()
+
+	res, err := FromUnleash(context.Background(), UnleashOptions{BaseURL: srv.URL, APIToken: "un-token", TargetEnv: "prod"})
+	if err != nil {
+		t.Fatalf("FromUnleash failed: %v", err)
+	}
+
+	search := findFlag(t, res, "search-v2")
+	if search.Rollout != 30 {
+		t.Errorf("expected rollout 30 from unconstrained strategy, got %d", search.Rollout)
+	}
+	if len(search.TargetingRules) != 2 {
+		t.Fatalf("expected userWithId and segment rules, got %+v", search.TargetingRules)
+	}
+	if d := search.TargetingRules[1].Distribution; d[VariantOn] != 50 || d[VariantOff] != 50 {
+		t.Errorf("constrained rollout should split on/off, got %v", d)
+	}
+	if len(search.Variants) != 2 || search.Variants[0].Weight != 30 {
+		t.Errorf("boolean flag should carry on/off variants, got %+v", search.Variants)
+	}
+	if !hasNote(res, "search-v2", "remoteAddress") {
+		t.Errorf("expected note for unsupported strategy, got %+v", res.Notes)
+	}
+	validateRules(t, search)
+
+	banner := findFlag(t, res, "banner")
+	if banner.Variants[0].Weight != 50 || banner.Variants[0].Config["value"].(map[string]any)["color"] != "blue" {
+		t.Errorf("unexpected banner variants: %+v", banner.Variants)
+	}
+	if c := banner.TargetingRules[0].Conditions[0]; c.Operator != rules.OpSemVerGt || c.Property != "appVersion" {
+		t.Errorf("unexpected banner condition: %+v", c)
+	}
+	validateRules(t, banner)
+}
+
+func TestScaleWeights(t *testing.T) {
+	got := scaleWeights([]int{333, 333, 334}, 1000, 100)
+	sum := 0
+	for _, w := range got {
+		sum += w
+	}
+	if sum != 100 {
+		t.Errorf("weights should sum to 100, got %v", got)
+	}
+}
@@ -0,0 +1,412 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// LaunchDarkly rollout weights are expressed in thousandths of a percent.
+const ldWeightTotal = 100000
+
+// LaunchDarklyOptions configures FromLaunchDarkly.
+type LaunchDarklyOptions struct {
+	BaseURL     string       // API base URL (default https://app.launchdarkly.com)
+	APIToken    string       // API access token with reader role
+	Project     string       // Project key
+	Environment string       // LaunchDarkly environment key to read targeting from (default "production")
+	TargetEnv   string       // goflagship environment to assign imported flags to
+	HTTPClient  *http.Client // optional
+}
+
+type ldFlagList struct {
+	Items []ldFlag `json:"items"`
+	Links struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"_links"`
+}
+
+type ldFlag struct {
+	Key          string                       `json:"key"`
+	Name         string                       `json:"name"`
+	Description  string                       `json:"description"`
+	Kind         string                       `json:"kind"`
+	Variations   []ldVariation                `json:"variations"`
+	Environments map[string]ldFlagEnvironment `json:"environments"`
+}
+
+type ldVariation struct {
+	Value any    `json:"value"`
+	Name  string `json:"name"`
+}
+
+type ldFlagEnvironment struct {
+	On             bool              `json:"on"`
+	OffVariation   *int              `json:"offVariation"`
+	Fallthrough    ldVariationOrRoll `json:"fallthrough"`
+	Targets        []ldTarget        `json:"targets"`
+	ContextTargets []ldTarget        `json:"contextTargets"`
+	Rules          []ldRule          `json:"rules"`
+	Prerequisites  []any             `json:"prerequisites"`
+}
+
+type ldVariationOrRoll struct {
+	Variation *int       `json:"variation"`
+	Rollout   *ldRollout `json:"rollout"`
+}
+
+type ldRollout struct {
+	Variations []struct {
+		Variation int `json:"variation"`
+		Weight    int `json:"weight"`
+	} `json:"variations"`
+	BucketBy string `json:"bucketBy"`
+}
+
+type ldTarget struct {
+	Values    []string `json:"values"`
+	Variation int      `json:"variation"`
+}
+
+type ldRule struct {
+	ID          string     `json:"_id"`
+	Description string     `json:"description"`
+	Clauses     []ldClause `json:"clauses"`
+	ldVariationOrRoll
+}
+
+type ldClause struct {
+	Attribute string `json:"attribute"`
+	Op        string `json:"op"`
+	Values    []any  `json:"values"`
+	Negate    bool   `json:"negate"`
+}
+
+type ldSegmentList struct {
+	Items []ldSegment `json:"items"`
+}
+
+type ldSegment struct {
+	Key      string   `json:"key"`
+	Included []string `json:"included"`
+	Excluded []string `json:"excluded"`
+	Rules    []any    `json:"rules"`
+}
+
+// FromLaunchDarkly fetches all flags (and segments) for a project and converts
+// the targeting of one LaunchDarkly environment into goflagship flags.
+func FromLaunchDarkly(ctx context.Context, opts LaunchDarklyOptions) (*Result, error) {
+	if opts.APIToken == "" || opts.Project == "" {
+		return nil, fmt.Errorf("launchdarkly: API token and project are required")
+	}
+	base := strings.TrimRight(opts.BaseURL, "/")
+	if base == "" {
+		base = "https://app.launchdarkly.com"
+	}
+	if opts.Environment == "" {
+		opts.Environment = "production"
+	}
+
+	var flags []ldFlag
+	next := fmt.Sprintf("%s/api/v2/flags/%s?summary=0&env=%s", base, url.PathEscape(opts.Project), url.QueryEscape(opts.Environment))
+	for next != "" {
+		var page ldFlagList
+		if err := getJSON(ctx, opts.HTTPClient, next, opts.APIToken, &page); err != nil {
+			return nil, fmt.Errorf("launchdarkly: list flags: %w", err)
+		}
+		flags = append(flags, page.Items...)
+		next = ""
+		if page.Links.Next != nil && page.Links.Next.Href != "" {
+			next = base + page.Links.Next.Href
+		}
+	}
+
+	var segments ldSegmentList
+	segURL := fmt.Sprintf("%s/api/v2/segments/%s/%s", base, url.PathEscape(opts.Project), url.PathEscape(opts.Environment))
+	if err := getJSON(ctx, opts.HTTPClient, segURL, opts.APIToken, &segments); err != nil {
+		return nil, fmt.Errorf("launchdarkly: list segments: %w", err)
+	}
+
+	return convertLaunchDarkly(flags, segments.Items, opts.Environment, opts.TargetEnv), nil
+}
+
+// ldConverter carries state shared by the conversion of a single flag.
+type ldConverter struct {
+	res      *Result
+	segments map[string]ldSegment
+	flagKey  string
+	boolean  bool
+	names    []string // goflagship variant name per LaunchDarkly variation index
+}
+
+func convertLaunchDarkly(flags []ldFlag, segments []ldSegment, ldEnv, targetEnv string) *Result {
+	res := &Result{Flags: []store.Flag{}, Notes: []Note{}}
+	segIndex := make(map[string]ldSegment, len(segments))
+	for _, s := range segments {
+		segIndex[s.Key] = s
+	}
+
+	for _, f := range flags {
+		envCfg, ok := f.Environments[ldEnv]
+		if !ok {
+			res.note(f.Key, "skipped: no configuration for environment %q", ldEnv)
+			continue
+		}
+		c := &ldConverter{res: res, segments: segIndex, flagKey: f.Key, boolean: isLDBoolean(f)}
+		c.names = c.variantNames(f.Variations)
+
+		flag := store.Flag{
+			Description: f.Description,
+			Enabled:     envCfg.On,
+			Env:         targetEnv,
+		}
+		if flag.Description == "" {
+			flag.Description = f.Name
+		}
+		c.applyFallthrough(&flag, f.Variations, envCfg.Fallthrough)
+		c.checkOffVariation(f.Variations, envCfg.OffVariation)
+
+		targets := append(append([]ldTarget{}, envCfg.Targets...), envCfg.ContextTargets...)
+		for i, t := range targets {
+			if len(t.Values) == 0 || !c.validVariation(t.Variation) {
+				continue
+			}
+			values := make([]any, len(t.Values))
+			for j, v := range t.Values {
+				values[j] = v
+			}
+			flag.TargetingRules = append(flag.TargetingRules, rules.Rule{
+				ID:           fmt.Sprintf("target-%d", i+1),
+				Conditions:   []rules.Condition{valuesCondition("id", values)},
+				Distribution: map[string]int{c.names[t.Variation]: 100},
+			})
+		}
+		for i, r := range envCfg.Rules {
+			if rule, ok := c.convertRule(i, r); ok {
+				flag.TargetingRules = append(flag.TargetingRules, rule)
+			}
+		}
+		if len(envCfg.Prerequisites) > 0 {
+			c.res.note(f.Key, "prerequisites are not supported and were dropped")
+		}
+
+		res.addFlag(f.Key, flag)
+	}
+	return res
+}
+
+// isLDBoolean reports whether the flag is a plain true/false flag.
+func isLDBoolean(f ldFlag) bool {
+	if len(f.Variations) != 2 {
+		return false
+	}
+	a, okA := f.Variations[0].Value.(bool)
+	b, okB := f.Variations[1].Value.(bool)
+	return okA && okB && a != b
+}
+
+func (c *ldConverter) variantNames(variations []ldVariation) []string {
+	names := make([]string, len(variations))
+	seen := make(map[string]bool, len(variations))
+	for i, v := range variations {
+		var name string
+		switch {
+		case c.boolean:
+			name = VariantOff
+			if v.Value == true {
+				name = VariantOn
+			}
+		case v.Name != "":
+			name = v.Name
+		default:
+			name = fmt.Sprintf("variation-%d", i)
+		}
+		if seen[name] {
+			name = fmt.Sprintf("%s-%d", name, i)
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+func (c *ldConverter) validVariation(i int) bool {
+	if i < 0 || i >= len(c.names) {
+		c.res.note(c.flagKey, "reference to unknown variation %d was dropped", i)
+		return false
+	}
+	return true
+}
+
+// weights returns per-variation weights in the given total for a fixed
+// variation or a percentage rollout.
+func (c *ldConverter) weights(vr ldVariationOrRoll, total int) ([]int, bool) {
+	out := make([]int, len(c.names))
+	switch {
+	case vr.Variation != nil:
+		if !c.validVariation(*vr.Variation) {
+			return nil, false
+		}
+		out[*vr.Variation] = total
+		return out, true
+	case vr.Rollout != nil:
+		raw := make([]int, len(c.names))
+		for _, wv := range vr.Rollout.Variations {
+			if !c.validVariation(wv.Variation) {
+				return nil, false
+			}
+			raw[wv.Variation] += wv.Weight
+		}
+		if vr.Rollout.BucketBy != "" && vr.Rollout.BucketBy != "key" {
+			c.res.note(c.flagKey, "rollout bucketed by %q is bucketed by user id instead", vr.Rollout.BucketBy)
+		}
+		return scaleWeights(raw, ldWeightTotal, total), true
+	}
+	return nil, false
+}
+
+// applyFallthrough maps the default (fallthrough) serving onto Rollout for
+// boolean flags, or onto variant weights for multivariate flags.
+func (c *ldConverter) applyFallthrough(flag *store.Flag, variations []ldVariation, ft ldVariationOrRoll) {
+	weights, ok := c.weights(ft, 100)
+	if !ok {
+		weights = make([]int, len(c.names))
+		if len(weights) > 0 {
+			weights[0] = 100
+		}
+		c.res.note(c.flagKey, "fallthrough could not be converted; defaulting to the first variation")
+	}
+
+	if c.boolean {
+		for i, name := range c.names {
+			if name == VariantOn {
+				flag.Rollout = int32(weights[i])
+			}
+		}
+		flag.Variants = booleanVariants(flag.Rollout)
+		return
+	}
+
+	flag.Variants = make([]store.Variant, len(variations))
+	for i, v := range variations {
+		flag.Variants[i] = store.Variant{
+			Name:   c.names[i],
+			Weight: weights[i],
+			Config: map[string]any{"value": v.Value},
+		}
+	}
+	flag.Rollout = 100
+}
+
+func (c *ldConverter) checkOffVariation(variations []ldVariation, off *int) {
+	if off == nil {
+		return
+	}
+	if c.boolean && *off >= 0 && *off < len(c.names) && c.names[*off] == VariantOff {
+		return
+	}
+	c.res.note(c.flagKey, "off variation is not supported; disabled flags return their default config")
+}
+
+func (c *ldConverter) convertRule(i int, r ldRule) (rules.Rule, bool) {
+	id := r.ID
+	if id == "" {
+		id = fmt.Sprintf("rule-%d", i+1)
+	}
+	var conditions []rules.Condition
+	for _, cl := range r.Clauses {
+		conds, ok := c.convertClause(cl)
+		if !ok {
+			c.res.note(c.flagKey, "rule %d dropped: clause %s %s is not supported", i+1, cl.Attribute, clauseOp(cl))
+			return rules.Rule{}, false
+		}
+		conditions = append(conditions, conds...)
+	}
+	if len(conditions) == 0 {
+		c.res.note(c.flagKey, "rule %d dropped: no conditions", i+1)
+		return rules.Rule{}, false
+	}
+	total := 10000 // basis points preserve fractional rollout percentages
+	if r.Variation != nil {
+		total = 100
+	}
+	weights, ok := c.weights(r.ldVariationOrRoll, total)
+	if !ok {
+		c.res.note(c.flagKey, "rule %d dropped: no variation or rollout", i+1)
+		return rules.Rule{}, false
+	}
+	return rules.Rule{ID: id, Conditions: conditions, Distribution: distribution(c.names, weights)}, true
+}
+
+func clauseOp(cl ldClause) string {
+	if cl.Negate {
+		return "not " + cl.Op
+	}
+	return cl.Op
+}
+
+var ldOperators = map[string]rules.Operator{
+	"lessThan":           rules.OpLt,
+	"lessThanOrEqual":    rules.OpLte,
+	"greaterThan":        rules.OpGt,
+	"greaterThanOrEqual": rules.OpGte,
+	"semVerGreaterThan":  rules.OpSemVerGt,
+	"semVerLessThan":     rules.OpSemVerLt,
+	"contains":           rules.OpContains,
+}
+
+// convertClause maps a LaunchDarkly clause onto one or more AND-ed conditions.
+func (c *ldConverter) convertClause(cl ldClause) ([]rules.Condition, bool) {
+	property := normalizeProperty(cl.Attribute)
+
+	switch cl.Op {
+	case "in":
+		if len(cl.Values) == 0 {
+			return nil, false
+		}
+		if cl.Negate {
+			if len(cl.Values) != 1 {
+				return nil, false
+			}
+			return []rules.Condition{{Property: property, Operator: rules.OpNeq, Value: cl.Values[0]}}, true
+		}
+		return []rules.Condition{valuesCondition(property, cl.Values)}, true
+
+	case "segmentMatch":
+		if cl.Negate || len(cl.Values) != 1 {
+			return nil, false
+		}
+		return c.segmentCondition(cl.Values[0])
+	}
+
+	op, ok := ldOperators[cl.Op]
+	if !ok || cl.Negate || len(cl.Values) != 1 {
+		return nil, false
+	}
+	return []rules.Condition{{Property: property, Operator: op, Value: cl.Values[0]}}, true
+}
+
+// segmentCondition inlines a segment as an id membership check. Only segments
+// defined by an included list can be represented.
+func (c *ldConverter) segmentCondition(v any) ([]rules.Condition, bool) {
+	key, _ := v.(string)
+	seg, ok := c.segments[key]
+	if !ok || len(seg.Included) == 0 || len(seg.Rules) > 0 {
+		return nil, false
+	}
+	if len(seg.Excluded) > 0 {
+		c.res.note(c.flagKey, "segment %q: excluded users are not supported and were ignored", key)
+	}
+	values := make([]any, len(seg.Included))
+	for i, id := range seg.Included {
+		values[i] = id
+	}
+	return []rules.Condition{valuesCondition("id", values)}, true
+}
@@ -0,0 +1,326 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// Unleash variant weights are expressed out of 1000.
+const unleashWeightTotal = 1000
+
+// UnleashOptions configures FromUnleash.
+type UnleashOptions struct {
+	BaseURL    string       // Unleash URL, e.g. https://unleash.example.com (required)
+	APIToken   string       // Client or admin API token; the token's environment is imported
+	Project    string       // Optional project filter
+	TargetEnv  string       // goflagship environment to assign imported flags to
+	HTTPClient *http.Client // optional
+}
+
+type unleashFeatures struct {
+	Features []unleashFeature `json:"features"`
+	Segments []unleashSegment `json:"segments"`
+}
+
+type unleashFeature struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Enabled     bool              `json:"enabled"`
+	Strategies  []unleashStrategy `json:"strategies"`
+	Variants    []unleashVariant  `json:"variants"`
+}
+
+type unleashStrategy struct {
+	Name        string              `json:"name"`
+	Parameters  map[string]any      `json:"parameters"`
+	Constraints []unleashConstraint `json:"constraints"`
+	Segments    []int               `json:"segments"`
+	Variants    []unleashVariant    `json:"variants"`
+}
+
+type unleashConstraint struct {
+	ContextName string   `json:"contextName"`
+	Operator    string   `json:"operator"`
+	Values      []string `json:"values"`
+	Value       string   `json:"value"`
+	Inverted    bool     `json:"inverted"`
+}
+
+type unleashVariant struct {
+	Name    string `json:"name"`
+	Weight  int    `json:"weight"`
+	Payload *struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"payload"`
+}
+
+type unleashSegment struct {
+	ID          int                 `json:"id"`
+	Name        string              `json:"name"`
+	Constraints []unleashConstraint `json:"constraints"`
+}
+
+// FromUnleash fetches feature toggles through the Unleash client API and
+// converts them into goflagship flags.
+func FromUnleash(ctx context.Context, opts UnleashOptions) (*Result, error) {
+	if opts.BaseURL == "" || opts.APIToken == "" {
+		return nil, fmt.Errorf("unleash: URL and API token are required")
+	}
+	u := strings.TrimRight(opts.BaseURL, "/") + "/api/client/features"
+	if opts.Project != "" {
+		u += "?project=" + url.QueryEscape(opts.Project)
+	}
+
+	var features unleashFeatures
+	if err := getJSON(ctx, opts.HTTPClient, u, opts.APIToken, &features); err != nil {
+		return nil, fmt.Errorf("unleash: list features: %w", err)
+	}
+	return convertUnleash(features, opts.TargetEnv), nil
+}
+
+func convertUnleash(data unleashFeatures, targetEnv string) *Result {
+	res := &Result{Flags: []store.Flag{}, Notes: []Note{}}
+	segments := make(map[int]unleashSegment, len(data.Segments))
+	for _, s := range data.Segments {
+		segments[s.ID] = s
+	}
+
+	for _, f := range data.Features {
+		flag := store.Flag{
+			Description: f.Description,
+			Enabled:     f.Enabled,
+			Env:         targetEnv,
+		}
+		variants := f.Variants
+		for _, s := range f.Strategies {
+			if len(variants) == 0 && len(s.Variants) > 0 {
+				variants = s.Variants
+			}
+		}
+		flag.Variants = convertUnleashVariants(res, f.Name, variants)
+
+		// Unleash strategies are OR-ed: unconstrained strategies define the
+		// default rollout, constrained ones become targeting rules.
+		for i, s := range f.Strategies {
+			pct, ok := unleashRolloutPercentage(s)
+			if !ok {
+				res.note(f.Name, "strategy %d (%s) dropped: strategy is not supported", i+1, s.Name)
+				continue
+			}
+			conditions, ok := unleashConditions(res, f.Name, i, s, segments)
+			if !ok {
+				continue
+			}
+			if len(conditions) == 0 {
+				if int32(pct) > flag.Rollout {
+					flag.Rollout = int32(pct)
+				}
+				continue
+			}
+			dist, ok := unleashDistribution(flag.Variants, pct)
+			if !ok {
+				res.note(f.Name, "strategy %d: partial rollout within a variant flag is not supported; serving variant weights to all matches", i+1)
+				dist, _ = unleashDistribution(flag.Variants, 100)
+			}
+			flag.TargetingRules = append(flag.TargetingRules, rules.Rule{
+				ID:           fmt.Sprintf("strategy-%d", i+1),
+				Conditions:   conditions,
+				Distribution: dist,
+			})
+		}
+		if len(f.Strategies) == 0 && f.Enabled {
+			flag.Rollout = 100 // toggles without strategies are on for everyone
+		}
+		if len(flag.Variants) == 0 {
+			flag.Variants = booleanVariants(flag.Rollout)
+		}
+
+		res.addFlag(f.Name, flag)
+	}
+	return res
+}
+
+func convertUnleashVariants(res *Result, flagName string, variants []unleashVariant) []store.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	raw := make([]int, len(variants))
+	total := 0
+	for i, v := range variants {
+		raw[i] = v.Weight
+		total += v.Weight
+	}
+	if total != unleashWeightTotal {
+		res.note(flagName, "variant weights sum to %d instead of %d and were rescaled", total, unleashWeightTotal)
+	}
+	weights := scaleWeights(raw, total, 100)
+
+	out := make([]store.Variant, len(variants))
+	for i, v := range variants {
+		out[i] = store.Variant{Name: v.Name, Weight: weights[i]}
+		if v.Payload != nil {
+			out[i].Config = map[string]any{"value": unleashPayloadValue(v.Payload.Type, v.Payload.Value)}
+		}
+	}
+	return out
+}
+
+// unleashPayloadValue decodes JSON payloads; other payload types stay strings.
+func unleashPayloadValue(typ, value string) any {
+	if typ == "json" {
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err == nil {
+			return v
+		}
+	}
+	if typ == "number" {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+// unleashRolloutPercentage returns the share of matching users a strategy
+// enables. Strategies that do not target by percentage report 100.
+func unleashRolloutPercentage(s unleashStrategy) (int, bool) {
+	switch s.Name {
+	case "default", "userWithId":
+		return 100, true
+	case "flexibleRollout", "gradualRolloutUserId", "gradualRolloutRandom", "gradualRolloutSessionId":
+		key := "rollout"
+		if s.Name != "flexibleRollout" {
+			key = "percentage"
+		}
+		pct, err := strconv.Atoi(fmt.Sprint(s.Parameters[key]))
+		if err != nil || pct < 0 || pct > 100 {
+			return 0, false
+		}
+		return pct, true
+	}
+	return 0, false
+}
+
+func unleashDistribution(variants []store.Variant, pct int) (map[string]int, bool) {
+	if len(variants) == 0 {
+		return distribution([]string{VariantOn, VariantOff}, []int{pct, 100 - pct}), true
+	}
+	if pct != 100 {
+		return nil, false
+	}
+	names := make([]string, len(variants))
+	weights := make([]int, len(variants))
+	for i, v := range variants {
+		names[i] = v.Name
+		weights[i] = v.Weight
+	}
+	return distribution(names, weights), true
+}
+
+// unleashConditions collects the AND-ed conditions of a strategy: user ids,
+// inline constraints and the constraints of referenced segments.
+func unleashConditions(res *Result, flagName string, i int, s unleashStrategy, segments map[int]unleashSegment) ([]rules.Condition, bool) {
+	var conditions []rules.Condition
+	if s.Name == "userWithId" {
+		var ids []any
+		for _, id := range strings.Split(fmt.Sprint(s.Parameters["userIds"]), ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			res.note(flagName, "strategy %d (userWithId) dropped: no user ids", i+1)
+			return nil, false
+		}
+		conditions = append(conditions, valuesCondition("id", ids))
+	}
+
+	constraints := append([]unleashConstraint{}, s.Constraints...)
+	for _, id := range s.Segments {
+		seg, ok := segments[id]
+		if !ok {
+			res.note(flagName, "strategy %d dropped: unknown segment %d", i+1, id)
+			return nil, false
+		}
+		constraints = append(constraints, seg.Constraints...)
+	}
+	for _, c := range constraints {
+		cond, ok := convertUnleashConstraint(c)
+		if !ok {
+			res.note(flagName, "strategy %d dropped: constraint %s %s is not supported", i+1, c.ContextName, unleashOp(c))
+			return nil, false
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, true
+}
+
+func unleashOp(c unleashConstraint) string {
+	if c.Inverted {
+		return "NOT " + c.Operator
+	}
+	return c.Operator
+}
+
+var unleashNumericOperators = map[string]rules.Operator{
+	"NUM_EQ":  rules.OpEq,
+	"NUM_GT":  rules.OpGt,
+	"NUM_GTE": rules.OpGte,
+	"NUM_LT":  rules.OpLt,
+	"NUM_LTE": rules.OpLte,
+}
+
+func convertUnleashConstraint(c unleashConstraint) (rules.Condition, bool) {
+	property := normalizeProperty(c.ContextName)
+
+	switch c.Operator {
+	case "IN", "NOT_IN":
+		if len(c.Values) == 0 {
+			return rules.Condition{}, false
+		}
+		negate := (c.Operator == "NOT_IN") != c.Inverted
+		if negate {
+			if len(c.Values) != 1 {
+				return rules.Condition{}, false
+			}
+			return rules.Condition{Property: property, Operator: rules.OpNeq, Value: c.Values[0]}, true
+		}
+		values := make([]any, len(c.Values))
+		for i, v := range c.Values {
+			values[i] = v
+		}
+		return valuesCondition(property, values), true
+	}
+
+	if c.Inverted {
+		return rules.Condition{}, false
+	}
+	switch c.Operator {
+	case "STR_CONTAINS":
+		if len(c.Values) != 1 {
+			return rules.Condition{}, false
+		}
+		return rules.Condition{Property: property, Operator: rules.OpContains, Value: c.Values[0]}, true
+	case "SEMVER_GT":
+		return rules.Condition{Property: property, Operator: rules.OpSemVerGt, Value: c.Value}, c.Value != ""
+	case "SEMVER_LT":
+		return rules.Condition{Property: property, Operator: rules.OpSemVerLt, Value: c.Value}, c.Value != ""
+	}
+	if op, ok := unleashNumericOperators[c.Operator]; ok {
+		n, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return rules.Condition{}, false
+		}
+		return rules.Condition{Property: property, Operator: op, Value: n}, true
+	}
+	return rules.Condition{}, false
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// MailConfig configures email delivery of reports over SMTP.
+type MailConfig struct {
+	Addr     string   // SMTP server "host:port"
+	Username string   // empty disables authentication
+	Password string   // used with Username
+	From     string   // sender address
+	To       []string // recipients
+}
+
+// Validate checks that the configuration can send mail.
+func (c MailConfig) Validate() error {
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("SMTP address must be host:port: %w", err)
+	}
+	if c.From == "" {
+		return errors.New("a sender address is required")
+	}
+	if len(c.To) == 0 {
+		return errors.New("at least one recipient is required")
+	}
+	for _, addr := range append([]string{c.From}, c.To...) {
+		if !strings.Contains(addr, "@") || strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("invalid email address %q", addr)
+		}
+	}
+	return nil
+}
+
+// Mailer sends reports by email.
+type Mailer struct {
+	cfg MailConfig
+
+	// sendMail is smtp.SendMail, replaced in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewMailer returns a Mailer for cfg, which must be valid.
+func NewMailer(cfg MailConfig) (*Mailer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Mailer{cfg: cfg, sendMail: smtp.SendMail}, nil
+}
+
+// Send emails a plain-text message. The connection uses STARTTLS when the
+// server offers it; credentials are only sent over TLS (or to localhost).
+func (m *Mailer) Send(subject, body string) error {
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(m.cfg.Addr)
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, host)
+	}
+	if err := m.sendMail(m.cfg.Addr, auth, m.cfg.From, m.cfg.To, m.message(subject, body, time.Now())); err != nil {
+		return fmt.Errorf("send report email: %w", err)
+	}
+	return nil
+}
+
+// message formats an RFC 5322 message with CRLF line endings.
+func (m *Mailer) message(subject, body string, now time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + m.cfg.From + "\r\n")
+	b.WriteString("To: " + strings.Join(m.cfg.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + strings.NewReplacer("\r", " ", "\n", " ").Replace(subject) + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,150 @@
+// Package report renders and delivers the periodic summary report: what
+// changed in a project over the last period, which flags look stale, which
+// experiments are running and which audit events deserve a second look.
+//
+// The api package gathers a Summary from the store, the audit log and the
+// stale flag cleanup report; this package turns it into text with a
+// text/template (DefaultTemplate unless one is configured) and sends it by
+// email (Mailer). Webhook delivery goes through the regular dispatcher.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Summary is the data of one summary report.
+type Summary struct {
+	Project     string    `json:"project"`
+	Env         string    `json:"env"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	NewFlags     []string     `json:"new_flags"`
+	ChangedFlags []FlagChange `json:"changed_flags"`
+	DeletedFlags []string     `json:"deleted_flags"`
+	StaleFlags   []StaleFlag  `json:"stale_flags"`
+	Experiments  []Experiment `json:"experiments"`
+	Anomalies    []Anomaly    `json:"anomalies"`
+
+	// AuditAvailable is false when there is no audit log to read (the memory
+	// store): changed flags then come from flag timestamps, and new and
+	// deleted flags and anomalies are unknown.
+	AuditAvailable bool `json:"audit_available"`
+}
+
+// FlagChange summarizes the updates to one flag.
+type FlagChange struct {
+	Key     string   `json:"key"`
+	Changes int      `json:"changes"`
+	Actors  []string `json:"actors,omitempty"`
+}
+
+// StaleFlag is a flag from the stale flag cleanup report.
+type StaleFlag struct {
+	Key     string   `json:"key"`
+	Reasons []string `json:"reasons"`
+}
+
+// Experiment is an enabled flag that splits traffic between variants.
+type Experiment struct {
+	Key      string   `json:"key"`
+	Variants []string `json:"variants"` // "name (weight%)"
+}
+
+// Anomaly counts audit events of one kind that may need attention, such as
+// failed authentications or protection overrides.
+type Anomaly struct {
+	Kind        string `json:"kind"`
+	Count       int    `json:"count"`
+	Description string `json:"description"`
+}
+
+// Subject returns the email subject for s.
+func (s Summary) Subject() string {
+	return fmt.Sprintf("[goflagship] Summary for %s/%s, %s to %s",
+		s.Project, s.Env, s.From.Format("2006-01-02"), s.To.Format("2006-01-02"))
+}
+
+// DefaultTemplate is the plain-text layout used unless another template is
+// configured. Templates are executed with a Summary.
+const DefaultTemplate = `goflagship summary for project {{.Project}}, environment {{.Env}}
+{{.From.Format "2006-01-02 15:04"}} to {{.To.Format "2006-01-02 15:04"}} UTC
+{{if not .AuditAvailable}}
+No audit log is available: changes are taken from flag timestamps, and new
+flags, deleted flags and anomalies are not reported.
+{{end}}
+New flags ({{len .NewFlags}})
+{{- range .NewFlags}}
+  - {{.}}
+{{- end}}
+
+Changed flags ({{len .ChangedFlags}})
+{{- range .ChangedFlags}}
+  - {{.Key}}: {{.Changes}} change(s){{if .Actors}} by {{join .Actors ", "}}{{end}}
+{{- end}}
+
+Deleted flags ({{len .DeletedFlags}})
+{{- range .DeletedFlags}}
+  - {{.}}
+{{- end}}
+
+Stale flags ({{len .StaleFlags}})
+{{- range .StaleFlags}}
+  - {{.Key}}: {{join .Reasons ", "}}
+{{- end}}
+
+Running experiments ({{len .Experiments}})
+{{- range .Experiments}}
+  - {{.Key}}: {{join .Variants ", "}}
+{{- end}}
+
+Audit anomalies ({{len .Anomalies}})
+{{- range .Anomalies}}
+  - {{.Description}}: {{.Count}}
+{{- end}}
+`
+
+// Template renders a Summary as text.
+type Template struct {
+	tmpl *template.Template
+}
+
+var templateFuncs = template.FuncMap{"join": strings.Join}
+
+// ParseTemplate parses a text/template. Besides the standard functions,
+// templates can use join (strings.Join).
+func ParseTemplate(text string) (*Template, error) {
+	tmpl, err := template.New("summary").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse report template: %w", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// LoadTemplate reads and parses the template at path. An empty path returns
+// the default template.
+func LoadTemplate(path string) (*Template, error) {
+	if path == "" {
+		return ParseTemplate(DefaultTemplate)
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read report template: %w", err)
+	}
+	return ParseTemplate(string(text))
+}
+
+// Render executes the template with s.
+func (t *Template) Render(s Summary) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, s); err != nil {
+		return "", fmt.Errorf("render report: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSummary() Summary {
+	from := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	return Summary{
+		Project:        "default",
+		Env:            "prod",
+		From:           from,
+		To:             from.Add(7 * 24 * time.Hour),
+		NewFlags:       []string{"new_checkout"},
+		ChangedFlags:   []FlagChange{{Key: "banner", Changes: 3, Actors: []string{"alice", "bob"}}},
+		StaleFlags:     []StaleFlag{{Key: "old_off", Reasons: []string{"fully_off", "not_evaluated"}}},
+		Experiments:    []Experiment{{Key: "pricing", Variants: []string{"control (50%)", "test (50%)"}}},
+		Anomalies:      []Anomaly{{Kind: "auth_failed", Count: 12, Description: "Failed authentications"}},
+		AuditAvailable: true,
+	}
+}
+
+func TestDefaultTemplate_Render(t *testing.T) {
+	tmpl, err := LoadTemplate("")
+	if err != nil {
+		t.Fatalf("LoadTemplate: %v", err)
+	}
+	text, err := tmpl.Render(testSummary())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, want := range []string{
+		"project default, environment prod",
+		"2026-10-05 00:00 to 2026-10-12 00:00 UTC",
+		"New flags (1)\n  - new_checkout",
+		"  - banner: 3 change(s) by alice, bob",
+		"Deleted flags (0)",
+		"  - old_off: fully_off, not_evaluated",
+		"  - pricing: control (50%), test (50%)",
+		"  - Failed authentications: 12",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report is missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "No audit log") {
+		t.Error("audit note should only be shown without an audit log")
+	}
+}
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	if _, err := ParseTemplate("{{.Project"); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	tmpl, err := ParseTemplate("{{.Missing}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	if _, err := tmpl.Render(testSummary()); err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+}
+
+func TestMailConfig_Validate(t *testing.T) {
+	valid := MailConfig{Addr: "smtp.example.com:587", From: "flagship@example.com", To: []string{"team@example.com"}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for name, cfg := range map[string]MailConfig{
+		"no port":          {Addr: "smtp.example.com", From: valid.From, To: valid.To},
+		"no sender":        {Addr: valid.Addr, To: valid.To},
+		"no recipients":    {Addr: valid.Addr, From: valid.From},
+		"header injection": {Addr: valid.Addr, From: valid.From, To: []string{"a@example.com\r\nBcc: x@evil.test"}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMailer_Send(t *testing.T) {
+	m, err := NewMailer(MailConfig{
+		Addr:     "smtp.example.com:587",
+		Username: "flagship",
+		Password: "secret",
+		From:     "flagship@example.com",
+		To:       []string{"team@example.com", "lead@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("NewMailer: %v", err)
+	}
+	var gotAddr string
+	var gotAuth smtp.Auth
+	var gotTo []string
+	var gotMsg string
+	m.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotTo, gotMsg = addr, a, to, string(msg)
+		return nil
+	}
+
+	if err := m.Send("Weekly\nsummary", "line 1\nline 2\n"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" || gotAuth == nil || len(gotTo) != 2 {
+		t.Errorf("sendMail called with addr=%q auth=%v to=%v", gotAddr, gotAuth, gotTo)
+	}
+	for _, want := range []string{
+		"To: team@example.com, lead@example.com\r\n",
+		"Subject: Weekly summary\r\n",
+		"\r\n\r\nline 1\r\nline 2\r\n",
+	} {
+		if !strings.Contains(gotMsg, want) {
+			t.Errorf("message is missing %q:\n%q", want, gotMsg)
+		}
+	}
+}
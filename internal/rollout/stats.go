@@ -0,0 +1,93 @@
+package rollout
+
+import "math"
+
+// ChiSquareTest runs Pearson's chi-square goodness-of-fit test of observed
+// counts against expected shares, as used to detect sample ratio mismatch.
+//
+// Preconditions:
+//   - observed and expected have the same length
+//   - expected shares are >= 0 and sum to 1
+//
+// Postconditions:
+//   - Returns the test statistic and its p-value (0-1); a small p-value means
+//     the counts are unlikely under the expected shares
+//   - Categories with an expected share of 0 do not count as degrees of
+//     freedom; any observation in one gives (+Inf, 0)
+//   - Fewer than two categories with a share, or no observations, give (0, 1)
+func ChiSquareTest(observed []int, expected []float64) (stat, pValue float64) {
+	total := 0
+	for _, o := range observed {
+		total += o
+	}
+	if total == 0 {
+		return 0, 1
+	}
+
+	categories := 0
+	for i, share := range expected {
+		if share <= 0 {
+			if observed[i] > 0 {
+				return math.Inf(1), 0
+			}
+			continue
+		}
+		categories++
+		e := share * float64(total)
+		d := float64(observed[i]) - e
+		stat += d * d / e
+	}
+	if categories < 2 {
+		return 0, 1
+	}
+	return stat, gammaQ(float64(categories-1)/2, stat/2)
+}
+
+// gammaQ is the regularized upper incomplete gamma function Q(a, x), the
+// survival function of the chi-square distribution with 2a degrees of
+// freedom at 2x.
+func gammaQ(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lg, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lg)
+	if x < a+1 {
+		// Series for P(a, x)
+		sum, term := 1/a, 1/a
+		for n := 1; n < 500; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*prefix)
+	}
+
+	// Continued fraction for Q(a, x) (modified Lentz)
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < 500; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Min(1, prefix*h)
+}
@@ -0,0 +1,64 @@
+package rollout
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChiSquareTest_CriticalValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		observed []int
+		expected []float64
+		wantP    float64
+	}{
+		{"no observations", []int{0, 0}, []float64{0.5, 0.5}, 1},
+		{"exact split", []int{500, 500}, []float64{0.5, 0.5}, 1},
+	}
+	for _, tt := range tests {
+		if _, p := ChiSquareTest(tt.observed, tt.expected); math.Abs(p-tt.wantP) > 1e-9 {
+			t.Errorf("%s: expected p=%v, got %v", tt.name, tt.wantP, p)
+		}
+	}
+
+	// Critical values: df=1 at 0.05 and 0.001, df=2 and df=10 at 0.05.
+	for _, c := range []struct {
+		df    float64
+		stat  float64
+		wantP float64
+	}{
+		{1, 3.841459, 0.05},
+		{1, 10.827566, 0.001},
+		{2, 5.991465, 0.05},
+		{10, 18.307038, 0.05},
+	} {
+		if p := gammaQ(c.df/2, c.stat/2); math.Abs(p-c.wantP) > 1e-6 {
+			t.Errorf("df=%v stat=%v: expected p=%v, got %v", c.df, c.stat, c.wantP, p)
+		}
+	}
+}
+
+func TestChiSquareTest_Mismatch(t *testing.T) {
+	stat, p := ChiSquareTest([]int{550, 450}, []float64{0.5, 0.5})
+	if math.Abs(stat-10) > 1e-9 || p > 0.01 {
+		t.Errorf("expected stat=10 and p<0.01 for a 55/45 split, got %v, %v", stat, p)
+	}
+	if stat, p := ChiSquareTest([]int{90, 10}, []float64{1, 0}); !math.IsInf(stat, 1) || p != 0 {
+		t.Errorf("expected observations in a zero-share category to fail, got %v, %v", stat, p)
+	}
+}
+
+func TestBucketUser_AATest(t *testing.T) {
+	// Hashing users into 10 groups of buckets should look uniform.
+	observed := make([]int, 10)
+	expected := make([]float64, 10)
+	for i := range expected {
+		expected[i] = 0.1
+	}
+	for i := 0; i < 20000; i++ {
+		observed[BucketUser("user-"+itoa(i), "aa_flag", "salt")/10]++
+	}
+	if _, p := ChiSquareTest(observed, expected); p < 0.001 {
+		t.Errorf("bucketing looks skewed: %v (p=%v)", observed, p)
+	}
+}
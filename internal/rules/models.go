@@ -17,6 +17,18 @@ const (
 	OpSemVerLt Operator = "semver_lt"
 )
 
+// OnErrorConfigKey is the flag config key holding the enabled state served
+// when the flag's expression or targeting rules fail at runtime, e.g.
+// {"on_error": true} for a flag that must stay on. It defaults to false.
+const OnErrorConfigKey = "on_error"
+
+// OnErrorEnabled returns the enabled state a flag with config serves when its
+// evaluation fails.
+func OnErrorEnabled(config map[string]any) bool {
+	enabled, _ := config[OnErrorConfigKey].(bool)
+	return enabled
+}
+
 // Condition represents a single targeting predicate.
 // When multiple conditions belong to one Rule, they are evaluated with AND semantics:
 // all conditions must match for the rule to apply.
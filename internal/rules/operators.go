@@ -0,0 +1,46 @@
+package rules
+
+// ValueType names the kind of condition value an operator expects, as
+// enforced by ValidateRule.
+type ValueType string
+
+const (
+	ValueString ValueType = "string"
+	ValueNumber ValueType = "number"
+	ValueList   ValueType = "list"   // JSON array of strings or numbers
+	ValueScalar ValueType = "scalar" // string, number or boolean
+)
+
+// OperatorInfo describes an operator for rule builders, e.g. the admin UI.
+type OperatorInfo struct {
+	Operator Operator `json:"operator"`
+	Label    string   `json:"label"`
+	// ValueType is the type of the condition value.
+	ValueType ValueType `json:"value_type"`
+	// AttributeTypes are the context attribute types the operator can match.
+	AttributeTypes []string `json:"attribute_types"`
+	Description    string   `json:"description"`
+}
+
+// operatorCatalog lists every operator in validOperators, in the order a
+// rule builder should offer them.
+var operatorCatalog = []OperatorInfo{
+	{OpEq, "equals", ValueScalar, []string{"string", "number", "boolean"}, "Attribute equals the value (strings compare case-insensitively)"},
+	{OpNeq, "does not equal", ValueScalar, []string{"string", "number", "boolean"}, "Attribute is missing or differs from the value"},
+	{OpIn, "is one of", ValueList, []string{"string", "number"}, "Attribute equals one of the listed values"},
+	{OpContains, "contains", ValueString, []string{"string"}, "Attribute contains the value as a substring (case-insensitive)"},
+	{OpGt, "greater than", ValueNumber, []string{"number"}, "Attribute is a number greater than the value"},
+	{OpGte, "greater than or equal", ValueNumber, []string{"number"}, "Attribute is a number greater than or equal to the value"},
+	{OpLt, "less than", ValueNumber, []string{"number"}, "Attribute is a number less than the value"},
+	{OpLte, "less than or equal", ValueNumber, []string{"number"}, "Attribute is a number less than or equal to the value"},
+	{OpSemVerGt, "version greater than", ValueString, []string{"string"}, "Attribute is a semantic version newer than the value, e.g. 2.1.0"},
+	{OpSemVerLt, "version less than", ValueString, []string{"string"}, "Attribute is a semantic version older than the value"},
+}
+
+// Operators returns a description of every supported operator. The result
+// is a copy and may be modified.
+func Operators() []OperatorInfo {
+	out := make([]OperatorInfo, len(operatorCatalog))
+	copy(out, operatorCatalog)
+	return out
+}
@@ -0,0 +1,34 @@
+package rules
+
+import "testing"
+
+func TestOperators_MatchValidation(t *testing.T) {
+	samples := map[ValueType]any{
+		ValueString: "2.1.0",
+		ValueNumber: 42.0,
+		ValueList:   []any{"a", "b"},
+		ValueScalar: true,
+	}
+
+	seen := map[Operator]bool{}
+	for _, info := range Operators() {
+		if _, ok := validOperators[info.Operator]; !ok {
+			t.Errorf("operator %q is listed but not accepted by ValidateRule", info.Operator)
+		}
+		seen[info.Operator] = true
+
+		rule := Rule{
+			ID:           "r1",
+			Conditions:   []Condition{{Property: "plan", Operator: info.Operator, Value: samples[info.ValueType]}},
+			Distribution: map[string]int{"on": 100},
+		}
+		if err := ValidateRule(rule); err != nil {
+			t.Errorf("operator %q: a %s value should be valid: %v", info.Operator, info.ValueType, err)
+		}
+	}
+	for op := range validOperators {
+		if !seen[op] {
+			t.Errorf("operator %q is accepted by ValidateRule but not listed", op)
+		}
+	}
+}
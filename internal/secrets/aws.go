@@ -0,0 +1,152 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSProvider reads a secret from AWS Secrets Manager. The secret's
+// SecretString must be a JSON object of key/value pairs, which is how the
+// AWS console stores key/value secrets.
+//
+// Requests are signed with Signature Version 4 using static credentials (an
+// access key, optionally with a session token), so no AWS SDK is needed.
+type AWSProvider struct {
+	Region          string
+	SecretID        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Endpoint        string // defaults to https://secretsmanager.<region>.amazonaws.com
+	Client          *http.Client
+
+	now func() time.Time // for tests
+}
+
+// Name implements Provider.
+func (p *AWSProvider) Name() string { return "aws" }
+
+// Fetch implements Provider.
+func (p *AWSProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + p.Region + ".amazonaws.com"
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": p.SecretID})
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	signV4(req, body, p.AccessKeyID, p.SecretAccessKey, p.SessionToken, p.Region, "secretsmanager", now())
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("aws: get secret %s: status %d: %s", p.SecretID, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("aws: decode response: %w", err)
+	}
+	if out.SecretString == nil {
+		return nil, fmt.Errorf("aws: secret %s has no SecretString (binary secrets are not supported)", p.SecretID)
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(*out.SecretString), &data); err != nil {
+		return nil, fmt.Errorf("aws: secret %s is not a JSON object of key/value pairs", p.SecretID)
+	}
+	return stringValues(data), nil
+}
+
+// signV4 adds AWS Signature Version 4 headers to req. It signs the Host
+// header, Content-Type and every X-Amz-* header set on req.
+func signV4(req *http.Request, body []byte, accessKeyID, secretAccessKey, sessionToken, region, service string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,209 @@
+// Package secrets reads sensitive configuration from an external secrets
+// backend (HashiCorp Vault or AWS Secrets Manager) instead of plaintext
+// environment variables.
+//
+// A backend holds one secret: a set of key/value pairs named like the
+// environment variables they replace:
+//
+//	ADMIN_API_KEY            legacy superadmin key
+//	DB_DSN                   PostgreSQL connection string
+//	DB_REPLICA_DSN           PostgreSQL read replica connection string
+//	ROLLOUT_SALT             salt for deterministic user bucketing
+//	GITSYNC_WEBHOOK_SECRET   HMAC secret of the git sync push webhook
+//	ENCRYPTION_KEYS          key encryption keys for encryption at rest
+//	STEP_UP_TOTP_SECRET      TOTP secret for step-up authentication
+//	REPORT_SMTP_PASSWORD     SMTP password for summary report emails
+//	WEBHOOK_SECRET_<id>      signing secret of the outgoing webhook <id>
+//
+// Keys present in the secret take precedence over the environment. A Source
+// caches the values and can refresh them periodically (Run), so rotated
+// credentials are picked up without a restart where that is safe.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keys of the configuration values that may be read from a secrets backend.
+const (
+	AdminAPIKey          = "ADMIN_API_KEY"
+	DatabaseDSN          = "DB_DSN"
+	DatabaseReplicaDSN   = "DB_REPLICA_DSN"
+	RolloutSalt          = "ROLLOUT_SALT"
+	GitSyncWebhookSecret = "GITSYNC_WEBHOOK_SECRET"
+	EncryptionKeys       = "ENCRYPTION_KEYS"
+	StepUpTOTPSecret     = "STEP_UP_TOTP_SECRET"
+	ReportSMTPPassword   = "REPORT_SMTP_PASSWORD"
+
+	// WebhookSecretPrefix is followed by a webhook ID to name that webhook's
+	// signing secret.
+	WebhookSecretPrefix = "WEBHOOK_SECRET_"
+)
+
+// ConfigKeys are the keys config.Load reads from the backend.
+var ConfigKeys = []string{AdminAPIKey, DatabaseDSN, DatabaseReplicaDSN, RolloutSalt, GitSyncWebhookSecret, EncryptionKeys, StepUpTOTPSecret, ReportSMTPPassword}
+
+// Provider fetches the current key/value pairs from a secrets backend.
+type Provider interface {
+	// Name identifies the backend in logs ("vault", "aws").
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// Config selects and configures a Provider (see NewProvider).
+type Config struct {
+	Backend string // "vault" or "aws"
+
+	VaultAddr      string // e.g. https://vault.example.com:8200
+	VaultToken     string
+	VaultNamespace string // Vault Enterprise namespace (optional)
+	VaultPath      string // API path of the secret, e.g. secret/data/goflagship
+
+	AWSRegion          string
+	AWSSecretID        string // secret name or ARN
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string // optional, for temporary credentials
+	AWSEndpoint        string // optional override of the regional endpoint
+}
+
+// fetchTimeout bounds a single request to a backend.
+const fetchTimeout = 10 * time.Second
+
+// NewProvider returns the Provider for cfg.Backend.
+func NewProvider(cfg Config) (Provider, error) {
+	client := &http.Client{Timeout: fetchTimeout}
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "vault":
+		if cfg.VaultAddr == "" || cfg.VaultToken == "" || cfg.VaultPath == "" {
+			return nil, fmt.Errorf("secrets: vault backend requires VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH")
+		}
+		return &VaultProvider{
+			Addr:      cfg.VaultAddr,
+			Token:     cfg.VaultToken,
+			Namespace: cfg.VaultNamespace,
+			Path:      cfg.VaultPath,
+			Client:    client,
+		}, nil
+	case "aws":
+		if cfg.AWSRegion == "" || cfg.AWSSecretID == "" || cfg.AWSAccessKeyID == "" || cfg.AWSSecretAccessKey == "" {
+			return nil, fmt.Errorf("secrets: aws backend requires AWS_REGION, AWS_SECRET_ID, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return &AWSProvider{
+			Region:          cfg.AWSRegion,
+			SecretID:        cfg.AWSSecretID,
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+			Endpoint:        cfg.AWSEndpoint,
+			Client:          client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("secrets: unsupported backend %q (expected vault or aws)", cfg.Backend)
+	}
+}
+
+// Source caches the values of a Provider. It is safe for concurrent use.
+type Source struct {
+	provider Provider
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// NewSource returns an empty Source for p. Call Refresh to load it.
+func NewSource(p Provider) *Source {
+	return &Source{provider: p, values: map[string]string{}}
+}
+
+// Name is the name of the underlying provider.
+func (s *Source) Name() string {
+	return s.provider.Name()
+}
+
+// Get returns the value of key. Empty values are treated as missing.
+func (s *Source) Get(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[key]
+	return v, ok && v != ""
+}
+
+// WebhookSecret returns the signing secret stored for the webhook with the
+// given ID, if any.
+func (s *Source) WebhookSecret(webhookID string) (string, bool) {
+	return s.Get(WebhookSecretPrefix + webhookID)
+}
+
+// Refresh fetches the values from the provider and reports whether they
+// changed. On error the previous values are kept.
+func (s *Source) Refresh(ctx context.Context) (bool, error) {
+	values, err := s.provider.Fetch(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := !maps.Equal(s.values, values)
+	s.values = values
+	return changed, nil
+}
+
+// Run refreshes the source every interval until ctx is cancelled, calling
+// onChange after each refresh that changed a value. Failed refreshes are
+// logged and retried on the next tick. An interval <= 0 disables refreshing.
+func (s *Source) Run(ctx context.Context, interval time.Duration, onChange func()) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := s.Refresh(ctx)
+			if err != nil {
+				log.Printf("[secrets] refresh from %s failed, keeping previous values: %v", s.Name(), err)
+				continue
+			}
+			if changed {
+				log.Printf("[secrets] values from %s changed", s.Name())
+				if onChange != nil {
+					onChange()
+				}
+			}
+		}
+	}
+}
+
+// stringValues converts a decoded JSON object to string values. Non-string
+// values are kept in their JSON form.
+func stringValues(data map[string]any) map[string]string {
+	values := make(map[string]string, len(data))
+	for k, v := range data {
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case nil:
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			values[k] = string(b)
+		}
+	}
+	return values
+}
@@ -0,0 +1,191 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVaultProvider_KVv2(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/goflagship" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Vault-Token") != "tok" || r.Header.Get("X-Vault-Namespace") != "team" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		_, _ = io.WriteString(w, `{"data":{"data":{"ADMIN_API_KEY":"k1","LIMIT":5},"metadata":{"version":3}}}`)
+	}))
+	defer srv.Close()
+
+	p := &VaultProvider{Addr: srv.URL + "/", Token: "tok", Namespace: "team", Path: "/secret/data/goflagship", Client: srv.Client()}
+	values, err := p.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if values["ADMIN_API_KEY"] != "k1" || values["LIMIT"] != "5" {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
+
+func TestVaultProvider_KVv1AndErrors(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"data":{"DB_DSN":"postgres://x"}}`)
+	}))
+	defer srv.Close()
+
+	p := &VaultProvider{Addr: srv.URL, Token: "tok", Path: "secret/goflagship", Client: srv.Client()}
+	values, err := p.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if values["DB_DSN"] != "postgres://x" {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	status = http.StatusForbidden
+	if _, err := p.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
+
+func TestAWSProvider_Fetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			t.Errorf("unexpected request: %s %v", r.Method, r.Header)
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20261015/eu-west-1/secretsmanager/aws4_request, ") ||
+			!strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target,") {
+			t.Errorf("unexpected Authorization header: %s", auth)
+		}
+		if r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("missing session token")
+		}
+		var in map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if in["SecretId"] != "flagship/prod" {
+			t.Errorf("unexpected body: %v", in)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"ROLLOUT_SALT":"s1","WEBHOOK_SECRET_abc":"w1"}`})
+	}))
+	defer srv.Close()
+
+	p := &AWSProvider{
+		Region: "eu-west-1", SecretID: "flagship/prod",
+		AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session",
+		Endpoint: srv.URL, Client: srv.Client(),
+		now: func() time.Time { return time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC) },
+	}
+	values, err := p.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if values["ROLLOUT_SALT"] != "s1" || values["WEBHOOK_SECRET_abc"] != "w1" {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
+
+// TestSignV4 checks the signer against the "get-vanilla" case of the AWS
+// Signature Version 4 test suite.
+func TestSignV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	signV4(req, nil, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "", "us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n  %s\nwant\n  %s", got, want)
+	}
+}
+
+type fakeProvider struct {
+	values map[string]string
+	err    error
+	calls  atomic.Int32
+}
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+func (f *fakeProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	f.calls.Add(1)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.values, nil
+}
+
+func TestSource_RefreshKeepsValuesOnError(t *testing.T) {
+	p := &fakeProvider{values: map[string]string{AdminAPIKey: "k1", WebhookSecretPrefix + "id1": "w1", DatabaseDSN: ""}}
+	src := NewSource(p)
+
+	changed, err := src.Refresh(context.Background())
+	if err != nil || !changed {
+		t.Fatalf("first refresh: changed=%v err=%v", changed, err)
+	}
+	if v, ok := src.Get(AdminAPIKey); !ok || v != "k1" {
+		t.Errorf("Get(ADMIN_API_KEY) = %q, %v", v, ok)
+	}
+	if v, ok := src.WebhookSecret("id1"); !ok || v != "w1" {
+		t.Errorf("WebhookSecret = %q, %v", v, ok)
+	}
+	if _, ok := src.Get(DatabaseDSN); ok {
+		t.Errorf("empty values should be treated as missing")
+	}
+
+	if changed, _ := src.Refresh(context.Background()); changed {
+		t.Errorf("refresh with identical values reported a change")
+	}
+
+	p.err = errors.New("unavailable")
+	if _, err := src.Refresh(context.Background()); err == nil {
+		t.Fatal("expected refresh error")
+	}
+	if v, _ := src.Get(AdminAPIKey); v != "k1" {
+		t.Errorf("values were dropped after a failed refresh: %q", v)
+	}
+}
+
+func TestSource_RunCallsOnChange(t *testing.T) {
+	p := &fakeProvider{values: map[string]string{AdminAPIKey: "k2"}}
+	src := NewSource(p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 1)
+	go src.Run(ctx, 5*time.Millisecond, func() { changed <- struct{}{} })
+	defer cancel()
+
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("onChange was not called")
+	}
+	if v, _ := src.Get(AdminAPIKey); v != "k2" {
+		t.Errorf("Get = %q, want k2", v)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if _, err := NewProvider(Config{Backend: "vault", VaultAddr: "http://v", VaultToken: "t", VaultPath: "secret/data/x"}); err != nil {
+		t.Errorf("vault: %v", err)
+	}
+	if _, err := NewProvider(Config{Backend: "vault", VaultAddr: "http://v"}); err == nil {
+		t.Error("vault without token: expected error")
+	}
+	if _, err := NewProvider(Config{Backend: "AWS", AWSRegion: "us-east-1", AWSSecretID: "s", AWSAccessKeyID: "a", AWSSecretAccessKey: "b"}); err != nil {
+		t.Errorf("aws: %v", err)
+	}
+	if _, err := NewProvider(Config{Backend: "gcp"}); err == nil {
+		t.Error("unknown backend: expected error")
+	}
+}
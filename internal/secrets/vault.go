@@ -0,0 +1,68 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// VaultProvider reads a secret from HashiCorp Vault over its HTTP API. Both
+// KV version 2 (Path like "secret/data/goflagship") and version 1 (Path like
+// "secret/goflagship") mounts are supported.
+type VaultProvider struct {
+	Addr      string
+	Token     string
+	Namespace string
+	Path      string
+	Client    *http.Client
+}
+
+// Name implements Provider.
+func (p *VaultProvider) Name() string { return "vault" }
+
+// Fetch implements Provider.
+func (p *VaultProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	url := strings.TrimRight(p.Addr, "/") + "/v1/" + strings.TrimLeft(p.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+	if p.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.Namespace)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("vault: read %s: status %d: %s", p.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("vault: decode response: %w", err)
+	}
+
+	// KV v2 nests the values in data.data next to data.metadata.
+	var v2 struct {
+		Data     map[string]any `json:"data"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal(envelope.Data, &v2); err == nil && v2.Metadata != nil && v2.Data != nil {
+		return stringValues(v2.Data), nil
+	}
+	var v1 map[string]any
+	if err := json.Unmarshal(envelope.Data, &v1); err != nil || v1 == nil {
+		return nil, fmt.Errorf("vault: secret %s has no data", p.Path)
+	}
+	return stringValues(v1), nil
+}
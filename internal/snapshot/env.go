@@ -0,0 +1,29 @@
+package snapshot
+
+import (
+	"log"
+	"sync"
+)
+
+// envSnapshots holds snapshots for environments other than the server's
+// default one, keyed by env name. They are built on demand when a request
+// presents an SDK key for that environment.
+var envSnapshots sync.Map // env -> *Snapshot
+
+// LoadEnv returns the snapshot stored for env by UpdateEnv, if any.
+// The default environment's snapshot is available via Load instead.
+func LoadEnv(env string) (*Snapshot, bool) {
+	v, ok := envSnapshots.Load(env)
+	if !ok {
+		return nil, false
+	}
+	return v.(*Snapshot), true
+}
+
+// UpdateEnv replaces the snapshot for env and notifies listeners registered
+// with SubscribeEnv(env).
+func UpdateEnv(env string, newSnapshot *Snapshot) {
+	envSnapshots.Store(env, newSnapshot)
+	log.Printf("[snapshot] updated env=%s: flags=%d etag=%s", env, len(newSnapshot.Flags), newSnapshot.ETag)
+	publish(env, newSnapshot.ETag)
+}
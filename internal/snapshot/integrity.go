@@ -0,0 +1,110 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/TimurManjosov/goflagship/internal/validation"
+)
+
+// Integrity checks run on every snapshot build. Writes are validated by the
+// API, but rows edited directly in the database, imported by gitsync or
+// written by an older server can still be inconsistent. Rather than serve
+// such a flag (and let evaluation misbehave), the build excludes it, logs a
+// warning and counts it in snapshot_excluded_flags_total{reason}. The rest of
+// the snapshot is still swapped in, so one bad flag cannot block every update.
+//
+// Checks, with their metric reason:
+//   - rollout: rollout outside 0-100
+//   - variants: variant names empty or duplicated, or weights not summing to 100
+//   - rules: a targeting rule fails rules.ValidateRule, or two rules share an ID;
+//     an empty distribution is tolerated, since the engine then serves the
+//     default variant
+//   - key_collision: keys that differ only in case; the first key in byte
+//     order is kept, the others are excluded
+//
+// Flags have no prerequisites, so there are no dependency cycles to check.
+
+const (
+	excludeReasonRollout      = "rollout"
+	excludeReasonVariants     = "variants"
+	excludeReasonRules        = "rules"
+	excludeReasonKeyCollision = "key_collision"
+)
+
+// excludeInvalidFlags removes flags that fail the integrity checks from
+// flagMap and returns the excluded keys with their reason.
+func excludeInvalidFlags(flagMap map[string]FlagView) map[string]string {
+	excluded := make(map[string]string)
+	for key, view := range flagMap {
+		if reason, err := checkFlagView(view); err != nil {
+			excluded[key] = reason
+			log.Printf("[snapshot] WARNING: excluding flag key=%s env=%s: %v", key, view.Env, err)
+		}
+	}
+
+	keys := make([]string, 0, len(flagMap))
+	for key := range flagMap {
+		if _, bad := excluded[key]; !bad {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	kept := make(map[string]string, len(keys)) // lower-cased key -> kept key
+	for _, key := range keys {
+		folded := strings.ToLower(key)
+		if first, ok := kept[folded]; ok {
+			excluded[key] = excludeReasonKeyCollision
+			log.Printf("[snapshot] WARNING: excluding flag key=%s env=%s: key differs only in case from %s", key, flagMap[key].Env, first)
+			continue
+		}
+		kept[folded] = key
+	}
+
+	for key, reason := range excluded {
+		delete(flagMap, key)
+		telemetry.SnapshotExcludedFlags.WithLabelValues(reason).Inc()
+	}
+	return excluded
+}
+
+// checkFlagView validates a single flag and returns the metric reason and
+// error for the first check it fails.
+func checkFlagView(view FlagView) (string, error) {
+	if view.Rollout < 0 || view.Rollout > 100 {
+		return excludeReasonRollout, fmt.Errorf("rollout %d is outside 0-100", view.Rollout)
+	}
+
+	if len(view.Variants) > 0 {
+		params := make([]validation.VariantValidationParams, len(view.Variants))
+		for i, v := range view.Variants {
+			params[i] = validation.VariantValidationParams{Name: v.Name, Weight: v.Weight}
+		}
+		if result := validation.ValidateVariants(params); !result.Valid {
+			return excludeReasonVariants, fmt.Errorf("invalid variants: %s", result.Errors["variants"])
+		}
+	}
+
+	seenIDs := make(map[string]bool, len(view.TargetingRules))
+	for _, rule := range view.TargetingRules {
+		if err := rules.ValidateRule(rule); err != nil && !isEmptyDistribution(rule, err) {
+			return excludeReasonRules, fmt.Errorf("invalid targeting rule %q: %w", rule.ID, err)
+		}
+		if seenIDs[rule.ID] {
+			return excludeReasonRules, fmt.Errorf("duplicate targeting rule id %q", rule.ID)
+		}
+		seenIDs[rule.ID] = true
+	}
+	return "", nil
+}
+
+// isEmptyDistribution reports whether err only complains that rule has no
+// distribution.
+func isEmptyDistribution(rule rules.Rule, err error) bool {
+	return len(rule.Distribution) == 0 && errors.Is(err, rules.ErrInvalidDistribution)
+}
@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestBuildFromFlags_ExcludesInvalidFlags(t *testing.T) {
+	validRule := rules.Rule{
+		ID:           "beta",
+		Conditions:   []rules.Condition{{Property: "plan", Operator: rules.OpEq, Value: "pro"}},
+		Distribution: map[string]int{"on": 100},
+	}
+	flags := []store.Flag{
+		{Key: "good", Enabled: true, Rollout: 50, Env: "prod",
+			Variants:       []store.Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50}},
+			TargetingRules: []rules.Rule{validRule}},
+		{Key: "bad_rollout", Enabled: true, Rollout: 150, Env: "prod"},
+		{Key: "bad_weights", Enabled: true, Rollout: 100, Env: "prod",
+			Variants: []store.Variant{{Name: "a", Weight: 50}, {Name: "b", Weight: 40}}},
+		{Key: "dup_variants", Enabled: true, Rollout: 100, Env: "prod",
+			Variants: []store.Variant{{Name: "a", Weight: 50}, {Name: "a", Weight: 50}}},
+		{Key: "bad_rule", Enabled: true, Rollout: 100, Env: "prod",
+			TargetingRules: []rules.Rule{{ID: "r1", Distribution: map[string]int{"on": 100}}}},
+		{Key: "dup_rule_ids", Enabled: true, Rollout: 100, Env: "prod",
+			TargetingRules: []rules.Rule{validRule, validRule}},
+	}
+
+	snap := BuildFromFlags(flags)
+
+	if _, ok := snap.Flags["good"]; !ok {
+		t.Error("valid flag missing from the snapshot")
+	}
+	for _, key := range []string{"bad_rollout", "bad_weights", "dup_variants", "bad_rule", "dup_rule_ids"} {
+		if _, ok := snap.Flags[key]; ok {
+			t.Errorf("%s should be excluded from the snapshot", key)
+		}
+	}
+}
+
+func TestBuildFromFlags_ExcludesCaseCollisions(t *testing.T) {
+	flags := []store.Flag{
+		{Key: "new_checkout", Enabled: true, Rollout: 100, Env: "prod"},
+		{Key: "New_Checkout", Enabled: false, Env: "prod"},
+		{Key: "other", Enabled: true, Rollout: 100, Env: "prod"},
+	}
+
+	snap := BuildFromFlags(flags)
+
+	if len(snap.Flags) != 2 {
+		t.Fatalf("expected 2 flags, got %d", len(snap.Flags))
+	}
+	if _, ok := snap.Flags["New_Checkout"]; !ok {
+		t.Error("first key in byte order should be kept")
+	}
+	if _, ok := snap.Flags["new_checkout"]; ok {
+		t.Error("colliding key should be excluded")
+	}
+}
+
+func TestBuildFromFlags_ExclusionKeepsETagStable(t *testing.T) {
+	valid := []store.Flag{{Key: "good", Enabled: true, Rollout: 100, Env: "prod"}}
+	withInvalid := append(valid, store.Flag{Key: "bad", Rollout: -1, Env: "prod"})
+
+	if BuildFromFlags(valid).ETag != BuildFromFlags(withInvalid).ETag {
+		t.Error("an excluded flag should not affect the ETag")
+	}
+}
@@ -10,14 +10,24 @@ type subCh = chan string // carries new ETags
 
 var (
 	mu   sync.Mutex
-	subs = make(map[subCh]struct{})
+	subs = make(map[subCh]string) // channel -> env ("" for the default snapshot)
 )
 
-// Subscribe registers a listener and returns its channel and an unsubscribe func.
+// Subscribe registers a listener for the default snapshot (see Update) and
+// returns its channel and an unsubscribe func.
 func Subscribe() (subCh, func()) {
+	return subscribe("")
+}
+
+// SubscribeEnv registers a listener for the snapshot of env (see UpdateEnv).
+func SubscribeEnv(env string) (subCh, func()) {
+	return subscribe(env)
+}
+
+func subscribe(env string) (subCh, func()) {
 	ch := make(subCh, 1)
 	mu.Lock()
-	subs[ch] = struct{}{}
+	subs[ch] = env
 	telemetry.SSEClients.Inc() // +1
 	mu.Unlock()
 
@@ -31,10 +41,18 @@ func Subscribe() (subCh, func()) {
 	return ch, unsub
 }
 
-// publishUpdate notifies all listeners (non-blocking).
+// publishUpdate notifies all listeners of the default snapshot (non-blocking).
 func publishUpdate(etag string) {
+	publish("", etag)
+}
+
+// publish notifies the listeners of env (non-blocking).
+func publish(env, etag string) {
 	mu.Lock()
-	for ch := range subs {
+	for ch, subEnv := range subs {
+		if subEnv != env {
+			continue
+		}
 		select {
 		case ch <- etag:
 		default: // if client is slow, skip instead of blocking
@@ -177,3 +177,29 @@ func TestUnsubscribeIsIdempotent(t *testing.T) {
 	// The current implementation doesn't guard against multiple unsubs
 	// This test just verifies that one unsubscribe works correctly
 }
+
+func TestPublishIsScopedToEnv(t *testing.T) {
+	defaultUpdates, unsubDefault := Subscribe()
+	defer unsubDefault()
+	stagingUpdates, unsubStaging := SubscribeEnv("staging")
+	defer unsubStaging()
+
+	UpdateEnv("staging", BuildFromFlags(nil))
+
+	select {
+	case <-stagingUpdates:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("staging subscriber was not notified")
+	}
+	select {
+	case etag := <-defaultUpdates:
+		t.Errorf("default subscriber must not receive staging updates, got %s", etag)
+	default:
+	}
+	if snap, ok := LoadEnv("staging"); !ok || snap.Flags == nil {
+		t.Error("LoadEnv should return the stored snapshot")
+	}
+	if _, ok := LoadEnv("unknown"); ok {
+		t.Error("LoadEnv should report missing environments")
+	}
+}
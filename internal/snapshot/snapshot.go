@@ -31,6 +31,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"log"
+	"maps"
+	"slices"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -60,13 +62,22 @@ type FlagView struct {
 	Config      map[string]any `json:"config,omitempty"`
 	TargetingRules []rules.Rule `json:"targetingRules,omitempty"`
 	Variants    []Variant      `json:"variants,omitempty"` // For A/B testing
+	Tags        []string       `json:"tags,omitempty"`
+	Project     string         `json:"project,omitempty"`
 	Env         string         `json:"env"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 }
 
+// SchemaVersion is the version of the Snapshot payload format. It is bumped
+// whenever the format changes in a way old SDKs cannot read, so the server can
+// keep rendering older versions for clients that ask for them (see
+// api/snapshotschema.go).
+const SchemaVersion = 1
+
 // Snapshot represents an immutable point-in-time view of all feature flags.
 // It includes an ETag for cache validation and optional rollout salt for client-side evaluation.
 type Snapshot struct {
+	SchemaVersion int               `json:"schemaVersion"`          // Payload format, see SchemaVersion
 	ETag        string              `json:"etag"`                   // SHA-256 hash of flags for cache validation
 	Flags       map[string]FlagView `json:"flags"`                  // Map of flag key to flag data
 	UpdatedAt   time.Time           `json:"updatedAt"`              // Timestamp of snapshot creation
@@ -151,6 +162,7 @@ func Load() *Snapshot {
 			Flags:       map[string]FlagView{},
 			UpdatedAt:   time.Now().UTC(),
 			RolloutSalt: rolloutSalt,
+			SchemaVersion: SchemaVersion,
 		}
 	}
 	return (*Snapshot)(pointer)
@@ -186,6 +198,8 @@ func storeSnapshot(snapshot *Snapshot) {
 //   - rows is nil: Returns snapshot with empty flags map
 //   - rows is empty: Returns snapshot with empty flags map
 //   - rows contains duplicate keys: Last row wins (map overwrite)
+//   - row is archived: Skipped, archived flags are never served
+//   - row fails the integrity checks: Excluded with a warning (see integrity.go)
 //   - row.Config is invalid JSON: Config is set to nil, no error returned (see below)
 //   - row.Description is null: Converted to empty string
 //   - row.Expression is null: Set to nil (no expression)
@@ -204,6 +218,9 @@ func storeSnapshot(snapshot *Snapshot) {
 func BuildFromRows(rows []dbgen.Flag) *Snapshot {
 	flagsMap := make(map[string]FlagView, len(rows))
 	for _, row := range rows {
+		if row.Archived {
+			continue
+		}
 		var config map[string]any
 		if len(row.Config) > 0 {
 			_ = json.Unmarshal(row.Config, &config) // Ignore unmarshal errors, config stays nil
@@ -223,17 +240,21 @@ func BuildFromRows(rows []dbgen.Flag) *Snapshot {
 			Expression:  row.Expression, // Already *string from database
 			Config:      config,
 			TargetingRules: targetingRules,
+			Tags:        row.Tags,
+			Project:     row.Project,
 			Env:         row.Env,
-			UpdatedAt:   row.UpdatedAt.Time,
+			UpdatedAt:   canonicalTime(row.UpdatedAt.Time),
 		}
 	}
 	
+	excludeInvalidFlags(flagsMap)
 	etag := computeETag(flagsMap)
 	return &Snapshot{
 		ETag:        etag,
 		Flags:       flagsMap,
 		UpdatedAt:   time.Now().UTC(),
 		RolloutSalt: rolloutSalt,
+		SchemaVersion: SchemaVersion,
 	}
 }
 
@@ -255,6 +276,8 @@ func BuildFromRows(rows []dbgen.Flag) *Snapshot {
 //   - flags is nil: Returns snapshot with empty flags map
 //   - flags is empty: Returns snapshot with empty flags map
 //   - flags contains duplicate keys: Last flag wins (map overwrite)
+//   - flag is archived: Skipped, archived flags are never served
+//   - flag fails the integrity checks: Excluded with a warning (see integrity.go)
 //   - flag has no variants: Variants field is nil (not empty slice)
 //   - flag has empty variants slice: Converted to empty slice
 //
@@ -263,12 +286,19 @@ func BuildFromRows(rows []dbgen.Flag) *Snapshot {
 //   - Preserves all variant fields (Name, Weight, Config)
 //   - Empty variant config remains nil
 //
+// Timestamps:
+//   - UpdatedAt is normalized with canonicalTime, so replicas serve the same
+//     flags and ETag whatever their time zone
+//
 // Usage:
 //   This is the primary method for creating snapshots from the store layer.
 //   Typically called after store.GetAllFlags() returns results.
 func BuildFromFlags(flags []store.Flag) *Snapshot {
 	flagMap := make(map[string]FlagView, len(flags))
 	for _, flag := range flags {
+		if flag.Archived {
+			continue
+		}
 		// Convert store.Variant to snapshot.Variant
 		var variants []Variant
 		if len(flag.Variants) > 0 {
@@ -291,18 +321,52 @@ func BuildFromFlags(flags []store.Flag) *Snapshot {
 			Config:      flag.Config,
 			TargetingRules: flag.TargetingRules,
 			Variants:    variants,
+			Tags:        flag.Tags,
+			Project:     flag.Project,
 			Env:         flag.Env,
-			UpdatedAt:   flag.UpdatedAt,
+			UpdatedAt:   canonicalTime(flag.UpdatedAt),
 		}
 	}
 	
+	excludeInvalidFlags(flagMap)
 	etag := computeETag(flagMap)
 	return &Snapshot{
 		ETag:        etag,
 		Flags:       flagMap,
 		UpdatedAt:   time.Now().UTC(),
 		RolloutSalt: rolloutSalt,
+		SchemaVersion: SchemaVersion,
+	}
+}
+
+// FilterByTag returns a snapshot of the flags of s that carry tag, with its
+// own ETag. s is not modified.
+func (s *Snapshot) FilterByTag(tag string) *Snapshot {
+	flags := make(map[string]FlagView)
+	for key, flag := range s.Flags {
+		if slices.Contains(flag.Tags, tag) {
+			flags[key] = flag
+		}
 	}
+	filtered := *s
+	filtered.Flags = flags
+	filtered.ETag = computeETag(flags)
+	return &filtered
+}
+
+// FilterByProject returns a snapshot of the flags of s in project, with its
+// own ETag. s is not modified.
+func (s *Snapshot) FilterByProject(project string) *Snapshot {
+	flags := make(map[string]FlagView)
+	for key, flag := range s.Flags {
+		if flag.Project == project {
+			flags[key] = flag
+		}
+	}
+	filtered := *s
+	filtered.Flags = flags
+	filtered.ETag = computeETag(flags)
+	return &filtered
 }
 
 // computeETag generates a weak ETag from the flag map using SHA-256.
@@ -312,15 +376,16 @@ func BuildFromFlags(flags []store.Flag) *Snapshot {
 //
 // Postconditions:
 //   - Always returns non-empty string in format: W/"<hex-hash>"
-//   - Same flag content produces same ETag (deterministic)
+//   - Same flag content produces same ETag (deterministic), on every replica
 //   - Different flag content produces different ETag (high probability)
 //   - Format follows HTTP weak ETag convention (W/ prefix)
 //
 // Algorithm:
-//   1. Serialize flagMap to JSON (deterministic for Go maps in JSON)
-//   2. Compute SHA-256 hash of serialized JSON
-//   3. Encode hash as hex string
-//   4. Wrap in weak ETag format: W/"<hex>"
+//   1. Sort flags by key and normalize UpdatedAt with canonicalTime
+//   2. Serialize the sorted flags to JSON (object keys of configs are sorted)
+//   3. Compute SHA-256 hash of serialized JSON
+//   4. Encode hash as hex string
+//   5. Wrap in weak ETag format: W/"<hex>"
 //
 // Edge Cases:
 //   - flagMap is nil: Produces ETag for empty map
@@ -330,16 +395,33 @@ func BuildFromFlags(flags []store.Flag) *Snapshot {
 // ETag Format:
 //   The ETag changes whenever flag content changes, enabling efficient cache validation.
 //   Weak ETag (W/) indicates semantic equivalence rather than byte-for-byte identity.
+//   Replicas behind a load balancer must agree on it, so it depends only on the
+//   flags, never on map iteration order, time zone or clock precision.
 //
 // Performance:
 //   Uses SHA-256 for collision resistance.
 //   JSON marshaling is deterministic but may be slow for large flag sets.
 func computeETag(flagMap map[string]FlagView) string {
-	serialized, _ := json.Marshal(flagMap)
+	keys := slices.Sorted(maps.Keys(flagMap))
+	flags := make([]FlagView, 0, len(keys))
+	for _, key := range keys {
+		flag := flagMap[key]
+		flag.UpdatedAt = canonicalTime(flag.UpdatedAt)
+		flags = append(flags, flag)
+	}
+	serialized, _ := json.Marshal(flags)
 	hash := sha256.Sum256(serialized)
 	return `W/"` + hex.EncodeToString(hash[:]) + `"`
 }
 
+// canonicalTime normalizes a flag timestamp: UTC, truncated to the microsecond
+// precision PostgreSQL stores and without a monotonic clock reading. pgx
+// returns timestamps in the local time zone, so without this two replicas
+// reading the same row in different zones would compute different ETags.
+func canonicalTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
 // Update atomically replaces the current snapshot and notifies SSE listeners.
 //
 // Thread-safety: This function is thread-safe and can be called from any goroutine.
@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
 	"github.com/TimurManjosov/goflagship/internal/store"
 )
 
@@ -24,6 +27,22 @@ func TestBuildFromFlags_Empty(t *testing.T) {
 	}
 }
 
+func TestBuildFromFlags_SkipsArchived(t *testing.T) {
+	flags := []store.Flag{
+		{Key: "live", Enabled: true, Rollout: 100, Env: "prod"},
+		{Key: "old", Enabled: true, Rollout: 100, Archived: true, Env: "prod"},
+	}
+
+	snap := BuildFromFlags(flags)
+
+	if _, ok := snap.Flags["old"]; ok {
+		t.Error("archived flag should not be in the snapshot")
+	}
+	if _, ok := snap.Flags["live"]; !ok {
+		t.Error("live flag missing from the snapshot")
+	}
+}
+
 func TestBuildFromFlags_MultipleFlags(t *testing.T) {
 	now := time.Now().UTC()
 	flags := []store.Flag{
@@ -97,6 +116,57 @@ func TestBuildFromFlags_ETags_Different(t *testing.T) {
 	}
 }
 
+// TestBuildFromFlags_ETags_CrossInstance builds the same flags as two replicas
+// would see them: loaded in a different order, with timestamps in another time
+// zone and with the nanosecond and monotonic parts PostgreSQL does not keep.
+func TestBuildFromFlags_ETags_CrossInstance(t *testing.T) {
+	updatedAt := time.Now()
+	flags := []store.Flag{
+		{Key: "alpha", Enabled: true, Rollout: 50, Env: "prod", UpdatedAt: updatedAt,
+			Config: map[string]any{"b": 2, "a": 1}},
+		{Key: "beta", Enabled: false, Rollout: 100, Env: "prod", UpdatedAt: updatedAt.Add(time.Second),
+			Variants: []store.Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}}},
+	}
+
+	berlin := time.FixedZone("Berlin", 2*60*60)
+	replica := []store.Flag{flags[1], flags[0]}
+	for i := range replica {
+		replica[i].UpdatedAt = replica[i].UpdatedAt.Truncate(time.Microsecond).In(berlin)
+	}
+
+	snap1 := BuildFromFlags(flags)
+	snap2 := BuildFromFlags(replica)
+	if snap1.ETag != snap2.ETag {
+		t.Errorf("Expected equal ETags across replicas, got %s and %s", snap1.ETag, snap2.ETag)
+	}
+	if !snap1.Flags["alpha"].UpdatedAt.Equal(snap2.Flags["alpha"].UpdatedAt) ||
+		snap2.Flags["alpha"].UpdatedAt.Location() != time.UTC {
+		t.Errorf("Expected UpdatedAt normalized to UTC, got %v and %v",
+			snap1.Flags["alpha"].UpdatedAt, snap2.Flags["alpha"].UpdatedAt)
+	}
+}
+
+func TestBuildFromRows_ETagMatchesBuildFromFlags(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 15, 12, 0, 0, 123456789, time.UTC)
+	flags := []store.Flag{
+		{Key: "alpha", Description: "Alpha", Enabled: true, Rollout: 25, Env: "prod",
+			Config: map[string]any{"color": "blue"}, UpdatedAt: updatedAt},
+	}
+	rows := []dbgen.Flag{{
+		Key:         "alpha",
+		Description: pgtype.Text{String: "Alpha", Valid: true},
+		Enabled:     true,
+		Rollout:     25,
+		Config:      []byte(`{"color": "blue"}`),
+		Env:         "prod",
+		UpdatedAt:   pgtype.Timestamptz{Time: updatedAt.Truncate(time.Microsecond).In(time.FixedZone("EST", -5*60*60)), Valid: true},
+	}}
+
+	if BuildFromFlags(flags).ETag != BuildFromRows(rows).ETag {
+		t.Error("Expected a snapshot built from rows to have the ETag of one built from flags")
+	}
+}
+
 func TestBuildFromFlags_ConfigJSON(t *testing.T) {
 	config := map[string]any{
 		"text":  "Hello World",
@@ -313,3 +383,25 @@ func TestSnapshotMarshaling(t *testing.T) {
 		t.Errorf("Flags count mismatch: %d != %d", len(unmarshaled.Flags), len(snap.Flags))
 	}
 }
+
+func TestFilterByTag(t *testing.T) {
+	snap := BuildFromFlags([]store.Flag{
+		{Key: "checkout", Enabled: true, Rollout: 100, Env: "prod", Tags: []string{"payments", "team-web"}},
+		{Key: "banner", Enabled: true, Rollout: 100, Env: "prod", Tags: []string{"marketing"}},
+		{Key: "search", Enabled: true, Rollout: 100, Env: "prod"},
+	})
+
+	filtered := snap.FilterByTag("payments")
+	if len(filtered.Flags) != 1 || filtered.Flags["checkout"].Tags[0] != "payments" {
+		t.Fatalf("expected only checkout with its tags, got %+v", filtered.Flags)
+	}
+	if filtered.ETag == snap.ETag || filtered.ETag != snap.FilterByTag("payments").ETag {
+		t.Errorf("expected a deterministic ETag of its own, got %s (full %s)", filtered.ETag, snap.ETag)
+	}
+	if len(snap.Flags) != 3 {
+		t.Errorf("expected the original snapshot to be unchanged, got %d flags", len(snap.Flags))
+	}
+	if got := snap.FilterByTag("unknown"); len(got.Flags) != 0 {
+		t.Errorf("expected no flags for an unknown tag, got %+v", got.Flags)
+	}
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrContextAttributeNotFound is returned when deleting an attribute that is
+// not declared.
+var ErrContextAttributeNotFound = errors.New("context attribute not found")
+
+// Context attribute types, named after the JSON type of the value.
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeList    = "list"
+	AttributeTypeObject  = "object"
+)
+
+// ContextAttribute declares an attribute that evaluation contexts may carry
+// and targeting rules may refer to, e.g. "plan" (string, "pro").
+type ContextAttribute struct {
+	Project     string    `json:"project"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"` // one of the AttributeType constants
+	Description string    `json:"description"`
+	Example     any       `json:"example,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	UpdatedBy   string    `json:"updated_by"`
+}
+
+// ContextAttributeStore is implemented by stores that can persist the context
+// attribute registry. Both MemoryStore and PostgresStore implement it.
+type ContextAttributeStore interface {
+	// ListContextAttributes returns the attributes declared in project,
+	// sorted by name.
+	ListContextAttributes(ctx context.Context, project string) ([]ContextAttribute, error)
+
+	// PutContextAttribute declares attr, replacing an existing declaration
+	// with the same project and name. UpdatedAt is set by the store.
+	PutContextAttribute(ctx context.Context, attr ContextAttribute) (ContextAttribute, error)
+
+	// DeleteContextAttribute returns ErrContextAttributeNotFound if the
+	// attribute is not declared.
+	DeleteContextAttribute(ctx context.Context, project, name string) error
+}
+
+// ListContextAttributes returns the attributes declared in project, sorted by name.
+func (m *MemoryStore) ListContextAttributes(ctx context.Context, project string) ([]ContextAttribute, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	attrs := make([]ContextAttribute, 0, len(m.contextAttributes[project]))
+	for _, attr := range m.contextAttributes[project] {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+	return attrs, nil
+}
+
+// PutContextAttribute creates or replaces an attribute declaration.
+func (m *MemoryStore) PutContextAttribute(ctx context.Context, attr ContextAttribute) (ContextAttribute, error) {
+	attr.UpdatedAt = time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.contextAttributes == nil {
+		m.contextAttributes = make(map[string]map[string]ContextAttribute)
+	}
+	if m.contextAttributes[attr.Project] == nil {
+		m.contextAttributes[attr.Project] = make(map[string]ContextAttribute)
+	}
+	m.contextAttributes[attr.Project][attr.Name] = attr
+	return attr, nil
+}
+
+// DeleteContextAttribute removes an attribute declaration.
+func (m *MemoryStore) DeleteContextAttribute(ctx context.Context, project, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.contextAttributes[project][name]; !ok {
+		return ErrContextAttributeNotFound
+	}
+	delete(m.contextAttributes[project], name)
+	return nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	mydb "github.com/TimurManjosov/goflagship/internal/db"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+// FlagRef identifies a flag; keys are only unique within an environment.
+type FlagRef struct {
+	Key string `json:"key"`
+	Env string `json:"env"`
+}
+
+// FlagBatch is a set of flag writes applied together by ApplyFlagBatch.
+type FlagBatch struct {
+	Upserts []UpsertParams
+	Deletes []FlagRef
+}
+
+// BatchFlagStore is implemented by stores that can apply several flag writes
+// atomically. Both MemoryStore and PostgresStore implement it.
+type BatchFlagStore interface {
+	// ApplyFlagBatch applies every upsert and delete in batch, or none of them.
+	// Deleting a flag that does not exist is not an error.
+	ApplyFlagBatch(ctx context.Context, batch FlagBatch) error
+}
+
+// ApplyFlagBatch applies batch under a single lock, so readers never observe
+// a partially applied batch. Expected versions are checked before anything is
+// written.
+func (m *MemoryStore) ApplyFlagBatch(ctx context.Context, batch FlagBatch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, params := range batch.Upserts {
+		if err := m.checkVersion(params); err != nil {
+			return err
+		}
+	}
+	for _, params := range batch.Upserts {
+		m.upsertFlag(params, now)
+	}
+	for _, ref := range batch.Deletes {
+		m.trashFlag(flagID(ref.Key, ref.Env), now)
+	}
+	return nil
+}
+
+// ApplyFlagBatch applies batch in one transaction.
+func (p *PostgresStore) ApplyFlagBatch(ctx context.Context, batch FlagBatch) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after Commit
+
+	q := dbgen.New(mydb.Instrument(tx))
+	for _, params := range batch.Upserts {
+		if err := p.upsertFlag(ctx, q, params); err != nil {
+			return err
+		}
+	}
+	for _, ref := range batch.Deletes {
+		if err := q.DeleteFlag(ctx, dbgen.DeleteFlagParams{Key: ref.Key, Env: ref.Env}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+)
+
+// CachedStore wraps a PostgresStore and caches the results of GetAllFlags
+// and GetFlagByKey for a TTL, taking admin read load off the connection
+// pool. Every write through the store invalidates the written environment,
+// so the store sees its own writes immediately; writes made by other
+// replicas become visible once the TTL expires.
+//
+// Reads with a ReadPrimary context (snapshot rebuilds, read-modify-write
+// callers) bypass the cache and refresh it. Errors are not cached.
+//
+// The PostgresStore is embedded, so all of its other methods (API keys,
+// audit logs, trash, ...) are available unchanged.
+type CachedStore struct {
+	*PostgresStore
+	base Store // the PostgresStore's flag methods, replaced in tests
+	ttl  time.Duration
+	now  func() time.Time // time.Now, replaced in tests
+
+	mu    sync.Mutex
+	envs  map[string]cachedFlags // env -> GetAllFlags result
+	flags map[string]cachedFlag  // flagID(key, env) -> GetFlagByKey result
+	gen   map[string]uint64      // env -> invalidation count, see put
+}
+
+type cachedFlags struct {
+	flags   []Flag
+	expires time.Time
+}
+
+type cachedFlag struct {
+	flag    Flag
+	env     string
+	expires time.Time
+}
+
+// NewCachedStore returns a CachedStore caching reads of p for ttl.
+func NewCachedStore(p *PostgresStore, ttl time.Duration) *CachedStore {
+	return &CachedStore{
+		PostgresStore: p,
+		base:          p,
+		ttl:           ttl,
+		now:           time.Now,
+		envs:          map[string]cachedFlags{},
+		flags:         map[string]cachedFlag{},
+		gen:           map[string]uint64{},
+	}
+}
+
+// GetAllFlags returns the cached flags of env, reading them from the
+// database if they are missing or expired.
+func (c *CachedStore) GetAllFlags(ctx context.Context, env string) ([]Flag, error) {
+	c.mu.Lock()
+	entry, ok := c.envs[env]
+	gen := c.gen[env]
+	c.mu.Unlock()
+	if ok && !readsPrimary(ctx) && c.now().Before(entry.expires) {
+		telemetry.StoreCacheRequests.WithLabelValues("GetAllFlags", "hit").Inc()
+		return slices.Clone(entry.flags), nil
+	}
+	telemetry.StoreCacheRequests.WithLabelValues("GetAllFlags", "miss").Inc()
+
+	flags, err := c.base.GetAllFlags(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	c.put(env, gen, func() {
+		c.envs[env] = cachedFlags{flags: slices.Clone(flags), expires: c.now().Add(c.ttl)}
+	})
+	return flags, nil
+}
+
+// GetFlagByKey returns the cached flag, reading it from the database if it
+// is missing or expired.
+func (c *CachedStore) GetFlagByKey(ctx context.Context, key, env string) (*Flag, error) {
+	id := flagID(key, env)
+	c.mu.Lock()
+	entry, ok := c.flags[id]
+	gen := c.gen[env]
+	c.mu.Unlock()
+	if ok && !readsPrimary(ctx) && c.now().Before(entry.expires) {
+		telemetry.StoreCacheRequests.WithLabelValues("GetFlagByKey", "hit").Inc()
+		flag := entry.flag
+		return &flag, nil
+	}
+	telemetry.StoreCacheRequests.WithLabelValues("GetFlagByKey", "miss").Inc()
+
+	flag, err := c.base.GetFlagByKey(ctx, key, env)
+	if err != nil {
+		return nil, err
+	}
+	c.put(env, gen, func() {
+		c.flags[id] = cachedFlag{flag: *flag, env: env, expires: c.now().Add(c.ttl)}
+	})
+	return flag, nil
+}
+
+// put runs store under the lock unless env was invalidated since gen was
+// read, so a read that raced with a write cannot cache the old flags.
+func (c *CachedStore) put(env string, gen uint64, store func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen[env] == gen {
+		store()
+	}
+}
+
+// Invalidate drops the cached flags of env.
+func (c *CachedStore) Invalidate(env string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen[env]++
+	delete(c.envs, env)
+	for id, entry := range c.flags {
+		if entry.env == env {
+			delete(c.flags, id)
+		}
+	}
+}
+
+// invalidateAll drops every cached flag.
+func (c *CachedStore) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for env := range c.envs {
+		c.gen[env]++
+	}
+	for _, entry := range c.flags {
+		c.gen[entry.env]++
+	}
+	c.envs = map[string]cachedFlags{}
+	c.flags = map[string]cachedFlag{}
+}
+
+// UpsertFlag writes the flag and invalidates its environment.
+func (c *CachedStore) UpsertFlag(ctx context.Context, params UpsertParams) error {
+	defer c.Invalidate(params.Env)
+	return c.base.UpsertFlag(ctx, params)
+}
+
+// DeleteFlag deletes the flag and invalidates its environment.
+func (c *CachedStore) DeleteFlag(ctx context.Context, key, env string) error {
+	defer c.Invalidate(env)
+	return c.base.DeleteFlag(ctx, key, env)
+}
+
+// ApplyFlagBatch applies batch and invalidates the environments it writes.
+func (c *CachedStore) ApplyFlagBatch(ctx context.Context, batch FlagBatch) error {
+	defer func() {
+		for _, params := range batch.Upserts {
+			c.Invalidate(params.Env)
+		}
+		for _, ref := range batch.Deletes {
+			c.Invalidate(ref.Env)
+		}
+	}()
+	return c.PostgresStore.ApplyFlagBatch(ctx, batch)
+}
+
+// RestoreFlag restores the flag from the trash and invalidates its environment.
+func (c *CachedStore) RestoreFlag(ctx context.Context, key, env string) (*Flag, error) {
+	defer c.Invalidate(env)
+	return c.PostgresStore.RestoreFlag(ctx, key, env)
+}
+
+// RotateFlagConfigs re-encrypts the stored configs and drops the cache.
+func (c *CachedStore) RotateFlagConfigs(ctx context.Context) (int, error) {
+	defer c.invalidateAll()
+	return c.PostgresStore.RotateFlagConfigs(ctx)
+}
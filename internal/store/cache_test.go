@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingStore counts the flag reads that reach the underlying store.
+type countingStore struct {
+	*MemoryStore
+	reads atomic.Int32
+}
+
+func (s *countingStore) GetAllFlags(ctx context.Context, env string) ([]Flag, error) {
+	s.reads.Add(1)
+	return s.MemoryStore.GetAllFlags(ctx, env)
+}
+
+func (s *countingStore) GetFlagByKey(ctx context.Context, key, env string) (*Flag, error) {
+	s.reads.Add(1)
+	return s.MemoryStore.GetFlagByKey(ctx, key, env)
+}
+
+func newTestCachedStore(ttl time.Duration) (*CachedStore, *countingStore, *time.Time) {
+	base := &countingStore{MemoryStore: NewMemoryStore()}
+	c := NewCachedStore(nil, ttl)
+	c.base = base
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, base, &now
+}
+
+func TestCachedStore_CachesReads(t *testing.T) {
+	c, base, now := newTestCachedStore(time.Minute)
+	ctx := context.Background()
+	if err := c.UpsertFlag(ctx, UpsertParams{Key: "checkout", Env: "prod", Rollout: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 3 {
+		if flags, err := c.GetAllFlags(ctx, "prod"); err != nil || len(flags) != 1 {
+			t.Fatalf("GetAllFlags = %v, %v", flags, err)
+		}
+		if flag, err := c.GetFlagByKey(ctx, "checkout", "prod"); err != nil || flag.Rollout != 10 {
+			t.Fatalf("GetFlagByKey = %+v, %v", flag, err)
+		}
+	}
+	if got := base.reads.Load(); got != 2 {
+		t.Errorf("expected one read per method, got %d", got)
+	}
+
+	// Callers may modify what they get without affecting the cache.
+	flags, _ := c.GetAllFlags(ctx, "prod")
+	flags[0].Rollout = 99
+	flag, _ := c.GetFlagByKey(ctx, "checkout", "prod")
+	flag.Rollout = 99
+	if flags, _ := c.GetAllFlags(ctx, "prod"); flags[0].Rollout != 10 {
+		t.Error("expected the cached flags to be unaffected by the caller")
+	}
+	if flag, _ := c.GetFlagByKey(ctx, "checkout", "prod"); flag.Rollout != 10 {
+		t.Error("expected the cached flag to be unaffected by the caller")
+	}
+
+	// Entries expire after the TTL.
+	*now = now.Add(time.Minute)
+	c.GetAllFlags(ctx, "prod")
+	c.GetFlagByKey(ctx, "checkout", "prod")
+	if got := base.reads.Load(); got != 4 {
+		t.Errorf("expected expired entries to be read again, got %d reads", got)
+	}
+
+	// Reads that must see the primary bypass the cache.
+	c.GetAllFlags(ReadPrimary(ctx), "prod")
+	if got := base.reads.Load(); got != 5 {
+		t.Errorf("expected a ReadPrimary read to reach the store, got %d reads", got)
+	}
+
+	// Missing flags are not cached.
+	for range 2 {
+		if _, err := c.GetFlagByKey(ctx, "missing", "prod"); err == nil {
+			t.Fatal("expected an error for a missing flag")
+		}
+	}
+	if got := base.reads.Load(); got != 7 {
+		t.Errorf("expected errors not to be cached, got %d reads", got)
+	}
+}
+
+func TestCachedStore_WritesInvalidate(t *testing.T) {
+	c, base, _ := newTestCachedStore(time.Hour)
+	ctx := context.Background()
+	if err := c.UpsertFlag(ctx, UpsertParams{Key: "checkout", Env: "prod", Rollout: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "staging"}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetAllFlags(ctx, "prod")
+	c.GetFlagByKey(ctx, "checkout", "prod")
+	c.GetAllFlags(ctx, "staging")
+
+	if err := c.UpsertFlag(ctx, UpsertParams{Key: "checkout", Env: "prod", Rollout: 50}); err != nil {
+		t.Fatal(err)
+	}
+	if flag, _ := c.GetFlagByKey(ctx, "checkout", "prod"); flag.Rollout != 50 {
+		t.Errorf("expected the update to be visible, got rollout %d", flag.Rollout)
+	}
+	if err := c.DeleteFlag(ctx, "checkout", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if flags, _ := c.GetAllFlags(ctx, "prod"); len(flags) != 0 {
+		t.Errorf("expected the delete to be visible, got %+v", flags)
+	}
+
+	// Other environments stay cached.
+	reads := base.reads.Load()
+	c.GetAllFlags(ctx, "staging")
+	if got := base.reads.Load(); got != reads {
+		t.Errorf("expected staging to stay cached, got %d reads instead of %d", got, reads)
+	}
+}
+
+func TestCachedStore_StaleReadIsNotCached(t *testing.T) {
+	c, base, _ := newTestCachedStore(time.Hour)
+	ctx := context.Background()
+
+	// A read that started before a write must not cache what it read.
+	c.mu.Lock()
+	gen := c.gen["prod"]
+	c.mu.Unlock()
+	c.Invalidate("prod")
+	c.put("prod", gen, func() { c.envs["prod"] = cachedFlags{expires: c.now().Add(time.Hour)} })
+
+	c.GetAllFlags(ctx, "prod")
+	if got := base.reads.Load(); got != 1 {
+		t.Errorf("expected the stale read not to be cached, got %d reads", got)
+	}
+}
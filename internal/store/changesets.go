@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrChangeSetNotFound is returned when a change set lookup matches no change set.
+	ErrChangeSetNotFound = errors.New("change set not found")
+
+	// ErrChangeSetClosed is returned when modifying a change set that was
+	// already applied or discarded.
+	ErrChangeSetClosed = errors.New("change set is closed")
+)
+
+// Change set statuses. Only open change sets can be modified.
+const (
+	ChangeSetOpen      = "open"
+	ChangeSetApplied   = "applied"
+	ChangeSetDiscarded = "discarded"
+)
+
+// ChangeSet is a named list of staged flag changes in one environment that
+// are reviewed, then applied or discarded together. Operations is opaque to
+// the store; the api package defines its shape.
+type ChangeSet struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Env         string          `json:"env"`
+	Operations  json.RawMessage `json:"operations"`
+	Status      string          `json:"status"` // one of the ChangeSet status constants
+	CreatedAt   time.Time       `json:"created_at"`
+	CreatedBy   string          `json:"created_by"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	ClosedAt    *time.Time      `json:"closed_at,omitempty"`
+	ClosedBy    string          `json:"closed_by,omitempty"`
+}
+
+// CreateChangeSetParams contains the parameters for creating a change set.
+type CreateChangeSetParams struct {
+	Name        string
+	Description string
+	Env         string
+	Operations  json.RawMessage
+	CreatedBy   string
+}
+
+// ChangeSetStore is implemented by stores that can persist change sets.
+// Both MemoryStore and PostgresStore implement it.
+type ChangeSetStore interface {
+	// CreateChangeSet stores a new, open change set.
+	CreateChangeSet(ctx context.Context, params CreateChangeSetParams) (ChangeSet, error)
+
+	// GetChangeSet returns ErrChangeSetNotFound for unknown IDs.
+	GetChangeSet(ctx context.Context, id string) (*ChangeSet, error)
+
+	// ListChangeSets returns the change sets in env with status, newest
+	// first. Empty arguments match any environment or status.
+	ListChangeSets(ctx context.Context, env, status string) ([]ChangeSet, error)
+
+	// UpdateChangeSetOperations replaces the operations of an open change
+	// set. Returns ErrChangeSetNotFound or ErrChangeSetClosed.
+	UpdateChangeSetOperations(ctx context.Context, id string, operations json.RawMessage) (ChangeSet, error)
+
+	// CloseChangeSet moves an open change set to status (applied or
+	// discarded). Returns ErrChangeSetNotFound or ErrChangeSetClosed.
+	CloseChangeSet(ctx context.Context, id, status, closedBy string) (ChangeSet, error)
+}
+
+// CreateChangeSet stores a new, open change set.
+func (m *MemoryStore) CreateChangeSet(ctx context.Context, params CreateChangeSetParams) (ChangeSet, error) {
+	id, err := newUUID()
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	now := time.Now().UTC()
+	cs := ChangeSet{
+		ID:          id,
+		Name:        params.Name,
+		Description: params.Description,
+		Env:         params.Env,
+		Operations:  append(json.RawMessage{}, params.Operations...),
+		Status:      ChangeSetOpen,
+		CreatedAt:   now,
+		CreatedBy:   params.CreatedBy,
+		UpdatedAt:   now,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.changeSets == nil {
+		m.changeSets = make(map[string]ChangeSet)
+	}
+	m.changeSets[id] = cs
+	return cs, nil
+}
+
+// GetChangeSet returns a change set by ID.
+func (m *MemoryStore) GetChangeSet(ctx context.Context, id string) (*ChangeSet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cs, ok := m.changeSets[id]
+	if !ok {
+		return nil, ErrChangeSetNotFound
+	}
+	return &cs, nil
+}
+
+// ListChangeSets returns the change sets matching env and status, newest first.
+func (m *MemoryStore) ListChangeSets(ctx context.Context, env, status string) ([]ChangeSet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sets := make([]ChangeSet, 0, len(m.changeSets))
+	for _, cs := range m.changeSets {
+		if (env == "" || cs.Env == env) && (status == "" || cs.Status == status) {
+			sets = append(sets, cs)
+		}
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].CreatedAt.After(sets[j].CreatedAt) })
+	return sets, nil
+}
+
+// UpdateChangeSetOperations replaces the operations of an open change set.
+func (m *MemoryStore) UpdateChangeSetOperations(ctx context.Context, id string, operations json.RawMessage) (ChangeSet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cs, ok := m.changeSets[id]
+	if !ok {
+		return ChangeSet{}, ErrChangeSetNotFound
+	}
+	if cs.Status != ChangeSetOpen {
+		return ChangeSet{}, ErrChangeSetClosed
+	}
+	cs.Operations = append(json.RawMessage{}, operations...)
+	cs.UpdatedAt = time.Now().UTC()
+	m.changeSets[id] = cs
+	return cs, nil
+}
+
+// CloseChangeSet moves an open change set to status.
+func (m *MemoryStore) CloseChangeSet(ctx context.Context, id, status, closedBy string) (ChangeSet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cs, ok := m.changeSets[id]
+	if !ok {
+		return ChangeSet{}, ErrChangeSetNotFound
+	}
+	if cs.Status != ChangeSetOpen {
+		return ChangeSet{}, ErrChangeSetClosed
+	}
+	now := time.Now().UTC()
+	cs.Status, cs.ClosedAt, cs.ClosedBy, cs.UpdatedAt = status, &now, closedBy, now
+	m.changeSets[id] = cs
+	return cs, nil
+}
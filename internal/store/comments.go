@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// FlagComment is a free-text note attached to a flag in one environment,
+// e.g. "rolled back due to INC-1234". AuditLogID optionally links it to the
+// audit event it explains. Comments are kept when the flag is deleted.
+type FlagComment struct {
+	ID         string    `json:"id"`
+	FlagKey    string    `json:"flag_key"`
+	Env        string    `json:"env"`
+	Body       string    `json:"body"`
+	AuditLogID string    `json:"audit_log_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	CreatedBy  string    `json:"created_by"`
+}
+
+// CreateFlagCommentParams contains the parameters for adding a comment.
+type CreateFlagCommentParams struct {
+	FlagKey    string
+	Env        string
+	Body       string
+	AuditLogID string // optional, a UUID
+	CreatedBy  string
+}
+
+// FlagCommentStore is implemented by stores that can persist flag comments.
+// Both MemoryStore and PostgresStore implement it.
+type FlagCommentStore interface {
+	CreateFlagComment(ctx context.Context, params CreateFlagCommentParams) (FlagComment, error)
+
+	// ListFlagComments returns the comments on key in env, oldest first.
+	ListFlagComments(ctx context.Context, key, env string) ([]FlagComment, error)
+}
+
+// CreateFlagComment stores a new comment.
+func (m *MemoryStore) CreateFlagComment(ctx context.Context, params CreateFlagCommentParams) (FlagComment, error) {
+	id, err := newUUID()
+	if err != nil {
+		return FlagComment{}, err
+	}
+	comment := FlagComment{
+		ID:         id,
+		FlagKey:    params.FlagKey,
+		Env:        params.Env,
+		Body:       params.Body,
+		AuditLogID: params.AuditLogID,
+		CreatedAt:  time.Now().UTC(),
+		CreatedBy:  params.CreatedBy,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.comments == nil {
+		m.comments = make(map[string][]FlagComment)
+	}
+	fid := flagID(params.FlagKey, params.Env)
+	m.comments[fid] = append(m.comments[fid], comment)
+	return comment, nil
+}
+
+// ListFlagComments returns the comments on key in env, oldest first.
+func (m *MemoryStore) ListFlagComments(ctx context.Context, key, env string) ([]FlagComment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	comments := append([]FlagComment{}, m.comments[flagID(key, env)]...)
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+	return comments, nil
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+)
+
+// ConfigEncryptionStore is implemented by stores that can encrypt flag
+// configs at rest. Only PostgresStore implements it; MemoryStore keeps
+// nothing at rest.
+type ConfigEncryptionStore interface {
+	// RotateFlagConfigs re-encrypts every encrypted (or, when encryption
+	// of configs is enabled, plaintext) flag and flag revision config with
+	// the primary key and returns the number of configs rewritten.
+	RotateFlagConfigs(ctx context.Context) (int, error)
+}
+
+// SetEncryption configures flag config encryption. keyring decrypts configs
+// that were written encrypted; with encryptConfig set, configs are also
+// encrypted on write. Encrypted configs are stored as a JSON string holding
+// the encryption envelope, so the column stays valid JSONB.
+func (p *PostgresStore) SetEncryption(keyring *encryption.Keyring, encryptConfig bool) {
+	p.keyring = keyring
+	p.encryptConfig = encryptConfig && keyring != nil
+}
+
+// encodeConfig encrypts marshaled config JSON if config encryption is on.
+func (p *PostgresStore) encodeConfig(configBytes []byte) ([]byte, error) {
+	if !p.encryptConfig {
+		return configBytes, nil
+	}
+	return p.encodeEncrypted(string(configBytes))
+}
+
+// encodeEncrypted encrypts config JSON into its stored form.
+func (p *PostgresStore) encodeEncrypted(configJSON string) ([]byte, error) {
+	ciphertext, err := p.keyring.Encrypt(configJSON)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ciphertext)
+}
+
+// storedCiphertext returns the encryption envelope held by a flags row's
+// config column, or false if the config is stored in plaintext.
+func storedCiphertext(stored []byte) (string, bool) {
+	if len(stored) == 0 || stored[0] != '"' {
+		return "", false
+	}
+	var value string
+	if err := json.Unmarshal(stored, &value); err != nil || !encryption.IsEncrypted(value) {
+		return "", false
+	}
+	return value, true
+}
+
+// decodeConfig returns the config JSON stored in a flags row, decrypting it
+// if it was written encrypted.
+func (p *PostgresStore) decodeConfig(stored []byte) ([]byte, error) {
+	ciphertext, ok := storedCiphertext(stored)
+	if !ok {
+		return stored, nil
+	}
+	plaintext, err := p.keyring.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt flag config: %w", err)
+	}
+	return []byte(plaintext), nil
+}
+
+// RotateFlagConfigs implements ConfigEncryptionStore. Configs are rewritten
+// without bumping updated_at, since their content does not change. The
+// configs of flag revisions (see FlagRevisionStore) are rotated too, so old
+// versions stay readable once a retired key is removed.
+func (p *PostgresStore) RotateFlagConfigs(ctx context.Context) (int, error) {
+	if p.keyring == nil {
+		return 0, nil
+	}
+	rows, err := p.q.ListFlagConfigs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, row := range rows {
+		config, ok, err := p.rotateConfig(row.Config)
+		if err != nil {
+			return rotated, fmt.Errorf("flag %s/%s: %w", row.Env, row.Key, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := p.q.UpdateFlagConfig(ctx, dbgen.UpdateFlagConfigParams{Key: row.Key, Env: row.Env, Config: config}); err != nil {
+			return rotated, fmt.Errorf("flag %s/%s: %w", row.Env, row.Key, err)
+		}
+		rotated++
+	}
+
+	revisions, err := p.q.ListFlagRevisionConfigs(ctx)
+	if err != nil {
+		return rotated, err
+	}
+	for _, row := range revisions {
+		config, ok, err := p.rotateConfig(row.Config)
+		if err != nil {
+			return rotated, fmt.Errorf("flag %s/%s version %d: %w", row.Env, row.Key, row.Version, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := p.q.UpdateFlagRevisionConfig(ctx, dbgen.UpdateFlagRevisionConfigParams{Key: row.Key, Env: row.Env, Version: row.Version, Config: config}); err != nil {
+			return rotated, fmt.Errorf("flag %s/%s version %d: %w", row.Env, row.Key, row.Version, err)
+		}
+		rotated++
+	}
+	return rotated, nil
+}
+
+// rotateConfig returns stored re-encrypted with the primary key, or false if
+// it needs no rotation.
+func (p *PostgresStore) rotateConfig(stored []byte) ([]byte, bool, error) {
+	plaintext := string(stored)
+	if ciphertext, ok := storedCiphertext(stored); ok {
+		if !p.keyring.NeedsRotation(ciphertext) {
+			return nil, false, nil
+		}
+		var err error
+		if plaintext, err = p.keyring.Decrypt(ciphertext); err != nil {
+			return nil, false, err
+		}
+	} else if !p.encryptConfig {
+		return nil, false, nil
+	}
+
+	config, err := p.encodeEncrypted(plaintext)
+	if err != nil {
+		return nil, false, err
+	}
+	return config, true, nil
+}
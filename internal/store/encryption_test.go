@@ -0,0 +1,68 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+)
+
+func testKeyring(t *testing.T) *encryption.Keyring {
+	t.Helper()
+	k, err := encryption.ParseKeys("k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	return k
+}
+
+func TestPostgresStore_FlagConfigEncryption(t *testing.T) {
+	p := &PostgresStore{}
+	p.SetEncryption(testKeyring(t), true)
+
+	params, err := p.toUpsertFlagParams(UpsertParams{Key: "a", Env: "prod", Config: map[string]any{"api_url": "https://internal"}})
+	if err != nil {
+		t.Fatalf("toUpsertFlagParams: %v", err)
+	}
+	if !strings.HasPrefix(string(params.Config), `"enc:v1:k1:`) || strings.Contains(string(params.Config), "internal") {
+		t.Fatalf("config not encrypted: %s", params.Config)
+	}
+
+	flag, err := p.convertFromDB(dbgen.Flag{Key: "a", Env: "prod", Config: params.Config})
+	if err != nil {
+		t.Fatalf("convertFromDB: %v", err)
+	}
+	if flag.Config["api_url"] != "https://internal" {
+		t.Errorf("decrypted config = %v", flag.Config)
+	}
+
+	// Plaintext rows written before encryption was enabled still load.
+	flag, err = p.convertFromDB(dbgen.Flag{Key: "b", Env: "prod", Config: []byte(`{"x":1}`)})
+	if err != nil || flag.Config["x"] != float64(1) {
+		t.Errorf("plaintext config = %v, %v", flag.Config, err)
+	}
+}
+
+func TestPostgresStore_EncryptedConfigWithoutKey(t *testing.T) {
+	enc := &PostgresStore{}
+	enc.SetEncryption(testKeyring(t), true)
+	params, _ := enc.toUpsertFlagParams(UpsertParams{Key: "a", Env: "prod", Config: map[string]any{"x": 1}})
+
+	p := &PostgresStore{}
+	if _, err := p.convertFromDB(dbgen.Flag{Key: "a", Env: "prod", Config: params.Config}); err == nil {
+		t.Error("expected an error reading an encrypted config without a keyring")
+	}
+
+	// Without ENCRYPT_FLAG_CONFIG, configs are written in plaintext but
+	// encrypted ones can still be read.
+	p.SetEncryption(testKeyring(t), false)
+	if plain, _ := p.toUpsertFlagParams(UpsertParams{Key: "b", Env: "prod", Config: map[string]any{"x": 1}}); string(plain.Config) != `{"x":1}` {
+		t.Errorf("expected plaintext config, got %s", plain.Config)
+	}
+	if flag, err := p.convertFromDB(dbgen.Flag{Key: "a", Env: "prod", Config: params.Config}); err != nil || flag.Config["x"] != float64(1) {
+		t.Errorf("decrypted config = %v, %v", flag.Config, err)
+	}
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// EphemeralEnvironment is a short-lived environment, e.g. for a pull request
+// preview deployment, that is deleted together with its flags once ExpiresAt
+// has passed.
+type EphemeralEnvironment struct {
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+}
+
+// EphemeralEnvironmentStore is implemented by stores that can track
+// ephemeral environments. Both MemoryStore and PostgresStore implement it.
+type EphemeralEnvironmentStore interface {
+	// UpsertEphemeralEnvironment registers env, or moves its expiry if it is
+	// already registered. CreatedAt and CreatedBy of an existing entry are kept.
+	UpsertEphemeralEnvironment(ctx context.Context, env EphemeralEnvironment) (EphemeralEnvironment, error)
+
+	// ListEphemeralEnvironments returns all registered environments, soonest expiry first.
+	ListEphemeralEnvironments(ctx context.Context) ([]EphemeralEnvironment, error)
+
+	// ListExpiredEphemeralEnvironments returns environments with ExpiresAt <= now.
+	ListExpiredEphemeralEnvironments(ctx context.Context, now time.Time) ([]EphemeralEnvironment, error)
+
+	// DeleteEphemeralEnvironment unregisters env. It does not touch its flags.
+	DeleteEphemeralEnvironment(ctx context.Context, name string) error
+}
+
+// UpsertEphemeralEnvironment registers env or updates its expiry.
+func (m *MemoryStore) UpsertEphemeralEnvironment(ctx context.Context, env EphemeralEnvironment) (EphemeralEnvironment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ephemeralEnvs == nil {
+		m.ephemeralEnvs = make(map[string]EphemeralEnvironment)
+	}
+	if existing, ok := m.ephemeralEnvs[env.Name]; ok {
+		existing.ExpiresAt = env.ExpiresAt
+		m.ephemeralEnvs[env.Name] = existing
+		return existing, nil
+	}
+	env.CreatedAt = time.Now().UTC()
+	m.ephemeralEnvs[env.Name] = env
+	return env, nil
+}
+
+// ListEphemeralEnvironments returns all registered environments, soonest expiry first.
+func (m *MemoryStore) ListEphemeralEnvironments(ctx context.Context) ([]EphemeralEnvironment, error) {
+	return m.listEphemeralEnvironments(func(EphemeralEnvironment) bool { return true }), nil
+}
+
+// ListExpiredEphemeralEnvironments returns environments that expired at or before now.
+func (m *MemoryStore) ListExpiredEphemeralEnvironments(ctx context.Context, now time.Time) ([]EphemeralEnvironment, error) {
+	return m.listEphemeralEnvironments(func(env EphemeralEnvironment) bool { return !env.ExpiresAt.After(now) }), nil
+}
+
+func (m *MemoryStore) listEphemeralEnvironments(keep func(EphemeralEnvironment) bool) []EphemeralEnvironment {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	envs := make([]EphemeralEnvironment, 0, len(m.ephemeralEnvs))
+	for _, env := range m.ephemeralEnvs {
+		if keep(env) {
+			envs = append(envs, env)
+		}
+	}
+	sort.Slice(envs, func(i, j int) bool { return envs[i].ExpiresAt.Before(envs[j].ExpiresAt) })
+	return envs
+}
+
+// DeleteEphemeralEnvironment unregisters env.
+func (m *MemoryStore) DeleteEphemeralEnvironment(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.ephemeralEnvs, name)
+	return nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// FlagEvaluationStore is implemented by stores that keep when each flag was
+// last evaluated, so unused flags can be found across restarts and replicas.
+// The API records evaluation times in memory and writes them in batches (see
+// api/evaltracker.go). Deleting a flag deletes its evaluation time. Both
+// MemoryStore and PostgresStore implement it.
+type FlagEvaluationStore interface {
+	// RecordFlagEvaluations sets the last evaluation time of the flags of
+	// env in at, by key. A time earlier than the stored one is ignored, as
+	// are keys that are not flags in env.
+	RecordFlagEvaluations(ctx context.Context, env string, at map[string]time.Time) error
+
+	// ListFlagEvaluations returns the last evaluation time of every flag of
+	// env that has been evaluated, by key.
+	ListFlagEvaluations(ctx context.Context, env string) (map[string]time.Time, error)
+}
+
+// RecordFlagEvaluations sets the last evaluation times of flags in env.
+func (m *MemoryStore) RecordFlagEvaluations(ctx context.Context, env string, at map[string]time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, t := range at {
+		id := flagID(key, env)
+		if _, ok := m.flags[id]; !ok {
+			continue
+		}
+		if t.After(m.evaluations[id]) {
+			m.evaluations[id] = t.UTC()
+		}
+	}
+	return nil
+}
+
+// ListFlagEvaluations returns the last evaluation times of the flags of env.
+func (m *MemoryStore) ListFlagEvaluations(ctx context.Context, env string) (map[string]time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]time.Time)
+	for id, t := range m.evaluations {
+		if flag, ok := m.flags[id]; ok && flag.Env == env {
+			result[flag.Key] = t
+		}
+	}
+	return result, nil
+}
+
+// RecordFlagEvaluations sets the last evaluation times of flags in env in one
+// statement.
+func (p *PostgresStore) RecordFlagEvaluations(ctx context.Context, env string, at map[string]time.Time) error {
+	if len(at) == 0 {
+		return nil
+	}
+	params := dbgen.RecordFlagEvaluationsParams{
+		Keys:  make([]string, 0, len(at)),
+		Times: make([]pgtype.Timestamptz, 0, len(at)),
+		Env:   env,
+	}
+	keys := make([]string, 0, len(at))
+	for key := range at {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // a stable lock order for concurrent flushes from replicas
+	for _, key := range keys {
+		params.Keys = append(params.Keys, key)
+		params.Times = append(params.Times, pgtype.Timestamptz{Time: at[key], Valid: true})
+	}
+	return p.q.RecordFlagEvaluations(ctx, params)
+}
+
+// ListFlagEvaluations returns the last evaluation times of the flags of env.
+func (p *PostgresStore) ListFlagEvaluations(ctx context.Context, env string) (map[string]time.Time, error) {
+	rows, err := p.readQueries(ctx).ListFlagEvaluations(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		result[row.Key] = row.LastEvaluatedAt.Time.UTC()
+	}
+	return result, nil
+}
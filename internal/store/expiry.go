@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// FlagExpiryStore is implemented by stores that can find flags whose
+// ExpiresAt has passed, for the expiry worker (see api/expiry.go) to disable
+// or archive. Both MemoryStore and PostgresStore implement it.
+type FlagExpiryStore interface {
+	// ListExpiredFlags returns the unarchived flags of every environment
+	// whose ExpiresAt is at or before now, ordered by env and key.
+	ListExpiredFlags(ctx context.Context, now time.Time) ([]Flag, error)
+}
+
+// ListExpiredFlags returns the unarchived flags that expired at or before now.
+func (m *MemoryStore) ListExpiredFlags(ctx context.Context, now time.Time) ([]Flag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []Flag
+	for _, flag := range m.flags {
+		if !flag.Archived && flag.ExpiresAt != nil && !flag.ExpiresAt.After(now) {
+			result = append(result, flag)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Env != result[j].Env {
+			return result[i].Env < result[j].Env
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// ListExpiredFlags returns the unarchived flags that expired at or before
+// now. It reads from the primary: the caller writes each flag back at the
+// version read.
+func (p *PostgresStore) ListExpiredFlags(ctx context.Context, now time.Time) ([]Flag, error) {
+	dbFlags, err := p.q.ListExpiredFlags(ctx, pgtype.Timestamptz{Time: now, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	flags := make([]Flag, 0, len(dbFlags))
+	for _, dbFlag := range dbFlags {
+		flag, err := p.convertFromDB(dbFlag)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
@@ -31,6 +31,12 @@ import (
 //   }
 //   defer store.Close()
 func NewStore(ctx context.Context, storeType, dbDSN string) (Store, error) {
+	return NewStoreWithPool(ctx, storeType, dbDSN, mydb.PoolConfig{})
+}
+
+// NewStoreWithPool is NewStore with the connection pool settings used for
+// postgres stores.
+func NewStoreWithPool(ctx context.Context, storeType, dbDSN string, pool mydb.PoolConfig) (Store, error) {
 	switch storeType {
 	case "memory":
 		return NewMemoryStore(), nil
@@ -38,11 +44,11 @@ func NewStore(ctx context.Context, storeType, dbDSN string) (Store, error) {
 		if dbDSN == "" {
 			return nil, fmt.Errorf("database DSN cannot be empty when using postgres store (set DB_DSN environment variable)")
 		}
-		pool, err := mydb.NewPool(ctx, dbDSN)
+		p, err := mydb.NewPool(ctx, dbDSN, pool)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create postgres pool: %w", err)
 		}
-		return NewPostgresStore(pool), nil
+		return NewPostgresStore(p), nil
 	default:
 		return nil, fmt.Errorf("unsupported store type: %s (must be 'memory' or 'postgres')", storeType)
 	}
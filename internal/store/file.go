@@ -0,0 +1,336 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+
+	"github.com/TimurManjosov/goflagship/internal/validation"
+)
+
+// ErrReadOnly is returned by writes to a store whose flags cannot be changed
+// through the API, such as a FileStore.
+var ErrReadOnly = errors.New("store is read-only")
+
+// fileWatchDebounce batches the bursts of events editors and `git pull`
+// produce into a single reload.
+const fileWatchDebounce = 250 * time.Millisecond
+
+// FileStore serves flags from a directory of YAML/JSON flag files, the format
+// written by `flagship export` (a top-level "flags" list). It is read-only:
+// flags are changed by editing the files, e.g. through Git, so a deployment
+// needs no database.
+//
+// Files are read recursively; flags without an env belong to the default
+// environment given to NewFileStore, and a key may be defined only once per
+// environment. Flags without an updatedAt take their file's modification time.
+//
+// Watch reloads the directory whenever a file changes. A reload that fails
+// (e.g. a half-written file) keeps serving the last valid flags.
+type FileStore struct {
+	dir        string
+	defaultEnv string
+
+	mu    sync.RWMutex
+	flags map[string]Flag // flagID(key, env) -> Flag
+}
+
+// NewFileStore loads the flag files below dir. It fails if dir cannot be read
+// or holds an invalid flag.
+func NewFileStore(dir, defaultEnv string) (*FileStore, error) {
+	f := &FileStore{dir: dir, defaultEnv: defaultEnv}
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Dir returns the directory the flags are read from.
+func (f *FileStore) Dir() string {
+	return f.dir
+}
+
+// Reload re-reads the flag files and returns the environments whose flags
+// changed, sorted. On error the previous flags are kept.
+func (f *FileStore) Reload() ([]string, error) {
+	loaded, err := LoadFlagFiles(f.dir, f.defaultEnv)
+	if err != nil {
+		return nil, err
+	}
+	flags := make(map[string]Flag, len(loaded))
+	for _, flag := range loaded {
+		params := validation.FlagValidationParams{
+			Key:         flag.Key,
+			Env:         flag.Env,
+			Description: flag.Description,
+			Rollout:     flag.Rollout,
+			Config:      flag.Config,
+			Tags:        flag.Tags,
+			Owner:       flag.Owner,
+			TicketURL:   flag.TicketURL,
+			Metadata:    flag.Metadata,
+		}
+		for _, v := range flag.Variants {
+			params.Variants = append(params.Variants, validation.VariantValidationParams{Name: v.Name, Weight: v.Weight})
+		}
+		if result := validation.ValidateFlag(params); !result.Valid {
+			for _, field := range slices.Sorted(maps.Keys(result.Errors)) {
+				return nil, fmt.Errorf("flag %q in env %q: %s: %s", flag.Key, flag.Env, field, result.Errors[field])
+			}
+		}
+		flag.TargetingRules = ensureRulesInitialized(flag.TargetingRules)
+		flag.Project = projectOrDefault(flag.Project)
+		flags[flagID(flag.Key, flag.Env)] = flag
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	changed := map[string]bool{}
+	for id, flag := range flags {
+		if old, ok := f.flags[id]; !ok || !reflect.DeepEqual(old, flag) {
+			changed[flag.Env] = true
+		}
+	}
+	for id, flag := range f.flags {
+		if _, ok := flags[id]; !ok {
+			changed[flag.Env] = true
+		}
+	}
+	f.flags = flags
+	return slices.Sorted(maps.Keys(changed)), nil
+}
+
+// Watch reloads the store whenever a file below the directory changes, until
+// ctx is cancelled, and calls onChange for each environment whose flags
+// changed (e.g. to rebuild its snapshot). Reload errors are logged. It returns
+// an error only if the directory cannot be watched.
+func (f *FileStore) Watch(ctx context.Context, onChange func(ctx context.Context, env string) error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watch flag files: %w", err)
+	}
+	defer watcher.Close()
+	// fsnotify does not watch recursively, so every directory is added.
+	if err := addWatchDirs(watcher, f.dir); err != nil {
+		return fmt.Errorf("watch flag files: %w", err)
+	}
+
+	debounce := time.NewTimer(fileWatchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = addWatchDirs(watcher, event.Name)
+				}
+			}
+			debounce.Reset(fileWatchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("[filestore] watch error: %v", err)
+		case <-debounce.C:
+			changed, err := f.Reload()
+			if err != nil {
+				log.Printf("[filestore] reload failed, serving the previous flags: %v", err)
+				continue
+			}
+			if len(changed) == 0 {
+				continue
+			}
+			log.Printf("[filestore] reloaded flags: dir=%s envs=%s", f.dir, strings.Join(changed, ","))
+			for _, env := range changed {
+				if onChange == nil {
+					continue
+				}
+				if err := onChange(ctx, env); err != nil {
+					log.Printf("[filestore] change handler failed env=%s: %v", env, err)
+				}
+			}
+		}
+	}
+}
+
+// addWatchDirs adds root and the directories below it to watcher.
+func addWatchDirs(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		return watcher.Add(p)
+	})
+}
+
+// flagFile is the on-disk flag file format, shared with `flagship export`.
+type flagFile struct {
+	Flags []Flag `yaml:"flags" json:"flags"`
+}
+
+// LoadFlagFiles reads every .yaml/.yml/.json file below dir, in path order.
+// Flags without an env are assigned defaultEnv and flags without an
+// updatedAt their file's modification time. A key defined twice in one
+// environment is rejected so that the result never depends on file order.
+func LoadFlagFiles(dir, defaultEnv string) ([]Flag, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read flag files: %w", err)
+	}
+	sort.Strings(files)
+
+	var flags []Flag
+	source := map[string]string{} // flagID(key, env) -> file
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+		var ff flagFile
+		if err := yaml.Unmarshal(data, &ff); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		name, _ := filepath.Rel(dir, file)
+		for _, flag := range ff.Flags {
+			if flag.Env == "" {
+				flag.Env = defaultEnv
+			}
+			if flag.UpdatedAt.IsZero() {
+				flag.UpdatedAt = info.ModTime().UTC()
+			}
+			id := flagID(flag.Key, flag.Env)
+			if prev, dup := source[id]; dup {
+				return nil, fmt.Errorf("flag %q (env %s) defined in both %s and %s", flag.Key, flag.Env, prev, name)
+			}
+			source[id] = name
+			flags = append(flags, flag)
+		}
+	}
+	return flags, nil
+}
+
+// GetAllFlags retrieves all flags for the given environment.
+func (f *FileStore) GetAllFlags(ctx context.Context, env string) ([]Flag, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	result := make([]Flag, 0)
+	for _, flag := range f.flags {
+		if flag.Env == env {
+			result = append(result, flag)
+		}
+	}
+	return result, nil
+}
+
+// GetFlagByKey retrieves a single flag by its key within env.
+func (f *FileStore) GetFlagByKey(ctx context.Context, key, env string) (*Flag, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flag, exists := f.flags[flagID(key, env)]
+	if !exists {
+		return nil, errors.New("flag not found")
+	}
+	return &flag, nil
+}
+
+// GetFlagsByKeys retrieves the flags with the given keys within env, sorted by key.
+func (f *FileStore) GetFlagsByKeys(ctx context.Context, env string, keys []string) ([]Flag, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	result := make([]Flag, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if flag, ok := f.flags[flagID(key, env)]; ok {
+			result = append(result, flag)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// GetFlagsUpdatedSince retrieves the flags in env updated after since, oldest update first.
+func (f *FileStore) GetFlagsUpdatedSince(ctx context.Context, env string, since time.Time) ([]Flag, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	result := make([]Flag, 0)
+	for _, flag := range f.flags {
+		if flag.Env == env && flag.UpdatedAt.After(since) {
+			result = append(result, flag)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].UpdatedAt.Equal(result[j].UpdatedAt) {
+			return result[i].UpdatedAt.Before(result[j].UpdatedAt)
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// UpsertFlag returns ErrReadOnly; flags are changed by editing the files.
+func (f *FileStore) UpsertFlag(ctx context.Context, params UpsertParams) error {
+	return ErrReadOnly
+}
+
+// DeleteFlag returns ErrReadOnly; flags are changed by editing the files.
+func (f *FileStore) DeleteFlag(ctx context.Context, key, env string) error {
+	return ErrReadOnly
+}
+
+// Close is a no-op; Watch stops with its context.
+func (f *FileStore) Close() error {
+	return nil
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFlagFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStore_LoadsFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeFlagFile(t, dir, "prod.yaml", `
+flags:
+  - key: checkout
+    enabled: true
+    rollout: 50
+  - key: banner
+    env: staging
+    enabled: true
+    rollout: 100
+`)
+	writeFlagFile(t, dir, "team/search.json", `{"flags": [{"key": "search", "enabled": false, "rollout": 0}]}`)
+	writeFlagFile(t, dir, "README.md", "not a flag file")
+
+	fs, err := NewFileStore(dir, "prod")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := context.Background()
+
+	prod, _ := fs.GetAllFlags(ctx, "prod")
+	if len(prod) != 2 {
+		t.Fatalf("expected 2 prod flags, got %+v", prod)
+	}
+	flag, err := fs.GetFlagByKey(ctx, "checkout", "prod")
+	if err != nil || flag.Rollout != 50 || flag.UpdatedAt.IsZero() || flag.TargetingRules == nil {
+		t.Errorf("unexpected checkout flag: %+v, %v", flag, err)
+	}
+	if _, err := fs.GetFlagByKey(ctx, "banner", "staging"); err != nil {
+		t.Errorf("expected the staging flag: %v", err)
+	}
+	if _, err := fs.GetFlagByKey(ctx, "banner", "prod"); err == nil {
+		t.Error("expected banner to exist in staging only")
+	}
+
+	if err := fs.UpsertFlag(ctx, UpsertParams{Key: "new", Env: "prod"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly from UpsertFlag, got %v", err)
+	}
+	if err := fs.DeleteFlag(ctx, "checkout", "prod"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly from DeleteFlag, got %v", err)
+	}
+}
+
+func TestFileStore_RejectsInvalidFiles(t *testing.T) {
+	tests := map[string]string{
+		"duplicate key": "flags:\n  - key: a\n  - key: a\n",
+		"invalid key":   "flags:\n  - key: 'not valid'\n",
+		"bad rollout":   "flags:\n  - key: a\n    rollout: 500\n",
+		"bad yaml":      "flags: [",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFlagFile(t, dir, "flags.yaml", content)
+			if _, err := NewFileStore(dir, "prod"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFileStore_Reload(t *testing.T) {
+	dir := t.TempDir()
+	writeFlagFile(t, dir, "flags.yaml", "flags:\n  - key: a\n    rollout: 10\n  - key: b\n    env: dev\n")
+	fs, err := NewFileStore(dir, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFlagFile(t, dir, "other.yaml", "flags:\n  - key: c\n    env: staging\n")
+	if _, err := fs.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewriting flags.yaml also moves b's modification time; staging's file is untouched.
+	writeFlagFile(t, dir, "flags.yaml", "flags:\n  - key: a\n    rollout: 20\n    updatedat: 2026-01-01T00:00:00Z\n  - key: b\n    env: dev\n")
+	changed, err := fs.Reload()
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if !reflect.DeepEqual(changed, []string{"dev", "prod"}) {
+		t.Errorf("expected dev and prod to change, got %v", changed)
+	}
+	if flag, _ := fs.GetFlagByKey(context.Background(), "a", "prod"); !flag.UpdatedAt.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the updatedat from the file, got %v", flag.UpdatedAt)
+	}
+
+	// An invalid edit keeps the previous flags.
+	writeFlagFile(t, dir, "flags.yaml", "flags:\n  - key: a\n    rollout: 500\n")
+	if _, err := fs.Reload(); err == nil {
+		t.Fatal("expected the invalid edit to fail")
+	}
+	if flag, err := fs.GetFlagByKey(context.Background(), "a", "prod"); err != nil || flag.Rollout != 20 {
+		t.Errorf("expected the previous flag to be kept, got %+v, %v", flag, err)
+	}
+}
+
+func TestFileStore_Watch(t *testing.T) {
+	dir := t.TempDir()
+	writeFlagFile(t, dir, "flags.yaml", "flags:\n  - key: a\n    rollout: 10\n")
+	fs, err := NewFileStore(dir, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan string, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- fs.Watch(ctx, func(_ context.Context, env string) error {
+			changed <- env
+			return nil
+		})
+	}()
+
+	// Files in directories created after Watch started are picked up too.
+	deadline := time.After(5 * time.Second)
+	for attempt := 0; ; attempt++ {
+		writeFlagFile(t, dir, "team/more.yaml", "flags:\n  - key: b\n    env: dev\n    rollout: "+strings.Repeat("1", attempt%2+1)+"\n")
+		select {
+		case env := <-changed:
+			if env != "dev" {
+				t.Fatalf("expected dev to change, got %s", env)
+			}
+		case <-time.After(time.Second):
+			continue // the watcher may not have been set up yet
+		case <-deadline:
+			t.Fatal("timeout waiting for the watcher")
+		}
+		break
+	}
+	if _, err := fs.GetFlagByKey(ctx, "b", "dev"); err != nil {
+		t.Errorf("expected the new flag after the reload: %v", err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch returned %v", err)
+	}
+}
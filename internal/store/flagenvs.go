@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+// FlagEnvironmentStore is implemented by stores that can look a flag key up
+// across environments, e.g. to show where a flag is defined before it is
+// promoted or evaluated. Both MemoryStore and PostgresStore implement it.
+type FlagEnvironmentStore interface {
+	// ListFlagEnvironments returns the flag with key in every environment
+	// that defines it, sorted by environment. Trashed flags are not included.
+	ListFlagEnvironments(ctx context.Context, key string) ([]Flag, error)
+}
+
+// ListFlagEnvironments returns the flag with key in every environment.
+func (m *MemoryStore) ListFlagEnvironments(ctx context.Context, key string) ([]Flag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	flags := make([]Flag, 0)
+	for _, flag := range m.flags {
+		if flag.Key == key {
+			flags = append(flags, flag)
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Env < flags[j].Env })
+	return flags, nil
+}
+
+// ListFlagEnvironments returns the flag with key in every environment.
+func (p *PostgresStore) ListFlagEnvironments(ctx context.Context, key string) ([]Flag, error) {
+	rows, err := p.readQueries(ctx).ListFlagEnvironments(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return p.convertAllFromDB(rows)
+}
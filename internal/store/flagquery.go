@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+// Flag list sort orders; a leading "-" sorts descending. Ties are broken by key.
+const (
+	FlagSortKey           = "key"
+	FlagSortKeyDesc       = "-key"
+	FlagSortUpdatedAt     = "updated_at"
+	FlagSortUpdatedAtDesc = "-updated_at"
+)
+
+// FlagSorts lists the supported sort orders.
+var FlagSorts = []string{FlagSortKey, FlagSortKeyDesc, FlagSortUpdatedAt, FlagSortUpdatedAtDesc}
+
+// FlagQuery selects a page of the flags of one environment. Zero values do
+// not filter.
+type FlagQuery struct {
+	Env      string
+	Query    string   // case-insensitive substring of the key or description
+	Tag      string   // flags carrying this tag
+	Enabled  *bool    // flags in this state
+	Archived *bool    // archived (true) or active (false) flags
+	Project  string   // flags in this project; "" does not filter
+	Keys     []string // flags with one of these keys; nil does not filter, empty matches nothing
+	Sort     string   // one of FlagSorts; default FlagSortKey
+	Limit    int      // page size; 0 returns all matches
+	Offset   int
+}
+
+// FlagQueryStore is implemented by stores that filter, sort and page flag
+// lists themselves instead of returning every flag of an environment. Both
+// MemoryStore and PostgresStore implement it; other stores can be queried
+// with FilterFlags.
+type FlagQueryStore interface {
+	// QueryFlags returns the page of flags q selects and the number of flags
+	// matching q across all pages.
+	QueryFlags(ctx context.Context, q FlagQuery) ([]Flag, int, error)
+}
+
+// FilterFlags applies q to flags, which must all be in q.Env.
+func FilterFlags(flags []Flag, q FlagQuery) ([]Flag, int) {
+	query := strings.ToLower(q.Query)
+	var keys map[string]bool
+	if q.Keys != nil {
+		keys = make(map[string]bool, len(q.Keys))
+		for _, key := range q.Keys {
+			keys[key] = true
+		}
+	}
+	matches := make([]Flag, 0, len(flags))
+	for _, flag := range flags {
+		switch {
+		case q.Enabled != nil && flag.Enabled != *q.Enabled,
+			q.Archived != nil && flag.Archived != *q.Archived,
+			q.Tag != "" && !slices.Contains(flag.Tags, q.Tag),
+			q.Project != "" && flag.Project != q.Project,
+			query != "" && !strings.Contains(strings.ToLower(flag.Key), query) && !strings.Contains(strings.ToLower(flag.Description), query),
+			keys != nil && !keys[flag.Key]:
+			continue
+		}
+		matches = append(matches, flag)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		switch q.Sort {
+		case FlagSortKeyDesc:
+			return a.Key > b.Key
+		case FlagSortUpdatedAt:
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.Before(b.UpdatedAt)
+			}
+		case FlagSortUpdatedAtDesc:
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+		}
+		return a.Key < b.Key
+	})
+
+	total := len(matches)
+	start := min(q.Offset, total)
+	end := total
+	if q.Limit > 0 {
+		end = min(start+q.Limit, total)
+	}
+	return matches[start:end], total
+}
+
+// QueryFlags returns the page of flags q selects.
+func (m *MemoryStore) QueryFlags(ctx context.Context, q FlagQuery) ([]Flag, int, error) {
+	flags, err := m.GetAllFlags(ctx, q.Env)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := FilterFlags(flags, q)
+	return page, total, nil
+}
+
+// QueryFlags returns the page of flags q selects, filtered, sorted and paged
+// by the database.
+func (p *PostgresStore) QueryFlags(ctx context.Context, q FlagQuery) ([]Flag, int, error) {
+	var enabled pgtype.Bool
+	if q.Enabled != nil {
+		enabled = pgtype.Bool{Bool: *q.Enabled, Valid: true}
+	}
+	var archived pgtype.Bool
+	if q.Archived != nil {
+		archived = pgtype.Bool{Bool: *q.Archived, Valid: true}
+	}
+	tag := pgtype.Text{String: q.Tag, Valid: q.Tag != ""}
+	project := pgtype.Text{String: q.Project, Valid: q.Project != ""}
+	query := pgtype.Text{String: strings.ToLower(q.Query), Valid: q.Query != ""}
+	limit := int32(math.MaxInt32)
+	if q.Limit > 0 {
+		limit = int32(min(q.Limit, math.MaxInt32))
+	}
+	sortOrder := q.Sort
+	if sortOrder == "" {
+		sortOrder = FlagSortKey
+	}
+
+	queries := p.readQueries(ctx)
+	dbFlags, err := queries.ListFlagsPage(ctx, dbgen.ListFlagsPageParams{
+		Env:      q.Env,
+		Limit:    limit,
+		Offset:   int32(min(q.Offset, math.MaxInt32)),
+		Enabled:  enabled,
+		Tag:      tag,
+		Query:    query,
+		Keys:     q.Keys,
+		Archived: archived,
+		Project:  project,
+		Sort:     sortOrder,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := queries.CountFlagsPage(ctx, dbgen.CountFlagsPageParams{
+		Env:      q.Env,
+		Enabled:  enabled,
+		Tag:      tag,
+		Query:    query,
+		Keys:     q.Keys,
+		Archived: archived,
+		Project:  project,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	flags, err := p.convertAllFromDB(dbFlags)
+	if err != nil {
+		return nil, 0, err
+	}
+	return flags, int(total), nil
+}
@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrGuardrailNotFound is returned when a flag has no guardrail.
+var ErrGuardrailNotFound = errors.New("guardrail not found")
+
+// Guardrail watches the error rate reported for a flag and pauses or rolls
+// back its rollout when the rate exceeds MaxErrorRate.
+type Guardrail struct {
+	Key             string     `json:"key"`
+	Env             string     `json:"env"`
+	MaxErrorRate    float64    `json:"maxErrorRate"`            // 0-1
+	MinRequests     int64      `json:"minRequests"`             // requests in the window before the rate is checked
+	WindowSeconds   int32      `json:"windowSeconds"`           // how far back reports count
+	Action          string     `json:"action"`                  // what happens when tripped, see api/guardrails.go
+	RollbackRollout int32      `json:"rollbackRollout"`         // rollout restored by the rollback action
+	RequestMetric   string     `json:"requestMetric,omitempty"` // metric events counted as requests, see api/metricevents.go
+	ErrorMetric     string     `json:"errorMetric,omitempty"`   // metric events counted as errors
+	TrippedAt       *time.Time `json:"trippedAt,omitempty"`
+	TripReason      string     `json:"tripReason,omitempty"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// GuardrailStore is implemented by stores that keep flag guardrails, at most
+// one per flag. Deleting a flag deletes its guardrail. Both MemoryStore and
+// PostgresStore implement it.
+type GuardrailStore interface {
+	// ListGuardrails returns the guardrails of env, ordered by key.
+	ListGuardrails(ctx context.Context, env string) ([]Guardrail, error)
+
+	// GetGuardrail returns ErrGuardrailNotFound if key has no guardrail in env.
+	GetGuardrail(ctx context.Context, key, env string) (*Guardrail, error)
+
+	// SetGuardrail creates or replaces the guardrail of an existing flag,
+	// clearing its tripped state, and returns it as stored.
+	SetGuardrail(ctx context.Context, g Guardrail) (*Guardrail, error)
+
+	// SetGuardrailTripped records that a guardrail tripped at trippedAt for
+	// reason, or clears the tripped state if trippedAt is nil. Returns
+	// ErrGuardrailNotFound.
+	SetGuardrailTripped(ctx context.Context, key, env string, trippedAt *time.Time, reason string) (*Guardrail, error)
+
+	// DeleteGuardrail returns ErrGuardrailNotFound if key has no guardrail
+	// in env.
+	DeleteGuardrail(ctx context.Context, key, env string) error
+}
+
+// ListGuardrails returns the guardrails of env, ordered by key.
+func (m *MemoryStore) ListGuardrails(ctx context.Context, env string) ([]Guardrail, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Guardrail, 0)
+	for _, g := range m.guardrails {
+		if g.Env == env {
+			result = append(result, g)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// GetGuardrail returns the guardrail of a flag.
+func (m *MemoryStore) GetGuardrail(ctx context.Context, key, env string) (*Guardrail, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	g, ok := m.guardrails[flagID(key, env)]
+	if !ok {
+		return nil, ErrGuardrailNotFound
+	}
+	return &g, nil
+}
+
+// SetGuardrail creates or replaces the guardrail of a flag.
+func (m *MemoryStore) SetGuardrail(ctx context.Context, g Guardrail) (*Guardrail, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(g.Key, g.Env)
+	if _, ok := m.flags[id]; !ok {
+		return nil, errors.New("flag not found")
+	}
+	g.TrippedAt, g.TripReason = nil, ""
+	g.UpdatedAt = time.Now().UTC()
+	m.guardrails[id] = g
+	return &g, nil
+}
+
+// SetGuardrailTripped records or clears the tripped state of a guardrail.
+func (m *MemoryStore) SetGuardrailTripped(ctx context.Context, key, env string, trippedAt *time.Time, reason string) (*Guardrail, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(key, env)
+	g, ok := m.guardrails[id]
+	if !ok {
+		return nil, ErrGuardrailNotFound
+	}
+	g.TrippedAt, g.TripReason = trippedAt, reason
+	g.UpdatedAt = time.Now().UTC()
+	m.guardrails[id] = g
+	return &g, nil
+}
+
+// DeleteGuardrail deletes the guardrail of a flag.
+func (m *MemoryStore) DeleteGuardrail(ctx context.Context, key, env string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(key, env)
+	if _, ok := m.guardrails[id]; !ok {
+		return ErrGuardrailNotFound
+	}
+	delete(m.guardrails, id)
+	return nil
+}
+
+// ListGuardrails returns the guardrails of env, ordered by key.
+func (p *PostgresStore) ListGuardrails(ctx context.Context, env string) ([]Guardrail, error) {
+	rows, err := p.q.ListGuardrails(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Guardrail, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, guardrailFromDB(row))
+	}
+	return result, nil
+}
+
+// GetGuardrail returns the guardrail of a flag.
+func (p *PostgresStore) GetGuardrail(ctx context.Context, key, env string) (*Guardrail, error) {
+	row, err := p.q.GetGuardrail(ctx, dbgen.GetGuardrailParams{Key: key, Env: env})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGuardrailNotFound
+		}
+		return nil, err
+	}
+	g := guardrailFromDB(row)
+	return &g, nil
+}
+
+// SetGuardrail creates or replaces the guardrail of a flag; the foreign key
+// on flags rejects flags that do not exist.
+func (p *PostgresStore) SetGuardrail(ctx context.Context, g Guardrail) (*Guardrail, error) {
+	row, err := p.q.UpsertGuardrail(ctx, dbgen.UpsertGuardrailParams{
+		Key:             g.Key,
+		Env:             g.Env,
+		MaxErrorRate:    g.MaxErrorRate,
+		MinRequests:     g.MinRequests,
+		WindowSeconds:   g.WindowSeconds,
+		Action:          g.Action,
+		RollbackRollout: g.RollbackRollout,
+		RequestMetric:   g.RequestMetric,
+		ErrorMetric:     g.ErrorMetric,
+	})
+	if err != nil {
+		return nil, err
+	}
+	stored := guardrailFromDB(row)
+	return &stored, nil
+}
+
+// SetGuardrailTripped records or clears the tripped state of a guardrail.
+func (p *PostgresStore) SetGuardrailTripped(ctx context.Context, key, env string, trippedAt *time.Time, reason string) (*Guardrail, error) {
+	var at pgtype.Timestamptz
+	if trippedAt != nil {
+		at = pgtype.Timestamptz{Time: *trippedAt, Valid: true}
+	}
+	row, err := p.q.SetGuardrailTripped(ctx, dbgen.SetGuardrailTrippedParams{Key: key, Env: env, TrippedAt: at, TripReason: reason})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGuardrailNotFound
+		}
+		return nil, err
+	}
+	g := guardrailFromDB(row)
+	return &g, nil
+}
+
+// DeleteGuardrail deletes the guardrail of a flag.
+func (p *PostgresStore) DeleteGuardrail(ctx context.Context, key, env string) error {
+	deleted, err := p.q.DeleteGuardrail(ctx, dbgen.DeleteGuardrailParams{Key: key, Env: env})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrGuardrailNotFound
+	}
+	return nil
+}
+
+func guardrailFromDB(row dbgen.FlagGuardrail) Guardrail {
+	g := Guardrail{
+		Key:             row.Key,
+		Env:             row.Env,
+		MaxErrorRate:    row.MaxErrorRate,
+		MinRequests:     row.MinRequests,
+		WindowSeconds:   row.WindowSeconds,
+		Action:          row.Action,
+		RollbackRollout: row.RollbackRollout,
+		RequestMetric:   row.RequestMetric,
+		ErrorMetric:     row.ErrorMetric,
+		TripReason:      row.TripReason,
+		UpdatedAt:       row.UpdatedAt.Time,
+	}
+	if row.TrippedAt.Valid {
+		trippedAt := row.TrippedAt.Time
+		g.TrippedAt = &trippedAt
+	}
+	return g
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 )
@@ -11,14 +12,44 @@ import (
 // It uses a map for storage and RWMutex for thread-safe concurrent access.
 // This implementation is suitable for development, testing, or single-instance deployments.
 type MemoryStore struct {
-	mu    sync.RWMutex
-	flags map[string]Flag // key -> Flag
+	mu                sync.RWMutex
+	flags             map[string]Flag                        // flagID(key, env) -> Flag
+	sdkKeys           map[string]SDKKey                      // id -> SDKKey
+	ephemeralEnvs     map[string]EphemeralEnvironment        // name -> EphemeralEnvironment
+	comments          map[string][]FlagComment               // flagID(key, env) -> comments
+	contextAttributes map[string]map[string]ContextAttribute // project -> name -> attribute
+	contextPresets    map[string]ContextPreset               // name -> preset
+	readiness         map[string]ReadinessChecklist          // project -> checklist
+	changeSets        map[string]ChangeSet                   // id -> ChangeSet
+	trash             map[string]TrashedFlag                 // flagID(key, env) -> deleted flag
+	shadows           map[string]ShadowFlag                  // flagID(key, env) -> shadow version
+	guardrails        map[string]Guardrail                   // flagID(key, env) -> guardrail
+	userOverrides     map[string]map[string]UserOverride     // flagID(key, env) -> user ID -> override
+	revisions         map[string][]Flag                      // flagID(key, env) -> revisions, oldest first
+	stars             map[string]map[string]bool             // flagID(key, env) -> owner -> starred
+	evaluations       map[string]time.Time                   // flagID(key, env) -> last evaluation
+	projects          map[string]Project                     // key -> project
 }
 
 // NewMemoryStore creates a new in-memory store.
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		flags: make(map[string]Flag),
+		flags:             make(map[string]Flag),
+		sdkKeys:           make(map[string]SDKKey),
+		ephemeralEnvs:     make(map[string]EphemeralEnvironment),
+		comments:          make(map[string][]FlagComment),
+		contextAttributes: make(map[string]map[string]ContextAttribute),
+		contextPresets:    make(map[string]ContextPreset),
+		readiness:         make(map[string]ReadinessChecklist),
+		changeSets:        make(map[string]ChangeSet),
+		trash:             make(map[string]TrashedFlag),
+		shadows:           make(map[string]ShadowFlag),
+		guardrails:        make(map[string]Guardrail),
+		userOverrides:     make(map[string]map[string]UserOverride),
+		revisions:         make(map[string][]Flag),
+		stars:             make(map[string]map[string]bool),
+		evaluations:       make(map[string]time.Time),
+		projects:          map[string]Project{DefaultProject: defaultProjectEntry()},
 	}
 }
 
@@ -37,12 +68,12 @@ func (m *MemoryStore) GetAllFlags(ctx context.Context, env string) ([]Flag, erro
 	return result, nil
 }
 
-// GetFlagByKey retrieves a single flag by its key.
-func (m *MemoryStore) GetFlagByKey(ctx context.Context, key string) (*Flag, error) {
+// GetFlagByKey retrieves a single flag by its key within env.
+func (m *MemoryStore) GetFlagByKey(ctx context.Context, key, env string) (*Flag, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	flag, exists := m.flags[key]
+	flag, exists := m.flags[flagID(key, env)]
 	if !exists {
 		return nil, errors.New("flag not found")
 	}
@@ -50,42 +81,118 @@ func (m *MemoryStore) GetFlagByKey(ctx context.Context, key string) (*Flag, erro
 	return &flag, nil
 }
 
+// GetFlagsByKeys retrieves the flags with the given keys within env, sorted by key.
+func (m *MemoryStore) GetFlagsByKeys(ctx context.Context, env string, keys []string) ([]Flag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Flag, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if flag, ok := m.flags[flagID(key, env)]; ok {
+			result = append(result, flag)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// GetFlagsUpdatedSince retrieves the flags in env updated after since, oldest update first.
+func (m *MemoryStore) GetFlagsUpdatedSince(ctx context.Context, env string, since time.Time) ([]Flag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Flag, 0)
+	for _, flag := range m.flags {
+		if flag.Env == env && flag.UpdatedAt.After(since) {
+			result = append(result, flag)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].UpdatedAt.Equal(result[j].UpdatedAt) {
+			return result[i].UpdatedAt.Before(result[j].UpdatedAt)
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
 // UpsertFlag creates or updates a flag in memory.
 func (m *MemoryStore) UpsertFlag(ctx context.Context, params UpsertParams) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	flag := Flag{
+	if err := m.checkVersion(params); err != nil {
+		return err
+	}
+	m.upsertFlag(params, time.Now().UTC())
+	return nil
+}
+
+// checkVersion returns ErrVersionConflict if params.ExpectedVersion is set
+// and the stored flag is at another version. m.mu must be held.
+func (m *MemoryStore) checkVersion(params UpsertParams) error {
+	if params.ExpectedVersion != nil && *params.ExpectedVersion != m.flags[flagID(params.Key, params.Env)].Version {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// upsertFlag stores the flag for params at the next version and records it
+// as a revision. m.mu must be held.
+func (m *MemoryStore) upsertFlag(params UpsertParams, now time.Time) {
+	id := flagID(params.Key, params.Env)
+	flag := flagFromParams(params, now)
+	flag.Version = m.nextVersion(id)
+	m.flags[id] = flag
+	m.recordRevision(flag)
+}
+
+// flagFromParams builds the stored flag for params, updated at now.
+func flagFromParams(params UpsertParams, now time.Time) Flag {
+	return Flag{
 		Key:            params.Key,
 		Description:    params.Description,
 		Enabled:        params.Enabled,
 		Rollout:        params.Rollout,
 		Expression:     params.Expression,
 		Config:         params.Config,
+		ConfigJSON:     params.ConfigJSON,
 		TargetingRules: ensureRulesInitialized(params.TargetingRules),
 		Variants:       params.Variants,
+		Protected:      params.Protected,
+		Tags:           params.Tags,
+		Archived:       params.Archived,
+		Owner:          params.Owner,
+		TicketURL:      params.TicketURL,
+		Metadata:       params.Metadata,
+		ExpiresAt:      params.ExpiresAt,
+		Project:        projectOrDefault(params.Project),
 		Env:            params.Env,
-		UpdatedAt:      time.Now().UTC(),
+		UpdatedAt:      now,
 	}
-
-	m.flags[params.Key] = flag
-	return nil
 }
 
-// DeleteFlag removes a flag from memory.
+// DeleteFlag moves a flag to the trash (see FlagTrashStore).
 func (m *MemoryStore) DeleteFlag(ctx context.Context, key, env string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Check if flag exists and matches the environment before deleting
-	if flag, exists := m.flags[key]; exists && flag.Env == env {
-		delete(m.flags, key)
-	}
+	m.trashFlag(flagID(key, env), time.Now().UTC())
 
 	// Idempotent: no error if flag doesn't exist
 	return nil
 }
 
+// flagID is the map key of a flag; keys are only unique within an environment.
+func flagID(key, env string) string {
+	return env + "/" + key
+}
+
 // Close is a no-op for MemoryStore as there are no resources to release.
 func (m *MemoryStore) Close() error {
 	return nil
@@ -2,7 +2,10 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestMemoryStore_UpsertAndGet(t *testing.T) {
@@ -26,7 +29,7 @@ func TestMemoryStore_UpsertAndGet(t *testing.T) {
 	}
 
 	// Get the flag by key
-	flag, err := store.GetFlagByKey(ctx, "test-flag")
+	flag, err := store.GetFlagByKey(ctx, "test-flag", "prod")
 	if err != nil {
 		t.Fatalf("GetFlagByKey failed: %v", err)
 	}
@@ -109,7 +112,7 @@ func TestMemoryStore_Update(t *testing.T) {
 	}
 
 	// Verify the update
-	flag, err := store.GetFlagByKey(ctx, "update-test")
+	flag, err := store.GetFlagByKey(ctx, "update-test", "prod")
 	if err != nil {
 		t.Fatalf("GetFlagByKey failed: %v", err)
 	}
@@ -150,7 +153,7 @@ func TestMemoryStore_Delete(t *testing.T) {
 	}
 
 	// Verify deletion
-	_, err = store.GetFlagByKey(ctx, "delete-test")
+	_, err = store.GetFlagByKey(ctx, "delete-test", "prod")
 	if err == nil {
 		t.Error("Expected error when getting deleted flag, got nil")
 	}
@@ -187,7 +190,7 @@ func TestMemoryStore_DeleteWrongEnv(t *testing.T) {
 	}
 
 	// Flag should still exist
-	flag, err := store.GetFlagByKey(ctx, "env-test")
+	flag, err := store.GetFlagByKey(ctx, "env-test", "prod")
 	if err != nil {
 		t.Fatalf("GetFlagByKey failed: %v", err)
 	}
@@ -201,7 +204,7 @@ func TestMemoryStore_GetNonExistent(t *testing.T) {
 	store := NewMemoryStore()
 	ctx := context.Background()
 
-	_, err := store.GetFlagByKey(ctx, "non-existent")
+	_, err := store.GetFlagByKey(ctx, "non-existent", "prod")
 	if err == nil {
 		t.Error("Expected error when getting non-existent flag, got nil")
 	}
@@ -215,3 +218,402 @@ func TestMemoryStore_Close(t *testing.T) {
 		t.Errorf("Close() returned error: %v", err)
 	}
 }
+
+func TestMemoryStore_SDKKeys(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	key, err := store.CreateSDKKey(ctx, CreateSDKKeyParams{Name: "web", Env: "staging", KeyHash: "hash-1", KeyPrefix: "sdk_abc"})
+	if err != nil {
+		t.Fatalf("CreateSDKKey failed: %v", err)
+	}
+	if key.ID == "" || !key.Enabled {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+
+	got, err := store.GetSDKKeyByHash(ctx, "hash-1")
+	if err != nil || got.Env != "staging" {
+		t.Fatalf("GetSDKKeyByHash: got %+v, %v", got, err)
+	}
+	if _, err := store.GetSDKKeyByHash(ctx, "other"); !errors.Is(err, ErrSDKKeyNotFound) {
+		t.Errorf("expected ErrSDKKeyNotFound, got %v", err)
+	}
+
+	if keys, _ := store.ListSDKKeys(ctx, "prod"); len(keys) != 0 {
+		t.Errorf("expected no prod keys, got %d", len(keys))
+	}
+
+	if err := store.RevokeSDKKey(ctx, key.ID); err != nil {
+		t.Fatalf("RevokeSDKKey failed: %v", err)
+	}
+	if got, _ := store.GetSDKKeyByHash(ctx, "hash-1"); got.Enabled {
+		t.Error("revoked key should be disabled")
+	}
+	if err := store.RevokeSDKKey(ctx, "missing"); !errors.Is(err, ErrSDKKeyNotFound) {
+		t.Errorf("expected ErrSDKKeyNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_SameKeyInSeveralEnvs(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "shared", Rollout: 100, Env: "prod"})
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "shared", Rollout: 10, Env: "staging"})
+
+	prod, err := store.GetFlagByKey(ctx, "shared", "prod")
+	if err != nil || prod.Rollout != 100 {
+		t.Fatalf("prod flag: got %+v, %v", prod, err)
+	}
+	staging, err := store.GetFlagByKey(ctx, "shared", "staging")
+	if err != nil || staging.Rollout != 10 {
+		t.Fatalf("staging flag: got %+v, %v", staging, err)
+	}
+
+	if err := store.DeleteFlag(ctx, "shared", "staging"); err != nil {
+		t.Fatalf("DeleteFlag failed: %v", err)
+	}
+	if _, err := store.GetFlagByKey(ctx, "shared", "prod"); err != nil {
+		t.Errorf("deleting staging should keep prod: %v", err)
+	}
+}
+
+func TestMemoryStore_ApplyFlagBatch(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "keep", Enabled: true, Tags: []string{"promo"}, Env: "prod"})
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "drop", Enabled: true, Env: "prod"})
+
+	err := store.ApplyFlagBatch(ctx, FlagBatch{
+		Upserts: []UpsertParams{{Key: "keep", Enabled: false, Tags: []string{"promo"}, Archived: true, Env: "prod"}},
+		Deletes: []FlagRef{{Key: "drop", Env: "prod"}, {Key: "missing", Env: "prod"}},
+	})
+	if err != nil {
+		t.Fatalf("ApplyFlagBatch failed: %v", err)
+	}
+
+	keep, err := store.GetFlagByKey(ctx, "keep", "prod")
+	if err != nil || keep.Enabled || !keep.Archived || len(keep.Tags) != 1 {
+		t.Errorf("unexpected flag after batch: %+v, %v", keep, err)
+	}
+	if _, err := store.GetFlagByKey(ctx, "drop", "prod"); err == nil {
+		t.Error("drop should be deleted")
+	}
+}
+
+func TestMemoryStore_ChangeSets(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	cs, err := store.CreateChangeSet(ctx, CreateChangeSetParams{Name: "launch", Env: "prod", Operations: json.RawMessage("[]")})
+	if err != nil {
+		t.Fatalf("CreateChangeSet failed: %v", err)
+	}
+	if cs.ID == "" || cs.Status != ChangeSetOpen {
+		t.Fatalf("unexpected change set: %+v", cs)
+	}
+
+	ops := json.RawMessage(`[{"action":"delete","key":"old"}]`)
+	if _, err := store.UpdateChangeSetOperations(ctx, cs.ID, ops); err != nil {
+		t.Fatalf("UpdateChangeSetOperations failed: %v", err)
+	}
+	if got, err := store.GetChangeSet(ctx, cs.ID); err != nil || string(got.Operations) != string(ops) {
+		t.Fatalf("GetChangeSet: got %+v, %v", got, err)
+	}
+	if sets, _ := store.ListChangeSets(ctx, "prod", ChangeSetApplied); len(sets) != 0 {
+		t.Errorf("expected no applied change sets, got %d", len(sets))
+	}
+
+	closed, err := store.CloseChangeSet(ctx, cs.ID, ChangeSetApplied, "admin")
+	if err != nil || closed.Status != ChangeSetApplied || closed.ClosedAt == nil {
+		t.Fatalf("CloseChangeSet: got %+v, %v", closed, err)
+	}
+	if _, err := store.UpdateChangeSetOperations(ctx, cs.ID, ops); !errors.Is(err, ErrChangeSetClosed) {
+		t.Errorf("expected ErrChangeSetClosed, got %v", err)
+	}
+	if _, err := store.CloseChangeSet(ctx, cs.ID, ChangeSetDiscarded, "admin"); !errors.Is(err, ErrChangeSetClosed) {
+		t.Errorf("expected ErrChangeSetClosed, got %v", err)
+	}
+	if _, err := store.GetChangeSet(ctx, "missing"); !errors.Is(err, ErrChangeSetNotFound) {
+		t.Errorf("expected ErrChangeSetNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_PartialReads(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	for _, key := range []string{"b", "a", "c"} {
+		if err := store.UpsertFlag(ctx, UpsertParams{Key: key, Env: "prod"}); err != nil {
+			t.Fatalf("UpsertFlag failed: %v", err)
+		}
+	}
+	if err := store.UpsertFlag(ctx, UpsertParams{Key: "a", Env: "staging"}); err != nil {
+		t.Fatalf("UpsertFlag failed: %v", err)
+	}
+
+	flags, err := store.GetFlagsByKeys(ctx, "prod", []string{"c", "a", "missing", "a"})
+	if err != nil {
+		t.Fatalf("GetFlagsByKeys failed: %v", err)
+	}
+	if len(flags) != 2 || flags[0].Key != "a" || flags[1].Key != "c" || flags[0].Env != "prod" {
+		t.Errorf("expected prod flags a and c, got %+v", flags)
+	}
+
+	since := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+	if err := store.UpsertFlag(ctx, UpsertParams{Key: "b", Env: "prod", Enabled: true}); err != nil {
+		t.Fatalf("UpsertFlag failed: %v", err)
+	}
+	updated, err := store.GetFlagsUpdatedSince(ctx, "prod", since)
+	if err != nil {
+		t.Fatalf("GetFlagsUpdatedSince failed: %v", err)
+	}
+	if len(updated) != 1 || updated[0].Key != "b" || !updated[0].Enabled {
+		t.Errorf("expected only b to be updated since %v, got %+v", since, updated)
+	}
+}
+
+func TestMemoryStore_Trash(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Enabled: true, Rollout: 40})
+	if err := store.DeleteFlag(ctx, "banner", "prod"); err != nil {
+		t.Fatalf("DeleteFlag failed: %v", err)
+	}
+	trashed, err := store.ListTrashedFlags(ctx, "prod")
+	if err != nil || len(trashed) != 1 || trashed[0].Key != "banner" || trashed[0].DeletedAt.IsZero() {
+		t.Fatalf("ListTrashedFlags: got %+v, %v", trashed, err)
+	}
+
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod"})
+	if _, err := store.RestoreFlag(ctx, "banner", "prod"); !errors.Is(err, ErrFlagExists) {
+		t.Fatalf("expected ErrFlagExists while the key is taken, got %v", err)
+	}
+	_ = store.DeleteFlag(ctx, "banner", "prod") // replaces the trashed copy
+	restored, err := store.RestoreFlag(ctx, "banner", "prod")
+	if err != nil || restored.Enabled || restored.Rollout != 0 {
+		t.Fatalf("RestoreFlag: got %+v, %v", restored, err)
+	}
+	if _, err := store.GetTrashedFlag(ctx, "banner", "prod"); !errors.Is(err, ErrTrashedFlagNotFound) {
+		t.Errorf("expected the restored flag to leave the trash, got %v", err)
+	}
+
+	_ = store.DeleteFlag(ctx, "banner", "prod")
+	if purged, _ := store.PurgeTrash(ctx, time.Now().Add(-time.Hour)); purged != 0 {
+		t.Errorf("expected nothing purged before the retention, got %d", purged)
+	}
+	if purged, _ := store.PurgeTrash(ctx, time.Now().Add(time.Second)); purged != 1 {
+		t.Errorf("expected 1 purged flag, got %d", purged)
+	}
+	if _, err := store.RestoreFlag(ctx, "banner", "prod"); !errors.Is(err, ErrTrashedFlagNotFound) {
+		t.Errorf("expected ErrTrashedFlagNotFound after purge, got %v", err)
+	}
+}
+
+func TestMemoryStore_Versions(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	version := func(v int64) *int64 { return &v }
+
+	if err := store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", ExpectedVersion: version(1)}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict for a missing flag, got %v", err)
+	}
+	if err := store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", ExpectedVersion: version(0)}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", ExpectedVersion: version(0)}); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict for an existing flag, got %v", err)
+	}
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Rollout: 10}) // unconditional
+	if flag, _ := store.GetFlagByKey(ctx, "banner", "prod"); flag.Version != 2 {
+		t.Fatalf("expected version 2, got %d", flag.Version)
+	}
+
+	// A batch with a stale version writes nothing.
+	err := store.ApplyFlagBatch(ctx, FlagBatch{Upserts: []UpsertParams{
+		{Key: "other", Env: "prod"},
+		{Key: "banner", Env: "prod", Rollout: 20, ExpectedVersion: version(1)},
+	}})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict from the batch, got %v", err)
+	}
+	if _, err := store.GetFlagByKey(ctx, "other", "prod"); err == nil {
+		t.Error("expected the failed batch to write nothing")
+	}
+
+	_ = store.DeleteFlag(ctx, "banner", "prod")
+	if restored, _ := store.RestoreFlag(ctx, "banner", "prod"); restored.Version != 3 {
+		t.Errorf("expected a restore to count as an update, got version %d", restored.Version)
+	}
+}
+
+func TestMemoryStore_Revisions(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Rollout: 10})
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Rollout: 20})
+	_ = store.DeleteFlag(ctx, "banner", "prod")
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Rollout: 30})
+
+	revisions, err := store.ListFlagRevisions(ctx, "banner", "prod")
+	if err != nil || len(revisions) != 3 {
+		t.Fatalf("ListFlagRevisions: got %d revisions, %v", len(revisions), err)
+	}
+	for i, want := range []int32{30, 20, 10} {
+		if revisions[i].Rollout != want || revisions[i].Version != int64(3-i) {
+			t.Errorf("revision %d: got version %d rollout %d", i, revisions[i].Version, revisions[i].Rollout)
+		}
+	}
+	if revision, err := store.GetFlagRevision(ctx, "banner", "prod", 2); err != nil || revision.Rollout != 20 {
+		t.Errorf("GetFlagRevision: got %+v, %v", revision, err)
+	}
+	if _, err := store.GetFlagRevision(ctx, "banner", "prod", 4); !errors.Is(err, ErrFlagRevisionNotFound) {
+		t.Errorf("expected ErrFlagRevisionNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_Shadows(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if _, err := store.SetShadowFlag(ctx, ShadowFlag{Key: "banner", Env: "prod"}); err == nil {
+		t.Fatal("expected an error for a flag that does not exist")
+	}
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Enabled: true, Rollout: 40})
+	shadow, err := store.SetShadowFlag(ctx, ShadowFlag{Key: "banner", Env: "prod", Enabled: true, Rollout: 80})
+	if err != nil || shadow.Rollout != 80 || shadow.TargetingRules == nil || shadow.UpdatedAt.IsZero() {
+		t.Fatalf("SetShadowFlag: got %+v, %v", shadow, err)
+	}
+	if shadows, _ := store.ListShadowFlags(ctx, "prod"); len(shadows) != 1 || shadows[0].Key != "banner" {
+		t.Fatalf("ListShadowFlags: got %+v", shadows)
+	}
+	if shadows, _ := store.ListShadowFlags(ctx, "staging"); len(shadows) != 0 {
+		t.Errorf("expected no shadows in another env, got %+v", shadows)
+	}
+
+	_ = store.DeleteFlag(ctx, "banner", "prod")
+	if _, err := store.GetShadowFlag(ctx, "banner", "prod"); !errors.Is(err, ErrShadowFlagNotFound) {
+		t.Errorf("expected deleting the flag to drop its shadow, got %v", err)
+	}
+	if err := store.DeleteShadowFlag(ctx, "banner", "prod"); !errors.Is(err, ErrShadowFlagNotFound) {
+		t.Errorf("expected ErrShadowFlagNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_Guardrails(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if _, err := store.SetGuardrail(ctx, Guardrail{Key: "banner", Env: "prod"}); err == nil {
+		t.Fatal("expected an error for a flag that does not exist")
+	}
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Enabled: true, Rollout: 40})
+	if _, err := store.SetGuardrail(ctx, Guardrail{Key: "banner", Env: "prod", MaxErrorRate: 0.05, Action: "pause"}); err != nil {
+		t.Fatalf("SetGuardrail: %v", err)
+	}
+	if guardrails, _ := store.ListGuardrails(ctx, "prod"); len(guardrails) != 1 || guardrails[0].Key != "banner" {
+		t.Fatalf("ListGuardrails: got %+v", guardrails)
+	}
+
+	now := time.Now().UTC()
+	g, err := store.SetGuardrailTripped(ctx, "banner", "prod", &now, "too many errors")
+	if err != nil || g.TrippedAt == nil || g.TripReason != "too many errors" {
+		t.Fatalf("SetGuardrailTripped: got %+v, %v", g, err)
+	}
+	g, err = store.SetGuardrail(ctx, Guardrail{Key: "banner", Env: "prod", MaxErrorRate: 0.1, Action: "pause"})
+	if err != nil || g.TrippedAt != nil || g.TripReason != "" {
+		t.Errorf("expected replacing the guardrail to clear its tripped state, got %+v, %v", g, err)
+	}
+	if _, err := store.SetGuardrailTripped(ctx, "banner", "staging", &now, ""); !errors.Is(err, ErrGuardrailNotFound) {
+		t.Errorf("expected ErrGuardrailNotFound in another env, got %v", err)
+	}
+
+	_ = store.DeleteFlag(ctx, "banner", "prod")
+	if _, err := store.GetGuardrail(ctx, "banner", "prod"); !errors.Is(err, ErrGuardrailNotFound) {
+		t.Errorf("expected deleting the flag to drop its guardrail, got %v", err)
+	}
+	if err := store.DeleteGuardrail(ctx, "banner", "prod"); !errors.Is(err, ErrGuardrailNotFound) {
+		t.Errorf("expected ErrGuardrailNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_UserOverrides(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if _, err := store.SetUserOverride(ctx, UserOverride{Key: "banner", Env: "prod", UserID: "alice"}); err == nil {
+		t.Fatal("expected an error for a flag that does not exist")
+	}
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Enabled: true, Rollout: 40})
+	past := time.Now().Add(-time.Minute)
+	if _, err := store.SetUserOverride(ctx, UserOverride{Key: "banner", Env: "prod", UserID: "alice", Enabled: true}); err != nil {
+		t.Fatalf("SetUserOverride: %v", err)
+	}
+	if _, err := store.SetUserOverride(ctx, UserOverride{Key: "banner", Env: "prod", UserID: "bob", ExpiresAt: &past}); err != nil {
+		t.Fatalf("SetUserOverride: %v", err)
+	}
+	if overrides, _ := store.ListUserOverrides(ctx, "prod"); len(overrides) != 1 || overrides[0].UserID != "alice" {
+		t.Fatalf("expected only the unexpired override, got %+v", overrides)
+	}
+	if deleted, _ := store.DeleteExpiredUserOverrides(ctx, time.Now()); deleted != 1 {
+		t.Errorf("expected 1 expired override to be deleted, got %d", deleted)
+	}
+
+	_ = store.DeleteFlag(ctx, "banner", "prod")
+	if err := store.DeleteUserOverride(ctx, "banner", "prod", "alice"); !errors.Is(err, ErrUserOverrideNotFound) {
+		t.Errorf("expected deleting the flag to drop its overrides, got %v", err)
+	}
+}
+
+func TestMemoryStore_ContextPresets(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	attrs := map[string]any{"id": "qa-1", "plan": "premium"}
+	if _, err := store.PutContextPreset(ctx, ContextPreset{Name: "qa-premium", Attributes: attrs}); err != nil {
+		t.Fatalf("PutContextPreset: %v", err)
+	}
+	attrs["plan"] = "free" // the stored preset must not change
+	preset, err := store.GetContextPreset(ctx, "qa-premium")
+	if err != nil || preset.Attributes["plan"] != "premium" || preset.UpdatedAt.IsZero() {
+		t.Fatalf("GetContextPreset: %+v, %v", preset, err)
+	}
+
+	if err := store.DeleteContextPreset(ctx, "qa-premium"); err != nil {
+		t.Fatalf("DeleteContextPreset: %v", err)
+	}
+	if _, err := store.GetContextPreset(ctx, "qa-premium"); !errors.Is(err, ErrContextPresetNotFound) {
+		t.Errorf("expected ErrContextPresetNotFound, got %v", err)
+	}
+	if err := store.DeleteContextPreset(ctx, "qa-premium"); !errors.Is(err, ErrContextPresetNotFound) {
+		t.Errorf("expected ErrContextPresetNotFound, got %v", err)
+	}
+}
+
+func TestMemoryStore_FlagEvaluations(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	_ = store.UpsertFlag(ctx, UpsertParams{Key: "banner", Env: "prod", Enabled: true, Rollout: 40})
+
+	now := time.Now().UTC()
+	if err := store.RecordFlagEvaluations(ctx, "prod", map[string]time.Time{"banner": now, "missing": now}); err != nil {
+		t.Fatalf("RecordFlagEvaluations: %v", err)
+	}
+	_ = store.RecordFlagEvaluations(ctx, "prod", map[string]time.Time{"banner": now.Add(-time.Hour)})
+	got, err := store.ListFlagEvaluations(ctx, "prod")
+	if err != nil || len(got) != 1 || !got["banner"].Equal(now) {
+		t.Fatalf("expected only banner at %v, got %v, %v", now, got, err)
+	}
+	if got, _ := store.ListFlagEvaluations(ctx, "staging"); len(got) != 0 {
+		t.Errorf("expected no evaluations in staging, got %v", got)
+	}
+
+	_ = store.DeleteFlag(ctx, "banner", "prod")
+	if got, _ := store.ListFlagEvaluations(ctx, "prod"); len(got) != 0 {
+		t.Errorf("expected deleting the flag to drop its evaluation time, got %v", got)
+	}
+}
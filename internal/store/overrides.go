@@ -0,0 +1,193 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrUserOverrideNotFound is returned when a user has no override for a flag.
+var ErrUserOverrideNotFound = errors.New("user override not found")
+
+// UserOverride forces the result of a flag for one user, e.g. to put a
+// tester in the treatment group. It expires at ExpiresAt, if set.
+type UserOverride struct {
+	Key       string     `json:"key"`
+	Env       string     `json:"env"`
+	UserID    string     `json:"user_id"`
+	Enabled   bool       `json:"enabled"`
+	Variant   string     `json:"variant,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+// Expired reports whether the override has expired at now.
+func (o UserOverride) Expired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// FlagUserOverrideStore is implemented by stores that keep per-user flag
+// overrides, at most one per flag and user. Deleting a flag deletes its
+// overrides. Both MemoryStore and PostgresStore implement it.
+type FlagUserOverrideStore interface {
+	// ListUserOverrides returns the unexpired overrides of env, ordered by
+	// flag key and user ID.
+	ListUserOverrides(ctx context.Context, env string) ([]UserOverride, error)
+
+	// SetUserOverride creates or replaces the override of a user for an
+	// existing flag and returns it as stored.
+	SetUserOverride(ctx context.Context, o UserOverride) (*UserOverride, error)
+
+	// DeleteUserOverride returns ErrUserOverrideNotFound if the user has no
+	// override for key in env.
+	DeleteUserOverride(ctx context.Context, key, env, userID string) error
+
+	// DeleteExpiredUserOverrides removes the overrides expired at now and
+	// returns how many were removed.
+	DeleteExpiredUserOverrides(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ListUserOverrides returns the unexpired overrides of env.
+func (m *MemoryStore) ListUserOverrides(ctx context.Context, env string) ([]UserOverride, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	result := make([]UserOverride, 0)
+	for _, users := range m.userOverrides {
+		for _, o := range users {
+			if o.Env == env && !o.Expired(now) {
+				result = append(result, o)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Key != result[j].Key {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
+
+// SetUserOverride creates or replaces the override of a user.
+func (m *MemoryStore) SetUserOverride(ctx context.Context, o UserOverride) (*UserOverride, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(o.Key, o.Env)
+	if _, ok := m.flags[id]; !ok {
+		return nil, errors.New("flag not found")
+	}
+	if m.userOverrides[id] == nil {
+		m.userOverrides[id] = make(map[string]UserOverride)
+	}
+	o.UpdatedAt = time.Now().UTC()
+	m.userOverrides[id][o.UserID] = o
+	return &o, nil
+}
+
+// DeleteUserOverride deletes the override of a user.
+func (m *MemoryStore) DeleteUserOverride(ctx context.Context, key, env, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(key, env)
+	if _, ok := m.userOverrides[id][userID]; !ok {
+		return ErrUserOverrideNotFound
+	}
+	delete(m.userOverrides[id], userID)
+	return nil
+}
+
+// DeleteExpiredUserOverrides removes the overrides expired at now.
+func (m *MemoryStore) DeleteExpiredUserOverrides(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for _, users := range m.userOverrides {
+		for userID, o := range users {
+			if o.Expired(now) {
+				delete(users, userID)
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// ListUserOverrides returns the unexpired overrides of env.
+func (p *PostgresStore) ListUserOverrides(ctx context.Context, env string) ([]UserOverride, error) {
+	rows, err := p.q.ListUserOverrides(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]UserOverride, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, userOverrideFromDB(row))
+	}
+	return result, nil
+}
+
+// SetUserOverride creates or replaces the override of a user; the foreign
+// key on flags rejects flags that do not exist.
+func (p *PostgresStore) SetUserOverride(ctx context.Context, o UserOverride) (*UserOverride, error) {
+	var expiresAt pgtype.Timestamptz
+	if o.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *o.ExpiresAt, Valid: true}
+	}
+	row, err := p.q.UpsertUserOverride(ctx, dbgen.UpsertUserOverrideParams{
+		Key:       o.Key,
+		Env:       o.Env,
+		UserID:    o.UserID,
+		Enabled:   o.Enabled,
+		Variant:   o.Variant,
+		ExpiresAt: expiresAt,
+		UpdatedBy: o.UpdatedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	stored := userOverrideFromDB(row)
+	return &stored, nil
+}
+
+// DeleteUserOverride deletes the override of a user.
+func (p *PostgresStore) DeleteUserOverride(ctx context.Context, key, env, userID string) error {
+	deleted, err := p.q.DeleteUserOverride(ctx, dbgen.DeleteUserOverrideParams{Key: key, Env: env, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrUserOverrideNotFound
+	}
+	return nil
+}
+
+// DeleteExpiredUserOverrides removes the overrides expired at now.
+func (p *PostgresStore) DeleteExpiredUserOverrides(ctx context.Context, now time.Time) (int64, error) {
+	return p.q.DeleteExpiredUserOverrides(ctx, pgtype.Timestamptz{Time: now, Valid: true})
+}
+
+func userOverrideFromDB(row dbgen.FlagUserOverride) UserOverride {
+	o := UserOverride{
+		Key:       row.Key,
+		Env:       row.Env,
+		UserID:    row.UserID,
+		Enabled:   row.Enabled,
+		Variant:   row.Variant,
+		UpdatedAt: row.UpdatedAt.Time,
+		UpdatedBy: row.UpdatedBy,
+	}
+	if row.ExpiresAt.Valid {
+		expiresAt := row.ExpiresAt.Time
+		o.ExpiresAt = &expiresAt
+	}
+	return o
+}
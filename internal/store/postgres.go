@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	mydb "github.com/TimurManjosov/goflagship/internal/db"
 	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
 	"github.com/TimurManjosov/goflagship/internal/rules"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -41,16 +44,26 @@ const (
 type PostgresStore struct {
 	pool *pgxpool.Pool
 	q    *dbgen.Queries
+
+	keyring       *encryption.Keyring // see SetEncryption
+	encryptConfig bool
+
+	replica *replica // heavy reads, see replica.go
 }
 
 // NewPostgresStore creates a new PostgreSQL-backed store.
 func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
 	return &PostgresStore{
 		pool: pool,
-		q:    dbgen.New(pool),
+		q:    dbgen.New(mydb.Instrument(pool)), // per-query metrics, see db.Instrument
 	}
 }
 
+// PoolStat returns the current connection pool statistics.
+func (p *PostgresStore) PoolStat() *pgxpool.Stat {
+	return p.pool.Stat()
+}
+
 // GetAllFlags retrieves all flags for the given environment from the database.
 //
 // Preconditions:
@@ -73,7 +86,7 @@ func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
 //	Pre-allocates result slice with capacity = query result size.
 //	Converts all rows to domain objects before returning.
 func (p *PostgresStore) GetAllFlags(ctx context.Context, env string) ([]Flag, error) {
-	dbFlags, err := p.q.GetAllFlags(ctx, env)
+	dbFlags, err := p.readQueries(ctx).GetAllFlags(ctx, env)
 	if err != nil {
 		return nil, err
 	}
@@ -90,11 +103,12 @@ func (p *PostgresStore) GetAllFlags(ctx context.Context, env string) ([]Flag, er
 	return flags, nil
 }
 
-// GetFlagByKey retrieves a single flag by its key from the database.
+// GetFlagByKey retrieves a single flag by its key and environment from the database.
 //
 // Preconditions:
 //   - ctx must be non-nil (panic if nil)
 //   - key may be empty (will likely not find any flag)
+//   - env selects the environment; the same key may exist in several
 //
 // Postconditions:
 //   - Returns non-nil *Flag on success
@@ -103,15 +117,14 @@ func (p *PostgresStore) GetAllFlags(ctx context.Context, env string) ([]Flag, er
 //
 // Edge Cases:
 //   - key="": Likely returns "flag not found" (unless empty key exists in DB)
-//   - Multiple flags with same (key, env): Returns first match (DB/schema should prevent this)
-//   - Multiple environments with same key: Behavior depends on underlying query ordering and may be ambiguous
+//   - Multiple flags with same (key, env): Prevented by the unique (key, env) constraint
 //   - Flag exists but has invalid JSON config: Returns error
 //
 // Error Types:
 //   - "flag not found": Flag doesn't exist in database
 //   - Other errors: Database connectivity or data conversion errors
-func (p *PostgresStore) GetFlagByKey(ctx context.Context, key string) (*Flag, error) {
-	dbFlag, err := p.q.GetFlagByKey(ctx, key)
+func (p *PostgresStore) GetFlagByKey(ctx context.Context, key, env string) (*Flag, error) {
+	dbFlag, err := p.q.GetFlagByKey(ctx, dbgen.GetFlagByKeyParams{Key: key, Env: env})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errors.New("flag not found")
@@ -127,6 +140,45 @@ func (p *PostgresStore) GetFlagByKey(ctx context.Context, key string) (*Flag, er
 	return &flag, nil
 }
 
+// GetFlagsByKeys retrieves the flags with the given keys within env in one
+// query, sorted by key. Keys without a flag are skipped.
+func (p *PostgresStore) GetFlagsByKeys(ctx context.Context, env string, keys []string) ([]Flag, error) {
+	if len(keys) == 0 {
+		return []Flag{}, nil
+	}
+	dbFlags, err := p.q.GetFlagsByKeys(ctx, dbgen.GetFlagsByKeysParams{Env: env, Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	return p.convertAllFromDB(dbFlags)
+}
+
+// GetFlagsUpdatedSince retrieves the flags in env updated after since, oldest
+// update first. The (env, updated_at) index keeps this from scanning the table.
+func (p *PostgresStore) GetFlagsUpdatedSince(ctx context.Context, env string, since time.Time) ([]Flag, error) {
+	dbFlags, err := p.q.GetFlagsUpdatedSince(ctx, dbgen.GetFlagsUpdatedSinceParams{
+		Env:       env,
+		UpdatedAt: pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.convertAllFromDB(dbFlags)
+}
+
+// convertAllFromDB converts database rows with convertFromDB.
+func (p *PostgresStore) convertAllFromDB(dbFlags []dbgen.Flag) ([]Flag, error) {
+	flags := make([]Flag, 0, len(dbFlags))
+	for _, dbFlag := range dbFlags {
+		flag, err := p.convertFromDB(dbFlag)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
 // UpsertFlag creates or updates a flag in the database.
 //
 // Preconditions:
@@ -149,33 +201,98 @@ func (p *PostgresStore) GetFlagByKey(ctx context.Context, key string) (*Flag, er
 // Edge Cases:
 //   - params.Config=nil: Stored as {} (empty JSON object)
 //   - params.Config={}: Stored as {} (empty JSON object)
+//   - params.ConfigJSON set: Stored as given, params.Config is not marshaled
 //   - params.Description="": Stored as empty string
 //   - params.Expression=nil: Stored as NULL in database
 //   - Invalid JSON in Config: Returns JSON marshaling error
 //   - Key/Env combination exists: Updates existing flag
+//   - params.ExpectedVersion set and not matching: Returns ErrVersionConflict
 //
 // Database Constraints:
 //
-//	Primary key: (key, env) - ensures uniqueness per environment
+//	Unique key: (key, env) - ensures uniqueness per environment
 func (p *PostgresStore) UpsertFlag(ctx context.Context, params UpsertParams) error {
-	// Convert config map to JSON bytes
+	return p.upsertFlag(ctx, p.q, params)
+}
+
+// upsertFlag writes params with q. With params.ExpectedVersion set, the row is
+// only inserted (expected version 0) or updated at that version, in the same
+// statement, and ErrVersionConflict is returned if nothing was written.
+func (p *PostgresStore) upsertFlag(ctx context.Context, q *dbgen.Queries, params UpsertParams) error {
+	dbParams, err := p.toUpsertFlagParams(params)
+	if err != nil {
+		return err
+	}
+	if params.ExpectedVersion == nil {
+		return q.UpsertFlag(ctx, dbParams)
+	}
+
+	var written int64
+	if *params.ExpectedVersion == 0 {
+		written, err = q.InsertFlag(ctx, dbgen.InsertFlagParams(dbParams))
+	} else {
+		written, err = q.UpdateFlagIfVersion(ctx, dbgen.UpdateFlagIfVersionParams{
+			Key:             dbParams.Key,
+			Description:     dbParams.Description,
+			Enabled:         dbParams.Enabled,
+			Rollout:         dbParams.Rollout,
+			Expression:      dbParams.Expression,
+			Config:          dbParams.Config,
+			TargetingRules:  dbParams.TargetingRules,
+			Env:             dbParams.Env,
+			Protected:       dbParams.Protected,
+			Tags:            dbParams.Tags,
+			Archived:        dbParams.Archived,
+			Owner:           dbParams.Owner,
+			TicketUrl:       dbParams.TicketUrl,
+			Metadata:        dbParams.Metadata,
+			ExpiresAt:       dbParams.ExpiresAt,
+			Project:         dbParams.Project,
+			ExpectedVersion: *params.ExpectedVersion,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if written == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// toUpsertFlagParams converts params into the sqlc query parameters,
+// marshaling config (unless params.ConfigJSON is set) and targeting rules to
+// JSON and encrypting the config if configured (see SetEncryption).
+func (p *PostgresStore) toUpsertFlagParams(params UpsertParams) (dbgen.UpsertFlagParams, error) {
+	// Convert config map to JSON bytes, unless the caller already did
 	var configBytes []byte
-	if params.Config != nil {
+	switch {
+	case len(params.ConfigJSON) > 0:
+		configBytes = params.ConfigJSON
+	case params.Config != nil:
 		b, err := json.Marshal(params.Config)
 		if err != nil {
-			return err
+			return dbgen.UpsertFlagParams{}, err
 		}
 		configBytes = b
-	} else {
+	default:
 		configBytes = []byte(emptyJSONObject)
 	}
+	configBytes, err := p.encodeConfig(configBytes)
+	if err != nil {
+		return dbgen.UpsertFlagParams{}, err
+	}
 
 	targetingRulesBytes, err := json.Marshal(ensureRulesInitialized(params.TargetingRules))
 	if err != nil {
-		return fmt.Errorf("marshal targeting rules: %w", err)
+		return dbgen.UpsertFlagParams{}, fmt.Errorf("marshal targeting rules: %w", err)
+	}
+	metadataBytes, err := marshalMetadata(params.Metadata)
+	if err != nil {
+		return dbgen.UpsertFlagParams{}, fmt.Errorf("marshal metadata: %w", err)
 	}
 
-	dbParams := dbgen.UpsertFlagParams{
+	return dbgen.UpsertFlagParams{
 		Key:            params.Key,
 		Description:    pgtype.Text{String: params.Description, Valid: true},
 		Enabled:        params.Enabled,
@@ -184,9 +301,15 @@ func (p *PostgresStore) UpsertFlag(ctx context.Context, params UpsertParams) err
 		Config:         configBytes,
 		TargetingRules: targetingRulesBytes,
 		Env:            params.Env,
-	}
-
-	return p.q.UpsertFlag(ctx, dbParams)
+		Protected:      params.Protected,
+		Tags:           ensureTagsInitialized(params.Tags),
+		Archived:       params.Archived,
+		Owner:          params.Owner,
+		TicketUrl:      params.TicketURL,
+		Metadata:       metadataBytes,
+		ExpiresAt:      optionalTimestamptz(params.ExpiresAt),
+		Project:        projectOrDefault(params.Project),
+	}, nil
 }
 
 // DeleteFlag removes a flag from the database.
@@ -197,6 +320,7 @@ func (p *PostgresStore) UpsertFlag(ctx context.Context, params UpsertParams) err
 //
 // Postconditions:
 //   - Returns nil on success (flag deleted or didn't exist)
+//   - The deleted flag is moved to flag_trash in the same statement (see FlagTrashStore)
 //   - Idempotent: deleting non-existent flag is not an error
 //
 // Edge Cases:
@@ -237,6 +361,9 @@ func (p *PostgresStore) DeleteFlag(ctx context.Context, key, env string) error {
 // Always returns nil (implements io.Closer interface convention).
 func (p *PostgresStore) Close() error {
 	p.pool.Close()
+	if p.replica != nil {
+		p.replica.pool.Close()
+	}
 	return nil
 }
 
@@ -252,6 +379,41 @@ func (p *PostgresStore) CreateAPIKey(ctx context.Context, params dbgen.CreateAPI
 	return p.q.CreateAPIKey(ctx, params)
 }
 
+// ErrAPIKeysExist is returned by CreateFirstAPIKey if the database already
+// has API keys.
+var ErrAPIKeysExist = errors.New("api keys already exist")
+
+// CreateFirstAPIKey creates an API key only if there is none yet, or returns
+// ErrAPIKeysExist. Concurrent calls, e.g. from the setup of several replicas,
+// are serialized by an advisory lock, so at most one of them creates a key.
+func (p *PostgresStore) CreateFirstAPIKey(ctx context.Context, params dbgen.CreateAPIKeyParams) (dbgen.ApiKey, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return dbgen.ApiKey{}, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after Commit
+
+	q := dbgen.New(mydb.Instrument(tx))
+	if err := q.LockAPIKeySetup(ctx); err != nil {
+		return dbgen.ApiKey{}, err
+	}
+	count, err := q.CountAPIKeys(ctx)
+	if err != nil {
+		return dbgen.ApiKey{}, err
+	}
+	if count > 0 {
+		return dbgen.ApiKey{}, ErrAPIKeysExist
+	}
+	apiKey, err := q.CreateAPIKey(ctx, params)
+	if err != nil {
+		return dbgen.ApiKey{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return dbgen.ApiKey{}, err
+	}
+	return apiKey, nil
+}
+
 // GetAPIKeyByID retrieves an API key by its ID
 func (p *PostgresStore) GetAPIKeyByID(ctx context.Context, id pgtype.UUID) (dbgen.ApiKey, error) {
 	return p.q.GetAPIKeyByID(ctx, id)
@@ -267,11 +429,390 @@ func (p *PostgresStore) RevokeAPIKey(ctx context.Context, id pgtype.UUID) error
 	return p.q.RevokeAPIKey(ctx, id)
 }
 
+// DisableAPIKey disables an enabled API key, recording why. It reports
+// whether the key was enabled.
+func (p *PostgresStore) DisableAPIKey(ctx context.Context, id pgtype.UUID, reason string) (bool, error) {
+	n, err := p.q.DisableAPIKey(ctx, dbgen.DisableAPIKeyParams{ID: id, DisabledReason: reason})
+	return n > 0, err
+}
+
+// ReenableAPIKey enables an API key that was disabled for reason at or after
+// since, and counts that as a use. It reports whether such a key was found.
+func (p *PostgresStore) ReenableAPIKey(ctx context.Context, id pgtype.UUID, reason string, since time.Time) (bool, error) {
+	n, err := p.q.ReenableAPIKey(ctx, dbgen.ReenableAPIKeyParams{
+		ID:             id,
+		DisabledReason: reason,
+		DisabledSince:  pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	return n > 0, err
+}
+
 // DeleteAPIKey permanently deletes an API key
 func (p *PostgresStore) DeleteAPIKey(ctx context.Context, id pgtype.UUID) error {
 	return p.q.DeleteAPIKey(ctx, id)
 }
 
+// --- SDK Keys ---
+
+// CreateSDKKey creates a new SDK key in the database
+func (p *PostgresStore) CreateSDKKey(ctx context.Context, params CreateSDKKeyParams) (SDKKey, error) {
+	row, err := p.q.CreateSDKKey(ctx, dbgen.CreateSDKKeyParams{
+		Name:      params.Name,
+		Env:       params.Env,
+		KeyHash:   params.KeyHash,
+		KeyPrefix: params.KeyPrefix,
+		CreatedBy: params.CreatedBy,
+	})
+	if err != nil {
+		return SDKKey{}, err
+	}
+	return sdkKeyFromDB(row), nil
+}
+
+// ListSDKKeys retrieves the SDK keys for env, or all keys if env is empty
+func (p *PostgresStore) ListSDKKeys(ctx context.Context, env string) ([]SDKKey, error) {
+	rows, err := p.q.ListSDKKeys(ctx, pgtype.Text{String: env, Valid: env != ""})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]SDKKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, sdkKeyFromDB(row))
+	}
+	return keys, nil
+}
+
+// GetSDKKeyByHash retrieves an SDK key by the hash of its secret
+func (p *PostgresStore) GetSDKKeyByHash(ctx context.Context, keyHash string) (*SDKKey, error) {
+	row, err := p.q.GetSDKKeyByHash(ctx, keyHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSDKKeyNotFound
+		}
+		return nil, err
+	}
+	key := sdkKeyFromDB(row)
+	return &key, nil
+}
+
+// RevokeSDKKey disables an SDK key
+func (p *PostgresStore) RevokeSDKKey(ctx context.Context, id string) error {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		return ErrSDKKeyNotFound
+	}
+	n, err := p.q.RevokeSDKKey(ctx, uuid)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSDKKeyNotFound
+	}
+	return nil
+}
+
+func sdkKeyFromDB(row dbgen.SdkKey) SDKKey {
+	return SDKKey{
+		ID:        formatDBUUID(row.ID),
+		Name:      row.Name,
+		Env:       row.Env,
+		KeyHash:   row.KeyHash,
+		KeyPrefix: row.KeyPrefix,
+		Enabled:   row.Enabled,
+		CreatedAt: row.CreatedAt.Time,
+		CreatedBy: row.CreatedBy,
+	}
+}
+
+// --- Flag Comments ---
+
+// CreateFlagComment adds a comment to a flag
+func (p *PostgresStore) CreateFlagComment(ctx context.Context, params CreateFlagCommentParams) (FlagComment, error) {
+	var auditLogID pgtype.UUID
+	if params.AuditLogID != "" {
+		if err := auditLogID.Scan(params.AuditLogID); err != nil {
+			return FlagComment{}, fmt.Errorf("invalid audit log id: %w", err)
+		}
+	}
+	row, err := p.q.CreateFlagComment(ctx, dbgen.CreateFlagCommentParams{
+		FlagKey:    params.FlagKey,
+		Env:        params.Env,
+		Body:       params.Body,
+		AuditLogID: auditLogID,
+		CreatedBy:  params.CreatedBy,
+	})
+	if err != nil {
+		return FlagComment{}, err
+	}
+	return flagCommentFromDB(row), nil
+}
+
+// ListFlagComments retrieves the comments on a flag, oldest first
+func (p *PostgresStore) ListFlagComments(ctx context.Context, key, env string) ([]FlagComment, error) {
+	rows, err := p.q.ListFlagComments(ctx, dbgen.ListFlagCommentsParams{Env: env, FlagKey: key})
+	if err != nil {
+		return nil, err
+	}
+	comments := make([]FlagComment, 0, len(rows))
+	for _, row := range rows {
+		comments = append(comments, flagCommentFromDB(row))
+	}
+	return comments, nil
+}
+
+func flagCommentFromDB(row dbgen.FlagComment) FlagComment {
+	return FlagComment{
+		ID:         formatDBUUID(row.ID),
+		FlagKey:    row.FlagKey,
+		Env:        row.Env,
+		Body:       row.Body,
+		AuditLogID: formatDBUUID(row.AuditLogID),
+		CreatedAt:  row.CreatedAt.Time,
+		CreatedBy:  row.CreatedBy,
+	}
+}
+
+// --- Context Attributes ---
+
+// ListContextAttributes retrieves the attributes declared in a project, sorted by name
+func (p *PostgresStore) ListContextAttributes(ctx context.Context, project string) ([]ContextAttribute, error) {
+	rows, err := p.q.ListContextAttributes(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make([]ContextAttribute, 0, len(rows))
+	for _, row := range rows {
+		attrs = append(attrs, contextAttributeFromDB(row))
+	}
+	return attrs, nil
+}
+
+// PutContextAttribute creates or replaces an attribute declaration
+func (p *PostgresStore) PutContextAttribute(ctx context.Context, attr ContextAttribute) (ContextAttribute, error) {
+	var example []byte
+	if attr.Example != nil {
+		var err error
+		if example, err = json.Marshal(attr.Example); err != nil {
+			return ContextAttribute{}, fmt.Errorf("marshal example: %w", err)
+		}
+	}
+	row, err := p.q.UpsertContextAttribute(ctx, dbgen.UpsertContextAttributeParams{
+		Project:     attr.Project,
+		Name:        attr.Name,
+		Type:        attr.Type,
+		Description: attr.Description,
+		Example:     example,
+		UpdatedBy:   attr.UpdatedBy,
+	})
+	if err != nil {
+		return ContextAttribute{}, err
+	}
+	return contextAttributeFromDB(row), nil
+}
+
+// DeleteContextAttribute removes an attribute declaration
+func (p *PostgresStore) DeleteContextAttribute(ctx context.Context, project, name string) error {
+	n, err := p.q.DeleteContextAttribute(ctx, dbgen.DeleteContextAttributeParams{Project: project, Name: name})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrContextAttributeNotFound
+	}
+	return nil
+}
+
+func contextAttributeFromDB(row dbgen.ContextAttribute) ContextAttribute {
+	attr := ContextAttribute{
+		Project:     row.Project,
+		Name:        row.Name,
+		Type:        row.Type,
+		Description: row.Description,
+		UpdatedAt:   row.UpdatedAt.Time,
+		UpdatedBy:   row.UpdatedBy,
+	}
+	if len(row.Example) > 0 {
+		_ = json.Unmarshal(row.Example, &attr.Example)
+	}
+	return attr
+}
+
+// --- Change Sets ---
+
+// CreateChangeSet stores a new, open change set
+func (p *PostgresStore) CreateChangeSet(ctx context.Context, params CreateChangeSetParams) (ChangeSet, error) {
+	operations := []byte(params.Operations)
+	if len(operations) == 0 {
+		operations = []byte("[]")
+	}
+	row, err := p.q.CreateChangeSet(ctx, dbgen.CreateChangeSetParams{
+		Name:        params.Name,
+		Description: params.Description,
+		Env:         params.Env,
+		Operations:  operations,
+		CreatedBy:   params.CreatedBy,
+	})
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	return changeSetFromDB(row), nil
+}
+
+// GetChangeSet retrieves a change set by ID
+func (p *PostgresStore) GetChangeSet(ctx context.Context, id string) (*ChangeSet, error) {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		return nil, ErrChangeSetNotFound
+	}
+	row, err := p.q.GetChangeSet(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrChangeSetNotFound
+		}
+		return nil, err
+	}
+	cs := changeSetFromDB(row)
+	return &cs, nil
+}
+
+// ListChangeSets retrieves the change sets matching env and status, newest first
+func (p *PostgresStore) ListChangeSets(ctx context.Context, env, status string) ([]ChangeSet, error) {
+	rows, err := p.q.ListChangeSets(ctx, dbgen.ListChangeSetsParams{
+		Env:    pgtype.Text{String: env, Valid: env != ""},
+		Status: pgtype.Text{String: status, Valid: status != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sets := make([]ChangeSet, 0, len(rows))
+	for _, row := range rows {
+		sets = append(sets, changeSetFromDB(row))
+	}
+	return sets, nil
+}
+
+// UpdateChangeSetOperations replaces the operations of an open change set
+func (p *PostgresStore) UpdateChangeSetOperations(ctx context.Context, id string, operations json.RawMessage) (ChangeSet, error) {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		return ChangeSet{}, ErrChangeSetNotFound
+	}
+	row, err := p.q.UpdateChangeSetOperations(ctx, dbgen.UpdateChangeSetOperationsParams{ID: uuid, Operations: operations})
+	if err != nil {
+		return ChangeSet{}, p.changeSetUpdateError(ctx, id, err)
+	}
+	return changeSetFromDB(row), nil
+}
+
+// CloseChangeSet moves an open change set to status
+func (p *PostgresStore) CloseChangeSet(ctx context.Context, id, status, closedBy string) (ChangeSet, error) {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		return ChangeSet{}, ErrChangeSetNotFound
+	}
+	row, err := p.q.CloseChangeSet(ctx, dbgen.CloseChangeSetParams{ID: uuid, Status: status, ClosedBy: closedBy})
+	if err != nil {
+		return ChangeSet{}, p.changeSetUpdateError(ctx, id, err)
+	}
+	return changeSetFromDB(row), nil
+}
+
+// changeSetUpdateError tells a missing change set from a closed one when an
+// update of open change sets matched no row.
+func (p *PostgresStore) changeSetUpdateError(ctx context.Context, id string, err error) error {
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if _, err := p.GetChangeSet(ctx, id); err != nil {
+		return err
+	}
+	return ErrChangeSetClosed
+}
+
+func changeSetFromDB(row dbgen.ChangeSet) ChangeSet {
+	cs := ChangeSet{
+		ID:          formatDBUUID(row.ID),
+		Name:        row.Name,
+		Description: row.Description,
+		Env:         row.Env,
+		Operations:  json.RawMessage(row.Operations),
+		Status:      row.Status,
+		CreatedAt:   row.CreatedAt.Time,
+		CreatedBy:   row.CreatedBy,
+		UpdatedAt:   row.UpdatedAt.Time,
+		ClosedBy:    row.ClosedBy,
+	}
+	if row.ClosedAt.Valid {
+		closedAt := row.ClosedAt.Time
+		cs.ClosedAt = &closedAt
+	}
+	return cs
+}
+
+// formatDBUUID formats a UUID column as a string, or "" if it is NULL.
+func formatDBUUID(id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+	b := id.Bytes
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// --- Ephemeral Environments ---
+
+// UpsertEphemeralEnvironment registers an ephemeral environment or updates its expiry
+func (p *PostgresStore) UpsertEphemeralEnvironment(ctx context.Context, env EphemeralEnvironment) (EphemeralEnvironment, error) {
+	row, err := p.q.UpsertEphemeralEnvironment(ctx, dbgen.UpsertEphemeralEnvironmentParams{
+		Name:      env.Name,
+		ExpiresAt: pgtype.Timestamptz{Time: env.ExpiresAt, Valid: true},
+		CreatedBy: env.CreatedBy,
+	})
+	if err != nil {
+		return EphemeralEnvironment{}, err
+	}
+	return ephemeralEnvironmentFromDB(row), nil
+}
+
+// ListEphemeralEnvironments retrieves all ephemeral environments, soonest expiry first
+func (p *PostgresStore) ListEphemeralEnvironments(ctx context.Context) ([]EphemeralEnvironment, error) {
+	rows, err := p.q.ListEphemeralEnvironments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ephemeralEnvironmentsFromDB(rows), nil
+}
+
+// ListExpiredEphemeralEnvironments retrieves the ephemeral environments that expired at or before now
+func (p *PostgresStore) ListExpiredEphemeralEnvironments(ctx context.Context, now time.Time) ([]EphemeralEnvironment, error) {
+	rows, err := p.q.ListExpiredEphemeralEnvironments(ctx, pgtype.Timestamptz{Time: now, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return ephemeralEnvironmentsFromDB(rows), nil
+}
+
+// DeleteEphemeralEnvironment unregisters an ephemeral environment
+func (p *PostgresStore) DeleteEphemeralEnvironment(ctx context.Context, name string) error {
+	return p.q.DeleteEphemeralEnvironment(ctx, name)
+}
+
+func ephemeralEnvironmentsFromDB(rows []dbgen.EphemeralEnvironment) []EphemeralEnvironment {
+	envs := make([]EphemeralEnvironment, 0, len(rows))
+	for _, row := range rows {
+		envs = append(envs, ephemeralEnvironmentFromDB(row))
+	}
+	return envs
+}
+
+func ephemeralEnvironmentFromDB(row dbgen.EphemeralEnvironment) EphemeralEnvironment {
+	return EphemeralEnvironment{
+		Name:      row.Name,
+		ExpiresAt: row.ExpiresAt.Time,
+		CreatedAt: row.CreatedAt.Time,
+		CreatedBy: row.CreatedBy,
+	}
+}
+
 // --- Audit Logs ---
 
 // CreateAuditLog creates a new audit log entry
@@ -281,17 +822,28 @@ func (p *PostgresStore) CreateAuditLog(ctx context.Context, params dbgen.CreateA
 
 // ListAuditLogs retrieves audit logs with pagination and filtering
 func (p *PostgresStore) ListAuditLogs(ctx context.Context, params dbgen.ListAuditLogsParams) ([]dbgen.AuditLog, error) {
-	return p.q.ListAuditLogs(ctx, params)
+	return p.readQueries(ctx).ListAuditLogs(ctx, params)
+}
+
+// GetAuditLog retrieves a single audit log entry by ID
+func (p *PostgresStore) GetAuditLog(ctx context.Context, id pgtype.UUID) (dbgen.AuditLog, error) {
+	return p.readQueries(ctx).GetAuditLog(ctx, id)
 }
 
 // CountAuditLogs returns the total count of audit logs with filtering
 func (p *PostgresStore) CountAuditLogs(ctx context.Context, params dbgen.CountAuditLogsParams) (int64, error) {
-	return p.q.CountAuditLogs(ctx, params)
+	return p.readQueries(ctx).CountAuditLogs(ctx, params)
+}
+
+// SearchAuditLogs retrieves the newest audit logs whose resource, action or
+// user mentions params.Query
+func (p *PostgresStore) SearchAuditLogs(ctx context.Context, params dbgen.SearchAuditLogsParams) ([]dbgen.AuditLog, error) {
+	return p.readQueries(ctx).SearchAuditLogs(ctx, params)
 }
 
 // GetAuditLogsByAPIKey retrieves audit logs for a specific API key
 func (p *PostgresStore) GetAuditLogsByAPIKey(ctx context.Context, apiKeyID pgtype.UUID, limit, offset int32) ([]dbgen.AuditLog, error) {
-	return p.q.GetAuditLogsByAPIKey(ctx, dbgen.GetAuditLogsByAPIKeyParams{
+	return p.readQueries(ctx).GetAuditLogsByAPIKey(ctx, dbgen.GetAuditLogsByAPIKeyParams{
 		ApiKeyID: apiKeyID,
 		Limit:    limit,
 		Offset:   offset,
@@ -305,12 +857,19 @@ func (p *PostgresStore) GetQueries() *dbgen.Queries {
 
 // convertFromDB converts a database Flag to a store Flag.
 func (p *PostgresStore) convertFromDB(dbFlag dbgen.Flag) (Flag, error) {
+	configBytes, err := p.decodeConfig(dbFlag.Config)
+	if err != nil {
+		return Flag{}, err
+	}
 	var config map[string]any
-	if len(dbFlag.Config) > 0 {
-		if err := json.Unmarshal(dbFlag.Config, &config); err != nil {
+	if len(configBytes) > 0 {
+		if err := json.Unmarshal(configBytes, &config); err != nil {
 			return Flag{}, err
 		}
 	}
+	if config == nil {
+		configBytes = nil // a JSON null config is written back as {}
+	}
 
 	description := ""
 	if dbFlag.Description.Valid {
@@ -321,6 +880,15 @@ func (p *PostgresStore) convertFromDB(dbFlag dbgen.Flag) (Flag, error) {
 	if err != nil {
 		return Flag{}, fmt.Errorf("unmarshal targeting rules: %w", err)
 	}
+	var metadata map[string]string
+	if len(dbFlag.Metadata) > 0 {
+		if err := json.Unmarshal(dbFlag.Metadata, &metadata); err != nil {
+			return Flag{}, fmt.Errorf("unmarshal metadata: %w", err)
+		}
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
 
 	return Flag{
 		Key:            dbFlag.Key,
@@ -329,9 +897,19 @@ func (p *PostgresStore) convertFromDB(dbFlag dbgen.Flag) (Flag, error) {
 		Rollout:        dbFlag.Rollout,
 		Expression:     dbFlag.Expression,
 		Config:         config,
+		ConfigJSON:     configBytes,
 		TargetingRules: targetingRules,
+		Protected:      dbFlag.Protected,
+		Tags:           dbFlag.Tags,
+		Archived:       dbFlag.Archived,
+		Owner:          dbFlag.Owner,
+		TicketURL:      dbFlag.TicketUrl,
+		Metadata:       metadata,
+		ExpiresAt:      optionalTime(dbFlag.ExpiresAt),
+		Project:        dbFlag.Project,
 		Env:            dbFlag.Env,
 		UpdatedAt:      dbFlag.UpdatedAt.Time,
+		Version:        dbFlag.Version,
 	}, nil
 }
 
@@ -343,6 +921,40 @@ func ensureRulesInitialized(rs []rules.Rule) []rules.Rule {
 	return rs
 }
 
+// ensureTagsInitialized maps nil to an empty slice for the NOT NULL tags column.
+func ensureTagsInitialized(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// marshalMetadata encodes metadata for the NOT NULL metadata column, mapping
+// nil to {}.
+func marshalMetadata(metadata map[string]string) ([]byte, error) {
+	if metadata == nil {
+		return []byte(emptyJSONObject), nil
+	}
+	return json.Marshal(metadata)
+}
+
+// optionalTimestamptz converts t for a nullable timestamp column.
+func optionalTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+// optionalTime reverses optionalTimestamptz.
+func optionalTime(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time.UTC()
+	return &t
+}
+
 func unmarshalTargetingRules(raw json.RawMessage) ([]rules.Rule, error) {
 	if len(raw) == 0 || string(raw) == "null" {
 		return make([]rules.Rule, 0), nil
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToUpsertFlagParams_Config(t *testing.T) {
+	p := &PostgresStore{}
+	tests := []struct {
+		name   string
+		params UpsertParams
+		want   string
+	}{
+		{name: "nil config", params: UpsertParams{Key: "f", Env: "prod"}, want: `{}`},
+		{name: "config", params: UpsertParams{Key: "f", Env: "prod", Config: map[string]any{"b": 2, "a": 1}}, want: `{"a":1,"b":2}`},
+		{
+			name:   "encoded config is written as given",
+			params: UpsertParams{Key: "f", Env: "prod", Config: map[string]any{"a": 1}, ConfigJSON: json.RawMessage(`{"a": 1}`)},
+			want:   `{"a": 1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.toUpsertFlagParams(tt.params)
+			if err != nil {
+				t.Fatalf("toUpsertFlagParams: %v", err)
+			}
+			if string(got.Config) != tt.want {
+				t.Errorf("config = %s, want %s", got.Config, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+// ErrContextPresetNotFound is returned when a context preset does not exist.
+var ErrContextPresetNotFound = errors.New("context preset not found")
+
+// ContextPreset is a named evaluation context, e.g. "qa-premium-us", so
+// testers can evaluate flags for a known attribute set by name. Attributes
+// holds the context attributes as in a request, the user ID as "id".
+type ContextPreset struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Attributes  map[string]any `json:"attributes"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	UpdatedBy   string         `json:"updated_by"`
+}
+
+// ContextPresetStore is implemented by stores that can persist context
+// presets. Both MemoryStore and PostgresStore implement it.
+type ContextPresetStore interface {
+	// ListContextPresets returns all presets, sorted by name.
+	ListContextPresets(ctx context.Context) ([]ContextPreset, error)
+
+	// GetContextPreset returns ErrContextPresetNotFound if there is no
+	// preset named name.
+	GetContextPreset(ctx context.Context, name string) (ContextPreset, error)
+
+	// PutContextPreset creates preset, replacing an existing preset with
+	// the same name. UpdatedAt is set by the store.
+	PutContextPreset(ctx context.Context, preset ContextPreset) (ContextPreset, error)
+
+	// DeleteContextPreset returns ErrContextPresetNotFound if there is no
+	// preset named name.
+	DeleteContextPreset(ctx context.Context, name string) error
+}
+
+// ListContextPresets returns all presets, sorted by name.
+func (m *MemoryStore) ListContextPresets(ctx context.Context) ([]ContextPreset, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	presets := make([]ContextPreset, 0, len(m.contextPresets))
+	for _, preset := range m.contextPresets {
+		presets = append(presets, copyContextPreset(preset))
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+// GetContextPreset returns the preset named name.
+func (m *MemoryStore) GetContextPreset(ctx context.Context, name string) (ContextPreset, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	preset, ok := m.contextPresets[name]
+	if !ok {
+		return ContextPreset{}, ErrContextPresetNotFound
+	}
+	return copyContextPreset(preset), nil
+}
+
+// PutContextPreset creates or replaces a preset.
+func (m *MemoryStore) PutContextPreset(ctx context.Context, preset ContextPreset) (ContextPreset, error) {
+	preset = copyContextPreset(preset)
+	preset.UpdatedAt = time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contextPresets[preset.Name] = preset
+	return copyContextPreset(preset), nil
+}
+
+// DeleteContextPreset removes a preset.
+func (m *MemoryStore) DeleteContextPreset(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.contextPresets[name]; !ok {
+		return ErrContextPresetNotFound
+	}
+	delete(m.contextPresets, name)
+	return nil
+}
+
+// copyContextPreset copies the attribute map of preset, so callers cannot
+// modify a stored preset.
+func copyContextPreset(preset ContextPreset) ContextPreset {
+	preset.Attributes = maps.Clone(preset.Attributes)
+	if preset.Attributes == nil {
+		preset.Attributes = map[string]any{}
+	}
+	return preset
+}
+
+// ListContextPresets returns all presets, sorted by name.
+func (p *PostgresStore) ListContextPresets(ctx context.Context) ([]ContextPreset, error) {
+	rows, err := p.q.ListContextPresets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	presets := make([]ContextPreset, 0, len(rows))
+	for _, row := range rows {
+		presets = append(presets, contextPresetFromDB(row))
+	}
+	return presets, nil
+}
+
+// GetContextPreset returns the preset named name.
+func (p *PostgresStore) GetContextPreset(ctx context.Context, name string) (ContextPreset, error) {
+	row, err := p.q.GetContextPreset(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ContextPreset{}, ErrContextPresetNotFound
+	}
+	if err != nil {
+		return ContextPreset{}, err
+	}
+	return contextPresetFromDB(row), nil
+}
+
+// PutContextPreset creates or replaces a preset.
+func (p *PostgresStore) PutContextPreset(ctx context.Context, preset ContextPreset) (ContextPreset, error) {
+	attrs := preset.Attributes
+	if attrs == nil {
+		attrs = map[string]any{}
+	}
+	attributes, err := json.Marshal(attrs)
+	if err != nil {
+		return ContextPreset{}, fmt.Errorf("marshal attributes: %w", err)
+	}
+	row, err := p.q.UpsertContextPreset(ctx, dbgen.UpsertContextPresetParams{
+		Name:        preset.Name,
+		Description: preset.Description,
+		Attributes:  attributes,
+		UpdatedBy:   preset.UpdatedBy,
+	})
+	if err != nil {
+		return ContextPreset{}, err
+	}
+	return contextPresetFromDB(row), nil
+}
+
+// DeleteContextPreset removes a preset.
+func (p *PostgresStore) DeleteContextPreset(ctx context.Context, name string) error {
+	n, err := p.q.DeleteContextPreset(ctx, name)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrContextPresetNotFound
+	}
+	return nil
+}
+
+func contextPresetFromDB(row dbgen.ContextPreset) ContextPreset {
+	preset := ContextPreset{
+		Name:        row.Name,
+		Description: row.Description,
+		Attributes:  map[string]any{},
+		UpdatedAt:   row.UpdatedAt.Time,
+		UpdatedBy:   row.UpdatedBy,
+	}
+	if len(row.Attributes) > 0 {
+		_ = json.Unmarshal(row.Attributes, &preset.Attributes)
+	}
+	return preset
+}
@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+// DefaultProject is the project of flags written without one. It always
+// exists and cannot be deleted.
+const DefaultProject = "default"
+
+var (
+	// ErrProjectNotFound is returned when a project does not exist.
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrProjectExists is returned when creating a project whose key is taken.
+	ErrProjectExists = errors.New("project already exists")
+	// ErrProjectInUse is returned when deleting a project that still has
+	// flags (or, in PostgresStore, enabled API keys or webhooks).
+	ErrProjectInUse = errors.New("project is in use")
+)
+
+// Project groups flags, API keys and webhooks, e.g. one per product. Flag
+// keys stay unique per environment across projects.
+type Project struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ProjectStore is implemented by stores that keep a project registry. Both
+// MemoryStore and PostgresStore implement it; both start with
+// DefaultProject.
+type ProjectStore interface {
+	// ListProjects returns all projects, sorted by key.
+	ListProjects(ctx context.Context) ([]Project, error)
+
+	// GetProject returns ErrProjectNotFound if there is no project key.
+	GetProject(ctx context.Context, key string) (Project, error)
+
+	// CreateProject returns ErrProjectExists if the key is taken. The
+	// timestamps are set by the store.
+	CreateProject(ctx context.Context, project Project) (Project, error)
+
+	// UpdateProject replaces the name and description of a project. It
+	// returns ErrProjectNotFound if there is no project with its key.
+	UpdateProject(ctx context.Context, project Project) (Project, error)
+
+	// DeleteProject returns ErrProjectNotFound if there is no project key
+	// and ErrProjectInUse if flags still belong to it.
+	DeleteProject(ctx context.Context, key string) error
+}
+
+// projectOrDefault returns project, or DefaultProject if it is empty.
+func projectOrDefault(project string) string {
+	if project == "" {
+		return DefaultProject
+	}
+	return project
+}
+
+func defaultProjectEntry() Project {
+	now := time.Now().UTC()
+	return Project{Key: DefaultProject, Name: "Default", CreatedAt: now, UpdatedAt: now}
+}
+
+// ListProjects returns all projects, sorted by key.
+func (m *MemoryStore) ListProjects(ctx context.Context) ([]Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	projects := make([]Project, 0, len(m.projects))
+	for _, project := range m.projects {
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Key < projects[j].Key })
+	return projects, nil
+}
+
+// GetProject returns the project key.
+func (m *MemoryStore) GetProject(ctx context.Context, key string) (Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	project, ok := m.projects[key]
+	if !ok {
+		return Project{}, ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// CreateProject adds a project.
+func (m *MemoryStore) CreateProject(ctx context.Context, project Project) (Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.projects[project.Key]; ok {
+		return Project{}, ErrProjectExists
+	}
+	project.CreatedAt = time.Now().UTC()
+	project.UpdatedAt = project.CreatedAt
+	m.projects[project.Key] = project
+	return project, nil
+}
+
+// UpdateProject replaces the name and description of a project.
+func (m *MemoryStore) UpdateProject(ctx context.Context, project Project) (Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.projects[project.Key]
+	if !ok {
+		return Project{}, ErrProjectNotFound
+	}
+	existing.Name, existing.Description = project.Name, project.Description
+	existing.UpdatedAt = time.Now().UTC()
+	m.projects[project.Key] = existing
+	return existing, nil
+}
+
+// DeleteProject removes a project that no flag belongs to.
+func (m *MemoryStore) DeleteProject(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.projects[key]; !ok {
+		return ErrProjectNotFound
+	}
+	for _, flag := range m.flags {
+		if flag.Project == key {
+			return ErrProjectInUse
+		}
+	}
+	delete(m.projects, key)
+	return nil
+}
+
+// ListProjects returns all projects, sorted by key.
+func (p *PostgresStore) ListProjects(ctx context.Context) ([]Project, error) {
+	rows, err := p.readQueries(ctx).ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	projects := make([]Project, 0, len(rows))
+	for _, row := range rows {
+		projects = append(projects, projectFromDB(row))
+	}
+	return projects, nil
+}
+
+// GetProject returns the project key.
+func (p *PostgresStore) GetProject(ctx context.Context, key string) (Project, error) {
+	row, err := p.readQueries(ctx).GetProject(ctx, key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Project{}, ErrProjectNotFound
+	}
+	if err != nil {
+		return Project{}, err
+	}
+	return projectFromDB(row), nil
+}
+
+// CreateProject adds a project.
+func (p *PostgresStore) CreateProject(ctx context.Context, project Project) (Project, error) {
+	row, err := p.q.CreateProject(ctx, dbgen.CreateProjectParams{
+		Key:         project.Key,
+		Name:        project.Name,
+		Description: project.Description,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Project{}, ErrProjectExists
+	}
+	if err != nil {
+		return Project{}, err
+	}
+	return projectFromDB(row), nil
+}
+
+// UpdateProject replaces the name and description of a project.
+func (p *PostgresStore) UpdateProject(ctx context.Context, project Project) (Project, error) {
+	row, err := p.q.UpdateProject(ctx, dbgen.UpdateProjectParams{
+		Key:         project.Key,
+		Name:        project.Name,
+		Description: project.Description,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Project{}, ErrProjectNotFound
+	}
+	if err != nil {
+		return Project{}, err
+	}
+	return projectFromDB(row), nil
+}
+
+// DeleteProject removes a project that no flag, enabled API key or webhook
+// belongs to.
+func (p *PostgresStore) DeleteProject(ctx context.Context, key string) error {
+	n, err := p.q.DeleteProject(ctx, key)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := p.GetProject(ctx, key); err != nil {
+		return err
+	}
+	return ErrProjectInUse
+}
+
+func projectFromDB(row dbgen.Project) Project {
+	return Project{
+		Key:         row.Key,
+		Name:        row.Name,
+		Description: row.Description,
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+// ErrReadinessChecklistNotFound is returned when a project has no readiness
+// checklist.
+var ErrReadinessChecklistNotFound = errors.New("readiness checklist not found")
+
+// ReadinessChecklist lists what a flag of a project needs before it may be
+// enabled in one of Environments, e.g. a description and "owner-" and
+// "rollback-" tags before it goes live in prod.
+type ReadinessChecklist struct {
+	Project             string    `json:"project"`
+	Environments        []string  `json:"environments"`
+	RequireDescription  bool      `json:"require_description"`
+	RequiredTagPrefixes []string  `json:"required_tag_prefixes"` // each needs a tag starting with it
+	UpdatedAt           time.Time `json:"updated_at"`
+	UpdatedBy           string    `json:"updated_by"`
+}
+
+// ReadinessChecklistStore is implemented by stores that can persist a
+// readiness checklist per project. Both MemoryStore and PostgresStore
+// implement it.
+type ReadinessChecklistStore interface {
+	// GetReadinessChecklist returns ErrReadinessChecklistNotFound if the
+	// project has no checklist.
+	GetReadinessChecklist(ctx context.Context, project string) (ReadinessChecklist, error)
+
+	// PutReadinessChecklist creates or replaces the checklist of
+	// c.Project. UpdatedAt is set by the store.
+	PutReadinessChecklist(ctx context.Context, c ReadinessChecklist) (ReadinessChecklist, error)
+
+	// DeleteReadinessChecklist returns ErrReadinessChecklistNotFound if the
+	// project has no checklist.
+	DeleteReadinessChecklist(ctx context.Context, project string) error
+}
+
+// GetReadinessChecklist returns the checklist of project.
+func (m *MemoryStore) GetReadinessChecklist(ctx context.Context, project string) (ReadinessChecklist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.readiness[project]
+	if !ok {
+		return ReadinessChecklist{}, ErrReadinessChecklistNotFound
+	}
+	return copyReadinessChecklist(c), nil
+}
+
+// PutReadinessChecklist creates or replaces the checklist of a project.
+func (m *MemoryStore) PutReadinessChecklist(ctx context.Context, c ReadinessChecklist) (ReadinessChecklist, error) {
+	c = copyReadinessChecklist(c)
+	c.UpdatedAt = time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readiness[c.Project] = c
+	return copyReadinessChecklist(c), nil
+}
+
+// DeleteReadinessChecklist removes the checklist of a project.
+func (m *MemoryStore) DeleteReadinessChecklist(ctx context.Context, project string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.readiness[project]; !ok {
+		return ErrReadinessChecklistNotFound
+	}
+	delete(m.readiness, project)
+	return nil
+}
+
+// copyReadinessChecklist copies the slices of c, so callers cannot modify a
+// stored checklist.
+func copyReadinessChecklist(c ReadinessChecklist) ReadinessChecklist {
+	c.Environments = append([]string{}, c.Environments...)
+	c.RequiredTagPrefixes = append([]string{}, c.RequiredTagPrefixes...)
+	return c
+}
+
+// GetReadinessChecklist returns the checklist of project.
+func (p *PostgresStore) GetReadinessChecklist(ctx context.Context, project string) (ReadinessChecklist, error) {
+	row, err := p.q.GetReadinessChecklist(ctx, project)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ReadinessChecklist{}, ErrReadinessChecklistNotFound
+	}
+	if err != nil {
+		return ReadinessChecklist{}, err
+	}
+	return readinessChecklistFromDB(row), nil
+}
+
+// PutReadinessChecklist creates or replaces the checklist of a project.
+func (p *PostgresStore) PutReadinessChecklist(ctx context.Context, c ReadinessChecklist) (ReadinessChecklist, error) {
+	row, err := p.q.UpsertReadinessChecklist(ctx, dbgen.UpsertReadinessChecklistParams{
+		Project:             c.Project,
+		Environments:        nonNilStrings(c.Environments),
+		RequireDescription:  c.RequireDescription,
+		RequiredTagPrefixes: nonNilStrings(c.RequiredTagPrefixes),
+		UpdatedBy:           c.UpdatedBy,
+	})
+	if err != nil {
+		return ReadinessChecklist{}, err
+	}
+	return readinessChecklistFromDB(row), nil
+}
+
+// DeleteReadinessChecklist removes the checklist of a project.
+func (p *PostgresStore) DeleteReadinessChecklist(ctx context.Context, project string) error {
+	n, err := p.q.DeleteReadinessChecklist(ctx, project)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReadinessChecklistNotFound
+	}
+	return nil
+}
+
+func readinessChecklistFromDB(row dbgen.ReadinessChecklist) ReadinessChecklist {
+	return ReadinessChecklist{
+		Project:             row.Project,
+		Environments:        nonNilStrings(row.Environments),
+		RequireDescription:  row.RequireDescription,
+		RequiredTagPrefixes: nonNilStrings(row.RequiredTagPrefixes),
+		UpdatedAt:           row.UpdatedAt.Time,
+		UpdatedBy:           row.UpdatedBy,
+	}
+}
+
+// nonNilStrings returns s, or an empty slice if s is nil, so NOT NULL array
+// columns and JSON responses get [] rather than null.
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
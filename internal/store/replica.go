@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	mydb "github.com/TimurManjosov/goflagship/internal/db"
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// A PostgresStore can send its heavy reads (GetAllFlags and audit log
+// listing) to a read replica. Everything else, and every read made with a
+// ReadPrimary context, goes to the primary. The replica is only used while
+// its replication lag, checked by MonitorReplica, is within the configured
+// maximum; until the first check and whenever it lags or cannot be reached,
+// reads fall back to the primary.
+
+// ReplicaCheckInterval is how often the server checks the replica's lag.
+const ReplicaCheckInterval = 5 * time.Second
+
+type replica struct {
+	pool    *pgxpool.Pool
+	q       *dbgen.Queries
+	maxLag  time.Duration
+	healthy atomic.Bool
+}
+
+type readPrimaryKey struct{}
+
+// ReadPrimary returns ctx marked so that reads go to the primary even when a
+// replica is configured, for callers that must see their own writes or that
+// decide writes based on what they read.
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryKey{}, true)
+}
+
+func readsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(readPrimaryKey{}).(bool)
+	return primary
+}
+
+// SetReplica routes heavy reads to the replica behind pool while its lag is
+// at most maxLag. Call before the store is used and start MonitorReplica.
+// The store closes pool on Close.
+func (p *PostgresStore) SetReplica(pool *pgxpool.Pool, maxLag time.Duration) {
+	p.replica = &replica{pool: pool, q: dbgen.New(mydb.Instrument(pool)), maxLag: maxLag}
+}
+
+// MonitorReplica checks the replica's lag now and every interval until ctx
+// is cancelled. It returns immediately if no replica is configured.
+func (p *PostgresStore) MonitorReplica(ctx context.Context, interval time.Duration) {
+	if p.replica == nil {
+		return
+	}
+	p.checkReplica(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkReplica(ctx)
+		}
+	}
+}
+
+// checkReplica measures the replication lag and enables or disables reads
+// from the replica accordingly.
+func (p *PostgresStore) checkReplica(ctx context.Context) {
+	r := p.replica
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	seconds, err := r.q.ReplicaLagSeconds(checkCtx)
+	lag := time.Duration(seconds * float64(time.Second))
+	healthy := err == nil && lag <= r.maxLag
+	if err == nil {
+		telemetry.DBReplicaLag.Set(seconds)
+	}
+	if healthy {
+		telemetry.DBReplicaInUse.Set(1)
+	} else {
+		telemetry.DBReplicaInUse.Set(0)
+	}
+
+	if was := r.healthy.Swap(healthy); was != healthy {
+		switch {
+		case healthy:
+			log.Printf("[store] reading from replica (lag %s)", lag)
+		case err != nil:
+			log.Printf("[store] replica unavailable, reading from primary: %v", err)
+		default:
+			log.Printf("[store] replica lag %s exceeds %s, reading from primary", lag, r.maxLag)
+		}
+	}
+}
+
+// readQueries returns the queries heavy reads should use for ctx.
+func (p *PostgresStore) readQueries(ctx context.Context) *dbgen.Queries {
+	if r := p.replica; r != nil && r.healthy.Load() && !readsPrimary(ctx) {
+		return r.q
+	}
+	return p.q
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPostgresStore_ReadQueries(t *testing.T) {
+	p := &PostgresStore{}
+	ctx := context.Background()
+	if p.readQueries(ctx) != p.q {
+		t.Fatal("expected primary without a replica")
+	}
+
+	p.SetReplica(nil, 0)
+	if p.readQueries(ctx) != p.q {
+		t.Fatal("expected primary before the replica's lag was checked")
+	}
+	p.replica.healthy.Store(true)
+	if p.readQueries(ctx) != p.replica.q {
+		t.Fatal("expected replica while it is healthy")
+	}
+	if p.readQueries(ReadPrimary(ctx)) != p.q {
+		t.Fatal("expected primary for a ReadPrimary context")
+	}
+}
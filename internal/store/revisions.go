@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrFlagRevisionNotFound is returned when a flag has no revision with the
+// requested version.
+var ErrFlagRevisionNotFound = errors.New("flag revision not found")
+
+// FlagRevisionStore is implemented by stores that record every version of a
+// flag. Each write (create, update, restore from the trash) records the flag
+// as written, under its new version; revisions are never changed and outlive
+// the flag. Versions keep counting up when a deleted flag's key is reused, so
+// a version identifies one revision. Both MemoryStore and PostgresStore
+// implement it.
+//
+// Revisions are returned as Flags: Version is the revision's version and
+// UpdatedAt when it was written.
+type FlagRevisionStore interface {
+	// ListFlagRevisions returns the revisions of key in env, newest first.
+	ListFlagRevisions(ctx context.Context, key, env string) ([]Flag, error)
+
+	// GetFlagRevision returns ErrFlagRevisionNotFound if key has no
+	// revision with version in env.
+	GetFlagRevision(ctx context.Context, key, env string, version int64) (*Flag, error)
+}
+
+// nextVersion returns the version of the next write of the flag with id,
+// counting on from its deleted predecessors. m.mu must be held.
+func (m *MemoryStore) nextVersion(id string) int64 {
+	version := m.flags[id].Version
+	if revisions := m.revisions[id]; len(revisions) > 0 {
+		version = max(version, revisions[len(revisions)-1].Version)
+	}
+	return version + 1
+}
+
+// recordRevision stores flag as the revision of its version. m.mu must be held.
+func (m *MemoryStore) recordRevision(flag Flag) {
+	id := flagID(flag.Key, flag.Env)
+	m.revisions[id] = append(m.revisions[id], flag)
+}
+
+// ListFlagRevisions returns the revisions of a flag, newest first.
+func (m *MemoryStore) ListFlagRevisions(ctx context.Context, key, env string) ([]Flag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revisions := m.revisions[flagID(key, env)]
+	result := make([]Flag, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		result = append(result, revisions[i])
+	}
+	return result, nil
+}
+
+// GetFlagRevision returns one revision of a flag.
+func (m *MemoryStore) GetFlagRevision(ctx context.Context, key, env string, version int64) (*Flag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, revision := range m.revisions[flagID(key, env)] {
+		if revision.Version == version {
+			return &revision, nil
+		}
+	}
+	return nil, ErrFlagRevisionNotFound
+}
+
+// ListFlagRevisions returns the revisions of a flag, newest first. They are
+// written by the flag write queries themselves, see flags.sql.
+func (p *PostgresStore) ListFlagRevisions(ctx context.Context, key, env string) ([]Flag, error) {
+	rows, err := p.q.ListFlagRevisions(ctx, dbgen.ListFlagRevisionsParams{Key: key, Env: env})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Flag, 0, len(rows))
+	for _, row := range rows {
+		flag, err := p.revisionFromDB(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, flag)
+	}
+	return result, nil
+}
+
+// GetFlagRevision returns one revision of a flag.
+func (p *PostgresStore) GetFlagRevision(ctx context.Context, key, env string, version int64) (*Flag, error) {
+	row, err := p.q.GetFlagRevision(ctx, dbgen.GetFlagRevisionParams{Key: key, Env: env, Version: version})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFlagRevisionNotFound
+		}
+		return nil, err
+	}
+	flag, err := p.revisionFromDB(row)
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// revisionFromDB converts a revision row with convertFromDB.
+func (p *PostgresStore) revisionFromDB(row dbgen.FlagRevision) (Flag, error) {
+	return p.convertFromDB(dbgen.Flag{
+		Key:            row.Key,
+		Description:    row.Description,
+		Enabled:        row.Enabled,
+		Rollout:        row.Rollout,
+		Expression:     row.Expression,
+		Config:         row.Config,
+		TargetingRules: row.TargetingRules,
+		Env:            row.Env,
+		UpdatedAt:      row.CreatedAt,
+		Protected:      row.Protected,
+		Tags:           row.Tags,
+		Archived:       row.Archived,
+		Version:        row.Version,
+		Owner:          row.Owner,
+		TicketUrl:      row.TicketUrl,
+		Metadata:       row.Metadata,
+		ExpiresAt:      row.ExpiresAt,
+		Project:        row.Project,
+	})
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrSDKKeyNotFound is returned when an SDK key lookup or revocation matches no key.
+var ErrSDKKeyNotFound = errors.New("sdk key not found")
+
+// SDKKey is a client-side key bound to a single environment. Only a hash of
+// the key is stored; KeyPrefix keeps its first characters for display.
+type SDKKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Env       string    `json:"env"`
+	KeyHash   string    `json:"-"`
+	KeyPrefix string    `json:"key_prefix"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+}
+
+// CreateSDKKeyParams contains the parameters for creating an SDK key.
+type CreateSDKKeyParams struct {
+	Name      string
+	Env       string
+	KeyHash   string
+	KeyPrefix string
+	CreatedBy string
+}
+
+// SDKKeyStore is implemented by stores that can persist SDK keys.
+// Both MemoryStore and PostgresStore implement it.
+type SDKKeyStore interface {
+	CreateSDKKey(ctx context.Context, params CreateSDKKeyParams) (SDKKey, error)
+
+	// ListSDKKeys returns the keys for env, or all keys if env is empty,
+	// newest first. Revoked keys are included.
+	ListSDKKeys(ctx context.Context, env string) ([]SDKKey, error)
+
+	// GetSDKKeyByHash returns ErrSDKKeyNotFound if no key has the hash.
+	// Revoked keys are returned with Enabled set to false.
+	GetSDKKeyByHash(ctx context.Context, keyHash string) (*SDKKey, error)
+
+	// RevokeSDKKey disables a key. Returns ErrSDKKeyNotFound for unknown IDs.
+	RevokeSDKKey(ctx context.Context, id string) error
+}
+
+// CreateSDKKey stores a new, enabled SDK key.
+func (m *MemoryStore) CreateSDKKey(ctx context.Context, params CreateSDKKeyParams) (SDKKey, error) {
+	id, err := newUUID()
+	if err != nil {
+		return SDKKey{}, err
+	}
+	key := SDKKey{
+		ID:        id,
+		Name:      params.Name,
+		Env:       params.Env,
+		KeyHash:   params.KeyHash,
+		KeyPrefix: params.KeyPrefix,
+		Enabled:   true,
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: params.CreatedBy,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sdkKeys == nil {
+		m.sdkKeys = make(map[string]SDKKey)
+	}
+	m.sdkKeys[id] = key
+	return key, nil
+}
+
+// ListSDKKeys returns the keys for env (all keys if env is empty), newest first.
+func (m *MemoryStore) ListSDKKeys(ctx context.Context, env string) ([]SDKKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]SDKKey, 0, len(m.sdkKeys))
+	for _, key := range m.sdkKeys {
+		if env == "" || key.Env == env {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// GetSDKKeyByHash looks up a key by its hash.
+func (m *MemoryStore) GetSDKKeyByHash(ctx context.Context, keyHash string) (*SDKKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.sdkKeys {
+		if key.KeyHash == keyHash {
+			return &key, nil
+		}
+	}
+	return nil, ErrSDKKeyNotFound
+}
+
+// RevokeSDKKey disables a key.
+func (m *MemoryStore) RevokeSDKKey(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.sdkKeys[id]
+	if !ok {
+		return ErrSDKKeyNotFound
+	}
+	key.Enabled = false
+	m.sdkKeys[id] = key
+	return nil
+}
+
+// newUUID returns a random (version 4) UUID string.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generate id: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
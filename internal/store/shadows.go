@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/rules"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrShadowFlagNotFound is returned when a flag has no shadow version.
+var ErrShadowFlagNotFound = errors.New("shadow flag not found")
+
+// ShadowFlag is a pending version of a flag's targeting. It is evaluated
+// alongside the active flag for comparison but never served. Fields not
+// listed here (description, config, variants, ...) are shared with the
+// active flag.
+type ShadowFlag struct {
+	Key            string       `json:"key"`
+	Env            string       `json:"env"`
+	Enabled        bool         `json:"enabled"`
+	Rollout        int32        `json:"rollout"`
+	Expression     *string      `json:"expression,omitempty"`
+	TargetingRules []rules.Rule `json:"targetingRules"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+}
+
+// FlagShadowStore is implemented by stores that keep shadow versions of
+// flags, at most one per flag. Deleting a flag deletes its shadow version.
+// Both MemoryStore and PostgresStore implement it.
+type FlagShadowStore interface {
+	// ListShadowFlags returns the shadow versions of env, ordered by key.
+	ListShadowFlags(ctx context.Context, env string) ([]ShadowFlag, error)
+
+	// GetShadowFlag returns ErrShadowFlagNotFound if key has no shadow
+	// version in env.
+	GetShadowFlag(ctx context.Context, key, env string) (*ShadowFlag, error)
+
+	// SetShadowFlag creates or replaces the shadow version of an existing
+	// flag and returns it as stored.
+	SetShadowFlag(ctx context.Context, shadow ShadowFlag) (*ShadowFlag, error)
+
+	// DeleteShadowFlag returns ErrShadowFlagNotFound if key has no shadow
+	// version in env.
+	DeleteShadowFlag(ctx context.Context, key, env string) error
+}
+
+// ListShadowFlags returns the shadow versions of env, ordered by key.
+func (m *MemoryStore) ListShadowFlags(ctx context.Context, env string) ([]ShadowFlag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]ShadowFlag, 0)
+	for _, shadow := range m.shadows {
+		if shadow.Env == env {
+			result = append(result, shadow)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// GetShadowFlag returns the shadow version of a flag.
+func (m *MemoryStore) GetShadowFlag(ctx context.Context, key, env string) (*ShadowFlag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	shadow, ok := m.shadows[flagID(key, env)]
+	if !ok {
+		return nil, ErrShadowFlagNotFound
+	}
+	return &shadow, nil
+}
+
+// SetShadowFlag creates or replaces the shadow version of a flag.
+func (m *MemoryStore) SetShadowFlag(ctx context.Context, shadow ShadowFlag) (*ShadowFlag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(shadow.Key, shadow.Env)
+	if _, ok := m.flags[id]; !ok {
+		return nil, errors.New("flag not found")
+	}
+	shadow.TargetingRules = ensureRulesInitialized(shadow.TargetingRules)
+	shadow.UpdatedAt = time.Now().UTC()
+	m.shadows[id] = shadow
+	return &shadow, nil
+}
+
+// DeleteShadowFlag deletes the shadow version of a flag.
+func (m *MemoryStore) DeleteShadowFlag(ctx context.Context, key, env string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(key, env)
+	if _, ok := m.shadows[id]; !ok {
+		return ErrShadowFlagNotFound
+	}
+	delete(m.shadows, id)
+	return nil
+}
+
+// ListShadowFlags returns the shadow versions of env, ordered by key.
+func (p *PostgresStore) ListShadowFlags(ctx context.Context, env string) ([]ShadowFlag, error) {
+	rows, err := p.q.ListShadowFlags(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ShadowFlag, 0, len(rows))
+	for _, row := range rows {
+		shadow, err := shadowFlagFromDB(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, shadow)
+	}
+	return result, nil
+}
+
+// GetShadowFlag returns the shadow version of a flag.
+func (p *PostgresStore) GetShadowFlag(ctx context.Context, key, env string) (*ShadowFlag, error) {
+	row, err := p.q.GetShadowFlag(ctx, dbgen.GetShadowFlagParams{Key: key, Env: env})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrShadowFlagNotFound
+		}
+		return nil, err
+	}
+	shadow, err := shadowFlagFromDB(row)
+	if err != nil {
+		return nil, err
+	}
+	return &shadow, nil
+}
+
+// SetShadowFlag creates or replaces the shadow version of a flag; the
+// foreign key on flags rejects flags that do not exist.
+func (p *PostgresStore) SetShadowFlag(ctx context.Context, shadow ShadowFlag) (*ShadowFlag, error) {
+	targetingRules, err := json.Marshal(ensureRulesInitialized(shadow.TargetingRules))
+	if err != nil {
+		return nil, fmt.Errorf("marshal targeting rules: %w", err)
+	}
+	row, err := p.q.UpsertShadowFlag(ctx, dbgen.UpsertShadowFlagParams{
+		Key:            shadow.Key,
+		Env:            shadow.Env,
+		Enabled:        shadow.Enabled,
+		Rollout:        shadow.Rollout,
+		Expression:     shadow.Expression,
+		TargetingRules: targetingRules,
+	})
+	if err != nil {
+		return nil, err
+	}
+	stored, err := shadowFlagFromDB(row)
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// DeleteShadowFlag deletes the shadow version of a flag.
+func (p *PostgresStore) DeleteShadowFlag(ctx context.Context, key, env string) error {
+	deleted, err := p.q.DeleteShadowFlag(ctx, dbgen.DeleteShadowFlagParams{Key: key, Env: env})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrShadowFlagNotFound
+	}
+	return nil
+}
+
+func shadowFlagFromDB(row dbgen.FlagShadow) (ShadowFlag, error) {
+	targetingRules, err := unmarshalTargetingRules(row.TargetingRules)
+	if err != nil {
+		return ShadowFlag{}, fmt.Errorf("unmarshal targeting rules: %w", err)
+	}
+	return ShadowFlag{
+		Key:            row.Key,
+		Env:            row.Env,
+		Enabled:        row.Enabled,
+		Rollout:        row.Rollout,
+		Expression:     row.Expression,
+		TargetingRules: targetingRules,
+		UpdatedAt:      row.UpdatedAt.Time,
+	}, nil
+}
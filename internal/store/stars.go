@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+// FlagStarStore is implemented by stores that keep per-owner starred flags,
+// e.g. the handful of flags an on-call dashboard shows during incidents. An
+// owner is an opaque caller identity (an API key, later an SSO user).
+// Deleting a flag removes its stars. Both MemoryStore and PostgresStore
+// implement it.
+type FlagStarStore interface {
+	// StarFlag stars an existing flag for owner; starring twice is a no-op.
+	StarFlag(ctx context.Context, owner, key, env string) error
+
+	// UnstarFlag removes a star; unstarring a flag that is not starred is a
+	// no-op.
+	UnstarFlag(ctx context.Context, owner, key, env string) error
+
+	// ListStarredFlags returns the keys of the flags owner starred in env,
+	// sorted.
+	ListStarredFlags(ctx context.Context, owner, env string) ([]string, error)
+}
+
+// StarFlag stars an existing flag for owner.
+func (m *MemoryStore) StarFlag(ctx context.Context, owner, key, env string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(key, env)
+	if _, ok := m.flags[id]; !ok {
+		return errors.New("flag not found")
+	}
+	if m.stars[id] == nil {
+		m.stars[id] = make(map[string]bool)
+	}
+	m.stars[id][owner] = true
+	return nil
+}
+
+// UnstarFlag removes a star.
+func (m *MemoryStore) UnstarFlag(ctx context.Context, owner, key, env string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.stars[flagID(key, env)], owner)
+	return nil
+}
+
+// ListStarredFlags returns the keys of the flags owner starred in env.
+func (m *MemoryStore) ListStarredFlags(ctx context.Context, owner, env string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]string, 0)
+	for id, owners := range m.stars {
+		if flag, ok := m.flags[id]; ok && flag.Env == env && owners[owner] {
+			keys = append(keys, flag.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// StarFlag stars a flag for owner; the foreign key on flags rejects flags
+// that do not exist.
+func (p *PostgresStore) StarFlag(ctx context.Context, owner, key, env string) error {
+	return p.q.StarFlag(ctx, dbgen.StarFlagParams{Owner: owner, Key: key, Env: env})
+}
+
+// UnstarFlag removes a star.
+func (p *PostgresStore) UnstarFlag(ctx context.Context, owner, key, env string) error {
+	return p.q.UnstarFlag(ctx, dbgen.UnstarFlagParams{Owner: owner, Key: key, Env: env})
+}
+
+// ListStarredFlags returns the keys of the flags owner starred in env.
+func (p *PostgresStore) ListStarredFlags(ctx context.Context, owner, env string) ([]string, error) {
+	keys, err := p.readQueries(ctx).ListStarredFlagKeys(ctx, dbgen.ListStarredFlagKeysParams{Owner: owner, Env: env})
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	return keys, nil
+}
@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/rules"
@@ -14,16 +16,28 @@ type Store interface {
 	// Returns an empty slice if no flags are found.
 	GetAllFlags(ctx context.Context, env string) ([]Flag, error)
 
-	// GetFlagByKey retrieves a single flag by its key.
+	// GetFlagByKey retrieves a single flag by its key within env.
+	// Flag keys are unique per environment, so the same key may exist in several.
 	// Returns an error if the flag is not found.
-	GetFlagByKey(ctx context.Context, key string) (*Flag, error)
+	GetFlagByKey(ctx context.Context, key, env string) (*Flag, error)
+
+	// GetFlagsByKeys retrieves the flags with the given keys within env,
+	// sorted by key. Keys without a flag are skipped.
+	GetFlagsByKeys(ctx context.Context, env string, keys []string) ([]Flag, error)
+
+	// GetFlagsUpdatedSince retrieves the flags in env updated after since,
+	// oldest update first. Deleted flags are not reported; compare keys with
+	// a full read to detect deletions.
+	GetFlagsUpdatedSince(ctx context.Context, env string, since time.Time) ([]Flag, error)
 
 	// UpsertFlag creates or updates a flag.
-	// If a flag with the same key exists, it will be updated.
+	// If a flag with the same key exists in the same environment, it will be updated.
+	// Returns ErrVersionConflict if params.ExpectedVersion is set and does not match.
 	UpsertFlag(ctx context.Context, params UpsertParams) error
 
 	// DeleteFlag removes a flag by key and environment.
-	// Returns no error if the flag doesn't exist (idempotent).
+	// Returns no error if the flag doesn't exist (idempotent). Stores that
+	// implement FlagTrashStore keep the deleted flag in their trash.
 	DeleteFlag(ctx context.Context, key, env string) error
 
 	// Close releases any resources held by the store.
@@ -31,6 +45,11 @@ type Store interface {
 	Close() error
 }
 
+// ErrVersionConflict is returned by UpsertFlag when UpsertParams.ExpectedVersion
+// does not match the stored flag: it was changed (or created) since the caller
+// read it.
+var ErrVersionConflict = errors.New("flag version conflict")
+
 // Variant represents a variant in an A/B test or multi-variant experiment.
 type Variant struct {
 	Name   string         `json:"name"`
@@ -40,27 +59,56 @@ type Variant struct {
 
 // Flag represents a feature flag with all its attributes.
 type Flag struct {
-	Key            string         `json:"key"`
-	Description    string         `json:"description"`
-	Enabled        bool           `json:"enabled"`
-	Rollout        int32          `json:"rollout"`
-	Expression     *string        `json:"expression,omitempty"`
-	Config         map[string]any `json:"config,omitempty"`
-	TargetingRules []rules.Rule   `json:"targetingRules"`
-	Variants       []Variant      `json:"variants,omitempty"` // For A/B testing
-	Env            string         `json:"env"`
-	UpdatedAt      time.Time      `json:"updatedAt"`
+	Key            string            `json:"key"`
+	Description    string            `json:"description"`
+	Enabled        bool              `json:"enabled"`
+	Rollout        int32             `json:"rollout"`
+	Expression     *string           `json:"expression,omitempty"`
+	Config         map[string]any    `json:"config,omitempty"`
+	ConfigJSON     json.RawMessage   `json:"-"` // Config as stored, if the store keeps it (see UpsertParams.ConfigJSON)
+	TargetingRules []rules.Rule      `json:"targetingRules"`
+	Variants       []Variant         `json:"variants,omitempty"`  // For A/B testing
+	Protected      bool              `json:"protected,omitempty"` // Deleting or disabling requires superadmin or force
+	Tags           []string          `json:"tags,omitempty"`
+	Archived       bool              `json:"archived,omitempty"`  // Kept in the store but left out of snapshots
+	Owner          string            `json:"owner,omitempty"`     // Team or person responsible for the flag
+	TicketURL      string            `json:"ticketUrl,omitempty"` // Issue tracking the flag's rollout or removal
+	Metadata       map[string]string `json:"metadata,omitempty"`  // Free-form admin annotations, not served to SDKs
+	ExpiresAt      *time.Time        `json:"expiresAt,omitempty"` // When the flag is disabled or archived, see FlagExpiryStore
+	Project        string            `json:"project,omitempty"`   // see ProjectStore; DefaultProject if empty on write
+	Env            string            `json:"env"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+	Version        int64             `json:"version,omitempty"` // incremented by every write, see FlagRevisionStore
 }
 
 // UpsertParams contains the parameters for upserting a flag.
+//
+// ConfigJSON is optional: a caller that already encoded Config as JSON (e.g.
+// to check its size) sets it so the store writes those bytes instead of
+// marshaling Config again, which matters for large configs on bulk writes. It
+// must encode exactly Config.
+//
+// ExpectedVersion is optional too: if set, the write only succeeds if the
+// stored flag is at that version (0: the flag must not exist yet), and
+// ErrVersionConflict is returned otherwise. Without it the last write wins.
 type UpsertParams struct {
-	Key            string         `json:"key"`
-	Description    string         `json:"description"`
-	Enabled        bool           `json:"enabled"`
-	Rollout        int32          `json:"rollout"`
-	Expression     *string        `json:"expression,omitempty"`
-	Config         map[string]any `json:"config,omitempty"`
-	TargetingRules []rules.Rule   `json:"targetingRules"`
-	Variants       []Variant      `json:"variants,omitempty"` // For A/B testing
-	Env            string         `json:"env"`
+	Key             string            `json:"key"`
+	Description     string            `json:"description"`
+	Enabled         bool              `json:"enabled"`
+	Rollout         int32             `json:"rollout"`
+	Expression      *string           `json:"expression,omitempty"`
+	Config          map[string]any    `json:"config,omitempty"`
+	ConfigJSON      json.RawMessage   `json:"-"`
+	TargetingRules  []rules.Rule      `json:"targetingRules"`
+	Variants        []Variant         `json:"variants,omitempty"` // For A/B testing
+	Protected       bool              `json:"protected,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	Archived        bool              `json:"archived,omitempty"`
+	Owner           string            `json:"owner,omitempty"`
+	TicketURL       string            `json:"ticketUrl,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	ExpiresAt       *time.Time        `json:"expiresAt,omitempty"`
+	Project         string            `json:"project,omitempty"`
+	Env             string            `json:"env"`
+	ExpectedVersion *int64            `json:"-"`
 }
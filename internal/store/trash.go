@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	// ErrTrashedFlagNotFound is returned when restoring a flag that is not in
+	// the trash.
+	ErrTrashedFlagNotFound = errors.New("flag not found in trash")
+
+	// ErrFlagExists is returned when restoring a flag whose key has been
+	// reused by a new flag since it was deleted.
+	ErrFlagExists = errors.New("flag already exists")
+)
+
+// TrashedFlag is a deleted flag kept in the trash.
+type TrashedFlag struct {
+	Flag
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// FlagTrashStore is implemented by stores whose DeleteFlag moves flags to a
+// trash, from which they can be restored until purged. Deleting a flag that
+// is already in the trash replaces the trashed copy. Both MemoryStore and
+// PostgresStore implement it.
+type FlagTrashStore interface {
+	// ListTrashedFlags returns the trashed flags of env, most recently
+	// deleted first.
+	ListTrashedFlags(ctx context.Context, env string) ([]TrashedFlag, error)
+
+	// GetTrashedFlag returns ErrTrashedFlagNotFound if key is not in the
+	// trash of env.
+	GetTrashedFlag(ctx context.Context, key, env string) (*TrashedFlag, error)
+
+	// RestoreFlag moves a flag from the trash back to env. Returns
+	// ErrTrashedFlagNotFound or ErrFlagExists.
+	RestoreFlag(ctx context.Context, key, env string) (*Flag, error)
+
+	// PurgeTrash permanently removes the flags deleted before before and
+	// returns how many were removed.
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+}
+
+// trashFlag moves the flag with id to the trash and drops its shadow
+// version, guardrail, user overrides, stars and evaluation time. m.mu must be
+// held.
+func (m *MemoryStore) trashFlag(id string, now time.Time) {
+	if flag, ok := m.flags[id]; ok {
+		m.trash[id] = TrashedFlag{Flag: flag, DeletedAt: now}
+		delete(m.flags, id)
+		delete(m.shadows, id)
+		delete(m.guardrails, id)
+		delete(m.userOverrides, id)
+		delete(m.stars, id)
+		delete(m.evaluations, id)
+	}
+}
+
+// ListTrashedFlags returns the trashed flags of env, most recently deleted first.
+func (m *MemoryStore) ListTrashedFlags(ctx context.Context, env string) ([]TrashedFlag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]TrashedFlag, 0)
+	for _, flag := range m.trash {
+		if flag.Env == env {
+			result = append(result, flag)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].DeletedAt.Equal(result[j].DeletedAt) {
+			return result[i].DeletedAt.After(result[j].DeletedAt)
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// GetTrashedFlag returns a trashed flag of env.
+func (m *MemoryStore) GetTrashedFlag(ctx context.Context, key, env string) (*TrashedFlag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	flag, ok := m.trash[flagID(key, env)]
+	if !ok {
+		return nil, ErrTrashedFlagNotFound
+	}
+	return &flag, nil
+}
+
+// RestoreFlag moves a flag from the trash back to env.
+func (m *MemoryStore) RestoreFlag(ctx context.Context, key, env string) (*Flag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := flagID(key, env)
+	trashed, ok := m.trash[id]
+	if !ok {
+		return nil, ErrTrashedFlagNotFound
+	}
+	if _, exists := m.flags[id]; exists {
+		return nil, ErrFlagExists
+	}
+	flag := trashed.Flag
+	flag.UpdatedAt = time.Now().UTC()
+	flag.Version = max(flag.Version+1, m.nextVersion(id))
+	m.flags[id] = flag
+	m.recordRevision(flag)
+	delete(m.trash, id)
+	return &flag, nil
+}
+
+// PurgeTrash permanently removes the flags deleted before before.
+func (m *MemoryStore) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	for id, flag := range m.trash {
+		if flag.DeletedAt.Before(before) {
+			delete(m.trash, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// ListTrashedFlags returns the trashed flags of env, most recently deleted first.
+func (p *PostgresStore) ListTrashedFlags(ctx context.Context, env string) ([]TrashedFlag, error) {
+	rows, err := p.q.ListTrashedFlags(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]TrashedFlag, 0, len(rows))
+	for _, row := range rows {
+		flag, err := p.trashedFlagFromDB(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, flag)
+	}
+	return result, nil
+}
+
+// GetTrashedFlag returns a trashed flag of env.
+func (p *PostgresStore) GetTrashedFlag(ctx context.Context, key, env string) (*TrashedFlag, error) {
+	row, err := p.q.GetTrashedFlag(ctx, dbgen.GetTrashedFlagParams{Key: key, Env: env})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTrashedFlagNotFound
+		}
+		return nil, err
+	}
+	flag, err := p.trashedFlagFromDB(row)
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// RestoreFlag moves a flag from the trash back to env in one statement.
+func (p *PostgresStore) RestoreFlag(ctx context.Context, key, env string) (*Flag, error) {
+	row, err := p.q.RestoreFlag(ctx, dbgen.RestoreFlagParams{Key: key, Env: env})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		// Nothing restored: either not trashed or the key is taken.
+		if _, err := p.GetTrashedFlag(ctx, key, env); err != nil {
+			return nil, err
+		}
+		return nil, ErrFlagExists
+	}
+	flag, err := p.convertFromDB(row)
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// PurgeTrash permanently removes the flags deleted before before.
+func (p *PostgresStore) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	return p.q.PurgeTrash(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+}
+
+// trashedFlagFromDB converts a trash row with convertFromDB.
+func (p *PostgresStore) trashedFlagFromDB(row dbgen.FlagTrash) (TrashedFlag, error) {
+	flag, err := p.convertFromDB(dbgen.Flag{
+		ID:             row.ID,
+		Key:            row.Key,
+		Description:    row.Description,
+		Enabled:        row.Enabled,
+		Rollout:        row.Rollout,
+		Expression:     row.Expression,
+		Config:         row.Config,
+		TargetingRules: row.TargetingRules,
+		Env:            row.Env,
+		UpdatedAt:      row.UpdatedAt,
+		Protected:      row.Protected,
+		Tags:           row.Tags,
+		Archived:       row.Archived,
+		Version:        row.Version,
+		Owner:          row.Owner,
+		TicketUrl:      row.TicketUrl,
+		Metadata:       row.Metadata,
+		ExpiresAt:      row.ExpiresAt,
+		Project:        row.Project,
+	})
+	if err != nil {
+		return TrashedFlag{}, err
+	}
+	return TrashedFlag{Flag: flag, DeletedAt: row.DeletedAt.Time}, nil
+}
@@ -6,6 +6,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"strings"
 
 	"github.com/diegoholiveira/jsonlogic/v3"
@@ -28,6 +30,16 @@ var ErrInvalidExpression = errors.New("invalid expression: not valid JSON Logic"
 // ErrEmptyExpression is returned when an expression is empty or whitespace.
 var ErrEmptyExpression = errors.New("invalid expression: empty or whitespace")
 
+// MaxExpressionDepth is the deepest nesting of arrays and objects an
+// expression may have. Real targeting expressions are a few levels deep;
+// the JSON Logic library evaluates nested operations recursively, so the
+// depth is bounded before an expression reaches it.
+const MaxExpressionDepth = 64
+
+// ErrExpressionTooDeep is returned when an expression is nested deeper than
+// MaxExpressionDepth.
+var ErrExpressionTooDeep = fmt.Errorf("invalid expression: nested deeper than %d levels", MaxExpressionDepth)
+
 // Evaluate evaluates a JSON Logic expression against a user context.
 //
 // Preconditions:
@@ -55,6 +67,8 @@ var ErrEmptyExpression = errors.New("invalid expression: empty or whitespace")
 //   - expression is empty or whitespace: Returns (false, ErrEmptyExpression)
 //   - expression is invalid JSON: Returns (false, ErrInvalidExpression)
 //   - expression is valid JSON but invalid JSON Logic: Returns (false, ErrInvalidExpression)
+//   - expression nested deeper than MaxExpressionDepth: Returns (false, ErrExpressionTooDeep)
+//   - JSON Logic library panics: Recovered, returns (false, ErrInvalidExpression)
 //   - ctx is nil: Treated as empty context {}
 //   - ctx has no matching keys: Expression may still evaluate (depends on logic)
 //   - expression references non-existent ctx keys: Treated as undefined (falsy)
@@ -62,11 +76,15 @@ var ErrEmptyExpression = errors.New("invalid expression: empty or whitespace")
 // Error Cases:
 //   - ErrEmptyExpression: expression is empty or whitespace only
 //   - ErrInvalidExpression: expression is not valid JSON or JSON Logic
+//   - ErrExpressionTooDeep: expression is nested too deeply to evaluate safely
 //   - Other errors: JSON marshaling failures (rare)
 func Evaluate(expression string, ctx UserContext) (bool, error) {
 	if strings.TrimSpace(expression) == "" {
 		return false, ErrEmptyExpression
 	}
+	if expressionDepth(expression) > MaxExpressionDepth {
+		return false, ErrExpressionTooDeep
+	}
 
 	// Convert context to JSON
 	dataBytes, err := json.Marshal(ctx)
@@ -74,13 +92,9 @@ func Evaluate(expression string, ctx UserContext) (bool, error) {
 		return false, err
 	}
 
-	// Create readers for the JSON Logic library
-	ruleReader := strings.NewReader(expression)
-	dataReader := bytes.NewReader(dataBytes)
-	var resultBuf bytes.Buffer
-
 	// Apply the rule - this will fail if expression is not valid JSON
-	if err := jsonlogic.Apply(ruleReader, dataReader, &resultBuf); err != nil {
+	var resultBuf bytes.Buffer
+	if err := apply(expression, bytes.NewReader(dataBytes), &resultBuf); err != nil {
 		return false, ErrInvalidExpression
 	}
 
@@ -106,8 +120,9 @@ func Evaluate(expression string, ctx UserContext) (bool, error) {
 //
 // Validation Steps:
 //   1. Check if expression is empty/whitespace → ErrEmptyExpression
-//   2. Check if expression is valid JSON → ErrInvalidExpression
-//   3. Check if expression is valid JSON Logic → ErrInvalidExpression
+//   2. Check the nesting depth → ErrExpressionTooDeep
+//   3. Check if expression is valid JSON → ErrInvalidExpression
+//   4. Check if expression is valid JSON Logic → ErrInvalidExpression
 //
 // Edge Cases:
 //   - expression is empty: Returns ErrEmptyExpression
@@ -123,6 +138,9 @@ func ValidateExpression(expression string) error {
 	if strings.TrimSpace(expression) == "" {
 		return ErrEmptyExpression
 	}
+	if expressionDepth(expression) > MaxExpressionDepth {
+		return ErrExpressionTooDeep
+	}
 
 	// Check if it's valid JSON first
 	var rule any
@@ -131,17 +149,54 @@ func ValidateExpression(expression string) error {
 	}
 
 	// Try to validate by applying against empty data
-	ruleReader := strings.NewReader(expression)
-	dataReader := strings.NewReader("{}")
-	var resultBuf bytes.Buffer
-
-	if err := jsonlogic.Apply(ruleReader, dataReader, &resultBuf); err != nil {
+	if err := apply(expression, strings.NewReader("{}"), io.Discard); err != nil {
 		return ErrInvalidExpression
 	}
 
 	return nil
 }
 
+// apply runs the JSON Logic library on expression and data, turning a panic
+// of the library into an error so a malformed expression can never take the
+// server down.
+func apply(expression string, data io.Reader, result io.Writer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("json logic panic: %v", r)
+		}
+	}()
+	return jsonlogic.Apply(strings.NewReader(expression), data, result)
+}
+
+// expressionDepth returns the deepest nesting of arrays and objects in
+// expression, ignoring brackets inside strings. It does not validate the JSON.
+func expressionDepth(expression string) int {
+	depth, maxDepth := 0, 0
+	inString, escaped := false, false
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '[' || c == '{':
+			depth++
+			maxDepth = max(maxDepth, depth)
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return maxDepth
+}
+
 // isTruthy follows JavaScript-like truthiness rules.
 // Returns true for non-zero numbers, non-empty strings, non-empty arrays/objects, and true boolean.
 func isTruthy(v any) bool {
@@ -1,6 +1,7 @@
 package targeting
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -259,6 +260,12 @@ func TestEvaluate_Errors(t *testing.T) {
 			context:    UserContext{},
 			wantErr:    ErrInvalidExpression,
 		},
+		{
+			name:       "deeply nested",
+			expression: nestedNot(100000),
+			context:    UserContext{},
+			wantErr:    ErrExpressionTooDeep,
+		},
 	}
 
 	for _, tt := range tests {
@@ -306,6 +313,16 @@ func TestValidateExpression(t *testing.T) {
 			expression: "not json",
 			wantErr:    true,
 		},
+		{
+			name:       "nested to the maximum depth",
+			expression: nestedNot(MaxExpressionDepth / 2),
+			wantErr:    false,
+		},
+		{
+			name:       "nested too deeply",
+			expression: nestedNot(MaxExpressionDepth/2 + 1),
+			wantErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -345,3 +362,28 @@ func TestIsTruthy(t *testing.T) {
 		})
 	}
 }
+
+// nestedNot returns a negation nested n times, 2n levels deep.
+func nestedNot(n int) string {
+	return strings.Repeat(`{"!":[`, n) + "true" + strings.Repeat("]}", n)
+}
+
+func TestExpressionDepth(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       int
+	}{
+		{`true`, 0},
+		{`{"var": "plan"}`, 1},
+		{`{"in": [{"var": "country"}, ["US", "CA"]]}`, 3},
+		{`{"==": [{"var": "name"}, "[[{{"]}`, 3},
+		{`{"==": [{"var": "name"}, "quote \" [["]}`, 3},
+		{`[[[`, 3},
+	}
+
+	for _, tt := range tests {
+		if got := expressionDepth(tt.expression); got != tt.want {
+			t.Errorf("expressionDepth(%s) = %d, want %d", tt.expression, got, tt.want)
+		}
+	}
+}
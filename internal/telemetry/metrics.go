@@ -33,6 +33,35 @@ var (
 		Name: "snapshot_flags",
 		Help: "Number of flags currently in the in-memory snapshot",
 	})
+	SnapshotRebuildDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "snapshot_rebuild_duration_seconds",
+			Help:    "Snapshot rebuild latency in seconds by environment, including reading the flags",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"env"},
+	)
+	SnapshotRebuildFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "snapshot_rebuild_failures_total",
+			Help: "Total number of failed snapshot rebuilds by environment; the previous snapshot keeps being served",
+		},
+		[]string{"env"},
+	)
+	SnapshotLastRebuild = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "snapshot_last_rebuild_success_timestamp_seconds",
+			Help: "Unix time of the last successful snapshot rebuild by environment",
+		},
+		[]string{"env"},
+	)
+	SnapshotExcludedFlags = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "snapshot_excluded_flags_total",
+			Help: "Total number of flags excluded from snapshot builds by failed integrity check",
+		},
+		[]string{"reason"},
+	)
 
 	// Auth metrics
 	ActiveAPIKeys = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -46,6 +75,13 @@ var (
 		},
 		[]string{"reason"},
 	)
+	AuthLockouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_lockouts_total",
+			Help: "Total number of brute-force lockouts triggered, by scope (ip or key)",
+		},
+		[]string{"scope"},
+	)
 	RateLimitHits = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "rate_limit_hits_total",
@@ -53,10 +89,175 @@ var (
 		},
 		[]string{"type"},
 	)
+
+	// Tenant metrics, recorded for authenticated admin requests.
+	TenantRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tenant_requests_total",
+			Help: "Total number of authenticated requests by tenant",
+		},
+		[]string{"tenant"},
+	)
+	TenantRateLimitHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tenant_rate_limit_hits_total",
+			Help: "Total number of requests rejected by the per-tenant rate limit",
+		},
+		[]string{"tenant"},
+	)
+
+	// FlagExposures counts SDK-reported exposures (see POST /v1/exposures).
+	FlagExposures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "flag_exposures_total",
+			Help: "Total number of flag exposures reported by SDKs",
+		},
+		[]string{"flag", "variant", "enabled"},
+	)
+
+	// Evaluation metrics, labelled by the Eval* handler constants below.
+	EvaluationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "evaluation_duration_seconds",
+			Help:    "Evaluation request latency in seconds by handler",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"handler"},
+	)
+	EvaluationFlags = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "evaluation_flags_per_request",
+			Help:    "Number of flags evaluated per request by handler",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 11), // 1 .. 1024
+		},
+		[]string{"handler"},
+	)
+	EvaluationErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "evaluation_errors_total",
+			Help: "Total number of runtime evaluation errors by type",
+		},
+		[]string{"type"},
+	)
+	FlagEvaluationErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "flag_evaluation_errors_total",
+			Help: "Total number of runtime evaluation errors by flag; the flag's on_error value is served instead",
+		},
+		[]string{"flag"},
+	)
+	ShadowEvaluations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "flag_shadow_evaluations_total",
+			Help: "Total number of shadow version evaluations by flag and result (match or mismatch with the served result)",
+		},
+		[]string{"flag", "result"},
+	)
+
+	// Webhook circuit breaker metrics, labelled by webhook ID.
+	WebhookCircuitOpens = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_circuit_opens_total",
+			Help: "Total number of times a webhook's circuit breaker opened",
+		},
+		[]string{"webhook_id"},
+	)
+	WebhookDeliveriesSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_deliveries_skipped_total",
+			Help: "Total number of webhook deliveries skipped because the circuit was open",
+		},
+		[]string{"webhook_id"},
+	)
+	ContextAttributeViolations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "context_attribute_violations_total",
+			Help: "Total number of undeclared or mistyped context attributes in evaluations and targeting rules",
+		},
+		[]string{"source", "kind"}, // source: evaluation|rule, kind: undeclared|mistyped
+	)
+
+	// Store query metrics, labelled by sqlc query name (see db.Instrument).
+	DBQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Database query latency in seconds by query, including reading the rows",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"query"},
+	)
+	DBQueryErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Total number of failed database queries by query",
+		},
+		[]string{"query"},
+	)
+	DBReplicaLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_replica_lag_seconds",
+		Help: "Replication lag of the read replica at the last check",
+	})
+	DBReplicaInUse = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_replica_in_use",
+		Help: "1 while heavy reads go to the read replica, 0 while they fall back to the primary",
+	})
+	StoreCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "store_cache_requests_total",
+			Help: "Total number of flag reads served by the store cache (STORE_CACHE_TTL) by method and result",
+		},
+		[]string{"method", "result"}, // result: hit|miss
+	)
+)
+
+// Handler labels for the evaluation metrics.
+const (
+	EvalHandlerFlags   = "flags_evaluate" // GET/POST /v1/flags/evaluate
+	EvalHandlerContext = "evaluate"       // POST /v1/evaluate
+	EvalHandlerOFREP   = "ofrep"          // POST /ofrep/v1/evaluate/flags[/{key}]
+)
+
+// Error type labels for EvaluationErrors. Evaluation never fails a request:
+// a flag whose expression or rule cannot be evaluated serves its on_error
+// value (see rules.OnErrorConfigKey), and the error is counted here and in
+// FlagEvaluationErrors.
+const (
+	EvalErrorExpression      = "expression"       // targeting expression failed at runtime
+	EvalErrorUnknownOperator = "unknown_operator" // targeting rule condition uses an unknown operator
+	EvalErrorPanic           = "panic"            // evaluation of a corrupt flag panicked and was recovered
 )
 
+// TimeEvaluation wraps an evaluation handler and records its latency in
+// EvaluationDuration under the given handler label.
+func TimeEvaluation(handler string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next(w, r)
+		EvaluationDuration.WithLabelValues(handler).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveSnapshotRebuild records a snapshot rebuild of env that started at
+// start and failed with err, if not nil.
+func ObserveSnapshotRebuild(env string, start time.Time, err error) {
+	SnapshotRebuildDuration.WithLabelValues(env).Observe(time.Since(start).Seconds())
+	if err != nil {
+		SnapshotRebuildFailures.WithLabelValues(env).Inc()
+		return
+	}
+	SnapshotLastRebuild.WithLabelValues(env).SetToCurrentTime()
+}
+
+// ObserveEvaluatedFlags records how many flags one request evaluated.
+func ObserveEvaluatedFlags(handler string, n int) {
+	EvaluationFlags.WithLabelValues(handler).Observe(float64(n))
+}
+
 func Init() {
-	prometheus.MustRegister(httpReqs, httpDur, SSEClients, SnapshotFlags, ActiveAPIKeys, AuthFailures, RateLimitHits)
+	prometheus.MustRegister(httpReqs, httpDur, SSEClients, SnapshotFlags, SnapshotRebuildDuration, SnapshotRebuildFailures, SnapshotLastRebuild, SnapshotExcludedFlags, ActiveAPIKeys, AuthFailures, AuthLockouts, RateLimitHits, FlagExposures,
+		EvaluationDuration, EvaluationFlags, EvaluationErrors, FlagEvaluationErrors, ShadowEvaluations, TenantRequests, TenantRateLimitHits,
+		WebhookCircuitOpens, WebhookDeliveriesSkipped, ContextAttributeViolations, DBQueryDuration, DBQueryErrors,
+		DBReplicaLag, DBReplicaInUse, StoreCacheRequests)
 }
 
 func Middleware(next http.Handler) http.Handler {
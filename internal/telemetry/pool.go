@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector exports Postgres connection pool statistics, read from the
+// pool at scrape time. Acquired connections near the maximum together with a
+// growing wait count point to pool saturation.
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	maxConns      *prometheus.Desc
+	totalConns    *prometheus.Desc
+	acquiredConns *prometheus.Desc
+	idleConns     *prometheus.Desc
+	acquires      *prometheus.Desc
+	waits         *prometheus.Desc
+	waitSeconds   *prometheus.Desc
+	canceled      *prometheus.Desc
+}
+
+// NewPoolCollector returns a collector for the statistics returned by stat,
+// usually (*pgxpool.Pool).Stat.
+func NewPoolCollector(stat func() *pgxpool.Stat) prometheus.Collector {
+	return &poolCollector{
+		stat:          stat,
+		maxConns:      prometheus.NewDesc("db_pool_max_conns", "Maximum size of the database connection pool", nil, nil),
+		totalConns:    prometheus.NewDesc("db_pool_total_conns", "Open database connections (in use, idle and being opened)", nil, nil),
+		acquiredConns: prometheus.NewDesc("db_pool_acquired_conns", "Database connections currently in use", nil, nil),
+		idleConns:     prometheus.NewDesc("db_pool_idle_conns", "Idle database connections", nil, nil),
+		acquires:      prometheus.NewDesc("db_pool_acquires_total", "Total successful connection acquisitions", nil, nil),
+		waits:         prometheus.NewDesc("db_pool_waits_total", "Total acquisitions that had to wait for a connection", nil, nil),
+		waitSeconds:   prometheus.NewDesc("db_pool_acquire_duration_seconds_total", "Total time spent acquiring connections", nil, nil),
+		canceled:      prometheus.NewDesc("db_pool_canceled_acquires_total", "Total acquisitions canceled before a connection was available", nil, nil),
+	}
+}
+
+// RegisterPoolStats registers a pool collector for stat with the default registry.
+func RegisterPoolStats(stat func() *pgxpool.Stat) {
+	prometheus.MustRegister(NewPoolCollector(stat))
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.maxConns, c.totalConns, c.acquiredConns, c.idleConns, c.acquires, c.waits, c.waitSeconds, c.canceled} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitSeconds, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceled, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+}
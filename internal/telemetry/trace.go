@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Requests are correlated across subsystems (store, audit, webhooks, logs) by
+// the chi request ID and, when the caller sends a W3C traceparent header, its
+// trace ID.
+
+type traceContextKey struct{}
+
+// HeaderRequestID is the response header carrying the request ID.
+const HeaderRequestID = "X-Request-ID"
+
+// TraceMiddleware stores the trace ID of the request's traceparent header in
+// the context and echoes the request ID in the response. It must run after
+// middleware.RequestID.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set(HeaderRequestID, id)
+		}
+		if traceID := parseTraceparent(r.Header.Get("traceparent")); traceID != "" {
+			r = r.WithContext(context.WithValue(r.Context(), traceContextKey{}, traceID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TraceID returns the trace ID stored by TraceMiddleware, or "".
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceContextKey{}).(string)
+	return id
+}
+
+// Correlation returns the request and trace IDs of ctx as log fields
+// ("request_id=... trace_id=..."), or "" if there are none.
+func Correlation(ctx context.Context) string {
+	var fields []string
+	if id := middleware.GetReqID(ctx); id != "" {
+		fields = append(fields, "request_id="+id)
+	}
+	if id := TraceID(ctx); id != "" {
+		fields = append(fields, "trace_id="+id)
+	}
+	return strings.Join(fields, " ")
+}
+
+// parseTraceparent returns the trace ID of a W3C traceparent header
+// ("00-<32 hex trace id>-<16 hex parent id>-<2 hex flags>"), or "" if the
+// header is malformed or the trace ID is all zeros.
+func parseTraceparent(header string) string {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ""
+	}
+	traceID := strings.ToLower(parts[1])
+	if strings.Trim(traceID, "0") == "" || strings.Trim(traceID, "0123456789abcdef") != "" {
+		return ""
+	}
+	return traceID
+}
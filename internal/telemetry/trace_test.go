@@ -0,0 +1,46 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := map[string]string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-00": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01": "",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": "",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01":   "",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": "",
+		"": "",
+	}
+	for header, want := range tests {
+		if got := parseTraceparent(header); got != want {
+			t.Errorf("parseTraceparent(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	var correlation string
+	handler := middleware.RequestID(TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		correlation = Correlation(r.Context())
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get(HeaderRequestID); got != "req-1" {
+		t.Errorf("expected the request ID in the response, got %q", got)
+	}
+	if want := "request_id=req-1 trace_id=4bf92f3577b34da6a3ce929d0e0e4736"; correlation != want {
+		t.Errorf("expected %q, got %q", want, correlation)
+	}
+}
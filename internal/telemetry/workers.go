@@ -0,0 +1,62 @@
+package telemetry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+// workerCollector exports the health of supervised background workers (see
+// package worker), read at scrape time. A growing queue depth or a last
+// processed timestamp that stops advancing points to a stuck worker; a
+// growing restart count to a crashing one.
+type workerCollector struct {
+	statuses func() []worker.Status
+
+	up            *prometheus.Desc
+	queueDepth    *prometheus.Desc
+	lastProcessed *prometheus.Desc
+	restarts      *prometheus.Desc
+}
+
+// NewWorkerCollector returns a collector for the worker statuses returned by
+// statuses, usually (*api.Server).WorkerStatuses.
+func NewWorkerCollector(statuses func() []worker.Status) prometheus.Collector {
+	labels := []string{"worker"}
+	return &workerCollector{
+		statuses:      statuses,
+		up:            prometheus.NewDesc("worker_up", "Whether the background worker is running (1) or crashed, stopped or not started (0)", labels, nil),
+		queueDepth:    prometheus.NewDesc("worker_queue_depth", "Items waiting in the background worker's queue", labels, nil),
+		lastProcessed: prometheus.NewDesc("worker_last_processed_timestamp_seconds", "Unix time the background worker last processed an item or finished a run", labels, nil),
+		restarts:      prometheus.NewDesc("worker_restarts_total", "Total restarts of the background worker after a panic", labels, nil),
+	}
+}
+
+// RegisterWorkerStats registers a worker collector for statuses with the
+// default registry.
+func RegisterWorkerStats(statuses func() []worker.Status) {
+	prometheus.MustRegister(NewWorkerCollector(statuses))
+}
+
+func (c *workerCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.up, c.queueDepth, c.lastProcessed, c.restarts} {
+		ch <- d
+	}
+}
+
+func (c *workerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.statuses() {
+		up := 0.0
+		if s.State == worker.StateRunning {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up, s.Name)
+		ch <- prometheus.MustNewConstMetric(c.restarts, prometheus.CounterValue, float64(s.Restarts), s.Name)
+		if s.QueueDepth != nil {
+			ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(*s.QueueDepth), s.Name)
+		}
+		if s.LastProcessed != nil {
+			ch <- prometheus.MustNewConstMetric(c.lastProcessed, prometheus.GaugeValue, float64(s.LastProcessed.UnixNano())/1e9, s.Name)
+		}
+	}
+}
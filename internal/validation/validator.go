@@ -3,9 +3,13 @@ package validation
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/TimurManjosov/goflagship/internal/rules"
 )
 
 const (
@@ -23,11 +27,28 @@ const (
 	MaxRollout = 100
 	// MaxVariantNameLength is the maximum length for variant names
 	MaxVariantNameLength = 64
+	// MaxTags is the maximum number of tags on a flag
+	MaxTags = 20
+	// MaxTagLength is the maximum length for a single tag
+	MaxTagLength = 64
+	// MaxOwnerLength is the maximum length for a flag owner
+	MaxOwnerLength = 128
+	// MaxTicketURLLength is the maximum length for a flag's ticket URL
+	MaxTicketURLLength = 2048
+	// MaxMetadataEntries is the maximum number of metadata entries on a flag
+	MaxMetadataEntries = 50
+	// MaxMetadataKeyLength is the maximum length for a metadata key
+	MaxMetadataKeyLength = 64
+	// MaxMetadataValueLength is the maximum length for a metadata value
+	MaxMetadataValueLength = 1024
 )
 
 // keyPattern matches alphanumeric characters, underscores, and hyphens
 var keyPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
+// metadataKeyPattern also allows dots, for namespaced keys like "team.slack"
+var metadataKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
 // ValidationResult holds the result of validation
 type ValidationResult struct {
 	Valid  bool
@@ -68,6 +89,10 @@ type FlagValidationParams struct {
 	ConfigJSON  string // Raw JSON string for size validation
 	Variants    []VariantValidationParams
 	Expression  *string
+	Tags        []string
+	Owner       string
+	TicketURL   string
+	Metadata    map[string]string
 }
 
 // VariantValidationParams contains the parameters for validating a variant
@@ -93,8 +118,10 @@ type VariantValidationParams struct {
 //   2. Env validation (required, max length)
 //   3. Description validation (max length)
 //   4. Rollout validation (range 0-100)
-//   5. Config size validation (if ConfigJSON provided)
+//   5. Config size validation (if ConfigJSON provided) and on_error type
 //   6. Variants validation (if Variants provided)
+//   7. Tags validation (if Tags provided)
+//   8. Owner, ticket URL and metadata validation
 //
 // Edge Cases:
 //   - All required fields (e.g., Key, Env) empty: Multiple validation errors returned for those fields
@@ -133,12 +160,28 @@ func ValidateFlag(params FlagValidationParams) *ValidationResult {
 		result.Merge(configResult)
 	}
 
+	// The on_error fallback is served as the enabled state, so it must be a boolean
+	if v, ok := params.Config[rules.OnErrorConfigKey]; ok {
+		if _, isBool := v.(bool); !isBool {
+			result.AddError("config."+rules.OnErrorConfigKey, "on_error must be a boolean")
+		}
+	}
+
 	// Validate variants if provided
 	if len(params.Variants) > 0 {
 		variantsResult := ValidateVariants(params.Variants)
 		result.Merge(variantsResult)
 	}
 
+	// Validate tags if provided
+	if len(params.Tags) > 0 {
+		result.Merge(ValidateTags(params.Tags))
+	}
+
+	result.Merge(ValidateOwner(params.Owner))
+	result.Merge(ValidateTicketURL(params.TicketURL))
+	result.Merge(ValidateMetadata(params.Metadata))
+
 	return result
 }
 
@@ -203,6 +246,87 @@ func ValidateEnv(env string) *ValidationResult {
 	return result
 }
 
+// ValidateTags validates flag tags. Tags follow the key pattern so they can
+// be used unescaped in query parameters, and must be unique.
+func ValidateTags(tags []string) *ValidationResult {
+	result := NewValidationResult()
+
+	if len(tags) > MaxTags {
+		result.AddError("tags", "A flag must not have more than 20 tags")
+		return result
+	}
+
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength || !keyPattern.MatchString(tag) {
+			result.AddError("tags", "Tags must be 1-64 alphanumeric characters, underscores, or hyphens")
+			return result
+		}
+		if seen[tag] {
+			result.AddError("tags", "Duplicate tag: "+tag)
+			return result
+		}
+		seen[tag] = true
+	}
+
+	return result
+}
+
+// ValidateOwner validates a flag owner, a free-form team or person name.
+func ValidateOwner(owner string) *ValidationResult {
+	result := NewValidationResult()
+
+	if utf8.RuneCountInString(owner) > MaxOwnerLength {
+		result.AddError("owner", "Owner must not exceed 128 characters")
+	}
+
+	return result
+}
+
+// ValidateTicketURL validates a flag's ticket URL, which must be an absolute
+// http(s) URL if set.
+func ValidateTicketURL(ticketURL string) *ValidationResult {
+	result := NewValidationResult()
+
+	if ticketURL == "" {
+		return result
+	}
+	if len(ticketURL) > MaxTicketURLLength {
+		result.AddError("ticket_url", "Ticket URL must not exceed 2048 characters")
+		return result
+	}
+	u, err := url.Parse(ticketURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		result.AddError("ticket_url", "Ticket URL must be an absolute http or https URL")
+	}
+
+	return result
+}
+
+// ValidateMetadata validates free-form flag metadata. Keys follow the key
+// pattern plus dots; values are limited in length only.
+func ValidateMetadata(metadata map[string]string) *ValidationResult {
+	result := NewValidationResult()
+
+	if len(metadata) > MaxMetadataEntries {
+		result.AddError("metadata", fmt.Sprintf("A flag must not have more than %d metadata entries", MaxMetadataEntries))
+		return result
+	}
+
+	for key, value := range metadata {
+		if key == "" || utf8.RuneCountInString(key) > MaxMetadataKeyLength || !metadataKeyPattern.MatchString(key) {
+			result.AddError("metadata", "Metadata keys must be 1-64 alphanumeric characters, dots, underscores, or hyphens")
+			return result
+		}
+		if utf8.RuneCountInString(value) > MaxMetadataValueLength {
+			result.AddError("metadata."+key, fmt.Sprintf("Metadata values must not exceed %d characters", MaxMetadataValueLength))
+			return result
+		}
+	}
+
+	return result
+}
+
 // ValidateDescription validates a flag description
 func ValidateDescription(description string) *ValidationResult {
 	result := NewValidationResult()
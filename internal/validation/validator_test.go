@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -416,6 +417,129 @@ func TestValidateVariants(t *testing.T) {
 	}
 }
 
+func TestValidateTags(t *testing.T) {
+	tooMany := make([]string, MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+
+	tests := []struct {
+		name        string
+		tags        []string
+		wantValid   bool
+		wantMessage string
+	}{
+		{
+			name:      "no tags",
+			tags:      nil,
+			wantValid: true,
+		},
+		{
+			name:      "valid tags",
+			tags:      []string{"black-friday", "team_checkout"},
+			wantValid: true,
+		},
+		{
+			name:        "empty tag",
+			tags:        []string{""},
+			wantValid:   false,
+			wantMessage: "Tags must be 1-64 alphanumeric characters, underscores, or hyphens",
+		},
+		{
+			name:        "tag with space",
+			tags:        []string{"black friday"},
+			wantValid:   false,
+			wantMessage: "Tags must be 1-64 alphanumeric characters, underscores, or hyphens",
+		},
+		{
+			name:        "duplicate tags",
+			tags:        []string{"checkout", "checkout"},
+			wantValid:   false,
+			wantMessage: "Duplicate tag: checkout",
+		},
+		{
+			name:        "too many tags",
+			tags:        tooMany,
+			wantValid:   false,
+			wantMessage: "A flag must not have more than 20 tags",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateTags(tt.tags)
+			if result.Valid != tt.wantValid {
+				t.Errorf("ValidateTags() valid = %v, want %v, errors = %v", result.Valid, tt.wantValid, result.Errors)
+			}
+			if !tt.wantValid {
+				if msg, ok := result.Errors["tags"]; !ok || msg != tt.wantMessage {
+					t.Errorf("ValidateTags() message = %q, want %q", msg, tt.wantMessage)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTicketURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		ticketURL string
+		wantValid bool
+	}{
+		{name: "empty", ticketURL: "", wantValid: true},
+		{name: "https", ticketURL: "https://jira.example.com/browse/FLAG-12", wantValid: true},
+		{name: "http", ticketURL: "http://tracker.local/issues/3", wantValid: true},
+		{name: "relative", ticketURL: "/browse/FLAG-12", wantValid: false},
+		{name: "other scheme", ticketURL: "javascript:alert(1)", wantValid: false},
+		{name: "no host", ticketURL: "https://", wantValid: false},
+		{name: "too long", ticketURL: "https://example.com/" + strings.Repeat("a", MaxTicketURLLength), wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateTicketURL(tt.ticketURL)
+			if result.Valid != tt.wantValid {
+				t.Errorf("ValidateTicketURL(%q) valid = %v, want %v, errors = %v", tt.ticketURL, result.Valid, tt.wantValid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	tooMany := make(map[string]string, MaxMetadataEntries+1)
+	for i := 0; i <= MaxMetadataEntries; i++ {
+		tooMany[fmt.Sprintf("key-%d", i)] = "v"
+	}
+
+	tests := []struct {
+		name      string
+		metadata  map[string]string
+		wantValid bool
+		wantField string
+	}{
+		{name: "none", metadata: nil, wantValid: true},
+		{name: "valid", metadata: map[string]string{"team.slack": "#checkout", "cost_center": "42"}, wantValid: true},
+		{name: "empty key", metadata: map[string]string{"": "x"}, wantValid: false, wantField: "metadata"},
+		{name: "key with space", metadata: map[string]string{"cost center": "42"}, wantValid: false, wantField: "metadata"},
+		{name: "value too long", metadata: map[string]string{"notes": strings.Repeat("a", MaxMetadataValueLength+1)}, wantValid: false, wantField: "metadata.notes"},
+		{name: "too many entries", metadata: tooMany, wantValid: false, wantField: "metadata"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateMetadata(tt.metadata)
+			if result.Valid != tt.wantValid {
+				t.Errorf("ValidateMetadata() valid = %v, want %v, errors = %v", result.Valid, tt.wantValid, result.Errors)
+			}
+			if !tt.wantValid {
+				if _, ok := result.Errors[tt.wantField]; !ok {
+					t.Errorf("ValidateMetadata() errors = %v, want an error for %q", result.Errors, tt.wantField)
+				}
+			}
+		})
+	}
+}
+
 func TestValidateFlag(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -456,6 +580,28 @@ func TestValidateFlag(t *testing.T) {
 			wantValid:     false,
 			wantNumErrors: 1,
 		},
+		{
+			name: "boolean on_error",
+			params: FlagValidationParams{
+				Key:     "valid_key",
+				Env:     "prod",
+				Rollout: 50,
+				Config:  map[string]any{"on_error": true},
+			},
+			wantValid:     true,
+			wantNumErrors: 0,
+		},
+		{
+			name: "non-boolean on_error",
+			params: FlagValidationParams{
+				Key:     "valid_key",
+				Env:     "prod",
+				Rollout: 50,
+				Config:  map[string]any{"on_error": "true"},
+			},
+			wantValid:     false,
+			wantNumErrors: 1,
+		},
 	}
 
 	for _, tt := range tests {
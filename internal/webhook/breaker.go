@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerConfig configures the per-webhook circuit breaker. After Threshold
+// consecutive failed delivery attempts to a webhook its circuit opens:
+// deliveries to it are skipped (and recorded as such) for Cooldown instead of
+// tying up the worker with retries against a dead endpoint. The first
+// delivery after the cool-down is a probe: success closes the circuit, a
+// failure opens it again.
+type BreakerConfig struct {
+	Threshold int           // consecutive failed attempts that open the circuit (0 disables)
+	Cooldown  time.Duration // how long an open circuit skips deliveries
+}
+
+// CircuitEvent describes a circuit that was just opened.
+type CircuitEvent struct {
+	WebhookID string
+	URL       string
+	Failures  int // consecutive failed attempts
+	OpenFor   time.Duration
+}
+
+type circuitBreaker struct {
+	cfg    BreakerConfig
+	onOpen func(CircuitEvent)
+	now    func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+// SetCircuitBreaker enables the circuit breaker; onOpen, if not nil, is
+// called whenever a circuit opens. A threshold <= 0 disables it.
+func (d *Dispatcher) SetCircuitBreaker(cfg BreakerConfig, onOpen func(CircuitEvent)) {
+	if cfg.Threshold <= 0 {
+		d.breaker.Store(nil)
+		return
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 5 * time.Minute
+	}
+	d.breaker.Store(&circuitBreaker{cfg: cfg, onOpen: onOpen, now: time.Now, circuits: map[string]*circuit{}})
+}
+
+// openUntil reports whether the circuit of webhookID is open, and until when.
+func (b *circuitBreaker) openUntil(webhookID string) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[webhookID]
+	if !ok || !b.now().Before(c.openUntil) {
+		return time.Time{}, false
+	}
+	return c.openUntil, true
+}
+
+// failure records a failed attempt and reports whether it opened the circuit.
+func (b *circuitBreaker) failure(webhookID, url string) bool {
+	b.mu.Lock()
+	c, ok := b.circuits[webhookID]
+	if !ok {
+		c = &circuit{}
+		b.circuits[webhookID] = c
+	}
+	c.failures++
+	now := b.now()
+	opened := c.failures >= b.cfg.Threshold && !now.Before(c.openUntil)
+	if opened {
+		c.openUntil = now.Add(b.cfg.Cooldown)
+	}
+	failures := c.failures
+	b.mu.Unlock()
+
+	if opened && b.onOpen != nil {
+		b.onOpen(CircuitEvent{WebhookID: webhookID, URL: url, Failures: failures, OpenFor: b.cfg.Cooldown})
+	}
+	return opened
+}
+
+// success closes the circuit of webhookID.
+func (b *circuitBreaker) success(webhookID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, webhookID)
+}
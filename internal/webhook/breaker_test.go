@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDispatcher_CircuitBreaker(t *testing.T) {
+	var hits int32
+	var healthy atomic.Bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	queries := &mockQueries{}
+	d := NewDispatcher(queries)
+	var opened []CircuitEvent
+	d.SetCircuitBreaker(BreakerConfig{Threshold: 2, Cooldown: time.Minute}, func(e CircuitEvent) {
+		opened = append(opened, e)
+	})
+	now := time.Unix(1700000000, 0)
+	d.breaker.Load().now = func() time.Time { return now }
+
+	hook := testWebhook(receiver.URL)
+	hook.MaxRetries = 5
+	event := Event{Type: EventFlagUpdated}
+
+	// The second failed attempt opens the circuit and stops the retries.
+	d.deliverWithRetry(context.Background(), hook, event)
+	if atomic.LoadInt32(&hits) != 2 || len(opened) != 1 || opened[0].Failures != 2 || opened[0].OpenFor != time.Minute {
+		t.Fatalf("expected the circuit to open after 2 attempts, got %d hits and %+v", atomic.LoadInt32(&hits), opened)
+	}
+
+	// While open, deliveries are skipped but recorded.
+	d.deliverWithRetry(context.Background(), hook, event)
+	if atomic.LoadInt32(&hits) != 2 {
+		t.Fatalf("delivery should have been skipped, got %d hits", atomic.LoadInt32(&hits))
+	}
+	if last := queries.deliveries[len(queries.deliveries)-1]; last.Success || last.ErrorMessage.String == "" {
+		t.Fatalf("expected a recorded skipped delivery, got %+v", last)
+	}
+
+	// After the cool-down a failed probe opens the circuit again right away.
+	now = now.Add(time.Minute)
+	d.deliverWithRetry(context.Background(), hook, event)
+	if atomic.LoadInt32(&hits) != 3 || len(opened) != 2 {
+		t.Fatalf("expected one failed probe, got %d hits and %d opens", atomic.LoadInt32(&hits), len(opened))
+	}
+
+	// A successful probe closes it.
+	now = now.Add(time.Minute)
+	healthy.Store(true)
+	d.deliverWithRetry(context.Background(), hook, event)
+	if _, open := d.breaker.Load().openUntil(formatWebhookID(hook.ID)); open || atomic.LoadInt32(&hits) != 4 {
+		t.Fatalf("expected the circuit to close, got %d hits", atomic.LoadInt32(&hits))
+	}
+}
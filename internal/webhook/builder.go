@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/TimurManjosov/goflagship/internal/auth"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -31,6 +32,7 @@ type EventBuilder struct {
 func NewEventBuilder(r *http.Request) *EventBuilder {
 	metadata := Metadata{
 		RequestID: middleware.GetReqID(r.Context()),
+		TraceID:   telemetry.TraceID(r.Context()),
 		IPAddress: auth.GetIPAddress(r),
 	}
 	
@@ -46,6 +48,16 @@ func NewEventBuilder(r *http.Request) *EventBuilder {
 	}
 }
 
+// NewSystemEventBuilder creates a builder for events raised by background jobs
+// rather than HTTP requests. The metadata is left empty.
+func NewSystemEventBuilder() *EventBuilder {
+	return &EventBuilder{
+		event: Event{
+			Timestamp: time.Now(),
+		},
+	}
+}
+
 // ForFlag sets the resource to a flag with the given key and environment.
 func (b *EventBuilder) ForFlag(key, env string) *EventBuilder {
 	b.event.Resource = Resource{
@@ -56,6 +68,49 @@ func (b *EventBuilder) ForFlag(key, env string) *EventBuilder {
 	return b
 }
 
+// WithProject sets the project of the event's resource. Webhooks with a
+// project only receive events of that project (see Dispatcher.matches).
+func (b *EventBuilder) WithProject(project string) *EventBuilder {
+	b.event.Project = project
+	return b
+}
+
+// ForReport sets the resource to a generated report for env. The report is
+// sent as the event's "after" data; eventType is set explicitly because
+// reports have no before/after states to derive it from.
+func (b *EventBuilder) ForReport(eventType, name, env string, report map[string]any) *EventBuilder {
+	b.event.Type = eventType
+	b.event.Resource = Resource{
+		Type: "report",
+		Key:  name,
+	}
+	b.event.Environment = env
+	b.event.Data.After = report
+	return b
+}
+
+// ForFlagAlert sets the resource to a flag with the given key and
+// environment and sends data as the event's "after" data, for events about a
+// flag that are not changes to it.
+func (b *EventBuilder) ForFlagAlert(eventType, key, env string, data map[string]any) *EventBuilder {
+	b.ForFlag(key, env)
+	b.event.Type = eventType
+	b.event.Data.After = data
+	return b
+}
+
+// ForAPIKey sets the resource to the API key with the given ID and sends
+// data as the event's "after" data. API keys belong to no environment.
+func (b *EventBuilder) ForAPIKey(eventType, id string, data map[string]any) *EventBuilder {
+	b.event.Type = eventType
+	b.event.Resource = Resource{
+		Type: "api_key",
+		Key:  id,
+	}
+	b.event.Data.After = data
+	return b
+}
+
 // WithStates sets the before and after states for the event.
 // The event type (created/updated/deleted) is automatically determined:
 //   - before=nil, after!=nil → created
@@ -85,6 +140,13 @@ func (b *EventBuilder) WithChanges(changes map[string]any) *EventBuilder {
 	return b
 }
 
+// WithAuditEventID links the event to the audit event that recorded the
+// change. Deliveries of the event store the ID too.
+func (b *EventBuilder) WithAuditEventID(id string) *EventBuilder {
+	b.event.Metadata.AuditEventID = id
+	return b
+}
+
 // Build returns the constructed Event.
 // The returned event is ready to be dispatched via dispatcher.Dispatch().
 func (b *EventBuilder) Build() Event {
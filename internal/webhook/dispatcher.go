@@ -4,15 +4,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	"net/http"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/TimurManjosov/goflagship/internal/auth"
 	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+	"github.com/TimurManjosov/goflagship/internal/telemetry"
+	"github.com/TimurManjosov/goflagship/internal/worker"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -41,15 +48,18 @@ type WebhookQueries interface {
 //   4. Shutdown: Close() — stops worker and waits for pending deliveries
 //
 // Concurrency Model:
-//   - Single background worker goroutine processes events sequentially
+//   - Single background worker goroutine fans events out in order
 //   - Dispatch() is non-blocking (queues event in buffered channel)
-//   - Worker fetches matching webhooks and delivers with retry logic
-//   - Multiple deliveries for same event are sent concurrently (separate HTTP requests)
+//   - Worker fetches matching webhooks and hands each delivery to a pool
+//     (see pool.go) that delivers with retry logic
+//   - Up to SetConcurrency deliveries run at once, across webhooks
+//   - Deliveries to one webhook run one at a time, in event order
 //
 // Queue Behavior:
 //   - Queue size: 1000 events (configurable via queueSize const)
 //   - Queue full: Events are dropped with critical log message
-//   - Queue closed: Worker exits after processing remaining events
+//   - Queue closed: Worker exits after processing remaining events and deliveries
+//   - Per webhook: up to 1000 pending deliveries; more are dropped with critical log message
 //
 // Thread Safety:
 //   - Dispatch() is safe to call from multiple goroutines
@@ -60,30 +70,84 @@ type WebhookQueries interface {
 //   - Database errors: Logged, event is skipped
 //   - HTTP errors: Logged, delivery is retried
 //   - JSON marshal errors: Logged, delivery is marked as failed
+//   - Worker panics: Logged, event is skipped, worker restarts (see Worker)
+//   - Delivery panics: Logged, delivery is skipped and counted as a restart
 type Dispatcher struct {
 	queries WebhookQueries
-	client  *http.Client
+	client  atomic.Pointer[http.Client] // see SetTransport
 	queue   chan Event
 	done    chan struct{}
 	closed  int32 // atomic flag to prevent double-close
+
+	secretLookup atomic.Value                       // SecretLookup, see SetSecretLookup
+	keyring      atomic.Pointer[encryption.Keyring] // decrypts stored secrets and headers, see SetKeyring
+	urlPolicy    atomic.Pointer[URLPolicy]          // restricts delivery targets, see SetURLPolicy
+	proxyAddrs   sync.Map                           // proxy addresses the dialer connects to unchecked
+	breaker      atomic.Pointer[circuitBreaker]     // see SetCircuitBreaker
+	pool         *deliveryPool                      // runs deliveries, see SetConcurrency
+	health       *worker.Worker                     // supervises the worker, see Worker
 }
 
+// SecretLookup returns the signing secret for a webhook ID, if one is
+// managed outside the database (e.g. in a secrets backend).
+type SecretLookup func(webhookID string) (string, bool)
+
 // NewDispatcher creates a new webhook dispatcher
 func NewDispatcher(queries WebhookQueries) *Dispatcher {
-	return &Dispatcher{
+	d := &Dispatcher{
 		queries: queries,
-		client: &http.Client{
-			// Default timeout, will be overridden per-webhook
-			Timeout: 10 * time.Second,
-		},
-		queue: make(chan Event, queueSize),
-		done:  make(chan struct{}),
+		queue:   make(chan Event, queueSize),
+		done:    make(chan struct{}),
+	}
+	d.health = worker.New("webhook_dispatcher", func() int { return len(d.queue) })
+	d.pool = newDeliveryPool(d.deliverWithRetry, d.health.Recovered)
+	client, _ := d.newClient(TransportConfig{})
+	d.client.Store(client)
+	return d
+}
+
+// SetURLPolicy restricts the addresses deliveries may connect to, including
+// redirects. Without a policy any URL is delivered to.
+func (d *Dispatcher) SetURLPolicy(policy *URLPolicy) {
+	d.urlPolicy.Store(policy)
+}
+
+// SetSecretLookup makes deliveries to a webhook signed with the secret lookup
+// returns for its ID, falling back to the secret stored with the webhook. The
+// lookup runs on every delivery, so rotated secrets apply immediately.
+func (d *Dispatcher) SetSecretLookup(lookup SecretLookup) {
+	d.secretLookup.Store(lookup)
+}
+
+// SetKeyring sets the keyring that decrypts webhook secrets and custom
+// headers stored encrypted (see package encryption).
+func (d *Dispatcher) SetKeyring(keyring *encryption.Keyring) {
+	d.keyring.Store(keyring)
+}
+
+// signingSecret is the secret deliveries to webhook are signed with.
+func (d *Dispatcher) signingSecret(webhook dbgen.Webhook) (string, error) {
+	if lookup, _ := d.secretLookup.Load().(SecretLookup); lookup != nil {
+		if secret, ok := lookup(formatWebhookID(webhook.ID)); ok {
+			return secret, nil
+		}
 	}
+	return d.keyring.Load().Decrypt(webhook.Secret)
 }
 
-// Start begins processing events from the queue
+// Start begins processing events from the queue. The worker is restarted if
+// it panics; the event it was processing is skipped.
 func (d *Dispatcher) Start() {
-	go d.worker()
+	go func() {
+		defer close(d.done)
+		d.health.Run(context.Background(), func(context.Context) { d.worker() })
+		d.pool.wait()
+	}()
+}
+
+// Worker returns the supervised goroutine that fans events out.
+func (d *Dispatcher) Worker() *worker.Worker {
+	return d.health
 }
 
 // Stop stops the dispatcher and waits for pending events to be processed.
@@ -151,8 +215,6 @@ func (d *Dispatcher) Dispatch(event Event) {
 
 // worker processes events from the queue
 func (d *Dispatcher) worker() {
-	defer close(d.done)
-	
 	for event := range d.queue {
 		log.Printf("[webhook] processing event: type=%s resource=%s/%s env=%s",
 			event.Type, event.Resource.Type, event.Resource.Key, event.Environment)
@@ -161,18 +223,24 @@ func (d *Dispatcher) worker() {
 		if err != nil {
 			log.Printf("[webhook] failed to fetch webhooks for event: type=%s resource=%s/%s env=%s error=%v",
 				event.Type, event.Resource.Type, event.Resource.Key, event.Environment, err)
+			d.health.MarkProcessed()
 			continue
 		}
 
 		log.Printf("[webhook] found %d matching webhook(s) for event: type=%s resource=%s/%s",
 			len(webhooks), event.Type, event.Resource.Type, event.Resource.Key)
 
-		for _, webhook := range webhooks {
-			d.deliverWithRetry(context.Background(), webhook, event)
-		}
+		d.schedule(webhooks, event)
+		d.health.MarkProcessed()
 	}
 }
 
+// MatchingWebhooks returns the active webhooks event would be delivered to,
+// without delivering it. Used by dry runs.
+func (d *Dispatcher) MatchingWebhooks(ctx context.Context, event Event) ([]dbgen.Webhook, error) {
+	return d.getMatchingWebhooks(ctx, event)
+}
+
 // getMatchingWebhooks finds all webhooks that should receive this event
 func (d *Dispatcher) getMatchingWebhooks(ctx context.Context, event Event) ([]dbgen.Webhook, error) {
 	// Get all active webhooks
@@ -219,8 +287,16 @@ func (d *Dispatcher) matches(webhook dbgen.Webhook, event Event) bool {
 		}
 	}
 
-	// Note: project_id filtering would go here if we had projects
-	// For now, we don't filter by project since the schema doesn't have projects yet
+	// Tenants only receive events from their own environments
+	if !auth.TenantOwnsEnv(webhook.Tenant, event.Environment) {
+		return false
+	}
+
+	// Webhooks with a project only receive that project's events; events
+	// that belong to no project (reports, API keys) reach every webhook
+	if webhook.ProjectID.Valid && event.Project != "" && event.Project != webhook.ProjectID.String {
+		return false
+	}
 
 	return true
 }
@@ -252,6 +328,7 @@ func (d *Dispatcher) matches(webhook dbgen.Webhook, event Event) bool {
 //     - X-Flagship-Signature: HMAC-SHA256 of payload
 //     - X-Flagship-Event: event type
 //     - X-Flagship-Delivery: unique UUID for this delivery
+//     - X-Request-ID, traceparent: request and trace ID of the change, if any
 //   - Timeout: webhook.TimeoutSeconds (per-request timeout)
 //   - Body: JSON-serialized event
 //
@@ -266,6 +343,8 @@ func (d *Dispatcher) matches(webhook dbgen.Webhook, event Event) bool {
 //   - All retries fail: Final attempt logged as permanent failure
 //   - Context canceled: Current request aborted, logged as error
 //   - Response body > 1KB: Truncated to 1KB for storage
+//   - Circuit open (see SetCircuitBreaker): Skipped, recorded as a failed delivery
+//   - Attempt opens the circuit: No further retries
 //
 // Delivery Record:
 //   Each attempt creates a database record with:
@@ -278,37 +357,80 @@ func (d *Dispatcher) deliverWithRetry(ctx context.Context, webhook dbgen.Webhook
 		// This should not happen, but if it does, log delivery failure
 		log.Printf("[webhook] failed to marshal event payload: webhook_id=%s event_type=%s error=%v",
 			formatWebhookID(webhook.ID), event.Type, err)
-		d.logDelivery(ctx, webhook.ID, event.Type, payload, 0, "", err.Error(), 0, false, 0)
+		d.logDelivery(ctx, webhook.ID, event, payload, 0, "", err.Error(), 0, false, 0)
 		return
 	}
 
-	signature := ComputeHMAC(payload, webhook.Secret)
+	secret, err := d.signingSecret(webhook)
+	if err != nil {
+		log.Printf("[webhook] cannot sign delivery: webhook_id=%s event_type=%s error=%v",
+			formatWebhookID(webhook.ID), event.Type, err)
+		d.logDelivery(ctx, webhook.ID, event, payload, 0, "", "signing secret unavailable: "+err.Error(), 0, false, 0)
+		return
+	}
+	headers, err := DecodeHeaders(d.keyring.Load(), webhook.Headers)
+	if err != nil {
+		log.Printf("[webhook] cannot read custom headers: webhook_id=%s event_type=%s error=%v",
+			formatWebhookID(webhook.ID), event.Type, err)
+		d.logDelivery(ctx, webhook.ID, event, payload, 0, "", "custom headers unavailable: "+err.Error(), 0, false, 0)
+		return
+	}
+	if policy := d.urlPolicy.Load(); policy != nil {
+		if err := policy.CheckURL(webhook.Url); err != nil {
+			log.Printf("[webhook] refusing delivery: webhook_id=%s url=%s error=%v",
+				formatWebhookID(webhook.ID), webhook.Url, err)
+			d.logDelivery(ctx, webhook.ID, event, payload, 0, "", err.Error(), 0, false, 0)
+			return
+		}
+	}
+	signature := ComputeHMAC(payload, secret)
 	deliveryID := uuid.New().String()
 	webhookIDStr := formatWebhookID(webhook.ID)
 
+	breaker := d.breaker.Load()
+	if breaker != nil {
+		if until, open := breaker.openUntil(webhookIDStr); open {
+			log.Printf("[webhook] circuit open, skipping delivery: webhook_id=%s event_type=%s open_until=%s",
+				webhookIDStr, event.Type, until.Format(time.RFC3339))
+			telemetry.WebhookDeliveriesSkipped.WithLabelValues(webhookIDStr).Inc()
+			d.logDelivery(ctx, webhook.ID, event, payload, 0, "", "circuit open until "+until.UTC().Format(time.RFC3339)+", delivery skipped", 0, false, 0)
+			return
+		}
+	}
+
 	for attempt := 0; attempt <= int(webhook.MaxRetries); attempt++ {
 		start := time.Now()
 
-		log.Printf("[webhook] delivering: webhook_id=%s url=%s event_type=%s attempt=%d/%d",
-			webhookIDStr, webhook.Url, event.Type, attempt+1, webhook.MaxRetries+1)
+		log.Printf("[webhook] delivering: webhook_id=%s url=%s event_type=%s attempt=%d/%d request_id=%s",
+			webhookIDStr, webhook.Url, event.Type, attempt+1, webhook.MaxRetries+1, event.Metadata.RequestID)
 
 		req, err := http.NewRequest("POST", webhook.Url, bytes.NewReader(payload))
 		if err != nil {
 			log.Printf("[webhook] failed to create request: webhook_id=%s url=%s error=%v",
 				webhookIDStr, webhook.Url, err)
-			d.logDelivery(ctx, webhook.ID, event.Type, payload, 0, "", err.Error(), 0, false, attempt)
+			d.logDelivery(ctx, webhook.ID, event, payload, 0, "", err.Error(), 0, false, attempt)
 			return
 		}
 
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("X-Flagship-Signature", signature)
 		req.Header.Set("X-Flagship-Event", event.Type)
 		req.Header.Set("X-Flagship-Delivery", deliveryID)
+		if event.Metadata.RequestID != "" {
+			req.Header.Set("X-Request-ID", event.Metadata.RequestID)
+		}
+		if event.Metadata.TraceID != "" {
+			// Receivers continue the trace; the delivery has no span of its own.
+			req.Header.Set("traceparent", "00-"+event.Metadata.TraceID+"-"+deliverySpanID(deliveryID)+"-01")
+		}
 
 		// Create context with timeout for this request
 		reqCtx, cancel := context.WithTimeout(ctx, time.Duration(webhook.TimeoutSeconds)*time.Second)
 		
-		resp, err := d.client.Do(req.WithContext(reqCtx))
+		resp, err := d.client.Load().Do(req.WithContext(reqCtx))
 		duration := time.Since(start)
 
 		var statusCode int
@@ -331,15 +453,28 @@ func (d *Dispatcher) deliverWithRetry(ctx context.Context, webhook dbgen.Webhook
 		success := (err == nil && statusCode >= 200 && statusCode < 300)
 
 		// Log this delivery attempt
-		d.logDelivery(ctx, webhook.ID, event.Type, payload, statusCode, responseBody, errorMsg, int(duration.Milliseconds()), success, attempt)
+		d.logDelivery(ctx, webhook.ID, event, payload, statusCode, responseBody, errorMsg, int(duration.Milliseconds()), success, attempt)
 
 		if success {
 			log.Printf("[webhook] delivery succeeded: webhook_id=%s status=%d duration=%dms attempt=%d/%d",
 				webhookIDStr, statusCode, duration.Milliseconds(), attempt+1, webhook.MaxRetries+1)
 			// Update last triggered timestamp
 			_ = d.queries.UpdateWebhookLastTriggered(ctx, webhook.ID)
+			if breaker != nil {
+				breaker.success(webhookIDStr)
+			}
 			return // Success, no retry needed
 		}
+		if breaker != nil && breaker.failure(webhookIDStr, webhook.Url) {
+			log.Printf("[webhook] circuit opened after consecutive failures: webhook_id=%s cooldown=%s",
+				webhookIDStr, breaker.cfg.Cooldown)
+			telemetry.WebhookCircuitOpens.WithLabelValues(webhookIDStr).Inc()
+			return // Stop retrying a dead endpoint
+		}
+		if errors.Is(err, ErrBlockedURL) {
+			log.Printf("[webhook] delivery blocked: webhook_id=%s error=%q", webhookIDStr, errorMsg)
+			return // Retrying won't help
+		}
 
 		// Delivery failed
 		if attempt < int(webhook.MaxRetries) {
@@ -368,15 +503,19 @@ func formatWebhookID(id pgtype.UUID) string {
 }
 
 // logDelivery records a webhook delivery attempt in the database
-func (d *Dispatcher) logDelivery(ctx context.Context, webhookID pgtype.UUID, eventType string, payload []byte, statusCode int, responseBody string, errorMsg string, durationMs int, success bool, retryCount int) {
+func (d *Dispatcher) logDelivery(ctx context.Context, webhookID pgtype.UUID, event Event, payload []byte, statusCode int, responseBody string, errorMsg string, durationMs int, success bool, retryCount int) {
 	params := dbgen.CreateWebhookDeliveryParams{
 		WebhookID: webhookID,
-		EventType: eventType,
+		EventType: event.Type,
 		Payload:   payload,
 		Success:   success,
 		RetryCount: int32(retryCount),
 	}
 
+	if event.Metadata.AuditEventID != "" {
+		_ = params.AuditEventID.Scan(event.Metadata.AuditEventID) // left NULL if not a UUID
+	}
+
 	if statusCode > 0 {
 		params.StatusCode = pgtype.Int4{Int32: int32(statusCode), Valid: true}
 	}
@@ -396,3 +535,9 @@ func (d *Dispatcher) logDelivery(ctx context.Context, webhookID pgtype.UUID, eve
 	// Log delivery (fire and forget, don't fail the delivery if logging fails)
 	_, _ = d.queries.CreateWebhookDelivery(ctx, params)
 }
+
+// deliverySpanID derives the parent ID of a delivery's traceparent header
+// from its delivery ID.
+func deliverySpanID(deliveryID string) string {
+	return strings.ReplaceAll(deliveryID, "-", "")[:16]
+}
@@ -1,10 +1,14 @@
 package webhook
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"testing"
 
 	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestDispatcher_matches(t *testing.T) {
@@ -94,6 +98,48 @@ func TestDispatcher_matches(t *testing.T) {
 	}
 }
 
+func TestDispatcher_signingSecret(t *testing.T) {
+	id := uuid.New()
+	wh := dbgen.Webhook{ID: pgtype.UUID{Bytes: id, Valid: true}, Secret: "stored"}
+	d := &Dispatcher{}
+
+	if got, _ := d.signingSecret(wh); got != "stored" {
+		t.Errorf("without lookup: got %q, want stored", got)
+	}
+
+	d.SetSecretLookup(func(webhookID string) (string, bool) {
+		if webhookID == id.String() {
+			return "managed", true
+		}
+		return "", false
+	})
+	if got, _ := d.signingSecret(wh); got != "managed" {
+		t.Errorf("with lookup: got %q, want managed", got)
+	}
+	other := dbgen.Webhook{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Secret: "other"}
+	if got, _ := d.signingSecret(other); got != "other" {
+		t.Errorf("unmanaged webhook: got %q, want other", got)
+	}
+}
+
+func TestDispatcher_signingSecretEncrypted(t *testing.T) {
+	keyring, err := encryption.ParseKeys("k1:" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	stored, _ := keyring.Encrypt("whsec")
+	wh := dbgen.Webhook{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Secret: stored}
+
+	d := &Dispatcher{}
+	if _, err := d.signingSecret(wh); err == nil {
+		t.Error("expected an error without a keyring")
+	}
+	d.SetKeyring(keyring)
+	if got, err := d.signingSecret(wh); err != nil || got != "whsec" {
+		t.Errorf("signingSecret = %q, %v", got, err)
+	}
+}
+
 func TestEvent_JSONMarshaling(t *testing.T) {
 	event := Event{
 		Type:        EventFlagUpdated,
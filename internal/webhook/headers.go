@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+)
+
+// Custom headers are static headers sent with every delivery to a webhook,
+// e.g. an Authorization header for receivers with their own authentication.
+// They are stored as a JSON object of header name to value; each value is
+// encrypted with the keyring like the webhook secret, so names can be listed
+// without decrypting anything.
+
+const (
+	// MaxCustomHeaders is the number of custom headers a webhook may have.
+	MaxCustomHeaders = 20
+	// maxCustomHeaderValue bounds the length of a custom header value.
+	maxCustomHeaderValue = 4096
+)
+
+// reservedHeaders are set by the dispatcher or the HTTP client and cannot be
+// overridden, so deliveries stay verifiable. X-Flagship-* is reserved too.
+var reservedHeaders = []string{"Content-Type", "Content-Length", "Host", "Traceparent", "X-Request-Id"}
+
+// ValidateHeaders returns a description of what is wrong with headers, or
+// "" if they can be stored.
+func ValidateHeaders(headers map[string]string) string {
+	if len(headers) > MaxCustomHeaders {
+		return fmt.Sprintf("at most %d headers are allowed", MaxCustomHeaders)
+	}
+	for _, name := range sortedNames(headers) {
+		canonical := http.CanonicalHeaderKey(name)
+		switch {
+		case !isHeaderName(name):
+			return fmt.Sprintf("%q is not a valid header name", name)
+		case strings.HasPrefix(canonical, "X-Flagship-") || containsFold(reservedHeaders, canonical):
+			return fmt.Sprintf("%q is set by flagship and cannot be overridden", name)
+		case len(headers[name]) > maxCustomHeaderValue:
+			return fmt.Sprintf("the value of %q must be at most %d bytes", name, maxCustomHeaderValue)
+		case strings.ContainsAny(headers[name], "\r\n\x00"):
+			return fmt.Sprintf("the value of %q must not contain line breaks", name)
+		}
+	}
+	return ""
+}
+
+// EncodeHeaders returns the stored form of headers, each value encrypted
+// with keyring (or kept in plaintext if keyring is nil).
+func EncodeHeaders(keyring *encryption.Keyring, headers map[string]string) ([]byte, error) {
+	stored := make(map[string]string, len(headers))
+	for name, value := range headers {
+		encrypted, err := keyring.Encrypt(value)
+		if err != nil {
+			return nil, err
+		}
+		stored[http.CanonicalHeaderKey(name)] = encrypted
+	}
+	return json.Marshal(stored)
+}
+
+// HeaderNames returns the names of the stored headers, sorted.
+func HeaderNames(stored []byte) []string {
+	var headers map[string]string
+	if err := json.Unmarshal(stored, &headers); err != nil {
+		return nil
+	}
+	return sortedNames(headers)
+}
+
+// DecodeHeaders decrypts the stored headers.
+func DecodeHeaders(keyring *encryption.Keyring, stored []byte) (map[string]string, error) {
+	if len(stored) == 0 {
+		return nil, nil
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(stored, &headers); err != nil {
+		return nil, fmt.Errorf("decode webhook headers: %w", err)
+	}
+	for name, value := range headers {
+		plaintext, err := keyring.Decrypt(value)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt header %s: %w", name, err)
+		}
+		headers[name] = plaintext
+	}
+	return headers, nil
+}
+
+// RotateHeaders re-encrypts the stored header values with the primary key.
+// It returns stored unchanged and false if no value needed rotating.
+func RotateHeaders(keyring *encryption.Keyring, stored []byte) ([]byte, bool, error) {
+	if len(stored) == 0 {
+		return stored, false, nil
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(stored, &headers); err != nil {
+		return nil, false, fmt.Errorf("decode webhook headers: %w", err)
+	}
+	changed := false
+	for name, value := range headers {
+		rotated, ok, err := keyring.Rotate(value)
+		if err != nil {
+			return nil, false, fmt.Errorf("header %s: %w", name, err)
+		}
+		headers[name] = rotated
+		changed = changed || ok
+	}
+	if !changed {
+		return stored, false, nil
+	}
+	rotated, err := json.Marshal(headers)
+	return rotated, err == nil, err
+}
+
+// isHeaderName reports whether name is a valid HTTP header field name (an
+// RFC 7230 token).
+func isHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(headers map[string]string) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/encryption"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	if msg := ValidateHeaders(map[string]string{"Authorization": "Bearer x", "X-Team": "payments"}); msg != "" {
+		t.Errorf("valid headers rejected: %s", msg)
+	}
+	for name, headers := range map[string]map[string]string{
+		"invalid name":   {"Bad Header": "x"},
+		"reserved":       {"content-type": "text/plain"},
+		"flagship":       {"X-Flagship-Signature": "sha256=forged"},
+		"line break":     {"X-Team": "a\r\nX-Injected: b"},
+		"too long value": {"X-Team": strings.Repeat("a", maxCustomHeaderValue+1)},
+	} {
+		if ValidateHeaders(headers) == "" {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestEncodeHeaders_EncryptsValuesOnly(t *testing.T) {
+	keyring, err := encryption.ParseKeys("k1:" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	stored, err := EncodeHeaders(keyring, map[string]string{"authorization": "Bearer secret-token"})
+	if err != nil {
+		t.Fatalf("EncodeHeaders: %v", err)
+	}
+	if bytes.Contains(stored, []byte("secret-token")) {
+		t.Fatalf("value stored in plaintext: %s", stored)
+	}
+	if names := HeaderNames(stored); !slices.Equal(names, []string{"Authorization"}) {
+		t.Errorf("HeaderNames = %v", names)
+	}
+
+	if _, err := DecodeHeaders(nil, stored); err == nil {
+		t.Error("expected an error without a keyring")
+	}
+	headers, err := DecodeHeaders(keyring, stored)
+	if err != nil || headers["Authorization"] != "Bearer secret-token" {
+		t.Errorf("DecodeHeaders = %v, %v", headers, err)
+	}
+
+	if _, changed, err := RotateHeaders(keyring, stored); err != nil || changed {
+		t.Errorf("headers already use the primary key, got changed=%v, %v", changed, err)
+	}
+	plaintext, _ := EncodeHeaders(nil, map[string]string{"X-Team": "payments"})
+	rotated, changed, err := RotateHeaders(keyring, plaintext)
+	if err != nil || !changed || bytes.Contains(rotated, []byte("payments")) {
+		t.Errorf("plaintext headers should be encrypted, got %s, %v, %v", rotated, changed, err)
+	}
+}
@@ -40,6 +40,13 @@ func TestWebhookIntegration(t *testing.T) {
 		if deliveryID == "" {
 			t.Error("Missing X-Flagship-Delivery header")
 		}
+
+		if got := r.Header.Get("X-Request-ID"); got != "test-request-123" {
+			t.Errorf("Expected X-Request-ID of the event, got %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer receiver-token" {
+			t.Errorf("Expected the custom Authorization header, got %q", got)
+		}
 		
 		// Read and decode payload
 		body, err := io.ReadAll(r.Body)
@@ -84,6 +91,7 @@ func TestWebhookIntegration(t *testing.T) {
 				Secret:         "test-secret-123",
 				MaxRetries:     3,
 				TimeoutSeconds: 10,
+				Headers:        []byte(`{"Authorization":"Bearer receiver-token"}`),
 			},
 		},
 	}
@@ -113,7 +121,8 @@ func TestWebhookIntegration(t *testing.T) {
 			},
 		},
 		Metadata: Metadata{
-			RequestID: "test-request-123",
+			RequestID:    "test-request-123",
+			AuditEventID: "8c1f3a52-2b9e-4d7a-9f0e-5a6b7c8d9e0f",
 		},
 	}
 
@@ -129,6 +138,9 @@ func TestWebhookIntegration(t *testing.T) {
 		if receivedEvent.Resource.Key != testEvent.Resource.Key {
 			t.Errorf("Resource key mismatch: got %s, want %s", receivedEvent.Resource.Key, testEvent.Resource.Key)
 		}
+		if receivedEvent.Metadata.AuditEventID != testEvent.Metadata.AuditEventID {
+			t.Errorf("Audit event ID mismatch: got %s, want %s", receivedEvent.Metadata.AuditEventID, testEvent.Metadata.AuditEventID)
+		}
 	case <-time.After(5 * time.Second):
 		t.Fatal("Timeout waiting for webhook delivery")
 	}
@@ -151,6 +163,9 @@ func TestWebhookIntegration(t *testing.T) {
 		if delivery.RetryCount != 0 {
 			t.Errorf("Expected retry count to be 0, got %d", delivery.RetryCount)
 		}
+		if got := formatUUID(delivery.AuditEventID); got != testEvent.Metadata.AuditEventID {
+			t.Errorf("Expected delivery to record audit event %s, got %q", testEvent.Metadata.AuditEventID, got)
+		}
 	}
 }
 
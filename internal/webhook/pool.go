@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+const (
+	// defaultConcurrency is how many deliveries run at once unless
+	// SetConcurrency says otherwise.
+	defaultConcurrency = 4
+
+	// maxPendingPerWebhook bounds the deliveries waiting for one webhook;
+	// beyond it new deliveries to that webhook are dropped.
+	maxPendingPerWebhook = queueSize
+)
+
+// deliveryPool runs deliveries concurrently while keeping them in order per
+// webhook: each webhook with pending deliveries has one goroutine draining
+// its queue, and a semaphore bounds how many deliveries are in flight across
+// all webhooks. A slow or failing webhook therefore delays only its own
+// deliveries (and holds at most one slot).
+type deliveryPool struct {
+	deliver func(ctx context.Context, webhook dbgen.Webhook, event Event)
+	onPanic func(v any) // called after a delivery panicked
+	sem     atomic.Pointer[chan struct{}]
+
+	mu     sync.Mutex
+	queues map[string][]delivery // present while a goroutine drains it
+	wg     sync.WaitGroup
+}
+
+type delivery struct {
+	webhook dbgen.Webhook
+	event   Event
+}
+
+func newDeliveryPool(deliver func(ctx context.Context, webhook dbgen.Webhook, event Event), onPanic func(v any)) *deliveryPool {
+	p := &deliveryPool{deliver: deliver, onPanic: onPanic, queues: map[string][]delivery{}}
+	p.setConcurrency(defaultConcurrency)
+	return p
+}
+
+// SetConcurrency sets how many deliveries may run at once; n <= 0 restores
+// the default of 4. Deliveries to one webhook always run one at a time, in
+// event order.
+func (d *Dispatcher) SetConcurrency(n int) {
+	d.pool.setConcurrency(n)
+}
+
+func (p *deliveryPool) setConcurrency(n int) {
+	if n <= 0 {
+		n = defaultConcurrency
+	}
+	sem := make(chan struct{}, n)
+	p.sem.Store(&sem)
+}
+
+// enqueue queues a delivery behind the pending deliveries of its webhook. It
+// returns false if that webhook has too many pending deliveries.
+func (p *deliveryPool) enqueue(webhookID string, dl delivery) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	queue, draining := p.queues[webhookID]
+	if len(queue) >= maxPendingPerWebhook {
+		return false
+	}
+	p.queues[webhookID] = append(queue, dl)
+	if !draining {
+		p.wg.Add(1)
+		go p.drain(webhookID)
+	}
+	return true
+}
+
+// drain delivers the queued deliveries of webhookID in order until none are
+// left.
+func (p *deliveryPool) drain(webhookID string) {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		queue := p.queues[webhookID]
+		if len(queue) == 0 {
+			delete(p.queues, webhookID)
+			p.mu.Unlock()
+			return
+		}
+		next := queue[0]
+		queue[0] = delivery{}
+		p.queues[webhookID] = queue[1:]
+		p.mu.Unlock()
+
+		// Release to the semaphore acquired from, even if SetConcurrency
+		// replaced it meanwhile.
+		sem := *p.sem.Load()
+		sem <- struct{}{}
+		p.deliverRecovered(next)
+		<-sem
+	}
+}
+
+// deliverRecovered runs dl, recovering from a panic so the process survives
+// and the following deliveries to the webhook still run.
+func (p *deliveryPool) deliverRecovered(dl delivery) {
+	defer func() {
+		if v := recover(); v != nil {
+			p.onPanic(v)
+		}
+	}()
+	p.deliver(context.Background(), dl.webhook, dl.event)
+}
+
+// wait blocks until all queued deliveries are done.
+func (p *deliveryPool) wait() {
+	p.wg.Wait()
+}
+
+// schedule hands the deliveries of event to the pool.
+func (d *Dispatcher) schedule(webhooks []dbgen.Webhook, event Event) {
+	for _, webhook := range webhooks {
+		id := formatWebhookID(webhook.ID)
+		if !d.pool.enqueue(id, delivery{webhook: webhook, event: event}) {
+			log.Printf("[webhook] CRITICAL: %d deliveries pending for webhook, dropping event: webhook_id=%s type=%s resource=%s/%s",
+				maxPendingPerWebhook, id, event.Type, event.Resource.Type, event.Resource.Key)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+	"github.com/TimurManjosov/goflagship/internal/worker"
+)
+
+func TestDispatcher_ConcurrentDeliveriesInOrderPerWebhook(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+		received = map[string][]string{} // webhook path -> resource keys in arrival order
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		received[r.URL.Path] = append(received[r.URL.Path], event.Resource.Key)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	queries := &mockQueries{}
+	for i, id := range []string{
+		"550e8400-e29b-41d4-a716-446655440001",
+		"550e8400-e29b-41d4-a716-446655440002",
+		"550e8400-e29b-41d4-a716-446655440003",
+	} {
+		queries.webhooks = append(queries.webhooks, dbgen.Webhook{
+			ID:             uuidFromString(id),
+			Url:            receiver.URL + "/hook" + strconv.Itoa(i),
+			Enabled:        true,
+			Events:         []string{EventFlagUpdated},
+			Secret:         "test-secret",
+			TimeoutSeconds: 5,
+		})
+	}
+
+	d := NewDispatcher(queries)
+	d.SetConcurrency(2)
+	d.Start()
+	const events = 5
+	for i := 0; i < events; i++ {
+		d.Dispatch(Event{Type: EventFlagUpdated, Resource: Resource{Type: "flag", Key: strconv.Itoa(i)}})
+	}
+	_ = d.Close() // waits for all deliveries
+
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 2 {
+		t.Errorf("expected 2 deliveries in flight at peak, got %d", peak)
+	}
+	for i := range queries.webhooks {
+		got := received["/hook"+strconv.Itoa(i)]
+		if len(got) != events {
+			t.Fatalf("webhook %d: expected %d deliveries, got %v", i, events, got)
+		}
+		for j, key := range got {
+			if key != strconv.Itoa(j) {
+				t.Fatalf("webhook %d: deliveries out of order: %v", i, got)
+			}
+		}
+	}
+}
+
+func TestDeliveryPool_RecoversFromPanickingDelivery(t *testing.T) {
+	w := worker.New("webhook_dispatcher", nil)
+	var (
+		mu        sync.Mutex
+		delivered []string
+	)
+	p := newDeliveryPool(func(_ context.Context, _ dbgen.Webhook, event Event) {
+		if event.Resource.Key == "bad" {
+			panic("boom")
+		}
+		mu.Lock()
+		delivered = append(delivered, event.Resource.Key)
+		mu.Unlock()
+	}, w.Recovered)
+
+	for _, key := range []string{"a", "bad", "b"} {
+		p.enqueue("hook", delivery{event: Event{Resource: Resource{Key: key}}})
+	}
+	p.wait()
+
+	if len(delivered) != 2 || delivered[0] != "a" || delivered[1] != "b" {
+		t.Errorf("expected the deliveries after the panic to run, got %v", delivered)
+	}
+	if status := w.Status(); status.Restarts != 1 || status.LastPanic != "boom" {
+		t.Errorf("expected the panic to be recorded on the worker, got %+v", status)
+	}
+}
@@ -0,0 +1,219 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// URLPolicy decides where webhooks may be delivered, to keep the server from
+// being used to reach internal services (SSRF). By default it blocks
+// loopback, private (RFC 1918, unique local), link-local (including cloud
+// metadata endpoints like 169.254.169.254), carrier-grade NAT, multicast and
+// unspecified addresses. Hosts and networks on the allowlist are exempt.
+//
+// URLs are checked when webhooks are created or updated (ValidateURL), and
+// again at delivery: the dispatcher's dialer resolves the host once, checks
+// every address and connects to the checked address, so a DNS answer that
+// changes between check and connect (DNS rebinding) cannot slip through.
+type URLPolicy struct {
+	requireHTTPS bool
+	allowHosts   map[string]bool
+	allowNets    []netip.Prefix
+	resolver     *net.Resolver
+}
+
+// URLPolicyConfig configures a URLPolicy.
+type URLPolicyConfig struct {
+	// RequireHTTPS rejects http:// URLs (enabled when APP_ENV=prod).
+	RequireHTTPS bool
+	// Allowlist holds hostnames, IP addresses and CIDR networks that may be
+	// targeted even though they resolve to blocked addresses.
+	Allowlist []string
+}
+
+// ErrBlockedURL is returned (wrapped) for URLs the policy refuses.
+var ErrBlockedURL = errors.New("webhook URL not allowed")
+
+// blockedPrefixes are address ranges not covered by the netip.Addr
+// predicates used in blockedAddr.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, may embed any IPv4 address
+}
+
+// NewURLPolicy creates a URLPolicy. Allowlist entries that are neither an IP
+// address nor a CIDR network are treated as hostnames.
+func NewURLPolicy(cfg URLPolicyConfig) (*URLPolicy, error) {
+	p := &URLPolicy{
+		requireHTTPS: cfg.RequireHTTPS,
+		allowHosts:   map[string]bool{},
+		resolver:     net.DefaultResolver,
+	}
+	for _, entry := range cfg.Allowlist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowlist network %q: %w", entry, err)
+			}
+			p.allowNets = append(p.allowNets, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			p.allowNets = append(p.allowNets, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		p.allowHosts[strings.ToLower(strings.TrimSuffix(entry, "."))] = true
+	}
+	return p, nil
+}
+
+// CheckURL validates a URL without resolving its host: the scheme, and the
+// address if the host is an IP literal.
+func (p *URLPolicy) CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlockedURL, err)
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if p.requireHTTPS {
+			return fmt.Errorf("%w: must use https", ErrBlockedURL)
+		}
+	default:
+		return fmt.Errorf("%w: scheme must be http or https", ErrBlockedURL)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: must not contain credentials", ErrBlockedURL)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrBlockedURL)
+	}
+	if p.hostAllowed(host) {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(host, addr)
+	}
+	if lower := strings.ToLower(strings.TrimSuffix(host, ".")); lower == "localhost" || strings.HasSuffix(lower, ".localhost") {
+		return fmt.Errorf("%w: %s is a loopback host", ErrBlockedURL, host)
+	}
+	return nil
+}
+
+// ValidateURL is CheckURL plus a DNS lookup of the host, so that webhooks
+// pointing at internal names are rejected when they are created. Hosts that
+// do not resolve (yet) are accepted; the dispatcher checks again at delivery.
+func (p *URLPolicy) ValidateURL(ctx context.Context, rawURL string) error {
+	if err := p.CheckURL(rawURL); err != nil {
+		return err
+	}
+	u, _ := url.Parse(rawURL)
+	host := u.Hostname()
+	if p.hostAllowed(host) {
+		return nil
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil // IP literal, checked by CheckURL
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	addrs, err := p.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := p.checkAddr(host, addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DialContext resolves the host of addr, rejects it if any of its addresses
+// is blocked, and connects to the checked addresses only. It is used as the
+// dispatcher transport's dialer.
+func (p *URLPolicy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []netip.Addr
+	if ip, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{ip}
+	} else if addrs, err = p.resolver.LookupNetIP(ctx, "ip", host); err != nil {
+		return nil, err
+	}
+	if !p.hostAllowed(host) {
+		for _, ip := range addrs {
+			if err := p.checkAddr(host, ip); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	var lastErr error
+	for _, ip := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no addresses for %s", host)
+	}
+	return nil, lastErr
+}
+
+func (p *URLPolicy) hostAllowed(host string) bool {
+	return p.allowHosts[strings.ToLower(strings.TrimSuffix(host, "."))]
+}
+
+// checkAddr returns an error if addr is blocked and not allowlisted.
+func (p *URLPolicy) checkAddr(host string, addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range p.allowNets {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	if blockedAddr(addr) {
+		if host == addr.String() {
+			return fmt.Errorf("%w: %s is a private or reserved address", ErrBlockedURL, addr)
+		}
+		return fmt.Errorf("%w: %s resolves to private or reserved address %s", ErrBlockedURL, host, addr)
+	}
+	return nil
+}
+
+func blockedAddr(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// TransportConfig configures how the dispatcher connects to webhook
+// receivers, e.g. from networks that only allow egress through a proxy.
+type TransportConfig struct {
+	// ProxyURL is an http://, https:// or socks5:// proxy all deliveries go
+	// through. Empty uses HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the
+	// environment.
+	ProxyURL string
+	// CABundle is a PEM file with root certificates trusted in addition to
+	// the system roots, for receivers or proxies with a private CA.
+	CABundle string
+}
+
+// Validate checks that the proxy URL parses and the CA bundle can be loaded.
+func (c TransportConfig) Validate() error {
+	_, _, err := c.parse()
+	return err
+}
+
+func (c TransportConfig) parse() (*url.URL, *x509.CertPool, error) {
+	var proxyURL *url.URL
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, nil, fmt.Errorf("invalid proxy URL: scheme must be http, https or socks5")
+		}
+		if u.Host == "" {
+			return nil, nil, errors.New("invalid proxy URL: missing host")
+		}
+		proxyURL = u
+	}
+
+	var roots *x509.CertPool
+	if c.CABundle != "" {
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		if roots, err = x509.SystemCertPool(); err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("CA bundle %s contains no PEM certificates", c.CABundle)
+		}
+	}
+	return proxyURL, roots, nil
+}
+
+// SetTransport replaces the HTTP client deliveries are made with. Deliveries
+// in flight finish on the previous client.
+func (d *Dispatcher) SetTransport(cfg TransportConfig) error {
+	client, err := d.newClient(cfg)
+	if err != nil {
+		return err
+	}
+	if old := d.client.Swap(client); old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (d *Dispatcher) newClient(cfg TransportConfig) (*http.Client, error) {
+	proxyURL, roots, err := cfg.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if proxyURL != nil {
+		proxy = http.ProxyURL(proxyURL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req)
+		if u != nil {
+			d.proxyAddrs.Store(proxyAddr(u), true)
+		}
+		return u, err
+	}
+	transport.DialContext = d.dialContext
+	if roots != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		// Default timeout, will be overridden per-webhook
+		Timeout:       10 * time.Second,
+		CheckRedirect: d.checkRedirect,
+	}, nil
+}
+
+// dialContext connects through the URL policy, which pins the connection to
+// the addresses it checked. Connections to a proxy are not checked: the
+// proxy is configured by the operator and resolves the receiver itself, so
+// behind a proxy webhook hosts are only checked by name (CheckURL) and the
+// proxy is expected to enforce egress rules.
+func (d *Dispatcher) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	_, viaProxy := d.proxyAddrs.Load(addr)
+	if policy := d.urlPolicy.Load(); policy != nil && !viaProxy {
+		return policy.DialContext(ctx, network, addr)
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+func (d *Dispatcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if policy := d.urlPolicy.Load(); policy != nil {
+		return policy.CheckURL(req.URL.String())
+	}
+	return nil
+}
+
+// proxyAddr is the host:port the transport dials for proxy u.
+func proxyAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "socks5", "socks5h":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dbgen "github.com/TimurManjosov/goflagship/internal/db/gen"
+)
+
+func testWebhook(url string) dbgen.Webhook {
+	return dbgen.Webhook{
+		ID:             uuidFromString("550e8400-e29b-41d4-a716-446655440000"),
+		Url:            url,
+		Enabled:        true,
+		Events:         []string{EventFlagUpdated},
+		Secret:         "test-secret",
+		TimeoutSeconds: 5,
+	}
+}
+
+func TestDispatcher_ProxyURL(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	queries := &mockQueries{}
+	d := NewDispatcher(queries)
+	// The proxy itself is on a loopback address; only receivers are checked.
+	policy, _ := NewURLPolicy(URLPolicyConfig{})
+	d.SetURLPolicy(policy)
+	if err := d.SetTransport(TransportConfig{ProxyURL: proxy.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.deliverWithRetry(context.Background(), testWebhook("http://hooks.example.com/flagship"), Event{Type: EventFlagUpdated})
+	if proxied != "http://hooks.example.com/flagship" {
+		t.Fatalf("expected the delivery to go through the proxy, got %q", proxied)
+	}
+	if len(queries.deliveries) != 1 || !queries.deliveries[0].Success {
+		t.Fatalf("expected one successful delivery, got %+v", queries.deliveries)
+	}
+}
+
+func TestDispatcher_CABundle(t *testing.T) {
+	receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: receiver.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := &mockQueries{}
+	d := NewDispatcher(queries)
+	d.deliverWithRetry(context.Background(), testWebhook(receiver.URL), Event{Type: EventFlagUpdated})
+	if queries.deliveries[0].Success {
+		t.Fatal("a receiver with an untrusted certificate should fail")
+	}
+
+	if err := d.SetTransport(TransportConfig{CABundle: bundle}); err != nil {
+		t.Fatal(err)
+	}
+	d.deliverWithRetry(context.Background(), testWebhook(receiver.URL), Event{Type: EventFlagUpdated})
+	if !queries.deliveries[1].Success {
+		t.Fatalf("expected delivery with the CA bundle to succeed: %s", queries.deliveries[1].ErrorMessage.String)
+	}
+}
+
+func TestTransportConfig_Validate(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	_ = os.WriteFile(empty, []byte("not a certificate"), 0o600)
+
+	for _, cfg := range []TransportConfig{
+		{ProxyURL: "ftp://proxy:21"},
+		{ProxyURL: "http://"},
+		{CABundle: filepath.Join(t.TempDir(), "missing.pem")},
+		{CABundle: empty},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", cfg)
+		}
+	}
+	if err := (TransportConfig{ProxyURL: "socks5://proxy.internal"}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -5,7 +5,8 @@
 //  2. Event is queued in a buffered channel (non-blocking, async)
 //  3. Background worker processes events from queue
 //  4. For each event, worker finds matching webhooks (filters by event type and environment)
-//  5. Worker attempts delivery to each matching webhook with retry logic
+//  5. Worker queues a delivery to each matching webhook; a pool delivers them
+//     concurrently (in order per webhook) with retry logic
 //  6. Delivery attempts are logged to database (webhook_deliveries table)
 //  7. Successful deliveries update webhook's last_triggered timestamp
 //
@@ -22,7 +23,8 @@
 //  5. Add test case in dispatcher_test.go
 //
 // Thread Safety:
-//   - Dispatcher uses a goroutine worker to process events asynchronously
+//   - Dispatcher uses a goroutine worker to process events asynchronously,
+//     and a bounded pool of deliveries (see Dispatcher.SetConcurrency)
 //   - Dispatch() is non-blocking and safe to call from any goroutine
 //   - Queue has fixed size (1000); if full, events are dropped with warning
 package webhook
@@ -36,6 +38,21 @@ const (
 	EventFlagCreated = "flag.created"
 	EventFlagUpdated = "flag.updated"
 	EventFlagDeleted = "flag.deleted"
+
+	// EventCleanupReport carries the periodic stale flag cleanup report.
+	EventCleanupReport = "report.cleanup"
+
+	// EventSummaryReport carries the periodic project summary report.
+	EventSummaryReport = "report.summary"
+
+	// EventGuardrailTripped is sent when a flag's guardrail trips.
+	EventGuardrailTripped = "guardrail.tripped"
+
+	// EventAPIKeyExpiring is sent when an API key is about to expire.
+	EventAPIKeyExpiring = "apikey.expiring"
+
+	// EventFlagExpired is sent when a flag is turned off because its expires_at passed.
+	EventFlagExpired = "flag.expired"
 )
 
 // Event represents a webhook event that will be sent to subscribed webhooks
@@ -51,7 +68,7 @@ type Event struct {
 
 // Resource identifies the resource that triggered the event
 type Resource struct {
-	Type string `json:"type"` // e.g., "flag"
+	Type string `json:"type"` // e.g., "flag" or "report"
 	Key  string `json:"key"`  // e.g., flag key
 }
 
@@ -67,4 +84,9 @@ type Metadata struct {
 	APIKeyID  string `json:"apiKeyId,omitempty"`
 	IPAddress string `json:"ipAddress,omitempty"`
 	RequestID string `json:"requestId,omitempty"`
+	TraceID   string `json:"traceId,omitempty"` // W3C trace ID of the request, if sent
+
+	// AuditEventID is the ID of the audit event recording the change, when
+	// one was recorded; GET /v1/admin/audit-logs/{id}/diff shows the change.
+	AuditEventID string `json:"auditEventId,omitempty"`
 }
@@ -0,0 +1,234 @@
+// Package worker supervises the server's long-running background goroutines
+// (the audit writer, the webhook dispatcher and the periodic jobs).
+//
+// A Worker runs a function and restarts it if it panics, waiting a little
+// longer after every consecutive crash. It records what an operator needs to
+// tell a healthy worker from a stuck or crashing one: whether it is running,
+// how many items wait in its queue, when it last processed one and how often
+// it was restarted. A Registry collects the workers of a process for
+// GET /readyz and the worker_* metrics.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// States a worker can be in.
+const (
+	StateIdle       = "idle"       // not started yet
+	StateRunning    = "running"    // its function is running
+	StateRestarting = "restarting" // crashed, waiting to be restarted
+	StateStopped    = "stopped"    // its function returned
+)
+
+// Restart backoff: the first restart after a crash waits minRestartDelay,
+// every further consecutive crash doubles the wait up to maxRestartDelay. A
+// worker that ran for stableAfter before crashing starts over at
+// minRestartDelay.
+var (
+	minRestartDelay = time.Second
+	maxRestartDelay = time.Minute
+	stableAfter     = time.Minute
+)
+
+// Status is a snapshot of a worker's health.
+type Status struct {
+	Name          string     `json:"name"`
+	State         string     `json:"state"`
+	QueueDepth    *int       `json:"queue_depth,omitempty"` // nil if the worker has no queue
+	LastProcessed *time.Time `json:"last_processed_at,omitempty"`
+	Restarts      int        `json:"restarts"`
+	LastPanic     string     `json:"last_panic,omitempty"`
+	LastPanicAt   *time.Time `json:"last_panic_at,omitempty"`
+}
+
+// Worker is a supervised background goroutine. Create it with New and start
+// it with Run. All methods are safe for concurrent use.
+type Worker struct {
+	name  string
+	depth func() int
+
+	mu            sync.Mutex
+	state         string
+	lastProcessed time.Time
+	restarts      int
+	lastPanic     string
+	lastPanicAt   time.Time
+}
+
+// New returns an idle worker. depth, if not nil, returns the number of items
+// waiting in the worker's queue.
+func New(name string, depth func() int) *Worker {
+	return &Worker{name: name, depth: depth, state: StateIdle}
+}
+
+// Name returns the worker's name.
+func (w *Worker) Name() string {
+	return w.name
+}
+
+// Run calls fn until it returns or ctx is cancelled, restarting it after a
+// panic. fn's context carries w, see Processed. Run blocks; start it with
+// go w.Run(ctx, fn).
+func (w *Worker) Run(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithValue(ctx, workerKey{}, w)
+	delay := minRestartDelay
+	for {
+		started := time.Now()
+		w.setState(StateRunning)
+		if !w.call(ctx, fn) {
+			w.setState(StateStopped)
+			return
+		}
+		if time.Since(started) >= stableAfter {
+			delay = minRestartDelay
+		}
+		log.Printf("[worker] %s crashed, restarting in %s", w.name, delay)
+
+		w.setState(StateRestarting)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			w.setState(StateStopped)
+			return
+		case <-timer.C:
+		}
+		w.mu.Lock()
+		w.restarts++
+		w.mu.Unlock()
+		delay = min(2*delay, maxRestartDelay)
+	}
+}
+
+// call runs fn and reports whether it panicked.
+func (w *Worker) call(ctx context.Context, fn func(ctx context.Context)) (panicked bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			w.recordPanic(v)
+			panicked = true
+		}
+	}()
+	fn(ctx)
+	return false
+}
+
+func (w *Worker) recordPanic(v any) {
+	log.Printf("[worker] CRITICAL: %s panicked: %v\n%s", w.name, v, debug.Stack())
+	w.mu.Lock()
+	w.lastPanic = fmt.Sprint(v)
+	w.lastPanicAt = time.Now().UTC()
+	w.mu.Unlock()
+}
+
+// Recovered records a panic v that a goroutine of the worker recovered from
+// itself, e.g. one of several it runs, and counts it as a restart. Call it
+// from the deferred function that recovered.
+func (w *Worker) Recovered(v any) {
+	w.recordPanic(v)
+	w.mu.Lock()
+	w.restarts++
+	w.mu.Unlock()
+}
+
+func (w *Worker) setState(state string) {
+	w.mu.Lock()
+	w.state = state
+	w.mu.Unlock()
+}
+
+// MarkProcessed records that the worker just processed an item or finished
+// a scheduled run.
+func (w *Worker) MarkProcessed() {
+	w.mu.Lock()
+	w.lastProcessed = time.Now().UTC()
+	w.mu.Unlock()
+}
+
+// Status returns the worker's current health.
+func (w *Worker) Status() Status {
+	w.mu.Lock()
+	status := Status{Name: w.name, State: w.state, Restarts: w.restarts, LastPanic: w.lastPanic}
+	if !w.lastProcessed.IsZero() {
+		at := w.lastProcessed
+		status.LastProcessed = &at
+	}
+	if !w.lastPanicAt.IsZero() {
+		at := w.lastPanicAt
+		status.LastPanicAt = &at
+	}
+	w.mu.Unlock()
+
+	if w.depth != nil {
+		depth := w.depth()
+		status.QueueDepth = &depth
+	}
+	return status
+}
+
+type workerKey struct{}
+
+// Processed calls MarkProcessed on the worker running ctx, if any. Periodic
+// jobs call it after every run so they need not know whether they are
+// supervised.
+func Processed(ctx context.Context) {
+	if w, ok := ctx.Value(workerKey{}).(*Worker); ok {
+		w.MarkProcessed()
+	}
+}
+
+// Registry collects the workers of a process.
+type Registry struct {
+	mu      sync.Mutex
+	workers map[string]*Worker
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{workers: map[string]*Worker{}}
+}
+
+// Add registers workers, replacing any registered under the same name.
+// Nil workers are ignored.
+func (r *Registry) Add(workers ...*Worker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range workers {
+		if w != nil {
+			r.workers[w.name] = w
+		}
+	}
+}
+
+// Remove unregisters w, unless another worker was registered under its name
+// since.
+func (r *Registry) Remove(w *Worker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.workers[w.name] == w {
+		delete(r.workers, w.name)
+	}
+}
+
+// Statuses returns the health of all registered workers, sorted by name.
+func (r *Registry) Statuses() []Status {
+	r.mu.Lock()
+	workers := make([]*Worker, 0, len(r.workers))
+	for _, w := range r.workers {
+		workers = append(workers, w)
+	}
+	r.mu.Unlock()
+
+	statuses := make([]Status, 0, len(workers))
+	for _, w := range workers {
+		statuses = append(statuses, w.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
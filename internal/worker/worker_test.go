@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func withFastRestarts(t *testing.T) {
+	t.Helper()
+	minDelay, maxDelay := minRestartDelay, maxRestartDelay
+	minRestartDelay, maxRestartDelay = time.Millisecond, 4*time.Millisecond
+	t.Cleanup(func() { minRestartDelay, maxRestartDelay = minDelay, maxDelay })
+}
+
+func TestWorker_RestartsAfterPanic(t *testing.T) {
+	withFastRestarts(t)
+	w := New("jobs", nil)
+
+	calls := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(context.Background(), func(ctx context.Context) {
+			calls++
+			Processed(ctx)
+			if calls < 3 {
+				panic("boom")
+			}
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker was not restarted")
+	}
+
+	status := w.Status()
+	if calls != 3 || status.Restarts != 2 {
+		t.Errorf("expected 3 calls and 2 restarts, got %d and %d", calls, status.Restarts)
+	}
+	if status.State != StateStopped || status.LastPanic != "boom" || status.LastPanicAt == nil {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.LastProcessed == nil || status.QueueDepth != nil {
+		t.Errorf("expected a processed time and no queue, got %+v", status)
+	}
+}
+
+func TestWorker_StopsWhileRestarting(t *testing.T) {
+	minDelay := minRestartDelay
+	minRestartDelay = time.Hour
+	t.Cleanup(func() { minRestartDelay = minDelay })
+
+	w := New("jobs", func() int { return 7 })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx, func(context.Context) { panic("boom") })
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for w.Status().State != StateRestarting {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the worker to wait for its restart, got %+v", w.Status())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	status := w.Status()
+	if status.State != StateStopped || status.Restarts != 0 || status.QueueDepth == nil || *status.QueueDepth != 7 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestRegistry_Statuses(t *testing.T) {
+	r := NewRegistry()
+	r.Add(New("webhook", nil), nil, New("audit", nil))
+
+	statuses := r.Statuses()
+	if len(statuses) != 2 || statuses[0].Name != "audit" || statuses[1].Name != "webhook" {
+		t.Fatalf("expected workers sorted by name, got %+v", statuses)
+	}
+	if statuses[0].State != StateIdle {
+		t.Errorf("expected an idle worker, got %q", statuses[0].State)
+	}
+	Processed(context.Background()) // no worker in the context: no-op
+}
@@ -0,0 +1,359 @@
+// Package flagship is the Go SDK for the goflagship feature flag service.
+//
+// The client keeps an in-memory copy of the flag snapshot and evaluates flags
+// locally, so evaluations never block on the network:
+//
+//	c := flagship.New(flagship.Options{BaseURL: "http://localhost:8080"})
+//	if err := c.Start(ctx); err != nil { ... }
+//	defer c.Close()
+//
+//	if c.IsEnabled("new_checkout", flagship.User{ID: userID}) { ... }
+//
+// Start loads the snapshot and subscribes to the server's SSE stream
+// (/v1/flags/stream) to refresh it whenever flags change. Where SSE is
+// blocked the client polls the snapshot with If-None-Match instead (see
+// Options.Mode). When the server is
+// unreachable the client keeps serving the last good snapshot (see Healthy and
+// SnapshotAge); Options.Defaults cover the time before any snapshot has
+// loaded. Evaluation semantics
+// match the server's POST /v1/flags/evaluate endpoint (targeting expression,
+// percentage rollout, variants).
+package flagship
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/evaluation"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+)
+
+const defaultBaseURL = "http://localhost:8080"
+
+var (
+	// ErrNotReady is returned when a snapshot is required but none has loaded yet.
+	ErrNotReady = errors.New("flagship: no snapshot loaded")
+	// ErrFlagNotFound is reported when a flag is not in the snapshot.
+	ErrFlagNotFound = errors.New("flagship: flag not found")
+	// ErrTypeMismatch is reported when a flag value has a different type than requested.
+	ErrTypeMismatch = errors.New("flagship: flag value type mismatch")
+)
+
+// User is the evaluation context for a single user.
+type User struct {
+	ID         string         `json:"id"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// Result is the outcome of evaluating one flag for one user.
+type Result struct {
+	Key     string         `json:"key"`
+	Enabled bool           `json:"enabled"`
+	Variant string         `json:"variant,omitempty"`
+	Config  map[string]any `json:"config,omitempty"`
+}
+
+// Options configures a Client.
+type Options struct {
+	BaseURL      string        // Server URL (default http://localhost:8080)
+	APIKey       string        // Optional API key, sent as a bearer token
+	HTTPClient   *http.Client  // Optional HTTP client for snapshot and exposure requests
+	ReconnectMin time.Duration // Initial SSE reconnect delay (default 500ms)
+	ReconnectMax time.Duration // Maximum SSE reconnect delay (default 10s)
+
+	// Mode selects how the snapshot is kept up to date (default ModeAuto).
+	Mode         Mode
+	PollInterval time.Duration // Delay between snapshot polls (default 30s)
+	PollJitter   time.Duration // Random extra delay added to each poll (default PollInterval/10)
+
+	// StreamFailures is the number of consecutive failed SSE connection
+	// attempts after which ModeAuto falls back to polling (default 3).
+	// StreamRetry is how long it polls before trying the stream again
+	// (default 5m).
+	StreamFailures int
+	StreamRetry    time.Duration
+
+	MaxStaleness time.Duration // Snapshot age after which Healthy reports false (default 5m)
+
+	// Defaults are served while no snapshot has ever loaded, keyed by flag.
+	// A bool sets the flag's enabled state, a map[string]any is served as
+	// its config, and any other value is served as the config {"value": v}.
+	Defaults map[string]any
+
+	// Exposures configures exposure event reporting. Reporting is enabled by
+	// default; set Exposures.Disabled to turn it off.
+	Exposures ExposureOptions
+
+	// OnError is called for background errors (stream, refresh, flush) and
+	// for typed accessors that fall back to their default. Optional.
+	OnError func(err error)
+}
+
+// Client evaluates flags against a locally cached snapshot.
+// It is safe for concurrent use.
+type Client struct {
+	opts   Options
+	http   *http.Client
+	stream *http.Client // no timeout: SSE connections are long-lived
+
+	snap      atomic.Pointer[snapshot.Snapshot]
+	syncedAt  atomic.Int64 // UnixNano of the last time the server confirmed snap
+	streaming atomic.Bool  // SSE connected; snap is kept current by the stream
+	polling   atomic.Bool  // updates currently come from polling
+	exposures *exposureQueue
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// New creates a client. Call Start to load flags and begin receiving updates.
+func New(opts Options) *Client {
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	if opts.BaseURL == "" {
+		opts.BaseURL = defaultBaseURL
+	}
+	if opts.ReconnectMin <= 0 {
+		opts.ReconnectMin = 500 * time.Millisecond
+	}
+	if opts.ReconnectMax <= 0 {
+		opts.ReconnectMax = 10 * time.Second
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 30 * time.Second
+	}
+	if opts.PollJitter <= 0 {
+		opts.PollJitter = opts.PollInterval / 10
+	}
+	if opts.StreamFailures <= 0 {
+		opts.StreamFailures = 3
+	}
+	if opts.StreamRetry <= 0 {
+		opts.StreamRetry = 5 * time.Minute
+	}
+	if opts.MaxStaleness <= 0 {
+		opts.MaxStaleness = 5 * time.Minute
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	streamClient := &http.Client{Transport: httpClient.Transport}
+
+	c := &Client{opts: opts, http: httpClient, stream: streamClient}
+	c.polling.Store(opts.Mode == ModePolling)
+	if !opts.Exposures.Disabled {
+		c.exposures = newExposureQueue(c, opts.Exposures)
+	}
+	return c
+}
+
+// Start loads the initial snapshot and starts background updates and
+// exposure flushing. It returns the error of the initial load, but background
+// goroutines are started either way and keep trying to reach the server, so
+// callers may choose to run on Options.Defaults until it comes up. Close must
+// be called in both cases.
+func (c *Client) Start(ctx context.Context) error {
+	_, err := c.Refresh(ctx)
+	c.startOnce.Do(func() {
+		bg, cancel := context.WithCancel(context.Background())
+		c.cancel = cancel
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.runUpdates(bg)
+		}()
+		if c.exposures != nil {
+			c.wg.Add(1)
+			go func() {
+				defer c.wg.Done()
+				c.exposures.run(bg)
+			}()
+		}
+	})
+	return err
+}
+
+// Close stops background work and flushes pending exposure events.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.cancel != nil {
+			c.cancel()
+		}
+		c.wg.Wait()
+		if c.exposures != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err = c.exposures.flushAll(ctx)
+		}
+	})
+	return err
+}
+
+// Ready reports whether a snapshot has been loaded.
+func (c *Client) Ready() bool {
+	return c.snap.Load() != nil
+}
+
+// SnapshotAge reports how long the cached snapshot has gone without the
+// server confirming it is current. It is zero while the stream is connected
+// and before any snapshot has loaded.
+func (c *Client) SnapshotAge() time.Duration {
+	if c.snap.Load() == nil || c.streaming.Load() {
+		return 0
+	}
+	return time.Since(time.Unix(0, c.syncedAt.Load()))
+}
+
+// Healthy reports whether a snapshot is loaded and no older than
+// Options.MaxStaleness. An unhealthy but Ready client still serves its last
+// good snapshot.
+func (c *Client) Healthy() bool {
+	return c.Ready() && c.SnapshotAge() <= c.opts.MaxStaleness
+}
+
+func (c *Client) markSynced() {
+	c.syncedAt.Store(time.Now().UnixNano())
+}
+
+// ETag returns the ETag of the current snapshot, or "" if none is loaded.
+func (c *Client) ETag() string {
+	if s := c.snap.Load(); s != nil {
+		return s.ETag
+	}
+	return ""
+}
+
+// Keys returns the keys of all flags in the current snapshot, sorted.
+func (c *Client) Keys() []string {
+	s := c.snap.Load()
+	if s == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(s.Flags))
+	for k := range s.Flags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Evaluate evaluates a flag for user. Unknown flags return a disabled result,
+// as do evaluations before the first snapshot loads unless the flag has an
+// entry in Options.Defaults.
+func (c *Client) Evaluate(key string, user User) Result {
+	r, _ := c.evaluate(key, user)
+	return r
+}
+
+// evaluate is Evaluate that also reports why a flag could not be evaluated
+// (ErrNotReady or ErrFlagNotFound).
+func (c *Client) evaluate(key string, user User) (Result, error) {
+	s := c.snap.Load()
+	if s == nil {
+		if def, ok := c.opts.Defaults[key]; ok {
+			return defaultResult(key, def), nil
+		}
+		return Result{Key: key}, ErrNotReady
+	}
+	flag, ok := s.Flags[key]
+	if !ok {
+		return Result{Key: key}, ErrFlagNotFound
+	}
+	r := evaluation.EvaluateFlag(flag, evaluation.Context{UserID: user.ID, Attributes: user.Attributes}, s.RolloutSalt)
+	if c.exposures != nil {
+		c.exposures.record(r.Key, r.Variant, r.Enabled, user.ID)
+	}
+	return Result{Key: r.Key, Enabled: r.Enabled, Variant: r.Variant, Config: r.Config}, nil
+}
+
+// defaultResult builds the result served for an Options.Defaults entry.
+func defaultResult(key string, def any) Result {
+	switch v := def.(type) {
+	case bool:
+		return Result{Key: key, Enabled: v}
+	case map[string]any:
+		return Result{Key: key, Enabled: true, Config: v}
+	default:
+		return Result{Key: key, Enabled: true, Config: map[string]any{"value": v}}
+	}
+}
+
+// IsEnabled reports whether the flag is enabled for user.
+func (c *Client) IsEnabled(key string, user User) bool {
+	return c.Evaluate(key, user).Enabled
+}
+
+// Refresh fetches the snapshot, sending If-None-Match when one is cached.
+// It reports whether the snapshot changed. On error the cached snapshot is
+// kept.
+func (c *Client) Refresh(ctx context.Context) (bool, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/v1/flags/snapshot", nil)
+	if err != nil {
+		return false, err
+	}
+	// Pin the snapshot format this SDK was built against, so a newer server
+	// keeps sending it.
+	req.Header.Set("Accept", fmt.Sprintf("application/vnd.flagship.v%d+json", snapshot.SchemaVersion))
+	current := c.snap.Load()
+	if current != nil && current.ETag != "" {
+		req.Header.Set("If-None-Match", current.ETag)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("flagship: fetch snapshot: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		c.markSynced()
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, fmt.Errorf("flagship: fetch snapshot: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var next snapshot.Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&next); err != nil {
+		return false, fmt.Errorf("flagship: decode snapshot: %w", err)
+	}
+	if next.SchemaVersion > snapshot.SchemaVersion {
+		return false, fmt.Errorf("flagship: unsupported snapshot schema version %d", next.SchemaVersion)
+	}
+	if next.Flags == nil {
+		next.Flags = map[string]snapshot.FlagView{}
+	}
+	c.snap.Store(&next)
+	c.markSynced()
+	return current == nil || current.ETag != next.ETag, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.opts.BaseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("flagship: create request: %w", err)
+	}
+	if c.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.APIKey)
+	}
+	return req, nil
+}
+
+func (c *Client) reportError(err error) {
+	if err != nil && c.opts.OnError != nil {
+		c.opts.OnError(err)
+	}
+}
@@ -0,0 +1,134 @@
+package flagship
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/api"
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+// newTestServer starts a real API server backed by a memory store.
+func newTestServer(t *testing.T, flags ...store.UpsertParams) (*httptest.Server, *api.Server, store.Store) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	for _, f := range flags {
+		if err := st.UpsertFlag(ctx, f); err != nil {
+			t.Fatalf("seed flag: %v", err)
+		}
+	}
+	srv := api.NewServer(st, "prod", "test-key")
+	if err := srv.RebuildSnapshot(ctx, "prod"); err != nil {
+		t.Fatalf("rebuild snapshot: %v", err)
+	}
+	ts := httptest.NewServer(srv.Router())
+	t.Cleanup(ts.Close)
+	return ts, srv, st
+}
+
+func TestClient_RefreshAndEvaluate(t *testing.T) {
+	ts, _, _ := newTestServer(t,
+		store.UpsertParams{Key: "on_flag", Enabled: true, Rollout: 100, Env: "prod", Config: map[string]any{"color": "blue"}},
+		store.UpsertParams{Key: "off_flag", Enabled: false, Rollout: 100, Env: "prod"},
+	)
+	c := New(Options{BaseURL: ts.URL, Exposures: ExposureOptions{Disabled: true}})
+
+	if c.IsEnabled("on_flag", User{ID: "u1"}) {
+		t.Error("flags must evaluate as disabled before the first snapshot")
+	}
+	changed, err := c.Refresh(context.Background())
+	if err != nil || !changed {
+		t.Fatalf("Refresh: changed=%v err=%v", changed, err)
+	}
+
+	r := c.Evaluate("on_flag", User{ID: "u1"})
+	if !r.Enabled || r.Config["color"] != "blue" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if c.IsEnabled("off_flag", User{ID: "u1"}) || c.IsEnabled("missing", User{ID: "u1"}) {
+		t.Error("disabled and unknown flags must evaluate as disabled")
+	}
+
+	changed, err = c.Refresh(context.Background())
+	if err != nil || changed {
+		t.Errorf("second Refresh should be a 304: changed=%v err=%v", changed, err)
+	}
+}
+
+func TestClient_StreamUpdates(t *testing.T) {
+	ts, srv, st := newTestServer(t, store.UpsertParams{Key: "live", Enabled: false, Rollout: 100, Env: "prod"})
+	c := New(Options{BaseURL: ts.URL, Exposures: ExposureOptions{Disabled: true}})
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer c.Close()
+
+	// Give the stream a moment to connect before publishing the update.
+	time.Sleep(100 * time.Millisecond)
+	_ = st.UpsertFlag(context.Background(), store.UpsertParams{Key: "live", Enabled: true, Rollout: 100, Env: "prod"})
+	_ = srv.RebuildSnapshot(context.Background(), "prod")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !c.IsEnabled("live", User{ID: "u1"}) {
+		if time.Now().After(deadline) {
+			t.Fatalf("client did not pick up update; etag=%s server=%s", c.ETag(), snapshot.Load().ETag)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestClient_ServesStaleSnapshotWhenServerDown(t *testing.T) {
+	ts, _, _ := newTestServer(t, store.UpsertParams{Key: "on_flag", Enabled: true, Rollout: 100, Env: "prod"})
+	c := New(Options{BaseURL: ts.URL, MaxStaleness: 50 * time.Millisecond, Exposures: ExposureOptions{Disabled: true}})
+	if _, err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if !c.Healthy() {
+		t.Error("fresh snapshot should be healthy")
+	}
+
+	ts.Close()
+	if _, err := c.Refresh(context.Background()); err == nil {
+		t.Fatal("expected refresh error with the server down")
+	}
+	if !c.IsEnabled("on_flag", User{ID: "u1"}) {
+		t.Error("last good snapshot should still be served")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if c.SnapshotAge() < 50*time.Millisecond || c.Healthy() {
+		t.Errorf("snapshot should be stale: age=%v healthy=%v", c.SnapshotAge(), c.Healthy())
+	}
+	if !c.Ready() {
+		t.Error("stale client should still be ready")
+	}
+}
+
+func TestClient_DefaultsBeforeFirstSnapshot(t *testing.T) {
+	ts, _, _ := newTestServer(t)
+	ts.Close()
+	c := New(Options{
+		BaseURL:      ts.URL,
+		ReconnectMin: time.Hour,
+		Exposures:    ExposureOptions{Disabled: true},
+		Defaults:     map[string]any{"checkout": true, "title": "Offline"},
+	})
+	if err := c.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to report the failed initial load")
+	}
+	defer c.Close()
+
+	u := User{ID: "u1"}
+	if !c.IsEnabled("checkout", u) || c.IsEnabled("other", u) {
+		t.Error("defaults should be served before the first snapshot")
+	}
+	if got := c.StringValue("title", u, "x"); got != "Offline" {
+		t.Errorf("StringValue = %q", got)
+	}
+	if c.Healthy() {
+		t.Error("client without a snapshot must not be healthy")
+	}
+}
@@ -0,0 +1,273 @@
+package flagship
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exposure defaults.
+const (
+	defaultFlushInterval = 10 * time.Second
+	defaultBatchSize     = 500
+	defaultQueueSize     = 10000
+	maxFlushBackoff      = 5 * time.Minute
+)
+
+// ExposureOptions configures exposure event batching.
+//
+// Every evaluation records an exposure (flag, variant, hashed user ID,
+// timestamp). Identical exposures are recorded once per flush, and events are
+// sent to POST /v1/exposures in batches. The queue is bounded: when it is
+// full, new events are dropped (and counted in ExposureStats) rather than
+// blocking evaluations. Failed flushes are retried with exponential backoff;
+// a 429 response's Retry-After is honored.
+type ExposureOptions struct {
+	Disabled      bool          // Turn off exposure reporting
+	FlushInterval time.Duration // How often to flush (default 10s)
+	BatchSize     int           // Maximum events per request; a full batch flushes early (default 500)
+	QueueSize     int           // Maximum buffered events (default 10000)
+
+	// IncludeUserIDs sends raw user IDs along with their hashes, so cohort
+	// exports (GET /v1/flags/{key}/cohort) can list them. Off by default:
+	// only hashes leave the process.
+	IncludeUserIDs bool
+}
+
+// Exposure is a single evaluation event, as sent to the server.
+type Exposure struct {
+	Flag      string    `json:"flag"`
+	Variant   string    `json:"variant,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	UserHash  string    `json:"user_hash,omitempty"`
+	UserID    string    `json:"user_id,omitempty"` // only with ExposureOptions.IncludeUserIDs
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ExposureStats reports exposure delivery counters.
+type ExposureStats struct {
+	Queued  int   `json:"queued"`
+	Sent    int64 `json:"sent"`
+	Dropped int64 `json:"dropped"`
+}
+
+type exposureQueue struct {
+	client *Client
+	opts   ExposureOptions
+
+	mu      sync.Mutex
+	events  []Exposure
+	seen    map[string]struct{} // dedupe keys of queued events
+	sent    int64
+	dropped int64
+	wake    chan struct{}
+}
+
+func newExposureQueue(c *Client, opts ExposureOptions) *exposureQueue {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.BatchSize > opts.QueueSize {
+		opts.BatchSize = opts.QueueSize
+	}
+	return &exposureQueue{
+		client: c,
+		opts:   opts,
+		seen:   make(map[string]struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// hashUser returns a stable, non-reversible identifier for a user ID so raw
+// IDs never leave the process.
+func hashUser(id string) string {
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (q *exposureQueue) record(flag, variant string, enabled bool, userID string) {
+	e := Exposure{
+		Flag:      flag,
+		Variant:   variant,
+		Enabled:   enabled,
+		UserHash:  hashUser(userID),
+		Timestamp: time.Now().UTC(),
+	}
+	if q.opts.IncludeUserIDs {
+		e.UserID = userID
+	}
+	key := exposureKey(e)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, dup := q.seen[key]; dup {
+		return
+	}
+	if len(q.events) >= q.opts.QueueSize {
+		q.dropped++
+		return
+	}
+	q.seen[key] = struct{}{}
+	q.events = append(q.events, e)
+	if len(q.events) == q.opts.BatchSize {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (q *exposureQueue) stats() ExposureStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return ExposureStats{Queued: len(q.events), Sent: q.sent, Dropped: q.dropped}
+}
+
+// run flushes on every interval tick or when a batch fills up.
+func (q *exposureQueue) run(ctx context.Context) {
+	backoff := time.Duration(0)
+	timer := time.NewTimer(q.opts.FlushInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-q.wake:
+			if backoff > 0 {
+				continue // keep waiting for the backoff timer
+			}
+			timer.Stop()
+		}
+
+		next := q.opts.FlushInterval
+		if retryAfter, err := q.flushBatch(ctx); err != nil {
+			q.client.reportError(err)
+			backoff = min(max(backoff*2, q.opts.FlushInterval), maxFlushBackoff)
+			next = max(backoff, retryAfter)
+		} else {
+			backoff = 0
+		}
+		timer.Reset(next)
+	}
+}
+
+// flushAll sends every queued event, stopping at the first error.
+func (q *exposureQueue) flushAll(ctx context.Context) error {
+	for q.stats().Queued > 0 {
+		if _, err := q.flushBatch(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushBatch sends up to BatchSize events. On failure the batch is put back
+// at the front of the queue, as far as capacity allows. The returned duration
+// is the server's Retry-After, if any.
+func (q *exposureQueue) flushBatch(ctx context.Context) (time.Duration, error) {
+	q.mu.Lock()
+	n := min(len(q.events), q.opts.BatchSize)
+	if n == 0 {
+		q.mu.Unlock()
+		return 0, nil
+	}
+	batch := append([]Exposure(nil), q.events[:n]...)
+	q.events = q.events[n:]
+	q.seen = make(map[string]struct{}, len(q.events))
+	for _, e := range q.events {
+		q.seen[exposureKey(e)] = struct{}{}
+	}
+	q.mu.Unlock()
+
+	retryAfter, err := q.send(ctx, batch)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err == nil {
+		q.sent += int64(len(batch))
+		return 0, nil
+	}
+
+	room := q.opts.QueueSize - len(q.events)
+	if room < len(batch) {
+		q.dropped += int64(len(batch) - room)
+		batch = batch[:max(room, 0)]
+	}
+	q.events = append(batch, q.events...)
+	for _, e := range batch {
+		q.seen[exposureKey(e)] = struct{}{}
+	}
+	return retryAfter, err
+}
+
+// exposureKey identifies duplicate exposures within a flush window.
+func exposureKey(e Exposure) string {
+	return e.Flag + "\x00" + e.Variant + "\x00" + strconv.FormatBool(e.Enabled) + "\x00" + e.UserHash
+}
+
+type exposureBatch struct {
+	Exposures []Exposure `json:"exposures"`
+}
+
+func (q *exposureQueue) send(ctx context.Context, batch []Exposure) (time.Duration, error) {
+	body, err := json.Marshal(exposureBatch{Exposures: batch})
+	if err != nil {
+		return 0, fmt.Errorf("flagship: encode exposures: %w", err)
+	}
+	req, err := q.client.newRequest(ctx, http.MethodPost, "/v1/exposures", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := q.client.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("flagship: send exposures: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusOK {
+		return 0, nil
+	}
+	var retryAfter time.Duration
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+	return retryAfter, fmt.Errorf("flagship: send exposures: status %d", resp.StatusCode)
+}
+
+// ExposureStats returns exposure delivery counters. All counters are zero
+// when exposure reporting is disabled.
+func (c *Client) ExposureStats() ExposureStats {
+	if c.exposures == nil {
+		return ExposureStats{}
+	}
+	return c.exposures.stats()
+}
+
+// FlushExposures sends all queued exposure events now.
+func (c *Client) FlushExposures(ctx context.Context) error {
+	if c.exposures == nil {
+		return nil
+	}
+	return c.exposures.flushAll(ctx)
+}
@@ -0,0 +1,141 @@
+package flagship
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+)
+
+// exposureSink is a fake ingestion endpoint.
+type exposureSink struct {
+	mu     sync.Mutex
+	events []Exposure
+	status int
+}
+
+func (s *exposureSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != 0 {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(s.status)
+		return
+	}
+	var batch exposureBatch
+	_ = json.NewDecoder(r.Body).Decode(&batch)
+	s.events = append(s.events, batch.Exposures...)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *exposureSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+func newExposureClient(t *testing.T, sink *exposureSink, opts ExposureOptions) *Client {
+	t.Helper()
+	ts := httptest.NewServer(sink)
+	t.Cleanup(ts.Close)
+	c := New(Options{BaseURL: ts.URL, Exposures: opts})
+	c.snap.Store(&snapshot.Snapshot{
+		ETag:  "test",
+		Flags: map[string]snapshot.FlagView{"f": {Key: "f", Enabled: true, Rollout: 100}},
+	})
+	return c
+}
+
+func TestExposures_DedupeAndFlush(t *testing.T) {
+	sink := &exposureSink{}
+	c := newExposureClient(t, sink, ExposureOptions{})
+
+	for i := 0; i < 5; i++ {
+		c.Evaluate("f", User{ID: "u1"})
+	}
+	c.Evaluate("f", User{ID: "u2"})
+	c.Evaluate("missing", User{ID: "u1"}) // unknown flags are not exposures
+
+	if got := c.ExposureStats().Queued; got != 2 {
+		t.Fatalf("expected 2 deduplicated exposures, got %d", got)
+	}
+	if err := c.FlushExposures(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if sink.count() != 2 {
+		t.Fatalf("expected 2 events delivered, got %d", sink.count())
+	}
+	if e := sink.events[0]; e.UserHash == "" || e.UserHash == "u1" || e.UserID != "" || e.Flag != "f" || !e.Enabled {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if s := c.ExposureStats(); s.Sent != 2 || s.Queued != 0 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestExposures_IncludeUserIDs(t *testing.T) {
+	sink := &exposureSink{}
+	c := newExposureClient(t, sink, ExposureOptions{IncludeUserIDs: true})
+	c.Evaluate("f", User{ID: "u1"})
+	if err := c.FlushExposures(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if e := sink.events[0]; e.UserID != "u1" || e.UserHash != hashUser("u1") {
+		t.Errorf("expected the raw user ID along with its hash, got %+v", e)
+	}
+}
+
+func TestExposures_QueueFullDrops(t *testing.T) {
+	c := newExposureClient(t, &exposureSink{}, ExposureOptions{QueueSize: 3})
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		c.Evaluate("f", User{ID: id})
+	}
+	if s := c.ExposureStats(); s.Queued != 3 || s.Dropped != 2 {
+		t.Errorf("expected 3 queued and 2 dropped, got %+v", s)
+	}
+}
+
+func TestExposures_FailedFlushRequeues(t *testing.T) {
+	sink := &exposureSink{status: http.StatusTooManyRequests}
+	c := newExposureClient(t, sink, ExposureOptions{})
+	c.Evaluate("f", User{ID: "u1"})
+
+	retryAfter, err := c.exposures.flushBatch(context.Background())
+	if err == nil || retryAfter != time.Second {
+		t.Fatalf("expected error with Retry-After, got err=%v retryAfter=%v", err, retryAfter)
+	}
+	if c.ExposureStats().Queued != 1 {
+		t.Fatal("failed batch should be requeued")
+	}
+
+	sink.mu.Lock()
+	sink.status = 0
+	sink.mu.Unlock()
+	if err := c.FlushExposures(context.Background()); err != nil || sink.count() != 1 {
+		t.Errorf("retry should deliver the event: err=%v count=%d", err, sink.count())
+	}
+}
+
+func TestExposures_BatchSizeTriggersFlush(t *testing.T) {
+	sink := &exposureSink{}
+	c := newExposureClient(t, sink, ExposureOptions{BatchSize: 2, FlushInterval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.exposures.run(ctx)
+
+	c.Evaluate("f", User{ID: "a"})
+	c.Evaluate("f", User{ID: "b"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.count() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("full batch was not flushed early")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
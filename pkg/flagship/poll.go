@@ -0,0 +1,73 @@
+package flagship
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Mode selects how a Client keeps its snapshot up to date.
+type Mode int
+
+const (
+	// ModeAuto streams over SSE and falls back to polling after
+	// Options.StreamFailures failed connection attempts. While polling it
+	// retries the stream every Options.StreamRetry and switches back once a
+	// connection succeeds.
+	ModeAuto Mode = iota
+	// ModeStreaming only uses the SSE stream, reconnecting indefinitely.
+	ModeStreaming
+	// ModePolling only polls the snapshot endpoint.
+	ModePolling
+)
+
+// Polling reports whether the client currently receives updates by polling
+// rather than streaming.
+func (c *Client) Polling() bool {
+	return c.polling.Load()
+}
+
+// runUpdates keeps the snapshot current according to Options.Mode until ctx
+// is cancelled.
+func (c *Client) runUpdates(ctx context.Context) {
+	switch c.opts.Mode {
+	case ModeStreaming:
+		c.runStream(ctx, 0)
+	case ModePolling:
+		c.runPoll(ctx, 0)
+	default:
+		for ctx.Err() == nil {
+			c.runStream(ctx, c.opts.StreamFailures)
+			if ctx.Err() != nil {
+				return
+			}
+			c.polling.Store(true)
+			c.runPoll(ctx, c.opts.StreamRetry)
+			c.polling.Store(false)
+		}
+	}
+}
+
+// runPoll refreshes the snapshot every PollInterval plus up to PollJitter of
+// random delay, so a fleet of clients does not poll in lockstep. Refresh
+// sends If-None-Match, so unchanged snapshots cost a 304. It returns when ctx
+// is cancelled or, if d > 0, once d has elapsed.
+func (c *Client) runPoll(ctx context.Context, d time.Duration) {
+	var deadline <-chan time.Time
+	if d > 0 {
+		deadline = time.After(d)
+	}
+	for {
+		wait := c.opts.PollInterval + time.Duration(rand.Int64N(int64(c.opts.PollJitter)+1))
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			return
+		case <-time.After(wait):
+		}
+		if _, err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+			c.reportError(err)
+		}
+	}
+}
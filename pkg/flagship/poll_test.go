@@ -0,0 +1,97 @@
+package flagship
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestClient_AutoModeFallsBackToPolling(t *testing.T) {
+	_, srv, st := newTestServer(t, store.UpsertParams{Key: "live", Enabled: false, Rollout: 100, Env: "prod"})
+	router := srv.Router()
+
+	// Proxy that blocks SSE while blocked is set and counts conditional polls.
+	var blocked atomic.Bool
+	var notModified atomic.Int32
+	blocked.Store(true)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/flags/stream":
+			if blocked.Load() {
+				http.Error(w, "blocked", http.StatusForbidden)
+				return
+			}
+		case "/v1/flags/snapshot":
+			if r.Header.Get("If-None-Match") != "" {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, r)
+				if rec.Code == http.StatusNotModified {
+					notModified.Add(1)
+				}
+				for k, v := range rec.Header() {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(rec.Code)
+				_, _ = w.Write(rec.Body.Bytes())
+				return
+			}
+		}
+		router.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	c := New(Options{
+		BaseURL:        proxy.URL,
+		ReconnectMin:   time.Millisecond,
+		StreamFailures: 2,
+		StreamRetry:    200 * time.Millisecond,
+		PollInterval:   10 * time.Millisecond,
+		Exposures:      ExposureOptions{Disabled: true},
+	})
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer c.Close()
+
+	waitFor(t, "switch to polling", c.Polling)
+	waitFor(t, "conditional polls", func() bool { return notModified.Load() > 0 })
+
+	_ = st.UpsertFlag(context.Background(), store.UpsertParams{Key: "live", Enabled: true, Rollout: 100, Env: "prod"})
+	_ = srv.RebuildSnapshot(context.Background(), "prod")
+	waitFor(t, "polled update", func() bool { return c.IsEnabled("live", User{ID: "u1"}) })
+
+	blocked.Store(false)
+	waitFor(t, "switch back to streaming", func() bool { return !c.Polling() })
+}
+
+func TestClient_PollingMode(t *testing.T) {
+	ts, srv, st := newTestServer(t, store.UpsertParams{Key: "live", Enabled: false, Rollout: 100, Env: "prod"})
+	c := New(Options{BaseURL: ts.URL, Mode: ModePolling, PollInterval: 10 * time.Millisecond, Exposures: ExposureOptions{Disabled: true}})
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer c.Close()
+	if !c.Polling() {
+		t.Error("ModePolling client should report polling")
+	}
+
+	_ = st.UpsertFlag(context.Background(), store.UpsertParams{Key: "live", Enabled: true, Rollout: 100, Env: "prod"})
+	_ = srv.RebuildSnapshot(context.Background(), "prod")
+	waitFor(t, "polled update", func() bool { return c.IsEnabled("live", User{ID: "u1"}) })
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,306 @@
+// Package sdktest provides test helpers for code that uses the flagship Go SDK.
+//
+// NewClient returns a client connected to an in-process mock server whose
+// flags can be set per test:
+//
+//	func TestCheckout(t *testing.T) {
+//	    client := sdktest.NewClient(t)
+//	    client.Set("new_checkout", true)
+//
+//	    svc := checkout.New(client.Client)
+//	    ...
+//	}
+//
+// Set updates the mock server and refreshes the client before returning, so
+// the new value is visible immediately and tests stay deterministic. The mock
+// Server can also be used on its own to test other SDKs or HTTP integrations.
+package sdktest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/internal/snapshot"
+	"github.com/TimurManjosov/goflagship/pkg/flagship"
+)
+
+// Flag is a complete flag definition served by the mock server.
+type Flag struct {
+	Key        string
+	Enabled    bool
+	Rollout    int32   // Percentage 0-100
+	Expression *string // Optional JSON Logic targeting expression
+	Config     map[string]any
+	Variants   []Variant
+}
+
+// Variant is an A/B test variant of a Flag.
+type Variant struct {
+	Name   string
+	Weight int // Percentage weight; weights must sum to 100
+	Config map[string]any
+}
+
+func (f Flag) view() snapshot.FlagView {
+	v := snapshot.FlagView{
+		Key:        f.Key,
+		Enabled:    f.Enabled,
+		Rollout:    f.Rollout,
+		Expression: f.Expression,
+		Config:     f.Config,
+		Env:        "test",
+	}
+	for _, variant := range f.Variants {
+		v.Variants = append(v.Variants, snapshot.Variant{Name: variant.Name, Weight: variant.Weight, Config: variant.Config})
+	}
+	return v
+}
+
+// Server is an in-process mock of the flagship API. It serves the snapshot,
+// SSE stream and exposure ingestion endpoints used by the SDK.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	flags       map[string]snapshot.FlagView
+	version     int
+	subscribers map[chan string]struct{}
+	exposures   []flagship.Exposure
+}
+
+// NewServer starts a mock server that is closed when the test finishes.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		flags:       make(map[string]snapshot.FlagView),
+		subscribers: make(map[chan string]struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/flags/snapshot", s.handleSnapshot)
+	mux.HandleFunc("GET /v1/flags/stream", s.handleStream)
+	mux.HandleFunc("POST /v1/exposures", s.handleExposures)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close disconnects open streams and shuts down the server.
+func (s *Server) Close() {
+	s.mu.Lock()
+	for ch := range s.subscribers {
+		close(ch)
+		delete(s.subscribers, ch)
+	}
+	s.mu.Unlock()
+	s.Server.Close()
+}
+
+// Set sets a flag's value. true and false enable and disable the flag; a
+// map[string]any becomes the flag config; any other value is served as the
+// config {"value": v}.
+func (s *Server) Set(key string, value any) {
+	flag := Flag{Key: key, Enabled: true, Rollout: 100}
+	switch v := value.(type) {
+	case bool:
+		flag.Enabled = v
+	case map[string]any:
+		flag.Config = v
+	default:
+		flag.Config = map[string]any{"value": v}
+	}
+	s.SetFlag(flag)
+}
+
+// SetVariant enables a flag and assigns every user to variant, optionally
+// with a variant config.
+func (s *Server) SetVariant(key, variant string, config map[string]any) {
+	s.SetFlag(Flag{
+		Key:      key,
+		Enabled:  true,
+		Rollout:  100,
+		Variants: []Variant{{Name: variant, Weight: 100, Config: config}},
+	})
+}
+
+// SetFlag stores a complete flag definition and notifies connected streams.
+func (s *Server) SetFlag(flag Flag) {
+	s.mu.Lock()
+	s.flags[flag.Key] = flag.view()
+	s.publishLocked()
+	s.mu.Unlock()
+}
+
+// Delete removes a flag.
+func (s *Server) Delete(key string) {
+	s.mu.Lock()
+	delete(s.flags, key)
+	s.publishLocked()
+	s.mu.Unlock()
+}
+
+// Exposures returns the exposure events received so far.
+func (s *Server) Exposures() []flagship.Exposure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]flagship.Exposure(nil), s.exposures...)
+}
+
+func (s *Server) publishLocked() {
+	s.version++
+	etag := s.etagLocked()
+	for ch := range s.subscribers {
+		select {
+		case ch <- etag:
+		default: // subscriber is busy; it picks up the latest ETag on its next refresh
+		}
+	}
+}
+
+func (s *Server) etagLocked() string {
+	return fmt.Sprintf(`W/"sdktest-%d"`, s.version)
+}
+
+func (s *Server) snapshot() *snapshot.Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flags := make(map[string]snapshot.FlagView, len(s.flags))
+	for k, v := range s.flags {
+		flags[k] = v
+	}
+	return &snapshot.Snapshot{SchemaVersion: snapshot.SchemaVersion, ETag: s.etagLocked(), Flags: flags, UpdatedAt: time.Now().UTC()}
+}
+
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	snap := s.snapshot()
+	w.Header().Set("ETag", snap.ETag)
+	if r.Header.Get("If-None-Match") == snap.ETag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(snap)
+}
+
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan string, 1)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	etag := s.etagLocked()
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	writeEvent(w, "init", etag)
+	flusher.Flush()
+	for {
+		select {
+		case etag, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeEvent(w, "update", etag)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event, etag string) {
+	data, _ := json.Marshal(map[string]string{"etag": etag})
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+func (s *Server) handleExposures(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Exposures []flagship.Exposure `json:"exposures"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.exposures = append(s.exposures, body.Exposures...)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// TestClient is a flagship client wired to a mock Server.
+type TestClient struct {
+	*flagship.Client
+	Server *Server
+
+	t testing.TB
+}
+
+// NewClient starts a mock server and a client connected to it; both are
+// closed when the test finishes. Exposure reporting is disabled unless opts
+// are given. opts.BaseURL is always replaced with the mock server's URL.
+func NewClient(t testing.TB, opts ...flagship.Options) *TestClient {
+	t.Helper()
+	srv := NewServer(t)
+
+	o := flagship.Options{Exposures: flagship.ExposureOptions{Disabled: true}}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	o.BaseURL = srv.URL
+	client := flagship.New(o)
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("sdktest: start client: %v", err)
+	}
+	return &TestClient{Client: client, Server: srv, t: t}
+}
+
+// Set sets a flag value (see Server.Set) and refreshes the client.
+func (c *TestClient) Set(key string, value any) {
+	c.t.Helper()
+	c.Server.Set(key, value)
+	c.sync()
+}
+
+// SetVariant assigns every user to variant (see Server.SetVariant) and
+// refreshes the client.
+func (c *TestClient) SetVariant(key, variant string, config map[string]any) {
+	c.t.Helper()
+	c.Server.SetVariant(key, variant, config)
+	c.sync()
+}
+
+// SetFlag stores a complete flag definition and refreshes the client.
+func (c *TestClient) SetFlag(flag Flag) {
+	c.t.Helper()
+	c.Server.SetFlag(flag)
+	c.sync()
+}
+
+// Delete removes a flag and refreshes the client.
+func (c *TestClient) Delete(key string) {
+	c.t.Helper()
+	c.Server.Delete(key)
+	c.sync()
+}
+
+// sync refreshes the client synchronously instead of waiting for the stream.
+func (c *TestClient) sync() {
+	c.t.Helper()
+	if _, err := c.Refresh(context.Background()); err != nil {
+		c.t.Fatalf("sdktest: refresh client: %v", err)
+	}
+}
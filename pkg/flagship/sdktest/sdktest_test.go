@@ -0,0 +1,80 @@
+package sdktest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TimurManjosov/goflagship/pkg/flagship"
+)
+
+func TestTestClient_Set(t *testing.T) {
+	client := NewClient(t)
+	user := flagship.User{ID: "u1"}
+
+	if client.IsEnabled("new_checkout", user) {
+		t.Fatal("unset flags must be disabled")
+	}
+
+	client.Set("new_checkout", true)
+	if !client.IsEnabled("new_checkout", user) {
+		t.Error("flag should be enabled after Set(true)")
+	}
+
+	client.Set("new_checkout", false)
+	if client.IsEnabled("new_checkout", user) {
+		t.Error("flag should be disabled after Set(false)")
+	}
+
+	client.Set("banner", "hello")
+	if got := client.Evaluate("banner", user).Config["value"]; got != "hello" {
+		t.Errorf("expected value config, got %v", got)
+	}
+
+	client.Delete("banner")
+	if client.IsEnabled("banner", user) {
+		t.Error("deleted flag must be disabled")
+	}
+}
+
+func TestTestClient_SetVariant(t *testing.T) {
+	client := NewClient(t)
+	client.SetVariant("layout", "grid", map[string]any{"columns": 3})
+
+	r := client.Evaluate("layout", flagship.User{ID: "anyone"})
+	if r.Variant != "grid" || r.Config["columns"] != float64(3) {
+		t.Errorf("unexpected result: %+v", r)
+	}
+}
+
+func TestTestClient_ExposuresReachServer(t *testing.T) {
+	client := NewClient(t, flagship.Options{})
+	client.Set("f", true)
+	client.IsEnabled("f", flagship.User{ID: "u1"})
+
+	if err := client.FlushExposures(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if got := client.Server.Exposures(); len(got) != 1 || got[0].Flag != "f" {
+		t.Errorf("unexpected exposures: %+v", got)
+	}
+}
+
+func TestServer_StreamNotifiesClients(t *testing.T) {
+	srv := NewServer(t)
+	c := flagship.New(flagship.Options{BaseURL: srv.URL, Exposures: flagship.ExposureOptions{Disabled: true}})
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer c.Close()
+
+	// Keep publishing until the stream is connected and the client catches up.
+	deadline := time.Now().Add(3 * time.Second)
+	for !c.IsEnabled("live", flagship.User{ID: "u1"}) {
+		if time.Now().After(deadline) {
+			t.Fatal("client did not receive stream update")
+		}
+		srv.Set("live", true)
+		time.Sleep(50 * time.Millisecond)
+	}
+}
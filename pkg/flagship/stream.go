@@ -0,0 +1,119 @@
+package flagship
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type streamEvent struct {
+	ETag string `json:"etag"`
+}
+
+// runStream keeps an SSE connection open and refreshes the snapshot whenever
+// the server announces a new ETag. It reconnects with jittered exponential
+// backoff until ctx is cancelled or, if maxFailures > 0, until that many
+// consecutive connection attempts have failed.
+func (c *Client) runStream(ctx context.Context, maxFailures int) {
+	delay := c.opts.ReconnectMin
+	failures := 0
+	for {
+		connected, err := c.streamOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		c.reportError(err)
+		if connected {
+			delay = c.opts.ReconnectMin
+			failures = 0
+		} else {
+			failures++
+			if maxFailures > 0 && failures >= maxFailures {
+				return
+			}
+		}
+
+		wait := delay + time.Duration(rand.Int64N(int64(delay/2)+1))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, c.opts.ReconnectMax)
+	}
+}
+
+// streamOnce consumes one SSE connection. It reports whether the connection
+// was established and delivered at least one event, so the caller can reset
+// its backoff; proxies that accept and immediately drop SSE requests still
+// count as failures.
+func (c *Client) streamOnce(ctx context.Context) (bool, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/v1/flags/stream", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.stream.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("flagship: stream: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("flagship: stream: status %d", resp.StatusCode)
+	}
+	defer c.stopStreaming()
+
+	scanner := bufio.NewScanner(resp.Body)
+	var event, data string
+	received := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event == "init" || event == "update" {
+				received = true
+				c.handleStreamEvent(ctx, data)
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return received, fmt.Errorf("flagship: stream: %w", err)
+	}
+	return received, fmt.Errorf("flagship: stream closed by server")
+}
+
+func (c *Client) handleStreamEvent(ctx context.Context, data string) {
+	var ev streamEvent
+	if err := json.Unmarshal([]byte(data), &ev); err != nil || ev.ETag == "" {
+		return
+	}
+	if ev.ETag == c.ETag() {
+		c.streaming.Store(true)
+		return
+	}
+	if _, err := c.Refresh(ctx); err != nil {
+		c.reportError(err)
+		c.stopStreaming()
+		return
+	}
+	c.streaming.Store(true)
+}
+
+// stopStreaming marks the snapshot as no longer kept current by the stream.
+// It was current until now, which is where SnapshotAge starts counting.
+func (c *Client) stopStreaming() {
+	if c.streaming.Swap(false) {
+		c.markSynced()
+	}
+}
@@ -0,0 +1,157 @@
+package flagship
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ValueError describes why a typed accessor fell back to its default.
+// Err is or wraps ErrNotReady, ErrFlagNotFound or ErrTypeMismatch.
+type ValueError struct {
+	Key string
+	Err error
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("%v (flag %q)", e.Err, e.Key)
+}
+
+func (e *ValueError) Unwrap() error { return e.Err }
+
+// value evaluates a flag and returns the value it serves. A config of the form
+// {"value": x} serves x, any other non-empty config is served as a whole, and
+// a flag without config serves its enabled state (matching OFREP). ok is false
+// when the caller's default should be used: the flag is disabled, unknown, or
+// no snapshot is loaded (err is set for the last two).
+func (c *Client) value(key string, user User) (v any, ok bool, err error) {
+	r, err := c.evaluate(key, user)
+	if err != nil {
+		return nil, false, &ValueError{Key: key, Err: err}
+	}
+	if !r.Enabled {
+		return nil, false, nil
+	}
+	if len(r.Config) == 0 {
+		return true, true, nil
+	}
+	if inner, found := r.Config["value"]; found && len(r.Config) == 1 {
+		return inner, true, nil
+	}
+	return r.Config, true, nil
+}
+
+func (c *Client) mismatch(key string, want string, got any) {
+	c.reportError(&ValueError{Key: key, Err: fmt.Errorf("%w: want %s, got %T", ErrTypeMismatch, want, got)})
+}
+
+// BoolValue returns the flag's boolean value for user, or def if the flag is
+// disabled, unknown, or does not serve a boolean. Errors are passed to
+// Options.OnError.
+func (c *Client) BoolValue(key string, user User, def bool) bool {
+	v, ok, err := c.value(key, user)
+	if !ok {
+		c.reportError(err)
+		return def
+	}
+	b, isBool := v.(bool)
+	if !isBool {
+		c.mismatch(key, "bool", v)
+		return def
+	}
+	return b
+}
+
+// StringValue returns the flag's string value for user, or def if the flag
+// is disabled, unknown, or does not serve a string.
+func (c *Client) StringValue(key string, user User, def string) string {
+	v, ok, err := c.value(key, user)
+	if !ok {
+		c.reportError(err)
+		return def
+	}
+	s, isString := v.(string)
+	if !isString {
+		c.mismatch(key, "string", v)
+		return def
+	}
+	return s
+}
+
+// IntValue returns the flag's integer value for user, or def if the flag is
+// disabled, unknown, or does not serve a whole number.
+func (c *Client) IntValue(key string, user User, def int) int {
+	v, ok, err := c.value(key, user)
+	if !ok {
+		c.reportError(err)
+		return def
+	}
+	f, isNumber := number(v)
+	if !isNumber || f != math.Trunc(f) || f > math.MaxInt || f < math.MinInt {
+		c.mismatch(key, "int", v)
+		return def
+	}
+	return int(f)
+}
+
+// Float64Value returns the flag's numeric value for user, or def if the flag
+// is disabled, unknown, or does not serve a number.
+func (c *Client) Float64Value(key string, user User, def float64) float64 {
+	v, ok, err := c.value(key, user)
+	if !ok {
+		c.reportError(err)
+		return def
+	}
+	f, isNumber := number(v)
+	if !isNumber {
+		c.mismatch(key, "float64", v)
+		return def
+	}
+	return f
+}
+
+// JSONValue decodes the flag's value for user into target, which must be a
+// pointer. If the flag is disabled target is left untouched, so callers can
+// pre-populate it with defaults. Unlike the other accessors, errors are
+// returned rather than passed to Options.OnError, and target is left untouched
+// on error too.
+func (c *Client) JSONValue(key string, user User, target any) error {
+	v, ok, err := c.value(key, user)
+	if !ok {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return &ValueError{Key: key, Err: err}
+	}
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return &ValueError{Key: key, Err: fmt.Errorf("flagship: JSONValue target must be a non-nil pointer, got %T", target)}
+	}
+	// Decode into a copy so a failed decode leaves the caller's defaults intact;
+	// fields absent from the flag value keep their pre-populated values.
+	scratch := reflect.New(ptr.Elem().Type())
+	scratch.Elem().Set(ptr.Elem())
+	if err := json.Unmarshal(data, scratch.Interface()); err != nil {
+		return &ValueError{Key: key, Err: fmt.Errorf("%w: %v", ErrTypeMismatch, err)}
+	}
+	ptr.Elem().Set(scratch.Elem())
+	return nil
+}
+
+// number converts the numeric types a snapshot can hold to float64.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,99 @@
+package flagship
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TimurManjosov/goflagship/internal/store"
+)
+
+func TestClient_TypedValues(t *testing.T) {
+	ts, _, _ := newTestServer(t,
+		store.UpsertParams{Key: "bool_flag", Enabled: true, Rollout: 100, Env: "prod"},
+		store.UpsertParams{Key: "off_flag", Enabled: false, Rollout: 100, Env: "prod", Config: map[string]any{"value": "x"}},
+		store.UpsertParams{Key: "title", Enabled: true, Rollout: 100, Env: "prod", Config: map[string]any{"value": "Hello"}},
+		store.UpsertParams{Key: "limit", Enabled: true, Rollout: 100, Env: "prod", Config: map[string]any{"value": 25}},
+		store.UpsertParams{Key: "ratio", Enabled: true, Rollout: 100, Env: "prod", Config: map[string]any{"value": 0.25}},
+		store.UpsertParams{Key: "theme", Enabled: true, Rollout: 100, Env: "prod", Config: map[string]any{"color": "blue", "size": 2}},
+	)
+	var errs []error
+	c := New(Options{BaseURL: ts.URL, Exposures: ExposureOptions{Disabled: true}, OnError: func(err error) { errs = append(errs, err) }})
+	if _, err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	u := User{ID: "u1"}
+
+	if !c.BoolValue("bool_flag", u, false) {
+		t.Error("flag without config should serve its enabled state")
+	}
+	if got := c.StringValue("title", u, "default"); got != "Hello" {
+		t.Errorf("StringValue = %q", got)
+	}
+	if got := c.IntValue("limit", u, 10); got != 25 {
+		t.Errorf("IntValue = %d", got)
+	}
+	if got := c.Float64Value("ratio", u, 1); got != 0.25 {
+		t.Errorf("Float64Value = %v", got)
+	}
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// Disabled flags serve the default without reporting an error.
+	if got := c.StringValue("off_flag", u, "default"); got != "default" {
+		t.Errorf("disabled flag: got %q", got)
+	}
+	if len(errs) != 0 {
+		t.Errorf("disabled flag should not report errors: %v", errs)
+	}
+
+	if got := c.IntValue("ratio", u, 7); got != 7 {
+		t.Errorf("non-integer should fall back to default, got %d", got)
+	}
+	if got := c.StringValue("missing", u, "d"); got != "d" {
+		t.Errorf("unknown flag should fall back to default, got %q", got)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], ErrTypeMismatch) || !errors.Is(errs[1], ErrFlagNotFound) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestClient_JSONValue(t *testing.T) {
+	ts, _, _ := newTestServer(t,
+		store.UpsertParams{Key: "theme", Enabled: true, Rollout: 100, Env: "prod", Config: map[string]any{"color": "blue"}},
+		store.UpsertParams{Key: "title", Enabled: true, Rollout: 100, Env: "prod", Config: map[string]any{"value": "Hello"}},
+	)
+	c := New(Options{BaseURL: ts.URL, Exposures: ExposureOptions{Disabled: true}})
+	u := User{ID: "u1"}
+
+	type theme struct {
+		Color string `json:"color"`
+		Size  int    `json:"size"`
+	}
+	target := theme{Color: "red", Size: 12}
+	if err := c.JSONValue("theme", u, &target); !errors.Is(err, ErrNotReady) {
+		t.Fatalf("expected ErrNotReady before the first snapshot, got %v", err)
+	}
+	if _, err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	if err := c.JSONValue("theme", u, &target); err != nil {
+		t.Fatalf("JSONValue: %v", err)
+	}
+	if target.Color != "blue" || target.Size != 12 {
+		t.Errorf("expected decoded color and default size, got %+v", target)
+	}
+
+	before := target
+	if err := c.JSONValue("title", u, &target); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected ErrTypeMismatch, got %v", err)
+	}
+	if target != before {
+		t.Errorf("target must be untouched on error, got %+v", target)
+	}
+	if err := c.JSONValue("theme", u, target); err == nil {
+		t.Error("expected error for non-pointer target")
+	}
+}
@@ -17,11 +17,16 @@ type FlagView = {
   expression?: string | null;
   config?: Record<string, unknown>;
   variants?: Variant[];
+  tags?: string[];
   env: string;
   updatedAt: string;
 };
 
+/** Snapshot schema version this client understands (see the server's snapshot.SchemaVersion) */
+const SNAPSHOT_SCHEMA_VERSION = 1;
+
 type Snapshot = {
+  schemaVersion?: number;
   etag: string;
   flags: Record<string, FlagView>;
   updatedAt: string;
@@ -328,7 +333,9 @@ export class FlagshipClient {
 
   private async fetchSnapshot(skipCache = false): Promise<boolean> {
     const url = this.snapshotUrl();
-    const headers: Record<string, string> = {};
+    const headers: Record<string, string> = {
+      Accept: `application/vnd.flagship.v${SNAPSHOT_SCHEMA_VERSION}+json`,
+    };
 
     // Only send If-None-Match if we're not forcing a refresh
     if (!skipCache && this.cache?.etag) {
@@ -349,6 +356,9 @@ export class FlagshipClient {
     }
 
     const next = (await res.json()) as Snapshot;
+    if ((next.schemaVersion ?? 0) > SNAPSHOT_SCHEMA_VERSION) {
+      throw new Error(`unsupported snapshot schema version ${next.schemaVersion}`);
+    }
     const changed = !this.cache || this.cache.etag !== next.etag;
     this.cache = next;
     return changed;